@@ -0,0 +1,114 @@
+package goeditor
+
+import (
+	"fmt"
+
+	"github.com/ionut-t/goeditor/core"
+)
+
+// announcementBuffer is how many announcements wait for the host to read
+// them before newer ones are dropped.
+const announcementBuffer = 64
+
+// accessibility is the state of the accessibility mode. The channel is made
+// by New, so copies of the model announce on the same one.
+type accessibility struct {
+	enabled       bool
+	announcements chan string
+}
+
+// editorSnapshot is what announcements are about, taken before a message is
+// handled to tell what it changed.
+type editorSnapshot struct {
+	mode     core.Mode
+	position core.Position
+	lines    int
+	revision uint64
+	message  string
+	err      error
+}
+
+// SetAccessibilityMode sets whether the editor is drawn plainly and tells
+// what happens in words, for screen readers and braille displays. Tildes
+// past the end of the content, gutter signs and concealing aren't drawn, and
+// the cursor doesn't blink. Each change of mode, line count and cursor
+// position, and each message and error, is sent to Announcements as short
+// text such as "INSERT mode", "deleted 3 lines" or "line 12, column 4".
+func (m *Model) SetAccessibilityMode(enabled bool) {
+	m.accessibility.enabled = enabled
+	m.dirty |= needsRender
+	if enabled {
+		m.cursorVisible = m.isFocused
+	}
+}
+
+// AccessibilityMode reports whether the accessibility mode is on.
+func (m *Model) AccessibilityMode() bool {
+	return m.accessibility.enabled
+}
+
+// Announcements returns the channel the accessibility mode sends its
+// announcements on, for the host to pass on to a screen reader. Sending
+// doesn't wait: announcements the host hasn't read when the channel is full
+// are dropped.
+func (m *Model) Announcements() <-chan string {
+	return m.accessibility.announcements
+}
+
+// announce sends text to Announcements, dropping it if the channel is full.
+func (m *Model) announce(text string) {
+	select {
+	case m.accessibility.announcements <- text:
+	default:
+	}
+}
+
+// snapshot returns what announcements are about as they are now.
+func (m *Model) snapshot() editorSnapshot {
+	buffer := m.editor.GetBuffer()
+	return editorSnapshot{
+		mode:     m.editor.GetState().Mode,
+		position: buffer.GetCursor().Position,
+		lines:    buffer.LineCount(),
+		revision: buffer.Revision(),
+		message:  m.message,
+		err:      m.err,
+	}
+}
+
+// announceChanges announces what changed since before. The cursor moving
+// as text is typed or deleted on a line isn't announced, as the text is
+// what changed.
+func (m *Model) announceChanges(before editorSnapshot) {
+	after := m.snapshot()
+
+	if after.mode != before.mode {
+		m.announce(fmt.Sprintf(m.labels.ModeAnnouncement, m.labels.mode(after.mode)))
+	}
+
+	switch lines := after.lines - before.lines; {
+	case lines == 1:
+		m.announce(m.labels.LineAdded)
+	case lines > 1:
+		m.announce(fmt.Sprintf(m.labels.LinesAdded, lines))
+	case lines == -1:
+		m.announce(m.labels.LineDeleted)
+	case lines < -1:
+		m.announce(fmt.Sprintf(m.labels.LinesDeleted, -lines))
+	}
+
+	if after.position != before.position && (after.revision == before.revision || after.lines != before.lines) {
+		m.announce(fmt.Sprintf(m.labels.PositionAnnouncement, after.position.Row+1, after.position.Col+1))
+	}
+
+	if after.err != nil && after.err != before.err {
+		m.announce(after.err.Error())
+	} else if after.message != "" && after.message != before.message {
+		m.announce(after.message)
+	}
+}
+
+// cursorBlinks reports whether the drawn cursor blinks.
+func (m *Model) cursorBlinks() bool {
+	return m.cursorMode == CursorBlink && !m.accessibility.enabled
+}
@@ -0,0 +1,87 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// drainAnnouncements returns the announcements waiting on m's channel.
+func drainAnnouncements(m Model) []string {
+	var announcements []string
+	for {
+		select {
+		case text := <-m.Announcements():
+			announcements = append(announcements, text)
+		default:
+			return announcements
+		}
+	}
+}
+
+// TestAccessibilityMode tests the plain drawing and announcements of the
+// accessibility mode.
+func TestAccessibilityMode(t *testing.T) {
+	const content = "one\ntwo\nthree\nfour"
+
+	t.Run("modes are announced", func(t *testing.T) {
+		m := typeText(newTestModel(content, withAccessibility()), "i")
+		assert.Equal(t, []string{"INSERT mode"}, drainAnnouncements(m))
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+		m = typeText(m, "V")
+		assert.Equal(t, []string{"NORMAL mode", "VISUAL LINE mode"}, drainAnnouncements(m))
+	})
+
+	t.Run("the cursor position is announced as it moves", func(t *testing.T) {
+		m := typeText(newTestModel(content, withAccessibility()), "jll")
+		assert.Equal(t, []string{"line 2, column 1", "line 2, column 2", "line 2, column 3"}, drainAnnouncements(m))
+	})
+
+	t.Run("deleted and added lines are announced", func(t *testing.T) {
+		m := typeText(newTestModel(content, withAccessibility()), "3dd")
+		assert.Equal(t, []string{"deleted 3 lines"}, drainAnnouncements(m))
+
+		m = typeText(m, "u")
+		assert.Equal(t, "added 3 lines", drainAnnouncements(m)[0])
+
+		m = typeText(m, "Gdd")
+		assert.Equal(t, []string{"line 4, column 1", "deleted 1 line", "line 3, column 1"}, drainAnnouncements(m))
+	})
+
+	t.Run("typing isn't announced as the cursor moving", func(t *testing.T) {
+		m := typeText(newTestModel(content, withAccessibility()), "A!!")
+		assert.Equal(t, []string{"INSERT mode", "line 1, column 4"}, drainAnnouncements(m))
+	})
+
+	t.Run("nothing is announced when it's off", func(t *testing.T) {
+		m := newTestModel(content, withAccessibility())
+		m.SetAccessibilityMode(false)
+		m = typeText(m, "jdd")
+		assert.Empty(t, drainAnnouncements(m))
+	})
+
+	t.Run("decorations aren't drawn", func(t *testing.T) {
+		m := newTestModel(content, withAccessibility())
+		m.ShowTildeIndicator(true)
+		m.SetGutterSigns(map[int]GutterSign{0: {Text: "●", Style: lipgloss.NewStyle()}})
+		m.SetCursorMode(CursorBlink)
+		m.renderVisibleSlice()
+
+		view := sgr.ReplaceAllString(m.viewport.View(), "")
+		assert.NotContains(t, view, "~")
+		assert.NotContains(t, view, "●")
+		assert.Nil(t, m.CursorBlink())
+		m.SetTerminalCursor(true)
+		assert.False(t, m.Cursor().Blink)
+
+		m.SetTerminalCursor(false)
+		m.SetAccessibilityMode(false)
+		m.renderVisibleSlice()
+		view = sgr.ReplaceAllString(m.viewport.View(), "")
+		assert.Contains(t, view, "~")
+		assert.Contains(t, view, "●")
+	})
+}
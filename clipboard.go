@@ -0,0 +1,121 @@
+package goeditor
+
+import (
+	"sync"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/atotto/clipboard"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// SystemClipboard copies and pastes through the clipboard of the operating
+// system. It fails where there is none, e.g. on headless machines and over SSH.
+type SystemClipboard struct{}
+
+func (SystemClipboard) Write(text string) error {
+	return clipboard.WriteAll(text)
+}
+
+func (SystemClipboard) Read() (string, error) {
+	return clipboard.ReadAll()
+}
+
+// MemoryClipboard keeps the copied text in memory, private to the program.
+type MemoryClipboard struct {
+	mu      sync.Mutex
+	content string
+}
+
+func (c *MemoryClipboard) Write(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.content = text
+	return nil
+}
+
+func (c *MemoryClipboard) Read() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.content, nil
+}
+
+// OSC52Clipboard copies through the terminal with the OSC 52 escape sequence,
+// which reaches the clipboard of the machine the terminal runs on, even over
+// SSH. Few terminals let programs read the clipboard, so pastes get the text
+// copied last, or the clipboard content a tea.ReadClipboard command returned.
+//
+// The Model sends the copied text to the terminal, so it only works with a
+// Model set up with WithClipboard.
+type OSC52Clipboard struct {
+	MemoryClipboard
+	pending bool // Whether the text copied last is yet to be sent
+}
+
+// NewOSC52Clipboard returns a clipboard that copies through the terminal.
+func NewOSC52Clipboard() *OSC52Clipboard {
+	return &OSC52Clipboard{}
+}
+
+func (c *OSC52Clipboard) Write(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.content = text
+	c.pending = true
+	return nil
+}
+
+// flush returns the text copied since the last call.
+func (c *OSC52Clipboard) flush() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending := c.pending
+	c.pending = false
+	return c.content, pending
+}
+
+// fallbackClipboard uses a clipboard while it works and keeps the text in
+// memory for when it does not, so that yanking and pasting never fail.
+type fallbackClipboard struct {
+	clipboard core.Clipboard
+	memory    MemoryClipboard
+}
+
+func (c *fallbackClipboard) Write(text string) error {
+	_ = c.memory.Write(text)
+	_ = c.clipboard.Write(text)
+	return nil
+}
+
+func (c *fallbackClipboard) Read() (string, error) {
+	if text, err := c.clipboard.Read(); err == nil {
+		return text, nil
+	}
+	return c.memory.Read()
+}
+
+// WithClipboard sets the clipboard that yanks and pastes use. By default the
+// editor uses the system clipboard, falling back to memory where there is none.
+func (m *Model) WithClipboard(clipboard core.Clipboard) {
+	m.clipboard = clipboard
+	m.editor.Registers().SetClipboard(clipboard)
+}
+
+// clipboardCmd sends the text copied to an OSC52Clipboard to the terminal.
+func (m *Model) clipboardCmd() tea.Cmd {
+	c, ok := m.clipboard.(*OSC52Clipboard)
+	if !ok {
+		return nil
+	}
+	if text, ok := c.flush(); ok {
+		return tea.SetClipboard(text)
+	}
+	return nil
+}
+
+// handleClipboardMsg takes the clipboard content the terminal reported as the
+// content of an OSC52Clipboard.
+func (m *Model) handleClipboardMsg(msg tea.ClipboardMsg) {
+	if c, ok := m.clipboard.(*OSC52Clipboard); ok && msg.Selection == 'c' {
+		_ = c.MemoryClipboard.Write(msg.Content)
+	}
+}
@@ -0,0 +1,70 @@
+package goeditor
+
+import (
+	"errors"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenClipboard fails like the system clipboard of a headless machine.
+type brokenClipboard struct{}
+
+func (brokenClipboard) Write(string) error    { return errors.New("no clipboard") }
+func (brokenClipboard) Read() (string, error) { return "", errors.New("no clipboard") }
+
+// TestClipboard tests yanking and pasting with the clipboard providers.
+func TestClipboard(t *testing.T) {
+	press := func(m Model, text string) Model {
+		for _, r := range text {
+			m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+		return m
+	}
+
+	t.Run("falls back to memory when the clipboard fails", func(t *testing.T) {
+		m := New(80, 10)
+		m.Focus()
+		m.WithClipboard(&fallbackClipboard{clipboard: brokenClipboard{}})
+		m.SetContent("one")
+		m = press(m, "yyp")
+		assert.Equal(t, "one\none", m.GetCurrentContent())
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		m := New(80, 10)
+		m.Focus()
+		clipboard := &MemoryClipboard{}
+		m.WithClipboard(clipboard)
+		m.SetContent("one two")
+		m = press(m, "yw")
+		text, err := clipboard.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, "one ", text)
+	})
+
+	t.Run("OSC 52 sends copies to the terminal", func(t *testing.T) {
+		m := New(80, 10)
+		m.Focus()
+		clipboard := NewOSC52Clipboard()
+		m.WithClipboard(clipboard)
+		m.SetContent("one two")
+		m = press(m, "yw")
+		text, err := clipboard.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, "one ", text)
+		// Update has sent it
+		assert.Nil(t, m.clipboardCmd())
+
+		clipboard.Write("two")
+		cmd := m.clipboardCmd()
+		assert.NotNil(t, cmd)
+		assert.Equal(t, tea.SetClipboard("two")(), cmd())
+		assert.Nil(t, m.clipboardCmd())
+
+		m, _ = m.Update(tea.ClipboardMsg{Content: "copied elsewhere", Selection: 'c'})
+		m = press(m, "P")
+		assert.Equal(t, "copied elsewhereone two", m.GetCurrentContent())
+	})
+}
@@ -0,0 +1,139 @@
+package goeditor
+
+import (
+	"regexp"
+
+	"charm.land/lipgloss/v2"
+)
+
+// ConcealRule hides markup on the lines the cursor isn't on, like Vim's
+// conceal. The text of the first group of Pattern stays visible, drawn with
+// Style, and the rest of each match is concealed: `\*\*(.+?)\*\*` draws
+// **bold** as bold without the asterisks. A pattern without groups conceals
+// the whole match, such as -> drawn as the Replacement →.
+type ConcealRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string         // Drawn in place of each concealed part, depending on the ConcealLevel
+	Style       lipgloss.Style // Style of the visible text, or of the Replacement
+}
+
+// ConcealLevel sets how concealed text is drawn, like Vim's 'conceallevel'.
+type ConcealLevel int
+
+const (
+	ConcealOff     ConcealLevel = iota // Text is drawn as it is
+	ConcealReplace                     // Each concealed part is drawn as its Replacement, or a space
+	ConcealHide                        // Concealed parts are hidden unless they have a Replacement
+	ConcealAll                         // Concealed parts are hidden
+)
+
+// MarkdownConcealRules returns rules that hide the markup of bold, italic,
+// inline code and links in markdown, styling the text they mark.
+func MarkdownConcealRules() []ConcealRule {
+	return []ConcealRule{
+		{Pattern: regexp.MustCompile(`\*\*([^*]+)\*\*`), Style: lipgloss.NewStyle().Bold(true)},
+		{Pattern: regexp.MustCompile(`__([^_]+)__`), Style: lipgloss.NewStyle().Bold(true)},
+		{Pattern: regexp.MustCompile(`\*([^*\s][^*]*)\*`), Style: lipgloss.NewStyle().Italic(true)},
+		{Pattern: regexp.MustCompile(`\b_([^_\s][^_]*)_\b`), Style: lipgloss.NewStyle().Italic(true)},
+		{Pattern: regexp.MustCompile("`([^`]+)`")},
+		{Pattern: regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`), Style: lipgloss.NewStyle().Underline(true)},
+	}
+}
+
+// SetConcealRules sets the rules that conceal text on the lines the cursor
+// isn't on. The cursor line is drawn as it is, so the markup can be edited.
+// Lines wrap as if nothing was concealed. nil removes them.
+func (m *Model) SetConcealRules(rules []ConcealRule) {
+	m.concealRules = rules
+	m.renderVisibleSlice()
+}
+
+// SetConcealLevel sets how concealed text is drawn. It's ConcealHide by
+// default.
+func (m *Model) SetConcealLevel(level ConcealLevel) {
+	m.concealLevel = level
+	m.renderVisibleSlice()
+}
+
+// concealCell is how a character of a line with concealed text is drawn.
+type concealCell struct {
+	rule        int    // 1 + the index of the rule matching the character, 0 for none
+	concealed   bool   // Drawn as nothing, or as replacement at the start of a concealed part
+	replacement string // Drawn in place of the concealed part the character starts
+}
+
+// concealLine returns how each character of a line is drawn, or nil if
+// nothing on it is concealed. Where matches of rules overlap, the first rule
+// wins.
+func (m *Model) concealLine(line []rune) []concealCell {
+	if m.concealLevel == ConcealOff || len(m.concealRules) == 0 || m.accessibility.enabled {
+		return nil
+	}
+
+	text := string(line)
+	// Matches are in bytes, characters in runes
+	runeAt := make([]int, len(text)+1)
+	i := 0
+	for b := range text {
+		runeAt[b] = i
+		i++
+	}
+	runeAt[len(text)] = i
+
+	var cells []concealCell
+	for ruleIdx, rule := range m.concealRules {
+		if rule.Pattern == nil {
+			continue
+		}
+		for _, match := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := runeAt[match[0]], runeAt[match[1]]
+			if start == end {
+				continue
+			}
+			if cells == nil {
+				cells = make([]concealCell, len(line))
+			}
+			if claimed(cells[start:end]) {
+				continue
+			}
+
+			visibleStart, visibleEnd := end, end
+			if len(match) >= 4 && match[2] >= 0 {
+				visibleStart, visibleEnd = runeAt[match[2]], runeAt[match[3]]
+			}
+			for col := start; col < end; col++ {
+				cells[col] = concealCell{rule: ruleIdx + 1, concealed: col < visibleStart || col >= visibleEnd}
+			}
+			m.startConcealedPart(cells, start, rule)
+			if visibleEnd < end {
+				m.startConcealedPart(cells, visibleEnd, rule)
+			}
+		}
+	}
+	return cells
+}
+
+// startConcealedPart sets what is drawn for the concealed part starting at
+// col, if there is one.
+func (m *Model) startConcealedPart(cells []concealCell, col int, rule ConcealRule) {
+	if !cells[col].concealed {
+		return
+	}
+	switch {
+	case m.concealLevel == ConcealAll:
+	case rule.Replacement != "":
+		cells[col].replacement = rule.Replacement
+	case m.concealLevel == ConcealReplace:
+		cells[col].replacement = " "
+	}
+}
+
+// claimed reports whether a rule already matched any of cells.
+func claimed(cells []concealCell) bool {
+	for _, cell := range cells {
+		if cell.rule != 0 {
+			return true
+		}
+	}
+	return false
+}
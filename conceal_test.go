@@ -0,0 +1,59 @@
+package goeditor
+
+import (
+	"regexp"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConceal tests hiding markup on the lines the cursor isn't on.
+func TestConceal(t *testing.T) {
+	const content = "cursor **here**\nsome **bold** and `code`\na -> b"
+	arrow := ConcealRule{Pattern: regexp.MustCompile(`->`), Replacement: "→"}
+
+	t.Run("markup is hidden off the cursor line", func(t *testing.T) {
+		m := newTestModel(content, withSize(30, 6), withTrueColor(), withConcealRules(append(MarkdownConcealRules(), arrow)))
+		lines := renderedLines(m)
+		assert.Equal(t, "   1 [c]ursor **here**", lines[0])
+		assert.Equal(t, "   2 some bold and code", lines[1])
+		assert.Equal(t, "   3 a → b", lines[2])
+		assert.Contains(t, m.viewport.View(), lipgloss.NewStyle().Bold(true).Render("bold"))
+	})
+
+	t.Run("the cursor line shows the markup", func(t *testing.T) {
+		m := newTestModel(content, withSize(30, 6), withTrueColor(), withConcealRules(MarkdownConcealRules()))
+		m = typeText(m, "j")
+		m.renderVisibleSlice()
+		lines := renderedLines(m)
+		assert.Equal(t, "   1 cursor here", lines[0])
+		assert.Equal(t, "   2 [s]ome **bold** and `code`", lines[1])
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		for _, c := range []struct {
+			level      ConcealLevel
+			bold, with string
+		}{
+			{ConcealOff, "   2 some **bold** and `code`", "   3 a -> b"},
+			{ConcealReplace, "   2 some  bold  and  code", "   3 a → b"},
+			{ConcealHide, "   2 some bold and code", "   3 a → b"},
+			{ConcealAll, "   2 some bold and code", "   3 a  b"},
+		} {
+			m := newTestModel(content, withSize(30, 6), withTrueColor(), withConcealRules(append(MarkdownConcealRules(), arrow)))
+			m.SetConcealLevel(c.level)
+			lines := renderedLines(m)
+			assert.Equal(t, c.bold, lines[1], c.level)
+			assert.Equal(t, c.with, lines[2], c.level)
+		}
+	})
+
+	t.Run("the first rule wins where matches overlap", func(t *testing.T) {
+		m := newTestModel(content, withSize(30, 6), withTrueColor(), withConcealRules([]ConcealRule{
+			{Pattern: regexp.MustCompile(`\*\*(\w+)`)},
+			{Pattern: regexp.MustCompile(`bold\*\*`), Replacement: "!"},
+		}))
+		assert.Equal(t, "   2 some bold** and `code`", renderedLines(m)[1])
+	})
+}
@@ -0,0 +1,57 @@
+package goeditor
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// HandleKey updates the editor with msg as Update does, and reports whether
+// the editor consumed the key, for hosts with several widgets that pass on
+// the keys the focused one leaves alone, such as Esc with nothing to cancel
+// or j on the last line.
+//
+// A key is consumed if it changed the editor: its content, cursor, mode,
+// selection, scroll position or command line, or a panel or menu the editor
+// shows, or if it started a command waiting for more keys, such as d, or
+// failed with an error. Keys the editor ignores, disabled keys and keys
+// while it isn't focused are not.
+func (m Model) HandleKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	m, cmd := m.Update(msg)
+	return m, cmd, m.keyConsumed
+}
+
+// keyEffects is what a key may change in the editor, to tell whether it
+// consumed the key.
+type keyEffects struct {
+	revision      uint64
+	cursor        core.Position
+	mode          core.Mode
+	pendingKeys   string
+	commandLine   string
+	visualStart   core.Position
+	yank          core.SelectionType
+	topLine       int
+	search        string
+	searchCursor  int
+	completions   bool
+	completionIdx int
+}
+
+// keyEffects returns the state of m a key may change.
+func (m *Model) keyEffects() keyEffects {
+	state := m.editor.GetState()
+	return keyEffects{
+		revision:      m.editor.GetBuffer().Revision(),
+		cursor:        m.editor.GetBuffer().GetCursor().Position,
+		mode:          state.Mode,
+		pendingKeys:   state.PendingKeys,
+		commandLine:   state.CommandLine,
+		visualStart:   state.VisualStart,
+		yank:          state.YankSelection,
+		topLine:       m.currentVisualTopLine,
+		search:        m.searchInput.Value(),
+		searchCursor:  m.searchInput.Position(),
+		completions:   m.completionMenuVisible,
+		completionIdx: m.selectedCompletionIdx,
+	}
+}
@@ -0,0 +1,80 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHandleKey tests reporting whether the editor consumed a key.
+func TestHandleKey(t *testing.T) {
+	const content = "one\ntwo"
+
+	press := func(m Model, key tea.KeyPressMsg) (Model, bool) {
+		m, _, consumed := m.HandleKey(key)
+		return m, consumed
+	}
+	char := func(r rune) tea.KeyPressMsg { return tea.KeyPressMsg{Code: r, Text: string(r)} }
+	esc := tea.KeyPressMsg{Code: tea.KeyEscape}
+
+	t.Run("keys that change the editor are consumed", func(t *testing.T) {
+		m := newTestModel(content)
+		var consumed bool
+		for _, r := range "jxkiA" {
+			m, consumed = press(m, char(r))
+			assert.True(t, consumed, string(r))
+		}
+		m, consumed = press(m, esc)
+		assert.True(t, consumed)
+		assert.True(t, m.IsNormalMode())
+	})
+
+	t.Run("keys waiting for more are consumed", func(t *testing.T) {
+		m := newTestModel(content)
+		m, consumed := press(m, char('d'))
+		assert.True(t, consumed)
+		m, consumed = press(m, char('d'))
+		assert.True(t, consumed)
+		assert.Equal(t, "two", m.GetCurrentContent())
+	})
+
+	t.Run("keys that change nothing are not", func(t *testing.T) {
+		m := newTestModel(content)
+		m, consumed := press(m, esc)
+		assert.False(t, consumed)
+		m, consumed = press(m, char('k'))
+		assert.False(t, consumed)
+		_, consumed = press(m, tea.KeyPressMsg{Code: 'q', Mod: tea.ModCtrl})
+		assert.False(t, consumed)
+	})
+
+	t.Run("typing a search is consumed", func(t *testing.T) {
+		m := newTestModel(content)
+		m, consumed := press(m, char('/'))
+		assert.True(t, consumed)
+		m, _ = m.Update(enterSearchMode{}) // Sent for the editor's signal
+		m, consumed = press(m, char('t'))
+		assert.True(t, consumed)
+		_, consumed = press(m, tea.KeyPressMsg{Code: tea.KeyLeft})
+		assert.True(t, consumed)
+	})
+
+	t.Run("keys for the palette are consumed", func(t *testing.T) {
+		m := newTestModel(content)
+		m.OpenCommandPalette()
+		_, consumed := press(m, esc)
+		assert.True(t, consumed)
+	})
+
+	t.Run("disabled keys and keys while blurred are not", func(t *testing.T) {
+		m := newTestModel(content)
+		assert.NoError(t, m.DisableKeys("x"))
+		m, consumed := press(m, char('x'))
+		assert.False(t, consumed)
+
+		m.Blur()
+		_, consumed = press(m, char('j'))
+		assert.False(t, consumed)
+	})
+}
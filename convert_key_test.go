@@ -0,0 +1,133 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConvertBubbleKeys tests the conversion of Bubbletea keys to core key events.
+func TestConvertBubbleKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		key  tea.Key
+		want []core.KeyEvent
+	}{
+		{"letter", tea.Key{Code: 'a', Text: "a"}, []core.KeyEvent{{Rune: 'a'}}},
+		{"shifted letter", tea.Key{Code: 'a', ShiftedCode: 'A', Text: "A", Mod: tea.ModShift}, []core.KeyEvent{{Rune: 'A', Modifiers: core.ModShift}}},
+		{"multi-byte rune", tea.Key{Code: 'é', Text: "é"}, []core.KeyEvent{{Rune: 'é'}}},
+		{"emoji", tea.Key{Code: '😀', Text: "😀"}, []core.KeyEvent{{Rune: '😀'}}},
+		{"space key", tea.Key{Code: tea.KeySpace, Text: " "}, []core.KeyEvent{{Rune: ' ', Key: core.KeySpace}}},
+		{"space key without text", tea.Key{Code: tea.KeySpace}, []core.KeyEvent{{Rune: ' ', Key: core.KeySpace}}},
+		{"ctrl+space", tea.Key{Code: tea.KeySpace, Mod: tea.ModCtrl}, []core.KeyEvent{{Rune: ' ', Key: core.KeySpace, Modifiers: core.ModCtrl}}},
+		{"enter", tea.Key{Code: tea.KeyEnter}, []core.KeyEvent{{Key: core.KeyEnter}}},
+		{"tab", tea.Key{Code: tea.KeyTab}, []core.KeyEvent{{Rune: '\t', Key: core.KeyTab}}},
+		{"escape", tea.Key{Code: tea.KeyEscape}, []core.KeyEvent{{Key: core.KeyEscape}}},
+		{"backspace", tea.Key{Code: tea.KeyBackspace}, []core.KeyEvent{{Key: core.KeyBackspace}}},
+		{"arrow", tea.Key{Code: tea.KeyLeft}, []core.KeyEvent{{Key: core.KeyLeft}}},
+		{"page down", tea.Key{Code: tea.KeyPgDown}, []core.KeyEvent{{Key: core.KeyPageDown}}},
+		{"ctrl+d", tea.Key{Code: 'd', Mod: tea.ModCtrl}, []core.KeyEvent{{Key: core.KeyCtrlD, Modifiers: core.ModCtrl}}},
+		{"ctrl+r", tea.Key{Code: 'r', Mod: tea.ModCtrl}, []core.KeyEvent{{Key: core.KeyCtrlR, Modifiers: core.ModCtrl}}},
+		{"ctrl+shift+v", tea.Key{Code: 'v', Mod: tea.ModCtrl | tea.ModShift}, []core.KeyEvent{{Key: core.KeyCtrlV, Modifiers: core.ModCtrl | core.ModShift}}},
+		{"shift+tab", tea.Key{Code: tea.KeyTab, Mod: tea.ModShift}, []core.KeyEvent{{Rune: '\t', Key: core.KeyTab, Modifiers: core.ModShift}}},
+		{"alt+letter", tea.Key{Code: 'x', Text: "x", Mod: tea.ModAlt}, []core.KeyEvent{{Rune: 'x', Modifiers: core.ModAlt}}},
+		{"alt+letter without text", tea.Key{Code: 'x', Mod: tea.ModAlt}, []core.KeyEvent{{Rune: 'x', Modifiers: core.ModAlt}}},
+		{"alt+shift+letter", tea.Key{Code: 'a', ShiftedCode: 'A', Mod: tea.ModAlt | tea.ModShift}, []core.KeyEvent{{Rune: 'A', Modifiers: core.ModAlt | core.ModShift}}},
+		{"ctrl+digit", tea.Key{Code: '1', Mod: tea.ModCtrl}, []core.KeyEvent{{Rune: '1', Modifiers: core.ModCtrl}}},
+		{"ctrl+i isn't tab", tea.Key{Code: 'i', Mod: tea.ModCtrl}, []core.KeyEvent{{Key: core.KeyCtrlI, Modifiers: core.ModCtrl}}},
+		{"ctrl+letter on another layout", tea.Key{Code: 'й', BaseCode: 'q', Mod: tea.ModCtrl}, []core.KeyEvent{{Key: core.KeyCtrlQ, Modifiers: core.ModCtrl}}},
+		{"shift+enter", tea.Key{Code: tea.KeyEnter, Mod: tea.ModShift}, []core.KeyEvent{{Key: core.KeyEnter, Modifiers: core.ModShift}}},
+		{"super+letter", tea.Key{Code: 's', Mod: tea.ModSuper}, []core.KeyEvent{{Rune: 's', Modifiers: core.ModSuper}}},
+		{
+			"text of several runes",
+			tea.Key{Code: 'h', Text: "hé y"},
+			[]core.KeyEvent{{Rune: 'h'}, {Rune: 'é'}, {Rune: ' ', Key: core.KeySpace}, {Rune: 'y'}},
+		},
+		{"unknown key without text", tea.Key{Code: tea.KeyF1}, []core.KeyEvent{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tea.KeyPressMsg(tt.key)
+			assert.Equal(t, tt.want, convertBubbleKeys(msg))
+			assert.Equal(t, tt.want[0], convertBubbleKey(msg))
+		})
+	}
+}
+
+// TestUpdateEnhancedKeys tests keys that terminals with the Kitty keyboard
+// protocol tell apart.
+func TestUpdateEnhancedKeys(t *testing.T) {
+	t.Run("hosts bind keys the editor takes alike", func(t *testing.T) {
+		m := newTestModel("ab", withSize(80, 10))
+		assert.NoError(t, m.DisableKeys("<C-i>", "<S-CR>", "<C-S-a>", "<A-x>"))
+		m = typeText(m, "i")
+		for _, key := range []tea.Key{
+			{Code: 'i', Mod: tea.ModCtrl},
+			{Code: tea.KeyEnter, Mod: tea.ModShift},
+			{Code: 'a', Mod: tea.ModCtrl | tea.ModShift},
+			{Code: 'x', Mod: tea.ModAlt},
+		} {
+			m, _ = m.Update(tea.KeyPressMsg(key))
+		}
+		assert.Equal(t, "ab", m.GetCurrentContent())
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+		assert.Equal(t, "\t\nab", m.GetCurrentContent())
+	})
+
+	t.Run("unbound, they act as they do in other terminals", func(t *testing.T) {
+		m := typeText(newTestModel("ab", withSize(80, 10)), "i")
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'i', Mod: tea.ModCtrl})
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter, Mod: tea.ModShift})
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'x', Mod: tea.ModAlt})
+		assert.Equal(t, "\t\nab", m.GetCurrentContent())
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: '[', Mod: tea.ModCtrl})
+		assert.True(t, m.IsNormalMode())
+	})
+
+	t.Run("releases aren't keys", func(t *testing.T) {
+		m := typeText(newTestModel("ab", withSize(80, 10)), "i")
+		m, _ = m.Update(tea.KeyReleaseMsg{Code: 'x', Text: "x"})
+		assert.Equal(t, "ab", m.GetCurrentContent())
+	})
+}
+
+// TestUpdateTypesEveryRuneOfText tests that text of several runes in one key
+// message is inserted whole.
+func TestUpdateTypesEveryRuneOfText(t *testing.T) {
+	m := New(80, 10)
+	m.Focus()
+	m.SetContent("")
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'i', Text: "i"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'h', Text: "héllo wörld"})
+
+	assert.Equal(t, "héllo wörld", m.GetCurrentContent())
+}
+
+// TestUpdatePaste tests inserting text pasted into the terminal.
+func TestUpdatePaste(t *testing.T) {
+	t.Run("inserts the text as one change in insert mode", func(t *testing.T) {
+		m := newTestModel("ab", withSize(80, 10))
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'a', Text: "a"})
+		m, _ = m.Update(tea.PasteMsg{Content: "one\r\ntwo "})
+
+		assert.Equal(t, "aone\ntwo b", m.GetCurrentContent())
+		assert.Equal(t, core.Position{Row: 1, Col: 4}, m.GetCursorPosition())
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'u', Text: "u"})
+		assert.Equal(t, "ab", m.GetCurrentContent())
+	})
+
+	t.Run("is ignored in normal mode", func(t *testing.T) {
+		m := newTestModel("ab", withSize(80, 10))
+		m, _ = m.Update(tea.PasteMsg{Content: "dd"})
+		assert.Equal(t, "ab", m.GetCurrentContent())
+	})
+}
@@ -3,7 +3,11 @@ package core
 import (
 	"bytes"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"unicode"
 )
 
 // Buffer represents the text content being edited (Using Runes)
@@ -17,37 +21,158 @@ type Buffer interface {
 	LineCount() int                  // Get number of lines
 
 	// Modification
-	InsertRunesAt(row, col int, runes []rune) error     // Insert runes (handles newlines)
-	DeleteRunesAt(row, col int, count int) *EditorError // Delete runes (handles newlines)
-	// ReplaceRunesAt(row, col int, count int, runes []rune) error // Replace (can be Delete + Insert)
+	InsertRunesAt(row, col int, runes []rune) error            // Insert runes (handles newlines)
+	DeleteRunesAt(row, col int, count int) *EditorError        // Delete runes (handles newlines)
+	ReplaceRange(start, end Position, text string) error       // Replace the text from start up to end (handles newlines)
+	InsertLines(at int, lines []string) error                  // Insert lines before line at, or after the last line if at is LineCount()
+	DeleteLines(from, to int) error                            // Delete lines from through to
+	ReplaceAll(pattern *regexp.Regexp, replacement string) int // Replace every match, expanding $1 etc.; returns how many
+
+	// Offsets in GetCurrentContent, e.g. for LSP servers, parsers and diff libraries
+	OffsetOf(pos Position) int          // Byte offset of a position
+	PositionOf(offset int) Position     // Position at a byte offset
+	RuneOffsetOf(pos Position) int      // Rune offset of a position
+	PositionOfRune(offset int) Position // Position at a rune offset
 
 	// Cursor
 	GetCursor() Cursor
 	SetCursor(Cursor)
 
 	Find(pattern string, start Position, options SearchOptions) (Position, bool) // Find next/prev
-	// FindAll(pattern string, options SearchOptions) []Position TODO: Implement later if needed
+	FindAll(pattern string, options SearchOptions) []Position                    // Find every match in buffer order
 	// Replace(pattern string, replacement string, options SearchOptions) int // Implement later if needed)
 
-	IsModified() bool          // Check if buffer has been modified
+	IsModified() bool          // Check if buffer has been modified since it was saved, without comparing the content
+	DiffersFromSaved() bool    // Compare the content with the saved content, slower than IsModified
+	Revision() uint64          // Grows with every change to the content, to tell whether it changed since
 	SaveContent()              // Save content
+	SetSavedContent(string)    // Set the saved content, e.g. when the file changed on disk
 	SetContent(content []byte) // Set content (from file or other source)
 	IsEmpty() bool             // Check if buffer is empty
+
+	// Line endings
+	LineEnding() LineEnding           // Line ending detected by SetContent and used by GetCurrentContent
+	SetLineEnding(ending LineEnding)  // Change the line ending used when the content is saved
+	HasTrailingNewline() bool         // Whether the content ends with a line ending (the last line is empty)
+	SetTrailingNewline(trailing bool) // Add or remove the empty last line
+
+	// Encoding
+	Encoding() Encoding              // Encoding the content was read with and is written with
+	SetEncoding(enc Encoding)        // Change the encoding used when the content is saved
+	IsLossy() bool                   // Whether invalid bytes were replaced when the content was read
+	EncodedContent() ([]byte, error) // Current content encoded for saving
+
+	// File
+	FileName() string        // Name of the file being edited, set by the host; empty if none
+	SetFileName(name string) // Set the name of the file being edited
+}
+
+// LineEnding is the sequence that separates lines in the buffer's content
+type LineEnding int
+
+const (
+	LineEndingLF   LineEnding = iota // "\n" (unix)
+	LineEndingCRLF                   // "\r\n" (dos)
+)
+
+// String returns the Vim fileformat name of the line ending.
+func (l LineEnding) String() string {
+	if l == LineEndingCRLF {
+		return "dos"
+	}
+	return "unix"
+}
+
+// Sequence returns the characters written between lines.
+func (l LineEnding) Sequence() string {
+	if l == LineEndingCRLF {
+		return "\r\n"
+	}
+	return "\n"
 }
 
-// SearchOptions represents options for search operations
+// ParseLineEnding parses a Vim fileformat name ("unix" or "dos").
+func ParseLineEnding(name string) (LineEnding, bool) {
+	switch name {
+	case "unix":
+		return LineEndingLF, true
+	case "dos":
+		return LineEndingCRLF, true
+	}
+	return LineEndingLF, false
+}
+
+// SearchOptions represents options for search operations. They are used by
+// the editor state and by Buffer.Find and Buffer.FindAll alike.
 type SearchOptions struct {
 	IgnoreCase bool // Case insensitive search
-	SmartCase  bool // ...unless search contains uppercase
+	SmartCase  bool // With IgnoreCase, case sensitive if the pattern has an uppercase letter
 	Backwards  bool // Whether to search backwards
 	Wrap       bool // Whether to wrap around the buffer
+	WholeWord  bool // Only match whole words, as if the pattern were \<pattern\>
+
+	// IsWordChar classifies word characters for WholeWord and the \< and \>
+	// atoms. Letters, digits and underscores when nil.
+	IsWordChar func(rune) bool
+}
+
+// ignoresCase reports whether pattern is matched case insensitively: with
+// IgnoreCase, unless SmartCase is set and pattern has an uppercase letter.
+func (o SearchOptions) ignoresCase(pattern []rune) bool {
+	if !o.IgnoreCase {
+		return false
+	}
+	return !o.SmartCase || !slices.ContainsFunc(pattern, unicode.IsUpper)
+}
+
+// searchNeedle is a search pattern ready to match against lines.
+type searchNeedle struct {
+	runes      []rune
+	wordStart  bool // The match must start a word
+	wordEnd    bool // The match must end a word
+	ignoreCase bool
+	isWordChar func(rune) bool
+}
+
+// parseSearchPattern takes the Vim word boundary atoms off pattern: \< at the
+// start and \> at the end. Anywhere else they are matched literally.
+func parseSearchPattern(pattern string, options SearchOptions) searchNeedle {
+	needle := searchNeedle{wordStart: options.WholeWord, wordEnd: options.WholeWord, isWordChar: options.IsWordChar}
+	if rest, ok := strings.CutPrefix(pattern, "\\<"); ok {
+		pattern, needle.wordStart = rest, true
+	}
+	if rest, ok := strings.CutSuffix(pattern, "\\>"); ok {
+		pattern, needle.wordEnd = rest, true
+	}
+	if needle.isWordChar == nil {
+		needle.isWordChar = getDefaultIsWordCharFunc()
+	}
+
+	needle.runes = []rune(pattern)
+	needle.ignoreCase = options.ignoresCase(needle.runes)
+	return needle
+}
+
+// SearchMatchLen returns the number of runes matched by pattern, without the
+// \< and \> atoms.
+func SearchMatchLen(pattern string) int {
+	return len(parseSearchPattern(pattern, SearchOptions{}).runes)
 }
 
 // textBuffer implementation using runes for better unicode handling
 type textBuffer struct {
-	lines        [][]rune // Store lines as slices of runes
-	cursor       Cursor
-	savedContent string
+	lines         [][]rune // Store lines as slices of runes
+	cursor        Cursor
+	savedContent  string
+	savedEncoding Encoding
+	lineEnding    LineEnding
+	encoding      Encoding
+	lossy         bool // Invalid UTF-8 was replaced with U+FFFD when reading
+	fileName      string
+	revision      uint64       // Revision of the content, from nextRevision
+	savedRevision uint64       // Revision of the saved content
+	index         *lineIndex   // Where the lines start, for the revision it holds
+	strings       *lineStrings // The lines as strings, for the revision they hold
 }
 
 // NewBuffer creates a new empty buffer
@@ -59,12 +184,41 @@ func NewBuffer() Buffer {
 }
 
 func NewBufferFromBytes(content []byte) Buffer {
+	return NewBufferFromBytesWithFallback(content, EncodingUTF8)
+}
+
+// NewBufferFromBytesWithFallback creates a buffer from file content. Content that
+// starts with a UTF-16 byte order mark is decoded as UTF-16, and content that
+// is not valid UTF-8 is decoded with fallback; EncodingLatin1 preserves every byte
+// on save, while EncodingUTF8 replaces invalid bytes and marks the buffer as lossy.
+func NewBufferFromBytesWithFallback(content []byte, fallback Encoding) Buffer {
+	b := textBuffer{
+		lines:  [][]rune{{}}, // Start with one empty line
+		cursor: Cursor{Position: Position{0, 0}, Preferred: 0},
+	}
+
+	text, encoding, lossy := decodeContent(content, fallback)
+	b.encoding = encoding
+	b.lossy = lossy
+
+	b.SetContent([]byte(text))
+	b.SaveContent()
+	return &b
+}
+
+// NewBufferFromBytesWithEncoding creates a buffer from file content in the given
+// encoding. The content is written back in the same encoding when saved.
+func NewBufferFromBytesWithEncoding(content []byte, enc Encoding) Buffer {
 	b := textBuffer{
 		lines:  [][]rune{{}}, // Start with one empty line
 		cursor: Cursor{Position: Position{0, 0}, Preferred: 0},
 	}
 
-	b.SetContent(content)
+	text, lossy := enc.Decode(content)
+	b.encoding = enc
+	b.lossy = lossy
+
+	b.SetContent([]byte(text))
 	b.SaveContent()
 	return &b
 }
@@ -73,34 +227,51 @@ func (b *textBuffer) IsEmpty() bool {
 	return len(b.lines) == 1 && len(b.lines[0]) == 0
 }
 
+// SetContent replaces the buffer's lines. Every line break starts a new line,
+// so content ending with a line break has an empty last line and
+// GetCurrentContent returns exactly the content that was set.
 func (b *textBuffer) SetContent(content []byte) {
-	// Convert bytes to runes
-	runes := bytes.Runes(content)
-	linesRune := make([][]rune, 0)
-	var currentLine []rune
-
-	for _, r := range runes {
-		if r == '\n' {
-			linesRune = append(linesRune, currentLine)
-			currentLine = []rune{} // Start a new line
-		} else {
-			currentLine = append(currentLine, r)
-		}
+	// Content is treated as dos only when every line break is CRLF, so files with
+	// mixed line endings keep their carriage returns as part of the lines.
+	lineBreaks := bytes.Count(content, []byte("\n"))
+	b.lineEnding = LineEndingLF
+	if lineBreaks > 0 && bytes.Count(content, []byte("\r\n")) == lineBreaks {
+		b.lineEnding = LineEndingCRLF
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
 	}
 
-	if len(currentLine) > 0 {
-		linesRune = append(linesRune, currentLine) // Add the last line if not empty
+	linesRune := make([][]rune, 0, lineBreaks+1)
+	for line := range bytes.SplitSeq(content, []byte("\n")) {
+		linesRune = append(linesRune, bytes.Runes(line))
 	}
 
 	b.lines = linesRune
+	b.revision = nextRevision()
+	// Undo restores the content with SetContent, and undoing every change
+	// since a save leaves the buffer unmodified
+	if b.GetCurrentContent() == b.savedContent {
+		b.savedRevision = b.revision
+	}
 }
 
 func (b *textBuffer) GetLines() []string {
-	linesStr := make([]string, len(b.lines))
-	for i, r := range b.lines {
-		linesStr[i] = string(r)
+	if b.strings == nil || b.strings.revision != b.revision {
+		linesStr := make([]string, len(b.lines))
+		for i, r := range b.lines {
+			linesStr[i] = string(r)
+		}
+		b.strings = &lineStrings{revision: b.revision, lines: linesStr}
 	}
-	return linesStr
+	// The strings are shared, but not the slice holding them
+	return slices.Clone(b.strings.lines)
+}
+
+// lineStrings holds the lines converted to strings, kept until the next
+// change, as GetLines is called several times for each frame and converting
+// long lines is slow.
+type lineStrings struct {
+	revision uint64
+	lines    []string
 }
 
 func (b *textBuffer) GetLineRunes(lineNum int) []rune {
@@ -117,12 +288,53 @@ func (b *textBuffer) LineRuneCount(lineNum int) int {
 	return len(b.lines[lineNum])
 }
 
+// IsModified reports whether the content changed since it was saved, or was
+// set back to the saved content with SetContent, as undo does. It doesn't
+// compare the content, so editing text back to what it was leaves the buffer
+// modified, as in Vim; DiffersFromSaved compares it.
 func (b *textBuffer) IsModified() bool {
-	return b.savedContent != b.GetCurrentContent()
+	return b.revision != b.savedRevision || b.savedEncoding != b.encoding
+}
+
+// DiffersFromSaved reports whether the content or encoding differ from the
+// saved ones, comparing the whole content.
+func (b *textBuffer) DiffersFromSaved() bool {
+	return b.savedContent != b.GetCurrentContent() || b.savedEncoding != b.encoding
+}
+
+// Revision returns a number that grows with every change to the content.
+// Comparing it with an earlier value is a cheap way to tell whether the
+// content changed since, without comparing the content itself. Revisions
+// are unique across buffers, so replacing the buffer, as SetContent does,
+// changes it too.
+func (b *textBuffer) Revision() uint64 {
+	return b.revision
+}
+
+// revisions is the latest revision of any buffer.
+var revisions atomic.Uint64
+
+// nextRevision returns a revision no buffer had before.
+func nextRevision() uint64 {
+	return revisions.Add(1)
 }
 
 func (b *textBuffer) SaveContent() {
 	b.savedContent = b.GetCurrentContent()
+	b.savedEncoding = b.encoding
+	b.savedRevision = b.revision
+}
+
+// SetSavedContent sets the content saved, leaving the buffer modified unless
+// it's the current content, e.g. when the file changed on disk and the
+// buffer is kept.
+func (b *textBuffer) SetSavedContent(content string) {
+	b.savedContent = content
+	b.savedEncoding = b.encoding
+	b.savedRevision = 0
+	if content == b.GetCurrentContent() {
+		b.savedRevision = b.revision
+	}
 }
 
 // GetCurrentContent returns the entire buffer content as a string
@@ -132,7 +344,67 @@ func (b *textBuffer) GetCurrentContent() string {
 	for i, r := range b.lines {
 		linesStr[i] = string(r)
 	}
-	return strings.Join(linesStr, "\n")
+	return strings.Join(linesStr, b.lineEnding.Sequence())
+}
+
+func (b *textBuffer) Encoding() Encoding {
+	return b.encoding
+}
+
+func (b *textBuffer) SetEncoding(enc Encoding) {
+	b.encoding = enc
+}
+
+func (b *textBuffer) IsLossy() bool {
+	return b.lossy
+}
+
+func (b *textBuffer) EncodedContent() ([]byte, error) {
+	return b.encoding.Encode(b.GetCurrentContent())
+}
+
+// setCursorPastEnd sets the cursor like SetCursor but lets it be past the end
+// of the line, for VirtualEditAll.
+func (b *textBuffer) setCursorPastEnd(cursor Cursor) {
+	col := cursor.Position.Col
+	b.SetCursor(cursor)
+	b.cursor.Position.Col = max(col, b.cursor.Position.Col)
+}
+
+func (b *textBuffer) FileName() string {
+	return b.fileName
+}
+
+func (b *textBuffer) SetFileName(name string) {
+	b.fileName = name
+}
+
+func (b *textBuffer) LineEnding() LineEnding {
+	return b.lineEnding
+}
+
+func (b *textBuffer) SetLineEnding(ending LineEnding) {
+	if ending != b.lineEnding {
+		b.revision = nextRevision()
+	}
+	b.lineEnding = ending
+}
+
+func (b *textBuffer) HasTrailingNewline() bool {
+	return len(b.lines) > 1 && len(b.lines[len(b.lines)-1]) == 0
+}
+
+func (b *textBuffer) SetTrailingNewline(trailing bool) {
+	if trailing == b.HasTrailingNewline() {
+		return
+	}
+
+	if trailing {
+		b.lines = append(b.lines, []rune{})
+	} else {
+		b.lines = b.lines[:len(b.lines)-1]
+	}
+	b.revision = nextRevision()
 }
 
 // GetSavedContent returns the saved content as a string
@@ -166,6 +438,9 @@ func (b *textBuffer) SetCursor(cursor Cursor) {
 		cursor.Position.Col = lineLen
 	}
 
+	// Never place the cursor inside a grapheme cluster
+	cursor.Position.Col = graphemeStartCol(b.GetLineRunes(cursor.Position.Row), cursor.Position.Col)
+
 	b.cursor = cursor
 }
 
@@ -229,6 +504,7 @@ func (b *textBuffer) InsertRunesAt(row, col int, runes []rune) error {
 		b.lines[row] = newLine
 	}
 
+	b.revision = nextRevision()
 	return nil
 }
 
@@ -261,6 +537,7 @@ func (b *textBuffer) DeleteRunesAt(row, col int, count int) *EditorError {
 		newLine = append(newLine, line[:col]...)
 		newLine = append(newLine, line[col+count:]...)
 		b.lines[row] = newLine
+		b.revision = nextRevision()
 		return nil
 	}
 
@@ -284,6 +561,7 @@ func (b *textBuffer) DeleteRunesAt(row, col int, count int) *EditorError {
 		// Deleting the newline + content of this line
 		if remainingToDelete >= currentLineLen+1 { // +1 for the newline
 			remainingToDelete -= (currentLineLen + 1)
+			colOnLastDeletedLine = currentLineLen // Nothing is left of this line so far
 			currentRow++
 		} else {
 			// Deletion ends within this line
@@ -321,104 +599,214 @@ func (b *textBuffer) DeleteRunesAt(row, col int, count int) *EditorError {
 		b.cursor = Cursor{Position{0, 0}, 0} // Reset cursor if buffer was emptied
 	}
 
+	b.revision = nextRevision()
+	return nil
+}
+
+// ReplaceRange replaces the text from start up to but not including end with
+// text, whose newlines break lines. start and end may be in either order.
+func (b *textBuffer) ReplaceRange(start, end Position, text string) error {
+	for _, pos := range []Position{start, end} {
+		if pos.Row < 0 || pos.Row >= len(b.lines) || pos.Col < 0 || pos.Col > len(b.lines[pos.Row]) {
+			return fmt.Errorf("ReplaceRange: %w: %d:%d", ErrInvalidPosition, pos.Row, pos.Col)
+		}
+	}
+	start, end = NormalizeSelection(start, end)
+
+	parts := strings.Split(text, "\n")
+	replacement := make([][]rune, len(parts))
+	for i, part := range parts {
+		replacement[i] = []rune(part)
+	}
+	last := len(replacement) - 1
+	replacement[0] = slices.Concat(b.lines[start.Row][:start.Col], replacement[0])
+	replacement[last] = append(replacement[last], b.lines[end.Row][end.Col:]...)
+
+	b.lines = slices.Concat(b.lines[:start.Row], replacement, b.lines[end.Row+1:])
+	b.revision = nextRevision()
 	return nil
 }
 
-// Find searches forward or backward for the next occurrence of pattern.
+// InsertLines inserts lines before line at. Newlines in them break lines.
+func (b *textBuffer) InsertLines(at int, lines []string) error {
+	if at < 0 || at > len(b.lines) {
+		return fmt.Errorf("InsertLines: %w: row %d out of bounds [0, %d]", ErrInvalidPosition, at, len(b.lines))
+	}
+
+	var inserted [][]rune
+	for _, line := range lines {
+		for part := range strings.SplitSeq(line, "\n") {
+			inserted = append(inserted, []rune(part))
+		}
+	}
+	b.lines = slices.Insert(b.lines, at, inserted...)
+	b.revision = nextRevision()
+	return nil
+}
+
+// DeleteLines deletes the lines from through to, leaving an empty line if
+// they were all of them.
+func (b *textBuffer) DeleteLines(from, to int) error {
+	if from < 0 || to >= len(b.lines) || from > to {
+		return fmt.Errorf("DeleteLines: %w: rows %d-%d out of bounds [0, %d)", ErrInvalidPosition, from, to, len(b.lines))
+	}
+
+	b.lines = slices.Delete(b.lines, from, to+1)
+	if len(b.lines) == 0 {
+		b.lines = [][]rune{{}}
+	}
+	b.revision = nextRevision()
+	return nil
+}
+
+// ReplaceAll replaces every match of pattern with replacement, in which $1
+// or ${name} stand for the text of a group, as in regexp.Expand. Patterns
+// match across lines joined by \n. It returns how many matches it replaced.
+func (b *textBuffer) ReplaceAll(pattern *regexp.Regexp, replacement string) int {
+	text := strings.Join(b.GetLines(), "\n")
+	count := len(pattern.FindAllStringIndex(text, -1))
+	if count == 0 {
+		return 0
+	}
+
+	replaced := strings.Split(pattern.ReplaceAllString(text, replacement), "\n")
+	b.lines = make([][]rune, len(replaced))
+	for i, line := range replaced {
+		b.lines[i] = []rune(line)
+	}
+	b.revision = nextRevision()
+	return count
+}
+
+// Find searches forward or backward for the next occurrence of pattern,
+// excluding a match at start itself. With Wrap the search continues from the
+// other end of the buffer up to and including start.
 // Returns the position and true if found, or false otherwise.
 func (b *textBuffer) Find(pattern string, start Position, options SearchOptions) (Position, bool) {
-	if pattern == "" {
+	needle := parseSearchPattern(pattern, options)
+	if len(needle.runes) == 0 || len(b.lines) == 0 {
 		return Position{}, false
 	}
 
-	searchRunes := []rune(pattern)
-	if options.IgnoreCase {
-		searchRunes = []rune(strings.ToLower(pattern))
-	}
-	searchLen := len(searchRunes)
+	lastRow := len(b.lines) - 1
+	start.Row = max(0, min(start.Row, lastRow))
+	start.Col = max(0, start.Col)
 
-	numLines := b.LineCount()
-	currentLine := start.Row
-	currentCol := start.Col
+	// Each span is a row and an inclusive range of columns to try, in search order
+	type span struct{ row, lo, hi int }
+	var spans []span
 
 	if options.Backwards {
-		// Adjust starting column for backward search
-		if currentCol > 0 {
-			currentCol-- // Start searching *before* the cursor
-		} else if currentLine > 0 {
-			currentLine--
-			currentCol = b.LineRuneCount(currentLine)
-		} else {
-			if !options.Wrap {
-				return Position{}, false // Already at start of buffer
+		spans = append(spans, span{start.Row, 0, start.Col - 1})
+		for r := start.Row - 1; r >= 0; r-- {
+			spans = append(spans, span{r, 0, len(b.lines[r])})
+		}
+		if options.Wrap {
+			for r := lastRow; r > start.Row; r-- {
+				spans = append(spans, span{r, 0, len(b.lines[r])})
 			}
+			spans = append(spans, span{start.Row, start.Col, len(b.lines[start.Row])})
+		}
+	} else {
+		spans = append(spans, span{start.Row, start.Col + 1, len(b.lines[start.Row])})
+		for r := start.Row + 1; r <= lastRow; r++ {
+			spans = append(spans, span{r, 0, len(b.lines[r])})
+		}
+		if options.Wrap {
+			for r := 0; r < start.Row; r++ {
+				spans = append(spans, span{r, 0, len(b.lines[r])})
+			}
+			spans = append(spans, span{start.Row, 0, start.Col})
+		}
+	}
 
-			// Wrap to end of buffer
-			currentLine = numLines - 1
-			currentCol = b.LineRuneCount(currentLine)
+	for _, s := range spans {
+		if col, found := findInLine(b.lines[s.row], needle, s.lo, s.hi, options.Backwards); found {
+			return Position{Row: s.row, Col: col}, true
 		}
+	}
 
-		for r := currentLine; r >= 0; r-- {
-			lineRunes := b.GetLineRunes(r)
-			lineContent := lineRunes
-			if options.IgnoreCase {
-				lineContent = []rune(strings.ToLower(string(lineRunes)))
-			}
+	return Position{}, false // Not found
+}
 
-			startSearchCol := len(lineContent) - 1
-			if r == start.Row {
-				startSearchCol = currentCol
-			}
+// FindAll returns the start of every occurrence of pattern in buffer order.
+// Overlapping matches are included, so each one is a stop for n and N.
+func (b *textBuffer) FindAll(pattern string, options SearchOptions) []Position {
+	positions := []Position{}
+
+	needle := parseSearchPattern(pattern, options)
+	if len(needle.runes) == 0 {
+		return positions
+	}
 
-			for c := startSearchCol; c >= 0; c-- {
-				if c+searchLen > len(lineContent) {
-					continue
-				}
-				match := true
-				for i := 0; i < searchLen; i++ {
-					if lineContent[c+i] != searchRunes[i] {
-						match = false
-						break
-					}
-				}
-				if match {
-					return Position{Row: r, Col: c}, true
-				}
+	for row, line := range b.lines {
+		for col := 0; col+len(needle.runes) <= len(line); col++ {
+			if needle.matchesAt(line, col) {
+				positions = append(positions, Position{Row: row, Col: col})
 			}
 		}
+	}
 
-	} else { // Forward search
-		// Adjust starting column for forward search
-		currentCol++ // Start search *after* the cursor
-
-		for r := currentLine; r < numLines; r++ {
-			lineRunes := b.GetLineRunes(r)
-			lineContent := lineRunes
-			if options.IgnoreCase {
-				lineContent = []rune(strings.ToLower(string(lineRunes)))
-			}
+	return positions
+}
 
-			startSearchCol := 0
-			if r == start.Row {
-				startSearchCol = currentCol
-			}
+// findInLine returns the first (or with Backwards the last) column in lo..hi
+// at which needle matches line.
+func findInLine(line []rune, needle searchNeedle, lo, hi int, backwards bool) (int, bool) {
+	lo, hi = max(lo, 0), min(hi, len(line)-len(needle.runes))
+	if lo > hi {
+		return 0, false
+	}
 
-			// Use strings.Index on the relevant part of the line
-			if startSearchCol < len(lineContent) {
-				lineSuffix := string(lineContent[startSearchCol:])
-				searchStr := string(searchRunes)
-				idx := strings.Index(lineSuffix, searchStr)
-				if idx != -1 {
-					return Position{Row: r, Col: startSearchCol + idx}, true
-				}
+	if backwards {
+		for col := hi; col >= lo; col-- {
+			if needle.matchesAt(line, col) {
+				return col, true
 			}
+		}
+		return 0, false
+	}
 
-			// Reset column for next line down
-			currentCol = 0 // Start search from beginning of subsequent lines
+	for col := lo; col <= hi; col++ {
+		if needle.matchesAt(line, col) {
+			return col, true
 		}
 	}
+	return 0, false
+}
 
-	// TODO: Implement wrapping if options.Wrap is true
+// matchesAt reports whether the needle occurs in line at col, on word
+// boundaries when asked for. Case-insensitive matching folds rune by rune, so
+// columns stay valid for multi-byte characters.
+func (n searchNeedle) matchesAt(line []rune, col int) bool {
+	end := col + len(n.runes)
+	if n.wordStart && col > 0 && n.isWordChar(line[col-1]) {
+		return false
+	}
+	if n.wordEnd && end < len(line) && n.isWordChar(line[end]) {
+		return false
+	}
 
-	return Position{}, false // Not found
+	for i, r := range n.runes {
+		if !runesEqual(line[col+i], r, n.ignoreCase) {
+			return false
+		}
+	}
+	return true
+}
+
+// runesEqual compares two runes, optionally under Unicode simple case folding.
+func runesEqual(a, b rune, ignoreCase bool) bool {
+	if a == b {
+		return true
+	}
+	if !ignoreCase {
+		return false
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// fuzzBuffer returns a buffer with content, and its lines joined by line
+// breaks: the text the buffer edits, in which a line break is one rune.
+// Content that isn't valid UTF-8 or has carriage returns is skipped, as
+// loading changes it.
+func fuzzBuffer(t *testing.T, content string) (Buffer, []rune) {
+	if !utf8.ValidString(content) || strings.Contains(content, "\r") {
+		t.Skip()
+	}
+	b := NewBufferFromBytes([]byte(content))
+	return b, []rune(strings.Join(b.GetLines(), "\n"))
+}
+
+// fuzzPosition returns a position in b picked by row and col, with col at
+// most the length of the line, or one less when within is set.
+func fuzzPosition(b Buffer, row, col int, within bool) Position {
+	row = abs(row) % b.LineCount()
+	length := b.LineRuneCount(row)
+	if within {
+		length = max(0, length-1)
+	}
+	return Position{Row: row, Col: abs(col) % (length + 1)}
+}
+
+// offsetIn returns where pos is in text, the lines of b joined by line breaks.
+func offsetIn(b Buffer, pos Position) int {
+	offset := pos.Col
+	for row := range pos.Row {
+		offset += b.LineRuneCount(row) + 1
+	}
+	return offset
+}
+
+// checkBuffer fails t if b is not the text want, or has no lines.
+func checkBuffer(t *testing.T, b Buffer, want []rune) {
+	t.Helper()
+	if b.LineCount() == 0 {
+		t.Fatal("the buffer has no lines")
+	}
+	if got := strings.Join(b.GetLines(), "\n"); got != string(want) {
+		t.Fatalf("content is %q, want %q", got, string(want))
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// FuzzInsertRunesAt tests inserting text, line breaks and all, anywhere.
+func FuzzInsertRunesAt(f *testing.F) {
+	f.Add("hello\nworld", 0, 2, "X")
+	f.Add("a\n\nb", 1, 0, "one\ntwo\n")
+	f.Add("", 0, 0, "\n\n")
+	f.Add("日本\n語", 1, 1, "é\nü")
+
+	f.Fuzz(func(t *testing.T, content string, row, col int, text string) {
+		b, runes := fuzzBuffer(t, content)
+		if !utf8.ValidString(text) {
+			t.Skip()
+		}
+		pos := fuzzPosition(b, row, col, false)
+		offset := offsetIn(b, pos)
+
+		if err := b.InsertRunesAt(pos.Row, pos.Col, []rune(text)); err != nil {
+			t.Fatalf("InsertRunesAt(%d, %d): %v", pos.Row, pos.Col, err)
+		}
+		want := append(append(runes[:offset:offset], []rune(text)...), runes[offset:]...)
+		checkBuffer(t, b, want)
+	})
+}
+
+// FuzzDeleteRunesAt tests deleting any number of runes, across lines and
+// past the end of the buffer.
+func FuzzDeleteRunesAt(f *testing.F) {
+	f.Add("hello\nworld", 0, 3, 4)
+	f.Add("a\n\nb\nc", 0, 1, 3)
+	f.Add("one\ntwo\nthree", 1, 0, 100)
+	f.Add("x", 0, 1, 1)
+
+	f.Fuzz(func(t *testing.T, content string, row, col, count int) {
+		b, runes := fuzzBuffer(t, content)
+		pos := fuzzPosition(b, row, col, false)
+		offset := offsetIn(b, pos)
+		count = abs(count) % (len(runes) + 3)
+
+		if err := b.DeleteRunesAt(pos.Row, pos.Col, count); err != nil {
+			t.Fatalf("DeleteRunesAt(%d, %d, %d): %v", pos.Row, pos.Col, count, err.Error())
+		}
+		end := min(offset+count, len(runes))
+		want := append(runes[:offset:offset], runes[end:]...)
+		checkBuffer(t, b, want)
+	})
+}
+
+// FuzzFind tests that a match Find reports is in the buffer and is the
+// pattern.
+func FuzzFind(f *testing.F) {
+	f.Add("hello\nworld hello", "lo", 1, 3, false, true)
+	f.Add("aaa\naa", "aa", 0, 0, true, true)
+	f.Add("", "x", 0, 0, false, false)
+
+	f.Fuzz(func(t *testing.T, content, pattern string, row, col int, backwards, wrap bool) {
+		b, _ := fuzzBuffer(t, content)
+		if !utf8.ValidString(pattern) || strings.ContainsAny(pattern, `\`) {
+			t.Skip()
+		}
+		start := fuzzPosition(b, row, col, false)
+
+		pos, found := b.Find(pattern, start, SearchOptions{Backwards: backwards, Wrap: wrap})
+		if !found {
+			return
+		}
+		if pos.Row < 0 || pos.Row >= b.LineCount() || pos.Col < 0 {
+			t.Fatalf("Find returned %v, outside the buffer", pos)
+		}
+		line := b.GetLineRunes(pos.Row)
+		needle := []rune(pattern)
+		if pos.Col+len(needle) > len(line) || string(line[pos.Col:pos.Col+len(needle)]) != pattern {
+			t.Fatalf("Find returned %v, which isn't %q in %q", pos, pattern, string(line))
+		}
+	})
+}
+
+// FuzzDeleteVisualSelection tests that deleting a selection removes the
+// text it reports, from the start of the selection.
+func FuzzDeleteVisualSelection(f *testing.F) {
+	f.Add("hello\nworld", 0, 1, 1, 2)
+	f.Add("one\ntwo\nthree\nfour", 3, 1, 0, 2)
+	f.Add("a\n\nb", 0, 0, 2, 0)
+	f.Add("日本\n語", 0, 1, 1, 0)
+
+	f.Fuzz(func(t *testing.T, content string, startRow, startCol, endRow, endCol int) {
+		b, runes := fuzzBuffer(t, content)
+		start := fuzzPosition(b, startRow, startCol, true)
+		end := fuzzPosition(b, endRow, endCol, true)
+		from, _ := NormalizeSelection(start, end)
+		offset := offsetIn(b, from)
+
+		deleted, cursor, err := deleteVisualSelection(b, start, end)
+		if err != nil {
+			t.Fatalf("deleteVisualSelection(%v, %v): %v", start, end, err.Error())
+		}
+		if cursor != from {
+			t.Fatalf("cursor is at %v, want %v", cursor, from)
+		}
+		deletedRunes := []rune(deleted)
+		if offset+len(deletedRunes) > len(runes) || string(runes[offset:offset+len(deletedRunes)]) != deleted {
+			t.Fatalf("deleted %q, which isn't the text at %v", deleted, from)
+		}
+		want := append(runes[:offset:offset], runes[offset+len(deletedRunes):]...)
+		checkBuffer(t, b, want)
+	})
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBufferRoundTrip tests that GetCurrentContent returns exactly what SetContent received.
+func TestBufferRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"\n",
+		"\n\n",
+		"a",
+		"a\n",
+		"a\n\n",
+		"\na",
+		"a\nb",
+		"a\nb\n",
+		"a\r\nb\r\n",
+		"a\r\nb\n",
+		"héllo 👋\nwörld\n",
+	}
+
+	for _, input := range inputs {
+		b := NewBufferFromBytes([]byte(input))
+		assert.Equal(t, input, b.GetCurrentContent(), "round trip of %q", input)
+		assert.False(t, b.IsModified(), "%q should not be modified", input)
+	}
+}
+
+// TestBufferSetContentLines tests how content is split into lines.
+func TestBufferSetContentLines(t *testing.T) {
+	t.Run("empty content is a single empty line", func(t *testing.T) {
+		b := NewBufferFromBytes(nil)
+		assert.Equal(t, 1, b.LineCount())
+		assert.True(t, b.IsEmpty())
+		assert.False(t, b.HasTrailingNewline())
+	})
+
+	t.Run("trailing newline keeps an empty last line", func(t *testing.T) {
+		b := NewBufferFromBytes([]byte("a\n"))
+		assert.Equal(t, []string{"a", ""}, b.GetLines())
+		assert.True(t, b.HasTrailingNewline())
+	})
+
+	t.Run("blank line before the trailing newline is kept", func(t *testing.T) {
+		b := NewBufferFromBytes([]byte("a\n\n"))
+		assert.Equal(t, []string{"a", "", ""}, b.GetLines())
+	})
+
+	t.Run("SetTrailingNewline adds and removes the final line break", func(t *testing.T) {
+		b := NewBufferFromBytes([]byte("a"))
+		b.SetTrailingNewline(true)
+		assert.Equal(t, "a\n", b.GetCurrentContent())
+		b.SetTrailingNewline(false)
+		assert.Equal(t, "a", b.GetCurrentContent())
+	})
+}
+
+// TestEditorTrailingNewline tests that editing and undo keep the trailing newline.
+func TestEditorTrailingNewline(t *testing.T) {
+	t.Run("editing the first line keeps the trailing newline", func(t *testing.T) {
+		e := newTestEditor("hello\n")
+		keys(e, 'x')
+		assert.Equal(t, "ello\n", content(e))
+	})
+
+	t.Run("undo restores the original content", func(t *testing.T) {
+		e := newTestEditor("a\n\n")
+		keys(e, 'd', 'd', 'u')
+		assert.Equal(t, "a\n\n", content(e))
+	})
+
+	t.Run("empty content can be edited", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i', 'h', 'i')
+		assert.Equal(t, "hi", content(e))
+	})
+}
+
+// TestBufferRevision tests that every change to the content grows the revision.
+func TestBufferRevision(t *testing.T) {
+	b := NewBufferFromBytes([]byte("a\nb"))
+	changes := []func(){
+		func() { _ = b.InsertRunesAt(0, 1, []rune("x")) },
+		func() { _ = b.DeleteRunesAt(0, 0, 1) },
+		func() { _ = b.ReplaceRange(Position{Row: 0, Col: 0}, Position{Row: 1, Col: 0}, "c") },
+		func() { _ = b.InsertLines(0, []string{"d"}) },
+		func() { _ = b.DeleteLines(0, 0) },
+		func() { b.ReplaceAll(regexp.MustCompile("c"), "e") },
+		func() { b.SetLineEnding(LineEndingCRLF) },
+		func() { b.SetTrailingNewline(true) },
+		func() { b.SetContent([]byte("f")) },
+	}
+	for i, change := range changes {
+		revision := b.Revision()
+		change()
+		assert.Greater(t, b.Revision(), revision, "change %d", i)
+	}
+
+	revision := b.Revision()
+	b.SetCursor(Cursor{Position: Position{Row: 0, Col: 1}})
+	b.SetLineEnding(b.LineEnding())
+	b.SetTrailingNewline(b.HasTrailingNewline())
+	b.ReplaceAll(regexp.MustCompile("z"), "y")
+	b.SaveContent()
+	assert.Equal(t, revision, b.Revision())
+}
+
+// TestBufferIsModified tests tracking changes since the content was saved.
+func TestBufferIsModified(t *testing.T) {
+	b := NewBufferFromBytes([]byte("ab"))
+	assert.False(t, b.IsModified())
+
+	_ = b.InsertRunesAt(0, 2, []rune("c"))
+	assert.True(t, b.IsModified())
+	assert.True(t, b.DiffersFromSaved())
+
+	// Editing the text back doesn't make it unmodified, but comparing it tells
+	_ = b.DeleteRunesAt(0, 2, 1)
+	assert.True(t, b.IsModified())
+	assert.False(t, b.DiffersFromSaved())
+
+	// Setting the saved content back, as undo does, does
+	b.SetContent([]byte("ab"))
+	assert.False(t, b.IsModified())
+
+	_ = b.InsertRunesAt(0, 0, []rune("x"))
+	b.SaveContent()
+	assert.False(t, b.IsModified())
+	b.SetEncoding(EncodingLatin1)
+	assert.True(t, b.IsModified())
+}
+
+// TestBufferOffsets tests converting between positions and offsets.
+func TestBufferOffsets(t *testing.T) {
+	t.Run("every position round-trips", func(t *testing.T) {
+		for _, text := range []string{"", "a\nbc\n\nd", "héllo\n日本語\n👍🏽 x", "one\r\ntwo\r\n"} {
+			b := NewBufferFromBytes([]byte(text))
+			content := b.GetCurrentContent()
+			runes := []rune(content)
+			for row := range b.LineCount() {
+				for col := 0; col <= b.LineRuneCount(row); col++ {
+					pos := Position{Row: row, Col: col}
+					offset := b.OffsetOf(pos)
+					assert.Equal(t, pos, b.PositionOf(offset), "%q at %v", text, pos)
+					runeOffset := b.RuneOffsetOf(pos)
+					assert.Equal(t, pos, b.PositionOfRune(runeOffset), "%q at %v", text, pos)
+					assert.Equal(t, len(string(runes[:runeOffset])), offset, "%q at %v", text, pos)
+				}
+			}
+		}
+	})
+
+	t.Run("offsets count bytes and line endings", func(t *testing.T) {
+		b := NewBufferFromBytes([]byte("é\r\nab"))
+		assert.Equal(t, 2, b.OffsetOf(Position{Row: 0, Col: 1}))
+		assert.Equal(t, 4, b.OffsetOf(Position{Row: 1, Col: 0}))
+		assert.Equal(t, 3, b.RuneOffsetOf(Position{Row: 1, Col: 0}))
+		assert.Equal(t, Position{Row: 0, Col: 0}, b.PositionOf(1), "within é")
+		assert.Equal(t, Position{Row: 0, Col: 1}, b.PositionOf(3), "within the line ending")
+	})
+
+	t.Run("out of range is clamped", func(t *testing.T) {
+		b := NewBufferFromBytes([]byte("ab\ncd"))
+		assert.Equal(t, 2, b.OffsetOf(Position{Row: -1, Col: 5}))
+		assert.Equal(t, 5, b.OffsetOf(Position{Row: 9, Col: 9}))
+		assert.Equal(t, Position{Row: 0, Col: 0}, b.PositionOf(-3))
+		assert.Equal(t, Position{Row: 1, Col: 2}, b.PositionOf(99))
+		assert.Equal(t, Position{Row: 1, Col: 2}, b.PositionOfRune(99))
+	})
+
+	t.Run("the index follows changes", func(t *testing.T) {
+		b := NewBufferFromBytes([]byte("ab\ncd"))
+		assert.Equal(t, 3, b.OffsetOf(Position{Row: 1, Col: 0}))
+		assert.NoError(t, b.InsertRunesAt(0, 0, []rune("xy\n")))
+		assert.Equal(t, 3, b.OffsetOf(Position{Row: 1, Col: 0}))
+		assert.Equal(t, 6, b.OffsetOf(Position{Row: 2, Col: 0}))
+		b.SetLineEnding(LineEndingCRLF)
+		assert.Equal(t, 8, b.OffsetOf(Position{Row: 2, Col: 0}))
+	})
+}
+
+// BenchmarkFind measures searching a buffer of 500,000 lines for text only
+// its last line has.
+func BenchmarkFind(b *testing.B) {
+	buffer := NewBuffer()
+	buffer.SetContent([]byte(manyLines(500_000)))
+	last := buffer.LineCount() - 1
+	start, end := Position{Row: 0, Col: 0}, Position{Row: last, Col: buffer.LineRuneCount(last) - 1}
+
+	for _, bench := range []struct {
+		name    string
+		pattern string
+		start   Position
+		options SearchOptions
+	}{
+		{"forward", "line 499999 ", start, SearchOptions{}},
+		{"backward", "line 0 ", end, SearchOptions{Backwards: true}},
+		{"ignore case", "LINE 499999 ", start, SearchOptions{IgnoreCase: true}},
+		{"whole word", "499999", start, SearchOptions{WholeWord: true}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, found := buffer.Find(bench.pattern, bench.start, bench.options); !found {
+					b.Fatalf("%q not found", bench.pattern)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetCurrentContent measures joining the lines of a large buffer
+// into its content, as saving and every undo step do.
+func BenchmarkGetCurrentContent(b *testing.B) {
+	for _, lines := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("%d lines", lines), func(b *testing.B) {
+			buffer := NewBuffer()
+			buffer.SetContent([]byte(manyLines(lines)))
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = buffer.GetCurrentContent()
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"strings"
+)
+
+// Capability is a set of things the user may do in the editor. The host
+// takes them away with SetCapabilities, e.g. to show a read-only buffer that
+// can still be searched and copied from. Keys of a capability the editor
+// doesn't have do nothing but send a BlockedSignal, and ex commands that
+// need it fail with ErrNotAllowedId. Edits the host makes, such as
+// ReplaceRange and SetContent, are never blocked.
+type Capability uint
+
+const (
+	EditText       Capability = 1 << iota // Change the text: insert mode, operators, paste, undo and redo
+	VisualSelect                          // Select text, in visual and visual line mode or with Shift without Vim mode
+	CommandLine                           // Type ex commands after :
+	Search                                // Search with /, n, N, * and #
+	FileOps                               // Write, reload, rename and delete the file
+	ClipboardWrite                        // Copy text to the clipboard, by yanking, deleting or Ctrl-C
+
+	AllCapabilities = EditText | VisualSelect | CommandLine | Search | FileOps | ClipboardWrite
+)
+
+// capabilityNames are the names String gives the capabilities, in the order
+// of their bits.
+var capabilityNames = []string{"editing", "selecting", "commands", "searching", "file operations", "copying"}
+
+// String returns the names of the capabilities in c, such as "editing" or
+// "searching, copying".
+func (c Capability) String() string {
+	var names []string
+	for i, name := range capabilityNames {
+		if c&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "nothing"
+	}
+	return strings.Join(names, ", ")
+}
+
+// SetCapabilities sets what the user may do, AllCapabilities by default.
+// Leaving a mode that is no longer allowed returns to normal mode.
+func (e *editor) SetCapabilities(capabilities Capability) {
+	e.state.Capabilities = capabilities & AllCapabilities
+
+	if !e.state.VimMode {
+		if !e.HasCapability(VisualSelect) {
+			e.ResetSelection()
+		}
+		return
+	}
+
+	switch {
+	case e.IsInsertMode() && !e.HasCapability(EditText),
+		(e.IsVisualMode() || e.IsVisualLineMode()) && !e.HasCapability(VisualSelect),
+		e.IsCommandMode() && !e.HasCapability(CommandLine),
+		e.IsSearchMode() && !e.HasCapability(Search):
+		e.SetNormalMode()
+	}
+}
+
+// HasCapability reports whether the user may do all of capabilities.
+func (e *editor) HasCapability(capabilities Capability) bool {
+	return e.state.Capabilities&capabilities == capabilities
+}
+
+// allow reports whether the user may do what capability allows, sending a
+// BlockedSignal if not.
+func (e *editor) allow(capability Capability) bool {
+	if e.HasCapability(capability) {
+		return true
+	}
+	e.DispatchSignal(BlockedSignal{capability: capability})
+	return false
+}
+
+// notAllowed returns the error of an ex command that needs capability.
+func notAllowed(capability Capability) *EditorError {
+	return &EditorError{
+		id:  ErrNotAllowedId,
+		err: wrapNotice(ErrNotAllowed, MsgCapabilityBlocked, capability),
+	}
+}
+
+// setCapability adds capability to the set, or takes it away if disable is
+// set, for the Disable methods that predate SetCapabilities.
+func (e *editor) setCapability(capability Capability, disable bool) {
+	if disable {
+		e.SetCapabilities(e.state.Capabilities &^ capability)
+	} else {
+		e.SetCapabilities(e.state.Capabilities | capability)
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blocked returns the capabilities of the BlockedSignals dispatched since
+// the last call, dropping the other signals.
+func blocked(e modeContext) []Capability {
+	var capabilities []Capability
+	for signal := nextSignal(e); signal != nil; signal = nextSignal(e) {
+		if b, ok := signal.(BlockedSignal); ok {
+			capabilities = append(capabilities, b.Value())
+		}
+	}
+	return capabilities
+}
+
+// TestCapabilities tests taking capabilities away with SetCapabilities.
+func TestCapabilities(t *testing.T) {
+	t.Run("without EditText the keys that change the text are blocked", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'x')
+		e.SetCapabilities(AllCapabilities &^ EditText)
+		drainSignals(e)
+
+		for _, key := range []rune{'i', 'a', 'o', 'x', 'D', 'p', 'u'} {
+			keys(e, key)
+			assert.Equal(t, "ne\ntwo", content(e), "%c", key)
+			assert.True(t, e.IsNormalMode(), "%c", key)
+		}
+		keys(e, 'd', 'd', 'V', '>')
+		assert.Equal(t, "ne\ntwo", content(e))
+		assert.Equal(t, slices.Repeat([]Capability{EditText}, 10), blocked(e), "one for each key, d twice")
+
+		keys(e, 'y', 'y', 'j')
+		assert.Equal(t, 1, cursorPos(e).Row, "motions and yanks still work")
+	})
+
+	t.Run("ex commands that edit are undone without EditText", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		e.SetCapabilities(AllCapabilities &^ EditText)
+		drainSignals(e)
+		keys(e, ':', 'd')
+		err := e.HandleKey(KeyEvent{Key: KeyEnter})
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrNotAllowedId, err.ID())
+		assert.Equal(t, "one\ntwo", content(e))
+		assert.Equal(t, []Capability{EditText}, blocked(e))
+	})
+
+	t.Run("the host can still edit", func(t *testing.T) {
+		e := newTestEditor("one")
+		e.SetCapabilities(0)
+		assert.Nil(t, e.ReplaceRange(Position{Row: 0, Col: 0}, Position{Row: 0, Col: 3}, "two"))
+		assert.Equal(t, "two", content(e))
+	})
+
+	t.Run("modes are blocked without their capability", func(t *testing.T) {
+		e := newTestEditor("one two")
+		keys(e, 'v', 'e')
+		escape(e)
+		e.SetCapabilities(AllCapabilities &^ (VisualSelect | CommandLine | Search))
+		drainSignals(e)
+
+		keys(e, 'v', 'V', 'g', 'v', ':', '/', 'n', '*')
+		assert.True(t, e.IsNormalMode())
+		assert.Equal(t, []Capability{VisualSelect, VisualSelect, VisualSelect, CommandLine, Search, Search, Search}, blocked(e))
+	})
+
+	t.Run("taking away the capability of the mode returns to normal mode", func(t *testing.T) {
+		e := newTestEditor("one")
+		keys(e, 'i')
+		e.SetCapabilities(AllCapabilities &^ EditText)
+		assert.True(t, e.IsNormalMode())
+
+		e.SetCapabilities(AllCapabilities)
+		keys(e, 'v')
+		e.SetCapabilities(AllCapabilities &^ VisualSelect)
+		assert.True(t, e.IsNormalMode())
+	})
+
+	t.Run("without FileOps the file commands fail", func(t *testing.T) {
+		e := newTestEditor("one")
+		keys(e, 'x')
+		e.SetCapabilities(AllCapabilities &^ FileOps)
+		drainSignals(e)
+
+		for _, command := range []string{"w", "w! copy.txt", "wq", "x", "e!", "saveas copy.txt", "rename copy.txt", "delete!"} {
+			err := e.ExecuteCommand(command)
+			assert.NotNil(t, err, command)
+			assert.Equal(t, ErrNotAllowedId, err.ID(), command)
+		}
+		assert.Equal(t, "ne", content(e))
+		assert.Len(t, blocked(e), 8)
+	})
+
+	t.Run("without ClipboardWrite yanks don't reach the clipboard", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("one\ntwo")
+		cb.content = "before"
+		e.SetCapabilities(AllCapabilities &^ ClipboardWrite)
+		drainSignals(e)
+
+		keys(e, 'y', 'y', 'V', 'x')
+		assert.Equal(t, "before", cb.content)
+		assert.Equal(t, "two", content(e), "cutting still deletes")
+		assert.Equal(t, []Capability{ClipboardWrite, ClipboardWrite}, blocked(e))
+	})
+
+	t.Run("the Disable methods take capabilities away", func(t *testing.T) {
+		e := newTestEditor("")
+		e.DisableInsertMode(true)
+		e.DisableVisualLineMode(true)
+		assert.False(t, e.HasCapability(EditText))
+		assert.False(t, e.HasCapability(VisualSelect))
+		assert.True(t, e.HasCapability(CommandLine|Search|FileOps|ClipboardWrite))
+
+		e.DisableInsertMode(false)
+		assert.True(t, e.HasCapability(EditText))
+	})
+
+	t.Run("without Vim mode typing and selecting are blocked", func(t *testing.T) {
+		e := newTestEditor("one", withoutVimMode())
+		e.SetCapabilities(AllCapabilities &^ (EditText | VisualSelect))
+		drainSignals(e)
+
+		typeText(e, "ab")
+		press(e, KeyBackspace, 0)
+		press(e, KeyRight, ModShift)
+		assert.Equal(t, "one", content(e))
+		assert.Equal(t, "", selectedText(e))
+		assert.Equal(t, []Capability{EditText, EditText, EditText, VisualSelect}, blocked(e))
+	})
+
+	t.Run("String names the capabilities", func(t *testing.T) {
+		assert.Equal(t, "editing", EditText.String())
+		assert.Equal(t, "searching, copying", (Search | ClipboardWrite).String())
+	})
+}
@@ -1,55 +1,6 @@
 package core
 
-func changeWords(editor Editor, buffer Buffer, count int) *EditorError {
-	cursor := buffer.GetCursor()
-	startPos := cursor.Position
-	tempCursor := cursor
-	availableWidth := editor.GetState().AvailableWidth
-
-	// For 'cw', Vim deletes to the end of the current word (like 'ce').
-	_ = tempCursor.MoveWordToEnd(buffer, count, availableWidth, editor.IsWordChar)
-
-	// In 'cw', we delete INCLUDING the character at the end of the word.
-	// But deleteRange is exclusive of endPos, so we move one right.
-	tempCursor.MoveRight(buffer, 1, availableWidth)
-	exclusiveEndPos := tempCursor.Position
-
-	if startPos != exclusiveEndPos {
-		err := deleteRange(buffer, startPos, exclusiveEndPos)
-		if err == nil {
-			editor.SaveHistory()
-			buffer.SetCursor(cursor)
-			editor.SetInsertMode()
-		}
-		return err
-	}
-
-	return nil
-}
-
-func changeWordsBackward(editor Editor, buffer Buffer, count int) *EditorError {
-	cursor := buffer.GetCursor()
-	endPos := cursor.Position
-	tempCursor := cursor
-	availableWidth := editor.GetState().AvailableWidth
-
-	_ = tempCursor.MoveWordBackward(buffer, count, availableWidth, editor.IsWordChar)
-	startPos := tempCursor.Position
-
-	if startPos != endPos {
-		err := deleteRange(buffer, startPos, endPos)
-		if err == nil {
-			editor.SaveHistory()
-			cursor.Position = startPos
-			buffer.SetCursor(cursor)
-			editor.SetInsertMode()
-		}
-		return err
-	}
-	return nil
-}
-
-func changeToEndOfLine(editor Editor, buffer Buffer) *EditorError {
+func changeToEndOfLine(editor modeContext, buffer Buffer) *EditorError {
 	cursor := buffer.GetCursor()
 	lineLen := buffer.LineRuneCount(cursor.Position.Row)
 	if cursor.Position.Col < lineLen {
@@ -64,7 +15,24 @@ func changeToEndOfLine(editor Editor, buffer Buffer) *EditorError {
 	return nil
 }
 
-func replaceCharUnderCursor(editor Editor, buffer Buffer, ch rune) *EditorError {
+// changeLineRange replaces the lines [startRow, endRow] with one line, empty
+// but for the indent of the first with AutoIndent, and leaves the cursor at
+// its end, for cc and c with linewise motions.
+func changeLineRange(editor modeContext, buffer Buffer, startRow, endRow int) *EditorError {
+	indent := autoIndent(editor.GetState(), buffer.GetLineRunes(startRow))
+	start := Position{Row: startRow, Col: len(indent)}
+	if err := deleteRange(buffer, start, Position{Row: endRow, Col: buffer.LineRuneCount(endRow)}); err != nil {
+		return err
+	}
+
+	cursor := buffer.GetCursor()
+	cursor.Position = start
+	buffer.SetCursor(cursor)
+	editor.SaveHistory()
+	return nil
+}
+
+func replaceCharUnderCursor(editor modeContext, buffer Buffer, ch rune) *EditorError {
 	cursor := buffer.GetCursor()
 	lineLen := buffer.LineRuneCount(cursor.Position.Row)
 
@@ -6,7 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// TestChangeLine tests 'cc' — delete current line and enter insert mode.
+// TestChangeLine tests 'cc' — empty the current line and enter insert mode.
 func TestChangeLine(t *testing.T) {
 	t.Run("single line becomes empty", func(t *testing.T) {
 		e := newTestEditor("hello")
@@ -19,23 +19,23 @@ func TestChangeLine(t *testing.T) {
 	t.Run("first of two lines", func(t *testing.T) {
 		e := newTestEditor("first\nsecond")
 		keys(e, 'c', 'c')
-		assert.Equal(t, "second", content(e))
+		assert.Equal(t, "\nsecond", content(e))
 		assert.Equal(t, Position{0, 0}, cursorPos(e))
 		assertInsertMode(t, e)
 	})
 
-	t.Run("last line is removed, cursor moves to previous line", func(t *testing.T) {
+	t.Run("last line is emptied", func(t *testing.T) {
 		e := newTestEditor("first\nsecond")
 		keys(e, 'j', 'c', 'c')
-		assert.Equal(t, "first", content(e))
-		assert.Equal(t, Position{0, 0}, cursorPos(e))
+		assert.Equal(t, "first\n", content(e))
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
 		assertInsertMode(t, e)
 	})
 
 	t.Run("count: 2cc", func(t *testing.T) {
 		e := newTestEditor("one\ntwo\nthree")
 		keys(e, '2', 'c', 'c')
-		assert.Equal(t, "three", content(e))
+		assert.Equal(t, "\nthree", content(e))
 		assert.Equal(t, Position{0, 0}, cursorPos(e))
 		assertInsertMode(t, e)
 	})
@@ -170,8 +170,8 @@ func TestChangeToEndOfBuffer(t *testing.T) {
 		keys(e, 'j')
 		e.HandleKey(KeyEvent{Rune: 'c'})
 		e.HandleKey(KeyEvent{Rune: 'G'})
-		assert.Equal(t, "one", content(e))
-		assert.Equal(t, Position{0, 0}, cursorPos(e))
+		assert.Equal(t, "one\n", content(e))
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
 		assertInsertMode(t, e)
 	})
 }
@@ -196,3 +196,136 @@ func TestCharSearchWithOperators(t *testing.T) {
 		assertInsertMode(t, e)
 	})
 }
+
+// TestCharSearchCounts tests counts with f/F/t/T and ;/, in every mode.
+// "a.b.c.d.e": a=0 .=1 b=2 .=3 c=4 .=5 d=6 .=7 e=8
+func TestCharSearchCounts(t *testing.T) {
+	t.Run("3f. moves to the third occurrence", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, '3', 'f', '.')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
+
+	t.Run("count larger than occurrences leaves cursor unchanged", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, '9', 'f', '.')
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("count is not reused by the next command", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, '2', 'f', '.', 'l')
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+		assert.Nil(t, e.GetState().PendingCount)
+	})
+
+	t.Run("2; repeats twice", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'f', '.', '2', ';')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
+
+	t.Run("2, reverses twice", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, '$', 'F', '.', '2', ',')
+		assert.Equal(t, Position{0, 7}, cursorPos(e))
+		keys(e, '2', ';')
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+	})
+
+	t.Run("; after t does not get stuck", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 't', '.')
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+		keys(e, ';')
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+		keys(e, ',')
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("cancelled search keeps the last search for ;", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'f', '.')
+		keys(e, 'f')
+		escape(e)
+		keys(e, ';')
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+	})
+
+	t.Run("count before the operator: 2df.", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, '2', 'd', 'f', '.')
+		assert.Equal(t, "c.d.e", content(e))
+	})
+
+	t.Run("count after the operator: d2f.", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'd', '2', 'f', '.')
+		assert.Equal(t, "c.d.e", content(e))
+	})
+
+	t.Run("counts before and after the operator multiply: 2d2f.", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, '2', 'd', '2', 'f', '.')
+		assert.Equal(t, "e", content(e))
+	})
+
+	t.Run("d; deletes to the next match of the last search", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'f', '.', 'd', ';') // from col 1 through the '.' at col 3
+		assert.Equal(t, "ac.d.e", content(e))
+	})
+
+	t.Run("d2; uses the count", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'f', '.', 'd', '2', ';') // from col 1 through the '.' at col 5
+		assert.Equal(t, "ad.e", content(e))
+	})
+
+	t.Run("y, yanks with the reversed search", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("a.b.c.d.e")
+		keys(e, '$', 'F', '.', 'y', ',') // no '.' after col 7
+		assert.Equal(t, "", cb.content)
+		keys(e, 'F', '.', 'y', ',') // from col 5 through the '.' at col 7
+		assert.Equal(t, ".d.", cb.content)
+	})
+
+	t.Run("operator char search is remembered for ;", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'd', 't', '.', ';')
+		assert.Equal(t, ".b.c.d.e", content(e))
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("visual mode: v2f. extends the selection", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'v', '2', 'f', '.')
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+		keys(e, 'd')
+		assert.Equal(t, "c.d.e", content(e))
+	})
+
+	t.Run("visual mode: 2; repeats twice", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'v', 'f', '.', '2', ';')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
+
+	t.Run("visual mode: ; repeats a search made in normal mode", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'f', '.', 'v', ';')
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+	})
+
+	t.Run("visual line mode: 3t. moves the cursor", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, 'V', '3', 't', '.')
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+	})
+
+	t.Run("visual line mode: 2, reverses twice", func(t *testing.T) {
+		e := newTestEditor("a.b.c.d.e")
+		keys(e, '$', 'V', 'F', '.', '2', ',')
+		assert.Equal(t, Position{0, 7}, cursorPos(e))
+	})
+}
@@ -1,14 +1,58 @@
 package core
 
-import "fmt"
-
-// charSearchState holds state for character search motions (f/F/t/T)
+// charSearchState holds a character search motion (f/F/t/T) waiting for its character
 type charSearchState struct {
-	lastChar       rune // The character being searched for
 	searchType     rune // 'f', 'F', 't', or 'T'
+	count          int  // Count typed before the motion
 	waitingForChar bool // True when waiting for character input after f/F/t/T
 }
 
+// charSearch is a completed character search, remembered for ; and ,
+type charSearch struct {
+	char       rune // The character that was searched for
+	searchType rune // 'f', 'F', 't', or 'T'
+}
+
+// begin starts waiting for the character of a searchType motion.
+func (cs *charSearchState) begin(searchType rune, count int) {
+	cs.searchType = searchType
+	cs.count = max(count, 1)
+	cs.waitingForChar = true
+}
+
+// rememberCharSearch records the last character search in the editor state, so
+// ; and , repeat it in every mode.
+func rememberCharSearch(editor modeContext, searchType rune, char rune) {
+	state := editor.GetState()
+	state.lastCharSearch = charSearch{char: char, searchType: searchType}
+	editor.SetState(state)
+}
+
+// lastCharSearchMotion returns the motion ; (reverse=false) or , (reverse=true)
+// repeats, or false if there was no character search yet.
+func lastCharSearchMotion(editor modeContext, reverse bool) (searchType rune, char rune, ok bool) {
+	last := editor.GetState().lastCharSearch
+	if last.searchType == 0 || last.char == 0 {
+		return 0, 0, false
+	}
+
+	searchType = last.searchType
+	if reverse {
+		switch searchType {
+		case 'f':
+			searchType = 'F'
+		case 'F':
+			searchType = 'f'
+		case 't':
+			searchType = 'T'
+		case 'T':
+			searchType = 't'
+		}
+	}
+
+	return searchType, last.char, true
+}
+
 // findCharOnLine searches for a character on the current line.
 // searchType: 'f' (find forward), 'F' (find backward), 't' (till forward), 'T' (till backward)
 // Returns the column position if found, -1 if not found.
@@ -70,45 +114,66 @@ func findCharOnLine(lineRunes []rune, startCol int, char rune, searchType rune,
 	return -1 // Not found
 }
 
+// findCharTarget returns the column a character search moves to, or -1.
+// When repeating t or T with ; or , a match right next to the cursor is
+// skipped, so the repeat doesn't get stuck in front of it.
+func findCharTarget(lineRunes []rune, col int, char rune, searchType rune, count int, repeat bool) int {
+	if repeat {
+		switch searchType {
+		case 't':
+			col++
+		case 'T':
+			col--
+		}
+	}
+
+	return findCharOnLine(lineRunes, col, char, searchType, count)
+}
+
 // performCharSearch executes a character search and moves the cursor.
 // Returns error if character not found.
-func performCharSearch(buffer Buffer, cs *charSearchState, searchType rune, char rune, count int) error {
+func performCharSearch(editor modeContext, buffer Buffer, searchType rune, char rune, count int, repeat bool) error {
+	if !repeat {
+		// Save search state for repeat with ; and ,
+		rememberCharSearch(editor, searchType, char)
+	}
+
 	cursor := buffer.GetCursor()
 	lineRunes := buffer.GetLineRunes(cursor.Position.Row)
 
-	newCol := findCharOnLine(lineRunes, cursor.Position.Col, char, searchType, count)
+	newCol := findCharTarget(lineRunes, cursor.Position.Col, char, searchType, count, repeat)
 
 	if newCol == -1 {
-		return fmt.Errorf("character '%c' not found", char)
+		return newNotice(MsgCharNotFound, char)
 	}
 
 	// Update cursor position
 	cursor.Position.Col = newCol
 	buffer.SetCursor(cursor)
 
-	// Save search state for repeat with ; and ,
-	cs.lastChar = char
-	cs.searchType = searchType
-
 	return nil
 }
 
 // handleCharSearchOperator handles operator + character search motion combinations
-// like df, (delete until comma), yt; (yank till semicolon), etc.
-func handleCharSearchOperator(editor Editor, buffer Buffer, op string, searchType rune, char rune, count int) *EditorError {
+// like df, (delete until comma), yt; (yank till semicolon), d; etc.
+func handleCharSearchOperator(editor modeContext, buffer Buffer, op string, searchType rune, char rune, count int, repeat bool) *EditorError {
+	if !repeat {
+		rememberCharSearch(editor, searchType, char)
+	}
+
 	cursor := buffer.GetCursor()
 	state := editor.GetState()
 	startPos := cursor.Position
 	lineRunes := buffer.GetLineRunes(cursor.Position.Row)
 
 	// Find the target position
-	targetCol := findCharOnLine(lineRunes, cursor.Position.Col, char, searchType, count)
+	targetCol := findCharTarget(lineRunes, cursor.Position.Col, char, searchType, count, repeat)
 
 	if targetCol == -1 {
 		// Character not found
 		return &EditorError{
 			id:  ErrInvalidMotionId,
-			err: fmt.Errorf("character '%c' not found", char),
+			err: newNotice(MsgCharNotFound, char),
 		}
 	}
 
@@ -201,32 +266,21 @@ func handleCharSearchOperator(editor Editor, buffer Buffer, op string, searchTyp
 }
 
 // handleVisualCharSearchInput encapsulates the repeated waitingForChar block used
-// Returns (true, err) if the event was handled, (false, nil) if not.
-func handleVisualCharSearchInput(cs *charSearchState, editor Editor, buffer Buffer, key KeyEvent) (bool, *EditorError) {
-	cs.waitingForChar = false
-	editor.UpdateCommand("")
+// by the visual modes. Returns (true, err) if the event was handled, (false, nil) if not.
+func handleVisualCharSearchInput(cs *charSearchState, editor modeContext, buffer Buffer, key KeyEvent) (bool, *EditorError) {
+	searchType, count := cs.searchType, cs.count
+	*cs = charSearchState{}
 
 	if key.Key == KeyEscape {
-		*cs = charSearchState{}
 		editor.SetNormalMode()
 		return true, nil
 	}
 
 	if key.Rune == 0 {
-		*cs = charSearchState{}
 		return true, nil
 	}
 
-	state := editor.GetState()
-	count := 1
-	if state.PendingCount != nil {
-		count = *state.PendingCount
-		editor.ResetPendingCount()
-	}
-
-	searchErr := performCharSearch(buffer, cs, cs.searchType, key.Rune, count)
-	if searchErr != nil {
-		*cs = charSearchState{}
+	if searchErr := performCharSearch(editor, buffer, searchType, key.Rune, count, false); searchErr != nil {
 		editor.DispatchError(ErrCharNotFoundId, searchErr)
 	}
 
@@ -234,31 +288,14 @@ func handleVisualCharSearchInput(cs *charSearchState, editor Editor, buffer Buff
 }
 
 // repeatCharSearch repeats (reverse=false) or reverses (reverse=true) the last
-// character search stored in cs.
-func repeatCharSearch(cs *charSearchState, editor Editor, buffer Buffer, count int, reverse bool) {
-	if cs.searchType == 0 || cs.lastChar == 0 {
+// character search count times.
+func repeatCharSearch(editor modeContext, buffer Buffer, count int, reverse bool) {
+	searchType, char, ok := lastCharSearchMotion(editor, reverse)
+	if !ok {
 		return
 	}
 
-	searchType := cs.searchType
-	if reverse {
-		switch cs.searchType {
-		case 'f':
-			searchType = 'F'
-		case 'F':
-			searchType = 'f'
-		case 't':
-			searchType = 'T'
-		case 'T':
-			searchType = 't'
-		}
-	}
-
-	originalType := cs.searchType
-	if err := performCharSearch(buffer, cs, searchType, cs.lastChar, count); err != nil {
+	if err := performCharSearch(editor, buffer, searchType, char, count, true); err != nil {
 		editor.DispatchError(ErrCharNotFoundId, err)
 	}
-	if reverse {
-		cs.searchType = originalType
-	}
 }
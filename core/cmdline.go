@@ -0,0 +1,127 @@
+package core
+
+import "unicode"
+
+// cmdline is the text typed on the command line, with a cursor that moves
+// and edits anywhere in it, as in Vim's command-line mode:
+//
+//	Left, Right           move a character
+//	Ctrl-Left, Ctrl-Right move a word, as do Shift-Left and Shift-Right
+//	Home, Ctrl-B          move to the start
+//	End, Ctrl-E           move to the end
+//	Backspace, Ctrl-H     delete the character before the cursor
+//	Delete                delete the character under the cursor
+//	Ctrl-W                delete the word before the cursor
+//	Ctrl-U                delete everything before the cursor
+type cmdline struct {
+	text   []rune
+	cursor int // Index in text of the character the cursor is on
+}
+
+// String returns the text typed.
+func (c *cmdline) String() string {
+	return string(c.text)
+}
+
+// reset empties the line.
+func (c *cmdline) reset() {
+	c.text = nil
+	c.cursor = 0
+}
+
+// handleKey moves the cursor or edits the text for key, reporting whether
+// key is one the line handles.
+func (c *cmdline) handleKey(key KeyEvent) bool {
+	wordMove := key.Modifiers&(ModCtrl|ModShift) != 0
+
+	switch key.Key {
+	case KeyLeft:
+		if wordMove {
+			c.cursor = c.wordStart()
+		} else {
+			c.cursor = max(0, c.cursor-1)
+		}
+	case KeyRight:
+		if wordMove {
+			c.cursor = c.nextWord()
+		} else {
+			c.cursor = min(len(c.text), c.cursor+1)
+		}
+	case KeyHome, KeyCtrlB:
+		c.cursor = 0
+	case KeyEnd, KeyCtrlE:
+		c.cursor = len(c.text)
+	case KeyBackspace, KeyCtrlH:
+		c.delete(max(0, c.cursor-1), c.cursor)
+	case KeyDelete:
+		if c.cursor == len(c.text) {
+			// At the end it deletes backwards, as in Vim
+			c.delete(max(0, c.cursor-1), c.cursor)
+		} else {
+			c.delete(c.cursor, c.cursor+1)
+		}
+	case KeyCtrlW:
+		c.delete(c.wordStart(), c.cursor)
+	case KeyCtrlU:
+		c.delete(0, c.cursor)
+	default:
+		if key.Rune == 0 || key.Modifiers&(ModCtrl|ModAlt) != 0 {
+			return false
+		}
+		c.text = append(c.text[:c.cursor], append([]rune{key.Rune}, c.text[c.cursor:]...)...)
+		c.cursor++
+	}
+	return true
+}
+
+// delete removes the text from start up to end, leaving the cursor at start.
+func (c *cmdline) delete(start, end int) {
+	c.text = append(c.text[:start], c.text[end:]...)
+	c.cursor = start
+}
+
+// wordStart returns the start of the word before the cursor, skipping the
+// spaces before it. A word is a run of letters, digits and underscores, or
+// of other characters that aren't spaces.
+func (c *cmdline) wordStart() int {
+	i := c.cursor
+	for i > 0 && unicode.IsSpace(c.text[i-1]) {
+		i--
+	}
+	if i == 0 {
+		return 0
+	}
+	class := cmdlineCharClass(c.text[i-1])
+	for i > 0 && cmdlineCharClass(c.text[i-1]) == class {
+		i--
+	}
+	return i
+}
+
+// nextWord returns the start of the word after the cursor, or the end.
+func (c *cmdline) nextWord() int {
+	i := c.cursor
+	if i < len(c.text) && !unicode.IsSpace(c.text[i]) {
+		class := cmdlineCharClass(c.text[i])
+		for i < len(c.text) && cmdlineCharClass(c.text[i]) == class {
+			i++
+		}
+	}
+	for i < len(c.text) && unicode.IsSpace(c.text[i]) {
+		i++
+	}
+	return i
+}
+
+// cmdlineCharClass returns 0 for spaces, 1 for word characters and 2 for
+// the others.
+func cmdlineCharClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
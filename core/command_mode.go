@@ -1,63 +1,72 @@
 package core
 
 type commandMode struct {
-	commandBuffer string
+	commandBuffer cmdline
 }
 
 func NewCommandMode() EditorMode  { return &commandMode{} }
 func (m *commandMode) Name() Mode { return CommandMode }
 
-func (m *commandMode) Enter(editor Editor, buffer Buffer) {
+func (m *commandMode) Enter(editor modeContext, buffer Buffer) {
 	editor.DispatchSignal(EnterCommandModeSignal{})
-	m.commandBuffer = ""      // Clear buffer on entry
-	editor.UpdateStatus("")   // Clear status
-	editor.UpdateCommand(":") // Show prompt
+	m.commandBuffer.reset() // Clear buffer on entry
+	editor.UpdateStatus("") // Clear status
+	m.show(editor)          // Show prompt
 }
 
-func (m *commandMode) Exit(editor Editor, buffer Buffer) {
+func (m *commandMode) Exit(editor modeContext, buffer Buffer) {
 	editor.UpdateCommand("") // Clear command line on exit
 }
 
-func (m *commandMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *EditorError {
+func (m *commandMode) HandleKey(editor modeContext, buffer Buffer, key KeyEvent) *EditorError {
 	switch key.Key {
 	case KeyEscape:
 		editor.SetNormalMode()
 		return nil
 
 	case KeyBackspace:
-		if len(m.commandBuffer) > 0 {
-			// Handle UTF-8 correctly (remove last rune, not byte)
-			runes := []rune(m.commandBuffer)
-			runes = runes[:len(runes)-1]
-			m.commandBuffer = string(runes)
-			editor.UpdateCommand(":" + m.commandBuffer) // Update display
-		} else {
+		if len(m.commandBuffer.text) == 0 {
 			// Backspace on empty command line goes back to normal mode
 			editor.SetNormalMode()
+			return nil
 		}
-		return nil
 
 	case KeyEnter:
-		cmd := m.commandBuffer
+		cmd := m.commandBuffer.String()
 		// Exit command mode *before* executing (usually)
 		editor.SetNormalMode()
 		// Execute the command
-		err := editor.ExecuteCommand(cmd)
+		err := editor.executeCommand(cmd)
 		if err != nil {
 			editor.DispatchError(err.id, err.err)
 		}
 		return nil // Error handled by ExecuteCommand/SetMessage
 
-	// Add history navigation (Up/Down arrows) here later
+		// Add history navigation (Up/Down arrows) here later
+	}
 
-	default:
-		if key.Rune != 0 {
-			// Append character to command buffer
-			m.commandBuffer += string(key.Rune)
-			editor.UpdateCommand(":" + m.commandBuffer) // Update display
-			return nil
+	// Move the cursor or edit the line; unknown special keys are ignored
+	if m.commandBuffer.handleKey(key) {
+		m.show(editor)
+	}
+	return nil
+}
+
+// startCommand enters command mode with text already typed, as : does
+// after a count or in visual mode.
+func startCommand(editor modeContext, text string) {
+	editor.SetCommandMode()
+	if mode, ok := editor.GetMode().(*commandMode); ok {
+		for _, r := range text {
+			mode.commandBuffer.handleKey(KeyEvent{Rune: r})
 		}
-		// Ignore unknown special keys
-		return nil
+		mode.show(editor)
 	}
 }
+
+// show updates the command line with the prompt and the text typed, and
+// places the cursor after the prompt.
+func (m *commandMode) show(editor modeContext) {
+	editor.UpdateCommand(":" + m.commandBuffer.String())
+	editor.SetCommandCursor(1 + m.commandBuffer.cursor)
+}
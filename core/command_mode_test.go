@@ -7,7 +7,7 @@ import (
 )
 
 // drainSignals discards all pending signals from the editor's signal channel.
-func drainSignals(e Editor) {
+func drainSignals(e modeContext) {
 	ch := e.GetUpdateSignalChan()
 	for {
 		select {
@@ -19,7 +19,7 @@ func drainSignals(e Editor) {
 }
 
 // nextSignal returns the next signal from the editor's signal channel, or nil if none.
-func nextSignal(e Editor) Signal {
+func nextSignal(e modeContext) Signal {
 	select {
 	case s := <-e.GetUpdateSignalChan():
 		return s
@@ -84,6 +84,100 @@ func TestCommandModeTyping(t *testing.T) {
 	})
 }
 
+// TestCommandLineEditing tests moving the cursor and editing anywhere in
+// the command line.
+func TestCommandLineEditing(t *testing.T) {
+	press := func(e Editor, key KeyCode, mods ...KeyModifiers) {
+		event := KeyEvent{Key: key}
+		for _, mod := range mods {
+			event.Modifiers |= mod
+		}
+		e.HandleKey(event)
+	}
+	line := func(e Editor) (string, int) {
+		state := e.GetState()
+		return state.CommandLine, state.CommandCursor
+	}
+
+	t.Run("the cursor follows the prompt and the text typed", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, ':')
+		assert.Equal(t, 1, e.GetState().CommandCursor)
+		keys(e, 'w', 'q')
+		text, cursor := line(e)
+		assert.Equal(t, ":wq", text)
+		assert.Equal(t, 3, cursor)
+	})
+
+	t.Run("text is inserted at the cursor", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, ':', 's', 'e', 't', ' ', 'n', 'u')
+		press(e, KeyHome)
+		keys(e, '%')
+		press(e, KeyEnd)
+		press(e, KeyLeft)
+		keys(e, 'X')
+		text, cursor := line(e)
+		assert.Equal(t, ":%set nXu", text)
+		assert.Equal(t, 8, cursor)
+	})
+
+	t.Run("Backspace and Delete remove around the cursor", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, ':', 'a', 'b', 'c', 'd')
+		press(e, KeyLeft)
+		press(e, KeyLeft)
+		backspace(e)
+		press(e, KeyDelete)
+		text, cursor := line(e)
+		assert.Equal(t, ":ad", text)
+		assert.Equal(t, 2, cursor)
+	})
+
+	t.Run("Ctrl-W deletes the word before the cursor", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, ':', 'e', ' ', 'a', '/', 'b', 'c', ' ')
+		press(e, KeyCtrlW, ModCtrl)
+		assert.Equal(t, ":e a/", e.GetState().CommandLine)
+		press(e, KeyCtrlW, ModCtrl)
+		assert.Equal(t, ":e a", e.GetState().CommandLine)
+	})
+
+	t.Run("Ctrl-U deletes everything before the cursor", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, ':', 'f', 'o', 'o', 'b', 'a', 'r')
+		press(e, KeyLeft, ModCtrl)
+		press(e, KeyRight)
+		press(e, KeyRight)
+		press(e, KeyRight)
+		press(e, KeyCtrlU, ModCtrl)
+		text, cursor := line(e)
+		assert.Equal(t, ":bar", text)
+		assert.Equal(t, 1, cursor)
+	})
+
+	t.Run("word motions skip spaces and punctuation runs", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, ':', 's', '/', 'a', '/', 'b', '/')
+		press(e, KeyHome)
+		press(e, KeyRight, ModCtrl)
+		assert.Equal(t, 2, e.GetState().CommandCursor)
+		press(e, KeyRight, ModShift)
+		assert.Equal(t, 3, e.GetState().CommandCursor)
+		press(e, KeyLeft, ModCtrl)
+		assert.Equal(t, 2, e.GetState().CommandCursor)
+	})
+
+	t.Run("Enter runs the whole line wherever the cursor is", func(t *testing.T) {
+		e := newTestEditor("a\nb\nc")
+		keys(e, ':', '2')
+		press(e, KeyHome)
+		enter(e)
+		assert.Equal(t, 1, cursorPos(e).Row)
+		assert.Equal(t, -1, e.GetState().CommandCursor)
+	})
+}
+
 // --- :q / :q! ---
 
 // TestCommandModeQuit tests ':q' — quit when buffer is unmodified.
@@ -101,8 +195,10 @@ func TestCommandModeQuit(t *testing.T) {
 		keys(e, ':', 'q')
 		drainSignals(e) // drain EnterCommandModeSignal and any others before enter
 		enter(e)
+		_, ok := nextSignal(e).(ModeChangedSignal) // Back to normal mode first
+		assert.True(t, ok)
 		sig := nextSignal(e)
-		_, ok := sig.(QuitSignal)
+		_, ok = sig.(QuitSignal)
 		assert.True(t, ok)
 	})
 
@@ -115,6 +211,16 @@ func TestCommandModeQuit(t *testing.T) {
 		assert.False(t, e.GetState().Quit)
 	})
 
+	t.Run(":q on modified buffer asks to confirm", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, 'x') // modify buffer
+		drainSignals(e)
+		err := e.ExecuteCommand("q")
+		assert.Equal(t, ErrUnsavedChangesId, err.ID())
+		_, ok := nextSignal(e).(ConfirmQuitSignal)
+		assert.True(t, ok)
+	})
+
 	t.Run(":q! force-quits even with unsaved changes", func(t *testing.T) {
 		e := newTestEditor("hello")
 		keys(e, 'x') // modify buffer
@@ -135,6 +241,8 @@ func TestCommandModeWrite(t *testing.T) {
 		keys(e, ':', 'w')
 		drainSignals(e) // drain EnterCommandModeSignal and any others before enter
 		enter(e)
+		_, ok := nextSignal(e).(ModeChangedSignal) // Back to normal mode first
+		assert.True(t, ok)
 		sig := nextSignal(e)
 		save, ok := sig.(SaveSignal)
 		assert.True(t, ok)
@@ -165,6 +273,178 @@ func TestCommandModeWrite(t *testing.T) {
 	})
 }
 
+// TestCommandModeWriteRange tests writing a range of lines and appending.
+func TestCommandModeWriteRange(t *testing.T) {
+	save := func(t *testing.T, e modeContext, cmd string) SaveSignal {
+		t.Helper()
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand(cmd))
+		signal, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+		return signal
+	}
+
+	t.Run(":2,3w writes the lines to the file", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree\nfour")
+		signal := save(t, e, "2,3w part.txt")
+		path, content := signal.Value()
+		assert.Equal(t, "part.txt", *path)
+		assert.Equal(t, "two\nthree\n", content)
+		start, end, partial := signal.Lines()
+		assert.Equal(t, []any{1, 2, true}, []any{start, end, partial})
+		assert.False(t, signal.Append())
+	})
+
+	t.Run("addresses", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree\nfour")
+		keys(e, 'j')
+		_, content := save(t, e, ".,$w part.txt").Value()
+		assert.Equal(t, "two\nthree\nfour", content)
+		_, content = save(t, e, "3,1w part.txt").Value()
+		assert.Equal(t, "one\ntwo\nthree\n", content)
+		_, content = save(t, e, "4w part.txt").Value()
+		assert.Equal(t, "four", content)
+	})
+
+	t.Run("the whole buffer is not partial", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'x')
+		_, _, partial := save(t, e, "%w").Lines()
+		assert.False(t, partial)
+		assert.False(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("a partial write keeps the buffer modified", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'x')
+		save(t, e, "1w part.txt")
+		assert.True(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run(":w >> appends", func(t *testing.T) {
+		for _, cmd := range []string{"w >> log.txt", "w>>log.txt", "w >>log.txt"} {
+			e := newTestEditor("one\ntwo")
+			keys(e, 'x')
+			signal := save(t, e, cmd)
+			path, content := signal.Value()
+			assert.Equal(t, "log.txt", *path, cmd)
+			assert.Equal(t, "ne\ntwo", content, cmd)
+			assert.True(t, signal.Append(), cmd)
+			assert.True(t, e.GetBuffer().IsModified(), cmd)
+		}
+	})
+
+	t.Run(":1w >> appends the line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		signal := save(t, e, "1w >> log.txt")
+		_, content := signal.Value()
+		assert.Equal(t, "one\n", content)
+		assert.True(t, signal.Append())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		assert.Equal(t, ErrPartialWriteId, e.ExecuteCommand("1,2w").ID())
+		assert.Equal(t, ErrInvalidRangeId, e.ExecuteCommand("1,5w part.txt").ID())
+		assert.Equal(t, ErrInvalidRangeId, e.ExecuteCommand("0w part.txt").ID())
+		assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("1,2q").ID())
+
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("1,2w!"))
+		_, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+	})
+
+	t.Run("a line number still jumps to the line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		assert.Nil(t, e.ExecuteCommand("9"))
+		assert.Equal(t, Position{2, 0}, cursorPos(e))
+	})
+}
+
+// --- :f ---
+
+// TestFileInfo tests showing the file name and position with :f and Ctrl-G.
+func TestFileInfo(t *testing.T) {
+	t.Run("a buffer without a name", func(t *testing.T) {
+		e := newTestEditor("one")
+		assert.Equal(t, `"[No Name]" 1 line --100%--`, e.FileInfo())
+	})
+
+	t.Run(":f shows the name, modified flag and position", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree\nfour\n")
+		e.GetBuffer().SetFileName("notes.txt")
+		keys(e, 'x')
+		assert.Nil(t, e.ExecuteCommand("f"))
+		assert.Equal(t, `"notes.txt" [Modified] 4 lines --25%--`, e.GetState().CommandLine)
+	})
+
+	t.Run(":file with a name renames the buffer", func(t *testing.T) {
+		e := newTestEditor("one")
+		assert.Nil(t, e.ExecuteCommand("file new.txt"))
+		assert.Equal(t, "new.txt", e.GetBuffer().FileName())
+		assert.Equal(t, `"new.txt" 1 line --100%--`, e.GetState().CommandLine)
+	})
+
+	t.Run("Ctrl-G", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		e.GetBuffer().SetFileName("a.go")
+		keys(e, 'j')
+		e.HandleKey(KeyEvent{Key: KeyCtrlG, Modifiers: ModCtrl})
+		assert.Equal(t, `"a.go" 2 lines --100%--`, e.GetState().CommandLine)
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+	})
+
+	t.Run(":w {file} names a buffer without a name", func(t *testing.T) {
+		e := newTestEditor("one")
+		assert.Nil(t, e.ExecuteCommand("w first.txt"))
+		assert.Nil(t, e.ExecuteCommand("w second.txt"))
+		assert.Equal(t, "first.txt", e.GetBuffer().FileName())
+	})
+}
+
+// --- ZZ / ZQ ---
+
+// TestZZZQ tests ZZ, which saves if modified and quits, and ZQ, which quits
+// without saving.
+func TestZZZQ(t *testing.T) {
+	t.Run("ZZ on modified buffer saves and quits", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, 'x') // modify buffer
+		drainSignals(e)
+		keys(e, 'Z')
+		assert.True(t, e.HasPendingKeys())
+		keys(e, 'Z')
+		_, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+		assert.True(t, e.GetState().Quit)
+		assert.False(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("ZZ on unmodified buffer quits without saving", func(t *testing.T) {
+		e := newTestEditor("hello")
+		drainSignals(e)
+		keys(e, 'Z', 'Z')
+		_, ok := nextSignal(e).(QuitSignal)
+		assert.True(t, ok)
+	})
+
+	t.Run("ZQ quits without saving", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, 'x') // modify buffer
+		keys(e, 'Z', 'Q')
+		assert.True(t, e.GetState().Quit)
+		assert.True(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("another key cancels Z", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, 'Z', 'x', 'x')
+		assert.False(t, e.GetState().Quit)
+		assert.Equal(t, "ello", content(e)) // The key after Z is dropped
+	})
+}
+
 // --- :wq ---
 
 // TestCommandModeWriteQuit tests ':wq' — write then quit.
@@ -228,3 +508,114 @@ func TestCommandModeEmptyEnter(t *testing.T) {
 		assert.Equal(t, "hello", content(e))
 	})
 }
+
+// --- Registered commands ---
+
+// TestRegisterCommand tests commands added by the host with RegisterCommand.
+func TestRegisterCommand(t *testing.T) {
+	t.Run("registered command receives its arguments", func(t *testing.T) {
+		e := newTestEditor("hello")
+		var got []string
+		e.RegisterCommand("Greet", func(_ Editor, args []string) *EditorError {
+			got = args
+			return nil
+		})
+		assert.Nil(t, e.ExecuteCommand("Greet a b"))
+		assert.Equal(t, []string{"a", "b"}, got)
+	})
+
+	t.Run("error from handler is returned", func(t *testing.T) {
+		e := newTestEditor("hello")
+		e.RegisterCommand("Fail", func(_ Editor, _ []string) *EditorError {
+			return NewEditorError(ErrInvalidCommandId, ErrInvalidCommand)
+		})
+		err := e.ExecuteCommand("Fail")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrInvalidCommandId, err.ID())
+	})
+
+	t.Run("built-in commands take precedence", func(t *testing.T) {
+		e := newTestEditor("hello")
+		called := false
+		e.RegisterCommand("q!", func(_ Editor, _ []string) *EditorError {
+			called = true
+			return nil
+		})
+		assert.Nil(t, e.ExecuteCommand("q!"))
+		assert.False(t, called)
+		assert.True(t, e.GetState().Quit)
+	})
+
+	t.Run("registering nil removes the command", func(t *testing.T) {
+		e := newTestEditor("hello")
+		e.RegisterCommand("Tmp", func(_ Editor, _ []string) *EditorError { return nil })
+		e.RegisterCommand("Tmp", nil)
+		err := e.ExecuteCommand("Tmp")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrInvalidCommandId, err.ID())
+	})
+}
+
+// TestCommands tests listing the built-in and registered commands.
+func TestCommands(t *testing.T) {
+	e := newTestEditor("")
+	e.RegisterCommand("Zeta", func(Editor, []string) *EditorError { return nil })
+	e.RegisterCommand("Alpha", func(Editor, []string) *EditorError { return nil })
+
+	commands := e.Commands()
+	assert.Equal(t, "w", commands[0].Name)
+	assert.NotEmpty(t, commands[0].Description)
+
+	n := len(commands)
+	assert.Equal(t, CommandInfo{Name: "Alpha"}, commands[n-2])
+	assert.Equal(t, CommandInfo{Name: "Zeta"}, commands[n-1])
+
+	// Every built-in command runs without being an unknown command
+	for _, c := range commands[:n-2] {
+		if c.Args != "" || c.Name == "q" || c.Name == "q!" || c.Name == "wq" || c.Name == "x" || c.Name == "delete" {
+			continue
+		}
+		if err := e.ExecuteCommand(c.Name); err != nil {
+			assert.NotEqual(t, ErrInvalidCommandId, err.ID(), c.Name)
+		}
+	}
+}
+
+// TestSetNumbers tests :set number and relativenumber.
+func TestSetNumbers(t *testing.T) {
+	e := newTestEditor("")
+	assert.True(t, e.GetState().Numbers)
+
+	drainSignals(e)
+	assert.Nil(t, e.ExecuteCommand("set nonu rnu"))
+	assert.False(t, e.GetState().Numbers)
+	assert.True(t, e.GetState().RelativeNumbers)
+	assert.Equal(t, NumbersSignal{enabled: false}, nextSignal(e))
+	assert.Equal(t, RelativeNumbersSignal{enabled: true}, nextSignal(e))
+
+	assert.Nil(t, e.ExecuteCommand("set number"))
+	assert.True(t, e.GetState().Numbers)
+
+	err := e.ExecuteCommand("set nornu bogus")
+	assert.Equal(t, ErrInvalidCommandId, err.ID())
+	assert.False(t, e.GetState().RelativeNumbers)
+	assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("set").ID())
+}
+
+// TestSetWrapOptions tests :set numberwidth, showbreak and breakindent.
+func TestSetWrapOptions(t *testing.T) {
+	e := newTestEditor("")
+
+	assert.Nil(t, e.ExecuteCommand("set nuw=7 bri"))
+	assert.Equal(t, 7, e.GetState().NumberWidth)
+	assert.True(t, e.GetState().BreakIndent)
+	assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("set numberwidth=0").ID())
+	assert.Equal(t, 7, e.GetState().NumberWidth)
+
+	assert.Nil(t, e.ExecuteCommand(`set showbreak=>>\ \  nobri`))
+	assert.Equal(t, ">>  ", e.GetState().ShowBreak)
+	assert.False(t, e.GetState().BreakIndent)
+
+	assert.Nil(t, e.ExecuteCommand("set sbr="))
+	assert.Equal(t, "", e.GetState().ShowBreak)
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"maps"
+	"slices"
+)
+
+// CommandInfo describes a command that can be run from command mode.
+type CommandInfo struct {
+	Name        string // Typed after ':'
+	Args        string // Arguments the command takes, e.g. "{file}"; empty if none
+	Description string // Empty for registered commands
+}
+
+// builtinCommands lists the built-in commands shown to users, such as in a
+// command palette. Abbreviations and line numbers are left out.
+var builtinCommands = []CommandInfo{
+	{Name: "w", Description: "Save the file"},
+	{Name: "w!", Description: "Save even if invalid UTF-8 was replaced"},
+	{Name: "w", Args: "{file}", Description: "Save to another file"},
+	{Name: "w >>", Args: "{file}", Description: "Append to a file"},
+	{Name: "q", Description: "Quit"},
+	{Name: "q!", Description: "Quit without saving"},
+	{Name: "wq", Description: "Save and quit"},
+	{Name: "x", Description: "Save if modified and quit"},
+	{Name: "e!", Description: "Reload the file, dropping unsaved changes"},
+	{Name: "f", Description: "Show the file name and position"},
+	{Name: "d", Args: "{count}", Description: "Delete lines"},
+	{Name: "y", Args: "{count}", Description: "Copy lines"},
+	{Name: "s", Args: "/{pattern}/{text}/", Description: "Replace text on the line or a range of lines"},
+	{Name: "g", Args: "/{pattern}/{command}", Description: "Run a command on the lines that match"},
+	{Name: "format", Description: "Format the file with the formatter of its language"},
+	{Name: "set rnu", Description: "Show relative line numbers"},
+	{Name: "set nornu", Description: "Show absolute line numbers"},
+	{Name: "set nonu", Description: "Count line numbers only from the cursor line"},
+	{Name: "set numberwidth=", Args: "{n}", Description: "Set the least width of the line numbers"},
+	{Name: "set showbreak=", Args: "{text}", Description: "Show text where wrapped lines continue"},
+	{Name: "set bri", Description: "Indent wrapped lines as they are"},
+	{Name: "set nobri", Description: "Start wrapped lines at the left edge"},
+	{Name: "set textwidth=", Args: "{n}", Description: "Set the width gq reflows text to and typing breaks lines at"},
+	{Name: "set fo=c", Description: "Break only comments at the text width while typing"},
+	{Name: "set tabstop=", Args: "{n}", Description: "Set the columns between tab stops"},
+	{Name: "set et", Description: "Indent with spaces rather than tabs"},
+	{Name: "set ai", Description: "Start new lines with the indent of the one before"},
+	{Name: "set ff=unix", Description: "Save with LF line endings"},
+	{Name: "set ff=dos", Description: "Save with CRLF line endings"},
+	{Name: "saveas", Args: "{file}", Description: "Save to another file and edit it"},
+	{Name: "rename", Args: "{file}", Description: "Rename the file, once confirmed"},
+	{Name: "delete", Description: "Delete the file, once confirmed"},
+	{Name: "cn", Description: "Jump to the next quickfix item"},
+	{Name: "cp", Description: "Jump to the previous quickfix item"},
+	{Name: "cfirst", Description: "Jump to the first quickfix item"},
+	{Name: "clast", Description: "Jump to the last quickfix item"},
+	{Name: "copen", Description: "Show the quickfix list"},
+	{Name: "cclose", Description: "Hide the quickfix list"},
+	{Name: "TableAlign", Description: "Align the columns of the table"},
+	{Name: "TableAddRow", Description: "Add a row under the cursor"},
+	{Name: "TableDeleteRow", Description: "Delete the row under the cursor"},
+	{Name: "TableAddColumn", Description: "Add a column after the cursor"},
+	{Name: "TableDeleteColumn", Description: "Delete the column under the cursor"},
+}
+
+// Commands returns the built-in commands followed by the registered ones in
+// name order.
+func (e *editor) Commands() []CommandInfo {
+	commands := slices.Clone(builtinCommands)
+	for _, name := range slices.Sorted(maps.Keys(e.commands)) {
+		commands = append(commands, CommandInfo{Name: name})
+	}
+	return commands
+}
@@ -1,13 +1,14 @@
 package core
 
 import (
+	"strings"
 	"unicode"
 )
 
 // Cursor represents the current position for editing operations
 type Cursor struct {
 	Position  Position // Current position (row, column)
-	Preferred int      // Preferred column for vertical movement (sticky column)
+	Preferred int      // Screen column vertical movement keeps to, in cells from the start of the display line (sticky column)
 }
 
 // --- Cursor Movement ---
@@ -33,12 +34,13 @@ func (c *Cursor) MoveLeft(buffer Buffer, count int, availableWidth int) error {
 	if availableWidth <= 0 { // Avoid division by zero or nonsensical behavior
 		availableWidth = 1 // Fallback to minimal width
 	}
+	line := buffer.GetLineRunes(c.Position.Row)
 	for range count {
 		if c.Position.Col <= 0 {
 			// Already at the logical start of the line
 			return ErrStartOfLine
 		}
-		c.Position.Col--
+		c.Position.Col = prevGraphemeCol(line, c.Position.Col)
 	}
 	c.clampCol(buffer) // Clamp just in case (shouldn't be needed after bounds check)
 
@@ -55,13 +57,13 @@ func (c *Cursor) MoveRight(buffer Buffer, count int, availableWidth int) error {
 	if availableWidth <= 0 { // Avoid division by zero or nonsensical behavior
 		availableWidth = 1 // Fallback to minimal width
 	}
-	lineLen := buffer.LineRuneCount(c.Position.Row)
+	line := buffer.GetLineRunes(c.Position.Row)
 	for range count {
 		// Allow moving *to* the position *after* the last logical char
-		if c.Position.Col >= lineLen {
+		if c.Position.Col >= len(line) {
 			return ErrEndOfLine
 		}
-		c.Position.Col++
+		c.Position.Col = nextGraphemeCol(line, c.Position.Col)
 	}
 	c.clampCol(buffer) // Clamp just in case (e.g., if lineLen was 0)
 
@@ -301,6 +303,12 @@ func isWhiteSpace(r rune) bool {
 	return r == ' ' || r == '\t'
 }
 
+// isWORDChar reports whether r is part of a WORD, a run of non-blank
+// characters, as moved over by W, B and E.
+func isWORDChar(r rune) bool {
+	return !isWhiteSpace(r)
+}
+
 // MoveWordForward moves the cursor forward by count words (Vim 'w' behavior)
 func (c *Cursor) MoveWordForward(buffer Buffer, count int, availableWidth int, isWordChar func(rune) bool) error {
 	if availableWidth <= 0 {
@@ -443,6 +451,68 @@ endMove:
 	return nil
 }
 
+// MoveWordEndBackward moves the cursor back to the end of the previous word
+// count times (Vim 'ge' behavior). An empty line counts as a word, and the
+// start of the buffer stops it.
+func (c *Cursor) MoveWordEndBackward(buffer Buffer, count int, availableWidth int, isWordChar func(rune) bool) error {
+	if availableWidth <= 0 {
+		availableWidth = 1
+	}
+	class := func(r rune) int {
+		switch {
+		case isWhiteSpace(r):
+			return 0
+		case isWordChar(r):
+			return 1
+		}
+		return 2
+	}
+
+	row, col := c.Position.Row, c.Position.Col
+	if row == 0 && col == 0 {
+		return ErrStartOfBuffer
+	}
+
+	for range count {
+		line := buffer.GetLineRunes(row)
+		word := 0 // Class of the word being left, 0 once past it
+		if col < len(line) {
+			word = class(line[col])
+		}
+
+	searchLoop:
+		for {
+			if col > 0 {
+				col = prevGraphemeCol(line, col)
+			} else {
+				if row == 0 {
+					break searchLoop // The start of the buffer ends the search
+				}
+				// The line break ends the word; an empty line is one
+				row--
+				line = buffer.GetLineRunes(row)
+				if len(line) == 0 {
+					break searchLoop
+				}
+				col, word = len(line)-1, 0
+			}
+
+			switch cls := class(line[col]); {
+			case cls == word:
+				// Still in the word being left, or in whitespace
+			case cls == 0:
+				word = 0
+			default:
+				break searchLoop
+			}
+		}
+	}
+
+	c.Position = Position{Row: row, Col: col}
+	c.Preferred = c.Position.Col % availableWidth
+	return nil
+}
+
 // MoveWordBackward moves the cursor backward by count words (Vim 'b' behavior)
 func (c *Cursor) MoveWordBackward(buffer Buffer, count int, availableWidth int, isWordChar func(rune) bool) error {
 	if availableWidth <= 0 {
@@ -523,8 +593,10 @@ func (c *Cursor) MoveWordBackward(buffer Buffer, count int, availableWidth int,
 	return nil
 }
 
+// MoveBlockBackward moves to the previous blank line ({), skipping the blank
+// lines the cursor is on first. Without one it moves to the start of the buffer.
 func (c *Cursor) MoveBlockBackward(buffer Buffer, count int) error {
-	if c.Position.Row <= 0 {
+	if c.Position.Row <= 0 && c.Position.Col <= 0 {
 		return ErrStartOfBuffer
 	}
 
@@ -557,18 +629,23 @@ func (c *Cursor) MoveBlockBackward(buffer Buffer, count int) error {
 	return nil
 }
 
+// MoveBlockForward moves to the next blank line (}), skipping the blank lines
+// the cursor is on first. Without one it moves to the last character of the
+// buffer.
 func (c *Cursor) MoveBlockForward(buffer Buffer, count int) error {
-	if c.Position.Row >= buffer.LineCount()-1 {
+	lastRow := buffer.LineCount() - 1
+	lastCol := max(buffer.LineRuneCount(lastRow)-1, 0)
+	if c.Position.Row >= lastRow && c.Position.Col >= lastCol {
 		return ErrEndOfBuffer
 	}
 
 	for range count {
-		if c.Position.Row >= buffer.LineCount()-1 {
+		if c.Position.Row >= lastRow {
 			break
 		}
 
 		// If on a blank line, skip past it first
-		for c.Position.Row < buffer.LineCount()-1 {
+		for c.Position.Row < lastRow {
 			line := buffer.GetLineRunes(c.Position.Row)
 			if len(line) != 0 {
 				break
@@ -577,7 +654,7 @@ func (c *Cursor) MoveBlockForward(buffer Buffer, count int) error {
 		}
 
 		// Skip non-blank lines forward until we hit a blank line or the end of buffer
-		for c.Position.Row < buffer.LineCount()-1 {
+		for c.Position.Row < lastRow {
 			line := buffer.GetLineRunes(c.Position.Row)
 			if len(line) == 0 {
 				break
@@ -587,10 +664,130 @@ func (c *Cursor) MoveBlockForward(buffer Buffer, count int) error {
 	}
 
 	c.Position.Col = 0
+	if c.Position.Row == lastRow {
+		c.Position.Col = lastCol
+	}
 
 	return nil
 }
 
+// MoveSentenceForward moves to the start of the next sentence ()). Without one
+// it moves to the last character of the buffer.
+func (c *Cursor) MoveSentenceForward(buffer Buffer, count int) error {
+	pos := c.Position
+	for range count {
+		next, ok := nextTextPosition(buffer, pos)
+		for ok && !isSentenceStart(buffer, next) {
+			next, ok = nextTextPosition(buffer, next)
+		}
+		if !ok {
+			lastRow := buffer.LineCount() - 1
+			pos = Position{Row: lastRow, Col: max(buffer.LineRuneCount(lastRow)-1, 0)}
+			break
+		}
+		pos = next
+	}
+
+	if pos == c.Position {
+		return ErrEndOfBuffer
+	}
+	c.Position = pos
+	return nil
+}
+
+// MoveSentenceBackward moves to the start of the sentence the cursor is in, or
+// of the previous one if it is already at the start (().
+func (c *Cursor) MoveSentenceBackward(buffer Buffer, count int) error {
+	pos := c.Position
+	for range count {
+		prev, ok := prevTextPosition(buffer, pos)
+		for ok && !isSentenceStart(buffer, prev) {
+			prev, ok = prevTextPosition(buffer, prev)
+		}
+		if !ok {
+			pos = Position{}
+			break
+		}
+		pos = prev
+	}
+
+	if pos == c.Position {
+		return ErrStartOfBuffer
+	}
+	c.Position = pos
+	return nil
+}
+
+// nextTextPosition returns the position after pos, moving to the next line at
+// the end of a line. An empty line has a single position at column 0.
+func nextTextPosition(buffer Buffer, pos Position) (Position, bool) {
+	if pos.Col+1 < buffer.LineRuneCount(pos.Row) {
+		return Position{Row: pos.Row, Col: pos.Col + 1}, true
+	}
+	if pos.Row+1 >= buffer.LineCount() {
+		return pos, false
+	}
+	return Position{Row: pos.Row + 1}, true
+}
+
+// prevTextPosition returns the position before pos, the inverse of nextTextPosition.
+func prevTextPosition(buffer Buffer, pos Position) (Position, bool) {
+	if pos.Col > 0 {
+		return Position{Row: pos.Row, Col: min(pos.Col, buffer.LineRuneCount(pos.Row)) - 1}, true
+	}
+	if pos.Row == 0 {
+		return pos, false
+	}
+	return Position{Row: pos.Row - 1, Col: max(buffer.LineRuneCount(pos.Row-1)-1, 0)}, true
+}
+
+// isSentenceStart reports whether a sentence starts at pos. As in Vim, a
+// sentence ends at '.', '!' or '?' followed by the end of the line or
+// whitespace, with any closing ')', ']' or quotes in between. The first of a
+// run of empty lines is a sentence of its own, and a paragraph's first
+// character starts a sentence.
+func isSentenceStart(buffer Buffer, pos Position) bool {
+	line := buffer.GetLineRunes(pos.Row)
+	if len(line) == 0 {
+		return pos.Row == 0 || buffer.LineRuneCount(pos.Row-1) != 0
+	}
+	if pos.Col >= len(line) || isWhiteSpace(line[pos.Col]) {
+		return false
+	}
+
+	// Walk back over the whitespace and line breaks before pos
+	row, col := pos.Row, pos.Col-1
+	sawSpace := false
+	for {
+		if col < 0 {
+			if row == 0 {
+				return true // Start of the buffer
+			}
+			row--
+			line = buffer.GetLineRunes(row)
+			if len(line) == 0 {
+				return true // Start of a paragraph
+			}
+			col = len(line) - 1
+			sawSpace = true
+			continue
+		}
+		if !isWhiteSpace(line[col]) {
+			break
+		}
+		sawSpace = true
+		col--
+	}
+	if !sawSpace {
+		return false
+	}
+
+	for col >= 0 && strings.ContainsRune(")]\"'", line[col]) {
+		col--
+	}
+	return col >= 0 && strings.ContainsRune(".!?", line[col])
+}
+
 func (c *Cursor) ScrollUp(buffer Buffer, viewportHeight, availableWidth int) error {
 	return c.MoveUp(buffer, max(viewportHeight/2, 1), availableWidth)
 }
@@ -1,7 +1,6 @@
 package core
 
 import (
-	"errors"
 	"strings"
 )
 
@@ -54,11 +53,11 @@ func deleteRange(buffer Buffer, start, end Position) *EditorError {
 
 // deleteLineRange deletes an inclusive range of lines [startRow, endRow].
 // It handles single-line buffers correctly and returns content in top-to-bottom order.
-func deleteLineRange(editor Editor, buffer Buffer, startRow, endRow int) (string, *EditorError) {
+func deleteLineRange(editor modeContext, buffer Buffer, startRow, endRow int) (string, *EditorError) {
 	if startRow < 0 || endRow >= buffer.LineCount() || startRow > endRow {
 		return "", &EditorError{
 			id:  ErrInvalidPositionId,
-			err: errors.New("invalid line range for deletion"),
+			err: newNotice(MsgInvalidLineRange),
 		}
 	}
 
@@ -122,82 +121,22 @@ func deleteLineRange(editor Editor, buffer Buffer, startRow, endRow int) (string
 	return deletedContent.String(), firstErr
 }
 
-// deleteLines deletes `count` lines starting from the cursor row.
-func deleteLines(editor Editor, buffer Buffer, count int) (string, *EditorError) {
-	cursor := buffer.GetCursor()
-	startRow := cursor.Position.Row
-	endRow := startRow + count - 1
-	if endRow >= buffer.LineCount() {
-		endRow = buffer.LineCount() - 1
-	}
-	return deleteLineRange(editor, buffer, startRow, endRow)
-}
-
-func deleteWords(editor Editor, buffer Buffer, count int) (err *EditorError) {
-	cursor := buffer.GetCursor()
-	startPos := cursor.Position
-	tempCursor := cursor
-	availableWidth := editor.GetState().AvailableWidth
-
-	_ = tempCursor.MoveWordForward(buffer, count, availableWidth, editor.IsWordChar)
-	endPos := tempCursor.Position
-
-	if startPos != endPos {
-		err = deleteRange(buffer, startPos, endPos)
-		if err == nil {
-			editor.SaveHistory()
-			buffer.SetCursor(cursor) // Cursor stays at startPos
-		}
-	}
-
-	return err
-}
-
-func deleteWordsBackward(editor Editor, buffer Buffer, count int) *EditorError {
-	cursor := buffer.GetCursor()
-	originalPos := cursor.Position
-	tempCursor := cursor
-	availableWidth := editor.GetState().AvailableWidth
-
-	_ = tempCursor.MoveWordBackward(buffer, count, availableWidth, editor.IsWordChar)
-	startPos := tempCursor.Position
-
-	if startPos != originalPos {
-		err := deleteRange(buffer, startPos, originalPos)
-		if err == nil {
-			editor.SaveHistory()
-			cursor.Position = startPos // Move cursor to the beginning of the deleted range
-			buffer.SetCursor(cursor)
-		}
-		return err
+// deleteCharRange deletes a characterwise range and leaves the cursor at its start.
+func deleteCharRange(editor modeContext, buffer Buffer, r textRange) (string, *EditorError) {
+	deletedContent := textInRange(buffer, r)
+	if err := deleteRange(buffer, r.start, r.end); err != nil {
+		return "", err
 	}
-	return nil
-}
 
-func deleteWordToEnd(editor Editor, buffer Buffer, count int) *EditorError {
 	cursor := buffer.GetCursor()
-	startPos := cursor.Position
-	tempCursor := cursor
-	availableWidth := editor.GetState().AvailableWidth
-
-	_ = tempCursor.MoveWordToEnd(buffer, count, availableWidth, editor.IsWordChar)
-	// MoveWordToEnd lands on the last char of the word (inclusive), so move one right
-	// to get the exclusive end for deleteRange.
-	tempCursor.MoveRight(buffer, 1, availableWidth)
-	exclusiveEndPos := tempCursor.Position
+	cursor.Position = r.start
+	buffer.SetCursor(cursor)
+	editor.SaveHistory()
 
-	if startPos != exclusiveEndPos {
-		err := deleteRange(buffer, startPos, exclusiveEndPos)
-		if err == nil {
-			editor.SaveHistory()
-			buffer.SetCursor(cursor)
-		}
-		return err
-	}
-	return nil
+	return deletedContent, nil
 }
 
-func deleteToEndOfLine(editor Editor, buffer Buffer) (string, *EditorError) {
+func deleteToEndOfLine(editor modeContext, buffer Buffer) (string, *EditorError) {
 	cursor := buffer.GetCursor()
 	lineLen := buffer.LineRuneCount(cursor.Position.Row)
 	var deletedContent string
@@ -224,7 +163,8 @@ func deleteVisualSelection(buffer Buffer, startPos, endPos Position) (string, Po
 
 	var deletedContent string
 	startSel, endSel := NormalizeSelection(startPos, endPos)
-	finalCursorPos := startSel // Default final position is the start of selection
+	endSel.Col = graphemeEndCol(buffer.GetLineRunes(endSel.Row), endSel.Col) // Include the whole last cluster
+	finalCursorPos := startSel                                               // Default final position is the start of selection
 
 	// Simple case: Single line selection
 	if startSel.Row == endSel.Row {
@@ -233,6 +173,10 @@ func deleteVisualSelection(buffer Buffer, startPos, endPos Position) (string, Po
 		if count > 0 {
 			endCol := min(startSel.Col+count, len(lineRunes))
 			deletedContent = string(lineRunes[startSel.Col:endCol])
+			if startSel.Col+count > len(lineRunes) && startSel.Row+1 < buffer.LineCount() {
+				// The selection ends on the line break of an empty line
+				deletedContent += "\n"
+			}
 			err = buffer.DeleteRunesAt(startSel.Row, startSel.Col, count)
 		}
 	} else {
@@ -259,6 +203,10 @@ func deleteVisualSelection(buffer Buffer, startPos, endPos Position) (string, Po
 			contentBuilder.WriteString(string(endLineRunes[:endSel.Col+1]))
 		} else {
 			contentBuilder.WriteString(string(endLineRunes))
+			if endSel.Row+1 < buffer.LineCount() {
+				// The selection ends on the line break of an empty line
+				contentBuilder.WriteString("\n")
+			}
 		}
 		deletedContent = contentBuilder.String()
 
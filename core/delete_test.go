@@ -196,6 +196,60 @@ func TestDeleteAroundWord(t *testing.T) {
 	})
 }
 
+// TestOperatorWORD tests operators with the WORD motions and text objects.
+func TestOperatorWORD(t *testing.T) {
+	t.Run("dW", func(t *testing.T) {
+		e := newTestEditor("foo.bar baz")
+		keys(e, 'd', 'W')
+		assert.Equal(t, "baz", content(e))
+	})
+
+	t.Run("dE", func(t *testing.T) {
+		e := newTestEditor("foo.bar baz")
+		keys(e, 'd', 'E')
+		assert.Equal(t, " baz", content(e))
+	})
+
+	t.Run("dB", func(t *testing.T) {
+		e := newTestEditor("x foo.bar baz")
+		keys(e, 'f', 'b', 'd', 'B')
+		assert.Equal(t, "x bar baz", content(e))
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("cW changes to the end of the WORD", func(t *testing.T) {
+		e := newTestEditor("foo.bar baz")
+		keys(e, 'c', 'W')
+		assertInsertMode(t, e)
+		assert.Equal(t, " baz", content(e))
+	})
+
+	t.Run("diW", func(t *testing.T) {
+		e := newTestEditor("x foo.bar(baz) y")
+		keys(e, 'f', 'b', 'd', 'i', 'W')
+		assert.Equal(t, "x  y", content(e))
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("daW", func(t *testing.T) {
+		e := newTestEditor("x foo.bar(baz) y")
+		keys(e, 'f', 'b', 'd', 'a', 'W')
+		assert.Equal(t, "x y", content(e))
+	})
+
+	t.Run("yiW", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("x foo.bar y")
+		keys(e, 'f', 'b', 'y', 'i', 'W')
+		assert.Equal(t, "foo.bar", cb.content)
+	})
+
+	t.Run("viW", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("x foo.bar y")
+		keys(e, 'f', 'b', 'v', 'i', 'W', 'y')
+		assert.Equal(t, "foo.bar", cb.content)
+	})
+}
+
 // TestDeleteInsideParagraph tests 'dip' — delete inside paragraph (contiguous non-blank lines).
 func TestDeleteInsideParagraph(t *testing.T) {
 	t.Run("single paragraph — removes all lines leaving the blank separator", func(t *testing.T) {
@@ -355,3 +409,21 @@ func TestUndoDeleteLine(t *testing.T) {
 		assert.Equal(t, Position{0, 0}, cursorPos(e))
 	})
 }
+
+// BenchmarkDeleteLines measures deleting lines of a buffer of 10,000 lines
+// with dd, one and all of them.
+func BenchmarkDeleteLines(b *testing.B) {
+	text := manyLines(10_000)
+	for _, bench := range []string{"dd", "10000dd"} {
+		b.Run(bench, func(b *testing.B) {
+			e := newTestEditor(text)
+			b.ReportAllocs()
+			for b.Loop() {
+				b.StopTimer()
+				e.SetContent([]byte(text))
+				b.StartTimer()
+				keys(e, []rune(bench)...)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package core
+
+// HunkKind describes what a hunk does to the old text
+type HunkKind int
+
+const (
+	HunkChange HunkKind = iota // Lines replaced by other lines
+	HunkAdd                    // Lines only present in the new text
+	HunkDelete                 // Lines only present in the old text
+)
+
+// maxDiffCells bounds the size of the LCS table. When the differing middle of two
+// texts is larger than this, it is reported as a single change hunk instead.
+const maxDiffCells = 4_000_000
+
+// Hunk is a contiguous block of lines that differs between two texts.
+// Rows are zero-based. A hunk with no lines on one side is anchored before
+// OldStart (or NewStart) on that side.
+type Hunk struct {
+	OldStart int // First row of the hunk in the old text
+	OldLines int // Number of old lines covered by the hunk
+	NewStart int // First row of the hunk in the new text
+	NewLines int // Number of new lines covered by the hunk
+}
+
+// Kind reports whether the hunk adds, deletes or changes lines.
+func (h Hunk) Kind() HunkKind {
+	switch {
+	case h.OldLines == 0:
+		return HunkAdd
+	case h.NewLines == 0:
+		return HunkDelete
+	default:
+		return HunkChange
+	}
+}
+
+// OldEnd returns the row just past the hunk in the old text.
+func (h Hunk) OldEnd() int {
+	return h.OldStart + h.OldLines
+}
+
+// NewEnd returns the row just past the hunk in the new text.
+func (h Hunk) NewEnd() int {
+	return h.NewStart + h.NewLines
+}
+
+// DiffLines computes the hunks that turn old into new.
+// Hunks are returned in order and never overlap.
+func DiffLines(old, new []string) []Hunk {
+	// Strip common prefix and suffix, they never belong to a hunk
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix &&
+		old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	a := old[prefix : len(old)-suffix]
+	b := new[prefix : len(new)-suffix]
+
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	if len(a) == 0 || len(b) == 0 || len(a)*len(b) > maxDiffCells {
+		return []Hunk{{OldStart: prefix, OldLines: len(a), NewStart: prefix, NewLines: len(b)}}
+	}
+
+	// lcs[i][j] holds the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var hunks []Hunk
+	var current *Hunk
+
+	flush := func() {
+		if current != nil {
+			hunks = append(hunks, *current)
+			current = nil
+		}
+	}
+
+	open := func(i, j int) {
+		if current == nil {
+			current = &Hunk{OldStart: prefix + i, NewStart: prefix + j}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			open(i, j)
+			current.NewLines++
+			j++
+		default:
+			open(i, j)
+			current.OldLines++
+			i++
+		}
+	}
+	flush()
+
+	return hunks
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiffLines tests line-level hunk computation.
+func TestDiffLines(t *testing.T) {
+	t.Run("identical texts produce no hunks", func(t *testing.T) {
+		assert.Empty(t, DiffLines([]string{"a", "b"}, []string{"a", "b"}))
+	})
+
+	t.Run("added lines", func(t *testing.T) {
+		hunks := DiffLines([]string{"a", "c"}, []string{"a", "b", "c"})
+		assert.Equal(t, []Hunk{{OldStart: 1, OldLines: 0, NewStart: 1, NewLines: 1}}, hunks)
+		assert.Equal(t, HunkAdd, hunks[0].Kind())
+	})
+
+	t.Run("deleted lines", func(t *testing.T) {
+		hunks := DiffLines([]string{"a", "b", "c"}, []string{"a", "c"})
+		assert.Equal(t, []Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 0}}, hunks)
+		assert.Equal(t, HunkDelete, hunks[0].Kind())
+	})
+
+	t.Run("changed lines", func(t *testing.T) {
+		hunks := DiffLines([]string{"a", "b", "c"}, []string{"a", "x", "y", "c"})
+		assert.Equal(t, []Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 2}}, hunks)
+		assert.Equal(t, HunkChange, hunks[0].Kind())
+	})
+
+	t.Run("multiple hunks", func(t *testing.T) {
+		old := []string{"a", "b", "c", "d", "e"}
+		new := []string{"a", "B", "c", "d", "e", "f"}
+		hunks := DiffLines(old, new)
+		assert.Equal(t, []Hunk{
+			{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1},
+			{OldStart: 5, OldLines: 0, NewStart: 5, NewLines: 1},
+		}, hunks)
+	})
+
+	t.Run("empty old text", func(t *testing.T) {
+		hunks := DiffLines(nil, []string{"a", "b"})
+		assert.Equal(t, []Hunk{{OldStart: 0, OldLines: 0, NewStart: 0, NewLines: 2}}, hunks)
+	})
+
+	t.Run("applying hunks reproduces the new text", func(t *testing.T) {
+		old := []string{"one", "two", "three", "four", "five", "six"}
+		new := []string{"zero", "one", "three", "4", "five", "six", "seven"}
+
+		var result []string
+		row := 0
+		for _, h := range DiffLines(old, new) {
+			result = append(result, old[row:h.OldStart]...)
+			result = append(result, new[h.NewStart:h.NewEnd()]...)
+			row = h.OldEnd()
+		}
+		result = append(result, old[row:]...)
+
+		assert.Equal(t, new, result)
+	})
+}
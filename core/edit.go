@@ -0,0 +1,81 @@
+package core
+
+import "regexp"
+
+// ReplaceRange replaces the text from start up to but not including end with
+// text as one change, which u undoes at once. It's for hosts that edit the
+// content, such as refactoring tools, without typing keys.
+func (e *editor) ReplaceRange(start, end Position, text string) *EditorError {
+	return e.programmaticEdit(func() error {
+		return e.buffer.ReplaceRange(start, end, text)
+	})
+}
+
+// InsertLines inserts lines before line at as one change. at may be
+// LineCount() to add them after the last line.
+func (e *editor) InsertLines(at int, lines []string) *EditorError {
+	return e.programmaticEdit(func() error {
+		return e.buffer.InsertLines(at, lines)
+	})
+}
+
+// DeleteLines deletes the lines from through to as one change.
+func (e *editor) DeleteLines(from, to int) *EditorError {
+	return e.programmaticEdit(func() error {
+		return e.buffer.DeleteLines(from, to)
+	})
+}
+
+// ReplaceAll replaces every match of pattern with replacement as one change,
+// expanding $1 and ${name} to the text of groups. It returns how many matches
+// it replaced, 0 if the change was rejected.
+func (e *editor) ReplaceAll(pattern *regexp.Regexp, replacement string) (int, *EditorError) {
+	count := 0
+	err := e.programmaticEdit(func() error {
+		count = e.buffer.ReplaceAll(pattern, replacement)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// programmaticEdit runs edit on the buffer as one change, checked like a key,
+// keeping the cursor within the content. In a transaction, the transaction
+// is the change.
+func (e *editor) programmaticEdit(edit func() error) *EditorError {
+	e.enter()
+	defer e.leave()
+	return e.editProgrammatically(edit)
+}
+
+// editProgrammatically runs edit for programmaticEdit within a call.
+func (e *editor) editProgrammatically(edit func() error) *EditorError {
+	if e.InTransaction() {
+		if err := edit(); err != nil {
+			return &EditorError{
+				id:  ErrInvalidPositionId,
+				err: err,
+			}
+		}
+		e.buffer.SetCursor(e.buffer.GetCursor())
+		return nil
+	}
+
+	return e.checkedEdit(func() *EditorError {
+		e.preChangeCursor = e.buffer.GetCursor()
+		if err := edit(); err != nil {
+			return &EditorError{
+				id:  ErrInvalidPositionId,
+				err: err,
+			}
+		}
+
+		e.buffer.SetCursor(e.buffer.GetCursor())
+		e.clampCursorToLine()
+		e.SaveHistory()
+		e.ScrollViewport()
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProgrammaticEdits tests editing the content through the API.
+func TestProgrammaticEdits(t *testing.T) {
+	t.Run("ReplaceRange replaces text across lines", func(t *testing.T) {
+		e := newTestEditor("one two\nthree four\nfive")
+		err := e.ReplaceRange(Position{Row: 1, Col: 5}, Position{Row: 0, Col: 4}, "2\n3")
+		assert.Nil(t, err)
+		assert.Equal(t, "one 2\n3 four\nfive", content(e))
+	})
+
+	t.Run("ReplaceRange with an empty range inserts", func(t *testing.T) {
+		e := newTestEditor("ab")
+		assert.Nil(t, e.ReplaceRange(Position{Row: 0, Col: 1}, Position{Row: 0, Col: 1}, "-"))
+		assert.Equal(t, "a-b", content(e))
+	})
+
+	t.Run("ReplaceRange rejects positions outside the content", func(t *testing.T) {
+		e := newTestEditor("ab")
+		err := e.ReplaceRange(Position{Row: 0, Col: 0}, Position{Row: 0, Col: 3}, "x")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrInvalidPositionId, err.ID())
+		assert.Equal(t, "ab", content(e))
+	})
+
+	t.Run("InsertLines inserts before a line or at the end", func(t *testing.T) {
+		e := newTestEditor("a\nd")
+		assert.Nil(t, e.InsertLines(1, []string{"b", "c"}))
+		assert.Nil(t, e.InsertLines(4, []string{"e"}))
+		assert.Equal(t, "a\nb\nc\nd\ne", content(e))
+		assert.NotNil(t, e.InsertLines(6, []string{"f"}))
+	})
+
+	t.Run("DeleteLines deletes a range of lines", func(t *testing.T) {
+		e := newTestEditor("a\nb\nc\nd", withCursor(Position{Row: 3, Col: 0}))
+		assert.Nil(t, e.DeleteLines(1, 3))
+		assert.Equal(t, "a", content(e))
+		assert.Equal(t, Position{Row: 0, Col: 0}, cursorPos(e))
+
+		assert.Nil(t, e.DeleteLines(0, 0))
+		assert.Equal(t, "", content(e))
+		assert.NotNil(t, e.DeleteLines(0, 1))
+	})
+
+	t.Run("ReplaceAll expands groups and counts matches", func(t *testing.T) {
+		e := newTestEditor("foo(1)\nbar(2) foo(3)")
+		count, err := e.ReplaceAll(regexp.MustCompile(`foo\((\d)\)`), "baz($1)")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, "baz(1)\nbar(2) baz(3)", content(e))
+
+		count, err = e.ReplaceAll(regexp.MustCompile(`qux`), "x")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("each edit is undone at once", func(t *testing.T) {
+		e := newTestEditor("a b c")
+		count, _ := e.ReplaceAll(regexp.MustCompile(`\w`), "$0$0")
+		assert.Equal(t, 3, count)
+		assert.Nil(t, e.InsertLines(0, []string{"x", "y"}))
+		assert.Equal(t, "x\ny\naa bb cc", content(e))
+
+		keys(e, 'u')
+		assert.Equal(t, "aa bb cc", content(e))
+		keys(e, 'u')
+		assert.Equal(t, "a b c", content(e))
+	})
+
+	t.Run("edits are checked like keys", func(t *testing.T) {
+		e := newTestEditor("abc")
+		e.SetMaxLength(4)
+		err := e.ReplaceRange(Position{Row: 0, Col: 3}, Position{Row: 0, Col: 3}, "de")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrMaxLengthId, err.ID())
+		count, err := e.ReplaceAll(regexp.MustCompile(`b`), "bbb")
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, count)
+		assert.Equal(t, "abc", content(e))
+	})
+}
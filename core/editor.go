@@ -1,5 +1,11 @@
 package core
 
+import (
+	"log/slog"
+	"regexp"
+	"time"
+)
+
 // Position represents a specific location in the text buffer
 type Position struct {
 	Row int // Zero-indexed row (line number)
@@ -22,15 +28,29 @@ const (
 	cutType
 )
 
-// Editor represents the main editor interface
+// Editor is the public API of the editor, for hosts that embed it. It's used
+// from one goroutine at a time: calls that change the content panic if they
+// overlap another such call.
+//
+// Editor holds what editing needs: the buffer, modes, keys, edits, commands
+// and undo. What a host sets up once or only sometimes uses is grouped apart,
+// behind Display, Registers, Quickfix, Options, Languages and Limits.
+//
+// These interfaces are stable: within a major version, their methods keep
+// their signatures and behaviour and are not removed. Methods may be added,
+// so implementing them outside this package is not supported; New returns
+// the only implementation. What the modes need beyond them stays unexported.
 type Editor interface {
 	// Buffer manipulation
 	GetBuffer() Buffer
 	SetBuffer(Buffer)  // Replace the current buffer
 	SetContent([]byte) // Set buffer content from byte slice
 
+	// SetContentWithEncoding sets the buffer content from bytes in the given encoding.
+	// Saving writes the content back in that encoding.
+	SetContentWithEncoding(content []byte, enc Encoding)
+
 	// Mode handling
-	GetMode() EditorMode
 	SetNormalMode()
 	SetInsertMode()
 	SetVisualMode()
@@ -39,66 +59,214 @@ type Editor interface {
 	SetSearchMode()
 	DisableVimMode(bool)
 	IsVimMode() bool
-	DisableCommandMode(bool)
-	DisableInsertMode(bool)
-	DisableVisualMode(bool)
-	DisableVisualLineMode(bool)
-	DisableSearchMode(bool)
+	DisableCommandMode(bool)              // Take CommandLine away, or give it back
+	DisableInsertMode(bool)               // Take EditText away, or give it back
+	DisableVisualMode(bool)               // Take VisualSelect away, or give it back
+	DisableVisualLineMode(bool)           // Take VisualSelect away, or give it back
+	DisableSearchMode(bool)               // Take Search away, or give it back
+	SetModeHook(mode Mode, hook ModeHook) // Run hook.Enter and hook.Exit when entering and leaving mode
+	IsNormalMode() bool
+	IsInsertMode() bool
+	IsVisualMode() bool
+	IsVisualLineMode() bool
+	IsCommandMode() bool
+	IsSearchMode() bool
+	HasPendingKeys() bool // Whether normal mode waits for more keys, e.g. after d or a count
+	ResetSelection()
+
+	// Capabilities
+	SetCapabilities(capabilities Capability)    // What the user may do, AllCapabilities by default
+	HasCapability(capabilities Capability) bool // Whether the user may do all of capabilities
+
+	// Marks, used by ' and ` and in ranges such as :'a,'bd
+	SetMark(name rune, pos Position) // Set mark a to z, as m does
+	Mark(name rune) (Position, bool) // Position of a mark, including < and > for the last selection
 
 	// Event handling
 	HandleKey(key KeyEvent) *EditorError // Process a key press
 	TriggerCompletion(triggerKind CompletionTriggerKind, triggerChar string)
 	InsertCompletion(completion Completion) error
+	CommitText(text string) *EditorError // Insert composed text (e.g. from an IME) as a single change
+	InsertText(text string) *EditorError // Insert text (e.g. a terminal paste) in insert mode as a single change
+
+	// Programmatic edits, each a single change in any mode
+	ReplaceRange(start, end Position, text string) *EditorError
+	InsertLines(at int, lines []string) *EditorError
+	DeleteLines(from, to int) *EditorError
+	ReplaceAll(pattern *regexp.Regexp, replacement string) (int, *EditorError)
+	BeginTransaction()                     // Group the edits up to EndTransaction into one change
+	EndTransaction() *EditorError          // Save the grouped edits as one change, or undo them if rejected
+	Transact(fn func(Buffer)) *EditorError // Run fn's edits as one change
+	InTransaction() bool                   // Whether a transaction has begun and not ended
+	Format() *EditorError                  // Format the content as one change, as :format does
 
 	// State Management
-	GetState() State      // Get the current editor state
-	SetState(State)       // Update the editor state (used internally)
-	UpdateStatus(string)  // Helper to set status line
-	UpdateCommand(string) // Helper to set command line
+	GetState() State  // Get the current editor state
+	FileInfo() string // File name, modified flag and position, as shown by :f and Ctrl-G
+
+	// Changes on disk, reported by the host
+	MarkExternallyModified(content []byte)         // Reload, or send a ConflictSignal if there are unsaved changes
+	HasConflict() bool                             // Whether a ConflictSignal awaits ResolveConflict or MergeConflict
+	ResolveConflict(resolution ConflictResolution) // Keep the buffer or take the file
+	MergeConflict(merged string)                   // Take merged, e.g. from a diff view
+	FileRenamed(fileName string)                   // The host renamed the file for a RenameSignal
 
 	// Command execution (Called from Command Mode)
 	ExecuteCommand(cmd string) *EditorError
+	RegisterCommand(name string, handler CommandHandler) // Register an extra ex command (e.g. "Gstage")
+	Commands() []CommandInfo                             // Built-in and registered commands, e.g. for a command palette
 	ExecuteSearch(query string, searchOptions SearchOptions)
+	SearchAsync(pattern string, options SearchOptions) // Search in the background, sending the matches in batches
 	CancelSearch()
 
 	// History management
-	SaveHistory() // Indicate a state should be saved for undo
 	Undo() (string, error)
 	Redo() (string, error)
-	Paste() (string, error)       // Paste from clipboard after/below cursor
-	PasteBefore() (string, error) // Paste from clipboard before/above cursor
-	Copy(op copyType) error       // Copy to clipboard
 
-	// Viewport scrolling (Could be part of UpdateState or separate)
-	ScrollViewport()
-	GetUpdateSignalChan() <-chan Signal            // For UI updates
+	// Signals and messages
+	Message(id MessageId, args ...any) string // Text of a message of the catalogue, in the words set with SetMessages
+	DroppedSignals() int                      // Signals dropped so far because the consumer fell behind
+
+	// The rest of the API, by what it's for
+	Display() Display     // Sizing the view and what is drawn in it
+	Registers() Registers // Yanked text and the clipboard
+	Quickfix() Quickfix   // The quickfix list
+	Options() Options     // Settings of editing, most of which :set also changes
+	Languages() Languages // Statement syntaxes, formatters and settings by language
+	Limits() Limits       // Input limits and read-only text
+}
+
+// Display is how the host sizes the editor's view and finds out what to draw
+// in it.
+type Display interface {
+	SetViewportSize(width, height, availableWidth int) // Size of the view, and the width left for text by the line numbers
+	SetLayoutProvider(provider LayoutProvider)         // How lines wrap on screen, for gj and gk
+	SetDisplayLineMovement(enabled bool)               // Make j and k move by display lines, and gj and gk by lines
+	ShowRelativeLineNumbers(bool)
+	ShowAbsoluteLineNumbers(bool) // With relative numbers, number the cursor line from the start
+	SetNumberWidth(width int)     // Least columns of the line numbers, as :set numberwidth; 0 for 5
+	SetShowBreak(string)          // Drawn before the display lines wrapping continues a line on, as :set showbreak
+	SetBreakIndent(bool)          // Indent the display lines wrapping continues a line on, as :set breakindent
+
 	GetSelectionStatus(pos Position) SelectionType // Get selection status of a position
-	Save(*string)                                  // Save the current buffer content
-	Quit()                                         // Signal to quit the editor
-	DispatchError(id ErrorId, err error)           // Dispatch errors to consumers
-	DispatchSignal(signal Signal)                  // Dispatch signals to consumers
+	SearchResults() []Position                     // Where the last search matched
+}
+
+// Registers are the texts yanked and deleted, and the clipboard they go to.
+type Registers interface {
+	YankHistory() []string  // Texts yanked and deleted last, newest first
+	CanCyclePaste() bool    // Whether Ctrl-P and Ctrl-N would swap the text just pasted
+	SetClipboard(Clipboard) // Replace the clipboard used to yank and paste
+}
+
+// Quickfix is the quickfix list: places to jump to, such as the errors of a
+// compiler.
+type Quickfix interface {
+	SetQuickfixList(items []QuickfixItem)  // Replace the quickfix list, e.g. with compiler or linter output
+	JumpToQuickfix(index int) *EditorError // Select an item and move the cursor to it
+	OpenQuickfix()                         // Show the quickfix list (:copen)
+	CloseQuickfix()                        // Hide the quickfix list (:cclose)
+}
+
+// Options are the settings of editing, most of which :set also changes, and
+// those of the editor towards its host.
+type Options interface {
+	SetTextWidth(width int)           // Columns gq reflows text to, as :set textwidth; 0 for 79
+	SetAutoWrap(wrap AutoWrap)        // Which lines typing breaks at the text width, as :set formatoptions
+	SetTimeoutLen(time.Duration)      // How long keys waiting for more wait, as :set timeoutlen; 0 for forever
+	SetRedoOnU(enabled bool)          // Make U redo instead of undoing the line
+	SetVirtualEdit(mode VirtualEdit)  // Where the cursor may go past the end of a line
+	SetMaxHistory(max uint32)         // Set maximum history size for undo/redo
+	SetExtraWordChars(chars ...rune)  // Set additional characters to be considered part of words for navigation and selection
+	SetTableMode(enabled bool)        // Tab moves between the cells of tables, which are aligned as they change
+	SetTableDelimiter(delimiter rune) // | for markdown tables, or e.g. , for CSV
+
+	// SetInvalidUTF8Fallback sets the encoding used by SetContent for content that
+	// is not valid UTF-8. EncodingLatin1 keeps every byte so saving doesn't corrupt
+	// the file; the default, EncodingUTF8, replaces invalid bytes with U+FFFD.
+	SetInvalidUTF8Fallback(enc Encoding)
+
+	SetSignalPolicy(policy SignalPolicy)    // What happens to signals when the consumer falls behind
+	SetLogger(logger *slog.Logger)          // Log keys, mode changes, history saves and signals at LevelTrace, nil for none
+	SetMessages(texts map[MessageId]string) // Put the messages shown to the user in the host's words, e.g. for another language
+}
+
+// Languages are the settings the editor keeps by language, applied to the
+// content of the language set with SetLanguage.
+type Languages interface {
+	SetLanguage(language string)                                 // Picks the syntax of statements and the settings, such as sql
+	SetStatementSyntax(language string, syntax *StatementSyntax) // Where statements of language end, nil for none
+	SetFormatter(language string, formatter Formatter)           // Formats language for :format, nil for none
+	SetFormatOnSave(enabled bool)                                // Format before :w writes the whole buffer
+	SetCommentLeaders(language string, leaders ...string)        // Line comment leaders of language, kept by gq
+	ConfigureLanguage(language string, config LanguageConfig)    // Settings SetLanguage applies for language
+}
+
+// Limits reject edits that make the content too long or invalid, or that
+// change read-only text.
+type Limits interface {
+	SetMaxLength(maxLength int)                          // Reject edits that make the content longer than maxLength runes (0 for no limit)
+	SetValidateFunc(validate func(content string) error) // Reject edits whose content validate returns an error for
+	ProtectRange(start, end Position)                    // Make the text from start up to end read-only
+	ClearProtectedRanges()                               // Make the whole buffer editable again
+	ProtectedRanges() []ProtectedRange                   // The read-only ranges, moved with the text around them
+	IsProtected(pos Position) bool                       // Whether the character at pos is read-only
+}
+
+// modeContext is the editor as the modes and commands drive it: the public
+// Editor, and what only they call.
+type modeContext interface {
+	Editor
+	Display
+	Registers
+	Quickfix
+	Options
+	Languages
+	Limits
+
+	GetMode() EditorMode
+	SetState(State)       // Update the editor state
+	SaveHistory()         // Save the state for undo
+	UpdateCommand(string) // Set the command line
+	UpdateStatus(string)  // Helper to set status line
+	ScrollViewport()      // Keep the cursor in the viewport
+	SetCommandCursor(int) // Show a cursor at a rune index in the command line
 	ResetPendingCount()
 
-	ShowRelativeLineNumbers(bool)
-	IsNormalMode() bool
-	IsInsertMode() bool
-	IsVisualMode() bool
-	IsVisualLineMode() bool
-	IsCommandMode() bool
-	IsSearchMode() bool
+	GetUpdateSignalChan() <-chan Signal  // Signals for the host, read by the adapter
+	DispatchSignal(signal Signal)        // Send signal to the host
+	DispatchError(id ErrorId, err error) // Send an ErrorSignal to the host
+	Save(*string)                        // Save the content, as :w does
+	Quit()                               // Send a QuitSignal, as :q does
 
-	SearchResults() []Position
+	SearchWordUnderCursor(backwards bool) *EditorError // * and #
 	NextSearchResult() Cursor
 	PreviousSearchResult() Cursor
 
-	SetMaxHistory(max uint32) // Set maximum history size for undo/redo
+	UndoLine() (string, error)           // Undo the latest changes on the last changed line (U)
+	Paste() (string, error)              // Paste from clipboard after/below cursor
+	PasteBefore() (string, error)        // Paste from clipboard before/above cursor
+	PasteInsert() (string, error)        // Paste from clipboard at the cursor in insert mode
+	PasteOverSelection() (string, error) // Replace the visual selection with the clipboard content
+	Copy(op copyType) error              // Copy to clipboard
 
-	SetExtraWordChars(chars ...rune) // Set additional characters to be considered part of words for navigation and selection
-	IsWordChar(r rune) bool         // Reports whether r is considered a word character in this editor's context
+	IsWordChar(r rune) bool // Reports whether r is considered a word character in this editor's context
+	Layout() LayoutProvider // How lines are laid out on screen, for gj and gk
 
-	ResetSelection()
+	MoveLines(cursor *Cursor, count int, byDisplayLines bool) error // j, k, gj and gk, keeping the screen column
+
+	lineCommentLeaders() []string           // Comment leaders of the content's language, for gq
+	allow(capability Capability) bool       // Whether the user may do what capability allows, sending a BlockedSignal if not
+	joinChange()                            // Make the edits of the insert mode entered next part of the change just saved, for cc
+	executeCommand(cmd string) *EditorError // ExecuteCommand from within a call, as : and ZZ run commands
+	undo() (string, error)                  // Undo from within a call, as u does
+	redo() (string, error)                  // Redo from within a call, as Ctrl-R does
 }
 
+// CommandHandler runs a command registered with RegisterCommand.
+// args holds the whitespace separated arguments that followed the command name.
+type CommandHandler func(editor Editor, args []string) *EditorError
+
 type Clipboard interface {
 	Write(text string) error
 	Read() (string, error)
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encoding is the character encoding used to read and write the buffer's content
+type Encoding int
+
+const (
+	EncodingUTF8        Encoding = iota // UTF-8, the default
+	EncodingLatin1                      // ISO-8859-1; every byte maps to one rune, so any file round-trips
+	EncodingWindows1252                 // Windows-1252 (cp1252)
+	EncodingUTF16LE                     // UTF-16 little-endian with a byte order mark
+	EncodingUTF16BE                     // UTF-16 big-endian with a byte order mark
+)
+
+var (
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// String returns the Vim fileencoding name of the encoding.
+func (enc Encoding) String() string {
+	switch enc {
+	case EncodingLatin1:
+		return "latin1"
+	case EncodingWindows1252:
+		return "cp1252"
+	case EncodingUTF16LE:
+		return "utf-16le"
+	case EncodingUTF16BE:
+		return "utf-16"
+	default:
+		return "utf-8"
+	}
+}
+
+// ParseEncoding returns the encoding for a fileencoding name such as "utf-8",
+// "latin1", "cp1252", "utf-16le" or "utf-16". Names are case-insensitive.
+func ParseEncoding(name string) (Encoding, bool) {
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8":
+		return EncodingUTF8, true
+	case "latin1", "iso-8859-1", "iso8859-1":
+		return EncodingLatin1, true
+	case "cp1252", "windows-1252":
+		return EncodingWindows1252, true
+	case "utf-16le":
+		return EncodingUTF16LE, true
+	case "utf-16", "utf-16be":
+		return EncodingUTF16BE, true
+	default:
+		return EncodingUTF8, false
+	}
+}
+
+// DetectEncoding reports the encoding announced by a UTF-16 byte order mark.
+func DetectEncoding(content []byte) (Encoding, bool) {
+	switch {
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return EncodingUTF16LE, true
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return EncodingUTF16BE, true
+	default:
+		return EncodingUTF8, false
+	}
+}
+
+func (enc Encoding) charset() encoding.Encoding {
+	switch enc {
+	case EncodingLatin1:
+		return charmap.ISO8859_1
+	case EncodingWindows1252:
+		return charmap.Windows1252
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	default:
+		return nil
+	}
+}
+
+// Decode converts content in this encoding to text.
+// lossy reports whether bytes were replaced because they could not be decoded.
+func (enc Encoding) Decode(content []byte) (text string, lossy bool) {
+	charset := enc.charset()
+	if charset == nil {
+		if utf8.Valid(content) {
+			return string(content), false
+		}
+		return strings.ToValidUTF8(string(content), string(utf8.RuneError)), true
+	}
+
+	decoded, err := charset.NewDecoder().Bytes(content)
+	if err != nil {
+		return strings.ToValidUTF8(string(content), string(utf8.RuneError)), true
+	}
+	return string(decoded), false
+}
+
+// Encode converts text to bytes in this encoding.
+// It fails if the text contains characters the encoding cannot represent.
+func (enc Encoding) Encode(text string) ([]byte, error) {
+	charset := enc.charset()
+	if charset == nil {
+		return []byte(text), nil
+	}
+
+	encoded, err := charset.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, newNotice(MsgCannotEncode, enc, err)
+	}
+	return encoded, nil
+}
+
+// decodeContent decodes file content. A UTF-16 byte order mark selects that
+// encoding; otherwise fallback is used when the content isn't valid UTF-8.
+// With EncodingUTF8 as fallback invalid bytes are replaced and lossy is true.
+func decodeContent(content []byte, fallback Encoding) (text string, enc Encoding, lossy bool) {
+	if enc, ok := DetectEncoding(content); ok {
+		text, lossy = enc.Decode(content)
+		return text, enc, lossy
+	}
+
+	if utf8.Valid(content) {
+		return string(content), EncodingUTF8, false
+	}
+
+	text, lossy = fallback.Decode(content)
+	return text, fallback, lossy
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInvalidUTF8 tests detection of invalid UTF-8 and the byte-preserving latin1 fallback.
+func TestInvalidUTF8(t *testing.T) {
+	invalid := []byte("caf\xe9\nna\xefve")
+
+	t.Run("valid UTF-8 is not lossy", func(t *testing.T) {
+		e := newTestEditor("café")
+		assert.Equal(t, EncodingUTF8, e.GetBuffer().Encoding())
+		assert.False(t, e.GetBuffer().IsLossy())
+	})
+
+	t.Run("invalid bytes are replaced and the buffer is marked lossy", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContent(invalid)
+		assert.True(t, e.GetBuffer().IsLossy())
+		assert.Equal(t, []string{"caf�", "na�ve"}, e.GetBuffer().GetLines())
+	})
+
+	t.Run(":w refuses to save lossy content", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContent(invalid)
+		keys(e, 'x')
+		drainSignals(e)
+
+		err := e.ExecuteCommand("w")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrLossyContentId, err.ID())
+		assert.Nil(t, nextSignal(e))
+	})
+
+	t.Run(":w! saves lossy content", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContent(invalid)
+		drainSignals(e)
+
+		assert.Nil(t, e.ExecuteCommand("w!"))
+		sig, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+		_, saved := sig.Value()
+		assert.Equal(t, "caf�\nna�ve", saved)
+	})
+
+	t.Run("latin1 fallback keeps every byte on save", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetInvalidUTF8Fallback(EncodingLatin1)
+		e.SetContent(invalid)
+		assert.Equal(t, EncodingLatin1, e.GetBuffer().Encoding())
+		assert.False(t, e.GetBuffer().IsLossy())
+		assert.Equal(t, []string{"café", "naïve"}, e.GetBuffer().GetLines())
+
+		keys(e, 'A', '!')
+		escape(e)
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("w"))
+
+		sig, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+		_, saved := sig.Value()
+		assert.Equal(t, "caf\xe9!\nna\xefve", saved)
+	})
+
+	t.Run("latin1 fallback is not used for valid UTF-8", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetInvalidUTF8Fallback(EncodingLatin1)
+		e.SetContent([]byte("café"))
+		assert.Equal(t, EncodingUTF8, e.GetBuffer().Encoding())
+		assert.Equal(t, "café", content(e))
+	})
+
+	t.Run("undo keeps the latin1 encoding", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetInvalidUTF8Fallback(EncodingLatin1)
+		e.SetContent(invalid)
+		keys(e, 'x', 'u')
+		assert.Equal(t, EncodingLatin1, e.GetBuffer().Encoding())
+		assert.Equal(t, []string{"café", "naïve"}, e.GetBuffer().GetLines())
+	})
+
+	t.Run("characters outside latin1 fail to save", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetInvalidUTF8Fallback(EncodingLatin1)
+		e.SetContent(invalid)
+		keys(e, 'i', '€')
+		escape(e)
+		drainSignals(e)
+
+		assert.Nil(t, e.ExecuteCommand("w"))
+		sig, ok := nextSignal(e).(ErrorSignal)
+		assert.True(t, ok)
+		id, _ := sig.Value()
+		assert.Equal(t, ErrFailedToSaveId, id)
+		assert.True(t, e.GetBuffer().IsModified())
+	})
+}
+
+// TestEncoding tests decoding and re-encoding of non UTF-8 charsets.
+func TestEncoding(t *testing.T) {
+	saveContent := func(t *testing.T, e modeContext, command string) string {
+		t.Helper()
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand(command))
+		sig, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+		_, saved := sig.Value()
+		return saved
+	}
+
+	t.Run("UTF-16LE is detected from the byte order mark", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContent([]byte{0xFF, 0xFE, 'h', 0, 'i', 0, '\n', 0, 0xAC, 0x20})
+		assert.Equal(t, EncodingUTF16LE, e.GetBuffer().Encoding())
+		assert.Equal(t, []string{"hi", "€"}, e.GetBuffer().GetLines())
+	})
+
+	t.Run("UTF-16BE is detected from the byte order mark", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContent([]byte{0xFE, 0xFF, 0, 'h', 0, 'i'})
+		assert.Equal(t, EncodingUTF16BE, e.GetBuffer().Encoding())
+		assert.Equal(t, "hi", content(e))
+	})
+
+	t.Run("UTF-16 is saved with its byte order mark", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContent([]byte{0xFF, 0xFE, 'h', 0, 'i', 0})
+		keys(e, 'A', '!')
+		escape(e)
+		assert.Equal(t, "\xff\xfeh\x00i\x00!\x00", saveContent(t, e, "w"))
+	})
+
+	t.Run("explicit Windows-1252 content round-trips", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContentWithEncoding([]byte("\x80 caf\xe9"), EncodingWindows1252)
+		assert.Equal(t, "€ café", content(e))
+		assert.Equal(t, "\x80 caf\xe9", saveContent(t, e, "w!"))
+	})
+
+	t.Run("explicit ISO-8859-1 content round-trips", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetContentWithEncoding([]byte("caf\xe9"), EncodingLatin1)
+		assert.Equal(t, "café", content(e))
+		assert.Equal(t, "caf\xe9", saveContent(t, e, "w!"))
+	})
+
+	t.Run(":set fileencoding converts on save", func(t *testing.T) {
+		e := newTestEditor("café")
+		assert.Nil(t, e.ExecuteCommand("set fileencoding=latin1"))
+		assert.Equal(t, EncodingLatin1, e.GetBuffer().Encoding())
+		assert.True(t, e.GetBuffer().IsModified())
+		assert.Equal(t, "caf\xe9", saveContent(t, e, "w"))
+		assert.False(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run(":set fenc accepts aliases", func(t *testing.T) {
+		e := newTestEditor("a")
+		assert.Nil(t, e.ExecuteCommand("set fenc=UTF-16LE"))
+		assert.Equal(t, EncodingUTF16LE, e.GetBuffer().Encoding())
+		assert.Nil(t, e.ExecuteCommand("set fenc=windows-1252"))
+		assert.Equal(t, EncodingWindows1252, e.GetBuffer().Encoding())
+	})
+
+	t.Run("unknown fileencoding is rejected", func(t *testing.T) {
+		e := newTestEditor("a")
+		err := e.ExecuteCommand("set fenc=ebcdic")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrInvalidCommandId, err.ID())
+	})
+
+	t.Run("undo restores the previous file encoding, as it does the file format", func(t *testing.T) {
+		e := newTestEditor("a\nb")
+		assert.Nil(t, e.ExecuteCommand("set fenc=latin1"))
+		assert.Nil(t, e.ExecuteCommand("set ff=dos"))
+
+		keys(e, 'u')
+		assert.Equal(t, LineEndingLF, e.GetBuffer().LineEnding())
+		assert.Equal(t, EncodingLatin1, e.GetBuffer().Encoding())
+		keys(e, 'u')
+		assert.Equal(t, EncodingUTF8, e.GetBuffer().Encoding())
+
+		e.HandleKey(KeyEvent{Key: KeyCtrlR})
+		assert.Equal(t, EncodingLatin1, e.GetBuffer().Encoding())
+	})
+}
@@ -1,22 +1,33 @@
 package core
 
-import (
-	"errors"
-)
-
+// The errors of the editor, each a Notice of the message of the same name,
+// such as MsgEndOfBuffer for ErrEndOfBuffer.
 var (
-	ErrEndOfBuffer        = errors.New("end of buffer")
-	ErrStartOfBuffer      = errors.New("start of buffer")
-	ErrEndOfLine          = errors.New("end of line")
-	ErrStartOfLine        = errors.New("start of line")
-	ErrInvalidPosition    = errors.New("invalid position")
-	ErrInvalidMode        = errors.New("invalid mode")
-	ErrInvalidCommand     = errors.New("invalid command")
-	ErrNoPendingOperation = errors.New("no pending operation")
-	ErrDeleteRunes        = errors.New("cannot delete runes")
-	ErrNoChangesToSave    = errors.New("no changes to save")
-	ErrUnsavedChanges     = errors.New("unsaved changes (use :q! to override)")
-	ErrRenameFailed       = errors.New("rename requires a single argument (rename new_filename)")
+	ErrEndOfBuffer        error = newNotice(MsgEndOfBuffer)
+	ErrStartOfBuffer      error = newNotice(MsgStartOfBuffer)
+	ErrEndOfLine          error = newNotice(MsgEndOfLine)
+	ErrStartOfLine        error = newNotice(MsgStartOfLine)
+	ErrInvalidPosition    error = newNotice(MsgInvalidPosition)
+	ErrInvalidMode        error = newNotice(MsgInvalidMode)
+	ErrInvalidCommand     error = newNotice(MsgInvalidCommand)
+	ErrNoPendingOperation error = newNotice(MsgNoPendingOperation)
+	ErrDeleteRunes        error = newNotice(MsgDeleteRunes)
+	ErrNoChangesToSave    error = newNotice(MsgNoChangesToSave)
+	ErrUnsavedChanges     error = newNotice(MsgUnsavedChanges)
+	ErrRenameFailed       error = newNotice(MsgRenameFailed)
+	ErrLossyContent       error = newNotice(MsgLossyContent)
+	ErrNoQuickfixItems    error = newNotice(MsgNoQuickfixItems)
+	ErrNoMoreQuickfixes   error = newNotice(MsgNoMoreQuickfixes)
+	ErrInvalidRange       error = newNotice(MsgInvalidRange)
+	ErrPartialWrite       error = newNotice(MsgPartialWrite)
+	ErrMaxLength          error = newNotice(MsgMaxLength)
+	ErrProtected          error = newNotice(MsgProtected)
+	ErrNoTable            error = newNotice(MsgNoTable)
+	ErrMarkNotSet         error = newNotice(MsgMarkNotSet)
+	ErrPatternNotFound    error = newNotice(MsgPatternNotFound)
+	ErrNoFormatter        error = newNotice(MsgNoFormatter)
+	ErrFormatFailed       error = newNotice(MsgFormatFailed)
+	ErrNotAllowed         error = newNotice(MsgNotAllowed)
 )
 
 type ErrorId int
@@ -42,6 +53,20 @@ const (
 	ErrRedoFailedId
 	ErrCopyFailedId
 	ErrRenameFailedId
+	ErrLossyContentId
+	ErrNoQuickfixItemsId
+	ErrNoMoreQuickfixesId
+	ErrInvalidRangeId
+	ErrPartialWriteId
+	ErrMaxLengthId
+	ErrInvalidInputId
+	ErrProtectedId
+	ErrNoTableId
+	ErrMarkNotSetId
+	ErrPatternNotFoundId
+	ErrNoFormatterId
+	ErrFormatFailedId
+	ErrNotAllowedId
 )
 
 type EditorError struct {
@@ -49,6 +74,11 @@ type EditorError struct {
 	err error
 }
 
+// NewEditorError creates an error that can be returned from command handlers.
+func NewEditorError(id ErrorId, err error) *EditorError {
+	return &EditorError{id: id, err: err}
+}
+
 func (e EditorError) ID() ErrorId {
 	return e.id
 }
@@ -58,8 +88,5 @@ func (e EditorError) Error() error {
 }
 
 func (e *editor) DispatchError(id ErrorId, err error) {
-	select {
-	case e.updateSignal <- ErrorSignal{id, err}:
-	default: // Ignore if the channel is full
-	}
+	e.sendSignal(ErrorSignal{id, e.localise(err)})
 }
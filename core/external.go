@@ -0,0 +1,125 @@
+package core
+
+// ConflictResolution is how ResolveConflict settles a ConflictSignal.
+type ConflictResolution int
+
+const (
+	KeepMine   ConflictResolution = iota // Keep the buffer, which saving writes over the file
+	TakeTheirs                           // Reload the file, dropping the unsaved changes, as :e! does
+)
+
+// MarkExternallyModified tells the editor the file changed on disk, to
+// content, e.g. from a file watcher. The editor doesn't read files, so the
+// host passes what it read, encoded as SetContent takes it.
+//
+// A buffer without unsaved changes is reloaded, as a change that u undoes.
+// One with unsaved changes keeps them and sends a ConflictSignal; the host
+// may then ask whether to keep them, take the file or compare the two, and
+// answer with ResolveConflict or MergeConflict. Content that is the saved
+// content, as after the editor's own save, is ignored.
+func (e *editor) MarkExternallyModified(content []byte) {
+	e.enter()
+	defer e.leave()
+
+	theirs := e.decode(content)
+	e.diskContent = nil
+	if theirs == e.buffer.GetSavedContent() {
+		return
+	}
+
+	if !e.buffer.IsModified() {
+		e.reload(theirs)
+		return
+	}
+
+	e.diskContent = &theirs
+	e.DispatchSignal(ConflictSignal{
+		base:   e.buffer.GetSavedContent(),
+		mine:   e.buffer.GetCurrentContent(),
+		theirs: theirs,
+	})
+}
+
+// HasConflict reports whether the file changed on disk while the buffer had
+// unsaved changes, and the conflict isn't resolved yet.
+func (e *editor) HasConflict() bool {
+	return e.diskContent != nil
+}
+
+// ResolveConflict settles the conflict reported by a ConflictSignal, keeping
+// the buffer or reloading the file. Either way the file's content becomes
+// the saved content. It does nothing without a conflict.
+func (e *editor) ResolveConflict(resolution ConflictResolution) {
+	e.enter()
+	defer e.leave()
+
+	if e.diskContent == nil {
+		return
+	}
+	theirs := *e.diskContent
+	e.diskContent = nil
+
+	switch resolution {
+	case KeepMine:
+		e.buffer.SetSavedContent(theirs)
+	case TakeTheirs:
+		e.reload(theirs)
+	}
+}
+
+// MergeConflict settles the conflict reported by a ConflictSignal with merged,
+// such as the buffer after taking some of the file's changes in a diff view,
+// as a change that u undoes. The file's content becomes the saved content.
+// It does nothing without a conflict.
+func (e *editor) MergeConflict(merged string) {
+	e.enter()
+	defer e.leave()
+
+	if e.diskContent == nil {
+		return
+	}
+	theirs := *e.diskContent
+	e.diskContent = nil
+
+	e.replaceContent(merged)
+	e.buffer.SetSavedContent(theirs)
+}
+
+// revert replaces the buffer with the file's content: the content it last
+// changed to on disk, or else the saved content. It's :e!.
+func (e *editor) revert() {
+	theirs := e.buffer.GetSavedContent()
+	if e.diskContent != nil {
+		theirs = *e.diskContent
+		e.diskContent = nil
+	}
+	e.reload(theirs)
+}
+
+// decode returns content as the buffer would hold it after SetContent, in
+// its encoding.
+func (e *editor) decode(content []byte) string {
+	if encoding := e.buffer.Encoding(); encoding != EncodingUTF8 {
+		return NewBufferFromBytesWithEncoding(content, encoding).GetCurrentContent()
+	}
+	return NewBufferFromBytesWithFallback(content, e.invalidUTF8Fallback).GetCurrentContent()
+}
+
+// reload replaces the buffer with content read from the file, which becomes
+// the saved content.
+func (e *editor) reload(content string) {
+	e.replaceContent(content)
+	e.buffer.SaveContent()
+}
+
+// replaceContent replaces the buffer's content as one change, keeping the
+// cursor within it. Unlike an edit, it isn't checked against the input
+// limits or the protected ranges, as it comes from the file.
+func (e *editor) replaceContent(content string) {
+	e.preChangeCursor = e.buffer.GetCursor()
+	e.buffer.SetContent([]byte(content))
+	e.buffer.SetCursor(e.buffer.GetCursor())
+	e.clampCursorToLine()
+	e.SaveHistory()
+	e.ScrollViewport()
+}
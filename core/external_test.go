@@ -0,0 +1,114 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMarkExternallyModified tests reloading the file when it changed on disk,
+// and the conflict with unsaved changes.
+func TestMarkExternallyModified(t *testing.T) {
+	// modified returns an editor with "one\ntwo" saved and an unsaved change.
+	modified := func() modeContext {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'x')
+		drainSignals(e)
+		return e
+	}
+
+	t.Run("a buffer without changes is reloaded", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'j', '$')
+		e.MarkExternallyModified([]byte("one\nto\nthree"))
+
+		assert.Equal(t, "one\nto\nthree", content(e))
+		assert.Equal(t, Position{Row: 1, Col: 2}, cursorPos(e))
+		assert.False(t, e.GetBuffer().IsModified())
+		assert.False(t, e.HasConflict())
+		assert.Nil(t, nextSignal(e))
+
+		keys(e, 'u')
+		assert.Equal(t, "one\ntwo", content(e))
+	})
+
+	t.Run("the saved content is ignored", func(t *testing.T) {
+		e := modified()
+		e.MarkExternallyModified([]byte("one\ntwo"))
+		assert.Equal(t, "ne\ntwo", content(e))
+		assert.False(t, e.HasConflict())
+		assert.Nil(t, nextSignal(e))
+	})
+
+	t.Run("unsaved changes conflict", func(t *testing.T) {
+		e := modified()
+		e.MarkExternallyModified([]byte("one\ntwo\nthree"))
+
+		assert.True(t, e.HasConflict())
+		assert.Equal(t, "ne\ntwo", content(e))
+		signal, ok := nextSignal(e).(ConflictSignal)
+		assert.True(t, ok)
+		base, mine, theirs := signal.Value()
+		assert.Equal(t, "one\ntwo", base)
+		assert.Equal(t, "ne\ntwo", mine)
+		assert.Equal(t, "one\ntwo\nthree", theirs)
+	})
+
+	t.Run("keeping mine makes the file's content the saved one", func(t *testing.T) {
+		e := modified()
+		e.MarkExternallyModified([]byte("one\ntwo\nthree"))
+		e.ResolveConflict(KeepMine)
+
+		assert.False(t, e.HasConflict())
+		assert.Equal(t, "ne\ntwo", content(e))
+		assert.Equal(t, "one\ntwo\nthree", e.GetBuffer().GetSavedContent())
+		assert.True(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("taking theirs reloads the file", func(t *testing.T) {
+		e := modified()
+		e.MarkExternallyModified([]byte("one\ntwo\nthree"))
+		e.ResolveConflict(TakeTheirs)
+
+		assert.False(t, e.HasConflict())
+		assert.Equal(t, "one\ntwo\nthree", content(e))
+		assert.False(t, e.GetBuffer().IsModified())
+		keys(e, 'u')
+		assert.Equal(t, "ne\ntwo", content(e))
+	})
+
+	t.Run("merging takes the merged content", func(t *testing.T) {
+		e := modified()
+		e.MarkExternallyModified([]byte("one\ntwo\nthree"))
+		e.MergeConflict("ne\ntwo\nthree")
+
+		assert.False(t, e.HasConflict())
+		assert.Equal(t, "ne\ntwo\nthree", content(e))
+		assert.Equal(t, "one\ntwo\nthree", e.GetBuffer().GetSavedContent())
+		assert.True(t, e.GetBuffer().IsModified())
+
+		e.MergeConflict("ignored")
+		assert.Equal(t, "ne\ntwo\nthree", content(e))
+	})
+
+	t.Run(":e! reloads the file's latest content", func(t *testing.T) {
+		e := modified()
+		assert.Nil(t, e.ExecuteCommand("e!"))
+		assert.Equal(t, "one\ntwo", content(e))
+		assert.False(t, e.GetBuffer().IsModified())
+
+		keys(e, 'x')
+		e.MarkExternallyModified([]byte("one\ntwo\nthree"))
+		assert.Nil(t, e.ExecuteCommand("e!"))
+		assert.Equal(t, "one\ntwo\nthree", content(e))
+		assert.False(t, e.HasConflict())
+	})
+
+	t.Run("saving overwrites the file's changes", func(t *testing.T) {
+		e := modified()
+		e.MarkExternallyModified([]byte("one\ntwo\nthree"))
+		assert.Nil(t, e.ExecuteCommand("w"))
+		assert.False(t, e.HasConflict())
+		assert.False(t, e.GetBuffer().IsModified())
+	})
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"strings"
+)
+
+// isFileCommand reports whether command writes, reloads or renames the file,
+// which needs FileOps. :wq and :x run :w. :delete, which deletes lines when
+// given a range, asks for FileOps itself when it deletes the file.
+func isFileCommand(command string) bool {
+	switch command {
+	case "e!", "edit!", "rename", "rename!", "saveas", "sav", "saveas!", "sav!":
+		return true
+	}
+	return isWriteCommand(command)
+}
+
+// confirm dispatches a ConfirmSignal for action, which takes it by calling
+// act if the host accepts. Rejecting it, or answering again, does nothing.
+func (e *editor) confirm(action ConfirmAction, prompt string, act func()) {
+	answered := false
+	answer := func(accepted bool) {
+		if answered {
+			return
+		}
+		answered = true
+		if accepted {
+			e.enter()
+			defer e.leave()
+			act()
+		}
+	}
+
+	e.DispatchSignal(ConfirmSignal{
+		action: action,
+		prompt: prompt,
+		accept: func() { answer(true) },
+		reject: func() { answer(false) },
+	})
+}
+
+// fileNameOrDefault returns the buffer's file name, or "the file" if it has
+// none, for prompts.
+func (e *editor) fileNameOrDefault() string {
+	if name := e.buffer.FileName(); name != "" {
+		return name
+	}
+	return e.Message(MsgTheFile)
+}
+
+// executeDeleteCommand runs :d and :delete. :d deletes the cursor line, or
+// the lines of a range or a count, and so does :delete given one. On its own
+// :delete deletes the file once confirmed, and :delete! at once.
+func (e *editor) executeDeleteCommand(command string, lines *lineRange, args []string) *EditorError {
+	force := strings.HasSuffix(command, "!")
+	if command != "d" && lines == nil && len(args) == 0 {
+		if !e.allow(FileOps) {
+			return notAllowed(FileOps)
+		}
+		return e.executeDelete(force)
+	}
+	if force {
+		return &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+	}
+	return e.executeLineOperator("delete", lines, args)
+}
+
+// executeDelete asks to confirm deleting the file, warning of unsaved
+// changes, before sending DeleteFileSignal; :delete! sends it at once.
+func (e *editor) executeDelete(force bool) *EditorError {
+	deleteFile := func() { e.DispatchSignal(DeleteFileSignal{}) }
+	if force {
+		deleteFile()
+		return nil
+	}
+
+	prompt := e.Message(MsgConfirmDelete, e.fileNameOrDefault())
+	if e.buffer.IsModified() {
+		prompt = e.Message(MsgConfirmDeleteUnsaved, e.fileNameOrDefault())
+	}
+	e.confirm(ConfirmDeleteFile, prompt, deleteFile)
+	return nil
+}
+
+// executeRename asks to confirm renaming the file to the name in args before
+// sending RenameSignal; :rename! sends it at once. The buffer keeps its
+// name, and its unsaved changes, until the host answers FileRenamed.
+func (e *editor) executeRename(force bool, args []string) *EditorError {
+	if len(args) != 1 {
+		return &EditorError{
+			id:  ErrRenameFailedId,
+			err: ErrRenameFailed,
+		}
+	}
+
+	signal := RenameSignal{fileName: args[0], from: e.buffer.FileName()}
+	rename := func() { e.DispatchSignal(signal) }
+	if force {
+		rename()
+		return nil
+	}
+	e.confirm(ConfirmRename, e.Message(MsgConfirmRename, e.fileNameOrDefault(), args[0]), rename)
+	return nil
+}
+
+// FileRenamed tells the editor the host renamed the file to fileName, as a
+// RenameSignal asked, for the buffer to take the name. Unsaved changes stay
+// unsaved, to be written to the file under its new name.
+func (e *editor) FileRenamed(fileName string) {
+	e.enter()
+	defer e.leave()
+
+	e.buffer.SetFileName(fileName)
+	e.UpdateCommand(e.Message(MsgRenamed, fileName))
+}
+
+// executeSaveAs writes the whole buffer to the file named by args and makes
+// it the buffer's file, saved, as :saveas does. Content with invalid bytes
+// replaced is only written by :saveas!.
+func (e *editor) executeSaveAs(force bool, args []string) *EditorError {
+	path := strings.TrimSpace(strings.Join(args, " "))
+	if path == "" {
+		return &EditorError{
+			id:  ErrInvalidCommandId,
+			err: ErrInvalidCommand,
+		}
+	}
+
+	if err := e.formatBeforeSave(); err != nil {
+		return err
+	}
+	if !force && e.buffer.IsLossy() {
+		return &EditorError{
+			id:  ErrLossyContentId,
+			err: ErrLossyContent,
+		}
+	}
+
+	if e.save(&path, nil, false) {
+		e.buffer.SetFileName(path)
+	}
+	return nil
+}
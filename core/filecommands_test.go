@@ -0,0 +1,91 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFileCommands tests confirming :delete and :rename, and :saveas.
+func TestFileCommands(t *testing.T) {
+	newEditor := func() modeContext {
+		e := newTestEditor("one")
+		e.GetBuffer().SetFileName("notes.md")
+		drainSignals(e)
+		return e
+	}
+
+	t.Run(":delete sends DeleteFileSignal once accepted", func(t *testing.T) {
+		e := newEditor()
+		assert.Nil(t, e.ExecuteCommand("delete"))
+		confirm, ok := nextSignal(e).(ConfirmSignal)
+		assert.True(t, ok)
+		assert.Equal(t, ConfirmDeleteFile, confirm.Value())
+		assert.Equal(t, "Delete notes.md?", confirm.Prompt())
+		assert.Nil(t, nextSignal(e))
+
+		confirm.Accept()
+		assert.Equal(t, DeleteFileSignal{}, nextSignal(e))
+		confirm.Accept()
+		assert.Nil(t, nextSignal(e), "only the first answer counts")
+	})
+
+	t.Run("rejecting cancels", func(t *testing.T) {
+		e := newEditor()
+		keys(e, 'x')
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("del"))
+		confirm := nextSignal(e).(ConfirmSignal)
+		assert.Equal(t, "Delete notes.md and its unsaved changes?", confirm.Prompt())
+
+		confirm.Reject()
+		confirm.Accept()
+		assert.Nil(t, nextSignal(e))
+	})
+
+	t.Run(":delete! doesn't ask", func(t *testing.T) {
+		e := newEditor()
+		assert.Nil(t, e.ExecuteCommand("delete!"))
+		assert.Equal(t, DeleteFileSignal{}, nextSignal(e))
+	})
+
+	t.Run(":rename takes the name once the host renamed the file", func(t *testing.T) {
+		e := newEditor()
+		keys(e, 'x')
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("rename todo.md"))
+		confirm := nextSignal(e).(ConfirmSignal)
+		assert.Equal(t, ConfirmRename, confirm.Value())
+		assert.Equal(t, "Rename notes.md to todo.md?", confirm.Prompt())
+
+		confirm.Accept()
+		rename := nextSignal(e).(RenameSignal)
+		assert.Equal(t, "todo.md", rename.Value())
+		assert.Equal(t, "notes.md", rename.From())
+		assert.Equal(t, "notes.md", e.GetBuffer().FileName())
+
+		e.FileRenamed("todo.md")
+		assert.Equal(t, "todo.md", e.GetBuffer().FileName())
+		assert.True(t, e.GetBuffer().IsModified(), "unsaved changes stay unsaved")
+
+		assert.Equal(t, ErrRenameFailedId, e.ExecuteCommand("rename").ID())
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("rename! other.md"))
+		assert.Equal(t, RenameSignal{fileName: "other.md", from: "todo.md"}, nextSignal(e))
+	})
+
+	t.Run(":saveas saves to another file and edits it", func(t *testing.T) {
+		e := newEditor()
+		keys(e, 'x')
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("saveas copy.md"))
+		save := nextSignal(e).(SaveSignal)
+		path, content := save.Value()
+		assert.Equal(t, "copy.md", *path)
+		assert.Equal(t, "ne", content)
+		assert.Equal(t, "copy.md", e.GetBuffer().FileName())
+		assert.False(t, e.GetBuffer().IsModified())
+
+		assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("sav").ID())
+	})
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Formatter formats content of a language for :format, such as gofmt for Go.
+// The content's lines end with \n whatever the file's line ending, and so
+// should those formatted.
+type Formatter interface {
+	Format(content string) (string, error)
+}
+
+// FormatterFunc is a Formatter that is a function.
+type FormatterFunc func(content string) (string, error)
+
+// Format returns f(content).
+func (f FormatterFunc) Format(content string) (string, error) {
+	return f(content)
+}
+
+// JSONFormatter returns a Formatter that indents JSON with indent, such as
+// two spaces, one value or key per line. Content that isn't JSON is an error
+// giving the line it goes wrong on.
+func JSONFormatter(indent string) Formatter {
+	return FormatterFunc(func(content string) (string, error) {
+		var formatted bytes.Buffer
+		if err := json.Indent(&formatted, []byte(content), "", indent); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line := strings.Count(content[:min(int(syntaxErr.Offset), len(content))], "\n") + 1
+				return "", fmt.Errorf("line %d: %w", line, err)
+			}
+			return "", err
+		}
+		// json.Indent keeps the trailing newline, or lack of it
+		return formatted.String(), nil
+	})
+}
+
+// defaultFormatters are the formatters of languages that have one unless
+// SetFormatter changes them.
+var defaultFormatters = map[string]func() Formatter{
+	"json": func() Formatter { return JSONFormatter("  ") },
+}
+
+// SetFormatter sets the formatter of language, for :format. nil turns
+// formatting off for the language. JSON uses JSONFormatter by default.
+func (e *editor) SetFormatter(language string, formatter Formatter) {
+	if e.formatters == nil {
+		e.formatters = make(map[string]Formatter)
+	}
+	e.formatters[strings.ToLower(language)] = formatter
+}
+
+// SetFormatOnSave sets whether :w formats the content before writing all of
+// it, when the language has a formatter. Content the formatter rejects isn't
+// written.
+func (e *editor) SetFormatOnSave(enabled bool) {
+	e.formatOnSave = enabled
+}
+
+// formatter returns the formatter of the content's language, nil if it has
+// none.
+func (e *editor) formatter() Formatter {
+	if formatter, ok := e.formatters[e.language]; ok {
+		return formatter
+	}
+	if formatter, ok := defaultFormatters[e.language]; ok {
+		return formatter()
+	}
+	return nil
+}
+
+// Format formats the content with the formatter of its language, as :format
+// does, as one change. Only the lines that differ are replaced, so the
+// cursor stays on its line when that line is kept, and moves with it when
+// lines above are added or removed.
+func (e *editor) Format() *EditorError {
+	e.enter()
+	defer e.leave()
+	return e.format()
+}
+
+// format formats the content for Format.
+func (e *editor) format() *EditorError {
+	formatter := e.formatter()
+	if formatter == nil {
+		return e.localiseError(&EditorError{id: ErrNoFormatterId, err: ErrNoFormatter})
+	}
+
+	lines := e.buffer.GetLines()
+	formatted, err := formatter.Format(strings.Join(lines, "\n"))
+	if err != nil {
+		return e.localiseError(&EditorError{id: ErrFormatFailedId, err: wrapNotice(ErrFormatFailed, MsgFormatError, err)})
+	}
+	formattedLines := strings.Split(formatted, "\n")
+	for i, line := range formattedLines {
+		formattedLines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	hunks := DiffLines(lines, formattedLines)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	return e.editProgrammatically(func() error {
+		cursor := e.buffer.GetCursor()
+		cursor.Position = formattedPosition(hunks, cursor.Position)
+
+		// From the last hunk up, so the rows of those above stay put
+		for i := len(hunks) - 1; i >= 0; i-- {
+			if err := replaceHunk(e.buffer, hunks[i], formattedLines); err != nil {
+				return err
+			}
+		}
+		e.buffer.SetCursor(cursor)
+		return nil
+	})
+}
+
+// replaceHunk replaces the old lines of h in buffer with its new lines.
+func replaceHunk(buffer Buffer, h Hunk, newLines []string) error {
+	replacement := newLines[h.NewStart:h.NewEnd()]
+	switch h.Kind() {
+	case HunkAdd:
+		return buffer.InsertLines(h.OldStart, replacement)
+	case HunkDelete:
+		return buffer.DeleteLines(h.OldStart, h.OldEnd()-1)
+	default:
+		last := h.OldEnd() - 1
+		return buffer.ReplaceRange(
+			Position{Row: h.OldStart},
+			Position{Row: last, Col: buffer.LineRuneCount(last)},
+			strings.Join(replacement, "\n"),
+		)
+	}
+}
+
+// formattedPosition returns where pos goes when hunks are applied: it moves
+// with its line when the line is kept, or to the start of what replaced it.
+func formattedPosition(hunks []Hunk, pos Position) Position {
+	shift := 0
+	for _, h := range hunks {
+		switch {
+		case pos.Row >= h.OldEnd():
+			shift += h.NewLines - h.OldLines
+		case pos.Row >= h.OldStart:
+			// A line changed: its counterpart, if the hunk has as many
+			row := h.NewStart + min(pos.Row-h.OldStart, max(0, h.NewLines-1))
+			return Position{Row: row, Col: pos.Col}
+		default:
+			return Position{Row: pos.Row + shift, Col: pos.Col}
+		}
+	}
+	return Position{Row: pos.Row + shift, Col: pos.Col}
+}
+
+// formatBeforeSave formats the content if :w should before writing all of
+// it, reporting the formatter's error if it fails.
+func (e *editor) formatBeforeSave() *EditorError {
+	if !e.formatOnSave || e.formatter() == nil {
+		return nil
+	}
+	return e.format()
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFormat tests formatting the content with :format.
+func TestFormat(t *testing.T) {
+	t.Run("JSON is formatted, keeping the cursor on its line", func(t *testing.T) {
+		e := newTestEditor("{\n  \"a\":1,\n  \"b\": 2\n}", withLanguage("json"), withCursor(Position{Row: 2, Col: 3}))
+		assert.Nil(t, e.ExecuteCommand("format"))
+		assert.Equal(t, "{\n  \"a\": 1,\n  \"b\": 2\n}", content(e))
+		assert.Equal(t, Position{Row: 2, Col: 3}, cursorPos(e))
+	})
+
+	t.Run("the cursor moves with its line when lines are added above", func(t *testing.T) {
+		e := newTestEditor("{\n  \"a\": [1],\n  \"b\": 2\n}\n", withLanguage("json"), withCursor(Position{Row: 2, Col: 2}))
+		assert.Nil(t, e.Format())
+		assert.Equal(t, "{\n  \"a\": [\n    1\n  ],\n  \"b\": 2\n}\n", content(e))
+		assert.Equal(t, Position{Row: 4, Col: 2}, cursorPos(e))
+	})
+
+	t.Run("u undoes the format at once", func(t *testing.T) {
+		text := "{\"a\":[1],\n\"b\":{}}"
+		e := newTestEditor(text, withLanguage("json"), withCursor(Position{Row: 1}))
+		assert.Nil(t, e.Format())
+		keys(e, 'u')
+		assert.Equal(t, text, content(e))
+		assert.False(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("formatted content isn't changed", func(t *testing.T) {
+		e := newTestEditor("{\n  \"a\": 1\n}", withLanguage("json"), withCursor(Position{}))
+		assert.Nil(t, e.Format())
+		assert.False(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("invalid content is an error giving its line", func(t *testing.T) {
+		text := "{\n  \"a\": 1,\n  oops\n}"
+		e := newTestEditor(text, withLanguage("json"), withCursor(Position{}))
+		err := e.Format()
+		assert.Equal(t, ErrFormatFailedId, err.ID())
+		assert.ErrorIs(t, err.Error(), ErrFormatFailed)
+		assert.Contains(t, err.Error().Error(), "line 3")
+		assert.Equal(t, text, content(e))
+	})
+
+	t.Run("languages without a formatter are an error", func(t *testing.T) {
+		e := newTestEditor("text")
+		err := e.ExecuteCommand("format")
+		assert.Equal(t, ErrNoFormatterId, err.ID())
+
+		e.SetLanguage("json")
+		e.SetFormatter("JSON", nil)
+		assert.Equal(t, ErrNoFormatterId, e.Format().ID())
+	})
+
+	t.Run("the host sets formatters", func(t *testing.T) {
+		e := newTestEditor("b\na\nc", withLanguage("lines"))
+		e.SetFormatter("lines", FormatterFunc(func(content string) (string, error) {
+			return strings.ToUpper(content), nil
+		}))
+		assert.Nil(t, e.Format())
+		assert.Equal(t, "B\nA\nC", content(e))
+
+		e.SetFormatter("lines", FormatterFunc(func(string) (string, error) {
+			return "", errors.New("broken")
+		}))
+		assert.ErrorContains(t, e.Format().Error(), "broken")
+	})
+}
+
+// TestFormatOnSave tests formatting before :w with SetFormatOnSave.
+func TestFormatOnSave(t *testing.T) {
+	t.Run(":w writes the formatted content", func(t *testing.T) {
+		e := newTestEditor("{\"a\":1}\n", withLanguage("json"), withCursor(Position{}))
+		e.SetFormatOnSave(true)
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("w"))
+		save, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+		_, saved := save.Value()
+		assert.Equal(t, "{\n  \"a\": 1\n}\n", saved)
+		assert.False(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("content the formatter rejects isn't written", func(t *testing.T) {
+		e := newTestEditor("{\"a\":}", withLanguage("json"), withCursor(Position{}))
+		e.SetFormatOnSave(true)
+		keys(e, 'x')
+		drainSignals(e)
+		assert.Equal(t, ErrFormatFailedId, e.ExecuteCommand("w").ID())
+		_, ok := nextSignal(e).(SaveSignal)
+		assert.False(t, ok)
+	})
+
+	t.Run("writing part of the buffer doesn't format", func(t *testing.T) {
+		e := newTestEditor("{\"a\":\n1}", withLanguage("json"), withCursor(Position{}))
+		e.SetFormatOnSave(true)
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("1w part.json"))
+		assert.Equal(t, "{\"a\":\n1}", content(e))
+	})
+}
+
+// TestFormattedPosition tests where positions go when hunks are applied.
+func TestFormattedPosition(t *testing.T) {
+	old := []string{"a", "b", "c", "d", "e"}
+	formatted := []string{"a", "B1", "B2", "c", "e"}
+	hunks := DiffLines(old, formatted)
+
+	assert.Equal(t, Position{Row: 0, Col: 1}, formattedPosition(hunks, Position{Row: 0, Col: 1}))
+	assert.Equal(t, Position{Row: 1, Col: 1}, formattedPosition(hunks, Position{Row: 1, Col: 1}))
+	assert.Equal(t, Position{Row: 3}, formattedPosition(hunks, Position{Row: 2}))
+	assert.Equal(t, Position{Row: 4}, formattedPosition(hunks, Position{Row: 3}))
+	assert.Equal(t, Position{Row: 4}, formattedPosition(hunks, Position{Row: 4}))
+}
@@ -0,0 +1,40 @@
+package core
+
+// The editor is not safe for concurrent use. It is driven from one goroutine
+// at a time, as Bubble Tea's Update is: hosts with work in other goroutines,
+// such as a tea.Cmd, send its result back as a message instead of calling
+// the editor from there. Signals are the exception, as they are read from a
+// channel, and so is a search started by SearchAsync, which shares its
+// results through a lock.
+//
+// The calls that change the content check for this: one made while another
+// call is running panics, as concurrent map writes do, rather than
+// corrupting the buffer and the history. Within a call the editor uses the
+// unchecked forms of its methods, such as undo for Undo, and it lets go
+// while it runs the host's code, such as a command handler or a mode hook,
+// so that the host may call back into it from there.
+
+// enter marks the editor as in a call until the matching leave, panicking if
+// it is in one already.
+func (e *editor) enter() {
+	if !e.busy.CompareAndSwap(false, true) {
+		panic("core: editor used by more than one goroutine at once")
+	}
+}
+
+// leave ends the call started by enter.
+func (e *editor) leave() {
+	e.busy.Store(false)
+}
+
+// callHost runs the host's code fn, such as a command handler, outside the
+// call the editor is in, so that fn may call the editor.
+func (e *editor) callHost(fn func()) {
+	inCall := e.busy.Swap(false)
+	defer func() {
+		if inCall {
+			e.enter()
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConcurrentUse tests the check for calls from more than one goroutine.
+func TestConcurrentUse(t *testing.T) {
+	t.Run("a call during another goroutine's call panics", func(t *testing.T) {
+		e := newTestEditor("abc")
+		entered, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		e.SetValidateFunc(func(content string) error {
+			if content == "bc" {
+				close(entered)
+				<-release
+			}
+			return nil
+		})
+
+		go func() {
+			defer close(done)
+			keys(e, 'x')
+		}()
+		<-entered
+		assert.Panics(t, func() { _ = e.HandleKey(KeyEvent{Rune: 'x'}) })
+		close(release)
+		<-done
+
+		assert.NotPanics(t, func() { keys(e, 'x') })
+		assert.Equal(t, "c", content(e))
+	})
+
+	t.Run("a handler may call back into the editor", func(t *testing.T) {
+		e := newTestEditor("abc")
+		e.RegisterCommand("trim", func(editor Editor, args []string) *EditorError {
+			return editor.DeleteLines(0, 0)
+		})
+		assert.Nil(t, e.ExecuteCommand("trim"))
+		assert.Equal(t, "", content(e))
+	})
+
+	t.Run("calls may move between goroutines", func(t *testing.T) {
+		e := newTestEditor("abc")
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			keys(e, 'x')
+		}()
+		<-done
+		keys(e, 'x')
+		assert.Equal(t, "c", content(e))
+	})
+}
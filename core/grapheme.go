@@ -0,0 +1,116 @@
+package core
+
+import "github.com/rivo/uniseg"
+
+// Columns in the buffer are rune offsets. The helpers below map rune offsets to
+// grapheme cluster boundaries so that editing never splits a cluster such as an
+// emoji ZWJ sequence, a base letter with combining marks, or Hangul jamo.
+
+// isClusterBreak reports whether a grapheme cluster always starts at rune
+// offset i of the line: at either end, or where two ASCII runes meet, since
+// no ASCII rune joins a cluster other than "\r\n", which is never stored in
+// a line. Clusters can then be found from there without looking further.
+func isClusterBreak(line []rune, i int) bool {
+	return i <= 0 || i >= len(line) || line[i-1] < 0x80 && line[i] < 0x80
+}
+
+// graphemeBoundaries returns the rune offsets at which grapheme clusters start
+// around col, from the nearest break at or before col to the nearest one
+// after it, which is included. Only that part of the line is segmented, so
+// moving along a long line costs as much as the clusters around the cursor.
+func graphemeBoundaries(line []rune, col int) []int {
+	start := max(min(col, len(line)), 0)
+	for !isClusterBreak(line, start) {
+		start--
+	}
+	end := max(min(col+1, len(line)), 0)
+	for !isClusterBreak(line, end) {
+		end++
+	}
+
+	boundaries := []int{start}
+	gr := uniseg.NewGraphemes(string(line[start:end]))
+	for gr.Next() {
+		start += len(gr.Runes())
+		boundaries = append(boundaries, start)
+	}
+	return boundaries
+}
+
+// graphemeStartCol returns the start of the grapheme cluster that contains col.
+// Columns at or past the end of the line are returned unchanged.
+func graphemeStartCol(line []rune, col int) int {
+	if col <= 0 || col >= len(line) || isClusterBreak(line, col) {
+		return col
+	}
+
+	start := 0
+	for _, b := range graphemeBoundaries(line, col) {
+		if b > col {
+			break
+		}
+		start = b
+	}
+	return start
+}
+
+// nextGraphemeCol returns the start of the grapheme cluster after the one at col.
+func nextGraphemeCol(line []rune, col int) int {
+	if col >= len(line) {
+		return col
+	}
+	if isClusterBreak(line, col+1) {
+		return col + 1
+	}
+
+	for _, b := range graphemeBoundaries(line, col) {
+		if b > col {
+			return b
+		}
+	}
+	return len(line)
+}
+
+// prevGraphemeCol returns the start of the grapheme cluster before col.
+func prevGraphemeCol(line []rune, col int) int {
+	if col <= 0 {
+		return 0
+	}
+	if col > len(line) {
+		return len(line)
+	}
+	if isClusterBreak(line, col-1) {
+		return col - 1
+	}
+
+	prev := 0
+	for _, b := range graphemeBoundaries(line, col-1) {
+		if b >= col {
+			break
+		}
+		prev = b
+	}
+	return prev
+}
+
+// graphemeRuneCount returns the number of runes spanned by count grapheme
+// clusters starting at col, stopping at the end of the line.
+func graphemeRuneCount(line []rune, col, count int) int {
+	end := col
+	for range count {
+		if end >= len(line) {
+			break
+		}
+		end = nextGraphemeCol(line, end)
+	}
+	return end - col
+}
+
+// graphemeEndCol returns the last rune offset of the grapheme cluster at col.
+// It is used to make inclusive selection ends cover whole clusters.
+func graphemeEndCol(line []rune, col int) int {
+	if col < 0 || col >= len(line) {
+		return col
+	}
+	return nextGraphemeCol(line, col) - 1
+}
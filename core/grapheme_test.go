@@ -0,0 +1,151 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rivo/uniseg"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	family   = "👨‍👩‍👧"  // Emoji ZWJ sequence, 5 runes
+	eAcute   = "é"     // e + combining acute accent, 2 runes
+	hangulGa = "가"     // Conjoining jamo ᄀ + ᅡ, 2 runes
+	namaste  = "नमस्ते" // Devanagari "नमस्ते"
+)
+
+// TestGraphemeMotion tests that h/l move over whole grapheme clusters.
+func TestGraphemeMotion(t *testing.T) {
+	t.Run("l skips over a ZWJ sequence", func(t *testing.T) {
+		e := newTestEditor("a" + family + "b")
+		setWidth(e, 80)
+		keys(e, 'l', 'l')
+		assert.Equal(t, Position{0, 6}, cursorPos(e))
+	})
+
+	t.Run("h skips back over combining marks", func(t *testing.T) {
+		e := newTestEditor("a" + eAcute + "b")
+		setWidth(e, 80)
+		keys(e, '$', 'h')
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("j lands on the start of a cluster", func(t *testing.T) {
+		e := newTestEditor("abc\n" + hangulGa + hangulGa)
+		setWidth(e, 80)
+		keys(e, 'l', 'j')
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+	})
+}
+
+// TestGraphemeDelete tests that x, X and Backspace delete whole clusters.
+func TestGraphemeDelete(t *testing.T) {
+	t.Run("x deletes a ZWJ sequence", func(t *testing.T) {
+		e := newTestEditor(family + "b")
+		keys(e, 'x')
+		assert.Equal(t, "b", content(e))
+	})
+
+	t.Run("x with count counts clusters", func(t *testing.T) {
+		e := newTestEditor(eAcute + hangulGa + "z")
+		keys(e, '2', 'x')
+		assert.Equal(t, "z", content(e))
+	})
+
+	t.Run("X deletes the cluster before the cursor", func(t *testing.T) {
+		e := newTestEditor("a" + eAcute + "b")
+		setWidth(e, 80)
+		keys(e, '$', 'X')
+		assert.Equal(t, "ab", content(e))
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("Backspace in insert mode deletes a whole cluster", func(t *testing.T) {
+		e := newTestEditor("a" + family)
+		setWidth(e, 80)
+		keys(e, 'A')
+		backspace(e)
+		assert.Equal(t, "a", content(e))
+	})
+
+	t.Run("Devanagari conjuncts are not split", func(t *testing.T) {
+		e := newTestEditor(namaste)
+		keys(e, 'x')
+		assert.Equal(t, "मस्ते", content(e))
+	})
+}
+
+// TestGraphemeInsert tests typing combining sequences one rune at a time.
+func TestGraphemeInsert(t *testing.T) {
+	e := newTestEditor("z")
+	setWidth(e, 80)
+	keys(e, 'i', 'e', '́', 'x')
+	assert.Equal(t, eAcute+"xz", content(e))
+	assert.Equal(t, Position{0, 3}, cursorPos(e))
+}
+
+// TestGraphemeVisual tests that visual selections include the whole last cluster.
+func TestGraphemeVisual(t *testing.T) {
+	t.Run("d deletes the cluster under the cursor", func(t *testing.T) {
+		e := newTestEditor("a" + family + "b")
+		setWidth(e, 80)
+		keys(e, 'v', 'l', 'd')
+		assert.Equal(t, "b", content(e))
+	})
+
+	t.Run("y yanks the whole cluster", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard(eAcute + "b")
+		setWidth(e, 80)
+		keys(e, 'v', 'y')
+		assert.Equal(t, eAcute, cb.content)
+	})
+}
+
+// TestGraphemeColumns tests that finding clusters around a column agrees with
+// segmenting the whole line.
+func TestGraphemeColumns(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"plain ascii",
+		"a" + family + "b" + eAcute + eAcute + "c",
+		family + family,
+		hangulGa + " " + hangulGa + hangulGa,
+		namaste + "x" + namaste,
+		"🇷🇴🇫🇷a🇷🇴",
+		"é́x",
+	} {
+		line := []rune(text)
+
+		// The start of the cluster each rune belongs to, and where the next begins
+		starts := make([]int, len(line))
+		nexts := make([]int, len(line))
+		col := 0
+		gr := uniseg.NewGraphemes(text)
+		for gr.Next() {
+			n := len(gr.Runes())
+			for i := col; i < col+n; i++ {
+				starts[i], nexts[i] = col, col+n
+			}
+			col += n
+		}
+
+		for col := range line {
+			assert.Equal(t, starts[col], graphemeStartCol(line, col), "start of %d in %q", col, text)
+			assert.Equal(t, nexts[col], nextGraphemeCol(line, col), "next after %d in %q", col, text)
+			assert.Equal(t, nexts[col]-1, graphemeEndCol(line, col), "end of %d in %q", col, text)
+			assert.Equal(t, starts[col], prevGraphemeCol(line, nexts[col]), "before %d in %q", nexts[col], text)
+		}
+		assert.Equal(t, len(line), prevGraphemeCol(line, len(line)+2), "past the end of %q", text)
+	}
+}
+
+// BenchmarkGraphemeMotion moves along a long line that isn't ASCII, as
+// holding down l does.
+func BenchmarkGraphemeMotion(b *testing.B) {
+	line := []rune(strings.Repeat("word "+eAcute+family+" ", 2000))
+	for b.Loop() {
+		for col := 0; col < len(line); col = nextGraphemeCol(line, col) {
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,15 +13,66 @@ type testClipboard struct{ content string }
 func (c *testClipboard) Write(text string) error { c.content = text; return nil }
 func (c *testClipboard) Read() (string, error)   { return c.content, nil }
 
-func newTestEditor(content string) Editor {
-	e := New(nil)
+// editorOption sets up an editor made by newTestEditor, such as its
+// language or where the cursor starts. Options apply in order, after the
+// content is set.
+type editorOption func(e modeContext)
+
+func newTestEditor(content string, options ...editorOption) modeContext {
+	e := New(nil).(modeContext)
 	e.SetContent([]byte(content))
+	for _, option := range options {
+		option(e)
+	}
 	return e
 }
 
-func newTestEditorWithClipboard(content string) (Editor, *testClipboard) {
+// withCursor puts the cursor at pos.
+func withCursor(pos Position) editorOption {
+	return func(e modeContext) { moveTo(e, pos) }
+}
+
+// withLanguage sets the language of the content.
+func withLanguage(language string) editorOption {
+	return func(e modeContext) { e.SetLanguage(language) }
+}
+
+// withViewport sizes the viewport to width columns, which lines wrap at.
+func withViewport(width int) editorOption {
+	return func(e modeContext) { e.SetViewportSize(width, 10, width) }
+}
+
+// withTextWidth sets the text width gq and typing break lines at.
+func withTextWidth(width int) editorOption {
+	return func(e modeContext) { e.SetTextWidth(width) }
+}
+
+// withTableMode turns on table mode.
+func withTableMode() editorOption {
+	return func(e modeContext) { e.SetTableMode(true) }
+}
+
+// withoutVimMode turns Vim mode off, with a width of 80 columns.
+func withoutVimMode() editorOption {
+	return func(e modeContext) {
+		setWidth(e, 80)
+		e.DisableVimMode(true)
+	}
+}
+
+// withClipboard makes cb the clipboard.
+func withClipboard(cb *testClipboard) editorOption {
+	return func(e modeContext) { e.SetClipboard(cb) }
+}
+
+// withQuickfix sets the quickfix list.
+func withQuickfix(items ...QuickfixItem) editorOption {
+	return func(e modeContext) { e.SetQuickfixList(items) }
+}
+
+func newTestEditorWithClipboard(content string) (modeContext, *testClipboard) {
 	cb := &testClipboard{}
-	e := New(cb)
+	e := New(cb).(modeContext)
 	e.SetContent([]byte(content))
 	return e, cb
 }
@@ -38,6 +91,13 @@ func cursorPos(e Editor) Position {
 	return e.GetBuffer().GetCursor().Position
 }
 
+// moveTo puts the cursor at pos.
+func moveTo(e Editor, pos Position) {
+	cursor := e.GetBuffer().GetCursor()
+	cursor.Position = pos
+	e.GetBuffer().SetCursor(cursor)
+}
+
 func assertInsertMode(t *testing.T, e Editor) {
 	t.Helper()
 	assert.True(t, e.IsInsertMode(), "expected editor to be in insert mode")
@@ -45,7 +105,7 @@ func assertInsertMode(t *testing.T, e Editor) {
 
 // setWidth configures the editor's available text width, which is required for
 // correct column-preservation behaviour when moving up/down.
-func setWidth(e Editor, width int) {
+func setWidth(e modeContext, width int) {
 	s := e.GetState()
 	s.AvailableWidth = width
 	e.SetState(s)
@@ -55,3 +115,17 @@ func escape(e Editor)    { e.HandleKey(KeyEvent{Key: KeyEscape}) }
 func backspace(e Editor) { e.HandleKey(KeyEvent{Key: KeyBackspace}) }
 func enter(e Editor)     { e.HandleKey(KeyEvent{Key: KeyEnter}) }
 func tab(e Editor)       { e.HandleKey(KeyEvent{Key: KeyTab}) }
+func redo(e Editor)      { e.HandleKey(KeyEvent{Key: KeyCtrlR, Modifiers: ModCtrl}) }
+
+// manyLines returns n numbered lines of text, joined by line breaks, for
+// benchmarks.
+func manyLines(n int) string {
+	var b strings.Builder
+	for i := range n {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "line %d of a large buffer, with words to move over and search", i)
+	}
+	return b.String()
+}
@@ -1,21 +1,81 @@
 package core
 
-type insertMode struct{} // Can hold state if needed (e.g., for abbreviations)
+type insertMode struct {
+	literal  literalInput // Ctrl-V: insert the next key literally or by its code
+	digraph  digraphInput // Ctrl-K: enter a character by its digraph
+	register bool         // Ctrl-R: insert the register named by the next key
+}
 
 func NewInsertMode() EditorMode { return &insertMode{} }
 
 func (m *insertMode) Name() Mode { return InsertMode }
 
-func (m *insertMode) Enter(editor Editor, buffer Buffer) {
-	editor.UpdateStatus("-- INSERT --")
+func (m *insertMode) Enter(editor modeContext, buffer Buffer) {
+	editor.UpdateStatus(editor.Message(MsgInsertStatus))
 	editor.UpdateCommand("")
+	m.literal = literalInput{}
+	m.digraph = digraphInput{}
+	m.register = false
 	// Save state for undo *before* the first insertion
 	editor.SaveHistory()
 }
 
-func (m *insertMode) Exit(editor Editor, buffer Buffer) {}
+func (m *insertMode) Exit(editor modeContext, buffer Buffer) {}
+
+func (m *insertMode) HandleKey(editor modeContext, buffer Buffer, key KeyEvent) *EditorError {
+	if m.literal.active {
+		result := m.literal.handle(key)
+		if result.pending {
+			editor.UpdateCommand(m.literal.display())
+			return nil
+		}
+		editor.UpdateCommand("")
+		if err := m.insertRunes(editor, buffer, result.insert); err != nil || !result.replay {
+			return err
+		}
+	}
+
+	if m.digraph.active {
+		r := m.digraph.handle(key)
+		if m.digraph.active {
+			editor.UpdateCommand(m.digraph.display())
+			return nil
+		}
+		editor.UpdateCommand("")
+		if r == 0 {
+			return nil
+		}
+		return m.insertRunes(editor, buffer, []rune{r})
+	}
+
+	if m.register {
+		m.register = false
+		editor.UpdateCommand("")
+		switch key.Rune {
+		case '"', '+', '*': // The only register is the clipboard
+			return pasteInsert(editor)
+		}
+		return nil
+	}
+
+	switch key.Key {
+	case KeyCtrlV:
+		if key.Modifiers&ModShift != 0 { // Ctrl-Shift-V pastes, as in terminals
+			return pasteInsert(editor)
+		}
+		m.literal.start()
+		editor.UpdateCommand(m.literal.display())
+		return nil
+	case KeyCtrlK:
+		m.digraph.start()
+		editor.UpdateCommand(m.digraph.display())
+		return nil
+	case KeyCtrlR:
+		m.register = true
+		editor.UpdateCommand("^R")
+		return nil
+	}
 
-func (m *insertMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *EditorError {
 	cursor := buffer.GetCursor()
 	row, col := cursor.Position.Row, cursor.Position.Col
 	var err *EditorError
@@ -33,8 +93,9 @@ func (m *insertMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 	case KeyBackspace:
 		if col > 0 {
-			// Delete character before cursor
-			err = buffer.DeleteRunesAt(row, col-1, 1)
+			// Delete the grapheme cluster before the cursor
+			startCol := prevGraphemeCol(buffer.GetLineRunes(row), col)
+			err = buffer.DeleteRunesAt(row, startCol, col-startCol)
 			if err == nil {
 				cursor.MoveLeft(buffer, 1, availableWidth) // Move cursor back
 				buffer.SetCursor(cursor)
@@ -63,13 +124,14 @@ func (m *insertMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		return err
 
 	case KeyEnter:
-		// Insert newline character
-		insertErr := buffer.InsertRunesAt(row, col, []rune{'\n'})
+		// Insert newline character, and the indent of the line with autoindent
+		indent := autoIndent(state, buffer.GetLineRunes(row)[:col])
+		insertErr := buffer.InsertRunesAt(row, col, append([]rune{'\n'}, indent...))
 		if insertErr == nil {
-			// Move cursor to beginning of new line (which is row+1, col 0)
+			// Move cursor to the new line (which is row+1), after the indent
 			cursor.Position.Row++
-			cursor.Position.Col = 0
-			cursor.Preferred = 0 // Reset preferred col
+			cursor.Position.Col = len(indent)
+			cursor.Preferred = len(indent) % max(availableWidth, 1)
 			buffer.SetCursor(cursor)
 			editor.SaveHistory()
 		} else {
@@ -81,11 +143,11 @@ func (m *insertMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		return err
 
 	case KeyTab:
-		// Insert tab character (or spaces if configured)
-		// For simplicity, insert literal tab rune
-		insertErr := buffer.InsertRunesAt(row, col, []rune{'\t'})
+		// Insert a tab character, or spaces up to the next tab stop with expandtab
+		tab := tabText(state, buffer.GetLineRunes(row)[:col])
+		insertErr := buffer.InsertRunesAt(row, col, tab)
 		if insertErr == nil {
-			cursor.MoveRight(buffer, 1, availableWidth) // Tab counts as one "character" position for movement
+			cursor.MoveRight(buffer, len(tab), availableWidth)
 			buffer.SetCursor(cursor)
 			editor.SaveHistory()
 		} else {
@@ -115,7 +177,7 @@ func (m *insertMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 	case KeyUp:
 		if row > 0 {
-			cursor.MoveUp(buffer, 1, availableWidth) // Move cursor up
+			editor.MoveLines(&cursor, -1, false) // Move cursor up
 			buffer.SetCursor(cursor)
 			editor.SaveHistory() // Save after modification
 		}
@@ -123,7 +185,7 @@ func (m *insertMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 	case KeyDown:
 		if row < buffer.LineCount()-1 {
-			cursor.MoveDown(buffer, 1, availableWidth) // Move cursor down
+			editor.MoveLines(&cursor, 1, false) // Move cursor down
 			buffer.SetCursor(cursor)
 			editor.SaveHistory() // Save after modification
 		}
@@ -131,20 +193,48 @@ func (m *insertMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 	default: // Handle regular character runes
 		if key.Rune != 0 {
-			insertErr := buffer.InsertRunesAt(row, col, []rune{key.Rune})
-			if insertErr == nil {
-				cursor.MoveRight(buffer, 1, availableWidth) // Move cursor forward
-				buffer.SetCursor(cursor)
-				editor.SaveHistory() // Save after modification
-			} else {
-				err = &EditorError{
-					id:  ErrInvalidPositionId,
-					err: insertErr,
-				}
-			}
-			return err
+			return m.insertRunes(editor, buffer, []rune{key.Rune})
 		}
 		// Ignore unknown special keys or modifiers without runes in insert mode
 		return nil
 	}
 }
+
+// pasteInsert inserts the clipboard content at the cursor.
+func pasteInsert(editor modeContext) *EditorError {
+	_, err := editor.PasteInsert()
+	if err != nil {
+		return &EditorError{
+			id:  ErrFailedToPasteId,
+			err: err,
+		}
+	}
+	return nil
+}
+
+// insertRunes inserts runes at the cursor and moves the cursor past them,
+// breaking the line once it goes past the text width.
+func (m *insertMode) insertRunes(editor modeContext, buffer Buffer, runes []rune) *EditorError {
+	if len(runes) == 0 {
+		return nil
+	}
+
+	cursor := buffer.GetCursor()
+	if err := buffer.InsertRunesAt(cursor.Position.Row, cursor.Position.Col, runes); err != nil {
+		return &EditorError{
+			id:  ErrInvalidPositionId,
+			err: err,
+		}
+	}
+
+	cursor.MoveRight(buffer, len(runes), editor.GetState().AvailableWidth) // Move cursor forward
+	buffer.SetCursor(cursor)
+	if err := wrapAtTextWidth(editor, buffer); err != nil {
+		return &EditorError{
+			id:  ErrInvalidPositionId,
+			err: err,
+		}
+	}
+	editor.SaveHistory() // Save after modification
+	return nil
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -256,3 +257,293 @@ func TestInsertEscape(t *testing.T) {
 		assert.Equal(t, "hello", content(e))
 	})
 }
+
+// TestCommitText tests inserting composed text as a single change.
+func TestCommitText(t *testing.T) {
+	t.Run("inserts text at the cursor and moves past it", func(t *testing.T) {
+		e := newTestEditor("ab")
+		keys(e, 'l', 'i')
+		assert.Nil(t, e.CommitText("日本語"))
+		assert.Equal(t, "a日本語b", content(e))
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+	})
+
+	t.Run("is undone in one step", func(t *testing.T) {
+		e := newTestEditor("ab")
+		keys(e, 'i')
+		e.CommitText("한국어")
+		escape(e)
+		keys(e, 'u')
+		assert.Equal(t, "ab", content(e))
+	})
+
+	t.Run("multi-line text places cursor on the last line", func(t *testing.T) {
+		e := newTestEditor("x")
+		keys(e, 'i')
+		e.CommitText("one\ntwo")
+		assert.Equal(t, "one\ntwox", content(e))
+		assert.Equal(t, Position{1, 3}, cursorPos(e))
+	})
+
+	t.Run("fails outside insert mode", func(t *testing.T) {
+		e := newTestEditor("ab")
+		err := e.CommitText("z")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrInvalidModeId, err.ID())
+		assert.Equal(t, "ab", content(e))
+	})
+}
+
+// TestInsertText tests inserting pasted text as a single change.
+func TestInsertText(t *testing.T) {
+	t.Run("takes carriage returns as line breaks", func(t *testing.T) {
+		e := newTestEditor("x")
+		keys(e, 'i')
+		assert.Nil(t, e.InsertText("one\r\ntwo\rthree"))
+		assert.Equal(t, "one\ntwo\nthreex", content(e))
+		assert.Equal(t, Position{2, 5}, cursorPos(e))
+	})
+
+	t.Run("fails outside insert mode", func(t *testing.T) {
+		e := newTestEditor("ab")
+		assert.NotNil(t, e.InsertText("z"))
+		assert.Equal(t, "ab", content(e))
+	})
+}
+
+// TestInsertModePaste tests Ctrl-R and Ctrl-Shift-V — paste from the clipboard
+// in insert mode.
+func TestInsertModePaste(t *testing.T) {
+	ctrlR := KeyEvent{Key: KeyCtrlR, Modifiers: ModCtrl}
+
+	t.Run("Ctrl-R \" inserts the clipboard at the cursor", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("ab")
+		cb.content = "xyz"
+		keys(e, 'a')
+		e.HandleKey(ctrlR)
+		assert.Equal(t, "^R", e.GetState().CommandLine)
+		keys(e, '"')
+		assert.Equal(t, "", e.GetState().CommandLine)
+		assert.Equal(t, "axyzb", content(e))
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+		assertInsertMode(t, e)
+	})
+
+	t.Run("Ctrl-R + inserts a line-wise yank with its line break", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one\ntwo")
+		keys(e, 'y', 'y', 'j', 'i')
+		e.HandleKey(ctrlR)
+		keys(e, '+')
+		assert.Equal(t, "one\none\ntwo", content(e))
+		assert.Equal(t, Position{2, 0}, cursorPos(e))
+	})
+
+	t.Run("Ctrl-R with an unknown register inserts nothing", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("ab")
+		cb.content = "xyz"
+		keys(e, 'i')
+		e.HandleKey(ctrlR)
+		keys(e, 'q', 'c')
+		assert.Equal(t, "cab", content(e))
+	})
+
+	t.Run("Ctrl-Shift-V inserts the clipboard", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("ab")
+		cb.content = "xyz"
+		keys(e, 'i')
+		e.HandleKey(KeyEvent{Key: KeyCtrlV, Modifiers: ModCtrl | ModShift})
+		assert.Equal(t, "xyzab", content(e))
+	})
+
+	t.Run("is undone in one step", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("ab")
+		cb.content = "one\ntwo"
+		keys(e, 'i')
+		e.HandleKey(ctrlR)
+		keys(e, '"')
+		escape(e)
+		keys(e, 'u')
+		assert.Equal(t, "ab", content(e))
+	})
+}
+
+// TestInsertLiteral tests Ctrl-V — insert the next key literally or by its code.
+func TestInsertLiteral(t *testing.T) {
+	ctrlV := KeyEvent{Key: KeyCtrlV}
+
+	t.Run("inserts special keys as control characters", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		e.HandleKey(ctrlV)
+		e.HandleKey(KeyEvent{Key: KeyEscape})
+		e.HandleKey(ctrlV)
+		tab(e)
+		e.HandleKey(ctrlV)
+		e.HandleKey(KeyEvent{Key: KeyCtrlA, Modifiers: ModCtrl})
+		assertInsertMode(t, e)
+		assert.Equal(t, "\x1b\t\x01", content(e))
+	})
+
+	t.Run("shows the pending sequence", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		e.HandleKey(ctrlV)
+		assert.Equal(t, "^V", e.GetState().CommandLine)
+		keys(e, 'u', '0')
+		assert.Equal(t, "^Vu0", e.GetState().CommandLine)
+	})
+
+	t.Run("inserts a character by its code", func(t *testing.T) {
+		tests := []struct {
+			name string
+			keys string
+			want string
+		}{
+			{"decimal", "065", "A"},
+			{"octal", "o101", "A"},
+			{"two hex digits", "x41", "A"},
+			{"four hex digits", "u00e9", "é"},
+			{"eight hex digits", "U0001f600", "😀"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				e := newTestEditor("")
+				keys(e, 'i')
+				e.HandleKey(ctrlV)
+				keys(e, []rune(tt.keys)...)
+				assert.Equal(t, tt.want, content(e))
+				assert.Equal(t, "", e.GetState().CommandLine)
+			})
+		}
+	})
+
+	t.Run("a non-digit ends the code and is inserted after it", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		e.HandleKey(ctrlV)
+		keys(e, 'u', 'e', '9', 'z')
+		assert.Equal(t, "éz", content(e))
+	})
+
+	t.Run("a decimal code stops before going over 255", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		e.HandleKey(ctrlV)
+		keys(e, '2', '5', '6')
+		assert.Equal(t, "\x196", content(e))
+	})
+
+	t.Run("a prefix without digits inserts the letter", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		e.HandleKey(ctrlV)
+		keys(e, 'x', 'g')
+		assert.Equal(t, "xg", content(e))
+	})
+
+	t.Run("Escape after a code ends it and leaves insert mode", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		e.HandleKey(ctrlV)
+		keys(e, '6', '5')
+		escape(e)
+		assert.Equal(t, "A", content(e))
+		assert.Equal(t, NormalMode, e.GetState().Mode)
+	})
+}
+
+// TestInsertDigraph tests Ctrl-K — enter a character by its two-character digraph.
+func TestInsertDigraph(t *testing.T) {
+	ctrlK := KeyEvent{Key: KeyCtrlK}
+
+	tests := []struct {
+		name   string
+		first  rune
+		second rune
+		want   string
+	}{
+		{"acute", 'e', '\'', "é"},
+		{"grave", 'a', '!', "à"},
+		{"diaeresis", 'u', ':', "ü"},
+		{"tilde", 'n', '?', "ñ"},
+		{"cedilla", 'c', ',', "ç"},
+		{"caron", 's', '<', "š"},
+		{"reversed order", '\'', 'e', "é"},
+		{"sharp s", 's', 's', "ß"},
+		{"euro", 'E', 'u', "€"},
+		{"greek", 'l', '*', "λ"},
+		{"unknown digraph inserts the second character", 'q', 'z', "z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor("ab")
+			keys(e, 'a')
+			e.HandleKey(ctrlK)
+			keys(e, tt.first, tt.second)
+			assert.Equal(t, "a"+tt.want+"b", content(e))
+			assert.Equal(t, Position{0, 2}, cursorPos(e))
+			assert.Equal(t, "", e.GetState().CommandLine)
+		})
+	}
+
+	t.Run("shows the pending digraph", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		e.HandleKey(ctrlK)
+		assert.Equal(t, "^K", e.GetState().CommandLine)
+		keys(e, 'e')
+		assert.Equal(t, "^Ke", e.GetState().CommandLine)
+	})
+
+	t.Run("Escape cancels and stays in insert mode", func(t *testing.T) {
+		e := newTestEditor("ab")
+		keys(e, 'i')
+		e.HandleKey(ctrlK)
+		keys(e, 'e')
+		escape(e)
+		assertInsertMode(t, e)
+		assert.Equal(t, "ab", content(e))
+	})
+}
+
+// BenchmarkInsertText measures pasting a million characters in insert mode,
+// as one line and as lines of 80.
+func BenchmarkInsertText(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		text string
+	}{
+		{"one line", strings.Repeat("x", 1_000_000)},
+		{"lines of 80", strings.Repeat(strings.Repeat("x", 79)+"\n", 12_500)},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				b.StopTimer()
+				e := newTestEditor("")
+				keys(e, 'i')
+				b.StartTimer()
+				if err := e.InsertText(bench.text); err != nil {
+					b.Fatal(err.Error())
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkTypeInLargeBuffer measures typing a character in the middle of a
+// buffer of a million characters.
+func BenchmarkTypeInLargeBuffer(b *testing.B) {
+	e := newTestEditor(strings.Repeat(strings.Repeat("x", 79)+"\n", 12_500))
+	e.SetMaxHistory(100) // Each key saves the content for undo
+	cursor := e.GetBuffer().GetCursor()
+	cursor.Position = Position{Row: 6_250, Col: 40}
+	e.GetBuffer().SetCursor(cursor)
+	keys(e, 'i')
+	b.ReportAllocs()
+	for b.Loop() {
+		e.HandleKey(KeyEvent{Rune: 'x'})
+	}
+}
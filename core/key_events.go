@@ -34,9 +34,39 @@ const (
 	KeyDelete
 	KeyInsert
 
-	// Ctrl+letter shortcuts
+	// Ctrl+letter shortcuts, in alphabetical order so that KeyCtrlA+n is Ctrl
+	// and the n-th letter after A
+	KeyCtrlA
+	KeyCtrlB
+	KeyCtrlC
 	KeyCtrlD
+	KeyCtrlE
+	KeyCtrlF
+	KeyCtrlG
+	KeyCtrlH
+	KeyCtrlI
+	KeyCtrlJ
+	KeyCtrlK
+	KeyCtrlL
+	KeyCtrlM
+	KeyCtrlN
+	KeyCtrlO
+	KeyCtrlP
+	KeyCtrlQ
+	KeyCtrlR
+	KeyCtrlS
+	KeyCtrlT
 	KeyCtrlU
+	KeyCtrlV
+	KeyCtrlW
+	KeyCtrlX
+	KeyCtrlY
+	KeyCtrlZ
+
+	// KeyTimeout is no key the user typed: the host sends it when no key
+	// followed keys waiting for more, such as d or f, within TimeoutLen, and
+	// the editor drops those keys
+	KeyTimeout
 )
 
 // KeyModifiers represents modifier keys held during a keystroke
@@ -47,15 +77,50 @@ const (
 	ModCtrl KeyModifiers = 1 << iota
 	ModAlt
 	ModShift
+	ModSuper // The Windows or Command key, reported with the Kitty keyboard protocol
 )
 
-// KeyEvent represents a keyboard input event
+// KeyEvent represents a keyboard input event. Terminals with the Kitty
+// keyboard protocol tell apart keys that others send alike, such as Ctrl-I
+// and Tab, Shift-Enter and Enter, or Ctrl-Shift-A and Ctrl-A, and send
+// characters typed with Ctrl, such as Ctrl-1, as the Rune with ModCtrl.
 type KeyEvent struct {
 	Rune      rune
 	Key       KeyCode
 	Modifiers KeyModifiers
 }
 
+// CtrlKey returns the key code for Ctrl and the letter r, or KeyUnknown if r
+// is not an ASCII letter.
+func CtrlKey(r rune) KeyCode {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return KeyCtrlA + KeyCode(r-'a')
+	case r >= 'A' && r <= 'Z':
+		return KeyCtrlA + KeyCode(r-'A')
+	}
+	return KeyUnknown
+}
+
+// Legacy returns key as terminals without the Kitty keyboard protocol send
+// it, which is how the editor's modes take it: Ctrl-I is Tab, Ctrl-M is
+// Enter and Ctrl-[ is Escape. Other characters typed with Ctrl, Alt or Super
+// are no key, as the modes have no use for them. Hosts that bind such keys
+// match them before the editor sees them.
+func (k KeyEvent) Legacy() KeyEvent {
+	switch {
+	case k.Key == KeyCtrlI:
+		return KeyEvent{Key: KeyTab, Rune: '\t', Modifiers: k.Modifiers &^ ModCtrl}
+	case k.Key == KeyCtrlM:
+		return KeyEvent{Key: KeyEnter, Modifiers: k.Modifiers &^ ModCtrl}
+	case k.Key == KeyUnknown && k.Rune == '[' && k.Modifiers&ModCtrl != 0:
+		return KeyEvent{Key: KeyEscape}
+	case k.Key == KeyUnknown && k.Rune != 0 && k.Modifiers&(ModCtrl|ModAlt|ModSuper) != 0:
+		return KeyEvent{}
+	}
+	return k
+}
+
 // String returns a string representation of a Key (Refined for clarity)
 func (k KeyEvent) String() string {
 	var parts []string
@@ -70,6 +135,9 @@ func (k KeyEvent) String() string {
 	if k.Modifiers&ModShift != 0 {
 		parts = append(parts, "Shift")
 	}
+	if k.Modifiers&ModSuper != 0 {
+		parts = append(parts, "Super")
+	}
 
 	// Key representation
 	if k.Rune != 0 {
@@ -106,9 +174,18 @@ func (k KeyEvent) String() string {
 			parts = append(parts, "Delete")
 		case KeyInsert:
 			parts = append(parts, "Insert")
+		case KeyTimeout:
+			parts = append(parts, "Timeout")
 		case KeyUnknown:
 			parts = append(parts, "Unknown")
 		default:
+			if k.Key >= KeyCtrlA && k.Key <= KeyCtrlZ {
+				if k.Modifiers&ModCtrl == 0 {
+					parts = append(parts, "Ctrl")
+				}
+				parts = append(parts, string(rune('A'+k.Key-KeyCtrlA)))
+				break
+			}
 			parts = append(parts, fmt.Sprintf("SpecialKey(%d)", k.Key))
 		}
 	}
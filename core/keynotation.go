@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// namedKeys are the keys ParseKeys reads by name, lower case.
+var namedKeys = map[string]KeyEvent{
+	"esc":      {Key: KeyEscape},
+	"cr":       {Key: KeyEnter},
+	"enter":    {Key: KeyEnter},
+	"return":   {Key: KeyEnter},
+	"tab":      {Key: KeyTab, Rune: '\t'},
+	"bs":       {Key: KeyBackspace},
+	"space":    {Key: KeySpace, Rune: ' '},
+	"up":       {Key: KeyUp},
+	"down":     {Key: KeyDown},
+	"left":     {Key: KeyLeft},
+	"right":    {Key: KeyRight},
+	"home":     {Key: KeyHome},
+	"end":      {Key: KeyEnd},
+	"pageup":   {Key: KeyPageUp},
+	"pagedown": {Key: KeyPageDown},
+	"del":      {Key: KeyDelete},
+	"insert":   {Key: KeyInsert},
+	"lt":       {Rune: '<'},
+	"bslash":   {Rune: '\\'},
+}
+
+// ParseKeys reads keys written in Vim's notation, as in mappings: characters
+// are typed as they are, and names in angle brackets are keys such as <Esc>,
+// <CR>, <Tab>, <BS>, <Space>, <Up> or <Del>, with C-, A- (or M-), S- and D-
+// for Ctrl, Alt, Shift and Super, as in <C-r>, <S-Tab> and <D-s>. Ctrl with
+// a character other than a letter, such as <C-1>, is the character with
+// ModCtrl, as terminals with the Kitty keyboard protocol send it. <lt> is a
+// <; a < that doesn't start a name is one too.
+func ParseKeys(notation string) ([]KeyEvent, error) {
+	var events []KeyEvent
+	runes := []rune(notation)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '<' {
+			if end := nameEnd(runes, i+1); end > 0 {
+				event, err := parseNamedKey(string(runes[i+1 : end]))
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
+				i = end
+				continue
+			}
+		}
+
+		switch r {
+		case ' ':
+			events = append(events, namedKeys["space"])
+		case '\t':
+			events = append(events, namedKeys["tab"])
+		case '\n':
+			events = append(events, namedKeys["cr"])
+		default:
+			events = append(events, KeyEvent{Rune: r})
+		}
+	}
+	return events, nil
+}
+
+// nameEnd returns the index of the > that ends the name starting at start,
+// or 0 if no name starts there.
+func nameEnd(runes []rune, start int) int {
+	for i := start; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '>' && i > start:
+			return i
+		case r == '-' && i > start,
+			r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// parseNamedKey returns the key written as name between angle brackets.
+func parseNamedKey(name string) (KeyEvent, error) {
+	var modifiers KeyModifiers
+	rest := name
+	for len(rest) > 2 && rest[1] == '-' {
+		switch strings.ToLower(rest[:1]) {
+		case "c":
+			modifiers |= ModCtrl
+		case "a", "m":
+			modifiers |= ModAlt
+		case "s":
+			modifiers |= ModShift
+		case "d":
+			modifiers |= ModSuper
+		default:
+			return KeyEvent{}, fmt.Errorf("unknown modifier in <%s>", name)
+		}
+		rest = rest[2:]
+	}
+
+	event, ok := namedKeys[strings.ToLower(rest)]
+	switch {
+	case ok:
+	case len([]rune(rest)) == 1:
+		event = KeyEvent{Rune: []rune(rest)[0]}
+	default:
+		return KeyEvent{}, fmt.Errorf("unknown key <%s>", name)
+	}
+
+	if modifiers&ModCtrl != 0 && event.Key == KeyUnknown {
+		if key := CtrlKey(event.Rune); key != KeyUnknown {
+			event = KeyEvent{Key: key}
+		}
+	}
+	if modifiers&ModShift != 0 && event.Key == KeyUnknown {
+		// Shift has changed the character already, so <A-S-a> is <A-A>
+		event.Rune = unicode.ToUpper(event.Rune)
+	}
+	event.Modifiers = modifiers
+	return event, nil
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// defaultTabWidth is the columns between tab stops when the tab width is 0.
+const defaultTabWidth = 4
+
+// LanguageConfig holds the settings of a language, which SetLanguage applies
+// when the content's language changes to it, such as tabs for Go and two
+// spaces for YAML.
+type LanguageConfig struct {
+	TabWidth      int    // Columns between tab stops, which > indents by; 4 if 0
+	ExpandTab     bool   // Tab and > insert spaces rather than tabs
+	CommentString string // Starts line comments, such as //, kept by gq; the language's own if empty
+	AutoIndent    bool   // Enter, o and O start the new line with the indent of the one before
+	TextWidth     int    // Columns gq reflows text to and typing breaks lines at, as SetTextWidth
+}
+
+// SetLanguage sets the language of the content, such as sql, which picks the
+// syntax of its statements and applies the settings ConfigureLanguage gave
+// it. A language without settings keeps the ones in use.
+func (e *editor) SetLanguage(language string) {
+	e.language = strings.ToLower(language)
+	e.state.StatementSyntax = e.statementSyntax(e.language)
+	if config, ok := e.languageConfigs[e.language]; ok {
+		e.applyLanguageConfig(config)
+	}
+}
+
+// ConfigureLanguage sets the settings of language, applied whenever
+// SetLanguage picks it, and at once if it's the content's language.
+func (e *editor) ConfigureLanguage(language string, config LanguageConfig) {
+	language = strings.ToLower(language)
+	if e.languageConfigs == nil {
+		e.languageConfigs = make(map[string]LanguageConfig)
+	}
+	e.languageConfigs[language] = config
+	if language == e.language {
+		e.applyLanguageConfig(config)
+	}
+}
+
+// applyLanguageConfig puts the settings of config in the state.
+func (e *editor) applyLanguageConfig(config LanguageConfig) {
+	e.state.TabWidth = max(config.TabWidth, 0)
+	e.state.ExpandTab = config.ExpandTab
+	e.state.AutoIndent = config.AutoIndent
+	e.SetTextWidth(config.TextWidth)
+}
+
+// tabWidth returns the columns between the tab stops of state.
+func tabWidth(state State) int {
+	if state.TabWidth <= 0 {
+		return defaultTabWidth
+	}
+	return state.TabWidth
+}
+
+// columns returns the columns s takes at the start of a line, tabs going to
+// the next multiple of tabs.
+func columns(s string, tabs int) int {
+	cols := 0
+	for _, part := range strings.SplitAfter(s, "\t") {
+		text, tab := strings.CutSuffix(part, "\t")
+		cols += uniseg.StringWidth(text)
+		if tab {
+			cols += tabs - cols%tabs
+		}
+	}
+	return cols
+}
+
+// tabText returns what Tab inserts after the text before the cursor: a tab,
+// or with ExpandTab the spaces up to the next tab stop.
+func tabText(state State, before []rune) []rune {
+	if !state.ExpandTab {
+		return []rune{'\t'}
+	}
+	tabs := tabWidth(state)
+	return []rune(strings.Repeat(" ", tabs-columns(string(before), tabs)%tabs))
+}
+
+// autoIndent returns the indent a line opened after line starts with: its
+// leading blanks with AutoIndent, none without.
+func autoIndent(state State, line []rune) []rune {
+	if !state.AutoIndent {
+		return nil
+	}
+	end := 0
+	for end < len(line) && isBlank(line[end]) {
+		end++
+	}
+	return slices.Clone(line[:end])
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigureLanguage tests applying the settings of a language when
+// SetLanguage picks it.
+func TestConfigureLanguage(t *testing.T) {
+	newConfiguredEditor := func(content string) modeContext {
+		e := newTestEditor(content)
+		e.ConfigureLanguage("Go", LanguageConfig{TabWidth: 8})
+		e.ConfigureLanguage("yaml", LanguageConfig{TabWidth: 2, ExpandTab: true, AutoIndent: true, TextWidth: 40})
+		return e
+	}
+
+	t.Run("switching languages switches the settings", func(t *testing.T) {
+		e := newConfiguredEditor("")
+		e.SetLanguage("yaml")
+		state := e.GetState()
+		assert.Equal(t, 2, state.TabWidth)
+		assert.True(t, state.ExpandTab)
+		assert.True(t, state.AutoIndent)
+		assert.Equal(t, 40, state.TextWidth)
+
+		e.SetLanguage("go")
+		state = e.GetState()
+		assert.Equal(t, 8, state.TabWidth)
+		assert.False(t, state.ExpandTab)
+		assert.False(t, state.AutoIndent)
+		assert.Equal(t, 0, state.TextWidth)
+	})
+
+	t.Run("a language without settings keeps the ones in use", func(t *testing.T) {
+		e := newConfiguredEditor("")
+		e.SetLanguage("yaml")
+		e.SetLanguage("markdown")
+		assert.Equal(t, 2, e.GetState().TabWidth)
+	})
+
+	t.Run("configuring the current language applies at once", func(t *testing.T) {
+		e := newTestEditor("", withLanguage("python"))
+		e.ConfigureLanguage("python", LanguageConfig{ExpandTab: true})
+		assert.True(t, e.GetState().ExpandTab)
+	})
+
+	t.Run("expandtab makes Tab insert spaces up to the next tab stop", func(t *testing.T) {
+		e := newConfiguredEditor("")
+		e.SetLanguage("yaml")
+		keys(e, 'i', 'a')
+		e.HandleKey(KeyEvent{Key: KeyTab})
+		keys(e, 'b')
+		assert.Equal(t, "a b", content(e))
+
+		e.SetLanguage("go")
+		e.HandleKey(KeyEvent{Key: KeyTab})
+		assert.Equal(t, "a b\t", content(e))
+	})
+
+	t.Run("autoindent keeps the indent on new lines", func(t *testing.T) {
+		e := newConfiguredEditor("  key:")
+		e.SetLanguage("yaml")
+		keys(e, 'A')
+		e.HandleKey(KeyEvent{Key: KeyEnter})
+		keys(e, 'x')
+		assert.Equal(t, "  key:\n  x", content(e))
+
+		e.HandleKey(KeyEvent{Key: KeyEscape})
+		keys(e, 'o', 'y')
+		e.HandleKey(KeyEvent{Key: KeyEscape})
+		keys(e, 'O', 'z')
+		assert.Equal(t, "  key:\n  x\n  z\n  y", content(e))
+	})
+
+	t.Run("> indents by the tab width in spaces with expandtab", func(t *testing.T) {
+		e := newConfiguredEditor("a")
+		e.SetLanguage("yaml")
+		keys(e, 'V', '>')
+		assert.Equal(t, "  a", content(e))
+		keys(e, 'V', '<')
+		assert.Equal(t, "a", content(e))
+	})
+
+	t.Run("the comment string is kept by gq", func(t *testing.T) {
+		e := newTestEditor("# one two three four five six")
+		e.ConfigureLanguage("hcl", LanguageConfig{CommentString: "#", TextWidth: 15})
+		e.SetLanguage("hcl")
+		keys(e, 'g', 'q', 'q')
+		assert.Equal(t, "# one two three\n# four five six", content(e))
+	})
+
+	t.Run(":set sets the tab options", func(t *testing.T) {
+		e := newTestEditor("")
+		assert.Nil(t, e.ExecuteCommand("set ts=3 et ai"))
+		state := e.GetState()
+		assert.Equal(t, 3, state.TabWidth)
+		assert.True(t, state.ExpandTab)
+		assert.True(t, state.AutoIndent)
+
+		assert.Nil(t, e.ExecuteCommand("set noet noai"))
+		assert.False(t, e.GetState().ExpandTab)
+		assert.False(t, e.GetState().AutoIndent)
+		assert.NotNil(t, e.ExecuteCommand("set tabstop=0"))
+	})
+}
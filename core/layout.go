@@ -0,0 +1,173 @@
+package core
+
+// LayoutProvider tells the editor how lines are laid out on screen, for the
+// motions that move by display lines, gj and gk. The host implements it with
+// the layout it draws; without one, lines are taken to wrap every
+// AvailableWidth columns, one cell each.
+type LayoutProvider interface {
+	// DisplayLines returns the columns the display lines of row start at, in
+	// order, the first being 0.
+	DisplayLines(row int) []int
+
+	// DisplayWidth returns the cells the text of row from column from up to
+	// column to takes on screen, from on its display line and to on the same.
+	DisplayWidth(row, from, to int) int
+}
+
+// fixedWidthLayout is the layout of lines wrapped every width columns, for
+// hosts that don't set a LayoutProvider.
+type fixedWidthLayout struct {
+	buffer Buffer
+	width  int
+}
+
+func (l fixedWidthLayout) DisplayLines(row int) []int {
+	starts := []int{0}
+	for col := l.width; col < l.buffer.LineRuneCount(row); col += l.width {
+		starts = append(starts, col)
+	}
+	return starts
+}
+
+func (l fixedWidthLayout) DisplayWidth(row, from, to int) int {
+	return to - from
+}
+
+// SetLayoutProvider sets how lines are laid out on screen, for gj and gk. nil
+// takes them to wrap every AvailableWidth columns.
+func (e *editor) SetLayoutProvider(provider LayoutProvider) {
+	e.layoutProvider = provider
+}
+
+// SetDisplayLineMovement sets whether j, k and the up and down arrows move by
+// display lines, as gj and gk do, which then move by lines.
+func (e *editor) SetDisplayLineMovement(enabled bool) {
+	e.state.DisplayLineMovement = enabled
+}
+
+// Layout returns the layout set by SetLayoutProvider, or lines wrapped every
+// AvailableWidth columns.
+func (e *editor) Layout() LayoutProvider {
+	if e.layoutProvider != nil {
+		return e.layoutProvider
+	}
+	return fixedWidthLayout{buffer: e.buffer, width: max(1, e.state.AvailableWidth)}
+}
+
+// displayLineOf returns the index in starts of the display line col is on.
+func displayLineOf(starts []int, col int) int {
+	line := 0
+	for i, start := range starts {
+		if start > col {
+			break
+		}
+		line = i
+	}
+	return line
+}
+
+// visualColumn returns the cells from the start of the display line pos is
+// on to pos.
+func visualColumn(layout LayoutProvider, pos Position) int {
+	starts := layout.DisplayLines(pos.Row)
+	return layout.DisplayWidth(pos.Row, starts[displayLineOf(starts, pos.Col)], pos.Col)
+}
+
+// columnAt returns the column on display line line of row that is cells
+// from its start, or the last before it, given where the display lines of row
+// start. Only the last display line has the end of the line.
+func columnAt(buffer Buffer, layout LayoutProvider, row int, starts []int, line, cells int) int {
+	runes := buffer.GetLineRunes(row)
+	from := starts[line]
+	end := len(runes)
+	if line+1 < len(starts) {
+		end = starts[line+1]
+	}
+	col := from
+	for col < end {
+		next := nextGraphemeCol(runes, col)
+		if next >= end && line+1 < len(starts) || layout.DisplayWidth(row, from, next) > cells {
+			break
+		}
+		col = next
+	}
+	return col
+}
+
+// MoveDisplayLines moves the cursor count display lines down, or up when
+// count is negative, as gj and gk do, to the column Preferred cells from the
+// start of the display line, or the last before it. It stops at the ends of
+// the buffer, returning ErrEndOfBuffer or ErrStartOfBuffer if it couldn't
+// move at all.
+func (c *Cursor) MoveDisplayLines(buffer Buffer, layout LayoutProvider, count int) error {
+	row := c.Position.Row
+	starts := layout.DisplayLines(row)
+	line := displayLineOf(starts, c.Position.Col)
+
+	moved := 0
+	for moved < max(count, -count) {
+		if count > 0 && line+1 < len(starts) {
+			line++
+		} else if count > 0 && row+1 < buffer.LineCount() {
+			row++
+			starts = layout.DisplayLines(row)
+			line = 0
+		} else if count < 0 && line > 0 {
+			line--
+		} else if count < 0 && row > 0 {
+			row--
+			starts = layout.DisplayLines(row)
+			line = len(starts) - 1
+		} else {
+			break // At the end of the buffer
+		}
+		moved++
+	}
+	if moved == 0 && count < 0 {
+		return ErrStartOfBuffer
+	}
+	if moved == 0 {
+		return ErrEndOfBuffer
+	}
+
+	c.Position = Position{Row: row, Col: columnAt(buffer, layout, row, starts, line, c.Preferred)}
+	return nil
+}
+
+// MoveLinesVisually moves the cursor count lines down, or up when count is
+// negative, as j and k do, to the column Preferred cells from the start of
+// the first display line, or the last before it. It stops at the ends of the
+// buffer, returning ErrEndOfBuffer or ErrStartOfBuffer if it couldn't move
+// at all.
+func (c *Cursor) MoveLinesVisually(buffer Buffer, layout LayoutProvider, count int) error {
+	row := max(0, min(c.Position.Row+count, buffer.LineCount()-1))
+	if row == c.Position.Row && count < 0 {
+		return ErrStartOfBuffer
+	}
+	if row == c.Position.Row {
+		return ErrEndOfBuffer
+	}
+
+	c.Position = Position{Row: row, Col: columnAt(buffer, layout, row, layout.DisplayLines(row), 0, c.Preferred)}
+	return nil
+}
+
+// MoveLines moves cursor count lines down, or up when count is negative, by
+// display lines if byDisplayLines, keeping it in its screen column, for j, k,
+// gj, gk and the arrows. Moving up and down in a row, it keeps the column it
+// wanted past lines too short for it; otherwise it wants the column it's in.
+func (e *editor) MoveLines(cursor *Cursor, count int, byDisplayLines bool) error {
+	layout := e.Layout()
+	if cursor.Position != e.verticalMoveEnd {
+		cursor.Preferred = visualColumn(layout, cursor.Position)
+	}
+
+	var err error
+	if byDisplayLines {
+		err = cursor.MoveDisplayLines(e.buffer, layout, count)
+	} else {
+		err = cursor.MoveLinesVisually(e.buffer, layout, count)
+	}
+	e.verticalMoveEnd = cursor.Position
+	return err
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLayout lays rows out as given, each rune a cell.
+type testLayout map[int][]int
+
+func (l testLayout) DisplayLines(row int) []int {
+	if starts, ok := l[row]; ok {
+		return starts
+	}
+	return []int{0}
+}
+
+func (l testLayout) DisplayWidth(row, from, to int) int {
+	return to - from
+}
+
+// TestDisplayLineMovement tests moving by display lines with gj and gk.
+func TestDisplayLineMovement(t *testing.T) {
+	const text = "0123456789abcdefghij\nshort"
+
+	t.Run("gj and gk move by display lines", func(t *testing.T) {
+		e := newTestEditor(text, withViewport(10), withCursor(Position{Row: 0, Col: 3}))
+		keys(e, 'g', 'j')
+		assert.Equal(t, Position{Row: 0, Col: 13}, cursorPos(e))
+		keys(e, 'g', 'j')
+		assert.Equal(t, Position{Row: 1, Col: 3}, cursorPos(e))
+		keys(e, 'g', 'k')
+		assert.Equal(t, Position{Row: 0, Col: 13}, cursorPos(e))
+	})
+
+	t.Run("with a count", func(t *testing.T) {
+		e := newTestEditor(text, withViewport(10), withCursor(Position{Row: 1, Col: 3}))
+		keys(e, '2', 'g', 'k')
+		assert.Equal(t, Position{Row: 0, Col: 3}, cursorPos(e))
+		keys(e, 'g', 'k')
+		assert.Equal(t, Position{Row: 0, Col: 3}, cursorPos(e))
+	})
+
+	t.Run("the cursor keeps its screen column past shorter lines", func(t *testing.T) {
+		e := newTestEditor("0123456789abc\n0123456789", withViewport(10), withCursor(Position{Row: 0, Col: 8}))
+		keys(e, 'g', 'j')
+		assert.Equal(t, Position{Row: 0, Col: 13}, cursorPos(e))
+		keys(e, 'g', 'j')
+		assert.Equal(t, Position{Row: 1, Col: 8}, cursorPos(e))
+	})
+
+	t.Run("the layout provider says where lines wrap", func(t *testing.T) {
+		e := newTestEditor("one two three\nfour", withViewport(10), withCursor(Position{Row: 0, Col: 5}))
+		e.SetLayoutProvider(testLayout{0: {0, 8}})
+		keys(e, 'g', 'j')
+		assert.Equal(t, Position{Row: 0, Col: 13}, cursorPos(e))
+		keys(e, 'g', 'k')
+		assert.Equal(t, Position{Row: 0, Col: 5}, cursorPos(e))
+	})
+
+	t.Run("display line movement swaps j and k with gj and gk", func(t *testing.T) {
+		e := newTestEditor(text, withViewport(10), withCursor(Position{Row: 0, Col: 3}))
+		e.SetDisplayLineMovement(true)
+		keys(e, 'j')
+		assert.Equal(t, Position{Row: 0, Col: 13}, cursorPos(e))
+		keys(e, 'g', 'k') // A line up, and there's none
+		assert.Equal(t, Position{Row: 0, Col: 13}, cursorPos(e))
+		keys(e, 'g', 'j')
+		assert.Equal(t, Position{Row: 1, Col: 3}, cursorPos(e))
+	})
+
+	t.Run("visual mode extends the selection by display lines", func(t *testing.T) {
+		e := newTestEditor(text, withViewport(10), withCursor(Position{Row: 0, Col: 2}))
+		e.SetDisplayLineMovement(true)
+		keys(e, 'v', 'j', 'd')
+		assert.Equal(t, "01defghij\nshort", content(e))
+	})
+}
+
+// TestMoveDisplayLines tests moving the cursor by display lines.
+func TestMoveDisplayLines(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.SetContent([]byte("0123456789abc\n\nxy"))
+	layout := testLayout{0: {0, 10}}
+
+	cursor := Cursor{Position: Position{Row: 0, Col: 4}, Preferred: 4}
+	assert.NoError(t, cursor.MoveDisplayLines(buffer, layout, 3))
+	assert.Equal(t, Position{Row: 2, Col: 2}, cursor.Position)
+
+	assert.ErrorIs(t, cursor.MoveDisplayLines(buffer, layout, 1), ErrEndOfBuffer)
+	assert.NoError(t, cursor.MoveDisplayLines(buffer, layout, -5))
+	assert.Equal(t, Position{Row: 0, Col: 4}, cursor.Position)
+	assert.ErrorIs(t, cursor.MoveDisplayLines(buffer, layout, -1), ErrStartOfBuffer)
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// SetMaxLength limits the content to maxLength runes, counting each line
+// break as one. Edits that would make it longer are rejected with
+// ErrMaxLengthId. Zero or less removes the limit.
+func (e *editor) SetMaxLength(maxLength int) {
+	e.maxLength = max(maxLength, 0)
+}
+
+// SetValidateFunc sets a function that checks the content after each edit.
+// Edits it returns an error for are rejected with ErrInvalidInputId and that
+// error. nil removes it.
+func (e *editor) SetValidateFunc(validate func(content string) error) {
+	e.validate = validate
+}
+
+// checkedEdit runs edit and undoes what it changed if the content changes
+// protected text, breaks the maximum length or fails validation, returning
+// why instead of its error. Otherwise it moves the protected ranges with the
+// text around them.
+func (e *editor) checkedEdit(edit func() *EditorError) *EditorError {
+	return e.runCheckedEdit(edit, false)
+}
+
+// checkedUserEdit is checkedEdit for keys and text the user typed, which
+// also may not change the content without EditText. Whatever blocked keys
+// missed, such as ex commands that edit, is undone here.
+func (e *editor) checkedUserEdit(edit func() *EditorError) *EditorError {
+	return e.runCheckedEdit(edit, !e.HasCapability(EditText))
+}
+
+// runCheckedEdit runs edit for checkedEdit, rejecting any change to the
+// content if readOnly is set.
+func (e *editor) runCheckedEdit(edit func() *EditorError, readOnly bool) *EditorError {
+	if !readOnly && !e.hasEditChecks() {
+		return e.localiseError(edit())
+	}
+
+	snapshot := e.editSnapshot()
+	snapshot.readOnly = readOnly
+	err := edit()
+	if rejected := e.checkEdit(snapshot); rejected != nil {
+		if rejected.id == ErrNotAllowedId {
+			e.DispatchSignal(BlockedSignal{capability: EditText})
+		}
+		return e.localiseError(rejected)
+	}
+	return e.localiseError(err)
+}
+
+// hasEditChecks reports whether edits are checked by checkedEdit.
+func (e *editor) hasEditChecks() bool {
+	return e.maxLength != 0 || e.validate != nil || len(e.protected) > 0
+}
+
+// editState is what an edit checked by checkEdit started from.
+type editState struct {
+	content    string
+	cursor     Cursor
+	history    []historyEntry
+	historyPos int
+	undoLine   lineUndo
+	protected  []rune // Text the protected ranges are in, nil if there are none
+	readOnly   bool   // Whether any change to the content is rejected
+}
+
+// editSnapshot returns the state to check an edit against.
+func (e *editor) editSnapshot() editState {
+	s := editState{
+		content:    e.buffer.GetCurrentContent(),
+		cursor:     e.buffer.GetCursor(),
+		history:    slices.Clone(e.history),
+		historyPos: e.historyPos,
+		undoLine:   e.undoLine,
+	}
+	if len(e.protected) > 0 {
+		s.protected = e.protectedText()
+	}
+	return s
+}
+
+// checkEdit undoes the changes made since snapshot and returns why if they
+// aren't allowed, change protected text, break the maximum length or fail
+// validation.
+// Otherwise it moves the protected ranges with the text around them.
+func (e *editor) checkEdit(snapshot editState) *EditorError {
+	after := e.buffer.GetCurrentContent()
+	if after == snapshot.content {
+		return nil
+	}
+
+	var rejected *EditorError
+	protected, unchanged := e.protected, true
+	if len(e.protected) > 0 {
+		protected, unchanged = e.moveProtected(snapshot.protected, e.protectedText())
+	}
+	if snapshot.readOnly {
+		rejected = notAllowed(EditText)
+	} else if !unchanged {
+		rejected = &EditorError{id: ErrProtectedId, err: ErrProtected}
+	} else if length := utf8.RuneCountInString(after); e.maxLength > 0 && length > e.maxLength && length > utf8.RuneCountInString(snapshot.content) {
+		rejected = &EditorError{
+			id:  ErrMaxLengthId,
+			err: wrapNotice(ErrMaxLength, MsgTooLong, e.maxLength),
+		}
+	} else if e.validate != nil {
+		if invalid := e.validate(after); invalid != nil {
+			rejected = &EditorError{id: ErrInvalidInputId, err: invalid}
+		}
+	}
+	if rejected == nil {
+		e.protected = protected
+		return nil
+	}
+
+	e.restoreHistory(snapshot.content, snapshot.cursor)
+	e.history, e.historyPos, e.undoLine = snapshot.history, snapshot.historyPos, snapshot.undoLine
+	e.historySaved = false
+	return rejected
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMaxLength tests rejecting edits that make the content too long.
+func TestMaxLength(t *testing.T) {
+	t.Run("typing past the limit is rejected", func(t *testing.T) {
+		e := newTestEditor("abc")
+		e.SetMaxLength(4)
+		keys(e, 'A', 'd')
+		err := e.HandleKey(KeyEvent{Rune: 'e'})
+		assert.Equal(t, "abcd", content(e))
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrMaxLengthId, err.ID())
+			assert.ErrorIs(t, err.Error(), ErrMaxLength)
+		}
+	})
+
+	t.Run("a paste is rejected whole, not cut", func(t *testing.T) {
+		e := newTestEditor("ab")
+		e.SetMaxLength(5)
+		keys(e, 'A')
+		err := e.InsertText("cdefg")
+		assert.NotNil(t, err)
+		assert.Equal(t, "ab", content(e))
+	})
+
+	t.Run("line breaks count", func(t *testing.T) {
+		e := newTestEditor("ab")
+		e.SetMaxLength(2)
+		keys(e, 'A')
+		enter(e)
+		assert.Equal(t, "ab", content(e))
+	})
+
+	t.Run("content already too long can shrink", func(t *testing.T) {
+		e := newTestEditor("abcdef")
+		e.SetMaxLength(3)
+		keys(e, 'x')
+		assert.Equal(t, "bcdef", content(e))
+	})
+
+	t.Run("a rejected edit leaves the history alone", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("abc")
+		keys(e, 'x', 'u')
+		e.SetMaxLength(3)
+		cb.content = "de"
+		keys(e, 'p')
+		assert.Equal(t, "abc", content(e))
+		redo(e)
+		assert.Equal(t, "bc", content(e))
+	})
+
+	t.Run("zero removes the limit", func(t *testing.T) {
+		e := newTestEditor("abc")
+		e.SetMaxLength(3)
+		e.SetMaxLength(0)
+		keys(e, 'A', 'd')
+		assert.Equal(t, "abcd", content(e))
+	})
+}
+
+// TestValidateFunc tests rejecting edits that the host's function finds
+// invalid.
+func TestValidateFunc(t *testing.T) {
+	errNotNumber := errors.New("digits only")
+	digits := func(content string) error {
+		if strings.Trim(content, "0123456789") != "" {
+			return errNotNumber
+		}
+		return nil
+	}
+
+	t.Run("invalid characters are rejected", func(t *testing.T) {
+		e := newTestEditor("12")
+		e.SetValidateFunc(digits)
+		keys(e, 'A', '3')
+		err := e.HandleKey(KeyEvent{Rune: 'x'})
+		assert.Equal(t, "123", content(e))
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrInvalidInputId, err.ID())
+			assert.Equal(t, errNotNumber, err.Error())
+		}
+	})
+
+	t.Run("keys that don't change the content are not checked", func(t *testing.T) {
+		e := newTestEditor("ab")
+		e.SetValidateFunc(digits)
+		assert.Nil(t, e.HandleKey(KeyEvent{Rune: 'l'}))
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("nil removes it", func(t *testing.T) {
+		e := newTestEditor("12")
+		e.SetValidateFunc(digits)
+		e.SetValidateFunc(nil)
+		keys(e, 'A', 'x')
+		assert.Equal(t, "12x", content(e))
+	})
+}
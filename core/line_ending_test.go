@@ -0,0 +1,70 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLineEnding tests detection and preservation of CRLF and LF line endings.
+func TestLineEnding(t *testing.T) {
+	t.Run("LF content is detected as unix", func(t *testing.T) {
+		e := newTestEditor("a\nb")
+		assert.Equal(t, LineEndingLF, e.GetBuffer().LineEnding())
+		assert.Equal(t, []string{"a", "b"}, e.GetBuffer().GetLines())
+	})
+
+	t.Run("CRLF content is detected as dos and lines have no carriage returns", func(t *testing.T) {
+		e := newTestEditor("a\r\nb")
+		assert.Equal(t, LineEndingCRLF, e.GetBuffer().LineEnding())
+		assert.Equal(t, []string{"a", "b"}, e.GetBuffer().GetLines())
+		assert.Equal(t, "a\r\nb", content(e))
+	})
+
+	t.Run("edits keep CRLF line endings", func(t *testing.T) {
+		e := newTestEditor("a\r\nb")
+		keys(e, 'o', 'c')
+		escape(e)
+		assert.Equal(t, "a\r\nc\r\nb", content(e))
+		assert.True(t, e.GetBuffer().IsModified())
+	})
+
+	t.Run("carriage returns in LF files are kept", func(t *testing.T) {
+		e := newTestEditor("a\rb\nc")
+		assert.Equal(t, LineEndingLF, e.GetBuffer().LineEnding())
+		assert.Equal(t, "a\rb\nc", content(e))
+	})
+
+	t.Run(":set fileformat=dos converts on save", func(t *testing.T) {
+		e := newTestEditor("a\nb")
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("set fileformat=dos"))
+		assert.True(t, e.GetBuffer().IsModified())
+		assert.Nil(t, e.ExecuteCommand("w"))
+
+		sig, ok := nextSignal(e).(SaveSignal)
+		assert.True(t, ok)
+		_, saved := sig.Value()
+		assert.Equal(t, "a\r\nb", saved)
+	})
+
+	t.Run(":set ff=unix converts back", func(t *testing.T) {
+		e := newTestEditor("a\r\nb")
+		assert.Nil(t, e.ExecuteCommand("set ff=unix"))
+		assert.Equal(t, "a\nb", content(e))
+	})
+
+	t.Run("unknown fileformat is rejected", func(t *testing.T) {
+		e := newTestEditor("a")
+		err := e.ExecuteCommand("set ff=mac")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrInvalidCommandId, err.ID())
+	})
+
+	t.Run("undo restores the previous file format", func(t *testing.T) {
+		e := newTestEditor("a\nb")
+		e.ExecuteCommand("set ff=dos")
+		keys(e, 'u')
+		assert.Equal(t, LineEndingLF, e.GetBuffer().LineEnding())
+	})
+}
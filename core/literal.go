@@ -0,0 +1,261 @@
+package core
+
+import (
+	"strconv"
+	"unicode"
+)
+
+// literalInput holds the state of Ctrl-V in insert mode, which inserts the next
+// key literally or a character given by its code:
+//
+//	Ctrl-V 065      decimal, up to three digits (at most 255)
+//	Ctrl-V o101     octal, up to three digits (at most 377)
+//	Ctrl-V x41      hexadecimal, up to two digits
+//	Ctrl-V u00e9    hexadecimal, up to four digits
+//	Ctrl-V U0001f600 hexadecimal, up to eight digits
+//
+// A key that is not a digit of the code ends it early; the code typed so far is
+// inserted and the key is then handled as usual.
+type literalInput struct {
+	active bool
+	base   int    // 0 until the base is known, then 8, 10 or 16
+	max    int    // Maximum number of digits
+	prefix rune   // 'o', 'x', 'u' or 'U', 0 for decimal
+	digits []rune // Digits typed so far
+}
+
+// literalResult is what a key typed after Ctrl-V results in.
+type literalResult struct {
+	insert  []rune // Runes to insert
+	replay  bool   // Handle the key as usual after inserting
+	pending bool   // More digits are expected
+}
+
+// start begins a new Ctrl-V sequence.
+func (l *literalInput) start() {
+	*l = literalInput{active: true}
+}
+
+// display returns the pending sequence for the command line.
+func (l *literalInput) display() string {
+	s := "^V"
+	if l.prefix != 0 {
+		s += string(l.prefix)
+	}
+	return s + string(l.digits)
+}
+
+// handle consumes a key typed after Ctrl-V.
+func (l *literalInput) handle(key KeyEvent) literalResult {
+	if l.base == 0 {
+		switch {
+		case key.Rune >= '0' && key.Rune <= '9' && key.Modifiers&(ModCtrl|ModAlt) == 0:
+			l.base, l.max = 10, 3
+		case key.Rune == 'o' || key.Rune == 'O':
+			l.base, l.max, l.prefix = 8, 3, 'o'
+			return literalResult{pending: true}
+		case key.Rune == 'x' || key.Rune == 'X':
+			l.base, l.max, l.prefix = 16, 2, 'x'
+			return literalResult{pending: true}
+		case key.Rune == 'u':
+			l.base, l.max, l.prefix = 16, 4, 'u'
+			return literalResult{pending: true}
+		case key.Rune == 'U':
+			l.base, l.max, l.prefix = 16, 8, 'U'
+			return literalResult{pending: true}
+		default:
+			l.active = false
+			if r, ok := literalRune(key); ok {
+				return literalResult{insert: []rune{r}}
+			}
+			return literalResult{}
+		}
+	}
+
+	if isDigitInBase(key.Rune, l.base) && key.Modifiers&(ModCtrl|ModAlt) == 0 {
+		candidate := append(l.digits, key.Rune)
+		// A decimal or octal code that would go over 255 ends before this digit
+		if value, _ := strconv.ParseInt(string(candidate), l.base, 64); l.base != 16 && value > 255 {
+			return l.finish(true)
+		}
+		l.digits = candidate
+		if len(l.digits) == l.max {
+			return l.finish(false)
+		}
+		return literalResult{pending: true}
+	}
+
+	return l.finish(true)
+}
+
+// finish ends the sequence and inserts the code typed so far. If no digits were
+// typed after a prefix, the prefix letter itself is inserted.
+func (l *literalInput) finish(replay bool) literalResult {
+	l.active = false
+	if len(l.digits) == 0 {
+		if l.prefix != 0 {
+			return literalResult{insert: []rune{l.prefix}, replay: replay}
+		}
+		return literalResult{replay: replay}
+	}
+
+	value, err := strconv.ParseInt(string(l.digits), l.base, 32)
+	if err != nil || value > unicode.MaxRune {
+		return literalResult{replay: replay}
+	}
+	return literalResult{insert: []rune{rune(value)}, replay: replay}
+}
+
+// isDigitInBase reports whether r is a digit in the given base (8, 10 or 16).
+func isDigitInBase(r rune, base int) bool {
+	switch base {
+	case 8:
+		return r >= '0' && r <= '7'
+	case 10:
+		return r >= '0' && r <= '9'
+	case 16:
+		return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+	}
+	return false
+}
+
+// literalRune returns the character a key stands for when inserted literally:
+// control keys become their control characters, so Ctrl-V Escape inserts ^[.
+func literalRune(key KeyEvent) (rune, bool) {
+	switch key.Key {
+	case KeyEnter:
+		return '\r', true
+	case KeyTab:
+		return '\t', true
+	case KeyEscape:
+		return 0x1b, true
+	case KeyBackspace:
+		return 0x7f, true
+	case KeySpace:
+		return ' ', true
+	}
+
+	if key.Key >= KeyCtrlA && key.Key <= KeyCtrlZ {
+		return rune(key.Key-KeyCtrlA) + 1, true
+	}
+	if key.Rune == 0 {
+		return 0, false
+	}
+	return key.Rune, true
+}
+
+// digraphInput holds the state of Ctrl-K in insert mode, which enters a
+// character from the two characters of its digraph, such as Ctrl-K e ' for é.
+type digraphInput struct {
+	active bool
+	first  rune // First character of the digraph, 0 until typed
+}
+
+// start begins a new Ctrl-K sequence.
+func (d *digraphInput) start() {
+	*d = digraphInput{active: true}
+}
+
+// display returns the pending sequence for the command line.
+func (d *digraphInput) display() string {
+	if d.first == 0 {
+		return "^K"
+	}
+	return "^K" + string(d.first)
+}
+
+// handle consumes a key typed after Ctrl-K. It returns the rune to insert, or
+// 0 while the digraph is incomplete or when it was cancelled with Escape.
+func (d *digraphInput) handle(key KeyEvent) rune {
+	r := key.Rune
+	if key.Key == KeyEscape || r == 0 || key.Modifiers&ModCtrl != 0 {
+		d.active = false
+		return 0
+	}
+
+	if d.first == 0 {
+		d.first = r
+		return 0
+	}
+
+	d.active = false
+	return lookupDigraph(d.first, r)
+}
+
+// lookupDigraph returns the character for the digraph a b. As in Vim, the two
+// characters may be typed in either order, and an unknown digraph results in
+// its second character.
+func lookupDigraph(a, b rune) rune {
+	if r, ok := digraphs[[2]rune{a, b}]; ok {
+		return r
+	}
+	if r, ok := digraphs[[2]rune{b, a}]; ok {
+		return r
+	}
+	return b
+}
+
+// digraphs holds the RFC 1345 digraphs for accented Latin letters, common
+// symbols and the Greek alphabet, as in Vim's :digraphs table.
+var digraphs = map[[2]rune]rune{}
+
+func init() {
+	// Accents that combine with many letters
+	accents := []struct {
+		mark    rune
+		letters string
+		result  string
+	}{
+		{'\'', "AEIOUYaeiouyCcNnSsZz", "ÁÉÍÓÚÝáéíóúýĆćŃńŚśŹź"},
+		{'!', "AEIOUaeiou", "ÀÈÌÒÙàèìòù"},
+		{'>', "AEIOUaeiouCcGgHhJjSsWwYy", "ÂÊÎÔÛâêîôûĈĉĜĝĤĥĴĵŜŝŴŵŶŷ"},
+		{'?', "ANOanoIiUu", "ÃÑÕãñõĨĩŨũ"},
+		{':', "AEIOUaeiouyY", "ÄËÏÖÜäëïöüÿŸ"},
+		{',', "CcGgKkLlNnRrSsTt", "ÇçĢģĶķĻļŅņŖŗŞşŢţ"},
+		{'<', "CcDdEeNnRrSsTtZz", "ČčĎďĚěŇňŘřŠšŤťŽž"},
+		{'-', "AaEeIiOoUu", "ĀāĒēĪīŌōŪū"},
+		{'(', "AaEeGgIiOoUu", "ĂăĔĕĞğĬĭŎŏŬŭ"},
+		{';', "AaEeIiUu", "ĄąĘęĮįŲų"},
+		{'.', "CcEeGgIZz", "ĊċĖėĠġİŻż"},
+		{'/', "OoDdLlHhTt", "ØøĐđŁłĦħŦŧ"},
+		{'0', "AaUu", "ÅåŮů"},
+		{'"', "OoUu", "ŐőŰű"},
+	}
+	for _, a := range accents {
+		result := []rune(a.result)
+		for i, letter := range a.letters {
+			digraphs[[2]rune{letter, a.mark}] = result[i]
+		}
+	}
+
+	// Letters, symbols and Greek
+	pairs := []struct {
+		digraph string
+		result  rune
+	}{
+		{"AA", 'Å'}, {"aa", 'å'}, {"AE", 'Æ'}, {"ae", 'æ'}, {"OE", 'Œ'}, {"oe", 'œ'},
+		{"ss", 'ß'}, {"D-", 'Đ'}, {"d-", 'đ'}, {"TH", 'Þ'}, {"th", 'þ'}, {"IJ", 'Ĳ'}, {"ij", 'ĳ'},
+
+		{"Eu", '€'}, {"=e", '€'}, {"Pd", '£'}, {"Ye", '¥'}, {"Ct", '¢'}, {"Cu", '¤'},
+		{"Co", '©'}, {"Rg", '®'}, {"TM", '™'}, {"SE", '§'}, {"PI", '¶'}, {"DG", '°'},
+		{"+-", '±'}, {"*X", '×'}, {"-:", '÷'}, {"<<", '«'}, {">>", '»'}, {"!I", '¡'}, {"?I", '¿'},
+		{"My", 'µ'}, {"NS", '\u00a0'}, {"1S", '¹'}, {"2S", '²'}, {"3S", '³'},
+		{"12", '½'}, {"14", '¼'}, {"34", '¾'}, {"-N", '–'}, {"-M", '—'}, {".M", '·'},
+		{"'6", '‘'}, {"'9", '’'}, {"\"6", '“'}, {"\"9", '”'}, {",.", '…'},
+		{"->", '→'}, {"<-", '←'}, {"-!", '↑'}, {"-v", '↓'}, {"=>", '⇒'}, {"==", '⇔'},
+		{"!=", '≠'}, {"=<", '≤'}, {">=", '≥'}, {"?=", '≅'}, {"00", '∞'}, {"OK", '✓'}, {"XX", '✗'},
+
+		{"A*", 'Α'}, {"B*", 'Β'}, {"G*", 'Γ'}, {"D*", 'Δ'}, {"E*", 'Ε'}, {"Z*", 'Ζ'},
+		{"Y*", 'Η'}, {"H*", 'Θ'}, {"I*", 'Ι'}, {"K*", 'Κ'}, {"L*", 'Λ'}, {"M*", 'Μ'},
+		{"N*", 'Ν'}, {"C*", 'Ξ'}, {"O*", 'Ο'}, {"P*", 'Π'}, {"R*", 'Ρ'}, {"S*", 'Σ'},
+		{"T*", 'Τ'}, {"U*", 'Υ'}, {"F*", 'Φ'}, {"X*", 'Χ'}, {"Q*", 'Ψ'}, {"W*", 'Ω'},
+		{"a*", 'α'}, {"b*", 'β'}, {"g*", 'γ'}, {"d*", 'δ'}, {"e*", 'ε'}, {"z*", 'ζ'},
+		{"y*", 'η'}, {"h*", 'θ'}, {"i*", 'ι'}, {"k*", 'κ'}, {"l*", 'λ'}, {"m*", 'μ'},
+		{"n*", 'ν'}, {"c*", 'ξ'}, {"o*", 'ο'}, {"p*", 'π'}, {"r*", 'ρ'}, {"*s", 'ς'},
+		{"s*", 'σ'}, {"t*", 'τ'}, {"u*", 'υ'}, {"f*", 'φ'}, {"x*", 'χ'}, {"q*", 'ψ'}, {"w*", 'ω'},
+	}
+	for _, p := range pairs {
+		d := []rune(p.digraph)
+		digraphs[[2]rune{d[0], d[1]}] = p.result
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// LevelTrace is the level of the events the editor logs to the logger set
+// with SetLogger, below slog.LevelDebug so that they only show when asked
+// for.
+const LevelTrace = slog.LevelDebug - 4
+
+// SetLogger sets the logger the editor tells what it does, at LevelTrace,
+// to help find out why a key did nothing: each key, with the mode it came
+// in and what it did, mode changes, saves of the undo history and signals,
+// with those dropped because the host fell behind. nil, the default, logs
+// nothing.
+func (e *editor) SetLogger(logger *slog.Logger) {
+	e.logger = logger
+}
+
+// tracing reports whether the logger takes trace events, for callers to
+// skip working out what they log otherwise.
+func (e *editor) tracing() bool {
+	return e.logger != nil && e.logger.Enabled(context.Background(), LevelTrace)
+}
+
+// trace logs an event at LevelTrace, args being its attributes as for
+// slog.Logger.Log.
+func (e *editor) trace(msg string, args ...any) {
+	if e.tracing() {
+		e.logger.Log(context.Background(), LevelTrace, msg, args...)
+	}
+}
+
+// traceKey logs key, which came in mode, and what it did: the mode and the
+// keys pending after it, where it left the cursor, whether it changed the
+// content and its error.
+func (e *editor) traceKey(key KeyEvent, mode Mode, revision uint64, err *EditorError) {
+	args := []any{
+		"key", key.String(),
+		"mode", mode,
+		"changed", e.buffer.Revision() != revision,
+		"cursor", e.buffer.GetCursor().Position,
+	}
+	if e.state.Mode != mode {
+		args = append(args, "to", e.state.Mode)
+	}
+	if e.state.PendingKeys != "" {
+		args = append(args, "pending", e.state.PendingKeys)
+	}
+	if err != nil {
+		args = append(args, "error", err.err)
+	}
+	e.trace("key", args...)
+}
+
+// traceSignal logs signal, dispatched or dropped.
+func (e *editor) traceSignal(signal Signal, dropped bool) {
+	if e.tracing() {
+		e.trace("signal", "type", fmt.Sprintf("%T", signal), "dropped", dropped)
+	}
+}
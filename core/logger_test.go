@@ -0,0 +1,64 @@
+package core
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSetLogger tests the trace events the editor logs.
+func TestSetLogger(t *testing.T) {
+	newLoggedEditor := func(level slog.Level) (modeContext, *strings.Builder) {
+		var out strings.Builder
+		e := newTestEditor("one")
+		e.SetLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: dropTime,
+		})))
+		return e, &out
+	}
+
+	t.Run("keys, mode changes, history saves and signals are traced", func(t *testing.T) {
+		e, out := newLoggedEditor(LevelTrace)
+		keys(e, 'd')
+		escape(e)
+		keys(e, 'i', 'x')
+
+		log := out.String()
+		assert.Contains(t, log, `msg=key key=d mode=normal changed=false cursor="{Row:0 Col:0}" pending=d`)
+		assert.Contains(t, log, `msg=mode from=normal to=insert`)
+		assert.Contains(t, log, `msg=signal type=core.ModeChangedSignal dropped=false`)
+		assert.Contains(t, log, `msg=key key=i mode=normal changed=false cursor="{Row:0 Col:0}" to=insert`)
+		assert.Contains(t, log, `msg=history position=1 entries=2`)
+		assert.Contains(t, log, `msg=key key=x mode=insert changed=true cursor="{Row:0 Col:1}"`)
+	})
+
+	t.Run("errors and dropped signals are traced", func(t *testing.T) {
+		e, out := newLoggedEditor(LevelTrace)
+		for len(e.GetUpdateSignalChan()) < cap(e.GetUpdateSignalChan()) {
+			e.DispatchSignal(CommandSignal{})
+		}
+		e.DispatchSignal(QuitSignal{})
+		keys(e, 'X')
+
+		log := out.String()
+		assert.Contains(t, log, `msg=signal type=core.QuitSignal dropped=true`)
+		assert.Contains(t, log, `msg=key key=X mode=normal changed=false cursor="{Row:0 Col:0}" error="start of line"`)
+	})
+
+	t.Run("nothing is logged above LevelTrace", func(t *testing.T) {
+		e, out := newLoggedEditor(slog.LevelDebug)
+		keys(e, 'i', 'x')
+		assert.Empty(t, out.String())
+	})
+}
+
+// dropTime leaves the time out of log records, for comparing them.
+func dropTime(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return a
+}
@@ -0,0 +1,53 @@
+package core
+
+// SetMark sets mark name, a to z, at pos, as m does at the cursor. Marks
+// stay where they were set when the text around them changes, moved into
+// the content when it gets shorter.
+func (e *editor) SetMark(name rune, pos Position) {
+	if name < 'a' || name > 'z' {
+		return
+	}
+	e.marks[name] = pos
+}
+
+// Mark returns the position of mark name: a to z as set by m or SetMark,
+// or < and > for the start and end of the last visual selection.
+func (e *editor) Mark(name rune) (Position, bool) {
+	var pos Position
+	switch name {
+	case '<', '>':
+		sel := e.state.lastVisual
+		if sel.mode == "" {
+			return Position{}, false
+		}
+		start, end := NormalizeSelection(sel.start, sel.end)
+		pos = start
+		if name == '>' {
+			pos = end
+		}
+	default:
+		var ok bool
+		if pos, ok = e.marks[name]; !ok {
+			return Position{}, false
+		}
+	}
+	return e.clampPosition(pos), true
+}
+
+// jumpToMark moves the cursor to mark name: to the first non-blank of its
+// line for ', or to the mark itself for `.
+func jumpToMark(editor modeContext, buffer Buffer, name rune, linewise bool) *EditorError {
+	pos, ok := editor.Mark(name)
+	if !ok {
+		return &EditorError{id: ErrMarkNotSetId, err: ErrMarkNotSet}
+	}
+
+	cursor := buffer.GetCursor()
+	cursor.Position = pos
+	if linewise {
+		cursor.MoveToFirstNonBlank(buffer, editor.GetState().AvailableWidth)
+	}
+	buffer.SetCursor(cursor)
+	editor.ScrollViewport()
+	return nil
+}
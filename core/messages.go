@@ -0,0 +1,299 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// MessageId identifies a message the editor shows the user, in its errors,
+// its ErrorSignals or the command line, for hosts to put it in their own
+// words with SetMessages.
+type MessageId int
+
+const (
+	MsgEndOfBuffer MessageId = iota
+	MsgStartOfBuffer
+	MsgEndOfLine
+	MsgStartOfLine
+	MsgInvalidPosition
+	MsgInvalidMode
+	MsgInvalidCommand
+	MsgNoPendingOperation
+	MsgDeleteRunes
+	MsgNoChangesToSave
+	MsgUnsavedChanges
+	MsgRenameFailed
+	MsgLossyContent
+	MsgNoQuickfixItems
+	MsgNoMoreQuickfixes
+	MsgInvalidRange
+	MsgPartialWrite
+	MsgMaxLength
+	MsgProtected
+	MsgNoTable
+	MsgMarkNotSet
+	MsgPatternNotFound
+	MsgNoFormatter
+	MsgFormatFailed
+	MsgNotAllowed
+
+	// Errors with arguments, listed in the order of the verbs of their text
+	MsgTooLong             // Maximum length
+	MsgCapabilityBlocked   // Capability
+	MsgFormatError         // Error of the formatter
+	MsgCharNotFound        // Character
+	MsgInvalidTextObject   // Text object, i or a
+	MsgUnknownTextObject   // Text object
+	MsgInvalidMotion       // Operator
+	MsgInvalidLineRange    // None
+	MsgNoStringUnderCursor // None
+	MsgOldestChange        // None
+	MsgNewestChange        // None
+	MsgNoLineToUndo        // None
+	MsgNoClipboard         // None
+	MsgClipboardRead       // Error of the clipboard
+	MsgClipboardWrite      // Error of the clipboard
+	MsgCannotEncode        // Encoding, error of the encoder
+
+	// Notices shown on the command line
+	MsgRenamed      // New file name
+	MsgQuickfixItem // Index from 1, count, text of the item
+	MsgFileInfo     // File name, MsgModified or nothing, line count, MsgLine or MsgLines, percentage through the file
+	MsgNoName       // None
+	MsgModified     // None
+	MsgLine         // None
+	MsgLines        // None
+
+	// Status lines of the modes, in State.StatusLine
+	MsgNormalStatus     // None
+	MsgInsertStatus     // None
+	MsgVisualStatus     // None
+	MsgVisualLineStatus // None
+
+	// Prompts of ConfirmSignals
+	MsgConfirmDelete        // File name or MsgTheFile
+	MsgConfirmDeleteUnsaved // File name or MsgTheFile
+	MsgConfirmRename        // File name or MsgTheFile, new file name
+	MsgTheFile              // None
+)
+
+// Severity is how much a message matters to the user, for hosts to show
+// it accordingly.
+type Severity int
+
+const (
+	SeverityInfo    Severity = iota // Something the user may want to know, such as the file being renamed
+	SeverityWarning                 // A key that did nothing, such as a motion past the end of the line
+	SeverityError                   // A command that failed
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Message is a message of the catalogue.
+type Message struct {
+	Severity Severity
+	Text     string // Format for fmt.Sprintf with the arguments of the message
+}
+
+// defaultMessages is the catalogue of the messages in English.
+var defaultMessages = map[MessageId]Message{
+	MsgEndOfBuffer:        {SeverityWarning, "end of buffer"},
+	MsgStartOfBuffer:      {SeverityWarning, "start of buffer"},
+	MsgEndOfLine:          {SeverityWarning, "end of line"},
+	MsgStartOfLine:        {SeverityWarning, "start of line"},
+	MsgInvalidPosition:    {SeverityError, "invalid position"},
+	MsgInvalidMode:        {SeverityError, "invalid mode"},
+	MsgInvalidCommand:     {SeverityError, "invalid command"},
+	MsgNoPendingOperation: {SeverityWarning, "no pending operation"},
+	MsgDeleteRunes:        {SeverityError, "cannot delete runes"},
+	MsgNoChangesToSave:    {SeverityInfo, "no changes to save"},
+	MsgUnsavedChanges:     {SeverityError, "unsaved changes (use :q! to override)"},
+	MsgRenameFailed:       {SeverityError, "rename requires a single argument (rename new_filename)"},
+	MsgLossyContent:       {SeverityError, "invalid UTF-8 was replaced when reading (use :w! to override)"},
+	MsgNoQuickfixItems:    {SeverityError, "no errors"},
+	MsgNoMoreQuickfixes:   {SeverityError, "no more items"},
+	MsgInvalidRange:       {SeverityError, "invalid range"},
+	MsgPartialWrite:       {SeverityError, "use ! to write partial buffer"},
+	MsgMaxLength:          {SeverityError, "text is too long"},
+	MsgProtected:          {SeverityError, "text is read-only"},
+	MsgNoTable:            {SeverityWarning, "no table at the cursor"},
+	MsgMarkNotSet:         {SeverityError, "mark not set"},
+	MsgPatternNotFound:    {SeverityError, "pattern not found"},
+	MsgNoFormatter:        {SeverityError, "no formatter for the language"},
+	MsgFormatFailed:       {SeverityError, "format failed"},
+	MsgNotAllowed:         {SeverityError, "not allowed"},
+
+	MsgTooLong:             {SeverityError, "text is too long (%d characters)"},
+	MsgCapabilityBlocked:   {SeverityError, "%s not allowed"},
+	MsgFormatError:         {SeverityError, "format failed: %v"},
+	MsgCharNotFound:        {SeverityWarning, "character '%c' not found"},
+	MsgInvalidTextObject:   {SeverityError, "invalid text object '%c' after '%c'"},
+	MsgUnknownTextObject:   {SeverityError, "unsupported text object: %c"},
+	MsgInvalidMotion:       {SeverityError, "invalid motion after '%s'"},
+	MsgInvalidLineRange:    {SeverityError, "invalid line range for deletion"},
+	MsgNoStringUnderCursor: {SeverityError, "E348: No string under cursor"},
+	MsgOldestChange:        {SeverityInfo, "already at oldest change"},
+	MsgNewestChange:        {SeverityInfo, "already at newest change"},
+	MsgNoLineToUndo:        {SeverityInfo, "no line to undo"},
+	MsgNoClipboard:         {SeverityError, "clipboard handler not set"},
+	MsgClipboardRead:       {SeverityError, "failed to read clipboard: %v"},
+	MsgClipboardWrite:      {SeverityError, "failed to write clipboard: %v"},
+	MsgCannotEncode:        {SeverityError, "content cannot be written as %s: %v"},
+
+	MsgRenamed:      {SeverityInfo, "%q renamed"},
+	MsgQuickfixItem: {SeverityInfo, "(%d of %d): %s"},
+	MsgFileInfo:     {SeverityInfo, "%q%s %d %s --%d%%--"},
+	MsgNoName:       {SeverityInfo, "[No Name]"},
+	MsgModified:     {SeverityInfo, " [Modified]"},
+	MsgLine:         {SeverityInfo, "line"},
+	MsgLines:        {SeverityInfo, "lines"},
+
+	MsgNormalStatus:     {SeverityInfo, "-- NORMAL --"},
+	MsgInsertStatus:     {SeverityInfo, "-- INSERT --"},
+	MsgVisualStatus:     {SeverityInfo, "-- VISUAL --"},
+	MsgVisualLineStatus: {SeverityInfo, "-- VISUAL LINE --"},
+
+	MsgConfirmDelete:        {SeverityInfo, "Delete %s?"},
+	MsgConfirmDeleteUnsaved: {SeverityInfo, "Delete %s and its unsaved changes?"},
+	MsgConfirmRename:        {SeverityInfo, "Rename %s to %s?"},
+	MsgTheFile:              {SeverityInfo, "the file"},
+}
+
+// DefaultMessages returns the catalogue of the messages in English, for
+// hosts to start their own from.
+func DefaultMessages() map[MessageId]Message {
+	return maps.Clone(defaultMessages)
+}
+
+// Notice is a message of the catalogue with its arguments. It is the error
+// of the editor's EditorErrors and ErrorSignals, in the words set with
+// SetMessages, and wraps the errors it stands for, such as ErrMaxLength for
+// MsgTooLong, and the errors among its arguments, for errors.Is.
+type Notice struct {
+	ID   MessageId
+	Args []any
+
+	text string // In the host's words, empty for the default
+	err  error  // What the notice stands for, if it isn't a sentinel itself
+}
+
+// newNotice returns the notice id with args.
+func newNotice(id MessageId, args ...any) *Notice {
+	return &Notice{ID: id, Args: args}
+}
+
+// wrapNotice returns the notice id with args, standing for err.
+func wrapNotice(err error, id MessageId, args ...any) *Notice {
+	return &Notice{ID: id, Args: args, err: err}
+}
+
+// Error returns the text of the notice.
+func (n *Notice) Error() string {
+	if n.text != "" {
+		return n.text
+	}
+	return formatMessage(defaultMessages[n.ID].Text, n.Args)
+}
+
+// Severity returns the severity of the notice, as in DefaultMessages. Hosts
+// set the words of messages, not how much they matter, so it is the same
+// whatever SetMessages was given.
+func (n *Notice) Severity() Severity {
+	return defaultMessages[n.ID].Severity
+}
+
+// Unwrap returns the error the notice stands for and the errors among its
+// arguments.
+func (n *Notice) Unwrap() []error {
+	var errs []error
+	if n.err != nil {
+		errs = append(errs, n.err)
+	}
+	for _, arg := range n.Args {
+		if err, ok := arg.(error); ok {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// formatMessage formats the text of a message with its arguments.
+func formatMessage(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// SetMessages puts the messages of the catalogue in the host's words, such
+// as those of another language: each text is a format for fmt.Sprintf with
+// the arguments of the message, as in DefaultMessages, and may use explicit
+// argument indexes, as in "%[2]c: %[1]c", to take them in another order.
+// Messages missing from texts are left in English. nil goes back to
+// English for all of them. The severities of the messages stay those of
+// DefaultMessages.
+func (e *editor) SetMessages(texts map[MessageId]string) {
+	e.messages = maps.Clone(texts)
+}
+
+// Message returns the text of the message id with args, in the words set
+// with SetMessages, for hosts to show messages of their own alike.
+func (e *editor) Message(id MessageId, args ...any) string {
+	if text, ok := e.messages[id]; ok {
+		return formatMessage(text, args)
+	}
+	return formatMessage(defaultMessages[id].Text, args)
+}
+
+// localise returns err in the host's words if it is a notice the host set
+// the words of, or wraps one, standing for err, or err as it is. The text of
+// a wrapped notice is replaced within that of err, keeping what wraps it.
+func (e *editor) localise(err error) error {
+	var notice *Notice
+	if !errors.As(err, &notice) {
+		return err
+	}
+	if _, ok := e.messages[notice.ID]; !ok {
+		return err
+	}
+	return &Notice{
+		ID:   notice.ID,
+		Args: notice.Args,
+		text: strings.Replace(err.Error(), notice.Error(), e.Message(notice.ID, notice.Args...), 1),
+		err:  err,
+	}
+}
+
+// localiseError is localise for the error of an EditorError.
+func (e *editor) localiseError(err *EditorError) *EditorError {
+	if err == nil {
+		return nil
+	}
+	if localised := e.localise(err.err); localised != err.err {
+		return &EditorError{id: err.id, err: localised}
+	}
+	return err
+}
+
+// Severity returns the severity of the error's message, SeverityError if it
+// isn't one of the catalogue, such as an error of the host's validation.
+func (e EditorError) Severity() Severity {
+	var notice *Notice
+	if errors.As(e.err, &notice) {
+		return notice.Severity()
+	}
+	return SeverityError
+}
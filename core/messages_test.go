@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMessages tests the message catalogue and putting it in other words.
+func TestMessages(t *testing.T) {
+	t.Run("errors keep their English text and sentinels", func(t *testing.T) {
+		e := newTestEditor("one")
+		err := e.HandleKey(KeyEvent{Rune: 'u'})
+		assert.NotNil(t, err)
+		assert.Equal(t, "already at oldest change", err.Error().Error())
+		assert.Equal(t, SeverityInfo, err.Severity())
+
+		e.SetMaxLength(3)
+		err = e.InsertLines(0, []string{"two"})
+		assert.NotNil(t, err)
+		assert.ErrorIs(t, err.Error(), ErrMaxLength)
+		assert.Equal(t, "text is too long (3 characters)", err.Error().Error())
+	})
+
+	t.Run("SetMessages puts them in the host's words", func(t *testing.T) {
+		e := newTestEditor("one")
+		e.SetMessages(map[MessageId]string{
+			MsgInvalidCommand: "commande invalide",
+			MsgOldestChange:   "déjà à la modification la plus ancienne",
+			MsgCharNotFound:   "caractère « %c » introuvable",
+			MsgNoName:         "[Sans nom]",
+			MsgLine:           "ligne",
+		})
+
+		err := e.ExecuteCommand("nope")
+		assert.Equal(t, "commande invalide", err.Error().Error())
+		assert.ErrorIs(t, err.Error(), ErrInvalidCommand)
+
+		err = e.HandleKey(KeyEvent{Rune: 'u'})
+		assert.Equal(t, "déjà à la modification la plus ancienne", err.Error().Error())
+
+		drainSignals(e)
+		keys(e, 'f', 'z')
+		signal := nextSignal(e).(ErrorSignal)
+		_, sent := signal.Value()
+		assert.Equal(t, "caractère « z » introuvable", sent.Error())
+		assert.Equal(t, SeverityWarning, signal.Severity())
+
+		assert.Equal(t, `"[Sans nom]" 1 ligne --100%--`, e.FileInfo())
+	})
+
+	t.Run("wrapped notices are translated within what wraps them", func(t *testing.T) {
+		e := newTestEditor("one")
+		e.SetMessages(map[MessageId]string{MsgCharNotFound: "caractère « %c » introuvable"})
+		notice := newNotice(MsgCharNotFound, 'z')
+		err := e.(*editor).localise(fmt.Errorf("macro a: %w", notice))
+		assert.Equal(t, "macro a: caractère « z » introuvable", err.Error())
+		assert.ErrorIs(t, err, notice)
+
+		var localised *Notice
+		assert.ErrorAs(t, err, &localised)
+		assert.Equal(t, SeverityWarning, localised.Severity())
+	})
+
+	t.Run("the status lines of the modes are translated", func(t *testing.T) {
+		e := newTestEditor("one")
+		e.SetMessages(map[MessageId]string{MsgInsertStatus: "-- INSERTION --"})
+		keys(e, 'i')
+		assert.Equal(t, "-- INSERTION --", e.GetState().StatusLine)
+		escape(e)
+		assert.Equal(t, "-- NORMAL --", e.GetState().StatusLine)
+		assert.Equal(t, "-- INSERTION --", e.Message(MsgInsertStatus))
+		assert.Equal(t, "text is too long (3 characters)", e.Message(MsgTooLong, 3))
+	})
+
+	t.Run("arguments can be taken in another order", func(t *testing.T) {
+		e := newTestEditor("one")
+		e.SetMessages(map[MessageId]string{MsgConfirmRename: "%[2]s statt %[1]s?"})
+		assert.Nil(t, e.ExecuteCommand("rename two.txt"))
+
+		var confirm ConfirmSignal
+		for signal := nextSignal(e); signal != nil; signal = nextSignal(e) {
+			if c, ok := signal.(ConfirmSignal); ok {
+				confirm = c
+			}
+		}
+		assert.Equal(t, "two.txt statt the file?", confirm.Prompt())
+	})
+
+	t.Run("nil goes back to English", func(t *testing.T) {
+		e := newTestEditor("one")
+		e.SetMessages(map[MessageId]string{MsgInvalidCommand: "commande invalide"})
+		e.SetMessages(nil)
+		assert.Equal(t, "invalid command", e.ExecuteCommand("nope").Error().Error())
+	})
+
+	t.Run("errors of the host are kept as they are", func(t *testing.T) {
+		invalid := errors.New("numbers only")
+		e := newTestEditor("")
+		e.SetValidateFunc(func(string) error { return invalid })
+		e.SetMessages(map[MessageId]string{MsgInvalidCommand: "commande invalide"})
+		err := e.InsertLines(0, []string{"x"})
+		assert.Equal(t, invalid, err.Error())
+		assert.Equal(t, SeverityError, err.Severity())
+	})
+
+	t.Run("every message has a default", func(t *testing.T) {
+		messages := DefaultMessages()
+		for id := MsgEndOfBuffer; id <= MsgTheFile; id++ {
+			assert.NotEmpty(t, messages[id].Text, "message %d", id)
+		}
+		assert.Len(t, messages, int(MsgTheFile)+1)
+	})
+}
@@ -1,9 +1,5 @@
 package core
 
-import (
-	"fmt"
-)
-
 type Mode string
 
 const (
@@ -15,15 +11,34 @@ const (
 	SearchMode     Mode = "search"
 )
 
+// ModeHook is run when the editor enters or leaves a mode, e.g. to enable an
+// input method in insert mode or turn off the host's key bindings in command
+// mode. Enter runs once the editor is in the mode and Exit while it still
+// is. Either may be nil. They may call the editor, but not change its mode.
+type ModeHook struct {
+	Enter func(Editor)
+	Exit  func(Editor)
+}
+
+// SetModeHook sets the hook run when the editor enters or leaves mode,
+// replacing the one set before. The zero ModeHook removes it.
+func (e *editor) SetModeHook(mode Mode, hook ModeHook) {
+	if hook.Enter == nil && hook.Exit == nil {
+		delete(e.modeHooks, mode)
+		return
+	}
+	e.modeHooks[mode] = hook
+}
+
 // EditorMode represents a Vim editing mode
 type EditorMode interface {
 	Name() Mode
 	// HandleKey processes a key press. It can return an error or signal a mode change.
 	// It takes the current editor *state* and returns the *new desired state*.
 	// This makes state management more explicit.
-	HandleKey(editor Editor, buffer Buffer, key KeyEvent) *EditorError
-	Enter(editor Editor, buffer Buffer) // Called when entering the mode
-	Exit(editor Editor, buffer Buffer)  // Called when exiting the mode
+	HandleKey(editor modeContext, buffer Buffer, key KeyEvent) *EditorError
+	Enter(editor modeContext, buffer Buffer) // Called when entering the mode
+	Exit(editor modeContext, buffer Buffer)  // Called when exiting the mode
 }
 
 type VisualModeInterface interface {
@@ -35,7 +50,7 @@ type VisualModeInterface interface {
 // It returns:
 // - count: The calculated count (default 1 or accumulated value) if a non-digit key was pressed.
 // - processedDigit: true if the key was a digit ('0'-'9') and was consumed, false otherwise.
-func getMoveCount(mode VisualModeInterface, editor Editor, key KeyEvent) (count int, processedDigit bool) {
+func getMoveCount(mode VisualModeInterface, editor modeContext, key KeyEvent) (count int, processedDigit bool) {
 	currentCount := mode.GetCurrentCount()
 
 	// --- Handle Digit Input ---
@@ -46,14 +61,12 @@ func getMoveCount(mode VisualModeInterface, editor Editor, key KeyEvent) (count
 		}
 		*currentCount = (*currentCount * 10) + digit // Append digit
 		mode.SetCurrentCount(currentCount)           //  Set state back
-		editor.UpdateCommand(fmt.Sprintf("%d", *currentCount))
 		return 0, true
 	} else if key.Rune == '0' {
 		if currentCount != nil { // Can only append '0' if count already started
 			digit := 0
 			*currentCount = (*currentCount * 10) + digit // Append digit
 			mode.SetCurrentCount(currentCount)
-			editor.UpdateCommand(fmt.Sprintf("%d", *currentCount))
 			return 0, true
 		}
 	}
@@ -67,7 +80,6 @@ func getMoveCount(mode VisualModeInterface, editor Editor, key KeyEvent) (count
 		count = *currentCount
 		// Reset the count now that it's being used/finalized
 		mode.SetCurrentCount(nil)
-		editor.UpdateCommand("") // Clear count display
 	}
 
 	// Return the calculated count and indicate no digit was processed *by this part*
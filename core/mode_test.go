@@ -0,0 +1,46 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestModeHooks tests the hooks and signals of mode changes.
+func TestModeHooks(t *testing.T) {
+	t.Run("hooks run on entering and leaving a mode", func(t *testing.T) {
+		e := newTestEditor("hello")
+		var calls []string
+		e.SetModeHook(InsertMode, ModeHook{
+			Enter: func(ed Editor) { calls = append(calls, "enter "+string(ed.GetState().Mode)) },
+			Exit:  func(ed Editor) { calls = append(calls, "exit "+string(ed.GetState().Mode)) },
+		})
+
+		keys(e, 'i', 'a')
+		escape(e)
+		keys(e, 'v')
+		escape(e)
+		assert.Equal(t, []string{"enter insert", "exit insert"}, calls)
+
+		e.SetModeHook(InsertMode, ModeHook{})
+		keys(e, 'i')
+		assert.Len(t, calls, 2)
+	})
+
+	t.Run("a signal reports each change", func(t *testing.T) {
+		e := newTestEditor("hello")
+		drainSignals(e)
+		keys(e, 'v')
+		escape(e)
+		escape(e) // Staying in normal mode is no change
+
+		var changes [][2]Mode
+		for signal := nextSignal(e); signal != nil; signal = nextSignal(e) {
+			if changed, ok := signal.(ModeChangedSignal); ok {
+				from, to := changed.Value()
+				changes = append(changes, [2]Mode{from, to})
+			}
+		}
+		assert.Equal(t, [][2]Mode{{NormalMode, VisualMode}, {VisualMode, NormalMode}}, changes)
+	})
+}
@@ -121,6 +121,113 @@ func TestMoveUp(t *testing.T) {
 	})
 }
 
+// TestVirtualEdit tests where the cursor may go past the end of a line.
+func TestVirtualEdit(t *testing.T) {
+	t.Run("one more by default", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, '$', 'l')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
+
+	t.Run("none keeps the cursor on the last character", func(t *testing.T) {
+		e := newTestEditor("hello\nhi")
+		setWidth(e, 80)
+		e.SetVirtualEdit(VirtualEditNone)
+		keys(e, '$', 'l')
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+		keys(e, 'j')
+		assert.Equal(t, Position{1, 1}, cursorPos(e))
+		keys(e, 'A')
+		escape(e)
+		assert.Equal(t, Position{1, 1}, cursorPos(e))
+	})
+
+	t.Run("none clamps the cursor when set", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, '$', 'l')
+		e.SetVirtualEdit(VirtualEditNone)
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+	})
+
+	t.Run("none stays after the last character in insert mode", func(t *testing.T) {
+		e := newTestEditor("hello")
+		e.SetVirtualEdit(VirtualEditNone)
+		keys(e, 'A')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
+
+	t.Run("all moves past the end of the line", func(t *testing.T) {
+		e := newTestEditor("ab\nabcdef")
+		setWidth(e, 80)
+		e.SetVirtualEdit(VirtualEditAll)
+		keys(e, '5', 'l')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+		keys(e, 'h')
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+		keys(e, 'j', 'k')
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+		e.HandleKey(KeyEvent{Key: KeyRight})
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
+
+	t.Run("all fills the gap with spaces when typing", func(t *testing.T) {
+		e := newTestEditor("ab\nabcdef")
+		setWidth(e, 80)
+		e.SetVirtualEdit(VirtualEditAll)
+		keys(e, '4', 'l', 'i', 'x')
+		assert.Equal(t, "ab  x\nabcdef", content(e))
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+
+		escape(e)
+		keys(e, 'u')
+		assert.Equal(t, "ab\nabcdef", content(e))
+	})
+
+	t.Run("all appends after the cursor", func(t *testing.T) {
+		e := newTestEditor("ab")
+		e.SetVirtualEdit(VirtualEditAll)
+		keys(e, '3', 'l', 'a', 'x')
+		assert.Equal(t, "ab  x", content(e))
+	})
+
+	t.Run("all runs other commands at the end of the line", func(t *testing.T) {
+		e := newTestEditor("ab")
+		e.SetVirtualEdit(VirtualEditAll)
+		keys(e, '4', 'l', 'X')
+		assert.Equal(t, "a", content(e))
+	})
+
+	t.Run("all moves past the end in insert mode with the arrow keys", func(t *testing.T) {
+		e := newTestEditor("ab")
+		e.SetVirtualEdit(VirtualEditAll)
+		keys(e, 'A')
+		e.HandleKey(KeyEvent{Key: KeyRight})
+		keys(e, 'l')
+		assert.Equal(t, "ab l", content(e))
+
+		e.HandleKey(KeyEvent{Key: KeyRight})
+		e.HandleKey(KeyEvent{Key: KeyRight})
+		backspace(e)
+		assert.Equal(t, "ab l", content(e))
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
+
+	t.Run("all still waits for the character of f", func(t *testing.T) {
+		e := newTestEditor("hello")
+		e.SetVirtualEdit(VirtualEditAll)
+		keys(e, 'f', 'l')
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("all in visual mode", func(t *testing.T) {
+		e := newTestEditor("ab\ncd")
+		e.SetVirtualEdit(VirtualEditAll)
+		keys(e, 'v', '3', 'l')
+		assert.True(t, e.IsVisualMode())
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+	})
+}
+
 // TestMoveToLineStart tests '0' — move to column 0.
 func TestMoveToLineStart(t *testing.T) {
 	t.Run("moves to col 0", func(t *testing.T) {
@@ -283,8 +390,99 @@ func TestMoveWordToEnd(t *testing.T) {
 	})
 }
 
+// TestMoveWordEndBackward tests 'ge' and 'gE' — move back to the end of the previous word.
+func TestMoveWordEndBackward(t *testing.T) {
+	t.Run("from mid-word moves to the end of the previous word", func(t *testing.T) {
+		e := newTestEditor("hello world")
+		keys(e, '$', 'g', 'e')
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+	})
+
+	t.Run("stops at punctuation", func(t *testing.T) {
+		e := newTestEditor("foo.bar baz")
+		keys(e, 'w', 'w', 'g', 'e')
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+	})
+
+	t.Run("count: 2ge", func(t *testing.T) {
+		e := newTestEditor("one two three")
+		keys(e, '$', '2', 'g', 'e')
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("crosses lines and stops on empty ones", func(t *testing.T) {
+		e := newTestEditor("one\n\n  two")
+		keys(e, 'G', '$', 'g', 'e')
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+		keys(e, 'g', 'e')
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("stops at the start of the buffer", func(t *testing.T) {
+		e := newTestEditor("  one")
+		keys(e, '$', 'g', 'e')
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("gE moves over WORDs", func(t *testing.T) {
+		e := newTestEditor("foo.bar baz")
+		keys(e, '$', 'g', 'E')
+		assert.Equal(t, Position{0, 6}, cursorPos(e))
+	})
+
+	t.Run("visual mode", func(t *testing.T) {
+		e := newTestEditor("one two three")
+		keys(e, '$', 'v', '2', 'g', 'e')
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+		assert.Equal(t, SelectionCharacter, e.GetSelectionStatus(Position{0, 5}))
+	})
+}
+
+// TestMoveWORD tests 'W', 'B' and 'E' — move over WORDs of non-blank characters.
+func TestMoveWORD(t *testing.T) {
+	t.Run("W skips punctuation inside a WORD", func(t *testing.T) {
+		e := newTestEditor("foo.bar(baz) qux")
+		keys(e, 'W')
+		assert.Equal(t, Position{0, 13}, cursorPos(e))
+	})
+
+	t.Run("W wraps to the next line", func(t *testing.T) {
+		e := newTestEditor("a.b\n  c-d")
+		keys(e, 'W')
+		assert.Equal(t, Position{1, 2}, cursorPos(e))
+	})
+
+	t.Run("count: 2W", func(t *testing.T) {
+		e := newTestEditor("a.b c,d e!f")
+		keys(e, '2', 'W')
+		assert.Equal(t, Position{0, 8}, cursorPos(e))
+	})
+
+	t.Run("B moves to the start of the WORD", func(t *testing.T) {
+		e := newTestEditor("x foo.bar(baz)")
+		keys(e, '$', 'B')
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+		keys(e, 'B')
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("E moves to the end of the WORD", func(t *testing.T) {
+		e := newTestEditor("foo.bar(baz) qux")
+		keys(e, 'E')
+		assert.Equal(t, Position{0, 11}, cursorPos(e))
+		keys(e, 'E')
+		assert.Equal(t, Position{0, 15}, cursorPos(e))
+	})
+
+	t.Run("visual mode", func(t *testing.T) {
+		e := newTestEditor("foo.bar baz")
+		keys(e, 'v', 'E')
+		assert.Equal(t, Position{0, 6}, cursorPos(e))
+	})
+}
+
 // TestMoveParagraphForward tests '}' — move to the next blank line (paragraph boundary).
-// Like Vim: from a non-blank line, lands on the next blank line (or last line if none).
+// Like Vim: from a non-blank line, lands on the next blank line (or the last character if none).
 // From a blank line, skips the blank gap first, then lands on the following blank line.
 func TestMoveParagraphForward(t *testing.T) {
 	t.Run("lands on the blank line between paragraphs", func(t *testing.T) {
@@ -305,15 +503,23 @@ func TestMoveParagraphForward(t *testing.T) {
 		assert.Equal(t, Position{5, 0}, cursorPos(e))
 	})
 
-	t.Run("at last line stays put", func(t *testing.T) {
+	t.Run("at last line moves to its last character", func(t *testing.T) {
 		e := newTestEditor("one\ntwo\n\nthree")
-		keys(e, 'G', '}') // G → last line (row 3); } is a no-op
-		assert.Equal(t, Position{3, 0}, cursorPos(e))
+		keys(e, 'G', '}') // G → last line (row 3); } moves to the end of the buffer
+		assert.Equal(t, Position{3, 4}, cursorPos(e))
+		keys(e, '}') // already at the end; } is a no-op
+		assert.Equal(t, Position{3, 4}, cursorPos(e))
 	})
 
-	t.Run("no blank line: lands on last line", func(t *testing.T) {
+	t.Run("no blank line: lands on the last character", func(t *testing.T) {
 		e := newTestEditor("one\ntwo\nthree")
 		keys(e, '}') // skip non-blank until last line; no blank found
+		assert.Equal(t, Position{2, 4}, cursorPos(e))
+	})
+
+	t.Run("count past the last paragraph stops at the end of the buffer", func(t *testing.T) {
+		e := newTestEditor("a\n\nb")
+		keys(e, '5', '}')
 		assert.Equal(t, Position{2, 0}, cursorPos(e))
 	})
 
@@ -369,4 +575,92 @@ func TestMoveParagraphBackward(t *testing.T) {
 		keys(e, 'G', '{', '{') // row 4 → row 3 (blank) → row 1 (blank)
 		assert.Equal(t, Position{1, 0}, cursorPos(e))
 	})
+
+	t.Run("on the first line moves to its start", func(t *testing.T) {
+		e := newTestEditor("one two\n\nthree")
+		keys(e, 'w', '{')
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("count past the first paragraph stops at the start of the buffer", func(t *testing.T) {
+		e := newTestEditor("a\n\nb\nc")
+		keys(e, 'G', '5', '{')
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+}
+
+// TestMoveSentence tests ')' and '(' — move to the next and previous sentence start.
+func TestMoveSentence(t *testing.T) {
+	t.Run(") moves to the next sentence", func(t *testing.T) {
+		e := newTestEditor("One. Two! Three? Four")
+		keys(e, ')')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+		keys(e, '2', ')')
+		assert.Equal(t, Position{0, 17}, cursorPos(e))
+	})
+
+	t.Run("closing quotes and brackets may follow the punctuation", func(t *testing.T) {
+		e := newTestEditor(`He said "hi." (Then left.) Done`)
+		keys(e, ')')
+		assert.Equal(t, Position{0, 14}, cursorPos(e))
+		keys(e, ')')
+		assert.Equal(t, Position{0, 27}, cursorPos(e))
+	})
+
+	t.Run("a period inside a word does not end a sentence", func(t *testing.T) {
+		e := newTestEditor("Version 1.2 is out.\nNext")
+		keys(e, ')')
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+	})
+
+	t.Run("sentences continue across lines and stop at blank lines", func(t *testing.T) {
+		e := newTestEditor("First line\ncontinues. Second\n\nThird")
+		keys(e, ')')
+		assert.Equal(t, Position{1, 11}, cursorPos(e))
+		keys(e, ')')
+		assert.Equal(t, Position{2, 0}, cursorPos(e))
+		keys(e, ')')
+		assert.Equal(t, Position{3, 0}, cursorPos(e))
+	})
+
+	t.Run(") without another sentence moves to the end of the buffer", func(t *testing.T) {
+		e := newTestEditor("Only one sentence")
+		keys(e, ')')
+		assert.Equal(t, Position{0, 16}, cursorPos(e))
+	})
+
+	t.Run("( moves to the start of the current sentence, then the previous one", func(t *testing.T) {
+		e := newTestEditor("One. Two three. Four")
+		keys(e, '$', '(')
+		assert.Equal(t, Position{0, 16}, cursorPos(e))
+		keys(e, 'b', 'b', '(')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+		keys(e, '(')
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("d) deletes to the next sentence", func(t *testing.T) {
+		e := newTestEditor("One. Two. Three.")
+		keys(e, 'w', 'w', 'd', ')')
+		assert.Equal(t, "One. Three.", content(e))
+	})
+
+	t.Run("d) in the last sentence deletes to the end", func(t *testing.T) {
+		e := newTestEditor("One. Two")
+		keys(e, ')', 'd', ')')
+		assert.Equal(t, "One. ", content(e))
+	})
+
+	t.Run("c( changes back to the sentence start", func(t *testing.T) {
+		e := newTestEditor("One. Two three")
+		keys(e, '$', 'c', '(')
+		assertInsertMode(t, e)
+		assert.Equal(t, "One. e", content(e))
+	})
+
+	t.Run("v) extends the selection", func(t *testing.T) {
+		e := newTestEditor("One. Two.")
+		keys(e, 'v', ')')
+		assert.Equal(t, Position{0, 5}, cursorPos(e))
+	})
 }
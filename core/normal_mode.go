@@ -11,6 +11,15 @@ type normalMode struct {
 	pendingModifier   rune            // Stores text object modifier ('i' for inside, 'a' for around)
 	charSearch        charSearchState // Character search state (f/F/t/T)
 	waitingForReplace bool            // True when waiting for character input after 'r'
+	motionCount       *int            // Count typed after an operator (e.g. 3 in d3w)
+	pendingMotion     rune            // First key of a two-key motion after an operator (g in dgg)
+	gPrefix           bool            // True right after g, which also starts g-prefixed commands (gv)
+	gOrigin           Cursor          // Where the cursor was before g moved it to the first line
+	gCount            int             // Count typed before g, for gj, gk and gq
+	gCounted          bool            // Whether a count was typed before g
+	zPrefix           bool            // True after Z, waiting for the second key of ZZ or ZQ
+	bracketPrefix     rune            // ] or [, waiting for the second key of ]s or [s
+	markPrefix        rune            // m, ' or `, waiting for the name of a mark
 }
 
 func NewNormalMode() EditorMode {
@@ -23,8 +32,8 @@ func NewNormalMode() EditorMode {
 
 func (m *normalMode) Name() Mode { return NormalMode }
 
-func (m *normalMode) Enter(editor Editor, buffer Buffer) {
-	editor.UpdateStatus("-- NORMAL --")
+func (m *normalMode) Enter(editor modeContext, buffer Buffer) {
+	editor.UpdateStatus(editor.Message(MsgNormalStatus))
 	editor.UpdateCommand("")
 
 	// Reset pending state on entering normal mode
@@ -32,6 +41,11 @@ func (m *normalMode) Enter(editor Editor, buffer Buffer) {
 	m.pendingModifier = 0
 	m.charSearch = charSearchState{}
 	m.waitingForReplace = false
+	m.pendingMotion = 0
+	m.gPrefix = false
+	m.zPrefix = false
+	m.bracketPrefix = 0
+	m.markPrefix = 0
 	editor.ResetPendingCount()
 	// Clear visual selection when entering normal mode
 	state := editor.GetState()
@@ -39,15 +53,20 @@ func (m *normalMode) Enter(editor Editor, buffer Buffer) {
 	editor.SetState(state)
 }
 
-func (m *normalMode) Exit(editor Editor, buffer Buffer) {
+func (m *normalMode) Exit(editor modeContext, buffer Buffer) {
 	// Clear pending state when exiting normal mode
 	m.pendingKey = KeyEvent{Key: KeyUnknown}
 	m.pendingModifier = 0
 	m.charSearch = charSearchState{}
 	m.waitingForReplace = false
+	m.pendingMotion = 0
+	m.gPrefix = false
+	m.zPrefix = false
+	m.bracketPrefix = 0
+	m.markPrefix = 0
 }
 
-func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *EditorError {
+func (m *normalMode) HandleKey(editor modeContext, buffer Buffer, key KeyEvent) *EditorError {
 	var err *EditorError
 	actionTaken := false // Track if the key (or sequence) resulted in an action
 	state := editor.GetState()
@@ -58,8 +77,8 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 	// --- Handle Character Search Input (waiting for character after f/F/t/T) ---
 	if m.charSearch.waitingForChar {
-		m.charSearch.waitingForChar = false
-		editor.UpdateCommand("") // Clear the command display
+		searchType, count := m.charSearch.searchType, m.charSearch.count
+		m.charSearch = charSearchState{}
 
 		// Handle escape to cancel
 		if key.Key == KeyEscape {
@@ -74,12 +93,6 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 			return nil
 		}
 
-		count := 1
-		if pendingCount != nil {
-			count = *pendingCount
-			editor.ResetPendingCount()
-		}
-
 		// Check if there's a pending operator (d/y/c)
 		if m.pendingKey.Key != KeyUnknown || m.pendingKey.Rune != 0 {
 			// Operator + character search motion (e.g., df,  yt;)
@@ -97,7 +110,7 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 			}
 
 			if op != "" {
-				err = handleCharSearchOperator(editor, buffer, op, m.charSearch.searchType, key.Rune, count)
+				err = handleCharSearchOperator(editor, buffer, op, searchType, key.Rune, count, false)
 				if err != nil {
 					m.clearPendingState(editor)
 				}
@@ -106,7 +119,7 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		}
 
 		// No pending operator - just perform the character search
-		searchErr := performCharSearch(buffer, &m.charSearch, m.charSearch.searchType, key.Rune, count)
+		searchErr := performCharSearch(editor, buffer, searchType, key.Rune, count, false)
 		if searchErr != nil {
 			m.clearPendingState(editor)
 			editor.DispatchError(ErrCharNotFoundId, searchErr)
@@ -117,7 +130,6 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 	// --- Handle Replace Character Input (waiting for character after 'r') ---
 	if m.waitingForReplace {
 		m.waitingForReplace = false
-		editor.UpdateCommand("")
 
 		if key.Key == KeyEscape || key.Rune == 0 {
 			return nil
@@ -127,15 +139,131 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		return err
 	}
 
+	// --- Handle g-prefixed Commands (e.g., gv) ---
+	// A lone g has already moved to the first line, so only the second key is left
+	if m.gPrefix {
+		m.gPrefix = false
+		if key.Rune == 'v' {
+			editor.ResetPendingCount()
+			if editor.allow(VisualSelect) {
+				reselectVisual(editor, buffer)
+			}
+			return nil
+		}
+		// gj and gk move from where the cursor was, by display lines unless
+		// j and k do
+		if down, up := key.Rune == 'j' || key.Key == KeyDown, key.Rune == 'k' || key.Key == KeyUp; down || up {
+			cursor = m.gOrigin
+			count := m.gCount
+			if up {
+				count = -count
+			}
+			_ = editor.MoveLines(&cursor, count, !state.DisplayLineMovement)
+			buffer.SetCursor(cursor)
+			return nil
+		}
+		// ge and gE move back to the end of a word, from where the cursor was
+		if key.Rune == 'e' || key.Rune == 'E' {
+			cursor = m.gOrigin
+			_ = cursor.MoveWordEndBackward(buffer, m.gCount, availableWidth, motionWordChars(editor, key.Rune))
+			buffer.SetCursor(cursor)
+			return nil
+		}
+		// gq is an operator, from where the cursor was
+		if key.Rune == 'q' && editor.allow(EditText) {
+			buffer.SetCursor(m.gOrigin)
+			if m.gCounted {
+				gCount := m.gCount
+				state.PendingCount = &gCount
+				editor.SetState(state)
+			}
+			m.pendingKey = key
+			return nil
+		}
+	}
+
+	// --- Handle Z-prefixed Commands (ZZ and ZQ) ---
+	if m.zPrefix {
+		m.zPrefix = false
+		editor.ResetPendingCount()
+		switch key.Rune {
+		case 'Z': // Write if modified, then quit
+			return editor.executeCommand("x")
+		case 'Q': // Quit without saving
+			return editor.executeCommand("q!")
+		}
+		return nil
+	}
+
+	// --- Handle ]- and [-prefixed Commands (]s and [s) ---
+	if m.bracketPrefix != 0 {
+		backward := m.bracketPrefix == '['
+		m.bracketPrefix = 0
+		count := 1
+		if pendingCount != nil {
+			count = *pendingCount
+		}
+		editor.ResetPendingCount()
+		if key.Rune == 's' && state.StatementSyntax != nil {
+			if pos, found := statementStart(buffer, state.StatementSyntax, cursor.Position, count, backward); found {
+				cursor.Position = pos
+				if availableWidth > 0 {
+					cursor.Preferred = pos.Col % availableWidth
+				}
+				buffer.SetCursor(cursor)
+			}
+		}
+		return nil
+	}
+
+	// --- Handle Mark Commands (ma, 'a and `a) ---
+	if m.markPrefix != 0 {
+		prefix := m.markPrefix
+		m.markPrefix = 0
+		editor.ResetPendingCount()
+		if key.Rune == 0 {
+			return nil
+		}
+		if prefix == 'm' {
+			editor.SetMark(key.Rune, cursor.Position)
+			return nil
+		}
+		return jumpToMark(editor, buffer, key.Rune, prefix == '\'')
+	}
+
 	// --- Handle Pending Operation (e.g., after 'd') ---
 	if m.pendingKey.Key != KeyUnknown || m.pendingKey.Rune != 0 {
 		firstKey := m.pendingKey
 
+		// Digits after the operator are the motion's count (d3w, d2fx)
+		if key.Rune >= '1' && key.Rune <= '9' || key.Rune == '0' && m.motionCount != nil {
+			motionCount := int(key.Rune - '0')
+			if m.motionCount != nil {
+				motionCount += *m.motionCount * 10
+			}
+			m.motionCount = &motionCount
+			return nil
+		}
+
+		// gg waits for its second g
+		if key.Rune == 'g' && m.pendingMotion == 0 && m.pendingModifier == 0 {
+			m.pendingMotion = 'g'
+			return nil
+		}
+
+		// The operator and motion counts multiply, so 2d3w deletes six words.
+		// With either count alone it is used as is; G and gg read the result
+		// as a line number (3dG deletes up to line 3)
 		count := 1
+		hasCount := pendingCount != nil || m.motionCount != nil
 		if pendingCount != nil {
 			count = *pendingCount
 			editor.ResetPendingCount()
 		}
+		if m.motionCount != nil {
+			count *= *m.motionCount
+			m.motionCount = nil
+		}
 
 		op := ""
 		switch firstKey.Rune {
@@ -145,6 +273,8 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 			op = "yank"
 		case 'c': // Add change later
 			op = "change"
+		case 'q': // gq
+			op = "reflow"
 		default:
 			m.pendingKey = KeyEvent{Key: KeyUnknown}
 			m.pendingModifier = 0
@@ -162,20 +292,25 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 			// Handle text objects after modifier
 			switch key.Rune {
-			case 'w': // iw or aw = inside/around word
+			case 'w', 'W': // iw or aW = inside/around word or WORD
 				switch op {
 				case "yank":
-					err = yankTextObject(editor, buffer, modifier, 'w')
+					err = yankTextObject(editor, buffer, modifier, key.Rune)
 					actionTaken = true
 				case "delete":
-					err = deleteTextObject(editor, buffer, modifier, 'w')
+					err = deleteTextObject(editor, buffer, modifier, key.Rune)
 					actionTaken = true
 				case "change":
-					err = changeTextObject(editor, buffer, modifier, 'w')
+					err = changeTextObject(editor, buffer, modifier, key.Rune)
 					actionTaken = true
 				}
 			case 'p': // ip or ap = inside/around paragraph
 				switch op {
+				case "reflow":
+					if startRow, endRow, found := paragraphRows(buffer, cursor.Position, modifier); found {
+						err = reflowRows(editor, buffer, startRow, endRow)
+					}
+					actionTaken = true
 				case "yank":
 					err = yankParagraphTextObject(editor, buffer, modifier)
 					actionTaken = true
@@ -186,8 +321,20 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 					err = changeParagraphTextObject(editor, buffer, modifier)
 					actionTaken = true
 				}
+			case '(', ')', 'b', '[', ']', '{', '}', 'B', '<', '>', '"', '\'', '`': // i( a" ... = inside/around brackets and quotes
+				if r, found := delimitedTextObjectRange(buffer, cursor.Position, modifier, key.Rune); found {
+					err = applyOperator(editor, buffer, op, r)
+				}
+				actionTaken = true
+			case 's': // is or as = inside/around statement, for languages with a StatementSyntax
+				if state.StatementSyntax == nil {
+					editor.DispatchError(ErrInvalidMotionId, newNotice(MsgInvalidTextObject, key.Rune, modifier))
+				} else if r, found := statementTextObjectRange(buffer, state.StatementSyntax, cursor.Position, modifier); found {
+					err = applyOperator(editor, buffer, op, r)
+				}
+				actionTaken = true
 			default:
-				editor.DispatchError(ErrInvalidMotionId, fmt.Errorf("invalid text object '%c' after '%c'", key.Rune, modifier))
+				editor.DispatchError(ErrInvalidMotionId, newNotice(MsgInvalidTextObject, key.Rune, modifier))
 				actionTaken = true
 			}
 
@@ -196,7 +343,6 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 			}
 
 			if actionTaken {
-				editor.UpdateCommand("")
 				return nil
 			}
 
@@ -206,142 +352,55 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		// Check for text object modifiers (i/a)
 		if key.Rune == 'i' || key.Rune == 'a' {
 			m.pendingModifier = key.Rune
-			editor.UpdateCommand(fmt.Sprintf("%s%c%c", editor.GetState().CommandLine, firstKey.Rune, key.Rune))
 			return nil // Wait for the text object key
 		}
 
 		// Check for character search motions (f/F/t/T)
 		if key.Rune == 'f' || key.Rune == 'F' || key.Rune == 't' || key.Rune == 'T' {
-			m.charSearch.searchType = key.Rune
-			m.charSearch.waitingForChar = true
-			editor.UpdateCommand(fmt.Sprintf("%s%c", editor.GetState().CommandLine, key.Rune))
+			m.charSearch.begin(key.Rune, count)
 			// Keep pendingKey - we'll process the operator after getting the character
 			return nil
 		}
 
-		// Consume the pending key now if not waiting for text object
-		m.pendingKey = KeyEvent{Key: KeyUnknown}
-
-		// Handle motion keys after the operator
-		// Supported operator-motion commands:
-		//
-		// Yank commands:
-		//   yy  - yank current line (line-wise)
-		//   yw  - yank word forward (character-wise)
-		//   yb  - yank word backward (character-wise)
-		//   y$  - yank to end of line (character-wise)
-		//   yiw - yank inside word (character-wise)
-		//   yaw - yank around word, includes surrounding whitespace (character-wise)
-		//
-		// Delete commands:
-		//   dd  - delete current line (line-wise)
-		//   dw  - delete word forward (character-wise)
-		//   d$  - delete to end of line (character-wise)
-		switch key.Rune {
-		case 'd': // dd = delete line
-			if op == "delete" {
-				var deletedContent string
-				deletedContent, err = deleteLines(editor, buffer, count)
-				editor.DispatchSignal(DeleteSignal{content: deletedContent})
-				actionTaken = true
-			}
-		case 'y': // yy = yank line
-			if op == "yank" {
-				err = yankLines(editor, buffer, count)
-				actionTaken = true
-			}
-		case 'c': // cc = change line
-			if op == "change" {
-				_, err = deleteLines(editor, buffer, count)
-				if err == nil {
-					editor.SetInsertMode()
-				}
-				actionTaken = true
-			}
-		case 'w': // dw = delete word, yw = yank word forward, cw = change word
-			switch op {
-			case "delete":
-				err = deleteWords(editor, buffer, count)
-				actionTaken = true
-			case "yank":
-				err = yankWords(editor, buffer, count, true) // forward
-				actionTaken = true
-			case "change":
-				err = changeWords(editor, buffer, count)
-				actionTaken = true
-			}
-		case 'b': // yb = yank word backward, cb = change word backward, db = delete word backward
-			switch op {
-			case "delete":
-				err = deleteWordsBackward(editor, buffer, count)
-				actionTaken = true
-			case "yank":
-				err = yankWords(editor, buffer, count, false) // backward
-				actionTaken = true
-			case "change":
-				err = changeWordsBackward(editor, buffer, count)
-				actionTaken = true
-			}
-		case 'e': // de = delete to word end, ye = yank to word end, ce = change to word end
-			switch op {
-			case "delete":
-				err = deleteWordToEnd(editor, buffer, count)
-				actionTaken = true
-			case "yank":
-				err = yankWordToEnd(editor, buffer, count)
-				actionTaken = true
-			case "change":
-				err = changeWords(editor, buffer, count) // ce and cw behave the same
-				actionTaken = true
-			}
-		case '$': // d$ = delete to end of line, y$ = yank to end of line, c$ = change to end of line
-			switch op {
-			case "delete":
-				var deletedContent string
-				deletedContent, err = deleteToEndOfLine(editor, buffer)
-				editor.DispatchSignal(DeleteSignal{content: deletedContent})
-				actionTaken = true
-			case "yank":
-				err = yankToEndOfLine(editor, buffer)
-				actionTaken = true
-			case "change":
-				err = changeToEndOfLine(editor, buffer)
-				actionTaken = true
-			}
-		case 'G': // dG, yG, cG
-			switch op {
-			case "delete":
-				count := buffer.LineCount() - cursor.Position.Row
-				var deletedContent string
-				deletedContent, err = deleteLines(editor, buffer, count)
-				editor.DispatchSignal(DeleteSignal{content: deletedContent})
-				actionTaken = true
-			case "yank":
-				count := buffer.LineCount() - cursor.Position.Row
-				err = yankLines(editor, buffer, count)
-				actionTaken = true
-			case "change":
-				count := buffer.LineCount() - cursor.Position.Row
-				_, err = deleteLines(editor, buffer, count)
-				if err == nil {
-					editor.SetInsertMode()
-				}
-				actionTaken = true
+		// Repeated character search motions (d; y, c;)
+		if key.Rune == ';' || key.Rune == ',' {
+			m.pendingKey = KeyEvent{Key: KeyUnknown}
+			if searchType, char, ok := lastCharSearchMotion(editor, key.Rune == ','); ok {
+				err = handleCharSearchOperator(editor, buffer, op, searchType, char, count, true)
 			}
+			return err
+		}
 
+		// Consume the pending key now if not waiting for text object
+		m.pendingKey = KeyEvent{Key: KeyUnknown}
+		pendingMotion := m.pendingMotion
+		m.pendingMotion = 0
+
+		// Every other motion returns the range it covers, and the operator acts
+		// on that range: dw, yj, c0, dG, d}, ygg, ...
+		var r textRange
+		ok := false
+		switch {
+		case pendingMotion == 'g' && (key.Rune == 'e' || key.Rune == 'E'):
+			r, ok = wordEndBackwardRange(editor, buffer, key.Rune, count), true
+		case pendingMotion == 'g' && key.Rune != 'g' && (op != "reflow" || key.Rune != 'q'): // gqgq is gqq
 		default:
+			r, ok = operatorMotionRange(editor, buffer, op, key, count, hasCount)
+		}
+		if ok {
+			err = applyOperator(editor, buffer, op, r)
+		} else {
 			// Invalid motion key after operator
-			editor.DispatchError(ErrInvalidMotionId, fmt.Errorf("invalid motion after '%c'", firstKey.Rune))
-			actionTaken = true         // Consumed the keys, even if invalid combo
+			editor.DispatchError(ErrInvalidMotionId, newNotice(MsgInvalidMotion, operatorName(firstKey.Rune)))
 			editor.ResetPendingCount() // Reset count if combo was invalid
 		}
+		actionTaken = true
 
 		if err != nil {
 			return err // Return error from buffer operation
 		}
 
 		if actionTaken {
-			editor.UpdateCommand("")
 			return nil
 		} // Sequence handled
 
@@ -375,8 +434,6 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 			state.PendingCount = &newCount // Update state
 			editor.SetState(state)         // Save state
 		}
-		// Update command display regardless of actionTaken status for digits
-		editor.UpdateCommand(fmt.Sprintf("%d", *state.PendingCount))
 		return nil // Just consuming digits, wait for command
 
 	} else if key.Rune == '0' && pendingCount == nil {
@@ -391,10 +448,9 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		// '0' as part of a multi-digit count
 		digit := 0
 		newCount := (*pendingCount * 10) + digit
-		state.PendingCount = &newCount                               // Update state
-		editor.SetState(state)                                       // Save state
-		editor.UpdateCommand(fmt.Sprintf("%d", *state.PendingCount)) // Show count
-		return nil                                                   // Consuming digit, wait for command
+		state.PendingCount = &newCount // Update state
+		editor.SetState(state)         // Save state
+		return nil                     // Consuming digit, wait for command
 	}
 
 	// --- Get Count or Default to 1 ---
@@ -417,9 +473,9 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 	case key.Rune == 'h' || key.Key == KeyLeft:
 		moveErr = cursor.MoveLeftOrUp(buffer, count, col)
 	case key.Rune == 'j' || key.Key == KeyDown:
-		moveErr = cursor.MoveDown(buffer, count, availableWidth)
+		moveErr = editor.MoveLines(&cursor, count, state.DisplayLineMovement)
 	case key.Rune == 'k' || key.Key == KeyUp:
-		moveErr = cursor.MoveUp(buffer, count, availableWidth)
+		moveErr = editor.MoveLines(&cursor, -count, state.DisplayLineMovement)
 	case key.Key == KeyCtrlD:
 		moveErr = cursor.ScrollDown(buffer, state.ViewportHeight, availableWidth)
 	case key.Key == KeyCtrlU:
@@ -430,12 +486,22 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		moveErr = cursor.MoveBlockBackward(buffer, count)
 	case key.Rune == '}':
 		moveErr = cursor.MoveBlockForward(buffer, count)
+	case key.Rune == '(':
+		moveErr = cursor.MoveSentenceBackward(buffer, count)
+	case key.Rune == ')':
+		moveErr = cursor.MoveSentenceForward(buffer, count)
 	case key.Rune == 'w':
 		moveErr = cursor.MoveWordForward(buffer, count, availableWidth, editor.IsWordChar)
 	case key.Rune == 'e':
 		moveErr = cursor.MoveWordToEnd(buffer, count, availableWidth, editor.IsWordChar)
 	case key.Rune == 'b':
 		moveErr = cursor.MoveWordBackward(buffer, count, availableWidth, editor.IsWordChar)
+	case key.Rune == 'W':
+		moveErr = cursor.MoveWordForward(buffer, count, availableWidth, isWORDChar)
+	case key.Rune == 'E':
+		moveErr = cursor.MoveWordToEnd(buffer, count, availableWidth, isWORDChar)
+	case key.Rune == 'B':
+		moveErr = cursor.MoveWordBackward(buffer, count, availableWidth, isWORDChar)
 	case key.Rune == '0':
 		cursor.MoveToLineStart()
 	case key.Rune == '$' || key.Key == KeyEnd:
@@ -443,7 +509,9 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 	case key.Rune == '^' || key.Key == KeyHome:
 		cursor.MoveToFirstNonBlank(buffer, availableWidth)
 	case key.Rune == 'g':
+		m.gOrigin, m.gCount, m.gCounted = cursor, count, countWasPending
 		cursor.MoveToBufferStart() // Move to first line
+		m.gPrefix = true
 	case key.Rune == 'G':
 		cursor.MoveToBufferEnd(buffer, availableWidth) // Moves to start of last line
 	case key.Key == KeyEnter: // Move down count lines to first non-blank
@@ -455,7 +523,6 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		} else {
 			cursor.Position.Row = count - 1
 			buffer.SetCursor(cursor)
-			editor.UpdateCommand("")
 			editor.ResetPendingCount()
 		}
 
@@ -465,7 +532,7 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		editor.ResetPendingCount() // Clear count if entering insert mode
 
 	case key.Rune == 'I': // Insert at first non-blank
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 		cursor.MoveToFirstNonBlank(buffer, availableWidth)
@@ -474,7 +541,7 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		editor.ResetPendingCount() // Clear count if entering insert mode
 
 	case key.Rune == 'a': // Insert after cursor
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 		cursor.MoveRight(buffer, 1, availableWidth) // Move one right (allows append at end of line)
@@ -482,7 +549,7 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		editor.SetInsertMode()
 
 	case key.Rune == 'A': // Insert at end of line
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 		cursor.MoveToAfterLineEnd(buffer, availableWidth) // Move *after* last char
@@ -490,26 +557,28 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		editor.SetInsertMode()
 
 	case key.Rune == 'o': // Open line below
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 		cursor.MoveToAfterLineEnd(buffer, availableWidth) // Go to end of current line
 		buffer.SetCursor(cursor)
-		buffer.InsertRunesAt(cursor.Position.Row, cursor.Position.Col, []rune("\n")) // Insert newline
-		cursor.MoveDown(buffer, 1, availableWidth)                                   // Move cursor down
-		cursor.MoveToFirstNonBlank(buffer, availableWidth)                           // Go to start of new line
+		indent := autoIndent(state, buffer.GetLineRunes(cursor.Position.Row))
+		buffer.InsertRunesAt(cursor.Position.Row, cursor.Position.Col, append([]rune("\n"), indent...)) // Insert newline
+		cursor.Position.Row++                                                                           // Go to the new line
+		cursor.MoveToAfterLineEnd(buffer, availableWidth)                                               // After its indent
 		buffer.SetCursor(cursor)
 		editor.SaveHistory()
 		editor.SetInsertMode()
 
 	case key.Rune == 'O': // Open line above
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
-		cursor.MoveToLineStart()                                   // Go to start of current linavailableWidthe
-		buffer.InsertRunesAt(cursor.Position.Row, 0, []rune("\n")) // Insert newline (pushes current line down)
-		// Cursor stays on original line index, which is now the new blank line
-		cursor.MoveToFirstNonBlank(buffer, availableWidth) // Ensure col=0 on the new line
+		cursor.MoveToLineStart() // Go to start of current line
+		indent := autoIndent(state, buffer.GetLineRunes(cursor.Position.Row))
+		buffer.InsertRunesAt(cursor.Position.Row, 0, append(indent, '\n')) // Insert newline (pushes current line down)
+		// Cursor stays on original line index, which is now the new line
+		cursor.MoveToAfterLineEnd(buffer, availableWidth) // After its indent
 		buffer.SetCursor(cursor)
 		editor.SaveHistory()
 		editor.SetInsertMode()
@@ -524,44 +593,82 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		// If pending count or op, clear them
 		pendingCount = nil
 		m.pendingKey = KeyEvent{Key: KeyUnknown}
-		editor.UpdateCommand("") // Clear count display
 		editor.SetNormalMode()
 
-	case key.Rune == ':': // Enter command mode
-		editor.SetCommandMode()
+	case key.Rune == ':': // Enter command mode, with the lines of a count as the range (3: gives :.,.+2)
+		switch {
+		case pendingCount == nil:
+			editor.SetCommandMode()
+		case *pendingCount == 1:
+			startCommand(editor, ".")
+		default:
+			startCommand(editor, fmt.Sprintf(".,.+%d", *pendingCount-1))
+		}
+
+	case key.Key == KeyCtrlG: // Show the file name and position
+		editor.UpdateCommand(editor.FileInfo())
+
+	case key.Rune == 'Z': // ZZ and ZQ wait for the second key
+		m.zPrefix = true
+		return nil
+
+	case key.Rune == 'm' || key.Rune == '\'' || key.Rune == '`': // ma, 'a and `a wait for the mark
+		m.markPrefix = key.Rune
+		return nil
+
+	case key.Rune == ']' || key.Rune == '[': // ]s and [s wait for the second key
+		m.bracketPrefix = key.Rune
+		return nil
 
 	case key.Rune == '/': // Enter search mode
 		editor.SetSearchMode()
 
 	case key.Rune == 'n': // Go to next search result
+		if !editor.allow(Search) {
+			return nil
+		}
 		cursor = editor.NextSearchResult()
 
 	case key.Rune == 'N': // Go to previous search result
+		if !editor.allow(Search) {
+			return nil
+		}
 		cursor = editor.PreviousSearchResult()
 
+	case key.Rune == '*' || key.Rune == '#': // Search for the word under the cursor
+		if !editor.allow(Search) {
+			return nil
+		}
+		backwards := key.Rune == '#'
+		err = editor.SearchWordUnderCursor(backwards)
+		for i := 1; err == nil && i < count; i++ {
+			if backwards {
+				editor.PreviousSearchResult()
+			} else {
+				editor.NextSearchResult()
+			}
+		}
+		cursor = buffer.GetCursor()
+
 	// Character search motions
 	case key.Rune == 'f': // Find character forward
-		m.charSearch.searchType = 'f'
-		m.charSearch.waitingForChar = true
-		editor.UpdateCommand("f")
+		m.charSearch.begin('f', count)
+		editor.ResetPendingCount()
 		return nil
 
 	case key.Rune == 'F': // Find character backward
-		m.charSearch.searchType = 'F'
-		m.charSearch.waitingForChar = true
-		editor.UpdateCommand("F")
+		m.charSearch.begin('F', count)
+		editor.ResetPendingCount()
 		return nil
 
 	case key.Rune == 't': // Till character forward
-		m.charSearch.searchType = 't'
-		m.charSearch.waitingForChar = true
-		editor.UpdateCommand("t")
+		m.charSearch.begin('t', count)
+		editor.ResetPendingCount()
 		return nil
 
 	case key.Rune == 'T': // Till character backward
-		m.charSearch.searchType = 'T'
-		m.charSearch.waitingForChar = true
-		editor.UpdateCommand("T")
+		m.charSearch.begin('T', count)
+		editor.ResetPendingCount()
 		return nil
 
 	case key.Rune == ';': // Repeat last character search
@@ -572,13 +679,14 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 	// Editing commands (single key or start of sequence)
 	case key.Rune == 'x': // Delete character under cursor
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
-		lineLen := buffer.LineRuneCount(cursor.Position.Row)
-		if cursor.Position.Col < lineLen { // Only delete if cursor is on a char
-			err = buffer.DeleteRunesAt(cursor.Position.Row, cursor.Position.Col, count)
+		line := buffer.GetLineRunes(cursor.Position.Row)
+		if cursor.Position.Col < len(line) { // Only delete if cursor is on a char
+			runeCount := graphemeRuneCount(line, cursor.Position.Col, count)
+			err = buffer.DeleteRunesAt(cursor.Position.Row, cursor.Position.Col, runeCount)
 			if err == nil {
 				editor.SaveHistory()
 			}
@@ -595,14 +703,20 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 			buffer.SetCursor(cursor)
 		}
 	case key.Rune == 'X': // Delete character before cursor
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
 		if cursor.Position.Col > 0 {
-			err = buffer.DeleteRunesAt(cursor.Position.Row, cursor.Position.Col-1, count)
+			line := buffer.GetLineRunes(cursor.Position.Row)
+			startCol := cursor.Position.Col
+			for range count {
+				startCol = prevGraphemeCol(line, startCol)
+			}
+			err = buffer.DeleteRunesAt(cursor.Position.Row, startCol, cursor.Position.Col-startCol)
 			if err == nil {
-				cursor.MoveLeft(buffer, count, availableWidth) // Move cursor back
+				cursor.Position.Col = startCol // Move cursor back
+				cursor.Preferred = startCol % max(availableWidth, 1)
 				buffer.SetCursor(cursor)
 				editor.SaveHistory()
 			}
@@ -614,56 +728,52 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		}
 
 	case key.Rune == 'D': // Delete to end of line (equivalent to d$)
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
 		var deletedContent string
 		deletedContent, err = deleteToEndOfLine(editor, buffer)
-		editor.DispatchSignal(DeleteSignal{content: deletedContent})
+		editor.DispatchSignal(DeleteSignal{newRegisterText(deletedContent, cursor.Position, false)})
 
 	case key.Rune == 'r': // Replace character under cursor
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
 		m.waitingForReplace = true
-		editor.UpdateCommand("r")
 		return nil
 
 	case key.Rune == 'C': // Change to end of line (equivalent to c$)
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
 		err = changeToEndOfLine(editor, buffer)
 	case key.Rune == 'd': // Start 'delete' operation
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
 		m.pendingKey = key
 		// Don't clear count yet
-		editor.UpdateCommand(fmt.Sprintf("%s%c", editor.GetState().CommandLine, key.Rune))
 
 		return nil // Wait for the next key (motion)
 
 	case key.Rune == 'c': // Start 'change' operation
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
 		m.pendingKey = key
-		editor.UpdateCommand(fmt.Sprintf("%s%c", editor.GetState().CommandLine, key.Rune))
 		return nil // Wait for the next key (motion)
 
 	case key.Rune == 'y': // Start 'yank' operation
 		m.pendingKey = key
-		editor.UpdateCommand(fmt.Sprintf("%s%c", editor.GetState().CommandLine, key.Rune))
 		return nil // Wait for the next key (motion)
 
 	case key.Rune == 'p':
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
@@ -691,12 +801,10 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 				id:  ErrFailedToPasteId,
 				err: pasteErr,
 			}
-		} else {
-			editor.DispatchSignal(PasteSignal{content: content})
 		}
 
 	case key.Rune == 'P':
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
@@ -723,12 +831,13 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 				id:  ErrFailedToPasteId,
 				err: pasteErr,
 			}
-		} else {
-			editor.DispatchSignal(PasteSignal{content: content})
 		}
 
 	case key.Rune == 'u': // Undo
-		if content, undoErr := editor.Undo(); undoErr != nil {
+		if !editor.allow(EditText) {
+			return nil
+		}
+		if content, undoErr := editor.undo(); undoErr != nil {
 			err = &EditorError{
 				id:  ErrUndoFailedId,
 				err: undoErr,
@@ -738,8 +847,25 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		}
 		skipCursorUpdate = true
 
-	case key.Rune == 'U': // Redo
-		if content, redoErr := editor.Redo(); redoErr != nil {
+	case key.Rune == 'U' && !editor.GetState().RedoOnU: // Undo line
+		if !editor.allow(EditText) {
+			return nil
+		}
+		if content, undoErr := editor.UndoLine(); undoErr != nil {
+			err = &EditorError{
+				id:  ErrUndoFailedId,
+				err: undoErr,
+			}
+		} else {
+			editor.DispatchSignal(UndoSignal{contentBefore: content})
+		}
+		skipCursorUpdate = true
+
+	case key.Key == KeyCtrlR || key.Rune == 'U': // Redo
+		if !editor.allow(EditText) {
+			return nil
+		}
+		if content, redoErr := editor.redo(); redoErr != nil {
 			err = &EditorError{
 				id:  ErrRedoFailedId,
 				err: redoErr,
@@ -782,7 +908,7 @@ func (m *normalMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 }
 
 // handleCharSearchRepeat handles repeating (;) or reversing (,) the last character search.
-func (m *normalMode) handleCharSearchRepeat(editor Editor, buffer Buffer, reverse bool) Cursor {
+func (m *normalMode) handleCharSearchRepeat(editor modeContext, buffer Buffer, reverse bool) Cursor {
 	state := editor.GetState()
 	count := 1
 	if state.PendingCount != nil {
@@ -790,16 +916,46 @@ func (m *normalMode) handleCharSearchRepeat(editor Editor, buffer Buffer, revers
 		editor.ResetPendingCount()
 	}
 
-	repeatCharSearch(&m.charSearch, editor, buffer, count, reverse)
+	repeatCharSearch(editor, buffer, count, reverse)
 
 	return buffer.GetCursor() // Return refreshed cursor
 }
 
 // clearPendingState resets all pending state in normal mode
-func (m *normalMode) clearPendingState(editor Editor) {
+// isPending reports whether a multi-key command has been started but not
+// finished, e.g. an operator waiting for its motion or f waiting for a character.
+// A lone g is not pending: it has already moved to the first line.
+func (m *normalMode) isPending() bool {
+	return m.pendingKey.Key != KeyUnknown || m.pendingKey.Rune != 0 ||
+		m.pendingModifier != 0 ||
+		m.charSearch.waitingForChar ||
+		m.waitingForReplace ||
+		m.motionCount != nil ||
+		m.pendingMotion != 0 ||
+		m.zPrefix ||
+		m.bracketPrefix != 0 ||
+		m.markPrefix != 0
+}
+
+func (m *normalMode) clearPendingState(editor modeContext) {
 	m.pendingKey = KeyEvent{Key: KeyUnknown}
 	m.pendingModifier = 0
 	m.charSearch = charSearchState{}
 	m.waitingForReplace = false
+	m.motionCount = nil
+	m.pendingMotion = 0
+	m.gPrefix = false
+	m.zPrefix = false
+	m.bracketPrefix = 0
+	m.markPrefix = 0
 	editor.ResetPendingCount()
 }
+
+// operatorName returns how the operator started by r is typed: gq for q, r
+// for the others.
+func operatorName(r rune) string {
+	if r == 'q' {
+		return "gq"
+	}
+	return string(r)
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// lineIndex holds where each line starts in the content, for converting
+// between positions and offsets in O(log n). It's built when first needed
+// after a change, and kept until the next one.
+type lineIndex struct {
+	revision uint64 // Revision of the content the index is for
+	bytes    []int  // Byte offset of the start of each line
+	runes    []int  // Rune offset of the start of each line
+}
+
+// lineIndex returns the index of the current content.
+func (b *textBuffer) lineIndex() *lineIndex {
+	if b.index != nil && b.index.revision == b.revision {
+		return b.index
+	}
+
+	separator := b.lineEnding.Sequence()
+	index := &lineIndex{
+		revision: b.revision,
+		bytes:    make([]int, len(b.lines)),
+		runes:    make([]int, len(b.lines)),
+	}
+	byteOffset, runeOffset := 0, 0
+	for i, line := range b.lines {
+		index.bytes[i], index.runes[i] = byteOffset, runeOffset
+		for _, r := range line {
+			byteOffset += utf8.RuneLen(r)
+		}
+		byteOffset += len(separator)
+		runeOffset += len(line) + len(separator)
+	}
+	b.index = index
+	return index
+}
+
+// clampPosition returns pos moved into the content, at most just past the
+// end of its line.
+func (b *textBuffer) clampPosition(pos Position) Position {
+	pos.Row = max(0, min(pos.Row, len(b.lines)-1))
+	pos.Col = max(0, min(pos.Col, len(b.lines[pos.Row])))
+	return pos
+}
+
+// OffsetOf returns the byte offset of pos in the content as
+// GetCurrentContent returns it, UTF-8 encoded and with the line ending
+// between lines, as LSP servers and parsers such as tree-sitter count.
+// Positions outside the content are moved into it first.
+func (b *textBuffer) OffsetOf(pos Position) int {
+	pos = b.clampPosition(pos)
+	offset := b.lineIndex().bytes[pos.Row]
+	for _, r := range b.lines[pos.Row][:pos.Col] {
+		offset += utf8.RuneLen(r)
+	}
+	return offset
+}
+
+// PositionOf returns the position at byte offset in the content, as OffsetOf
+// counts it. An offset within a character or a line ending is the position
+// of that character or the end of that line.
+func (b *textBuffer) PositionOf(offset int) Position {
+	row := findLine(b.lineIndex().bytes, offset)
+	rest := offset - b.index.bytes[row]
+	col := 0
+	for _, r := range b.lines[row] {
+		rest -= utf8.RuneLen(r)
+		if rest < 0 {
+			break
+		}
+		col++
+	}
+	return Position{Row: row, Col: col}
+}
+
+// RuneOffsetOf returns the offset of pos in the content in runes, counting a
+// line ending as the runes it's written with.
+func (b *textBuffer) RuneOffsetOf(pos Position) int {
+	pos = b.clampPosition(pos)
+	return b.lineIndex().runes[pos.Row] + pos.Col
+}
+
+// PositionOfRune returns the position at a rune offset in the content, as
+// RuneOffsetOf counts it.
+func (b *textBuffer) PositionOfRune(offset int) Position {
+	row := findLine(b.lineIndex().runes, offset)
+	col := min(max(0, offset-b.index.runes[row]), len(b.lines[row]))
+	return Position{Row: row, Col: col}
+}
+
+// findLine returns the line starting last at or before offset, given the
+// offsets lines start at.
+func findLine(starts []int, offset int) int {
+	return max(0, sort.SearchInts(starts, offset+1)-1)
+}
@@ -0,0 +1,354 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// motionKind describes which text an operator acts on when it is followed by a motion
+type motionKind int
+
+const (
+	motionExclusive motionKind = iota // Up to, but not including, the end position (w, b, h, l, 0, ^, {, }, (, ))
+	motionInclusive                   // Up to and including the end position (e, ge, $)
+	motionLinewise                    // Whole lines from the start row to the end row (j, k, G, gg, dd)
+)
+
+// textRange is the text an operator acts on. For characterwise ranges end is
+// exclusive; for linewise ranges only the rows matter and both are included.
+type textRange struct {
+	start, end Position
+	linewise   bool
+}
+
+func (r textRange) isEmpty() bool {
+	return !r.linewise && r.start == r.end
+}
+
+// colsInRow returns the columns [lo, hi) of row covered by r.
+func (r textRange) colsInRow(row, lineLen int) (lo, hi int) {
+	lo, hi = 0, lineLen
+	if !r.linewise {
+		if row == r.start.Row {
+			lo = min(r.start.Col, lineLen)
+		}
+		if row == r.end.Row {
+			hi = min(r.end.Col, lineLen)
+		}
+	}
+	return lo, hi
+}
+
+// motionRange returns the text covered by moving from one position to another
+// with a motion of the given kind. Like Vim, an exclusive motion that ends in
+// column 0 of a later line stops at the end of the previous line instead, and
+// becomes linewise when it started at or before the first non-blank.
+func motionRange(buffer Buffer, from, to Position, kind motionKind) textRange {
+	start, end := NormalizeSelection(from, to)
+
+	switch kind {
+	case motionLinewise:
+		return textRange{start: Position{Row: start.Row}, end: Position{Row: end.Row}, linewise: true}
+
+	case motionInclusive:
+		line := buffer.GetLineRunes(end.Row)
+		if end.Col < len(line) {
+			end.Col = graphemeEndCol(line, end.Col) + 1
+		} else {
+			end.Col = len(line)
+		}
+
+	case motionExclusive:
+		if end.Row > start.Row && end.Col == 0 {
+			end = Position{Row: end.Row - 1, Col: buffer.LineRuneCount(end.Row - 1)}
+			if start.Col <= firstNonBlankCol(buffer.GetLineRunes(start.Row)) {
+				return textRange{start: Position{Row: start.Row}, end: Position{Row: end.Row}, linewise: true}
+			}
+		}
+	}
+
+	return textRange{start: start, end: end}
+}
+
+// firstNonBlankCol returns the column of the first non-whitespace rune, or 0.
+func firstNonBlankCol(line []rune) int {
+	for i, r := range line {
+		if !unicode.IsSpace(r) {
+			return i
+		}
+	}
+	return 0
+}
+
+// motionWordChars returns the characters a word motion moves over: keywords for
+// w, b and e, and any non-blank characters for W, B and E.
+func motionWordChars(editor modeContext, motion rune) func(rune) bool {
+	if unicode.IsUpper(motion) {
+		return isWORDChar
+	}
+	return editor.IsWordChar
+}
+
+// operatorMotionRange resolves the motion typed after an operator into the text
+// it covers from the cursor. ok is false if key is not a motion. hasCount
+// reports whether a count was typed, which changes G and gg.
+func operatorMotionRange(editor modeContext, buffer Buffer, op string, key KeyEvent, count int, hasCount bool) (r textRange, ok bool) {
+	state := editor.GetState()
+	availableWidth := state.AvailableWidth
+	cursor := buffer.GetCursor()
+	from := cursor.Position
+	lastRow := buffer.LineCount() - 1
+	line := buffer.GetLineRunes(from.Row)
+
+	switch {
+	// Repeating the operator (dd, yy, cc) acts on count lines
+	case op == "delete" && key.Rune == 'd', op == "yank" && key.Rune == 'y', op == "change" && key.Rune == 'c',
+		op == "reflow" && key.Rune == 'q':
+		return motionRange(buffer, from, Position{Row: min(from.Row+count-1, lastRow)}, motionLinewise), true
+
+	case key.Rune == 'j' || key.Key == KeyDown || key.Key == KeyEnter:
+		if from.Row == lastRow {
+			return textRange{}, true
+		}
+		return motionRange(buffer, from, Position{Row: min(from.Row+count, lastRow)}, motionLinewise), true
+
+	case key.Rune == 'k' || key.Key == KeyUp:
+		if from.Row == 0 {
+			return textRange{}, true
+		}
+		return motionRange(buffer, from, Position{Row: max(from.Row-count, 0)}, motionLinewise), true
+
+	case key.Rune == 'G':
+		target := lastRow
+		if hasCount {
+			target = min(max(count-1, 0), lastRow)
+		}
+		return motionRange(buffer, from, Position{Row: target}, motionLinewise), true
+
+	case key.Rune == 'g': // gg, the second g is handled by the caller
+		target := 0
+		if hasCount {
+			target = min(max(count-1, 0), lastRow)
+		}
+		return motionRange(buffer, from, Position{Row: target}, motionLinewise), true
+
+	case key.Rune == 'h' || key.Key == KeyLeft || key.Key == KeyBackspace:
+		col := from.Col
+		for range count {
+			col = prevGraphemeCol(line, col)
+		}
+		return motionRange(buffer, from, Position{Row: from.Row, Col: col}, motionExclusive), true
+
+	case key.Rune == 'l' || key.Key == KeyRight || key.Key == KeySpace:
+		col := from.Col + graphemeRuneCount(line, from.Col, count)
+		return motionRange(buffer, from, Position{Row: from.Row, Col: col}, motionExclusive), true
+
+	case key.Rune == '0' || key.Key == KeyHome:
+		return motionRange(buffer, from, Position{Row: from.Row}, motionExclusive), true
+
+	case key.Rune == '^':
+		return motionRange(buffer, from, Position{Row: from.Row, Col: firstNonBlankCol(line)}, motionExclusive), true
+
+	case key.Rune == '$' || key.Key == KeyEnd:
+		row := min(from.Row+count-1, lastRow)
+		if row == from.Row && from.Col >= len(line) {
+			return textRange{}, true
+		}
+		end := Position{Row: row, Col: max(buffer.LineRuneCount(row)-1, 0)}
+		return motionRange(buffer, from, end, motionInclusive), true
+
+	case key.Rune == 'w' || key.Rune == 'W':
+		// cw on a non-blank changes to the end of the word, like ce
+		if op == "change" && from.Col < len(line) && !isWhiteSpace(line[from.Col]) {
+			// On the last character of a word, that character is the first
+			// word, as e would move on to the end of the next
+			isWord := motionWordChars(editor, key.Rune)
+			next := from.Col + 1
+			if next == len(line) || isWhiteSpace(line[next]) || isWord(line[next]) != isWord(line[from.Col]) {
+				if count == 1 {
+					return motionRange(buffer, from, from, motionInclusive), true
+				}
+				count--
+			}
+			end := 'e'
+			if key.Rune == 'W' {
+				end = 'E'
+			}
+			return operatorMotionRange(editor, buffer, op, KeyEvent{Rune: end}, count, hasCount)
+		}
+
+		target := cursor
+		_ = target.MoveWordForward(buffer, count, availableWidth, motionWordChars(editor, key.Rune))
+		to := target.Position
+
+		// When the last word moved over ends its line, the operator stops there
+		// instead of continuing to the first word of the next line
+		if to.Row > from.Row && to.Col <= firstNonBlankCol(buffer.GetLineRunes(to.Row)) {
+			if prevLen := buffer.LineRuneCount(to.Row - 1); prevLen > 0 {
+				to = Position{Row: to.Row - 1, Col: prevLen}
+			}
+		}
+		return motionRange(buffer, from, to, motionExclusive), true
+
+	case key.Rune == 'b' || key.Rune == 'B':
+		target := cursor
+		_ = target.MoveWordBackward(buffer, count, availableWidth, motionWordChars(editor, key.Rune))
+		return motionRange(buffer, from, target.Position, motionExclusive), true
+
+	case key.Rune == 'e' || key.Rune == 'E':
+		target := cursor
+		if err := target.MoveWordToEnd(buffer, count, availableWidth, motionWordChars(editor, key.Rune)); err != nil {
+			return textRange{}, true
+		}
+		return motionRange(buffer, from, target.Position, motionInclusive), true
+
+	case key.Rune == '}':
+		target := cursor
+		if err := target.MoveBlockForward(buffer, count); err != nil {
+			return textRange{}, true
+		}
+		to := target.Position
+		// Without a blank line below, the paragraph ends with the buffer
+		if buffer.LineRuneCount(to.Row) > 0 {
+			to.Col = buffer.LineRuneCount(to.Row)
+		}
+		return motionRange(buffer, from, to, motionExclusive), true
+
+	case key.Rune == '{':
+		target := cursor
+		if err := target.MoveBlockBackward(buffer, count); err != nil {
+			return textRange{}, true
+		}
+		return motionRange(buffer, from, target.Position, motionExclusive), true
+
+	case key.Rune == ')':
+		target := cursor
+		if err := target.MoveSentenceForward(buffer, count); err != nil {
+			return textRange{}, true
+		}
+		to := target.Position
+		// Without another sentence, the last one ends with the buffer
+		if to.Row == lastRow && !isSentenceStart(buffer, to) {
+			to.Col = buffer.LineRuneCount(to.Row)
+		}
+		return motionRange(buffer, from, to, motionExclusive), true
+
+	case key.Rune == '(':
+		target := cursor
+		if err := target.MoveSentenceBackward(buffer, count); err != nil {
+			return textRange{}, true
+		}
+		return motionRange(buffer, from, target.Position, motionExclusive), true
+	}
+
+	return textRange{}, false
+}
+
+// wordEndBackwardRange returns the text ge or gE covers after an operator:
+// from the end of the count-th word before the cursor through the character
+// under it, both included.
+func wordEndBackwardRange(editor modeContext, buffer Buffer, motion rune, count int) textRange {
+	cursor := buffer.GetCursor()
+	target := cursor
+	if err := target.MoveWordEndBackward(buffer, count, editor.GetState().AvailableWidth, motionWordChars(editor, motion)); err != nil {
+		return textRange{}
+	}
+	return motionRange(buffer, cursor.Position, target.Position, motionInclusive)
+}
+
+// textInRange returns the text covered by a characterwise range.
+func textInRange(buffer Buffer, r textRange) string {
+	if r.start.Row == r.end.Row {
+		line := buffer.GetLineRunes(r.start.Row)
+		return string(line[min(r.start.Col, len(line)):min(r.end.Col, len(line))])
+	}
+
+	var sb strings.Builder
+	first := buffer.GetLineRunes(r.start.Row)
+	sb.WriteString(string(first[min(r.start.Col, len(first)):]))
+	for row := r.start.Row + 1; row < r.end.Row; row++ {
+		sb.WriteString("\n")
+		sb.WriteString(string(buffer.GetLineRunes(row)))
+	}
+	last := buffer.GetLineRunes(r.end.Row)
+	sb.WriteString("\n")
+	sb.WriteString(string(last[:min(r.end.Col, len(last))]))
+	return sb.String()
+}
+
+// textEnd returns the position just past text inserted at start.
+func textEnd(start Position, text string) Position {
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return Position{Row: start.Row, Col: start.Col + utf8.RuneCountInString(text)}
+	}
+	return Position{Row: start.Row + len(lines) - 1, Col: utf8.RuneCountInString(lines[len(lines)-1])}
+}
+
+// applyOperator runs a delete, yank or change operator over r.
+func applyOperator(editor modeContext, buffer Buffer, op string, r textRange) *EditorError {
+	if r.isEmpty() {
+		return nil
+	}
+
+	switch op {
+	case "delete":
+		var deletedContent string
+		var err *EditorError
+		if r.linewise {
+			deletedContent, err = deleteLineRange(editor, buffer, r.start.Row, r.end.Row)
+		} else {
+			deletedContent, err = deleteCharRange(editor, buffer, r)
+		}
+		if err != nil {
+			return err
+		}
+		start := r.start
+		if r.linewise {
+			start.Col = 0
+		}
+		editor.DispatchSignal(DeleteSignal{newRegisterText(deletedContent, start, r.linewise)})
+
+	case "yank":
+		return yankRange(editor, buffer, r)
+
+	case "change":
+		var err *EditorError
+		if r.linewise {
+			err = changeLineRange(editor, buffer, r.start.Row, r.end.Row)
+		} else {
+			_, err = deleteCharRange(editor, buffer, r)
+		}
+		if err != nil {
+			return err
+		}
+		if r.linewise {
+			// The lines typed undo together with the lines they replace
+			editor.joinChange()
+		}
+		editor.SetInsertMode()
+
+	case "reflow":
+		return reflowRows(editor, buffer, r.start.Row, r.end.Row)
+	}
+
+	return nil
+}
+
+// executeLineOperator runs :d and :y, which delete or yank the lines of
+// lines, or the cursor line without a range, after applying a count in args.
+func (e *editor) executeLineOperator(op string, lines *lineRange, args []string) *EditorError {
+	r := lineRange{e.buffer.GetCursor().Position.Row, e.buffer.GetCursor().Position.Row}
+	if lines != nil {
+		r = *lines
+	}
+	r, args, err := withCount(e.buffer, r, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+	}
+	return applyOperator(e, e.buffer, op, textRange{start: Position{Row: r.start}, end: Position{Row: r.end}, linewise: true})
+}
@@ -0,0 +1,463 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOperatorLinewiseMotions tests operators with j, k, G and gg, which act on whole lines.
+func TestOperatorLinewiseMotions(t *testing.T) {
+	t.Run("dj deletes the current and next line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree\nfour")
+		keys(e, 'd', 'j')
+		assert.Equal(t, "three\nfour", content(e))
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("d2j deletes three lines", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree\nfour")
+		keys(e, 'd', '2', 'j')
+		assert.Equal(t, "four", content(e))
+	})
+
+	t.Run("dk deletes the current and previous line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		keys(e, 'j', 'j', 'd', 'k')
+		assert.Equal(t, "one", content(e))
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("dj on the last line does nothing", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'j', 'd', 'j')
+		assert.Equal(t, "one\ntwo", content(e))
+	})
+
+	t.Run("dG deletes to the last line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		keys(e, 'j', 'd', 'G')
+		assert.Equal(t, "one", content(e))
+	})
+
+	t.Run("d2G deletes up to line 2", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree\nfour")
+		keys(e, 'G', 'd', '2', 'G')
+		assert.Equal(t, "one", content(e))
+	})
+
+	t.Run("dgg deletes to the first line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		keys(e, 'j', 'd', 'g', 'g')
+		assert.Equal(t, "three", content(e))
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("ygg moves the cursor to the first yanked line", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("one\ntwo\nthree")
+		keys(e, 'j', 'l', 'y', 'g', 'g')
+		assert.Equal(t, "one\ntwo\n", cb.content)
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("yj keeps the cursor", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("one\ntwo\nthree")
+		keys(e, 'l', 'y', 'j')
+		assert.Equal(t, "one\ntwo\n", cb.content)
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("cj replaces the lines and enters insert mode", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		keys(e, 'c', 'j')
+		assertInsertMode(t, e)
+		assert.Equal(t, "\nthree", content(e))
+	})
+
+	t.Run("dg followed by another key is invalid", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'j', 'd', 'g', 'x')
+		assert.Equal(t, "one\ntwo", content(e))
+	})
+}
+
+// TestOperatorLinewiseChange tests that c over lines leaves one line for the
+// text typed, which undoes in one step with the lines it replaced.
+func TestOperatorLinewiseChange(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		keys       string
+		autoIndent bool
+		changed    string
+		cursor     Position
+	}{
+		{name: "cc", keys: "jccX", changed: "a\nX\nc", cursor: Position{1, 1}},
+		{name: "cj", keys: "cjX", changed: "X\nc", cursor: Position{0, 1}},
+		{name: "cG", keys: "jcGX", changed: "a\nX", cursor: Position{1, 1}},
+		{name: "2cc on the last lines", keys: "j2ccXY", changed: "a\nXY", cursor: Position{1, 2}},
+		{name: "cc keeps the indent with autoindent", keys: "jccX", autoIndent: true, changed: "a\n  X\nc", cursor: Position{1, 3}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			const original = "a\n  bb\nc"
+			e := newTestEditor(original)
+			if tt.autoIndent {
+				assert.Nil(t, e.ExecuteCommand("set autoindent"))
+			}
+			keys(e, []rune(tt.keys)...)
+			assertInsertMode(t, e)
+			assert.Equal(t, tt.changed, content(e))
+			assert.Equal(t, tt.cursor, cursorPos(e))
+
+			escape(e)
+			keys(e, 'u')
+			assert.Equal(t, original, content(e))
+
+			redo(e)
+			assert.Equal(t, tt.changed, content(e))
+		})
+	}
+}
+
+// TestOperatorCharacterwiseMotions tests operators with exclusive and inclusive motions within and across lines.
+func TestOperatorCharacterwiseMotions(t *testing.T) {
+	t.Run("d0 deletes to the start of the line", func(t *testing.T) {
+		e := newTestEditor("hello world")
+		keys(e, 'w', 'd', '0')
+		assert.Equal(t, "world", content(e))
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("cw on the last character of a word changes only that character", func(t *testing.T) {
+		e := newTestEditor("a\nbb")
+		keys(e, 'c', 'w')
+		assert.Equal(t, "\nbb", content(e))
+
+		e = newTestEditor("ab cd ef")
+		keys(e, 'l', 'c', '2', 'w')
+		assert.Equal(t, "a ef", content(e))
+	})
+
+	t.Run("c0 changes to the start of the line", func(t *testing.T) {
+		e := newTestEditor("hello world")
+		keys(e, 'w', 'c', '0')
+		assertInsertMode(t, e)
+		assert.Equal(t, "world", content(e))
+	})
+
+	t.Run("y^ yanks back to the first non-blank", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("  hello world")
+		keys(e, '$', 'y', '^')
+		assert.Equal(t, "hello worl", cb.content)
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("d^ from before the first non-blank deletes the indent up to it", func(t *testing.T) {
+		e := newTestEditor("    x")
+		keys(e, 'l', 'd', '^')
+		assert.Equal(t, " x", content(e))
+	})
+
+	t.Run("dh and dl delete before and under the cursor", func(t *testing.T) {
+		e := newTestEditor("abcdef")
+		keys(e, 'l', 'l', 'd', 'h')
+		assert.Equal(t, "acdef", content(e))
+		keys(e, 'd', '2', 'l')
+		assert.Equal(t, "aef", content(e))
+	})
+
+	t.Run("dl stops at the end of the line", func(t *testing.T) {
+		e := newTestEditor("ab\ncd")
+		keys(e, 'l', 'd', '5', 'l')
+		assert.Equal(t, "a\ncd", content(e))
+	})
+
+	t.Run("d2$ deletes to the end of the next line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		keys(e, 'l', 'd', '2', '$')
+		assert.Equal(t, "o\nthree", content(e))
+	})
+
+	t.Run("de, ce and ye take the end of the word", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("foo bar baz")
+		keys(e, 'y', 'e')
+		assert.Equal(t, "foo", cb.content)
+		keys(e, 'd', '2', 'e')
+		assert.Equal(t, " baz", content(e))
+		keys(e, 'c', 'e')
+		assertInsertMode(t, e)
+		assert.Equal(t, "", content(e))
+	})
+
+	t.Run("dge deletes back to the end of the previous word, inclusive", func(t *testing.T) {
+		e := newTestEditor("foo bar baz")
+		keys(e, '$', 'd', 'g', 'e')
+		assert.Equal(t, "foo ba", content(e))
+	})
+
+	t.Run("ygE yanks back to the end of the previous WORD", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("a.b c.d")
+		keys(e, '$', 'y', 'g', 'E')
+		assert.Equal(t, "b c.d", cb.content)
+	})
+
+	t.Run("dw on the last word of a line keeps the line break", func(t *testing.T) {
+		e := newTestEditor("foo bar\nbaz")
+		keys(e, 'w', 'd', 'w')
+		assert.Equal(t, "foo \nbaz", content(e))
+	})
+
+	t.Run("cw on a word does not eat the following space", func(t *testing.T) {
+		e := newTestEditor("foo bar")
+		keys(e, 'c', 'w')
+		assertInsertMode(t, e)
+		assert.Equal(t, " bar", content(e))
+	})
+
+	t.Run("d} deletes to the end of the paragraph", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\n\nthree")
+		keys(e, 'l', 'd', '}')
+		assert.Equal(t, "o\n\nthree", content(e))
+	})
+
+	t.Run("d} from the start of a line is linewise", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\n\nthree")
+		keys(e, 'd', '}')
+		assert.Equal(t, "\nthree", content(e))
+	})
+
+	t.Run("d} in the last paragraph deletes to the end of the buffer", func(t *testing.T) {
+		e := newTestEditor("one\ntwo three")
+		keys(e, 'l', 'd', '}')
+		assert.Equal(t, "o", content(e))
+	})
+
+	t.Run("d{ deletes back to the start of the blank line", func(t *testing.T) {
+		e := newTestEditor("one\n\ntwo\nthree")
+		keys(e, 'G', '$', 'd', '{')
+		assert.Equal(t, "one\ne", content(e))
+	})
+
+	t.Run("delete dispatches the deleted text", func(t *testing.T) {
+		e := newTestEditor("hello world")
+		drainSignals(e)
+		keys(e, 'd', 'e')
+
+		var deleted []string
+		for sig := nextSignal(e); sig != nil; sig = nextSignal(e) {
+			if d, ok := sig.(DeleteSignal); ok {
+				deleted = append(deleted, d.Value())
+			}
+		}
+		assert.Equal(t, []string{"hello"}, deleted)
+	})
+}
+
+// TestOperatorCounts tests that a count before the operator and a count before
+// the motion multiply, as in Vim.
+func TestOperatorCounts(t *testing.T) {
+	words := "a b c d e f g h i j"
+	lines := "1\n2\n3\n4\n5\n6\n7\n8"
+
+	tests := []struct {
+		name    string
+		content string
+		keys    string
+		want    string
+	}{
+		{"operator count", words, "6dw", "g h i j"},
+		{"motion count", words, "d6w", "g h i j"},
+		{"both counts multiply", words, "2d3w", "g h i j"},
+		{"multi-digit counts", words, "1d10w", ""},
+		{"count larger than the buffer", words, "3d9w", ""},
+		{"inclusive motion", words, "2d3e", " h i j"},
+		{"characterwise motion", "abcdefghij", "2d3l", "ghij"},
+		{"character search", "axbxcxdxe", "2d2fx", "e"},
+		{"repeated operator", lines, "2d2d", "5\n6\n7\n8"},
+		{"count after the operator", lines, "d3d", "4\n5\n6\n7\n8"},
+		{"linewise motion", lines, "2d2j", "6\n7\n8"},
+		{"count selects the line for G", lines, "3dG", "4\n5\n6\n7\n8"},
+		{"count selects the line for gg", lines, "Gd2gg", "1"},
+		{"0 after an operator is a motion", "abcdef", "$2d0", "f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.content)
+			keys(e, []rune(tt.keys)...)
+			assert.Equal(t, tt.want, content(e))
+		})
+	}
+
+	t.Run("yank uses the combined count", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard(words)
+		keys(e, '2', 'y', '3', 'w')
+		assert.Equal(t, "a b c d e f ", cb.content)
+	})
+
+	t.Run("counts do not carry over after the operator", func(t *testing.T) {
+		e := newTestEditor(words)
+		keys(e, '2', 'd', '3', 'w', 'd', 'w')
+		assert.Equal(t, "h i j", content(e))
+		assert.Nil(t, e.GetState().PendingCount)
+	})
+}
+
+// TestOperatorDelimitedTextObjects tests operators with bracket and quote text objects.
+func TestOperatorDelimitedTextObjects(t *testing.T) {
+	t.Run("di( from inside nested parentheses uses the innermost pair", func(t *testing.T) {
+		e := newTestEditor("f(g(x), y)")
+		keys(e, 'f', 'x', 'd', 'i', '(')
+		assert.Equal(t, "f(g(), y)", content(e))
+	})
+
+	t.Run("da( on the closing parenthesis", func(t *testing.T) {
+		e := newTestEditor("f(a) b")
+		keys(e, 'f', ')', 'd', 'a', 'b')
+		assert.Equal(t, "f b", content(e))
+	})
+
+	t.Run("ci\" changes inside the quotes", func(t *testing.T) {
+		e := newTestEditor(`s := "old"`)
+		keys(e, 'c', 'i', '"')
+		assertInsertMode(t, e)
+		assert.Equal(t, `s := ""`, content(e))
+		assert.Equal(t, Position{0, 6}, cursorPos(e))
+	})
+
+	t.Run("yi[ yanks inside the brackets", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("a[1, 2]")
+		keys(e, '$', 'y', 'i', '[')
+		assert.Equal(t, "1, 2", cb.content)
+	})
+
+	t.Run("di{ on a block deletes the lines inside", func(t *testing.T) {
+		e := newTestEditor("func f() {\n\ta()\n\tb()\n}")
+		keys(e, 'j', 'd', 'i', '{')
+		assert.Equal(t, "func f() {\n}", content(e))
+	})
+
+	t.Run("di( spanning lines stops before a closing line", func(t *testing.T) {
+		e := newTestEditor("f(a,\n  b\n)")
+		keys(e, 'j', 'd', 'i', '(')
+		assert.Equal(t, "f(\n)", content(e))
+	})
+
+	t.Run("no enclosing pair does nothing", func(t *testing.T) {
+		e := newTestEditor("abc")
+		keys(e, 'd', 'i', '(')
+		assert.Equal(t, "abc", content(e))
+	})
+}
+
+// TestHasPendingKeys tests reporting a command that waits for more keys.
+func TestHasPendingKeys(t *testing.T) {
+	t.Run("idle", func(t *testing.T) {
+		e := newTestEditor("abc def")
+		assert.False(t, e.HasPendingKeys())
+	})
+
+	for _, prefix := range []string{"d", "3", "d2", "f", "r", "di", "dg"} {
+		t.Run(prefix, func(t *testing.T) {
+			e := newTestEditor("abc def")
+			keys(e, []rune(prefix)...)
+			assert.True(t, e.HasPendingKeys())
+
+			escape(e)
+			assert.False(t, e.HasPendingKeys())
+		})
+	}
+
+	t.Run("finished commands and a lone g", func(t *testing.T) {
+		e := newTestEditor("abc def")
+		keys(e, 'd', 'w')
+		assert.False(t, e.HasPendingKeys())
+		keys(e, 'g')
+		assert.False(t, e.HasPendingKeys())
+	})
+}
+
+// TestPendingKeys tests showing the keys of the command being typed.
+func TestPendingKeys(t *testing.T) {
+	t.Run("count, operator and motion count", func(t *testing.T) {
+		e := newTestEditor("one two three four five six seven")
+		keys(e, '2')
+		assert.Equal(t, "2", e.GetState().PendingKeys)
+		keys(e, 'd', '3')
+		assert.Equal(t, "2d3", e.GetState().PendingKeys)
+		assert.Empty(t, e.GetState().CommandLine)
+
+		keys(e, 'w')
+		assert.Empty(t, e.GetState().PendingKeys)
+		assert.Equal(t, "seven", content(e))
+	})
+
+	for _, prefix := range []string{"d", "3", "d2", "f", "r", "di", "dg", "2yt"} {
+		t.Run("escape cancels "+prefix, func(t *testing.T) {
+			e := newTestEditor("abc def")
+			keys(e, []rune(prefix)...)
+			assert.Equal(t, prefix, e.GetState().PendingKeys)
+
+			escape(e)
+			assert.Empty(t, e.GetState().PendingKeys)
+		})
+	}
+
+	t.Run("visual mode", func(t *testing.T) {
+		e := newTestEditor("a-b-c-d")
+		keys(e, 'v', '2')
+		assert.Equal(t, "2", e.GetState().PendingKeys)
+		keys(e, 'f')
+		assert.Equal(t, "2f", e.GetState().PendingKeys)
+		keys(e, '-')
+		assert.Empty(t, e.GetState().PendingKeys)
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+	})
+}
+
+// TestTimeoutKey tests dropping pending keys with KeyTimeout.
+func TestTimeoutKey(t *testing.T) {
+	timeout := func(e Editor) {
+		assert.Nil(t, e.HandleKey(KeyEvent{Key: KeyTimeout}))
+	}
+
+	for _, prefix := range []string{"d", "3", "d2", "f", "r", "di", "2yt"} {
+		t.Run("drops "+prefix, func(t *testing.T) {
+			e := newTestEditor("abc def")
+			keys(e, []rune(prefix)...)
+			timeout(e)
+			assert.False(t, e.HasPendingKeys())
+			assert.Empty(t, e.GetState().PendingKeys)
+
+			keys(e, 'w')
+			assert.Equal(t, "abc def", content(e), "w only moves")
+			assert.Equal(t, Position{0, 4}, cursorPos(e))
+		})
+	}
+
+	t.Run("visual mode keeps the selection", func(t *testing.T) {
+		e := newTestEditor("a-b-c-d")
+		keys(e, 'v', '2', 'f')
+		timeout(e)
+		assert.True(t, e.IsVisualMode())
+		assert.Empty(t, e.GetState().PendingKeys)
+
+		keys(e, 'l')
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("without pending keys it does nothing", func(t *testing.T) {
+		e := newTestEditor("abc")
+		keys(e, 'i')
+		timeout(e)
+		assert.True(t, e.IsInsertMode())
+		assert.Equal(t, "abc", content(e))
+	})
+
+	t.Run(":set timeoutlen is in milliseconds", func(t *testing.T) {
+		e := newTestEditor("")
+		assert.Nil(t, e.ExecuteCommand("set tm=500"))
+		assert.Equal(t, 500*time.Millisecond, e.GetState().TimeoutLen)
+		assert.NotNil(t, e.ExecuteCommand("set timeoutlen=-1"))
+	})
+}
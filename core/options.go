@@ -0,0 +1,177 @@
+package core
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// splitOptions splits the options of :set at whitespace, except for spaces
+// escaped with a backslash, as :set sbr=↪\  sets "↪ ".
+func splitOptions(options string) []string {
+	var split []string
+	var option strings.Builder
+	escaped := false
+	for _, r := range options {
+		switch {
+		case escaped:
+			if r != ' ' {
+				option.WriteRune('\\')
+			}
+			option.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case unicode.IsSpace(r):
+			if option.Len() > 0 {
+				split = append(split, option.String())
+				option.Reset()
+			}
+		default:
+			option.WriteRune(r)
+		}
+	}
+	if escaped { // The command was trimmed of the space escaped last
+		option.WriteRune(' ')
+	}
+	if option.Len() > 0 {
+		split = append(split, option.String())
+	}
+	return split
+}
+
+// valueOptions are the options :set gives a value, as in :set tw=80, under
+// their long and short names. set returns false if it can't take value.
+// Options that list letters also take += and -= to add and remove them.
+var valueOptions = []struct {
+	names []string
+	lists bool
+	set   func(e *editor, op, value string) bool
+}{
+	{names: []string{"fileformat", "ff"}, set: func(e *editor, _, value string) bool {
+		ending, ok := ParseLineEnding(value)
+		if ok {
+			e.buffer.SetLineEnding(ending)
+			e.SaveHistory()
+		}
+		return ok
+	}},
+	{names: []string{"fileencoding", "fenc"}, set: func(e *editor, _, value string) bool {
+		enc, ok := ParseEncoding(value)
+		if ok {
+			e.setEncoding(enc)
+		}
+		return ok
+	}},
+	{names: []string{"showbreak", "sbr"}, set: func(e *editor, _, value string) bool {
+		e.SetShowBreak(value)
+		return true
+	}},
+	{names: []string{"numberwidth", "nuw"}, set: func(e *editor, _, value string) bool {
+		width, ok := numberValue(value, 1)
+		if ok {
+			e.SetNumberWidth(width)
+		}
+		return ok
+	}},
+	{names: []string{"textwidth", "tw"}, set: func(e *editor, _, value string) bool {
+		width, ok := numberValue(value, 0)
+		if ok {
+			e.SetTextWidth(width)
+		}
+		return ok
+	}},
+	{names: []string{"timeoutlen", "tm"}, set: func(e *editor, _, value string) bool {
+		ms, ok := numberValue(value, 0)
+		if ok {
+			e.SetTimeoutLen(time.Duration(ms) * time.Millisecond)
+		}
+		return ok
+	}},
+	{names: []string{"tabstop", "ts"}, set: func(e *editor, _, value string) bool {
+		width, ok := numberValue(value, 1)
+		if ok {
+			e.state.TabWidth = width
+		}
+		return ok
+	}},
+	{names: []string{"formatoptions", "fo"}, lists: true, set: func(e *editor, op, value string) bool {
+		e.setFormatOptions(op, value)
+		return true
+	}},
+}
+
+// flagOptions are the options :set turns on by name, and off with no before
+// the name, as in :set nu and :set nonu, under their long and short names.
+var flagOptions = []struct {
+	names []string
+	set   func(e *editor, on bool)
+}{
+	{names: []string{"relativenumber", "rnu"}, set: func(e *editor, on bool) {
+		e.state.RelativeNumbers = on
+		e.DispatchSignal(RelativeNumbersSignal{enabled: on})
+	}},
+	{names: []string{"number", "nu"}, set: func(e *editor, on bool) {
+		e.state.Numbers = on
+		e.DispatchSignal(NumbersSignal{enabled: on})
+	}},
+	{names: []string{"breakindent", "bri"}, set: func(e *editor, on bool) {
+		e.SetBreakIndent(on)
+	}},
+	{names: []string{"expandtab", "et"}, set: func(e *editor, on bool) {
+		e.state.ExpandTab = on
+	}},
+	{names: []string{"autoindent", "ai"}, set: func(e *editor, on bool) {
+		e.state.AutoIndent = on
+	}},
+}
+
+// numberValue parses the value of an option that is a whole number no less
+// than least.
+func numberValue(value string, least int) (int, bool) {
+	n, err := strconv.Atoi(value)
+	return n, err == nil && n >= least
+}
+
+// invalidOption is the error of :set for an option it doesn't know, or a
+// value the option can't take.
+func invalidOption() *EditorError {
+	return &EditorError{
+		id:  ErrInvalidCommandId,
+		err: ErrInvalidCommand,
+	}
+}
+
+// setOption sets an option as :set does, such as relativenumber or ff=dos.
+func (e *editor) setOption(option string) *EditorError {
+	if i := strings.Index(option, "="); i > 0 {
+		name, op, value := option[:i], "=", option[i+1:]
+		if strings.HasSuffix(name, "+") || strings.HasSuffix(name, "-") {
+			name, op = name[:len(name)-1], name[len(name)-1:]+"="
+		}
+		for _, o := range valueOptions {
+			if slices.Contains(o.names, name) {
+				if op != "=" && !o.lists || !o.set(e, op, value) {
+					return invalidOption()
+				}
+				return nil
+			}
+		}
+		return invalidOption()
+	}
+
+	name, off := strings.CutPrefix(option, "no")
+	for _, o := range flagOptions {
+		if slices.Contains(o.names, option) {
+			o.set(e, true)
+			return nil
+		}
+		if off && slices.Contains(o.names, name) {
+			o.set(e, false)
+			return nil
+		}
+	}
+	return invalidOption()
+}
@@ -145,3 +145,68 @@ func TestPasteLinewiseBefore(t *testing.T) {
 		assert.Equal(t, Position{0, 0}, cursorPos(e))
 	})
 }
+
+// TestPasteOverSelection tests 'p' in visual and visual line mode.
+func TestPasteOverSelection(t *testing.T) {
+	t.Run("character-wise over a character-wise selection", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("foo bar")
+		cb.content = "baz"
+		keys(e, 'w', 'v', 'e', 'p')
+		assert.Equal(t, "foo baz", content(e))
+		assert.Equal(t, Position{0, 6}, cursorPos(e))
+		assert.True(t, e.IsNormalMode())
+	})
+
+	t.Run("the replaced text goes to the clipboard", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("foo bar")
+		cb.content = "baz"
+		keys(e, 'v', 'e', 'p')
+		assert.Equal(t, "foo", cb.content)
+		assert.Equal(t, "baz bar", content(e))
+	})
+
+	t.Run("a single undo restores the selection's text", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("one\ntwo")
+		cb.content = "new"
+		keys(e, 'v', 'e', 'p', 'u')
+		assert.Equal(t, "one\ntwo", content(e))
+	})
+
+	t.Run("line-wise over a character-wise selection goes on its own line", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("abcdef")
+		cb.content = "line\n"
+		keys(e, 'l', 'l', 'v', 'l', 'p')
+		assert.Equal(t, "ab\nline\nef", content(e))
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+	})
+
+	t.Run("line-wise over lines replaces them", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("a\nb\nc\nd")
+		cb.content = "x\ny\n"
+		keys(e, 'j', 'V', 'j', 'p')
+		assert.Equal(t, "a\nx\ny\nd", content(e))
+		assert.Equal(t, "b\nc\n", cb.content)
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+	})
+
+	t.Run("line-wise over the last lines", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("a\nb\nc")
+		cb.content = "x\n"
+		keys(e, 'G', 'V', 'k', 'p')
+		assert.Equal(t, "a\nx", content(e))
+	})
+
+	t.Run("line-wise over every line", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("a\nb")
+		cb.content = "x\n"
+		keys(e, 'V', 'j', 'p')
+		assert.Equal(t, "x", content(e))
+	})
+
+	t.Run("character-wise over lines goes on a line of its own", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("a\nb\nc")
+		cb.content = "word"
+		keys(e, 'j', 'V', 'p')
+		assert.Equal(t, "a\nword\nc", content(e))
+	})
+}
@@ -0,0 +1,248 @@
+package core
+
+// Keys of the editor without Vim mode, as in a plain text area: Shift with
+// the arrow keys, Home and End selects, Ctrl with Left and Right jumps by
+// word, Ctrl-A selects everything, Ctrl-C, Ctrl-X and Ctrl-V copy, cut and
+// paste, and Ctrl-Z and Ctrl-Y undo and redo. The selection runs from
+// VisualStart to the cursor, without the character under the cursor.
+
+// handlePlainKey handles a key of the editor without Vim mode. It reports
+// whether the key was handled; other keys go on to insert mode, after typed
+// text has replaced the selection.
+func (e *editor) handlePlainKey(key KeyEvent) (bool, *EditorError) {
+	if e.state.VimMode || !e.IsInsertMode() {
+		return false, nil
+	}
+
+	if moved, ok := e.plainMotion(key); ok {
+		shift := key.Modifiers&ModShift != 0
+		start, end, selected := e.plainSelection()
+		switch {
+		case shift && !selected:
+			if e.allow(VisualSelect) {
+				e.state.VisualStart = e.buffer.GetCursor().Position
+			}
+		case !shift && selected && key.Modifiers&ModCtrl == 0 && (key.Key == KeyLeft || key.Key == KeyRight):
+			// Left and Right go to the start and end of the selection
+			e.ResetSelection()
+			moved.Position = start
+			if key.Key == KeyRight {
+				moved.Position = end
+			}
+		case !shift:
+			e.ResetSelection()
+		}
+		e.buffer.SetCursor(moved)
+		if e.state.VisualStart == moved.Position {
+			e.ResetSelection()
+		}
+		return true, nil
+	}
+
+	// Keys that change the text need EditText
+	switch key.Key {
+	case KeyCtrlX, KeyCtrlV, KeyCtrlZ, KeyCtrlY, KeyBackspace, KeyDelete, KeyEnter, KeyTab:
+		if !e.allow(EditText) {
+			return true, nil
+		}
+	default:
+		if key.Rune != 0 && !e.allow(EditText) {
+			return true, nil
+		}
+	}
+
+	switch key.Key {
+	case KeyCtrlA:
+		if !e.allow(VisualSelect) {
+			return true, nil
+		}
+		e.state.VisualStart = Position{}
+		cursor := e.buffer.GetCursor()
+		cursor.Position.Row = e.buffer.LineCount() - 1
+		cursor.MoveToAfterLineEnd(e.buffer, e.state.AvailableWidth)
+		e.buffer.SetCursor(cursor)
+		if cursor.Position == (Position{}) {
+			e.ResetSelection()
+		}
+		return true, nil
+
+	case KeyCtrlC:
+		return true, e.copyPlainSelection(false)
+
+	case KeyCtrlX:
+		return true, e.copyPlainSelection(true)
+
+	case KeyCtrlV:
+		if _, _, selected := e.plainSelection(); selected {
+			e.deletePlainSelection()
+		}
+		return true, pasteInsert(e)
+
+	case KeyCtrlZ:
+		e.ResetSelection()
+		content, err := e.undo()
+		if err != nil {
+			return true, &EditorError{id: ErrUndoFailedId, err: err}
+		}
+		e.DispatchSignal(UndoSignal{contentBefore: content})
+		return true, nil
+
+	case KeyCtrlY:
+		e.ResetSelection()
+		content, err := e.redo()
+		if err != nil {
+			return true, &EditorError{id: ErrRedoFailedId, err: err}
+		}
+		e.DispatchSignal(RedoSignal{contentBefore: content})
+		return true, nil
+
+	case KeyBackspace, KeyDelete:
+		if _, _, selected := e.plainSelection(); selected {
+			e.deletePlainSelection()
+			return true, nil
+		}
+		if key.Key == KeyDelete {
+			return true, e.deleteForward()
+		}
+		return false, nil
+	}
+
+	// Typing replaces the selection
+	if key.Rune != 0 || key.Key == KeyEnter || key.Key == KeyTab {
+		if _, _, selected := e.plainSelection(); selected {
+			e.deletePlainSelection()
+		}
+	}
+	return false, nil
+}
+
+// plainMotion returns where a motion key of the editor without Vim mode
+// moves the cursor, and whether key is one.
+func (e *editor) plainMotion(key KeyEvent) (Cursor, bool) {
+	cursor := e.buffer.GetCursor()
+	width := e.state.AvailableWidth
+	ctrl := key.Modifiers&ModCtrl != 0
+
+	switch key.Key {
+	case KeyLeft:
+		if ctrl {
+			_ = cursor.MoveWordBackward(e.buffer, 1, width, e.IsWordChar)
+		} else if cursor.Position.Col > 0 {
+			_ = cursor.MoveLeft(e.buffer, 1, width)
+		} else if cursor.Position.Row > 0 {
+			cursor.Position.Row--
+			cursor.MoveToAfterLineEnd(e.buffer, width)
+		}
+	case KeyRight:
+		if ctrl {
+			_ = cursor.MoveWordForward(e.buffer, 1, width, e.IsWordChar)
+		} else if cursor.Position.Col < e.buffer.LineRuneCount(cursor.Position.Row) {
+			_ = cursor.MoveRight(e.buffer, 1, width)
+		} else if cursor.Position.Row < e.buffer.LineCount()-1 {
+			cursor.Position.Row++
+			cursor.MoveToLineStart()
+		}
+	case KeyUp:
+		if cursor.Position.Row == 0 {
+			cursor.MoveToLineStart()
+		} else {
+			_ = cursor.MoveUp(e.buffer, 1, width)
+		}
+	case KeyDown:
+		if cursor.Position.Row == e.buffer.LineCount()-1 {
+			cursor.MoveToAfterLineEnd(e.buffer, width)
+		} else {
+			_ = cursor.MoveDown(e.buffer, 1, width)
+		}
+	case KeyHome:
+		if ctrl {
+			cursor.MoveToBufferStart()
+			break
+		}
+		// Home goes to the first non-blank, then to the start of the line
+		col := cursor.Position.Col
+		cursor.MoveToFirstNonBlank(e.buffer, width)
+		if cursor.Position.Col == col {
+			cursor.MoveToLineStart()
+		}
+	case KeyEnd:
+		if ctrl {
+			cursor.Position.Row = e.buffer.LineCount() - 1
+		}
+		cursor.MoveToAfterLineEnd(e.buffer, width)
+	default:
+		return cursor, false
+	}
+	return cursor, true
+}
+
+// plainSelection returns the selection of the editor without Vim mode in
+// buffer order, with end exclusive, and whether there is one.
+func (e *editor) plainSelection() (start, end Position, selected bool) {
+	if e.state.VisualStart.Row == -1 {
+		return Position{}, Position{}, false
+	}
+	start, end = NormalizeSelection(e.state.VisualStart, e.buffer.GetCursor().Position)
+	return start, end, start != end
+}
+
+// copyPlainSelection writes the selection to the clipboard, and deletes it
+// if cut is set.
+func (e *editor) copyPlainSelection(cut bool) *EditorError {
+	start, end, selected := e.plainSelection()
+	if !selected {
+		return nil
+	}
+
+	if !e.allow(ClipboardWrite) {
+		return nil
+	}
+	if e.clipboard == nil {
+		return &EditorError{id: ErrFailedToYankId, err: newNotice(MsgNoClipboard)}
+	}
+
+	text := textInRange(e.buffer, textRange{start: start, end: end})
+	if err := e.clipboard.Write(text); err != nil {
+		return &EditorError{id: ErrFailedToYankId, err: err}
+	}
+
+	if !cut {
+		e.DispatchSignal(YankSignal{newRegisterText(text, start, false)})
+		return nil
+	}
+	e.deletePlainSelection()
+	e.DispatchSignal(DeleteSignal{newRegisterText(text, start, false)})
+	return nil
+}
+
+// deletePlainSelection deletes the selection and leaves the cursor where it
+// started.
+func (e *editor) deletePlainSelection() {
+	start, end, _ := e.plainSelection()
+	e.ResetSelection()
+	_, _ = deleteCharRange(e, e.buffer, textRange{start: start, end: end})
+}
+
+// deleteForward deletes the character after the cursor, or joins the next
+// line at the end of a line, for Delete.
+func (e *editor) deleteForward() *EditorError {
+	cursor := e.buffer.GetCursor()
+	row, col := cursor.Position.Row, cursor.Position.Col
+	line := e.buffer.GetLineRunes(row)
+
+	var err *EditorError
+	switch {
+	case col < len(line):
+		err = e.buffer.DeleteRunesAt(row, col, nextGraphemeCol(line, col)-col)
+	case row < e.buffer.LineCount()-1:
+		err = e.buffer.DeleteRunesAt(row, len(line), 1)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	e.buffer.SetCursor(cursor)
+	e.SaveHistory()
+	return nil
+}
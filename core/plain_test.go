@@ -0,0 +1,195 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// press sends a key with modifiers.
+func press(e Editor, key KeyCode, mods KeyModifiers) *EditorError {
+	return e.HandleKey(KeyEvent{Key: key, Modifiers: mods})
+}
+
+// selectedText returns the text selected without Vim mode.
+func selectedText(e Editor) string {
+	start, end, _ := e.(*editor).plainSelection()
+	return textInRange(e.GetBuffer(), textRange{start: start, end: end})
+}
+
+// TestPlainSelection tests selecting with Shift and the motion keys without
+// Vim mode.
+func TestPlainSelection(t *testing.T) {
+	t.Run("Shift with the arrow keys", func(t *testing.T) {
+		e := newTestEditor("hello world\nsecond", withoutVimMode())
+		press(e, KeyRight, ModShift)
+		press(e, KeyRight, ModShift)
+		assert.Equal(t, "he", selectedText(e))
+		assert.Equal(t, SelectionCharacter, e.GetSelectionStatus(Position{0, 1}))
+		assert.Equal(t, SelectionNone, e.GetSelectionStatus(Position{0, 2}))
+
+		press(e, KeyDown, ModShift)
+		assert.Equal(t, "hello world\nse", selectedText(e))
+	})
+
+	t.Run("Shift with Ctrl selects words", func(t *testing.T) {
+		e := newTestEditor("hello world", withoutVimMode())
+		press(e, KeyRight, ModShift|ModCtrl)
+		assert.Equal(t, "hello ", selectedText(e))
+	})
+
+	t.Run("Shift with Home and End", func(t *testing.T) {
+		e := newTestEditor("  hello", withoutVimMode())
+		press(e, KeyEnd, ModShift)
+		assert.Equal(t, "  hello", selectedText(e))
+		press(e, KeyHome, ModShift)
+		assert.Equal(t, "  ", selectedText(e))
+	})
+
+	t.Run("moving back to the start ends the selection", func(t *testing.T) {
+		e := newTestEditor("hello", withoutVimMode())
+		press(e, KeyRight, ModShift)
+		press(e, KeyLeft, ModShift)
+		assert.False(t, e.(*editor).IsSelected())
+	})
+
+	t.Run("Left and Right go to the ends of the selection", func(t *testing.T) {
+		e := newTestEditor("hello", withoutVimMode())
+		press(e, KeyRight, ModNone)
+		press(e, KeyRight, ModShift)
+		press(e, KeyRight, ModShift)
+		press(e, KeyLeft, ModNone)
+		assert.False(t, e.(*editor).IsSelected())
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+
+		press(e, KeyRight, ModShift)
+		press(e, KeyRight, ModShift)
+		press(e, KeyRight, ModNone)
+		assert.Equal(t, Position{0, 3}, cursorPos(e))
+	})
+
+	t.Run("Ctrl-A selects everything", func(t *testing.T) {
+		e := newTestEditor("one\ntwo", withoutVimMode())
+		press(e, KeyCtrlA, ModCtrl)
+		assert.Equal(t, "one\ntwo", selectedText(e))
+	})
+
+	t.Run("typing replaces the selection", func(t *testing.T) {
+		e := newTestEditor("hello world", withoutVimMode())
+		press(e, KeyRight, ModShift|ModCtrl)
+		keys(e, 'X')
+		assert.Equal(t, "Xworld", content(e))
+		assert.False(t, e.(*editor).IsSelected())
+	})
+
+	t.Run("Backspace and Delete delete the selection", func(t *testing.T) {
+		e := newTestEditor("hello world", withoutVimMode())
+		press(e, KeyEnd, ModShift)
+		press(e, KeyDelete, ModNone)
+		assert.Equal(t, "", content(e))
+
+		e = newTestEditor("one\ntwo", withoutVimMode())
+		press(e, KeyDown, ModShift)
+		backspace(e)
+		assert.Equal(t, "two", content(e))
+	})
+}
+
+// TestPlainMotions tests moving without Vim mode.
+func TestPlainMotions(t *testing.T) {
+	t.Run("Ctrl with the arrow keys jumps by word", func(t *testing.T) {
+		e := newTestEditor("one two three", withoutVimMode())
+		press(e, KeyRight, ModCtrl)
+		press(e, KeyRight, ModCtrl)
+		assert.Equal(t, Position{0, 8}, cursorPos(e))
+		press(e, KeyLeft, ModCtrl)
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+	})
+
+	t.Run("arrow keys cross lines", func(t *testing.T) {
+		e := newTestEditor("ab\ncd", withoutVimMode())
+		press(e, KeyEnd, ModNone)
+		press(e, KeyRight, ModNone)
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+		press(e, KeyLeft, ModNone)
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+	})
+
+	t.Run("Home goes to the first non-blank, then the start of the line", func(t *testing.T) {
+		e := newTestEditor("  hello", withoutVimMode())
+		press(e, KeyEnd, ModNone)
+		press(e, KeyHome, ModNone)
+		assert.Equal(t, Position{0, 2}, cursorPos(e))
+		press(e, KeyHome, ModNone)
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("Ctrl with Home and End goes to the ends of the buffer", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree", withoutVimMode())
+		press(e, KeyEnd, ModCtrl)
+		assert.Equal(t, Position{2, 5}, cursorPos(e))
+		press(e, KeyHome, ModCtrl)
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("Delete deletes forward and joins lines", func(t *testing.T) {
+		e := newTestEditor("ab\ncd", withoutVimMode())
+		press(e, KeyDelete, ModNone)
+		assert.Equal(t, "b\ncd", content(e))
+		press(e, KeyEnd, ModNone)
+		press(e, KeyDelete, ModNone)
+		assert.Equal(t, "bcd", content(e))
+	})
+}
+
+// TestPlainClipboardAndHistory tests the clipboard and undo keys without Vim
+// mode.
+func TestPlainClipboardAndHistory(t *testing.T) {
+	t.Run("Ctrl-C copies and keeps the selection", func(t *testing.T) {
+		cb := &testClipboard{}
+		e := newTestEditor("hello world", withoutVimMode(), withClipboard(cb))
+		press(e, KeyRight, ModShift|ModCtrl)
+		press(e, KeyCtrlC, ModCtrl)
+		assert.Equal(t, "hello ", cb.content)
+		assert.True(t, e.(*editor).IsSelected())
+		assert.Equal(t, "hello world", content(e))
+	})
+
+	t.Run("Ctrl-X cuts", func(t *testing.T) {
+		cb := &testClipboard{}
+		e := newTestEditor("hello world", withoutVimMode(), withClipboard(cb))
+		press(e, KeyRight, ModShift|ModCtrl)
+		press(e, KeyCtrlX, ModCtrl)
+		assert.Equal(t, "hello ", cb.content)
+		assert.Equal(t, "world", content(e))
+	})
+
+	t.Run("Ctrl-V pastes over the selection", func(t *testing.T) {
+		cb := &testClipboard{}
+		e := newTestEditor("hello world", withoutVimMode(), withClipboard(cb))
+		cb.content = "goodbye "
+		press(e, KeyRight, ModShift|ModCtrl)
+		press(e, KeyCtrlV, ModCtrl)
+		assert.Equal(t, "goodbye world", content(e))
+		assert.Equal(t, Position{0, 8}, cursorPos(e))
+	})
+
+	t.Run("Ctrl-Z and Ctrl-Y undo and redo", func(t *testing.T) {
+		e := newTestEditor("hello world", withoutVimMode())
+		press(e, KeyRight, ModShift|ModCtrl)
+		press(e, KeyDelete, ModNone)
+		assert.Equal(t, "world", content(e))
+
+		press(e, KeyCtrlZ, ModCtrl)
+		assert.Equal(t, "hello world", content(e))
+		press(e, KeyCtrlY, ModCtrl)
+		assert.Equal(t, "world", content(e))
+	})
+
+	t.Run("Vim mode keeps its keys", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("hello")
+		keys(e, 'i')
+		assert.Nil(t, press(e, KeyRight, ModShift))
+		assert.False(t, e.(*editor).IsSelected())
+	})
+}
@@ -0,0 +1,142 @@
+package core
+
+import "strings"
+
+// ProtectedRange is a read-only part of the buffer, from Start up to but not
+// including End. A range ending at column 0 of a line covers the line break
+// before it.
+type ProtectedRange struct {
+	Start, End Position
+}
+
+// ProtectRange makes the text from start up to end read-only, such as the
+// header of a template. Edits that change it are rejected with
+// ErrProtectedId, and the range moves with the text around it. Text can
+// still be typed at either end of the range.
+func (e *editor) ProtectRange(start, end Position) {
+	start, end = NormalizeSelection(e.clampPosition(start), e.clampPosition(end))
+	if start == end {
+		return
+	}
+	e.protected = append(e.protected, ProtectedRange{Start: start, End: end})
+}
+
+// ClearProtectedRanges makes the whole buffer editable again.
+func (e *editor) ClearProtectedRanges() {
+	e.protected = nil
+}
+
+// ProtectedRanges returns the read-only ranges in the order they were added.
+func (e *editor) ProtectedRanges() []ProtectedRange {
+	return append([]ProtectedRange(nil), e.protected...)
+}
+
+// IsProtected reports whether the character at pos is read-only.
+func (e *editor) IsProtected(pos Position) bool {
+	for _, r := range e.protected {
+		if !positionLess(pos, r.Start) && positionLess(pos, r.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// clampPosition keeps pos within the buffer, at most just after the end of
+// its line.
+func (e *editor) clampPosition(pos Position) Position {
+	pos.Row = max(min(pos.Row, e.buffer.LineCount()-1), 0)
+	pos.Col = max(min(pos.Col, e.buffer.LineRuneCount(pos.Row)), 0)
+	return pos
+}
+
+// positionLess reports whether a comes before b in the buffer.
+func positionLess(a, b Position) bool {
+	return a.Row < b.Row || a.Row == b.Row && a.Col < b.Col
+}
+
+// protectedText returns the buffer's lines joined by \n, which the protected
+// ranges are checked against.
+func (e *editor) protectedText() []rune {
+	return []rune(strings.Join(e.buffer.GetLines(), "\n"))
+}
+
+// moveProtected returns the protected ranges after the buffer's text changed
+// from before to after, and false if the change touched one of them.
+//
+// The change is found by trimming what both texts start and end with. When
+// it could be in more than one place, such as typing a after aa, it's taken
+// to be wherever it leaves the ranges alone.
+func (e *editor) moveProtected(before, after []rune) ([]ProtectedRange, bool) {
+	prefix := commonPrefix(before, after)
+	suffix := min(commonSuffix(before, after), len(before)-prefix, len(after)-prefix)
+	if moved, ok := e.moveProtectedAround(before, after, prefix, len(before)-suffix); ok {
+		return moved, true
+	}
+
+	suffix = commonSuffix(before, after)
+	prefix = min(prefix, len(before)-suffix, len(after)-suffix)
+	return e.moveProtectedAround(before, after, prefix, len(before)-suffix)
+}
+
+// moveProtectedAround returns the protected ranges after the text of before
+// from start up to end was replaced, leaving after, and false if that
+// changed one of them. Inserting at either end of a range doesn't.
+func (e *editor) moveProtectedAround(before, after []rune, start, end int) ([]ProtectedRange, bool) {
+	delta := len(after) - len(before)
+	moved := make([]ProtectedRange, 0, len(e.protected))
+	for _, r := range e.protected {
+		from, to := runeOffset(before, r.Start), runeOffset(before, r.End)
+		switch {
+		case start == end && from < start && start < to,
+			start < end && start < to && end > from:
+			return nil, false
+		case from >= end:
+			from, to = from+delta, to+delta
+		}
+		moved = append(moved, ProtectedRange{Start: offsetPosition(after, from), End: offsetPosition(after, to)})
+	}
+	return moved, true
+}
+
+// commonPrefix returns how many runes a and b start with in common.
+func commonPrefix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// commonSuffix returns how many runes a and b end with in common.
+func commonSuffix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// runeOffset returns the offset of pos in text, lines joined by \n.
+func runeOffset(text []rune, pos Position) int {
+	i := 0
+	for row := 0; row < pos.Row && i < len(text); i++ {
+		if text[i] == '\n' {
+			row++
+		}
+	}
+	return min(i+pos.Col, len(text))
+}
+
+// offsetPosition returns the position of offset in text, lines joined by \n.
+func offsetPosition(text []rune, offset int) Position {
+	var pos Position
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			pos.Row++
+			pos.Col = 0
+		} else {
+			pos.Col++
+		}
+	}
+	return pos
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProtectRange tests rejecting edits to read-only text and moving it with
+// the text around it.
+func TestProtectRange(t *testing.T) {
+	const template = "# Title\nbody\nfooter"
+	header := ProtectedRange{Start: Position{0, 0}, End: Position{1, 0}}
+
+	t.Run("editing protected text is rejected", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(header.Start, header.End)
+		err := e.HandleKey(KeyEvent{Rune: 'x'})
+		assert.Equal(t, template, content(e))
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrProtectedId, err.ID())
+			assert.ErrorIs(t, err.Error(), ErrProtected)
+		}
+	})
+
+	t.Run("joining onto the line break of a range is rejected", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(header.Start, header.End)
+		keys(e, 'J')
+		assert.Equal(t, template, content(e))
+		keys(e, 'A', '!')
+		assert.Equal(t, template, content(e))
+	})
+
+	t.Run("deleting across a range is rejected", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(Position{1, 2}, Position{1, 3})
+		keys(e, 'j', 'd', 'd')
+		assert.Equal(t, template, content(e))
+		assert.Equal(t, []ProtectedRange{{Position{1, 2}, Position{1, 3}}}, e.ProtectedRanges())
+	})
+
+	t.Run("text after a range is editable", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(header.Start, header.End)
+		keys(e, 'j', 'd', 'd')
+		assert.Equal(t, "# Title\nfooter", content(e))
+		assert.Equal(t, []ProtectedRange{header}, e.ProtectedRanges())
+	})
+
+	t.Run("a range moves with the text before it", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(Position{2, 0}, Position{2, 6})
+		keys(e, 'O', 'n', 'e', 'w')
+		escape(e)
+		assert.Equal(t, "new\n# Title\nbody\nfooter", content(e))
+		assert.Equal(t, []ProtectedRange{{Position{3, 0}, Position{3, 6}}}, e.ProtectedRanges())
+		keys(e, 'd', 'd')
+		assert.Equal(t, []ProtectedRange{{Position{2, 0}, Position{2, 6}}}, e.ProtectedRanges())
+		keys(e, 'u')
+		assert.Equal(t, []ProtectedRange{{Position{3, 0}, Position{3, 6}}}, e.ProtectedRanges())
+	})
+
+	t.Run("text can be typed at either end of a range", func(t *testing.T) {
+		e := newTestEditor("ab")
+		e.ProtectRange(Position{0, 0}, Position{0, 1})
+		keys(e, 'i', '<')
+		escape(e)
+		keys(e, 'l', 'i', '>')
+		assert.Equal(t, "<a>b", content(e))
+		assert.Equal(t, []ProtectedRange{{Position{0, 1}, Position{0, 2}}}, e.ProtectedRanges())
+	})
+
+	t.Run("a change that could be in either place leaves the range alone", func(t *testing.T) {
+		e := newTestEditor("aaa")
+		e.ProtectRange(Position{0, 1}, Position{0, 3})
+		err := e.HandleKey(KeyEvent{Rune: 'x'})
+		assert.Nil(t, err)
+		assert.Equal(t, "aa", content(e))
+		assert.Equal(t, []ProtectedRange{{Position{0, 0}, Position{0, 2}}}, e.ProtectedRanges())
+	})
+
+	t.Run("a range is clamped to the buffer and normalized", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(Position{9, 9}, Position{2, 1})
+		assert.Equal(t, []ProtectedRange{{Position{2, 1}, Position{2, 6}}}, e.ProtectedRanges())
+		assert.True(t, e.IsProtected(Position{2, 5}))
+		assert.False(t, e.IsProtected(Position{2, 0}))
+		assert.False(t, e.IsProtected(Position{2, 6}))
+	})
+
+	t.Run("clearing makes the buffer editable", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(header.Start, header.End)
+		e.ClearProtectedRanges()
+		keys(e, 'x')
+		assert.Equal(t, " Title\nbody\nfooter", content(e))
+	})
+
+	t.Run("new content clears the ranges", func(t *testing.T) {
+		e := newTestEditor(template)
+		e.ProtectRange(header.Start, header.End)
+		e.SetContent([]byte("other"))
+		assert.Empty(t, e.ProtectedRanges())
+	})
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"strconv"
+)
+
+// QuickfixItem is an entry of the quickfix list, such as a compiler error or a
+// linter warning reported by the host.
+type QuickfixItem struct {
+	Position Position // Where the item is in the buffer
+	Text     string   // Message shown for the item
+}
+
+// SetQuickfixList replaces the quickfix list. No item is selected until one is
+// jumped to, so :cn goes to the first item.
+func (e *editor) SetQuickfixList(items []QuickfixItem) {
+	e.state.QuickfixItems = append([]QuickfixItem(nil), items...)
+	e.state.QuickfixIndex = -1
+	e.dispatchQuickfix()
+}
+
+// JumpToQuickfix selects the item at index and moves the cursor to it.
+func (e *editor) JumpToQuickfix(index int) *EditorError {
+	items := e.state.QuickfixItems
+	if len(items) == 0 {
+		return e.localiseError(&EditorError{id: ErrNoQuickfixItemsId, err: ErrNoQuickfixItems})
+	}
+	if index < 0 || index >= len(items) {
+		return e.localiseError(&EditorError{id: ErrNoMoreQuickfixesId, err: ErrNoMoreQuickfixes})
+	}
+
+	e.state.QuickfixIndex = index
+	item := items[index]
+
+	row := min(max(item.Position.Row, 0), e.buffer.LineCount()-1)
+	col := min(max(item.Position.Col, 0), max(e.buffer.LineRuneCount(row)-1, 0))
+
+	cursor := e.buffer.GetCursor()
+	cursor.Position = Position{Row: row, Col: col}
+	cursor.Preferred = col
+	e.buffer.SetCursor(cursor)
+	e.ScrollViewport()
+
+	e.UpdateCommand(e.Message(MsgQuickfixItem, index+1, len(items), item.Text))
+	e.dispatchQuickfix()
+	return nil
+}
+
+// OpenQuickfix shows the quickfix list (:copen).
+func (e *editor) OpenQuickfix() {
+	e.state.QuickfixOpen = true
+	e.dispatchQuickfix()
+}
+
+// CloseQuickfix hides the quickfix list (:cclose).
+func (e *editor) CloseQuickfix() {
+	e.state.QuickfixOpen = false
+	e.dispatchQuickfix()
+}
+
+func (e *editor) dispatchQuickfix() {
+	e.DispatchSignal(QuickfixSignal{
+		items: e.state.QuickfixItems,
+		index: e.state.QuickfixIndex,
+		open:  e.state.QuickfixOpen,
+	})
+}
+
+// executeQuickfixCommand runs the quickfix commands:
+//
+//	:cn[ext] [count]      jump count items forward
+//	:cp[revious] [count]  jump count items back (also :cN[ext])
+//	:cc [n]               jump to item n, or the current item
+//	:cfir[st], :cr[ewind] jump to the first item
+//	:cla[st]              jump to the last item
+//	:cope[n], :ccl[ose]   show or hide the list
+//
+// Returns handled=false if command is not one of them.
+func (e *editor) executeQuickfixCommand(command string, args []string) (bool, *EditorError) {
+	index := e.state.QuickfixIndex
+
+	switch command {
+	case "cn", "cne", "cnext":
+		count, err := quickfixCount(args)
+		if err != nil {
+			return true, err
+		}
+		return true, e.JumpToQuickfix(index + count)
+	case "cp", "cpr", "cprevious", "cN", "cNext":
+		count, err := quickfixCount(args)
+		if err != nil {
+			return true, err
+		}
+		return true, e.JumpToQuickfix(index - count)
+	case "cc":
+		if len(args) == 0 {
+			return true, e.JumpToQuickfix(max(index, 0))
+		}
+		n, err := quickfixCount(args)
+		if err != nil {
+			return true, err
+		}
+		return true, e.JumpToQuickfix(n - 1)
+	case "cfir", "cfirst", "cr", "crewind":
+		return true, e.JumpToQuickfix(0)
+	case "cla", "clast":
+		return true, e.JumpToQuickfix(len(e.state.QuickfixItems) - 1)
+	case "cope", "copen":
+		e.OpenQuickfix()
+		return true, nil
+	case "ccl", "cclose":
+		e.CloseQuickfix()
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// quickfixCount parses the optional count argument of a quickfix command.
+func quickfixCount(args []string) (int, *EditorError) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+	}
+	return n, nil
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const quickfixSource = "package main\n\nfunc main() {\n\tx := 1\n}"
+
+var quickfixItems = []QuickfixItem{
+	{Position: Position{0, 8}, Text: "first"},
+	{Position: Position{2, 5}, Text: "second"},
+	{Position: Position{3, 1}, Text: "third"},
+}
+
+// TestQuickfixNavigation tests :cn, :cp, :cc, :cfirst and :clast.
+func TestQuickfixNavigation(t *testing.T) {
+	t.Run(":cn starts at the first item", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		assert.Nil(t, e.ExecuteCommand("cn"))
+		assert.Equal(t, Position{0, 8}, cursorPos(e))
+		assert.Equal(t, 0, e.GetState().QuickfixIndex)
+		assert.Equal(t, "(1 of 3): first", e.GetState().CommandLine)
+	})
+
+	t.Run(":cn and :cp move through the list", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		assert.Nil(t, e.ExecuteCommand("cn"))
+		assert.Nil(t, e.ExecuteCommand("cnext"))
+		assert.Equal(t, Position{2, 5}, cursorPos(e))
+		assert.Nil(t, e.ExecuteCommand("cp"))
+		assert.Equal(t, Position{0, 8}, cursorPos(e))
+	})
+
+	t.Run("a count skips items", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		assert.Nil(t, e.ExecuteCommand("cn 3"))
+		assert.Equal(t, Position{3, 1}, cursorPos(e))
+		assert.Nil(t, e.ExecuteCommand("cN 2"))
+		assert.Equal(t, Position{0, 8}, cursorPos(e))
+	})
+
+	t.Run("past either end is an error and keeps the cursor", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		assert.Nil(t, e.ExecuteCommand("clast"))
+		err := e.ExecuteCommand("cn")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrNoMoreQuickfixesId, err.ID())
+		assert.Equal(t, Position{3, 1}, cursorPos(e))
+
+		assert.Nil(t, e.ExecuteCommand("cfirst"))
+		err = e.ExecuteCommand("cp")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrNoMoreQuickfixesId, err.ID())
+	})
+
+	t.Run(":cc jumps to an item by number", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		assert.Nil(t, e.ExecuteCommand("cc 2"))
+		assert.Equal(t, Position{2, 5}, cursorPos(e))
+		assert.Nil(t, e.ExecuteCommand("cc"))
+		assert.Equal(t, Position{2, 5}, cursorPos(e))
+	})
+
+	t.Run("an empty list is an error", func(t *testing.T) {
+		e := newTestEditor("hello")
+		err := e.ExecuteCommand("cn")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrNoQuickfixItemsId, err.ID())
+	})
+
+	t.Run("an invalid count is an error", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		err := e.ExecuteCommand("cn x")
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrInvalidCommandId, err.ID())
+	})
+
+	t.Run("positions outside the buffer are clamped", func(t *testing.T) {
+		e := newTestEditor("ab\ncd")
+		e.SetQuickfixList([]QuickfixItem{{Position: Position{9, 9}, Text: "stale"}})
+		assert.Nil(t, e.JumpToQuickfix(0))
+		assert.Equal(t, Position{1, 1}, cursorPos(e))
+	})
+
+	t.Run("setting a new list clears the selection", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		assert.Nil(t, e.ExecuteCommand("clast"))
+		e.SetQuickfixList([]QuickfixItem{{Position: Position{1, 0}, Text: "new"}})
+		assert.Equal(t, -1, e.GetState().QuickfixIndex)
+		assert.Nil(t, e.ExecuteCommand("cn"))
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+	})
+
+	t.Run("typed in command mode", func(t *testing.T) {
+		e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+		keys(e, ':', 'c', 'n')
+		enter(e)
+		assert.Equal(t, Position{0, 8}, cursorPos(e))
+	})
+}
+
+// TestQuickfixOpenClose tests :copen and :cclose and the signal they dispatch.
+func TestQuickfixOpenClose(t *testing.T) {
+	e := newTestEditor(quickfixSource, withQuickfix(quickfixItems...))
+	drainSignals(e)
+
+	assert.Nil(t, e.ExecuteCommand("copen"))
+	assert.True(t, e.GetState().QuickfixOpen)
+
+	sig, ok := nextSignal(e).(QuickfixSignal)
+	assert.True(t, ok)
+	assert.True(t, sig.Open())
+	assert.Len(t, sig.Value(), 3)
+	assert.Equal(t, -1, sig.Index())
+
+	assert.Nil(t, e.ExecuteCommand("ccl"))
+	assert.False(t, e.GetState().QuickfixOpen)
+}
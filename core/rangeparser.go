@@ -0,0 +1,234 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineRange is a range of whole lines, 0-based and inclusive, given before a
+// command such as :2,5w.
+type lineRange struct {
+	start, end int
+}
+
+// check returns an error if r is not within buffer.
+func (r lineRange) check(buffer Buffer) *EditorError {
+	if r.start < 0 || r.end >= buffer.LineCount() {
+		return &EditorError{id: ErrInvalidRangeId, err: ErrInvalidRange}
+	}
+	return nil
+}
+
+// rangeParser reads the range of lines before an ex command, as Vim does. A
+// range is % for the whole buffer, or addresses separated by , or ;, of
+// which the last two are used. An address is one of
+//
+//	N        line N
+//	.        the cursor line
+//	$        the last line
+//	'x       the line of mark x, including '< and '> for the last selection
+//	/pat/    the next line matching pat, wrapping around the end
+//	?pat?    the previous line matching pat, wrapping around the start
+//
+// followed by any number of +N and -N, where a lone + or - counts one and a
+// number after an address adds to it. An address missing before an offset
+// or around a separator is the cursor line, so .,+5 and ,+5 are the same.
+// After ; the addresses that follow count from the line before it instead
+// of the cursor line, as in /begin/;/end/.
+type rangeParser struct {
+	buffer  Buffer
+	mark    func(name rune) (Position, bool) // Position of a mark, for 'x
+	pattern string                           // Last search pattern, used by an empty // or ??
+	options SearchOptions                    // Case options patterns are matched with
+}
+
+// rangeParser returns a parser for the ranges of the editor's commands.
+func (e *editor) rangeParser() rangeParser {
+	return rangeParser{
+		buffer:  e.buffer,
+		mark:    e.Mark,
+		pattern: e.state.SearchQuery.Term,
+		options: e.state.SearchOptions,
+	}
+}
+
+// parse splits the range off the front of cmd. A backwards range is
+// swapped, as Vim does when asked, but the range isn't checked against the
+// buffer, which check does. err is set when a range was found but is
+// malformed, or refers to a mark that isn't set or a pattern not found.
+func (p rangeParser) parse(cmd string) (r lineRange, rest string, found bool, err *EditorError) {
+	if rest, found := strings.CutPrefix(cmd, "%"); found {
+		return lineRange{0, p.buffer.LineCount() - 1}, rest, true, nil
+	}
+
+	current := p.buffer.GetCursor().Position.Row
+	var rows []int
+	rest = cmd
+	for {
+		row, after, found, err := p.address(rest, current)
+		if err != nil {
+			return lineRange{}, after, true, err
+		}
+		if !found {
+			if len(rows) == 0 && !strings.HasPrefix(rest, ",") && !strings.HasPrefix(rest, ";") {
+				return lineRange{}, cmd, false, nil
+			}
+			row = current
+		}
+		rows = append(rows, row)
+		rest = after
+
+		if after, ok := strings.CutPrefix(rest, ";"); ok {
+			current, rest = row, after
+			continue
+		}
+		if after, ok := strings.CutPrefix(rest, ","); ok {
+			rest = after
+			continue
+		}
+		break
+	}
+
+	r = lineRange{rows[len(rows)-1], rows[len(rows)-1]}
+	if len(rows) > 1 {
+		r.start = rows[len(rows)-2]
+	}
+	if r.start > r.end {
+		r.start, r.end = r.end, r.start
+	}
+	return r, rest, true, nil
+}
+
+// address reads an address from the front of s and returns its row, which
+// may be outside the buffer. current is the line . refers to and patterns
+// are searched from.
+func (p rangeParser) address(s string, current int) (row int, rest string, found bool, err *EditorError) {
+	rest = s
+	switch {
+	case strings.HasPrefix(s, "."):
+		row, rest, found = current, s[1:], true
+	case strings.HasPrefix(s, "$"):
+		row, rest, found = p.buffer.LineCount()-1, s[1:], true
+	case strings.HasPrefix(s, "'"):
+		name, size := utf8.DecodeRuneInString(s[1:])
+		pos, ok := p.mark(name)
+		if size == 0 || !ok {
+			return 0, s, true, &EditorError{id: ErrMarkNotSetId, err: ErrMarkNotSet}
+		}
+		row, rest, found = pos.Row, s[1+size:], true
+	case strings.HasPrefix(s, "/"), strings.HasPrefix(s, "?"):
+		pattern, after := cutPattern(s[1:], s[0])
+		if row, found = p.search(pattern, current, s[0] == '?'); !found {
+			return 0, after, true, &EditorError{id: ErrPatternNotFoundId, err: ErrPatternNotFound}
+		}
+		rest = after
+	default:
+		if n, after, ok := cutNumber(s); ok {
+			row, rest, found = n-1, after, true
+		}
+	}
+
+	// Offsets, which count from the current line when there's no address
+	for rest != "" {
+		sign := 1
+		switch rest[0] {
+		case '-':
+			sign = -1
+			fallthrough
+		case '+':
+			n, after, ok := cutNumber(rest[1:])
+			if !ok {
+				n = 1
+			}
+			if !found {
+				row, found = current, true
+			}
+			row += sign * n
+			rest = after
+			continue
+		}
+
+		n, after, ok := cutNumber(rest)
+		if !ok || !found {
+			break
+		}
+		row += n
+		rest = after
+	}
+	return row, rest, found, nil
+}
+
+// search returns the first line after current that matches pattern, or
+// with backwards the first line before it, wrapping around the buffer and
+// trying current last. An empty pattern is the last search pattern.
+func (p rangeParser) search(pattern string, current int, backwards bool) (int, bool) {
+	if pattern == "" {
+		pattern = p.pattern
+	}
+	options := p.options
+	options.Backwards = backwards
+	options.Wrap = true
+
+	start := Position{Row: current}
+	if !backwards {
+		start.Col = p.buffer.LineRuneCount(current)
+	}
+	pos, found := p.buffer.Find(pattern, start, options)
+	return pos.Row, found
+}
+
+// cutPattern splits a pattern ending with delimiter off the front of s,
+// where \ before delimiter makes it part of the pattern. The delimiter may
+// be left out at the end of s.
+func cutPattern(s string, delimiter byte) (pattern, rest string) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delimiter:
+			b.WriteByte(delimiter)
+			i++
+		case s[i] == delimiter:
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), ""
+}
+
+// cutNumber splits a decimal number off the front of s.
+func cutNumber(s string) (n int, rest string, found bool) {
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	n, err := strconv.Atoi(s[:digits])
+	if err != nil {
+		return 0, s, false
+	}
+	return n, s[digits:], true
+}
+
+// withCount applies a count given as the first of args to r, as in :d 3 or
+// :3y 2: the range becomes that many lines from its last one, stopping at
+// the end of the buffer. It returns the arguments after the count.
+func withCount(buffer Buffer, r lineRange, args []string) (lineRange, []string, *EditorError) {
+	if len(args) == 0 {
+		return r, args, nil
+	}
+	n, rest, ok := cutNumber(args[0])
+	if !ok || rest != "" {
+		return r, args, nil
+	}
+	if n <= 0 {
+		return r, args, &EditorError{id: ErrInvalidRangeId, err: ErrInvalidRange}
+	}
+	return lineRange{r.end, min(r.end+n-1, buffer.LineCount()-1)}, args[1:], nil
+}
+
+// takesRange reports whether command acts on a range of lines.
+func takesRange(command string) bool {
+	switch command {
+	case "d", "delete", "del", "y", "yank":
+		return true
+	}
+	return isWriteCommand(command)
+}
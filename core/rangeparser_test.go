@@ -0,0 +1,235 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRangeParser tests reading the ranges of ex commands.
+func TestRangeParser(t *testing.T) {
+	lines := "zero\nbegin\none\ntwo\nend\nthree\nbegin\nfour\nend\nfive"
+
+	// The cursor is on line 4 (row 3), with mark a on row 1 and b on row 7
+	newParser := func() (modeContext, rangeParser) {
+		e := newTestEditor(lines)
+		e.SetMark('a', Position{Row: 1})
+		e.SetMark('b', Position{Row: 7, Col: 2})
+		keys(e, '3', 'j')
+		return e, e.(*editor).rangeParser()
+	}
+
+	tests := []struct {
+		cmd        string
+		start, end int
+		rest       string
+	}{
+		{"%d", 0, 9, "d"},
+		{"2,5w x", 1, 4, "w x"},
+		{".", 3, 3, ""},
+		{"$", 9, 9, ""},
+		{".,$d", 3, 9, "d"},
+		{".,+5d", 3, 8, "d"},
+		{",+2y", 3, 5, "y"},
+		{"+,++y", 4, 5, "y"},
+		{"-3,.d", 0, 3, "d"},
+		{"-d", 2, 2, "d"},
+		{".5d", 8, 8, "d"},
+		{"$-2,$d", 7, 9, "d"},
+		{"2+3-1d", 3, 3, "d"},
+		{"5,2y", 1, 4, "y"},
+		{"'a,'bd", 1, 7, "d"},
+		{"'a+1,'b-1d", 2, 6, "d"},
+		{"/begin/d", 6, 6, "d"},
+		{"?begin?d", 1, 1, "d"},
+		{"/begin/,/end/d", 6, 4, "d"},
+		{"/begin/;/end/d", 6, 8, "d"},
+		{"/end/+1", 5, 5, ""},
+		{"/end", 4, 4, ""},
+		{"1,2,3,4d", 2, 3, "d"},
+		{"5;+1y", 4, 5, "y"},
+		{"3,d", 2, 3, "d"},
+	}
+	for _, tt := range tests {
+		_, p := newParser()
+		r, rest, found, err := p.parse(tt.cmd)
+		assert.True(t, found, tt.cmd)
+		assert.Nil(t, err, tt.cmd)
+		assert.Equal(t, lineRange{min(tt.start, tt.end), max(tt.start, tt.end)}, r, tt.cmd)
+		assert.Equal(t, tt.rest, rest, tt.cmd)
+	}
+
+	t.Run("commands without a range", func(t *testing.T) {
+		_, p := newParser()
+		for _, cmd := range []string{"w", "set rnu", "TableAlign", ""} {
+			_, rest, found, err := p.parse(cmd)
+			assert.False(t, found, cmd)
+			assert.Nil(t, err, cmd)
+			assert.Equal(t, cmd, rest, cmd)
+		}
+	})
+
+	t.Run("\\/ is part of the pattern", func(t *testing.T) {
+		e := newTestEditor("a\nx/y\nb")
+		r, rest, _, err := e.(*editor).rangeParser().parse(`/x\/y/d`)
+		assert.Nil(t, err)
+		assert.Equal(t, lineRange{1, 1}, r)
+		assert.Equal(t, "d", rest)
+	})
+
+	t.Run("an empty pattern is the last search", func(t *testing.T) {
+		e, _ := newParser()
+		e.ExecuteSearch("four", SearchOptions{})
+		keys(e, 'g', 'g')
+		r, _, _, err := e.(*editor).rangeParser().parse("//d")
+		assert.Nil(t, err)
+		assert.Equal(t, lineRange{7, 7}, r)
+	})
+
+	t.Run("the last selection is '< and '>", func(t *testing.T) {
+		e := newTestEditor(lines)
+		keys(e, 'j', 'V', 'j', 'j')
+		escape(e)
+		r, _, _, err := e.(*editor).rangeParser().parse("'<,'>d")
+		assert.Nil(t, err)
+		assert.Equal(t, lineRange{1, 3}, r)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, p := newParser()
+		_, _, found, err := p.parse("'c,'bd")
+		assert.True(t, found)
+		assert.Equal(t, ErrMarkNotSetId, err.ID())
+		_, _, _, err = p.parse("'<d")
+		assert.Equal(t, ErrMarkNotSetId, err.ID())
+		_, _, _, err = p.parse("/missing/d")
+		assert.Equal(t, ErrPatternNotFoundId, err.ID())
+
+		r, _, _, err := p.parse("1,20d")
+		assert.Nil(t, err)
+		assert.Equal(t, ErrInvalidRangeId, r.check(p.buffer).ID())
+	})
+}
+
+// TestLineCommands tests :d and :y, with ranges and counts.
+func TestLineCommands(t *testing.T) {
+	t.Run(":d deletes the cursor line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		keys(e, 'j')
+		assert.Nil(t, e.ExecuteCommand("d"))
+		assert.Equal(t, "one\nthree", content(e))
+	})
+
+	t.Run("ranges and counts", func(t *testing.T) {
+		tests := []struct{ cmd, want string }{
+			{"2,3d", "1\n4\n5\n6"},
+			{"%d", ""},
+			{"d 2", "1\n4\n5\n6"},
+			{"d2", "1\n4\n5\n6"},
+			{"3d 2", "1\n2\n5\n6"},
+			{"2,3d 2", "1\n2\n5\n6"},
+			{"5d 10", "1\n2\n3\n4"},
+			{"/4/,$d", "1\n2\n3"},
+			{".;+1d", "1\n4\n5\n6"},
+		}
+		for _, tt := range tests {
+			e := newTestEditor("1\n2\n3\n4\n5\n6")
+			keys(e, 'j')
+			assert.Nil(t, e.ExecuteCommand(tt.cmd), tt.cmd)
+			assert.Equal(t, tt.want, content(e), tt.cmd)
+		}
+	})
+
+	t.Run(":d dispatches a linewise DeleteSignal", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		drainSignals(e)
+		assert.Nil(t, e.ExecuteCommand("1,2d"))
+		signal, ok := nextSignal(e).(DeleteSignal)
+		assert.True(t, ok)
+		assert.Equal(t, "one\ntwo\n", signal.Value())
+		assert.True(t, signal.Linewise())
+	})
+
+	t.Run(":y copies the lines", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("one\ntwo\nthree")
+		assert.Nil(t, e.ExecuteCommand("2,$y"))
+		assert.Equal(t, "two\nthree\n", cb.content)
+		assert.Nil(t, e.ExecuteCommand("y 1"))
+		assert.Equal(t, "two\n", cb.content)
+		assert.Equal(t, "one\ntwo\nthree", content(e))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		assert.Equal(t, ErrInvalidRangeId, e.ExecuteCommand("1,3d").ID())
+		assert.Equal(t, ErrInvalidRangeId, e.ExecuteCommand("d 0").ID())
+		assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("d x").ID())
+		assert.Equal(t, "one\ntwo", content(e))
+	})
+
+	t.Run("a range on its own jumps to its last line", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		assert.Nil(t, e.ExecuteCommand("/three/"))
+		assert.Equal(t, Position{2, 0}, cursorPos(e))
+		assert.Nil(t, e.ExecuteCommand("-"))
+		assert.Equal(t, Position{1, 0}, cursorPos(e))
+		assert.Nil(t, e.ExecuteCommand("0"))
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("a count before : types a range", func(t *testing.T) {
+		e := newTestEditor("1\n2\n3\n4")
+		keys(e, '3', ':')
+		assert.Equal(t, ":.,.+2", e.GetState().CommandLine)
+		keys(e, 'd')
+		enter(e)
+		assert.Equal(t, "4", content(e))
+
+		keys(e, '1', ':')
+		assert.Equal(t, ":.", e.GetState().CommandLine)
+	})
+
+	t.Run(": in visual mode types the selection", func(t *testing.T) {
+		e := newTestEditor("1\n2\n3\n4")
+		keys(e, 'j', 'v', 'j', ':')
+		assert.True(t, e.IsCommandMode())
+		assert.Equal(t, ":'<,'>", e.GetState().CommandLine)
+		keys(e, 'd')
+		enter(e)
+		assert.Equal(t, "1\n4", content(e))
+	})
+}
+
+// TestMarks tests setting marks with m and jumping to them with ' and `.
+func TestMarks(t *testing.T) {
+	e := newTestEditor("one\n  two three\nfour")
+	keys(e, 'j', 'w', 'w', 'm', 'a', 'G')
+	pos, ok := e.Mark('a')
+	assert.True(t, ok)
+	assert.Equal(t, Position{1, 6}, pos)
+
+	keys(e, '`', 'a')
+	assert.Equal(t, Position{1, 6}, cursorPos(e))
+	keys(e, 'g', 'g', '\'', 'a')
+	assert.Equal(t, Position{1, 2}, cursorPos(e))
+
+	keys(e, '\'')
+	assert.Equal(t, ErrMarkNotSetId, e.HandleKey(KeyEvent{Rune: 'z'}).ID())
+	assert.Equal(t, Position{1, 2}, cursorPos(e))
+
+	t.Run("marks are moved into shorter content", func(t *testing.T) {
+		e.SetContent([]byte("one"))
+		pos, ok := e.Mark('a')
+		assert.True(t, ok)
+		assert.Equal(t, Position{0, 3}, pos)
+	})
+
+	t.Run("only a to z can be set", func(t *testing.T) {
+		e.SetMark('<', Position{})
+		e.SetMark('A', Position{})
+		_, ok := e.Mark('A')
+		assert.False(t, ok)
+		_, ok = e.Mark('<')
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,321 @@
+package core
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// defaultTextWidth is the width gq reflows text to when the text width is 0,
+// as in Vim.
+const defaultTextWidth = 79
+
+// listBullet matches the bullet that starts a list item, with the
+// whitespace after it: -, * or +, or a number followed by . or ).
+var listBullet = regexp.MustCompile(`^(?:[-*+]|[0-9]+[.)])[ \t]+`)
+
+// defaultCommentLeaders are the line comment leaders of languages that have
+// them unless SetCommentLeaders changes them.
+var defaultCommentLeaders = map[string][]string{
+	"go": {"//"}, "c": {"//"}, "cpp": {"//"}, "c++": {"//"}, "java": {"//"},
+	"javascript": {"//"}, "typescript": {"//"}, "tsx": {"//"}, "jsx": {"//"},
+	"rust": {"//"}, "swift": {"//"}, "kotlin": {"//"}, "csharp": {"//"},
+	"scala": {"//"}, "dart": {"//"}, "zig": {"//"}, "php": {"//", "#"},
+	"python": {"#"}, "ruby": {"#"}, "bash": {"#"}, "sh": {"#"}, "zsh": {"#"},
+	"shell": {"#"}, "fish": {"#"}, "yaml": {"#"}, "toml": {"#"}, "perl": {"#"},
+	"r": {"#"}, "make": {"#"}, "makefile": {"#"}, "docker": {"#"}, "dockerfile": {"#"},
+	"elixir": {"#"}, "nix": {"#"}, "ini": {";", "#"},
+	"sql": {"--"}, "mysql": {"--"}, "postgresql": {"--"}, "postgres": {"--"},
+	"plpgsql": {"--"}, "tsql": {"--"}, "lua": {"--"}, "haskell": {"--"},
+	"lisp": {";"}, "clojure": {";"}, "scheme": {";"}, "vim": {"\""},
+	"markdown": {">"}, "md": {">"}, "gitcommit": {"#"},
+}
+
+// SetTextWidth sets the width gq reflows text to, in columns, as
+// :set textwidth does. 0 is 79 columns. Typing past a width other than 0
+// breaks the line, for the lines SetAutoWrap picks.
+func (e *editor) SetTextWidth(width int) {
+	e.state.TextWidth = max(width, 0)
+}
+
+// SetCommentLeaders sets what starts the line comments of language, such as
+// // for Go, which gq keeps at the start of each line it reflows. No leaders
+// turns this off for the language. Common languages have theirs by default,
+// and markdown keeps the > of quotes.
+func (e *editor) SetCommentLeaders(language string, leaders ...string) {
+	if e.commentLeaders == nil {
+		e.commentLeaders = make(map[string][]string)
+	}
+	e.commentLeaders[strings.ToLower(language)] = leaders
+}
+
+// lineCommentLeaders returns the comment leaders of the content's language,
+// none if it has none.
+func (e *editor) lineCommentLeaders() []string {
+	if leaders, ok := e.commentLeaders[e.language]; ok {
+		return leaders
+	}
+	if leader := e.languageConfigs[e.language].CommentString; leader != "" {
+		return []string{leader}
+	}
+	return defaultCommentLeaders[e.language]
+}
+
+// reflowLine is a line split up for gq.
+type reflowLine struct {
+	lead   string // Indent and comment leader, with the whitespace after it
+	bullet string // Bullet of the list item the line starts, with the whitespace after it
+	text   string // The rest, without trailing whitespace
+}
+
+// leader returns the comment leader of l, without its indent or the
+// whitespace after it; lines with different leaders are different
+// paragraphs.
+func (l reflowLine) leader() string {
+	return strings.TrimSpace(l.lead)
+}
+
+// splitReflowLine splits line into its indent and comment leader, the bullet
+// of a list item, and its text.
+func splitReflowLine(line string, leaders []string) reflowLine {
+	rest := strings.TrimLeft(line, " \t")
+	for _, leader := range leaders {
+		if leader != "" && strings.HasPrefix(rest, leader) {
+			rest = strings.TrimLeft(rest[len(leader):], " \t")
+			break
+		}
+	}
+	l := reflowLine{lead: line[:len(line)-len(rest)]}
+
+	if bullet := listBullet.FindString(rest); bullet != "" {
+		l.bullet, rest = bullet, rest[len(bullet):]
+	}
+	l.text = strings.TrimRight(rest, " \t")
+	return l
+}
+
+// reflowLines reflows lines to width columns, as gq does. Blank lines, and
+// lines with nothing after a comment leader, separate paragraphs and are
+// kept. So does a change of comment leader, and each list item is a
+// paragraph of its own. Each paragraph's words are filled into lines as long
+// as fit, the first starting with the paragraph's indent, leader and bullet,
+// the rest lined up under its text.
+func reflowLines(lines []string, width, tabs int, leaders []string) []string {
+	var reflowed []string
+	var paragraph []reflowLine
+
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		reflowed = append(reflowed, fillParagraph(paragraph, width, tabs)...)
+		paragraph = nil
+	}
+
+	for _, line := range lines {
+		l := splitReflowLine(line, leaders)
+		switch {
+		case l.text == "" && l.bullet == "":
+			flush()
+			reflowed = append(reflowed, line)
+			continue
+		case len(paragraph) > 0 && (l.bullet != "" || l.leader() != paragraph[0].leader()):
+			flush()
+		}
+		paragraph = append(paragraph, l)
+	}
+	flush()
+	return reflowed
+}
+
+// fillParagraph fills the words of paragraph into lines of width columns,
+// tabs going to the next multiple of tabs. A word longer than that has a line
+// of its own.
+func fillParagraph(paragraph []reflowLine, width, tabs int) []string {
+	first := paragraph[0]
+	prefix := first.lead + first.bullet
+	rest := first.lead + strings.Repeat(" ", columns(prefix, tabs)-columns(first.lead, tabs))
+	if first.bullet == "" && len(paragraph) > 1 {
+		// A hanging indent is kept, as the second line sets it
+		rest = paragraph[1].lead
+	}
+
+	var words []string
+	for _, l := range paragraph {
+		words = append(words, strings.Fields(l.text)...)
+	}
+
+	var lines []string
+	line := prefix
+	empty := true
+	for _, word := range words {
+		if !empty && columns(line, tabs)+1+uniseg.StringWidth(word) > width {
+			lines = append(lines, line)
+			line, empty = rest, true
+		}
+		if !empty {
+			line += " "
+		}
+		line += word
+		empty = false
+	}
+	return append(lines, line)
+}
+
+// reflowRows reflows the rows from startRow to endRow to the text width, as
+// one change, and leaves the cursor on the first non-blank of the last line
+// reflowed.
+func reflowRows(editor modeContext, buffer Buffer, startRow, endRow int) *EditorError {
+	state := editor.GetState()
+	width := state.TextWidth
+	if width == 0 {
+		width = defaultTextWidth
+	}
+
+	lines := buffer.GetLines()[startRow : endRow+1]
+	reflowed := reflowLines(lines, width, tabWidth(state), editor.lineCommentLeaders())
+	changed := !slices.Equal(lines, reflowed)
+
+	if changed {
+		end := Position{Row: endRow, Col: buffer.LineRuneCount(endRow)}
+		if err := buffer.ReplaceRange(Position{Row: startRow}, end, strings.Join(reflowed, "\n")); err != nil {
+			return &EditorError{id: ErrInvalidPositionId, err: err}
+		}
+	}
+
+	cursor := buffer.GetCursor()
+	cursor.Position = Position{Row: startRow + len(reflowed) - 1}
+	buffer.SetCursor(cursor)
+	cursor = buffer.GetCursor()
+	cursor.MoveToFirstNonBlank(buffer, state.AvailableWidth)
+	buffer.SetCursor(cursor)
+
+	if changed {
+		editor.SaveHistory()
+	}
+	return nil
+}
+
+// AutoWrap sets which lines insert mode breaks once typing takes them past
+// the text width, like the t and c flags of Vim's 'formatoptions'. Nothing
+// is broken while the text width is 0.
+type AutoWrap int
+
+const (
+	// AutoWrapText breaks every line, as formatoptions=tc does. It's the
+	// default.
+	AutoWrapText AutoWrap = iota
+	// AutoWrapComments breaks only comments, as formatoptions=c does, which
+	// needs a language with comment leaders.
+	AutoWrapComments
+	// AutoWrapOff never breaks lines while typing.
+	AutoWrapOff
+)
+
+// formatOptions returns the formatoptions letters of w.
+func (w AutoWrap) formatOptions() string {
+	switch w {
+	case AutoWrapText:
+		return "tc"
+	case AutoWrapComments:
+		return "c"
+	}
+	return ""
+}
+
+// parseFormatOptions returns what the formatoptions letters in options
+// wrap: everything with t, comments with c alone. Other letters are
+// accepted and have no effect.
+func parseFormatOptions(options string) AutoWrap {
+	switch {
+	case strings.ContainsRune(options, 't'):
+		return AutoWrapText
+	case strings.ContainsRune(options, 'c'):
+		return AutoWrapComments
+	}
+	return AutoWrapOff
+}
+
+// SetAutoWrap sets which lines insert mode breaks at the text width.
+func (e *editor) SetAutoWrap(wrap AutoWrap) {
+	e.state.AutoWrap = wrap
+}
+
+// setFormatOptions sets formatoptions as :set fo=tc, fo+=t and fo-=t do.
+func (e *editor) setFormatOptions(op, letters string) {
+	options := e.state.AutoWrap.formatOptions()
+	switch op {
+	case "=":
+		options = letters
+	case "+=":
+		options += letters
+	case "-=":
+		options = strings.ReplaceAll(options, letters, "")
+	}
+	e.SetAutoWrap(parseFormatOptions(options))
+}
+
+// isBlank reports whether r is a space or a tab, where lines are broken.
+func isBlank(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// wrapAtTextWidth breaks the cursor's line at the last blanks before the
+// text width once typing a non-blank has taken the cursor past it. A first
+// word longer than the width is broken after. The new line starts with the
+// indent and comment leader of the broken one, lined up under the text of a
+// list item, and the cursor stays after what was typed.
+func wrapAtTextWidth(editor modeContext, buffer Buffer) error {
+	state := editor.GetState()
+	if state.TextWidth == 0 || state.AutoWrap == AutoWrapOff {
+		return nil
+	}
+
+	cursor := buffer.GetCursor()
+	row, col := cursor.Position.Row, cursor.Position.Col
+	runes := buffer.GetLineRunes(row)
+	tabs := tabWidth(state)
+	if col == 0 || col > len(runes) || isBlank(runes[col-1]) || columns(string(runes[:col]), tabs) <= state.TextWidth {
+		return nil
+	}
+
+	l := splitReflowLine(string(runes), editor.lineCommentLeaders())
+	if state.AutoWrap == AutoWrapComments && l.leader() == "" {
+		return nil
+	}
+
+	// The last blanks the text before fits in the width, or else the first
+	start, end := -1, -1
+	for i := utf8.RuneCountInString(l.lead + l.bullet); i < col; {
+		if !isBlank(runes[i]) {
+			i++
+			continue
+		}
+		blanks := i
+		for i < col && isBlank(runes[i]) {
+			i++
+		}
+		if start >= 0 && columns(string(runes[:blanks]), tabs) > state.TextWidth {
+			break
+		}
+		start, end = blanks, i
+	}
+	if start < 0 {
+		return nil
+	}
+
+	indent := l.lead + strings.Repeat(" ", columns(l.lead+l.bullet, tabs)-columns(l.lead, tabs))
+	if err := buffer.ReplaceRange(Position{Row: row, Col: start}, Position{Row: row, Col: end}, "\n"+indent); err != nil {
+		return err
+	}
+
+	cursor.Position = Position{Row: row + 1, Col: col - end + utf8.RuneCountInString(indent)}
+	if state.AvailableWidth > 0 {
+		cursor.Preferred = cursor.Position.Col % state.AvailableWidth
+	}
+	buffer.SetCursor(cursor)
+	return nil
+}
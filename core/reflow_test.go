@@ -0,0 +1,170 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReflow tests reflowing text with gq.
+func TestReflow(t *testing.T) {
+	t.Run("gqq fills the line's words to the text width", func(t *testing.T) {
+		e := newTestEditor("one two three four five six", withTextWidth(10))
+		keys(e, 'g', 'q', 'q')
+		assert.Equal(t, "one two\nthree four\nfive six", content(e))
+		assert.Equal(t, Position{Row: 2, Col: 0}, cursorPos(e))
+	})
+
+	t.Run("gqap joins short lines and keeps paragraphs apart", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree\n\nfour\nfive", withTextWidth(20))
+		keys(e, 'g', 'q', 'a', 'p')
+		assert.Equal(t, "one two three\n\nfour\nfive", content(e))
+	})
+
+	t.Run("gq with a motion reflows each paragraph it covers", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\n\nthree\nfour", withTextWidth(20))
+		keys(e, 'g', 'q', 'G')
+		assert.Equal(t, "one two\n\nthree four", content(e))
+	})
+
+	t.Run(":set textwidth sets the width", func(t *testing.T) {
+		e := newTestEditor("aaa bbb ccc")
+		assert.Nil(t, e.ExecuteCommand("set tw=8"))
+		assert.Equal(t, 8, e.GetState().TextWidth)
+		keys(e, 'g', 'q', 'q')
+		assert.Equal(t, "aaa bbb\nccc", content(e))
+
+		assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("set textwidth=x").ID())
+		assert.Equal(t, 8, e.GetState().TextWidth)
+	})
+
+	t.Run("the width is 79 by default", func(t *testing.T) {
+		e := newTestEditor("a\nb")
+		keys(e, 'g', 'q', 'j')
+		assert.Equal(t, "a b", content(e))
+	})
+
+	t.Run("list items keep their bullets and line up under their text", func(t *testing.T) {
+		e := newTestEditor("- one two three\n- four\n1. five six seven", withTextWidth(12))
+		keys(e, 'g', 'q', 'G')
+		assert.Equal(t, "- one two\n  three\n- four\n1. five six\n   seven", content(e))
+	})
+
+	t.Run("indent is kept, with a hanging indent from the second line", func(t *testing.T) {
+		e := newTestEditor("  one two\n    three four five", withTextWidth(14))
+		keys(e, 'g', 'q', 'j')
+		assert.Equal(t, "  one two\n    three four\n    five", content(e))
+	})
+
+	t.Run("comment leaders are kept when a language is set", func(t *testing.T) {
+		e := newTestEditor("// one two three\n// four\n\tx := 1", withTextWidth(12), withLanguage("go"))
+		keys(e, 'g', 'q', 'j')
+		assert.Equal(t, "// one two\n// three\n// four\n\tx := 1", content(e))
+
+		e = newTestEditor("# one two\ncode", withTextWidth(40), withLanguage("python"))
+		keys(e, 'g', 'q', 'j')
+		assert.Equal(t, "# one two\ncode", content(e), "a change of leader ends the paragraph")
+	})
+
+	t.Run("SetCommentLeaders sets a language's leaders", func(t *testing.T) {
+		e := newTestEditor("%% one two three", withTextWidth(10))
+		e.SetCommentLeaders("tex", "%%")
+		e.SetLanguage("tex")
+		keys(e, 'g', 'q', 'q')
+		assert.Equal(t, "%% one two\n%% three", content(e))
+	})
+
+	t.Run("a count reflows that many lines", func(t *testing.T) {
+		e := newTestEditor("a\nb\nc", withTextWidth(20))
+		keys(e, '2', 'g', 'q', 'q')
+		assert.Equal(t, "a b\nc", content(e))
+	})
+
+	t.Run("visual gq reflows the selected lines", func(t *testing.T) {
+		e := newTestEditor("a\nb\nc", withTextWidth(20))
+		keys(e, 'j', 'V', 'j', 'g', 'q')
+		assert.Equal(t, "a\nb c", content(e))
+		assert.True(t, e.IsNormalMode())
+
+		e = newTestEditor("a\nb\nc", withTextWidth(20))
+		keys(e, 'v', 'j', 'g', 'q')
+		assert.Equal(t, "a b\nc", content(e))
+	})
+
+	t.Run("undo puts the text back in one step", func(t *testing.T) {
+		e := newTestEditor("one two three", withTextWidth(8))
+		keys(e, 'g', 'q', 'q', 'u')
+		assert.Equal(t, "one two three", content(e))
+	})
+}
+
+// typeText types text in insert mode.
+func typeText(e Editor, text string) {
+	keys(e, []rune(text)...)
+}
+
+// TestAutoWrap tests breaking lines at the text width while typing.
+func TestAutoWrap(t *testing.T) {
+	t.Run("typing past the width breaks at the last space before it", func(t *testing.T) {
+		e := newTestEditor("", withTextWidth(10))
+		keys(e, 'i')
+		typeText(e, "one two three four")
+		assert.Equal(t, "one two\nthree four", content(e))
+		assert.Equal(t, Position{Row: 1, Col: 10}, cursorPos(e))
+	})
+
+	t.Run("nothing breaks with no text width", func(t *testing.T) {
+		e := newTestEditor("")
+		keys(e, 'i')
+		typeText(e, strings.Repeat("word ", 30))
+		assert.Equal(t, 1, e.GetBuffer().LineCount())
+	})
+
+	t.Run("a word longer than the width is broken after", func(t *testing.T) {
+		e := newTestEditor("", withTextWidth(5))
+		keys(e, 'i')
+		typeText(e, "abcdefgh ij")
+		assert.Equal(t, "abcdefgh\nij", content(e))
+	})
+
+	t.Run("the indent and comment leader are kept", func(t *testing.T) {
+		e := newTestEditor("", withTextWidth(14), withLanguage("go"))
+		keys(e, 'i')
+		typeText(e, "\t// one two three")
+		assert.Equal(t, "\t// one two\n\t// three", content(e))
+
+		e = newTestEditor("", withTextWidth(10))
+		keys(e, 'i')
+		typeText(e, "- one two three")
+		assert.Equal(t, "- one two\n  three", content(e))
+	})
+
+	t.Run("fo=c breaks only comments", func(t *testing.T) {
+		e := newTestEditor("", withTextWidth(10), withLanguage("go"))
+		assert.Nil(t, e.ExecuteCommand("set fo=c"))
+		assert.Equal(t, AutoWrapComments, e.GetState().AutoWrap)
+		keys(e, 'i')
+		typeText(e, "x := one + two")
+		assert.Equal(t, "x := one + two", content(e))
+	})
+
+	t.Run(":set formatoptions adds and removes letters", func(t *testing.T) {
+		e := newTestEditor("")
+		assert.Nil(t, e.ExecuteCommand("set fo-=t"))
+		assert.Equal(t, AutoWrapComments, e.GetState().AutoWrap)
+		assert.Nil(t, e.ExecuteCommand("set formatoptions+=t"))
+		assert.Equal(t, AutoWrapText, e.GetState().AutoWrap)
+		assert.Nil(t, e.ExecuteCommand("set fo="))
+		assert.Equal(t, AutoWrapOff, e.GetState().AutoWrap)
+	})
+
+	t.Run("undo takes back the break with the key that made it", func(t *testing.T) {
+		e := newTestEditor("", withTextWidth(5))
+		keys(e, 'i')
+		typeText(e, "abc de")
+		e.HandleKey(KeyEvent{Key: KeyEscape})
+		keys(e, 'u')
+		assert.Equal(t, "abc d", content(e))
+	})
+}
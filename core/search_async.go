@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// searchBatchLines is how many lines SearchAsync searches between sending
+// the matches it found.
+const searchBatchLines = 10000
+
+// asyncSearch is a search running in the background, started by SearchAsync.
+// The results are shared with the editor, which collects them on its own
+// goroutine.
+type asyncSearch struct {
+	cancel   context.CancelFunc
+	revision uint64 // Revision of the buffer searched
+
+	mu      sync.Mutex
+	results []Position // Matches found so far, in buffer order
+	done    bool
+}
+
+// SearchAsync searches for every match of pattern in the background, for
+// buffers too large to search while a key waits. Matches are sent in batches
+// as SearchResultsSignals, the last of which is Done, and become the
+// SearchResults as they arrive, so they are highlighted as the search goes.
+// The cursor doesn't move; n and N move to the matches. Starting another
+// search or CancelSearch cancels it.
+func (e *editor) SearchAsync(pattern string, options SearchOptions) {
+	e.enter()
+	defer e.leave()
+
+	e.setSearchQuery(pattern, options)
+	e.state.SearchResults = []Position{}
+	e.state.SearchResultIndex = -1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &asyncSearch{cancel: cancel, revision: e.buffer.Revision()}
+	e.asyncSearch = s
+
+	needle := parseSearchPattern(e.state.SearchQuery.Term, e.state.SearchOptions)
+	go s.run(ctx, e.buffer.GetLines(), needle, e.updateSignal)
+}
+
+// run finds the matches of needle in lines, sending them after each batch
+// of lines until it's done or ctx is cancelled.
+func (s *asyncSearch) run(ctx context.Context, lines []string, needle searchNeedle, signals chan<- Signal) {
+	results := []Position{}
+	for row, line := range lines {
+		if len(needle.runes) == 0 {
+			break
+		}
+		runes := []rune(line)
+		for col := 0; col+len(needle.runes) <= len(runes); col++ {
+			if needle.matchesAt(runes, col) {
+				results = append(results, Position{Row: row, Col: col})
+			}
+		}
+
+		if (row+1)%searchBatchLines == 0 && row+1 < len(lines) {
+			if ctx.Err() != nil {
+				return
+			}
+			found := s.publish(results, false)
+			// The editor takes the results from s; a dropped signal only
+			// delays redrawing them
+			select {
+			case signals <- SearchResultsSignal{positions: found, index: -1}:
+			default:
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	found := s.publish(results, true)
+	select {
+	case signals <- SearchResultsSignal{positions: found, index: -1, done: true}:
+	case <-ctx.Done():
+	}
+}
+
+// publish shares the results found so far with the editor and returns them.
+// The search only appends past them, so they can be read while it goes on;
+// their capacity ends with them, so appending to them elsewhere copies them.
+func (s *asyncSearch) publish(results []Position, done bool) []Position {
+	found := results[:len(results):len(results)]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results, s.done = found, done
+	return found
+}
+
+// collectAsyncSearch makes the matches found in the background so far the
+// search results, with the index of the one under the cursor.
+func (e *editor) collectAsyncSearch() {
+	s := e.asyncSearch
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	results, done := s.results, s.done
+	s.mu.Unlock()
+	if done {
+		e.asyncSearch = nil
+		e.searchRevision = s.revision
+	}
+	if results == nil {
+		return
+	}
+
+	e.state.SearchResults = results
+	e.state.SearchResultIndex = -1
+	cursor := e.buffer.GetCursor().Position
+	if i, found := slices.BinarySearchFunc(results, cursor, comparePositions); found {
+		e.state.SearchResultIndex = i
+	}
+}
+
+// cancelAsyncSearch stops the search running in the background, if any.
+func (e *editor) cancelAsyncSearch() {
+	if e.asyncSearch != nil {
+		e.asyncSearch.cancel()
+		e.asyncSearch = nil
+	}
+}
+
+// comparePositions orders positions as they are in the buffer.
+func comparePositions(a, b Position) int {
+	switch {
+	case positionLess(a, b):
+		return -1
+	case positionLess(b, a):
+		return 1
+	}
+	return 0
+}
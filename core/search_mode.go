@@ -5,15 +5,15 @@ type searchMode struct{}
 func NewSearchMode() EditorMode  { return &searchMode{} }
 func (m *searchMode) Name() Mode { return SearchMode }
 
-func (m *searchMode) Enter(editor Editor, buffer Buffer) {
+func (m *searchMode) Enter(editor modeContext, buffer Buffer) {
 	editor.DispatchSignal(EnterSearchModeSignal{})
 	editor.UpdateCommand("")
 }
 
-func (m *searchMode) Exit(editor Editor, buffer Buffer) {
+func (m *searchMode) Exit(editor modeContext, buffer Buffer) {
 	editor.DispatchSignal(ExitSearchModeSignal{})
 }
 
-func (m *searchMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *EditorError {
+func (m *searchMode) HandleKey(editor modeContext, buffer Buffer, key KeyEvent) *EditorError {
 	return nil
 }
@@ -0,0 +1,497 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFind tests forward and backward buffer search with wrapping and case folding.
+func TestFind(t *testing.T) {
+	find := func(content, pattern string, start Position, options SearchOptions) (Position, bool) {
+		return NewBufferFromBytes([]byte(content)).Find(pattern, start, options)
+	}
+
+	t.Run("empty pattern finds nothing", func(t *testing.T) {
+		_, found := find("abc", "", Position{}, SearchOptions{})
+		assert.False(t, found)
+	})
+
+	t.Run("forward finds the next match after start", func(t *testing.T) {
+		pos, found := find("foo bar foo", "foo", Position{Row: 0, Col: 0}, SearchOptions{})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 8}, pos)
+	})
+
+	t.Run("forward continues on following lines", func(t *testing.T) {
+		pos, found := find("foo\nbar\nxfoo", "foo", Position{Row: 0, Col: 0}, SearchOptions{})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 2, Col: 1}, pos)
+	})
+
+	t.Run("forward does not wrap without Wrap", func(t *testing.T) {
+		_, found := find("foo\nbar", "foo", Position{Row: 1, Col: 0}, SearchOptions{})
+		assert.False(t, found)
+	})
+
+	t.Run("forward wraps to the start of the buffer", func(t *testing.T) {
+		pos, found := find("foo\nbar", "foo", Position{Row: 1, Col: 0}, SearchOptions{Wrap: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 0}, pos)
+	})
+
+	t.Run("forward wrap finds a match before start on the same line", func(t *testing.T) {
+		pos, found := find("foo bar", "foo", Position{Row: 0, Col: 4}, SearchOptions{Wrap: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 0}, pos)
+	})
+
+	t.Run("wrap finds the only match at start", func(t *testing.T) {
+		pos, found := find("a foo b", "foo", Position{Row: 0, Col: 2}, SearchOptions{Wrap: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 2}, pos)
+
+		pos, found = find("a foo b", "foo", Position{Row: 0, Col: 2}, SearchOptions{Wrap: true, Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 2}, pos)
+	})
+
+	t.Run("backward finds the previous match before start", func(t *testing.T) {
+		pos, found := find("foo bar foo", "foo", Position{Row: 0, Col: 8}, SearchOptions{Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 0}, pos)
+	})
+
+	t.Run("backward picks the closest match on the line", func(t *testing.T) {
+		pos, found := find("foo foo foo x", "foo", Position{Row: 0, Col: 12}, SearchOptions{Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 8}, pos)
+	})
+
+	t.Run("backward finds a match that ends after start", func(t *testing.T) {
+		pos, found := find("xfoo", "foo", Position{Row: 0, Col: 2}, SearchOptions{Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 1}, pos)
+	})
+
+	t.Run("backward continues on previous lines", func(t *testing.T) {
+		pos, found := find("foo foo\nbar\nbaz", "foo", Position{Row: 2, Col: 1}, SearchOptions{Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 4}, pos)
+	})
+
+	t.Run("backward from column zero moves to the previous line", func(t *testing.T) {
+		pos, found := find("foo\nfoo", "foo", Position{Row: 1, Col: 0}, SearchOptions{Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 0}, pos)
+	})
+
+	t.Run("backward does not wrap without Wrap", func(t *testing.T) {
+		_, found := find("foo\nbar foo", "foo", Position{Row: 0, Col: 0}, SearchOptions{Backwards: true})
+		assert.False(t, found)
+	})
+
+	t.Run("backward wraps to the end of the buffer", func(t *testing.T) {
+		pos, found := find("foo\nbar foo foo", "foo", Position{Row: 0, Col: 0}, SearchOptions{Backwards: true, Wrap: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 1, Col: 8}, pos)
+	})
+
+	t.Run("backward wrap finds a match after start on the same line", func(t *testing.T) {
+		pos, found := find("x foo", "foo", Position{Row: 0, Col: 0}, SearchOptions{Backwards: true, Wrap: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 2}, pos)
+	})
+
+	t.Run("case-sensitive by default", func(t *testing.T) {
+		_, found := find("FOO", "foo", Position{}, SearchOptions{Wrap: true})
+		assert.False(t, found)
+	})
+
+	t.Run("IgnoreCase matches ASCII", func(t *testing.T) {
+		pos, found := find("x FoO", "foo", Position{}, SearchOptions{IgnoreCase: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 2}, pos)
+	})
+
+	t.Run("IgnoreCase matches multi-byte characters", func(t *testing.T) {
+		pos, found := find("ab ÉCOLE", "école", Position{}, SearchOptions{IgnoreCase: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 3}, pos)
+
+		pos, found = find("x ΣΊΣΥΦΟΣ", "σίσυφος", Position{}, SearchOptions{IgnoreCase: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 2}, pos)
+	})
+
+	t.Run("IgnoreCase keeps rune columns when lowercasing changes length", func(t *testing.T) {
+		// 'İ' lowercases to two runes, which would shift columns after it
+		pos, found := find("İx ab", "AB", Position{}, SearchOptions{IgnoreCase: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 3}, pos)
+	})
+
+	t.Run("IgnoreCase backward search", func(t *testing.T) {
+		pos, found := find("Über über x", "ÜBER", Position{Row: 0, Col: 10}, SearchOptions{IgnoreCase: true, Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 5}, pos)
+	})
+
+	t.Run("columns are rune offsets", func(t *testing.T) {
+		pos, found := find("日本語 text", "text", Position{}, SearchOptions{})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 0, Col: 4}, pos)
+	})
+
+	t.Run("pattern longer than every line", func(t *testing.T) {
+		_, found := find("ab\ncd", "abcd", Position{}, SearchOptions{Wrap: true})
+		assert.False(t, found)
+	})
+
+	t.Run("start outside the buffer is clamped", func(t *testing.T) {
+		pos, found := find("foo\nbar", "bar", Position{Row: 10, Col: 10}, SearchOptions{Backwards: true})
+		assert.True(t, found)
+		assert.Equal(t, Position{Row: 1, Col: 0}, pos)
+	})
+}
+
+// TestSearchNavigation tests / and ? searches with n and N navigation.
+func TestSearchNavigation(t *testing.T) {
+	t.Run("n wraps to the first match", func(t *testing.T) {
+		e := newTestEditor("foo\nbar foo")
+		e.ExecuteSearch("foo", SearchOptions{Wrap: true})
+		assert.Equal(t, Position{Row: 1, Col: 4}, cursorPos(e))
+
+		e.NextSearchResult()
+		assert.Equal(t, Position{Row: 0, Col: 0}, cursorPos(e))
+	})
+
+	t.Run("N wraps to the last match", func(t *testing.T) {
+		e := newTestEditor("foo\nbar foo")
+		e.ExecuteSearch("foo", SearchOptions{Wrap: true})
+		e.NextSearchResult()
+
+		e.PreviousSearchResult()
+		assert.Equal(t, Position{Row: 1, Col: 4}, cursorPos(e))
+	})
+
+	t.Run("backward search finds the previous match", func(t *testing.T) {
+		e := newTestEditor("foo\nbar foo")
+		e.GetBuffer().SetCursor(Cursor{Position: Position{Row: 1, Col: 4}})
+		e.ExecuteSearch("foo", SearchOptions{Backwards: true, Wrap: true})
+		assert.Equal(t, Position{Row: 0, Col: 0}, cursorPos(e))
+	})
+
+	t.Run("\\c suffix ignores case", func(t *testing.T) {
+		e := newTestEditor("x FOO")
+		e.ExecuteSearch("foo\\c", SearchOptions{Wrap: true})
+		assert.Equal(t, Position{Row: 0, Col: 2}, cursorPos(e))
+	})
+
+	t.Run("\\c suffix overrides smart case", func(t *testing.T) {
+		e := newTestEditor("x abc Abc")
+		e.ExecuteSearch("abc\\c", SearchOptions{IgnoreCase: true, SmartCase: true, Wrap: true})
+		assert.Equal(t, "abc", e.GetState().SearchQuery.Term)
+		assert.Len(t, e.SearchResults(), 2)
+	})
+
+	t.Run("\\C suffix matches case", func(t *testing.T) {
+		e := newTestEditor("x ABC abc")
+		e.ExecuteSearch("abc\\C", SearchOptions{IgnoreCase: true, SmartCase: true, Wrap: true})
+		assert.Equal(t, Position{Row: 0, Col: 6}, cursorPos(e))
+		assert.Len(t, e.SearchResults(), 1)
+	})
+}
+
+// TestSearchCase tests every combination of IgnoreCase and SmartCase.
+func TestSearchCase(t *testing.T) {
+	const content = "foo Foo FOO"
+
+	for _, tc := range []struct {
+		name    string
+		pattern string
+		options SearchOptions
+		want    []Position
+	}{
+		{"case sensitive", "foo", SearchOptions{}, []Position{{0, 0}}},
+		{"case sensitive with uppercase", "Foo", SearchOptions{}, []Position{{0, 4}}},
+		{"smart case alone is case sensitive", "foo", SearchOptions{SmartCase: true}, []Position{{0, 0}}},
+		{"ignore case", "foo", SearchOptions{IgnoreCase: true}, []Position{{0, 0}, {0, 4}, {0, 8}}},
+		{"ignore case with uppercase", "Foo", SearchOptions{IgnoreCase: true}, []Position{{0, 0}, {0, 4}, {0, 8}}},
+		{"smart case with lowercase", "foo", SearchOptions{IgnoreCase: true, SmartCase: true}, []Position{{0, 0}, {0, 4}, {0, 8}}},
+		{"smart case with uppercase", "Foo", SearchOptions{IgnoreCase: true, SmartCase: true}, []Position{{0, 4}}},
+		{"smart case with non-ASCII uppercase", "ÉCOLE", SearchOptions{IgnoreCase: true, SmartCase: true}, []Position{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBufferFromBytes([]byte(content))
+			assert.Equal(t, tc.want, b.FindAll(tc.pattern, tc.options))
+
+			pos, found := b.Find(tc.pattern, Position{Row: 0, Col: 10}, SearchOptions{
+				IgnoreCase: tc.options.IgnoreCase,
+				SmartCase:  tc.options.SmartCase,
+				Wrap:       true,
+			})
+			assert.Equal(t, len(tc.want) > 0, found)
+			if found {
+				assert.Equal(t, tc.want[0], pos)
+			}
+		})
+	}
+
+	t.Run("n and N keep smart case", func(t *testing.T) {
+		e := newTestEditor("Foo foo Foo")
+		e.ExecuteSearch("Foo", SearchOptions{IgnoreCase: true, SmartCase: true, Wrap: true})
+		assert.Equal(t, Position{Row: 0, Col: 8}, cursorPos(e))
+
+		e.NextSearchResult()
+		assert.Equal(t, Position{Row: 0, Col: 0}, cursorPos(e))
+		e.PreviousSearchResult()
+		assert.Equal(t, Position{Row: 0, Col: 8}, cursorPos(e))
+	})
+}
+
+// TestSearchResults tests the match list and current index kept in state.
+func TestSearchResults(t *testing.T) {
+	t.Run("FindAll returns every match in order", func(t *testing.T) {
+		b := NewBufferFromBytes([]byte("foo x Foo\nfoofoo"))
+		assert.Equal(t, []Position{{0, 0}, {1, 0}, {1, 3}}, b.FindAll("foo", SearchOptions{}))
+		assert.Equal(t, []Position{{0, 0}, {0, 6}, {1, 0}, {1, 3}}, b.FindAll("foo", SearchOptions{IgnoreCase: true}))
+		assert.Empty(t, b.FindAll("", SearchOptions{}))
+	})
+
+	t.Run("search records all matches and the current index", func(t *testing.T) {
+		e := newTestEditor("a foo\nfoo\nb foo")
+		e.ExecuteSearch("foo", SearchOptions{Wrap: true})
+
+		state := e.GetState()
+		assert.Equal(t, []Position{{0, 2}, {1, 0}, {2, 2}}, state.SearchResults)
+		assert.Equal(t, 0, state.SearchResultIndex)
+		assert.Equal(t, "/foo", state.CommandLine)
+	})
+
+	t.Run("n and N update the index and wrap", func(t *testing.T) {
+		e := newTestEditor("a foo\nfoo\nb foo")
+		e.ExecuteSearch("foo", SearchOptions{Wrap: true})
+
+		e.NextSearchResult()
+		assert.Equal(t, 1, e.GetState().SearchResultIndex)
+		e.NextSearchResult()
+		assert.Equal(t, 2, e.GetState().SearchResultIndex)
+		e.NextSearchResult()
+		assert.Equal(t, 0, e.GetState().SearchResultIndex)
+		e.PreviousSearchResult()
+		assert.Equal(t, 2, e.GetState().SearchResultIndex)
+	})
+
+	t.Run("signal carries the matches and index", func(t *testing.T) {
+		e := newTestEditor("foo foo foo")
+		e.ExecuteSearch("foo", SearchOptions{Wrap: true})
+		drainSignals(e)
+
+		e.NextSearchResult()
+		var sig SearchResultsSignal
+		for s := nextSignal(e); s != nil; s = nextSignal(e) {
+			if results, ok := s.(SearchResultsSignal); ok {
+				sig = results
+			}
+		}
+		assert.Len(t, sig.Value(), 3)
+		assert.Equal(t, 2, sig.Index())
+	})
+
+	t.Run("no match clears the results", func(t *testing.T) {
+		e := newTestEditor("foo")
+		e.ExecuteSearch("bar", SearchOptions{Wrap: true})
+		assert.Empty(t, e.GetState().SearchResults)
+		assert.Equal(t, -1, e.GetState().SearchResultIndex)
+	})
+
+	t.Run("results are refreshed after edits", func(t *testing.T) {
+		e := newTestEditor("foo\nfoo")
+		e.ExecuteSearch("foo", SearchOptions{Wrap: true})
+		keys(e, 'O')
+		keys(e, []rune("foo")...)
+		escape(e)
+
+		// The search moved to row 1, so the new line is inserted above it
+		e.NextSearchResult()
+		assert.Len(t, e.GetState().SearchResults, 3)
+		assert.Equal(t, Position{Row: 2, Col: 0}, cursorPos(e))
+		assert.Equal(t, 2, e.GetState().SearchResultIndex)
+	})
+
+	t.Run("n and N reuse the results until the buffer changes", func(t *testing.T) {
+		e := newTestEditor("foo\nfoo\nfoo")
+		e.ExecuteSearch("foo", SearchOptions{Wrap: true})
+		found := e.GetState().SearchResults
+
+		e.NextSearchResult()
+		e.PreviousSearchResult()
+		assert.Same(t, &found[0], &e.GetState().SearchResults[0])
+
+		keys(e, 'x')
+		e.NextSearchResult()
+		assert.NotSame(t, &found[0], &e.GetState().SearchResults[0])
+		assert.Len(t, e.GetState().SearchResults, 2)
+	})
+}
+
+// TestSearchWholeWord tests the WholeWord option and the \< and \> atoms.
+func TestSearchWholeWord(t *testing.T) {
+	const content = "foo food afoo foo_bar foo"
+
+	for _, tc := range []struct {
+		name    string
+		pattern string
+		options SearchOptions
+		want    []Position
+	}{
+		{"substrings", "foo", SearchOptions{}, []Position{{0, 0}, {0, 4}, {0, 10}, {0, 14}, {0, 22}}},
+		{"whole word", "foo", SearchOptions{WholeWord: true}, []Position{{0, 0}, {0, 22}}},
+		{"start of word", "\\<foo", SearchOptions{}, []Position{{0, 0}, {0, 4}, {0, 14}, {0, 22}}},
+		{"end of word", "foo\\>", SearchOptions{}, []Position{{0, 0}, {0, 10}, {0, 22}}},
+		{"both atoms", "\\<foo\\>", SearchOptions{}, []Position{{0, 0}, {0, 22}}},
+		{"custom word characters", "foo", SearchOptions{WholeWord: true, IsWordChar: unicode.IsLetter}, []Position{{0, 0}, {0, 14}, {0, 22}}},
+		{"atoms inside are literal", "f\\<oo", SearchOptions{}, []Position{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBufferFromBytes([]byte(content))
+			assert.Equal(t, tc.want, b.FindAll(tc.pattern, tc.options))
+		})
+	}
+
+	t.Run("n and N keep the option", func(t *testing.T) {
+		e := newTestEditor("foo food foo")
+		e.ExecuteSearch("foo", SearchOptions{WholeWord: true, Wrap: true})
+		assert.Equal(t, Position{Row: 0, Col: 9}, cursorPos(e))
+
+		e.NextSearchResult()
+		assert.Equal(t, Position{Row: 0, Col: 0}, cursorPos(e))
+		e.PreviousSearchResult()
+		assert.Equal(t, Position{Row: 0, Col: 9}, cursorPos(e))
+	})
+
+	t.Run("extra word characters of the editor", func(t *testing.T) {
+		e := newTestEditor("foo foo-bar foo")
+		e.SetExtraWordChars('-')
+		e.ExecuteSearch("\\<foo\\>", SearchOptions{Wrap: true})
+		assert.Equal(t, []Position{{0, 0}, {0, 12}}, e.SearchResults())
+	})
+
+	t.Run("match length leaves out the atoms", func(t *testing.T) {
+		assert.Equal(t, 3, SearchMatchLen("\\<foo\\>"))
+		assert.Equal(t, 5, SearchMatchLen("f\\<oo"))
+	})
+}
+
+// TestSearchWordUnderCursor tests * and #.
+func TestSearchWordUnderCursor(t *testing.T) {
+	t.Run("* finds the next whole word", func(t *testing.T) {
+		e := newTestEditor("foo food\nfoo")
+		keys(e, 'l', '*')
+		assert.Equal(t, Position{Row: 1, Col: 0}, cursorPos(e))
+		assert.Equal(t, "\\<foo\\>", e.GetState().SearchQuery.Pattern)
+
+		keys(e, 'n')
+		assert.Equal(t, Position{Row: 0, Col: 0}, cursorPos(e))
+	})
+
+	t.Run("# finds the previous whole word", func(t *testing.T) {
+		e := newTestEditor("foo x foo x foo")
+		e.GetBuffer().SetCursor(Cursor{Position: Position{Row: 0, Col: 13}})
+		keys(e, '#')
+		assert.Equal(t, Position{Row: 0, Col: 6}, cursorPos(e))
+	})
+
+	t.Run("with a count", func(t *testing.T) {
+		e := newTestEditor("foo foo foo foo")
+		keys(e, '2', '*')
+		assert.Equal(t, Position{Row: 0, Col: 8}, cursorPos(e))
+	})
+
+	t.Run("uses the next word on the line", func(t *testing.T) {
+		e := newTestEditor("  bar baz bar")
+		keys(e, '*')
+		assert.Equal(t, Position{Row: 0, Col: 10}, cursorPos(e))
+	})
+
+	t.Run("fails without a word", func(t *testing.T) {
+		e := newTestEditor("foo  ")
+		e.GetBuffer().SetCursor(Cursor{Position: Position{Row: 0, Col: 4}})
+		assert.NotNil(t, e.SearchWordUnderCursor(false))
+	})
+}
+
+// waitForSearch returns the SearchResultsSignals sent by SearchAsync up to
+// the one that is done.
+func waitForSearch(t *testing.T, e modeContext) []SearchResultsSignal {
+	t.Helper()
+	var signals []SearchResultsSignal
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case s := <-e.GetUpdateSignalChan():
+			if results, ok := s.(SearchResultsSignal); ok {
+				signals = append(signals, results)
+				if results.Done() {
+					return signals
+				}
+			}
+		case <-timeout:
+			t.Fatal("search didn't finish")
+		}
+	}
+}
+
+// TestSearchAsync tests searching in the background.
+func TestSearchAsync(t *testing.T) {
+	t.Run("matches arrive in batches and become the results", func(t *testing.T) {
+		lines := make([]string, 2*searchBatchLines+5)
+		for i := range lines {
+			lines[i] = "x"
+		}
+		lines[3], lines[searchBatchLines+3] = "a foo", "foo foo"
+		e := newTestEditor(strings.Join(lines, "\n"))
+		drainSignals(e)
+
+		e.SearchAsync("foo", SearchOptions{})
+		signals := waitForSearch(t, e)
+
+		assert.Len(t, signals, 3)
+		assert.Equal(t, []Position{{Row: 3, Col: 2}}, signals[0].Value())
+		assert.False(t, signals[0].Done())
+		want := []Position{{Row: 3, Col: 2}, {Row: searchBatchLines + 3, Col: 0}, {Row: searchBatchLines + 3, Col: 4}}
+		assert.Equal(t, want, signals[2].Value())
+		assert.Equal(t, want, e.SearchResults())
+		assert.Equal(t, "foo", e.GetState().SearchQuery.Term)
+		assert.Equal(t, Position{}, cursorPos(e))
+
+		keys(e, 'n')
+		assert.Equal(t, Position{Row: 3, Col: 2}, cursorPos(e))
+	})
+
+	t.Run("the options apply", func(t *testing.T) {
+		e := newTestEditor("Foo foo")
+		drainSignals(e)
+		e.SearchAsync("FOO", SearchOptions{IgnoreCase: true})
+		waitForSearch(t, e)
+		assert.Equal(t, []Position{{Row: 0, Col: 0}, {Row: 0, Col: 4}}, e.SearchResults())
+	})
+
+	t.Run("a new search cancels the running one", func(t *testing.T) {
+		e := newTestEditor(strings.Repeat("foo bar\n", 5*searchBatchLines))
+		drainSignals(e)
+		e.SearchAsync("foo", SearchOptions{})
+		e.SearchAsync("bar", SearchOptions{})
+
+		signals := waitForSearch(t, e)
+		last := signals[len(signals)-1].Value()
+		assert.Len(t, last, 5*searchBatchLines)
+		assert.Equal(t, Position{Row: 0, Col: 4}, last[0])
+		assert.Len(t, e.SearchResults(), 5*searchBatchLines)
+		assert.Equal(t, "bar", e.GetState().SearchQuery.Term)
+	})
+}
@@ -2,30 +2,63 @@ package core
 
 type Signal any
 
-type YankSignal struct {
-	content string
+// registerText is text a yank, delete or paste moved between the buffer and
+// a register.
+type registerText struct {
+	content  string
+	start    Position
+	end      Position
+	register rune
+	linewise bool
 }
 
-type PasteSignal struct {
-	content string
+// newRegisterText describes content at start in the buffer, moved to or from
+// the unnamed register.
+func newRegisterText(content string, start Position, linewise bool) registerText {
+	return registerText{
+		content:  content,
+		start:    start,
+		end:      textEnd(start, content),
+		register: '"',
+		linewise: linewise,
+	}
 }
 
-func (p PasteSignal) Value() string {
-	return p.content
+// Value returns the text.
+func (r registerText) Value() string {
+	return r.content
 }
 
-type CommandSignal struct{}
+// Range returns the position of the first character of the text in the buffer
+// and the position just past its last, which for line-wise text is the start
+// of the line after it. Deleted text is where it was before the deletion.
+func (r registerText) Range() (start, end Position) {
+	return r.start, r.end
+}
 
-func (y YankSignal) Value() string {
-	return y.content
+// Register returns the name of the register the text went to or came from.
+// The unnamed register '"' is the clipboard.
+func (r registerText) Register() rune {
+	return r.register
 }
 
-type DeleteSignal struct {
-	content string
+// Linewise reports whether the text is made of whole lines.
+func (r registerText) Linewise() bool {
+	return r.linewise
 }
 
-func (d DeleteSignal) Value() string {
-	return d.content
+type YankSignal struct {
+	registerText
+}
+
+type PasteSignal struct {
+	registerText
+}
+
+type CommandSignal struct{}
+
+type DeleteSignal struct {
+	registerText
 }
 
 type RelativeNumbersSignal struct {
@@ -36,6 +69,15 @@ func (r RelativeNumbersSignal) Value() bool {
 	return r.enabled
 }
 
+// NumbersSignal reports :set number or :set nonumber.
+type NumbersSignal struct {
+	enabled bool
+}
+
+func (n NumbersSignal) Value() bool {
+	return n.enabled
+}
+
 type UndoSignal struct {
 	contentBefore string
 }
@@ -52,21 +94,35 @@ func (r RedoSignal) Value() string {
 	return r.contentBefore
 }
 
+// RenameSignal asks the host to rename the file, once :rename is confirmed.
+// The host answers FileRenamed when it has, for the editor to take the new
+// name.
 type RenameSignal struct {
 	fileName string
+	from     string
 }
 
 func (r RenameSignal) Value() string {
 	return r.fileName
 }
 
+// From returns the name of the file before the rename, empty if the buffer
+// had none.
+func (r RenameSignal) From() string {
+	return r.from
+}
+
 type DeleteFileSignal struct{}
 
 func (d DeleteFileSignal) Value() {}
 
+// SaveSignal carries the content to write, encoded in the buffer's encoding.
 type SaveSignal struct {
-	path    *string
-	content string
+	path       *string
+	content    string
+	partial    bool
+	start, end int
+	appendTo   bool
 }
 
 func (s SaveSignal) Value() (path *string, content string) {
@@ -76,8 +132,80 @@ func (s SaveSignal) Value() (path *string, content string) {
 	return path, content
 }
 
+// Lines returns the first and last line written, 0-based, and whether only
+// those lines were written rather than the whole buffer, as by :2,5w file.
+func (s SaveSignal) Lines() (start, end int, partial bool) {
+	return s.start, s.end, s.partial
+}
+
+// Append reports whether the content is added to the end of the file
+// instead of replacing it, as by :w >> file.
+func (s SaveSignal) Append() bool {
+	return s.appendTo
+}
+
 type QuitSignal struct{}
 
+// ConfirmQuitSignal is dispatched when quitting is refused because the
+// buffer has unsaved changes, so the host can ask whether to save first.
+type ConfirmQuitSignal struct{}
+
+// ConfirmAction is what a ConfirmSignal asks to confirm.
+type ConfirmAction int
+
+const (
+	ConfirmDeleteFile ConfirmAction = iota // :delete, which sends DeleteFileSignal
+	ConfirmRename                          // :rename, which sends RenameSignal
+)
+
+// ConfirmSignal asks the host to confirm an action on the file before the
+// editor takes it, as :delete and :rename do. The host may ask the user with
+// Prompt, then calls Accept to go ahead or Reject to cancel, from the
+// goroutine driving the editor. Only the first answer counts.
+type ConfirmSignal struct {
+	action ConfirmAction
+	prompt string
+	accept func()
+	reject func()
+}
+
+// Value returns the action to confirm.
+func (c ConfirmSignal) Value() ConfirmAction {
+	return c.action
+}
+
+// Prompt returns a question for the user, such as "Delete notes.md?".
+func (c ConfirmSignal) Prompt() string {
+	return c.prompt
+}
+
+// Accept takes the action.
+func (c ConfirmSignal) Accept() {
+	if c.accept != nil {
+		c.accept()
+	}
+}
+
+// Reject cancels the action.
+func (c ConfirmSignal) Reject() {
+	if c.reject != nil {
+		c.reject()
+	}
+}
+
+// ConflictSignal is dispatched by MarkExternallyModified when the file
+// changed on disk while the buffer had unsaved changes. The host may ask
+// whether to keep them, take the file or compare the two, and answer with
+// ResolveConflict or MergeConflict.
+type ConflictSignal struct {
+	base, mine, theirs string
+}
+
+// Value returns the content last saved, the buffer's and the file's.
+func (c ConflictSignal) Value() (base, mine, theirs string) {
+	return c.base, c.mine, c.theirs
+}
+
 type ErrorSignal EditorError
 
 func (e ErrorSignal) Value() (id ErrorId, err error) {
@@ -87,6 +215,34 @@ func (e ErrorSignal) Value() (id ErrorId, err error) {
 	return id, err
 }
 
+// Severity returns the severity of the error's message.
+func (e ErrorSignal) Severity() Severity {
+	return EditorError(e).Severity()
+}
+
+// ModeChangedSignal is dispatched when the editor changes mode, after the
+// hooks set with SetModeHook ran.
+type ModeChangedSignal struct {
+	from, to Mode
+}
+
+// Value returns the mode left and the mode entered.
+func (m ModeChangedSignal) Value() (from, to Mode) {
+	return m.from, m.to
+}
+
+// BlockedSignal is dispatched when the user tries something the editor
+// doesn't have the capability for, as set by SetCapabilities, e.g. to say
+// the buffer is read-only.
+type BlockedSignal struct {
+	capability Capability
+}
+
+// Value returns the capability the action needed.
+func (b BlockedSignal) Value() Capability {
+	return b.capability
+}
+
 type EnterCommandModeSignal struct{}
 
 type EnterSearchModeSignal struct{}
@@ -95,12 +251,50 @@ type ExitSearchModeSignal struct{}
 
 type SearchResultsSignal struct {
 	positions []Position
+	index     int
+	done      bool
 }
 
+// Value returns every match of the search in buffer order. For a search
+// still running in the background, it's the matches found so far.
 func (s SearchResultsSignal) Value() []Position {
 	return s.positions
 }
 
+// Index returns the position in Value of the match under the cursor, or -1.
+func (s SearchResultsSignal) Index() int {
+	return s.index
+}
+
+// Done reports whether the search has finished. Only SearchAsync sends
+// results before it has.
+func (s SearchResultsSignal) Done() bool {
+	return s.done
+}
+
+// QuickfixSignal is sent when the quickfix list, its selected item or whether
+// it is shown changes.
+type QuickfixSignal struct {
+	items []QuickfixItem
+	index int
+	open  bool
+}
+
+// Value returns the items of the quickfix list.
+func (q QuickfixSignal) Value() []QuickfixItem {
+	return q.items
+}
+
+// Index returns the position in Value of the selected item, or -1.
+func (q QuickfixSignal) Index() int {
+	return q.index
+}
+
+// Open reports whether the list is shown.
+func (q QuickfixSignal) Open() bool {
+	return q.open
+}
+
 type CompletionRequestSignal struct {
 	context CompletionContext
 }
@@ -126,8 +320,122 @@ func (c CompletionResponseSignal) Value() ([]Completion, CompletionContext) {
 }
 
 func (e *editor) DispatchSignal(signal Signal) {
+	// Yanked and deleted text goes into the yank history on its way out
+	switch signal := signal.(type) {
+	case YankSignal:
+		e.recordYank(signal.Value())
+	case DeleteSignal:
+		e.recordYank(signal.Value())
+	}
+
+	e.sendSignal(signal)
+}
+
+// SignalPolicy is what the editor does with a signal when the host isn't
+// reading them fast enough to leave room for it. Dispatching never waits for
+// the host.
+type SignalPolicy int
+
+const (
+	SignalDropNewest SignalPolicy = iota // Drop the new signal, the default
+	SignalDropOldest                     // Drop the oldest unread signal to make room
+	SignalMerge                          // Drop the unread signals the new one supersedes, such as older search results, or else the oldest
+)
+
+// SetSignalPolicy sets what happens to signals when the host falls behind.
+func (e *editor) SetSignalPolicy(policy SignalPolicy) {
+	e.signalPolicy = policy
+}
+
+// DroppedSignals returns how many signals were dropped so far because the
+// host fell behind, to tell it missed some.
+func (e *editor) DroppedSignals() int {
+	return e.droppedSignals
+}
+
+// sendSignal sends signal to the host without waiting, making room for it
+// as the signal policy says if the channel is full.
+func (e *editor) sendSignal(signal Signal) {
+	if e.trySendSignal(signal) {
+		e.traceSignal(signal, false)
+		return
+	}
+
+	switch e.signalPolicy {
+	case SignalMerge:
+		if e.mergeSignals(signal) {
+			break
+		}
+		fallthrough
+	case SignalDropOldest:
+		select {
+		case oldest := <-e.updateSignal:
+			e.droppedSignals++
+			e.traceSignal(oldest, true)
+		default:
+		}
+	}
+
+	sent := e.trySendSignal(signal)
+	if !sent {
+		e.droppedSignals++
+	}
+	e.traceSignal(signal, !sent)
+}
+
+// trySendSignal sends signal if there's room for it in the channel.
+func (e *editor) trySendSignal(signal Signal) bool {
 	select {
 	case e.updateSignal <- signal:
-	default: // Ignore if the channel is full
+		return true
+	default:
+		return false
+	}
+}
+
+// mergeSignals drops the unread signals that newer supersedes, keeping the
+// order of the others, and reports whether it dropped any.
+func (e *editor) mergeSignals(newer Signal) bool {
+	var unread []Signal
+drain:
+	for len(unread) < cap(e.updateSignal) {
+		select {
+		case signal := <-e.updateSignal:
+			unread = append(unread, signal)
+		default:
+			break drain
+		}
+	}
+
+	merged := false
+	for _, signal := range unread {
+		if supersedes(newer, signal) {
+			e.droppedSignals++
+			merged = true
+		} else if !e.trySendSignal(signal) {
+			e.droppedSignals++
+		}
+	}
+	return merged
+}
+
+// supersedes reports whether newer makes older pointless to read, as both
+// describe the latest state of the same thing rather than an event.
+func supersedes(newer, older Signal) bool {
+	var ok bool
+	switch newer.(type) {
+	case CommandSignal:
+		_, ok = older.(CommandSignal)
+	case RelativeNumbersSignal:
+		_, ok = older.(RelativeNumbersSignal)
+	case NumbersSignal:
+		_, ok = older.(NumbersSignal)
+	case SearchResultsSignal:
+		_, ok = older.(SearchResultsSignal)
+	case CompletionRequestSignal:
+		_, ok = older.(CompletionRequestSignal)
+	case ConflictSignal:
+		_, ok = older.(ConflictSignal)
 	}
+	return ok
 }
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSignalPolicy tests dispatching signals when the host falls behind.
+func TestSignalPolicy(t *testing.T) {
+	// fill leaves room for no more signals, the first of them a QuitSignal
+	// and the rest CommandSignals.
+	fill := func(e modeContext) {
+		drainSignals(e)
+		e.DispatchSignal(QuitSignal{})
+		for len(e.GetUpdateSignalChan()) < cap(e.GetUpdateSignalChan()) {
+			e.DispatchSignal(CommandSignal{})
+		}
+	}
+
+	t.Run("the new signal is dropped by default", func(t *testing.T) {
+		e := newTestEditor("")
+		fill(e)
+		e.DispatchError(ErrInvalidModeId, nil)
+		assert.Equal(t, 1, e.DroppedSignals())
+		assert.IsType(t, QuitSignal{}, nextSignal(e))
+	})
+
+	t.Run("the oldest signal is dropped to make room", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetSignalPolicy(SignalDropOldest)
+		fill(e)
+		e.DispatchSignal(ConfirmQuitSignal{})
+		assert.Equal(t, 1, e.DroppedSignals())
+		assert.IsType(t, CommandSignal{}, nextSignal(e))
+
+		var last Signal
+		for signal := nextSignal(e); signal != nil; signal = nextSignal(e) {
+			last = signal
+		}
+		assert.IsType(t, ConfirmQuitSignal{}, last)
+	})
+
+	t.Run("superseded signals are merged", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetSignalPolicy(SignalMerge)
+		fill(e)
+		e.DispatchSignal(CommandSignal{})
+		assert.Equal(t, cap(e.GetUpdateSignalChan())-1, e.DroppedSignals())
+		assert.IsType(t, QuitSignal{}, nextSignal(e))
+		assert.IsType(t, CommandSignal{}, nextSignal(e))
+		assert.Nil(t, nextSignal(e))
+	})
+
+	t.Run("merging falls back to dropping the oldest", func(t *testing.T) {
+		e := newTestEditor("")
+		e.SetSignalPolicy(SignalMerge)
+		fill(e)
+		e.DispatchSignal(ConfirmQuitSignal{})
+		assert.Equal(t, 1, e.DroppedSignals())
+		assert.IsType(t, CommandSignal{}, nextSignal(e))
+	})
+}
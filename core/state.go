@@ -3,9 +3,11 @@ package core
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 )
@@ -17,11 +19,12 @@ type SearchQuery struct {
 
 // State represents the complete current state of the editor (Refined)
 type State struct {
-	Mode         Mode   // Current editing mode (Normal, Insert, Visual, Command)
-	PreviousMode Mode   // Previous editing mode
-	StatusLine   string // Content of the status line (bottom line)
-	CommandLine  string // Current command being typed or message to display
-	Quit         bool   // Flag indicating if the editor should exit
+	Mode          Mode   // Current editing mode (Normal, Insert, Visual, Command)
+	PreviousMode  Mode   // Previous editing mode
+	StatusLine    string // Content of the status line (bottom line)
+	CommandLine   string // Current command being typed or message to display
+	CommandCursor int    // Index in the runes of CommandLine of the cursor editing it, -1 if none
+	Quit          bool   // Flag indicating if the editor should exit
 
 	// Viewport information
 	TopLine        int // First line visible in the viewport (0-indexed)
@@ -35,15 +38,46 @@ type State struct {
 	// Command handling
 	SearchQuery       SearchQuery // Current search query (for Search command)
 	SearchOptions     SearchOptions
-	SearchResults     []Position // List of positions for search results
-	SearchResultIndex int        // Current index in the search results
+	SearchResults     []Position // Every match of the current search, in buffer order
+	SearchResultIndex int        // Index in SearchResults of the match under the cursor, -1 if none
 	PendingCount      *int       // For handling numeric prefixes to commands (e.g., "5j") - Managed in normalMode
+	PendingKeys       string     // Keys of the command being typed (e.g., "2d3"), shown like Vim's showcmd
+
+	// Quickfix list
+	QuickfixItems []QuickfixItem // Items set by the host with SetQuickfixList
+	QuickfixIndex int            // Index in QuickfixItems of the selected item, -1 if none
+	QuickfixOpen  bool           // Whether the list is shown (:copen)
 
 	// Error/Message Display
 	Message string // Temporary message to display
 
 	// UI Options
 	RelativeNumbers bool // Flag for relative line numbers
+	Numbers         bool // Flag for absolute line numbers; with RelativeNumbers, only the cursor line's
+	NumberWidth     int  // Least columns the line numbers take, with the space after them; 5 if 0
+
+	ShowBreak   string // Drawn at the start of the display lines wrapping continues a line on
+	BreakIndent bool   // Display lines wrapping continues a line on are indented as the line is
+
+	RedoOnU bool // U redoes instead of undoing the last changed line
+
+	TimeoutLen time.Duration // How long keys waiting for more wait for the next one before they are dropped, forever if 0
+
+	DisplayLineMovement bool // j and k move by display lines, and gj and gk by lines
+
+	TextWidth int      // Columns gq reflows text to, 79 if 0, and insert mode breaks lines at, none if 0
+	AutoWrap  AutoWrap // Which lines insert mode breaks at TextWidth
+
+	TabWidth   int  // Columns between tab stops, which > indents by with ExpandTab; 4 if 0
+	ExpandTab  bool // Tab and > insert spaces rather than tabs
+	AutoIndent bool // Enter, o and O start the new line with the indent of the one before
+
+	VirtualEdit VirtualEdit // Where the cursor may go past the end of a line
+
+	TableMode      bool // Tab moves between the cells of tables, which are aligned as they change
+	TableDelimiter rune // Delimiter of the cells of tables, | if 0
+
+	StatementSyntax *StatementSyntax // Where statements end for is, as, ]s and [s, nil if the language has none
 
 	VimMode bool
 
@@ -51,15 +85,11 @@ type State struct {
 
 	isWordCharFunc func(rune) bool // Pre-computed classifier for word characters
 
-	WithCommandMode bool // Whether command mode is enabled
+	lastCharSearch charSearch // Last f/F/t/T search, repeated by ; and , in every mode
 
-	WithInsertMode bool // Whether insert mode is enabled
+	lastVisual visualSelection // Last visual selection, restored by gv
 
-	WithVisualMode bool // Whether visual mode is enabled
-
-	WithVisualLineMode bool // Whether visual line mode is enabled
-
-	WithSearchMode bool // Whether search mode is enabled
+	Capabilities Capability // What the user may do, set by SetCapabilities
 }
 
 // defaultIsWordCharFunc is the singleton default classifier (letters, digits, '_').
@@ -122,6 +152,7 @@ func InitialState() State {
 		PreviousMode:      "normal",
 		StatusLine:        "-- NORMAL --",
 		CommandLine:       "",
+		CommandCursor:     -1,
 		TopLine:           0,
 		ViewportHeight:    24,
 		ViewportWidth:     80,
@@ -129,18 +160,15 @@ func InitialState() State {
 		SearchQuery:       SearchQuery{},
 		SearchResults:     []Position{},
 		SearchResultIndex: -1,
+		QuickfixIndex:     -1,
 		PendingCount:      nil,
 		Message:           "",
 		RelativeNumbers:   false, // Default to absolute numbers
+		Numbers:           true,
 		Quit:              false,
 		VimMode:           true,
 		isWordCharFunc:    getDefaultIsWordCharFunc(),
-
-		WithCommandMode:    true,
-		WithInsertMode:     true,
-		WithVisualMode:     true,
-		WithVisualLineMode: true,
-		WithSearchMode:     true,
+		Capabilities:      AllCapabilities,
 	}
 }
 
@@ -152,28 +180,71 @@ type editor struct {
 	state       State
 
 	// IMPROVEMENT: Use a more efficient history mechanism (diffs, ring buffer)
-	history         []string // Store snapshots of buffer content as strings
-	cursorHistory   []Cursor // Store cursor states corresponding to history
-	historyPos      int      // Current position in the history (-1 = initial state)
-	maxHistory      uint32   // Max number of history entries
-	preChangeCursor Cursor   // Cursor position captured at the start of each key event
+	history         []historyEntry // Snapshots of the buffer content
+	historyPos      int            // Current position in the history (-1 = initial state)
+	maxHistory      uint32         // Max number of history entries
+	preChangeCursor Cursor         // Cursor position captured at the start of each key event
+	historySaved    bool           // Whether the current key event saved a history entry
+	undoLine        lineUndo       // Line restored by U
+	joiningChange   bool           // Whether insert mode adds its edits to the change of c, so they undo as one
 
-	clipboard    Clipboard // Clipboard interface for copy/paste
-	updateSignal chan Signal
+	clipboard      Clipboard // Clipboard interface for copy/paste
+	updateSignal   chan Signal
+	signalPolicy   SignalPolicy // What happens to signals when updateSignal is full
+	droppedSignals int          // Signals dropped because updateSignal was full
+
+	yankHistory []string    // Texts yanked and deleted last, newest first
+	lastPaste   *pasteCycle // Paste that Ctrl-P and Ctrl-N can swap, nil after any other key
+	pasted      *string     // Text pasted by p or P during the current key event
+
+	commands  map[string]CommandHandler // Extra commands registered by the host
+	modeHooks map[Mode]ModeHook         // Run when entering and leaving modes, set by the host
+	marks     map[rune]Position         // Marks a to z, set by m and SetMark
+
+	invalidUTF8Fallback Encoding // Encoding used by SetContent for content that isn't valid UTF-8
+
+	maxLength int                        // Most runes the content may have after an edit, 0 for no limit
+	validate  func(content string) error // Checks the content after each edit, nil for none
+	protected []ProtectedRange           // Read-only text, moved with the text around it
+
+	asyncSearch    *asyncSearch // Search started by SearchAsync, until its results are collected
+	searchRevision uint64       // Revision of the buffer SearchResults hold every match of, 0 if they must be found again
+
+	transactionDepth int       // BeginTransaction calls not ended yet
+	transaction      editState // State before the outermost transaction
+	inGlobal         bool      // Running the command of :g, which can't run :g again
+
+	language          string                      // Language of the content, lower case
+	statementSyntaxes map[string]*StatementSyntax // Statement syntaxes set by the host, by language
+	formatters        map[string]Formatter        // Formatters set by the host, by language
+	formatOnSave      bool                        // Whether :w formats before writing the whole buffer
+	commentLeaders    map[string][]string         // Comment leaders set by the host, by language, kept by gq
+	languageConfigs   map[string]LanguageConfig   // Settings set by the host, by language, applied by SetLanguage
+	layoutProvider    LayoutProvider              // How lines are laid out on screen, nil if wrapped every AvailableWidth columns
+	verticalMoveEnd   Position                    // Where the last move up or down left the cursor, wanting its Preferred column
+
+	diskContent *string // Content the file changed to on disk while the buffer had unsaved changes
+
+	logger   *slog.Logger         // Told what the editor does at LevelTrace, nil for none
+	messages map[MessageId]string // Texts of the messages set by the host, in place of the English ones
+
+	busy atomic.Bool // Whether a call that changes the content is running
 }
 
 // New creates a new editor instance
 func New(clipboard Clipboard) Editor {
 	e := &editor{
-		buffer:        NewBuffer(),
-		modes:         make(map[Mode]EditorMode),
-		state:         InitialState(), // Use initial state function
-		history:       []string{},     // Initialize history
-		cursorHistory: []Cursor{},     // Initialize cursor history
-		historyPos:    -1,             // Start before the first save
-		maxHistory:    1000,           // Default history size
-		clipboard:     clipboard,
-		updateSignal:  make(chan Signal, 100), // Buffered channel for updates
+		buffer:       NewBuffer(),
+		modes:        make(map[Mode]EditorMode),
+		state:        InitialState(),   // Use initial state function
+		history:      []historyEntry{}, // Initialize history
+		historyPos:   -1,               // Start before the first save
+		maxHistory:   1000,             // Default history size
+		clipboard:    clipboard,
+		updateSignal: make(chan Signal, 100), // Buffered channel for updates
+		commands:     make(map[string]CommandHandler),
+		modeHooks:    make(map[Mode]ModeHook),
+		marks:        make(map[rune]Position),
 	}
 
 	// Register modes (pass editor instance if modes need it during init)
@@ -202,12 +273,34 @@ func New(clipboard Clipboard) Editor {
 	return e
 }
 
+// Display returns the editor as the host sizes and draws its view.
+func (e *editor) Display() Display { return e }
+
+// Registers returns the editor's yanked text and clipboard.
+func (e *editor) Registers() Registers { return e }
+
+// Quickfix returns the editor's quickfix list.
+func (e *editor) Quickfix() Quickfix { return e }
+
+// Options returns the editor's settings.
+func (e *editor) Options() Options { return e }
+
+// Languages returns the editor's settings by language.
+func (e *editor) Languages() Languages { return e }
+
+// Limits returns the editor's input limits.
+func (e *editor) Limits() Limits { return e }
+
 // SetMaxHistory allows setting the maximum number of history entries.
 // Default is 1000.
 func (e *editor) SetMaxHistory(max uint32) {
 	e.maxHistory = max
 }
 
+func (e *editor) SetClipboard(clipboard Clipboard) {
+	e.clipboard = clipboard
+}
+
 func (e *editor) DisableVimMode(disable bool) {
 	e.state.VimMode = !disable
 	if disable {
@@ -222,33 +315,81 @@ func (e *editor) IsVimMode() bool {
 	return e.state.VimMode
 }
 
+// DisableCommandMode takes CommandLine away, or gives it back.
 func (e *editor) DisableCommandMode(disable bool) {
-	e.state.WithCommandMode = !disable
+	e.setCapability(CommandLine, disable)
 }
 
+// DisableInsertMode takes EditText away, or gives it back.
 func (e *editor) DisableInsertMode(disable bool) {
-	e.state.WithInsertMode = !disable
+	e.setCapability(EditText, disable)
 }
 
+// DisableVisualMode takes VisualSelect away, or gives it back, for visual
+// and visual line mode alike.
 func (e *editor) DisableVisualMode(disable bool) {
-	e.state.WithVisualMode = !disable
+	e.setCapability(VisualSelect, disable)
 }
 
+// DisableVisualLineMode is DisableVisualMode.
 func (e *editor) DisableVisualLineMode(disable bool) {
-	e.state.WithVisualLineMode = !disable
+	e.setCapability(VisualSelect, disable)
 }
 
+// DisableSearchMode takes Search away, or gives it back.
 func (e *editor) DisableSearchMode(disable bool) {
-	e.state.WithSearchMode = !disable
+	e.setCapability(Search, disable)
 }
 
 func (e *editor) ShowRelativeLineNumbers(show bool) {
 	e.state.RelativeNumbers = show
 }
 
+// ShowAbsoluteLineNumbers sets whether lines are numbered from the start, as
+// Vim's number. With relative numbers, only the cursor line is.
+func (e *editor) ShowAbsoluteLineNumbers(show bool) {
+	e.state.Numbers = show
+}
+
+// SetNumberWidth sets the least columns the line numbers take, with the
+// space after them, as Vim's numberwidth. 0 is 5.
+func (e *editor) SetNumberWidth(width int) {
+	e.state.NumberWidth = max(width, 0)
+}
+
+// SetTimeoutLen sets how long keys waiting for more, such as a count, an
+// operator or f waiting for its character, wait for the next key, as Vim's
+// timeoutlen. Once it has passed, the host sends a KeyTimeout and the keys
+// are dropped. 0, the default, waits forever.
+func (e *editor) SetTimeoutLen(timeout time.Duration) {
+	e.state.TimeoutLen = max(timeout, 0)
+}
+
+// SetShowBreak sets what is drawn at the start of the display lines wrapping
+// continues a line on, as Vim's showbreak, such as "↪ ".
+func (e *editor) SetShowBreak(showBreak string) {
+	e.state.ShowBreak = showBreak
+}
+
+// SetBreakIndent sets whether the display lines wrapping continues a line on
+// are indented as the line is, as Vim's breakindent.
+func (e *editor) SetBreakIndent(enabled bool) {
+	e.state.BreakIndent = enabled
+}
+
 func (e *editor) setMode(modeName Mode) {
 	newMode := e.modes[modeName]
+	oldModeName := e.state.Mode
+	changed := e.currentMode != nil && oldModeName != modeName
 
+	if changed {
+		if oldModeName == InsertMode {
+			e.joiningChange = false
+		}
+		if hook := e.modeHooks[oldModeName].Exit; hook != nil {
+			e.callHost(func() { hook(e) })
+		}
+	}
 	if e.currentMode != nil {
 		e.currentMode.Exit(e, e.buffer) // Pass buffer to Exit
 	}
@@ -257,6 +398,14 @@ func (e *editor) setMode(modeName Mode) {
 	e.currentMode = newMode
 	e.state.Mode = modeName          // Update state string
 	e.currentMode.Enter(e, e.buffer) // Pass buffer to Enter
+
+	if changed {
+		if hook := e.modeHooks[modeName].Enter; hook != nil {
+			e.callHost(func() { hook(e) })
+		}
+		e.trace("mode", "from", oldModeName, "to", modeName)
+		e.DispatchSignal(ModeChangedSignal{from: oldModeName, to: modeName})
+	}
 }
 
 func (e *editor) SetNormalMode() {
@@ -264,7 +413,9 @@ func (e *editor) SetNormalMode() {
 }
 
 func (e *editor) SetInsertMode() {
-	if !e.state.WithInsertMode {
+	// Without Vim mode the editor stays in insert mode, where EditText only
+	// blocks the keys that change the text
+	if e.state.VimMode && !e.allow(EditText) {
 		return
 	}
 
@@ -272,7 +423,7 @@ func (e *editor) SetInsertMode() {
 }
 
 func (e *editor) SetVisualMode() {
-	if !e.state.WithVisualMode {
+	if !e.allow(VisualSelect) {
 		return
 	}
 
@@ -280,7 +431,7 @@ func (e *editor) SetVisualMode() {
 }
 
 func (e *editor) SetVisualLineMode() {
-	if !e.state.WithVisualLineMode {
+	if !e.allow(VisualSelect) {
 		return
 	}
 
@@ -288,7 +439,7 @@ func (e *editor) SetVisualLineMode() {
 }
 
 func (e *editor) SetCommandMode() {
-	if !e.state.WithCommandMode {
+	if !e.allow(CommandLine) {
 		return
 	}
 
@@ -296,7 +447,7 @@ func (e *editor) SetCommandMode() {
 }
 
 func (e *editor) SetSearchMode() {
-	if !e.state.WithSearchMode {
+	if !e.allow(Search) {
 		return
 	}
 
@@ -308,18 +459,30 @@ func (e *editor) GetBuffer() Buffer {
 }
 
 func (e *editor) SetBuffer(buffer Buffer) {
+	e.enter()
+	defer e.leave()
+
 	e.buffer = buffer
 	// Reset history when buffer changes completely
-	e.history = []string{}
-	e.cursorHistory = []Cursor{}
+	e.history = []historyEntry{}
 	e.historyPos = -1
-	e.SaveHistory()                                       // Save the new buffer's initial state
-	e.UpdateStatus(fmt.Sprintf("-- %s --", e.state.Mode)) // Update status
-	e.ScrollViewport()                                    // Adjust viewport for new buffer
+	e.undoLine = lineUndo{}
+	e.protected = nil
+	e.SaveHistory()                // Save the new buffer's initial state
+	e.UpdateStatus(e.modeStatus()) // Update status
+	e.ScrollViewport()             // Adjust viewport for new buffer
 }
 
 func (e *editor) SetContent(content []byte) {
-	e.SetBuffer(NewBufferFromBytes(content))
+	e.SetBuffer(NewBufferFromBytesWithFallback(content, e.invalidUTF8Fallback))
+}
+
+func (e *editor) SetContentWithEncoding(content []byte, enc Encoding) {
+	e.SetBuffer(NewBufferFromBytesWithEncoding(content, enc))
+}
+
+func (e *editor) SetInvalidUTF8Fallback(enc Encoding) {
+	e.invalidUTF8Fallback = enc
 }
 
 func (e *editor) GetMode() EditorMode {
@@ -331,20 +494,109 @@ func (e *editor) GetUpdateSignalChan() <-chan Signal {
 }
 
 func (e *editor) HandleKey(key KeyEvent) *EditorError {
+	e.enter()
+	defer e.leave()
+
+	mode, revision := e.state.Mode, e.buffer.Revision()
+	err := e.checkedUserEdit(func() *EditorError {
+		return e.handleKey(key)
+	})
+	if e.tracing() {
+		e.traceKey(key, mode, revision, err)
+	}
+	return err
+}
+
+// handleKey processes a key press for HandleKey.
+func (e *editor) handleKey(key KeyEvent) *EditorError {
+	key = key.Legacy()
 	if e.currentMode == nil {
 		return &EditorError{
 			id:  ErrInvalidModeId,
 			err: errors.New("no current mode set"),
 		}
 	}
+	e.collectAsyncSearch()
+
+	if key.Key == KeyTimeout {
+		e.cancelPendingKeys()
+		return nil
+	}
 
 	// Snapshot cursor before any change so SaveHistory can record the pre-change position.
+	// A change to a visual selection is undone to the start of the selection.
 	e.preChangeCursor = e.buffer.GetCursor()
+	if e.IsVisualMode() || e.IsVisualLineMode() {
+		e.preChangeCursor.Position, _ = NormalizeSelection(e.state.VisualStart, e.preChangeCursor.Position)
+	}
+	e.historySaved = false
+
+	// Ctrl-P and Ctrl-N right after a paste swap the pasted text for an older
+	// or a newer yank
+	lastPaste := e.lastPaste
+	e.lastPaste = nil
+	if lastPaste != nil && e.IsNormalMode() && !e.HasPendingKeys() && (key.Key == KeyCtrlP || key.Key == KeyCtrlN) {
+		e.lastPaste = lastPaste
+		err := e.cyclePaste(key.Key == KeyCtrlP)
+		e.ScrollViewport()
+		return err
+	}
+
+	historyPos := e.historyPos
+	count := e.state.PendingCount
+	e.pasted = nil
+
+	if handled, err := e.handleTableKey(key); handled {
+		e.ScrollViewport()
+		return err
+	}
+
+	if handled, err := e.handlePlainKey(key); handled {
+		e.ScrollViewport()
+		return err
+	}
+
+	if e.virtualMove(key) {
+		e.updatePendingKeys(key)
+		e.ScrollViewport()
+		return nil
+	}
+
+	virtual := e.leaveVirtualCol(key)
+
+	// Typing past the end of the line fills the gap with spaces first
+	if e.IsInsertMode() && (key.Rune != 0 || key.Key == KeyTab || key.Key == KeyEnter) {
+		if err := e.fillToCursor(); err != nil {
+			return &EditorError{id: ErrInvalidPositionId, err: err}
+		}
+	}
 
 	// Let the current mode handle the key
+	wasInsert := e.IsInsertMode()
 	err := e.currentMode.HandleKey(e, e.buffer, key)
+	e.insertAtVirtualCol(virtual)
+	e.clampCursorToLine()
+	if err == nil {
+		err = e.alignTableAfterKey(key, wasInsert)
+	}
+
+	// Redo puts the cursor where the key left it, which may be after the
+	// change was saved
+	if e.historySaved && e.historyPos >= 0 {
+		e.history[e.historyPos].after = e.buffer.GetCursor()
+	}
+
+	if e.pasted != nil && len(e.yankHistory) > 0 {
+		e.lastPaste = &pasteCycle{
+			key:        key,
+			count:      count,
+			historyPos: historyPos,
+			index:      slices.Index(e.yankHistory, *e.pasted),
+		}
+	}
 
 	// Update derived state AFTER handling key
+	e.updatePendingKeys(key)
 	e.ScrollViewport() // Ensure cursor is visible after potential movement
 
 	return err
@@ -400,6 +652,75 @@ func (e *editor) InsertCompletion(completion Completion) error {
 	return nil
 }
 
+// CommitText inserts text at the cursor as one atomic change, as needed when an
+// input method commits a composed string. It is only valid in insert mode.
+func (e *editor) CommitText(text string) *EditorError {
+	return e.InsertText(text)
+}
+
+// InsertText inserts text at the cursor as one change and leaves the cursor
+// after it, so that a terminal paste is not typed character by character.
+// Carriage returns are taken as line breaks. It is only valid in insert mode.
+func (e *editor) InsertText(text string) *EditorError {
+	e.enter()
+	defer e.leave()
+
+	return e.checkedUserEdit(func() *EditorError {
+		return e.insertText(text)
+	})
+}
+
+// insertText inserts text for InsertText.
+func (e *editor) insertText(text string) *EditorError {
+	if !e.IsInsertMode() {
+		return &EditorError{
+			id:  ErrInvalidModeId,
+			err: ErrInvalidMode,
+		}
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	if err := e.fillToCursor(); err != nil {
+		return &EditorError{
+			id:  ErrInvalidPositionId,
+			err: err,
+		}
+	}
+
+	cursor := e.buffer.GetCursor()
+	e.preChangeCursor = cursor
+
+	runes := []rune(text)
+	if err := e.buffer.InsertRunesAt(cursor.Position.Row, cursor.Position.Col, runes); err != nil {
+		return &EditorError{
+			id:  ErrInvalidPositionId,
+			err: err,
+		}
+	}
+
+	// Place the cursor after the inserted text
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		cursor.Position.Col += len(runes)
+	} else {
+		cursor.Position.Row += len(lines) - 1
+		cursor.Position.Col = len([]rune(lines[len(lines)-1]))
+	}
+	cursor.Preferred = cursor.Position.Col % max(e.state.AvailableWidth, 1)
+	e.buffer.SetCursor(cursor)
+
+	e.SaveHistory()
+	e.ScrollViewport()
+
+	return nil
+}
+
 // buildCompletionContext creates a CompletionContext from current editor state
 func (e *editor) buildCompletionContext(triggerKind CompletionTriggerKind, triggerChar string) CompletionContext {
 	cursor := e.buffer.GetCursor()
@@ -451,6 +772,7 @@ func (e *editor) buildCompletionContext(triggerKind CompletionTriggerKind, trigg
 
 func (e *editor) GetState() State {
 	// Ensure cursor pos in state reflects buffer (optional, state doesn't store it now)
+	e.collectAsyncSearch()
 	return e.state
 }
 
@@ -459,6 +781,31 @@ func (e *editor) SetState(state State) {
 	e.state = state
 }
 
+// SetViewportSize sets the size of the view the host draws the editor in, and
+// the width left in it for the text, which moving up and down and wrapping
+// depend on.
+func (e *editor) SetViewportSize(width, height, availableWidth int) {
+	e.state.ViewportWidth = width
+	e.state.ViewportHeight = height
+	e.state.AvailableWidth = availableWidth
+}
+
+// modeStatus returns the status line of the current mode, as the mode sets
+// it on entering.
+func (e *editor) modeStatus() string {
+	switch e.state.Mode {
+	case NormalMode:
+		return e.Message(MsgNormalStatus)
+	case InsertMode:
+		return e.Message(MsgInsertStatus)
+	case VisualMode:
+		return e.Message(MsgVisualStatus)
+	case VisualLineMode:
+		return e.Message(MsgVisualLineStatus)
+	}
+	return fmt.Sprintf("-- %s --", e.state.Mode)
+}
+
 // UpdateStatus is a helper for modes to update the status line
 func (e *editor) UpdateStatus(status string) {
 	e.state.StatusLine = status
@@ -470,24 +817,91 @@ func (e *editor) UpdateCommand(cmd string) {
 		e.DispatchSignal(CommandSignal{})
 	}
 	e.state.CommandLine = cmd
+	e.state.CommandCursor = -1
+}
+
+// SetCommandCursor shows a cursor at col, a rune index in the command line,
+// until the line next changes.
+func (e *editor) SetCommandCursor(col int) {
+	e.state.CommandCursor = col
 }
 
 // ExecuteCommand executes a command string (typically entered in command mode)
 func (e *editor) ExecuteCommand(cmd string) *EditorError {
+	e.enter()
+	defer e.leave()
+
+	return e.localiseError(e.executeCommand(cmd))
+}
+
+// executeCommand runs cmd for ExecuteCommand.
+func (e *editor) executeCommand(cmd string) *EditorError {
 	cmd = strings.TrimSpace(cmd)
 	if cmd == "" {
 		return nil
 	}
 
+	// A range is taken by :d, :y, :w, :s and :g, as in :2,5w part.txt; a
+	// range on its own jumps to its last line, as :10 and :'a do
+	var lines *lineRange
+	if r, rest, found, err := e.rangeParser().parse(cmd); found {
+		if err != nil {
+			return err
+		}
+		if rest = strings.TrimSpace(rest); rest == "" {
+			e.jumpToLine(r.end)
+			return nil
+		}
+		if err := r.check(e.buffer); err != nil {
+			return err
+		}
+		lines = &r
+		cmd = rest
+	}
+
+	// :s and :g take patterns, which may have spaces
+	if command, args, found := cutPatternCommand(cmd); found {
+		switch command {
+		case "s", "substitute":
+			return e.executeSubstitute(lines, args)
+		default:
+			invert := command == "g!" || command == "global!" || command == "v" || command == "vglobal"
+			return e.executeGlobal(lines, invert, args)
+		}
+	}
+
 	parts := strings.Fields(cmd)
 	command := parts[0]
 	args := parts[1:]
 
-	// TODO: Add range parsing (e.g., :%s/foo/bar/g)
+	// :w>>file appends like :w >> file
+	if i := strings.Index(command, ">>"); i > 0 {
+		args = append([]string{command[i:]}, args...)
+		command = command[:i]
+	}
+
+	// :d3 takes a count like :d 3
+	if i := strings.IndexAny(command, "0123456789"); i > 0 && takesRange(command[:i]) && !isWriteCommand(command[:i]) {
+		args = append([]string{command[i:]}, args...)
+		command = command[:i]
+	}
+
+	if lines != nil && !takesRange(command) {
+		return &EditorError{
+			id:  ErrInvalidCommandId,
+			err: ErrInvalidCommand,
+		}
+	}
+
+	if isFileCommand(command) && !e.allow(FileOps) {
+		return notAllowed(FileOps)
+	}
 
 	switch command {
 	case "q", "quit":
 		if e.buffer.IsModified() {
+			// The host may ask whether to save, quit anyway or cancel
+			e.DispatchSignal(ConfirmQuitSignal{})
 			return &EditorError{
 				id:  ErrUnsavedChangesId,
 				err: ErrUnsavedChanges,
@@ -502,113 +916,101 @@ func (e *editor) ExecuteCommand(cmd string) *EditorError {
 		e.Quit()
 		return nil
 
-	case "w", "write":
-		// If a path is provided, use it; else nil to indicate current file
-		// TODO: Improve file handling
+	case "e!", "edit!":
+		// Drop the unsaved changes and reload the file
 		if len(args) > 0 {
-			path := args[0]
-			e.Save(&path)
-		} else {
-			if !e.buffer.IsModified() {
-				return &EditorError{
-					id:  ErrNoChangesToSaveId,
-					err: ErrNoChangesToSave,
-				}
+			return &EditorError{
+				id:  ErrInvalidCommandId,
+				err: ErrInvalidCommand,
 			}
-
-			e.Save(nil)
 		}
-
+		e.revert()
 		return nil
 
+	case "w", "write":
+		// If a path is provided, use it; else nil to indicate current file
+		return e.executeWrite(false, lines, args)
+
+	case "w!", "write!":
+		// Save even if the content is unchanged or invalid bytes were replaced
+		return e.executeWrite(true, lines, args)
+
 	case "wq":
 		// Placeholder: write then quit
-		err := e.ExecuteCommand("w")
+		err := e.executeCommand("w")
 		if err != nil {
 			return err // Error during write
 		}
-		return e.ExecuteCommand("q") // Attempt quit
+		return e.executeCommand("q") // Attempt quit
 
 	case "wq!":
-		err := e.ExecuteCommand("w")
+		err := e.executeCommand("w")
 		if err != nil {
 			return err
 		}
-		return e.ExecuteCommand("q!") // Force quit
+		return e.executeCommand("q!") // Force quit
 
 	case "x", "xit":
 		// Write only if modified, then quit
 		if e.buffer.IsModified() {
-			err := e.ExecuteCommand("w")
+			err := e.executeCommand("w")
 			if err != nil {
 				return err
 			}
 		}
-		return e.ExecuteCommand("q")
-
-		// Add more commands: e, edit, r, read, s, substitute etc.
-		// case "s": return e.executeSubstitute(args)
-
-	case "set": // Handle basic set commands
-		if len(args) == 1 {
-			switch args[0] {
-			case "relativenumber", "rnu":
-				e.state.RelativeNumbers = true
-				e.DispatchSignal(RelativeNumbersSignal{enabled: true})
-				return nil
-			case "norelativenumber", "nornu":
-				e.state.RelativeNumbers = false
-				e.DispatchSignal(RelativeNumbersSignal{enabled: false})
-				return nil
-				// Add 'number'/'nonu' later if needed
-			}
-		}
-		return &EditorError{
-			id:  ErrInvalidCommandId,
-			err: ErrInvalidCommand,
-		}
+		return e.executeCommand("q")
 
-	case "rename":
-		if len(args) != 1 {
+	case "set": // Handle basic set commands, any number of them
+		if len(args) == 0 {
 			return &EditorError{
-				id:  ErrRenameFailedId,
-				err: ErrRenameFailed,
+				id:  ErrInvalidCommandId,
+				err: ErrInvalidCommand,
+			}
+		}
+		for _, arg := range splitOptions(strings.TrimPrefix(cmd, command)) {
+			if err := e.setOption(arg); err != nil {
+				return err
 			}
 		}
+		return nil
 
-		e.DispatchSignal(RenameSignal{
-			fileName: args[0],
-		})
+	case "rename", "rename!":
+		return e.executeRename(command == "rename!", args)
 
-		return nil
+	case "saveas", "sav", "saveas!", "sav!":
+		return e.executeSaveAs(strings.HasSuffix(command, "!"), args)
+
+	case "TableAlign", "TableAddRow", "TableDeleteRow", "TableAddColumn", "TableDeleteColumn":
+		return e.executeTableCommand(command)
 
-	case "delete", "del":
-		e.DispatchSignal(DeleteFileSignal{})
+	case "f", "file":
+		// A name sets the file name, as for a new file
+		if len(args) > 0 {
+			e.buffer.SetFileName(strings.Join(args, " "))
+		}
+		e.UpdateCommand(e.FileInfo())
 		return nil
 
+	case "d", "delete", "del", "delete!", "del!":
+		return e.executeDeleteCommand(command, lines, args)
+
+	case "format":
+		return e.format()
+
+	case "y", "yank":
+		return e.executeLineOperator("yank", lines, args)
+
 	default:
-		// Handle line number navigation (e.g., ":10")
-		lineNum := -1
-		_, scanErr := fmt.Sscan(command, &lineNum)
-		if scanErr == nil && lineNum > 0 {
-			targetRow := lineNum - 1 // User enters 1-based, we use 0-based
-			cursor := e.buffer.GetCursor()
-			// Clamp targetRow
-			if targetRow >= e.buffer.LineCount() {
-				targetRow = e.buffer.LineCount() - 1
-			}
-			if targetRow < 0 {
-				targetRow = 0
-			}
+		if handler, ok := e.commands[command]; ok {
+			var err *EditorError
+			e.callHost(func() { err = handler(e, args) })
+			return err
+		}
 
-			cursor.Position.Row = targetRow
-			cursor.Position.Col = 0 // Move to start of that line
-			// Try moving to first non-blank instead?
-			// cursor.MoveToFirstNonBlank(e.buffer)
-			e.buffer.SetCursor(cursor)
-			e.ScrollViewport() // Ensure jumped-to line is visible
-			return nil
+		if handled, err := e.executeQuickfixCommand(command, args); handled {
+			return err
 		}
+
 		return &EditorError{
 			id:  ErrInvalidCommandId,
 			err: ErrInvalidCommand,
@@ -616,23 +1018,121 @@ func (e *editor) ExecuteCommand(cmd string) *EditorError {
 	}
 }
 
+// jumpToLine moves the cursor to the start of row, moved into the buffer,
+// as a range on its own does.
+func (e *editor) jumpToLine(row int) {
+	cursor := e.buffer.GetCursor()
+	cursor.Position.Row = max(0, min(row, e.buffer.LineCount()-1))
+	cursor.Position.Col = 0
+	e.buffer.SetCursor(cursor)
+	e.ScrollViewport() // Ensure jumped-to line is visible
+}
+
+// RegisterCommand adds a command that can be run from command mode.
+// Built-in commands take precedence over registered ones.
+func (e *editor) RegisterCommand(name string, handler CommandHandler) {
+	if handler == nil {
+		delete(e.commands, name)
+		return
+	}
+	e.commands[name] = handler
+}
+
 func (e *editor) ExecuteSearch(pattern string, searchOptions SearchOptions) {
+	e.enter()
+	defer e.leave()
+
+	e.search(pattern, e.buffer.GetCursor().Position, searchOptions)
+
+	// Set after leaving search mode, since entering a mode clears the command line
+	e.setMode(e.state.PreviousMode)
+	e.UpdateCommand("/" + e.state.SearchQuery.Pattern)
+	e.dispatchSearchResults()
+}
+
+// SearchWordUnderCursor searches for the whole word under the cursor, or the
+// next word on the line, like Vim's * and # (backwards). The case options of
+// the last search are kept, without smart case, and the search wraps.
+func (e *editor) SearchWordUnderCursor(backwards bool) *EditorError {
+	cursor := e.buffer.GetCursor()
+	line := e.buffer.GetLineRunes(cursor.Position.Row)
+
+	start := cursor.Position.Col
+	for start < len(line) && !e.IsWordChar(line[start]) {
+		start++
+	}
+	if start >= len(line) {
+		return e.localiseError(&EditorError{
+			id:  ErrCharNotFoundId,
+			err: newNotice(MsgNoStringUnderCursor),
+		})
+	}
+	for start > 0 && e.IsWordChar(line[start-1]) {
+		start--
+	}
+	end := start
+	for end < len(line) && e.IsWordChar(line[end]) {
+		end++
+	}
+
+	options := e.state.SearchOptions
+	options.SmartCase = false
+	options.Backwards = backwards
+	options.Wrap = true
+
+	// Searching from the start of the word skips it going forwards and
+	// backwards
+	pattern := "\\<" + string(line[start:end]) + "\\>"
+	e.search(pattern, Position{Row: cursor.Position.Row, Col: start}, options)
+
+	e.UpdateCommand("/" + pattern)
+	e.ScrollViewport()
+	e.dispatchSearchResults()
+	return nil
+}
+
+// search looks for pattern from start and moves the cursor to the first
+// match.
+func (e *editor) search(pattern string, start Position, searchOptions SearchOptions) {
+	e.setSearchQuery(pattern, searchOptions)
+
+	// Find the first result
+	pos, found := e.buffer.Find(e.state.SearchQuery.Term, start, e.state.SearchOptions)
+
+	if found {
+		e.onSearchResultFound(pos)
+	} else {
+		e.state.SearchResults = []Position{}
+		e.state.SearchResultIndex = -1
+	}
+}
+
+// setSearchQuery makes pattern the current search, cancelling a search
+// running in the background. A \c or \C suffix on pattern overrides the
+// case options.
+func (e *editor) setSearchQuery(pattern string, searchOptions SearchOptions) {
+	e.cancelAsyncSearch()
+	e.searchRevision = 0
 	e.state.SearchQuery.Pattern = pattern
 	query := pattern
 
 	ignoreCase := searchOptions.IgnoreCase
 	smartCase := searchOptions.SmartCase
 
+	// \c and \C at the end of the pattern force ignoring or matching case
 	if before, ok := strings.CutSuffix(pattern, "\\c"); ok {
-		pattern = before
+		query = before
 		ignoreCase = true
 		smartCase = false
-		query = strings.TrimRight(pattern, "\\c")
-	} else if before0, ok0 := strings.CutSuffix(pattern, "\\C"); ok0 {
-		pattern = before0
+	} else if before, ok := strings.CutSuffix(pattern, "\\C"); ok {
+		query = before
 		ignoreCase = false
-		searchOptions.SmartCase = true
-		query = strings.TrimRight(pattern, "\\C")
+		smartCase = false
+	}
+
+	isWordChar := searchOptions.IsWordChar
+	if isWordChar == nil {
+		isWordChar = e.IsWordChar
 	}
 
 	e.state.SearchQuery.Term = query
@@ -641,34 +1141,16 @@ func (e *editor) ExecuteSearch(pattern string, searchOptions SearchOptions) {
 		SmartCase:  smartCase,
 		Backwards:  searchOptions.Backwards,
 		Wrap:       searchOptions.Wrap,
+		WholeWord:  searchOptions.WholeWord,
+		IsWordChar: isWordChar,
 	}
-
-	// Find the first result
-	pos, found := e.buffer.Find(query, e.buffer.GetCursor().Position, e.state.SearchOptions)
-
-	if !found && e.state.SearchOptions.Wrap {
-		pos, found = e.buffer.Find(query, Position{Row: 0, Col: 0}, e.state.SearchOptions)
-	}
-
-	if found {
-		e.state.SearchResults = []Position{pos}
-		e.state.SearchResultIndex = 0
-		cursor := e.buffer.GetCursor()
-		cursor.Position = pos
-		e.buffer.SetCursor(cursor)
-	} else {
-		e.state.SearchResults = []Position{}
-		e.state.SearchResultIndex = -1
-	}
-
-	e.UpdateCommand("/" + e.state.SearchQuery.Pattern)
-	e.setMode(e.state.PreviousMode)
-	e.DispatchSignal(SearchResultsSignal{positions: e.state.SearchResults})
 }
 
 func (e *editor) CancelSearch() {
+	e.cancelAsyncSearch()
 	e.state.SearchQuery = SearchQuery{}
 	e.state.SearchResults = []Position{}
+	e.searchRevision = 0
 	e.setMode(e.state.PreviousMode)
 }
 
@@ -683,17 +1165,13 @@ func (e *editor) NextSearchResult() Cursor {
 	currentPos := e.buffer.GetCursor().Position
 	pos, found := e.buffer.Find(e.state.SearchQuery.Term, currentPos, options)
 
-	// If not found and wrap is enabled, search from beginning
-	if !found && options.Wrap {
-		pos, found = e.buffer.Find(e.state.SearchQuery.Term, Position{Row: 0, Col: 0}, options)
-	}
-
 	if found {
 		e.onSearchResultFound(pos)
+		e.UpdateCommand("/" + e.state.SearchQuery.Pattern)
 		e.ScrollViewport()
 	}
 
-	e.DispatchSignal(SearchResultsSignal{positions: e.state.SearchResults})
+	e.dispatchSearchResults()
 
 	return e.buffer.GetCursor()
 }
@@ -710,35 +1188,44 @@ func (e *editor) PreviousSearchResult() Cursor {
 	currentPos := e.buffer.GetCursor().Position
 	pos, found := e.buffer.Find(e.state.SearchQuery.Term, currentPos, options)
 
-	// If not found and wrap is enabled, search from end
-	if !found && e.state.SearchOptions.Wrap && e.buffer.LineCount() > 0 {
-		lastLine := e.buffer.LineCount() - 1
-		lastLineLen := e.buffer.LineRuneCount(lastLine)
-		pos, found = e.buffer.Find(e.state.SearchQuery.Term, Position{Row: lastLine, Col: lastLineLen}, options)
-	}
-
 	if found {
 		e.onSearchResultFound(pos)
+		e.UpdateCommand("/" + e.state.SearchQuery.Pattern)
 		e.ScrollViewport()
 	}
 
-	e.DispatchSignal(SearchResultsSignal{positions: e.state.SearchResults})
+	e.dispatchSearchResults()
 
 	return e.buffer.GetCursor()
 }
 
 func (e *editor) SearchResults() []Position {
+	e.collectAsyncSearch()
 	return e.state.SearchResults
 }
 
+// onSearchResultFound moves the cursor to pos and finds the matches again if
+// the buffer has been edited since they were found, so the index stays
+// correct without searching the whole buffer on every n and N.
 func (e *editor) onSearchResultFound(pos Position) {
-	e.state.SearchResults = []Position{pos}
-	e.state.SearchResultIndex = 0
+	if revision := e.buffer.Revision(); e.searchRevision != revision {
+		e.state.SearchResults = e.buffer.FindAll(e.state.SearchQuery.Term, e.state.SearchOptions)
+		e.searchRevision = revision
+	}
+	e.state.SearchResultIndex = slices.Index(e.state.SearchResults, pos)
 	cursor := e.buffer.GetCursor()
 	cursor.Position = pos
 	e.buffer.SetCursor(cursor)
 }
 
+func (e *editor) dispatchSearchResults() {
+	e.DispatchSignal(SearchResultsSignal{
+		positions: e.state.SearchResults,
+		index:     e.state.SearchResultIndex,
+		done:      true,
+	})
+}
+
 // ScrollViewport ensures the cursor is within the visible area
 func (e *editor) ScrollViewport() {
 	cursor := e.buffer.GetCursor()
@@ -758,41 +1245,76 @@ func (e *editor) ScrollViewport() {
 }
 
 // --- History Management (Simple Snapshot Implementation) ---
+
+// historyEntry is a snapshot of the buffer content with the cursor on either
+// side of the change that led to it.
+type historyEntry struct {
+	content  string
+	before   Cursor          // Cursor before the change, restored when it is undone
+	after    Cursor          // Cursor after the change, restored when it is redone
+	encoding *encodingChange // Encoding the change set, nil if it kept it
+}
+
+// encodingChange is a change of the encoding the content is saved in, which
+// leaves the content as it is.
+type encodingChange struct {
+	before, after Encoding
+}
+
 func (e *editor) SaveHistory() {
+	// A transaction saves its edits as one change when it ends
+	if e.InTransaction() {
+		return
+	}
+
 	currentState := e.buffer.GetCurrentContent()
 	currentCursor := e.buffer.GetCursor()
 
 	// If we used Undo, truncate the future history
 	if e.historyPos < len(e.history)-1 {
 		e.history = e.history[:e.historyPos+1]
-		e.cursorHistory = e.cursorHistory[:e.historyPos+1]
+	}
+
+	// The text typed after c replaces what the change left, so that undo
+	// brings back what was there before it
+	if e.joiningChange && e.historyPos > 0 && e.historyPos == len(e.history)-1 {
+		entry := &e.history[e.historyPos]
+		if entry.content != currentState {
+			e.trackLineChange(entry.content, currentState)
+			entry.content = currentState
+		}
+		entry.after = currentCursor
+		return
 	}
 
 	// Avoid saving duplicate state if no changes occurred
-	if len(e.history) > 0 && e.historyPos >= 0 && e.historyPos < len(e.history) {
-		if e.history[e.historyPos] == currentState {
-			// Even if content is the same, update cursor position if it changed
-			if e.historyPos < len(e.cursorHistory) {
-				savedCursor := e.cursorHistory[e.historyPos]
-				if savedCursor.Position.Row != currentCursor.Position.Row ||
-					savedCursor.Position.Col != currentCursor.Position.Col {
-					e.cursorHistory[e.historyPos] = currentCursor
-				}
-			}
+	if e.historyPos >= 0 && e.historyPos < len(e.history) {
+		if e.history[e.historyPos].content == currentState {
+			// The change may still be moving the cursor, as c does before
+			// entering insert mode
+			e.history[e.historyPos].after = currentCursor
 			return
 		}
+		e.trackLineChange(e.history[e.historyPos].content, currentState)
 	}
 
-	// Before appending the new state, record the pre-change cursor in the current slot
-	// so that Undo can restore the cursor to where it was before this change.
-	if e.historyPos >= 0 && e.historyPos < len(e.cursorHistory) {
-		e.cursorHistory[e.historyPos] = e.preChangeCursor
-	}
+	e.appendHistory(currentState, nil)
+}
 
-	// Add the new state
-	e.history = append(e.history, currentState)
-	e.cursorHistory = append(e.cursorHistory, currentCursor)
+// appendHistory adds the current state to the history as a change after the
+// changes not undone, dropping the oldest ones beyond the limit.
+func (e *editor) appendHistory(content string, encoding *encodingChange) {
+	if e.historyPos < len(e.history)-1 {
+		e.history = e.history[:e.historyPos+1]
+	}
+	e.history = append(e.history, historyEntry{
+		content:  content,
+		before:   e.preChangeCursor,
+		after:    e.buffer.GetCursor(),
+		encoding: encoding,
+	})
 	e.historyPos = len(e.history) - 1
+	e.historySaved = true
 
 	maxHistory := int(e.maxHistory)
 
@@ -800,68 +1322,180 @@ func (e *editor) SaveHistory() {
 	if len(e.history) > maxHistory {
 		// Remove the oldest entry
 		e.history = e.history[len(e.history)-maxHistory:]
-		e.cursorHistory = e.cursorHistory[len(e.cursorHistory)-maxHistory:]
 		e.historyPos = len(e.history) - 1
 	}
+	e.trace("history", "position", e.historyPos, "entries", len(e.history))
+}
+
+// setEncoding sets the encoding the content is saved in as a change of its
+// own, which u undoes as it undoes setting the line ending.
+func (e *editor) setEncoding(enc Encoding) {
+	before := e.buffer.Encoding()
+	if enc == before {
+		return
+	}
+	e.buffer.SetEncoding(enc)
+	e.preChangeCursor = e.buffer.GetCursor()
+	e.appendHistory(e.buffer.GetCurrentContent(), &encodingChange{before, enc})
+}
+
+// joinChange makes the edits of the insert mode entered next part of the
+// change just saved, until insert mode is left.
+func (e *editor) joinChange() {
+	e.joiningChange = true
 }
 
 func (e *editor) Undo() (string, error) {
+	e.enter()
+	defer e.leave()
+
+	return e.undo()
+}
+
+// undo undoes the latest change, for Undo and the keys that undo.
+func (e *editor) undo() (string, error) {
+	e.joiningChange = false
 	if e.historyPos <= 0 {
-		return "", errors.New("already at oldest change")
+		return "", e.localise(newNotice(MsgOldestChange))
 	}
 
 	currentStateContent := e.buffer.GetCurrentContent()
 
+	// Restore the cursor to where it was before the change, not where it ended up after it
+	change := e.history[e.historyPos]
 	e.historyPos--
-	prevStateContent := e.history[e.historyPos]
-	// Restore the cursor to where it was in the previous state, not where it ended up after the change.
-	changeCursor := e.cursorHistory[e.historyPos]
-
+	prevStateContent := e.history[e.historyPos].content
 	if prevStateContent == "" {
 		prevStateContent = "\n"
 	}
+	e.restoreHistory(prevStateContent, change.before)
+	if change.encoding != nil {
+		e.buffer.SetEncoding(change.encoding.before)
+	}
 
-	e.buffer.SetContent([]byte(prevStateContent))
+	return currentStateContent, nil
+}
 
-	// Jump to where the change happened, clamped to the restored content bounds
-	lineCount := e.buffer.LineCount()
-	if changeCursor.Position.Row >= lineCount {
-		changeCursor.Position.Row = max(0, lineCount-1)
+func (e *editor) Redo() (string, error) {
+	e.enter()
+	defer e.leave()
+
+	return e.redo()
+}
+
+// redo redoes the latest change undone, for Redo and the keys that redo.
+func (e *editor) redo() (string, error) {
+	if e.historyPos >= len(e.history)-1 {
+		return "", e.localise(newNotice(MsgNewestChange))
 	}
-	lineLen := e.buffer.LineRuneCount(changeCursor.Position.Row)
-	if changeCursor.Position.Col > lineLen {
-		changeCursor.Position.Col = lineLen
+
+	currentContent := e.buffer.GetCurrentContent()
+
+	e.historyPos++
+	entry := e.history[e.historyPos]
+	e.restoreHistory(entry.content, entry.after)
+	if entry.encoding != nil {
+		e.buffer.SetEncoding(entry.encoding.after)
 	}
-	e.buffer.SetCursor(changeCursor)
+
+	return currentContent, nil
+}
+
+// restoreHistory sets the buffer content of a history entry and puts the
+// cursor back, kept inside the restored content.
+func (e *editor) restoreHistory(content string, cursor Cursor) {
+	e.buffer.SetContent([]byte(content))
+
+	lineCount := e.buffer.LineCount()
+	cursor.Position.Row = max(0, min(cursor.Position.Row, lineCount-1))
+	cursor.Position.Col = max(0, min(cursor.Position.Col, e.buffer.LineRuneCount(cursor.Position.Row)))
+	e.buffer.SetCursor(cursor)
 
 	e.ScrollViewport()
+}
 
-	return currentStateContent, nil
+// lineUndo is the line U restores: the last changed line as it was before the
+// latest run of changes to it.
+type lineUndo struct {
+	valid    bool
+	row      int
+	original string
 }
 
-func (e *editor) Redo() (string, error) {
-	if e.historyPos >= len(e.history)-1 {
-		return "", errors.New("already at newest change")
+// trackLineChange updates the line restored by U after the content changed
+// from before to after. A change to a single line keeps the line's original
+// text while the changes stay on it; a change to several lines forgets it.
+func (e *editor) trackLineChange(before, after string) {
+	sep := e.buffer.LineEnding().Sequence()
+	beforeLines := strings.Split(before, sep)
+	afterLines := strings.Split(after, sep)
+
+	if len(beforeLines) != len(afterLines) {
+		e.undoLine = lineUndo{}
+		return
+	}
+
+	row := -1
+	for i := range beforeLines {
+		if beforeLines[i] == afterLines[i] {
+			continue
+		}
+		if row != -1 {
+			e.undoLine = lineUndo{}
+			return
+		}
+		row = i
+	}
+
+	if row == -1 || (e.undoLine.valid && e.undoLine.row == row) {
+		return
+	}
+	e.undoLine = lineUndo{valid: true, row: row, original: beforeLines[row]}
+}
+
+// UndoLine undoes all the latest changes on the last changed line (U). It is a
+// change itself, so U again redoes them and u undoes it.
+func (e *editor) UndoLine() (string, error) {
+	line := e.undoLine
+	if !line.valid || line.row >= e.buffer.LineCount() {
+		return "", e.localise(newNotice(MsgNoLineToUndo))
 	}
 
 	currentContent := e.buffer.GetCurrentContent()
+	current := e.buffer.GetLineRunes(line.row)
+	if string(current) == line.original {
+		return currentContent, nil
+	}
 
-	e.historyPos++
-	nextStateContent := e.history[e.historyPos]
-	nextCursor := e.cursorHistory[e.historyPos]
+	if err := e.buffer.DeleteRunesAt(line.row, 0, len(current)); err != nil {
+		return "", err.Error()
+	}
+	if err := e.buffer.InsertRunesAt(line.row, 0, []rune(line.original)); err != nil {
+		return "", err
+	}
 
-	e.buffer.SetContent([]byte(nextStateContent))
-	e.buffer.SetCursor(nextCursor)
+	cursor := e.buffer.GetCursor()
+	cursor.Position = Position{Row: line.row}
+	e.buffer.SetCursor(cursor)
+	e.SaveHistory()
+
+	// U again restores the text it replaced
+	e.undoLine = lineUndo{valid: true, row: line.row, original: string(current)}
 
 	e.ScrollViewport()
 
 	return currentContent, nil
 }
 
+// SetRedoOnU makes U redo, as Ctrl-R does, instead of undoing the line.
+func (e *editor) SetRedoOnU(enabled bool) {
+	e.state.RedoOnU = enabled
+}
+
 func (e *editor) Paste() (string, error) {
 	content, err := e.clipboard.Read()
 	if err != nil {
-		return "", fmt.Errorf("failed to read clipboard: %w", err)
+		return "", newNotice(MsgClipboardRead, err)
 	}
 
 	cursor := e.buffer.GetCursor()
@@ -878,12 +1512,34 @@ func (e *editor) Paste() (string, error) {
 		cursor.Position.Row++
 		cursor.Position.Col = 0
 		e.buffer.SetCursor(cursor)
+		e.SaveHistory()
+		e.DispatchSignal(PasteSignal{newRegisterText(content, cursor.Position, true)})
+		e.pasted = &content
 	} else {
 		// Character-wise paste: insert AFTER the cursor char — matching Vim's 'p' behaviour.
-		e.buffer.InsertRunesAt(cursor.Position.Row, cursor.Position.Col+1, []rune(content))
+		start := Position{Row: cursor.Position.Row, Col: min(cursor.Position.Col+1, e.buffer.LineRuneCount(cursor.Position.Row))}
+		e.buffer.InsertRunesAt(start.Row, start.Col, []rune(content))
+		e.SaveHistory()
+		e.DispatchSignal(PasteSignal{newRegisterText(content, start, false)})
+		e.pasted = &content
 	}
 
-	e.SaveHistory()
+	return content, nil
+}
+
+// PasteInsert inserts the clipboard content at the cursor in insert mode, as
+// Ctrl-R " does, and leaves the cursor after it.
+func (e *editor) PasteInsert() (string, error) {
+	content, err := e.clipboard.Read()
+	if err != nil {
+		return "", newNotice(MsgClipboardRead, err)
+	}
+
+	start := e.buffer.GetCursor().Position
+	if err := e.insertText(content); err != nil {
+		return "", err.Error()
+	}
+	e.DispatchSignal(PasteSignal{newRegisterText(content, start, strings.HasSuffix(content, "\n"))})
 
 	return content, nil
 }
@@ -891,7 +1547,7 @@ func (e *editor) Paste() (string, error) {
 func (e *editor) PasteBefore() (string, error) {
 	content, err := e.clipboard.Read()
 	if err != nil {
-		return "", fmt.Errorf("failed to read clipboard: %w", err)
+		return "", newNotice(MsgClipboardRead, err)
 	}
 
 	cursor := e.buffer.GetCursor()
@@ -908,6 +1564,95 @@ func (e *editor) PasteBefore() (string, error) {
 	}
 
 	e.SaveHistory()
+	e.DispatchSignal(PasteSignal{newRegisterText(content, cursor.Position, strings.HasSuffix(content, "\n"))})
+	e.pasted = &content
+
+	return content, nil
+}
+
+// PasteOverSelection replaces the visual selection with the clipboard content
+// as a single change, and puts the replaced text on the clipboard (visual p).
+//
+// Line-wise content replaces whole lines in visual line mode and goes on lines
+// of its own in visual mode; character-wise content pasted over lines also gets
+// lines of its own.
+func (e *editor) PasteOverSelection() (string, error) {
+	content, err := e.clipboard.Read()
+	if err != nil {
+		return "", newNotice(MsgClipboardRead, err)
+	}
+
+	sel := currentVisualSelection(e.state.Mode, e.buffer, e.state.VisualStart)
+	r := sel.textRange(e.buffer)
+	cursor := e.buffer.GetCursor()
+
+	var replaced string
+	var pasted registerText
+	if r.linewise {
+		var lines strings.Builder
+		for row := r.start.Row; row <= r.end.Row; row++ {
+			lines.WriteString(string(e.buffer.GetLineRunes(row)))
+			lines.WriteString("\n")
+		}
+		replaced = lines.String()
+
+		text := strings.TrimSuffix(content, "\n")
+		lastRow := e.buffer.LineCount() - 1
+		switch {
+		case r.end.Row < lastRow:
+			_ = deleteRange(e.buffer, Position{Row: r.start.Row}, Position{Row: r.end.Row + 1})
+			e.buffer.InsertRunesAt(r.start.Row, 0, []rune(text+"\n"))
+		case r.start.Row > 0:
+			prevLen := e.buffer.LineRuneCount(r.start.Row - 1)
+			_ = deleteRange(e.buffer, Position{Row: r.start.Row - 1, Col: prevLen}, Position{Row: r.end.Row, Col: e.buffer.LineRuneCount(r.end.Row)})
+			e.buffer.InsertRunesAt(r.start.Row-1, prevLen, []rune("\n"+text))
+		default:
+			_ = deleteRange(e.buffer, Position{}, Position{Row: r.end.Row, Col: e.buffer.LineRuneCount(r.end.Row)})
+			e.buffer.InsertRunesAt(0, 0, []rune(text))
+		}
+
+		pasted = newRegisterText(text+"\n", Position{Row: r.start.Row}, true)
+		cursor.Position = Position{Row: r.start.Row}
+		e.buffer.SetCursor(cursor)
+		cursor = e.buffer.GetCursor()
+		cursor.MoveToFirstNonBlank(e.buffer, e.state.AvailableWidth)
+	} else {
+		replaced = textInRange(e.buffer, r)
+		_ = deleteRange(e.buffer, r.start, r.end)
+
+		if before, ok := strings.CutSuffix(content, "\n"); ok {
+			// Line-wise content goes on lines of its own between the two halves
+			e.buffer.InsertRunesAt(r.start.Row, r.start.Col, []rune("\n"+before+"\n"))
+			cursor.Position = Position{Row: r.start.Row + 1}
+			pasted = newRegisterText(content, cursor.Position, true)
+		} else {
+			// The cursor ends on the last pasted character
+			e.buffer.InsertRunesAt(r.start.Row, r.start.Col, []rune(content))
+			pasted = newRegisterText(content, r.start, false)
+			cursor.Position = r.start
+			for _, c := range content {
+				if c == '\n' {
+					cursor.Position.Row++
+					cursor.Position.Col = 0
+				} else {
+					cursor.Position.Col++
+				}
+			}
+			cursor.Position.Col = max(cursor.Position.Col-1, 0)
+		}
+	}
+
+	e.buffer.SetCursor(cursor)
+	e.SaveHistory()
+	e.DispatchSignal(PasteSignal{pasted})
+
+	// The text replaced goes to the clipboard only if the user may copy
+	if !e.HasCapability(ClipboardWrite) {
+		return content, nil
+	}
+	if err := e.clipboard.Write(replaced); err != nil {
+		return content, newNotice(MsgClipboardWrite, err)
+	}
 
 	return content, nil
 }
@@ -915,7 +1660,7 @@ func (e *editor) PasteBefore() (string, error) {
 // Copy extracts text based on visual selection or current line and writes to clipboard.
 func (e *editor) Copy(op copyType) error {
 	if e.clipboard == nil {
-		return errors.New("clipboard handler not set")
+		return newNotice(MsgNoClipboard)
 	}
 
 	state := e.GetState() // Use local variable for state
@@ -929,6 +1674,8 @@ func (e *editor) Copy(op copyType) error {
 	if isVisual {
 		// Visual mode or yank from normal mode: use the selected range, normalize it
 		start, end = NormalizeSelection(state.VisualStart, cursor.Position)
+		// Include the whole grapheme cluster at the end of the selection
+		end.Col = graphemeEndCol(buffer.GetLineRunes(end.Row), end.Col)
 
 		// Check if the selection is line-wise
 		// Either from visual-line mode OR from YankSelection being set to SelectionLine
@@ -1007,20 +1754,18 @@ func (e *editor) Copy(op copyType) error {
 	}
 
 	// Write to the actual clipboard
+	if !e.allow(ClipboardWrite) {
+		return nil
+	}
 	if err := e.clipboard.Write(content); err != nil {
-		errMsg := fmt.Sprintf("failed to copy to clipboard: %v", err)
-		return errors.New(errMsg)
+		return newNotice(MsgClipboardWrite, err)
 	}
 
 	if op == cutType {
 		return nil
 	}
 
-	signal := YankSignal{
-		content: content,
-	}
-
-	e.DispatchSignal(signal)
+	e.DispatchSignal(YankSignal{newRegisterText(content, start, isLineWise)})
 
 	return nil
 }
@@ -1040,6 +1785,13 @@ func (e *editor) GetSelectionStatus(pos Position) SelectionType {
 
 	// Normalize selection range using the accessible function
 	selStart, selEnd := NormalizeSelection(state.VisualStart, cursor.Position)
+	if state.Mode == InsertMode {
+		// Without Vim mode the selection ends before the cursor
+		selEnd.Col--
+	} else {
+		// Include the whole grapheme cluster at the end of the selection
+		selEnd.Col = graphemeEndCol(buffer.GetLineRunes(selEnd.Row), selEnd.Col)
+	}
 
 	// Check if this is line-wise selection (either visual-line mode or yank line selection)
 	isLineWise := state.Mode == "visual-line" || state.YankSelection == SelectionLine
@@ -1066,9 +1818,37 @@ func (e *editor) GetSelectionStatus(pos Position) SelectionType {
 }
 
 func (e *editor) Save(path *string) {
-	e.buffer.SaveContent()
-	signal := SaveSignal{path: path, content: e.buffer.GetSavedContent()}
-	e.DispatchSignal(signal)
+	if err := e.formatBeforeSave(); err != nil {
+		e.DispatchError(err.ID(), err.Error())
+		return
+	}
+	e.save(path, nil, false)
+}
+
+// FileInfo describes the file like Vim's :file, e.g.
+// "main.go" [Modified] 120 lines --45%--
+func (e *editor) FileInfo() string {
+	name := e.buffer.FileName()
+	if name == "" {
+		name = e.Message(MsgNoName)
+	}
+
+	modified := ""
+	if e.buffer.IsModified() {
+		modified = e.Message(MsgModified)
+	}
+
+	lines := e.buffer.LineCount()
+	if e.buffer.HasTrailingNewline() {
+		lines--
+	}
+	unit := e.Message(MsgLines)
+	if lines == 1 {
+		unit = e.Message(MsgLine)
+	}
+
+	row := e.buffer.GetCursor().Position.Row
+	return e.Message(MsgFileInfo, name, modified, lines, unit, (row+1)*100/max(lines, 1))
 }
 
 func (e *editor) Quit() {
@@ -1085,10 +1865,7 @@ func (e *editor) IsWordChar(r rune) bool {
 }
 
 func (e *editor) ResetPendingCount() {
-	if e.state.PendingCount != nil {
-		e.state.PendingCount = nil
-		e.UpdateCommand("")
-	}
+	e.state.PendingCount = nil
 }
 
 func (e *editor) IsNormalMode() bool {
@@ -1115,6 +1892,44 @@ func (e *editor) IsSearchMode() bool {
 	return e.state.Mode == SearchMode
 }
 
+// HasPendingKeys reports whether keys typed so far in normal or visual mode
+// wait for more, such as a count, an operator or f waiting for its character.
+func (e *editor) HasPendingKeys() bool {
+	if e.state.PendingCount != nil {
+		return true
+	}
+	if mode, ok := e.currentMode.(interface{ isPending() bool }); ok {
+		return mode.isPending()
+	}
+	return false
+}
+
+// cancelPendingKeys drops the keys typed so far of a command waiting for
+// more, staying in the mode, as when they time out.
+func (e *editor) cancelPendingKeys() {
+	if mode, ok := e.currentMode.(interface{ clearPendingState(modeContext) }); ok {
+		mode.clearPendingState(e)
+	}
+	e.ResetPendingCount()
+	e.state.PendingKeys = ""
+}
+
+// updatePendingKeys adds key to the keys of the command being typed, or
+// clears them once the command has run or been cancelled.
+func (e *editor) updatePendingKeys(key KeyEvent) {
+	if !e.HasPendingKeys() {
+		e.state.PendingKeys = ""
+		return
+	}
+
+	switch {
+	case key.Rune != 0:
+		e.state.PendingKeys += string(key.Rune)
+	case key.Key >= KeyCtrlA && key.Key <= KeyCtrlZ:
+		e.state.PendingKeys += "^" + string(rune('A'+key.Key-KeyCtrlA))
+	}
+}
+
 func (e *editor) ResetSelection() {
 	state := e.GetState()
 	state.VisualStart = Position{Row: -1, Col: -1}
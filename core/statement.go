@@ -0,0 +1,212 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// StatementSyntax tells where the statements of a language end, for the is
+// and as text objects and the ]s and [s motions. Terminators in quotes and
+// comments don't end a statement.
+type StatementSyntax struct {
+	Terminator   rune      // Ends a statement, such as ;
+	LineComment  string    // Starts a comment up to the end of the line, such as --
+	BlockComment [2]string // Start and end of a block comment, such as /* and */
+	Quotes       string    // Characters that start and end quoted text
+}
+
+// SQLStatementSyntax returns the syntax of SQL statements, which end with ;.
+func SQLStatementSyntax() *StatementSyntax {
+	return &StatementSyntax{
+		Terminator:   ';',
+		LineComment:  "--",
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "'\"`",
+	}
+}
+
+// defaultStatementSyntaxes are the statement syntaxes of languages that have
+// one unless SetStatementSyntax changes them.
+var defaultStatementSyntaxes = map[string]func() *StatementSyntax{
+	"sql":        SQLStatementSyntax,
+	"mysql":      SQLStatementSyntax,
+	"postgresql": SQLStatementSyntax,
+	"postgres":   SQLStatementSyntax,
+	"plpgsql":    SQLStatementSyntax,
+	"tsql":       SQLStatementSyntax,
+}
+
+// SetStatementSyntax sets the syntax of the statements of language, for the
+// is and as text objects and the ]s and [s motions. nil turns them off for
+// the language. The SQL languages use SQLStatementSyntax by default.
+func (e *editor) SetStatementSyntax(language string, syntax *StatementSyntax) {
+	language = strings.ToLower(language)
+	if e.statementSyntaxes == nil {
+		e.statementSyntaxes = make(map[string]*StatementSyntax)
+	}
+	e.statementSyntaxes[language] = syntax
+	if language == e.language {
+		e.state.StatementSyntax = syntax
+	}
+}
+
+// statementSyntax returns the statement syntax of language, nil if it has
+// none.
+func (e *editor) statementSyntax(language string) *StatementSyntax {
+	if syntax, ok := e.statementSyntaxes[language]; ok {
+		return syntax
+	}
+	if syntax, ok := defaultStatementSyntaxes[language]; ok {
+		return syntax()
+	}
+	return nil
+}
+
+// statementSpan is a statement in the buffer's text, from the offset of its
+// first character outside whitespace and comments up to just after its
+// terminator.
+type statementSpan struct {
+	start, end int
+}
+
+// statements returns the statements of text, lines joined by \n. Text after
+// the last terminator is a statement too, up to its last character other
+// than whitespace.
+func (s *StatementSyntax) statements(text []rune) []statementSpan {
+	var spans []statementSpan
+	segment := 0
+	for i := 0; i < len(text); {
+		if end := s.skipComment(text, i); end > i {
+			i = end
+			continue
+		}
+		switch {
+		case strings.ContainsRune(s.Quotes, text[i]):
+			quote := text[i]
+			i++
+			for i < len(text) && text[i] != quote {
+				i++
+			}
+			i = min(i+1, len(text))
+		case text[i] == s.Terminator:
+			i++
+			spans = s.appendStatement(spans, text, segment, i)
+			segment = i
+		default:
+			i++
+		}
+	}
+	return s.appendStatement(spans, text, segment, len(text))
+}
+
+// skipComment returns the offset after the comment starting at offset i of
+// text, or i if none starts there.
+func (s *StatementSyntax) skipComment(text []rune, i int) int {
+	switch {
+	case hasPrefixAt(text, i, s.LineComment):
+		for i < len(text) && text[i] != '\n' {
+			i++
+		}
+	case hasPrefixAt(text, i, s.BlockComment[0]):
+		i += len([]rune(s.BlockComment[0]))
+		for i < len(text) && !hasPrefixAt(text, i, s.BlockComment[1]) {
+			i++
+		}
+		i = min(i+len([]rune(s.BlockComment[1])), len(text))
+	}
+	return i
+}
+
+// appendStatement appends the statement from start up to end to spans,
+// without the whitespace and comments before it or the whitespace after it.
+// A lone terminator isn't a statement.
+func (s *StatementSyntax) appendStatement(spans []statementSpan, text []rune, start, end int) []statementSpan {
+	for start < end {
+		if unicode.IsSpace(text[start]) {
+			start++
+		} else if next := s.skipComment(text, start); next > start {
+			start = next
+		} else {
+			break
+		}
+	}
+	for end > start && unicode.IsSpace(text[end-1]) {
+		end--
+	}
+	if start == end || end-start == 1 && text[start] == s.Terminator {
+		return spans
+	}
+	return append(spans, statementSpan{start: start, end: end})
+}
+
+// hasPrefixAt reports whether text has prefix at offset i. An empty prefix
+// never matches.
+func hasPrefixAt(text []rune, i int, prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	for _, r := range prefix {
+		if i >= len(text) || text[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// statementTextObjectRange returns the range of the is or as text object at
+// pos: the statement the cursor is in, or the next one when it's between
+// statements. 'a' includes the whitespace after the statement, or before it
+// if there is none after.
+func statementTextObjectRange(buffer Buffer, syntax *StatementSyntax, pos Position, modifier rune) (textRange, bool) {
+	text := []rune(strings.Join(buffer.GetLines(), "\n"))
+	spans := syntax.statements(text)
+	if len(spans) == 0 {
+		return textRange{}, false
+	}
+
+	offset := runeOffset(text, pos)
+	span := spans[len(spans)-1]
+	for _, candidate := range spans {
+		if offset < candidate.end {
+			span = candidate
+			break
+		}
+	}
+
+	start, end := span.start, span.end
+	if modifier == 'a' {
+		for end < len(text) && unicode.IsSpace(text[end]) {
+			end++
+		}
+		if end == span.end {
+			for start > 0 && unicode.IsSpace(text[start-1]) {
+				start--
+			}
+		}
+	}
+	return textRange{start: offsetPosition(text, start), end: offsetPosition(text, end)}, true
+}
+
+// statementStart returns the start of the count-th statement after pos, or
+// before it when backward, as far as there are statements.
+func statementStart(buffer Buffer, syntax *StatementSyntax, pos Position, count int, backward bool) (Position, bool) {
+	text := []rune(strings.Join(buffer.GetLines(), "\n"))
+	offset := runeOffset(text, pos)
+
+	var starts []int
+	for _, span := range syntax.statements(text) {
+		if backward && span.start < offset || !backward && span.start > offset {
+			starts = append(starts, span.start)
+		}
+	}
+	if len(starts) == 0 {
+		return pos, false
+	}
+
+	i := min(count, len(starts)) - 1
+	if backward {
+		i = len(starts) - 1 - i
+	}
+	return offsetPosition(text, starts[i]), true
+}
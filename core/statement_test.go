@@ -0,0 +1,119 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sqlStatements = `SELECT name
+FROM users; -- first;
+INSERT INTO t VALUES ('a;b');
+/* skip; */ DELETE FROM t`
+
+// TestStatementTextObject tests selecting statements with is and as.
+func TestStatementTextObject(t *testing.T) {
+	t.Run("dis deletes the statement across lines", func(t *testing.T) {
+		e := newTestEditor(sqlStatements, withLanguage("sql"), withCursor(Position{Row: 0, Col: 3}))
+		keys(e, []rune("dis")...)
+		assert.Equal(t, " -- first;\nINSERT INTO t VALUES ('a;b');\n/* skip; */ DELETE FROM t", content(e))
+	})
+
+	t.Run("das deletes the whitespace after the statement", func(t *testing.T) {
+		e := newTestEditor("SELECT 1;\nSELECT 2;", withLanguage("sql"), withCursor(Position{Row: 0, Col: 2}))
+		keys(e, []rune("das")...)
+		assert.Equal(t, "SELECT 2;", content(e))
+	})
+
+	t.Run("das on the last statement deletes the whitespace before it", func(t *testing.T) {
+		e := newTestEditor("SELECT 1;\nSELECT 2;", withLanguage("sql"), withCursor(Position{Row: 1, Col: 2}))
+		keys(e, []rune("das")...)
+		assert.Equal(t, "SELECT 1;", content(e))
+	})
+
+	t.Run("terminators in quotes and comments don't end a statement", func(t *testing.T) {
+		e := newTestEditor(sqlStatements, withLanguage("sql"), withCursor(Position{Row: 2, Col: 0}))
+		keys(e, []rune("dis")...)
+		assert.Equal(t, "SELECT name\nFROM users; -- first;\n\n/* skip; */ DELETE FROM t", content(e))
+	})
+
+	t.Run("a statement without a terminator ends with the text", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("SELECT 1;\nSELECT 2  \n")
+		e.SetLanguage("sql")
+		moveTo(e, Position{Row: 1, Col: 3})
+		keys(e, []rune("yis")...)
+		assert.Equal(t, "SELECT 2", cb.content)
+	})
+
+	t.Run("between statements selects the next one", func(t *testing.T) {
+		e := newTestEditor("SELECT 1;   SELECT 2;", withLanguage("sql"), withCursor(Position{Row: 0, Col: 10}))
+		keys(e, []rune("cis")...)
+		assert.True(t, e.IsInsertMode())
+		assert.Equal(t, "SELECT 1;   ", content(e))
+	})
+
+	t.Run("vis selects the statement", func(t *testing.T) {
+		e := newTestEditor("SELECT 1;\nSELECT\n  2;", withLanguage("sql"), withCursor(Position{Row: 1, Col: 2}))
+		keys(e, []rune("visd")...)
+		assert.Equal(t, "SELECT 1;\n", content(e))
+	})
+
+	t.Run("is is invalid without statements", func(t *testing.T) {
+		e := newTestEditor("SELECT 1;")
+		keys(e, []rune("dis")...)
+		assert.Equal(t, "SELECT 1;", content(e))
+	})
+
+	t.Run("the syntax is set per language", func(t *testing.T) {
+		e := newTestEditor("a. b. c.")
+		e.SetStatementSyntax("prose", &StatementSyntax{Terminator: '.'})
+		e.SetLanguage("Prose")
+		moveTo(e, Position{Row: 0, Col: 3})
+		keys(e, []rune("das")...)
+		assert.Equal(t, "a. c.", content(e))
+
+		e.SetStatementSyntax("prose", nil)
+		keys(e, []rune("das")...)
+		assert.Equal(t, "a. c.", content(e))
+	})
+}
+
+// TestStatementMotion tests moving between statements with ]s and [s.
+func TestStatementMotion(t *testing.T) {
+	text := "SELECT 1;\nSELECT 2; SELECT 3;\n\nSELECT\n  4;"
+
+	t.Run("]s moves to the next statement", func(t *testing.T) {
+		e := newTestEditor(text, withLanguage("sql"), withCursor(Position{Row: 0, Col: 3}))
+		keys(e, []rune("]s")...)
+		assert.Equal(t, Position{Row: 1, Col: 0}, cursorPos(e))
+		keys(e, []rune("]s")...)
+		assert.Equal(t, Position{Row: 1, Col: 10}, cursorPos(e))
+		keys(e, []rune("]s")...)
+		assert.Equal(t, Position{Row: 3, Col: 0}, cursorPos(e))
+		keys(e, []rune("]s")...)
+		assert.Equal(t, Position{Row: 3, Col: 0}, cursorPos(e))
+	})
+
+	t.Run("[s moves to the start of the statement, then the previous one", func(t *testing.T) {
+		e := newTestEditor(text, withLanguage("sql"), withCursor(Position{Row: 4, Col: 2}))
+		keys(e, []rune("[s")...)
+		assert.Equal(t, Position{Row: 3, Col: 0}, cursorPos(e))
+		keys(e, []rune("[s")...)
+		assert.Equal(t, Position{Row: 1, Col: 10}, cursorPos(e))
+	})
+
+	t.Run("a count moves over statements", func(t *testing.T) {
+		e := newTestEditor(text, withLanguage("sql"))
+		keys(e, []rune("2]s")...)
+		assert.Equal(t, Position{Row: 1, Col: 10}, cursorPos(e))
+		keys(e, []rune("5[s")...)
+		assert.Equal(t, Position{Row: 0, Col: 0}, cursorPos(e))
+	})
+
+	t.Run("without statements ]s does nothing", func(t *testing.T) {
+		e := newTestEditor(text)
+		keys(e, []rune("]sx")...)
+		assert.Equal(t, "ELECT 1;\nSELECT 2; SELECT 3;\n\nSELECT\n  4;", content(e))
+		assert.False(t, e.HasPendingKeys())
+	})
+}
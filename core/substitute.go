@@ -0,0 +1,194 @@
+package core
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// cutPatternCommand splits :s, :g, :g! and :v, or their long names, off the
+// front of cmd when a delimiter follows them, as in s/a/b/ and g#x#d. Their
+// patterns may have spaces, so they are split off before the arguments of
+// other commands are.
+func cutPatternCommand(cmd string) (command, rest string, found bool) {
+	for _, name := range []string{"substitute", "global!", "global", "vglobal", "s", "g!", "g", "v"} {
+		if rest, ok := strings.CutPrefix(cmd, name); ok && rest != "" && isPatternDelimiter(rest[0]) {
+			return name, rest, true
+		}
+	}
+	return "", cmd, false
+}
+
+// isPatternDelimiter reports whether c can delimit the pattern of :s or :g,
+// which any ASCII character but a letter, a digit, a space, ", | and \ can,
+// as in Vim.
+func isPatternDelimiter(c byte) bool {
+	switch {
+	case c >= utf8.RuneSelf, c == ' ', c == '\t', c == '"', c == '|', c == '\\':
+		return false
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return false
+	}
+	return true
+}
+
+// executeSubstitute runs :s/pattern/replacement/flags on the lines of lines,
+// or the cursor line without a range, as one change. The pattern matches as
+// it does after /, an empty one being the last search pattern, and the
+// replacement is literal. The flags are g, to replace every match on a line
+// rather than the first, and i or I, to ignore or match case whatever the
+// options say. The cursor ends on the last line changed.
+func (e *editor) executeSubstitute(lines *lineRange, args string) *EditorError {
+	r := lineRange{e.buffer.GetCursor().Position.Row, e.buffer.GetCursor().Position.Row}
+	if lines != nil {
+		r = *lines
+	}
+
+	pattern, rest := cutPattern(args[1:], args[0])
+	replacement, flags := cutPattern(rest, args[0])
+	if pattern == "" {
+		pattern = e.state.SearchQuery.Term
+	}
+
+	options := e.state.SearchOptions
+	all := false
+	for _, flag := range strings.TrimSpace(flags) {
+		switch flag {
+		case 'g':
+			all = true
+		case 'i':
+			options.IgnoreCase, options.SmartCase = true, false
+		case 'I':
+			options.IgnoreCase = false
+		default:
+			return &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+		}
+	}
+
+	needle := parseSearchPattern(pattern, options)
+	if len(needle.runes) == 0 {
+		return &EditorError{id: ErrPatternNotFoundId, err: ErrPatternNotFound}
+	}
+
+	changed := make(map[int]string)
+	last := -1
+	for row := r.start; row <= r.end; row++ {
+		if line, ok := substituteLine(e.buffer.GetLineRunes(row), needle, []rune(replacement), all); ok {
+			changed[row] = line
+			last = row
+		}
+	}
+	if last < 0 {
+		return &EditorError{id: ErrPatternNotFoundId, err: ErrPatternNotFound}
+	}
+
+	return e.editProgrammatically(func() error {
+		for row, line := range changed {
+			end := Position{Row: row, Col: e.buffer.LineRuneCount(row)}
+			if err := e.buffer.ReplaceRange(Position{Row: row}, end, line); err != nil {
+				return err
+			}
+		}
+
+		cursor := Cursor{Position: Position{Row: last}}
+		cursor.MoveToFirstNonBlank(e.buffer, e.state.AvailableWidth)
+		e.buffer.SetCursor(cursor)
+		return nil
+	})
+}
+
+// substituteLine replaces the first match of needle in line with
+// replacement, or every match with all. It returns false if there is none.
+func substituteLine(line []rune, needle searchNeedle, replacement []rune, all bool) (string, bool) {
+	var sb strings.Builder
+	from, found := 0, false
+	for {
+		col, ok := findInLine(line, needle, from, len(line), false)
+		if !ok {
+			break
+		}
+		sb.WriteString(string(line[from:col]))
+		sb.WriteString(string(replacement))
+		from, found = col+len(needle.runes), true
+		if !all {
+			break
+		}
+	}
+	if !found {
+		return "", false
+	}
+	sb.WriteString(string(line[from:]))
+	return sb.String(), true
+}
+
+// executeGlobal runs :g/pattern/command, which runs command on each line of
+// lines, or of the buffer without a range, that matches pattern, or with
+// invert that doesn't, as :g! and :v do. The lines are found first, then
+// command runs with the cursor at the start of each in turn, all of it one
+// change. A command that deletes lines is taken to delete them from its own
+// line down, as :d does, and the lines it deletes are skipped.
+func (e *editor) executeGlobal(lines *lineRange, invert bool, args string) *EditorError {
+	if e.inGlobal {
+		return &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+	}
+
+	r := lineRange{0, e.buffer.LineCount() - 1}
+	if lines != nil {
+		r = *lines
+	}
+
+	pattern, command := cutPattern(args[1:], args[0])
+	if pattern == "" {
+		pattern = e.state.SearchQuery.Term
+	}
+	if command = strings.TrimSpace(command); command == "" {
+		return &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+	}
+
+	needle := parseSearchPattern(pattern, e.state.SearchOptions)
+	if len(needle.runes) == 0 {
+		return &EditorError{id: ErrPatternNotFoundId, err: ErrPatternNotFound}
+	}
+
+	var rows []int
+	for row := r.start; row <= r.end; row++ {
+		line := e.buffer.GetLineRunes(row)
+		if _, found := findInLine(line, needle, 0, len(line), false); found != invert {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return &EditorError{id: ErrPatternNotFoundId, err: ErrPatternNotFound}
+	}
+
+	e.beginTransaction()
+	e.inGlobal = true
+
+	var err *EditorError
+	for i, row := range rows {
+		if row < 0 || row >= e.buffer.LineCount() {
+			continue
+		}
+
+		lineCount := e.buffer.LineCount()
+		e.buffer.SetCursor(Cursor{Position: Position{Row: row}})
+		if err = e.executeCommand(command); err != nil {
+			break
+		}
+
+		// Move the lines still to come by the lines added or deleted
+		delta := e.buffer.LineCount() - lineCount
+		for j := i + 1; j < len(rows); j++ {
+			if rows[j] >= 0 && delta < 0 && rows[j] < row-delta {
+				rows[j] = -1
+			} else if rows[j] >= 0 {
+				rows[j] += delta
+			}
+		}
+	}
+
+	e.inGlobal = false
+	if ended := e.endTransaction(); err == nil {
+		err = ended
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSubstitute tests :s on the cursor line and on ranges.
+func TestSubstitute(t *testing.T) {
+	t.Run("patterns, replacements and flags", func(t *testing.T) {
+		tests := []struct{ cmd, want string }{
+			{"s/foo/X/", "X bar foo\nbar foo\nFoo"},
+			{"s/foo/X/g", "X bar X\nbar foo\nFoo"},
+			{"%s/foo/X/g", "X bar X\nbar X\nFoo"},
+			{"2,3s/foo/X", "foo bar foo\nbar X\nFoo"},
+			{"%s/foo/X/gi", "X bar X\nbar X\nX"},
+			{"substitute#foo#a/b#", "a/b bar foo\nbar foo\nFoo"},
+			{"s/foo bar/two words/", "two words foo\nbar foo\nFoo"},
+			{"s/o//g", "f bar f\nbar foo\nFoo"},
+			{"s/o", "fo bar foo\nbar foo\nFoo"},
+		}
+		for _, tt := range tests {
+			e := newTestEditor("foo bar foo\nbar foo\nFoo")
+			assert.Nil(t, e.ExecuteCommand(tt.cmd), tt.cmd)
+			assert.Equal(t, tt.want, content(e), tt.cmd)
+		}
+	})
+
+	t.Run("an escaped delimiter is part of the pattern", func(t *testing.T) {
+		e := newTestEditor("a/b")
+		assert.Nil(t, e.ExecuteCommand(`s/\//-/`))
+		assert.Equal(t, "a-b", content(e))
+	})
+
+	t.Run("an empty pattern is the last search", func(t *testing.T) {
+		e := newTestEditor("one two")
+		e.ExecuteSearch("two", SearchOptions{})
+		assert.Nil(t, e.ExecuteCommand("s//2/"))
+		assert.Equal(t, "one 2", content(e))
+	})
+
+	t.Run("the cursor ends on the last line changed and u undoes it all", func(t *testing.T) {
+		e := newTestEditor("x\n  x\ny")
+		assert.Nil(t, e.ExecuteCommand("%s/x/z/"))
+		assert.Equal(t, "z\n  z\ny", content(e))
+		assert.Equal(t, Position{1, 2}, cursorPos(e))
+
+		keys(e, 'u')
+		assert.Equal(t, "x\n  x\ny", content(e))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		assert.Equal(t, ErrPatternNotFoundId, e.ExecuteCommand("s/two/2/").ID())
+		assert.Equal(t, ErrPatternNotFoundId, e.ExecuteCommand("s//2/").ID())
+		assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("s/one/1/x").ID())
+		assert.Equal(t, ErrInvalidRangeId, e.ExecuteCommand("1,5s/one/1/").ID())
+		assert.Equal(t, "one\ntwo", content(e))
+	})
+}
+
+// TestGlobal tests :g, :g! and :v.
+func TestGlobal(t *testing.T) {
+	const text = "foo 1\nbar\nfoo 2\nfoo 3\nbaz"
+
+	t.Run("commands run on the lines that match", func(t *testing.T) {
+		tests := []struct{ cmd, want string }{
+			{"g/foo/d", "bar\nbaz"},
+			{"global/foo/d", "bar\nbaz"},
+			{"g!/foo/d", "foo 1\nfoo 2\nfoo 3"},
+			{"v/foo/d", "foo 1\nfoo 2\nfoo 3"},
+			{"2,4g/foo/d", "foo 1\nbar\nbaz"},
+			{"g/foo/s/o/0/g", "f00 1\nbar\nf00 2\nf00 3\nbaz"},
+			{"g/foo/.,+1d", "baz"},
+			{"g/ba/s/a/A/", "foo 1\nbAr\nfoo 2\nfoo 3\nbAz"},
+		}
+		for _, tt := range tests {
+			e := newTestEditor(text)
+			assert.Nil(t, e.ExecuteCommand(tt.cmd), tt.cmd)
+			assert.Equal(t, tt.want, content(e), tt.cmd)
+		}
+	})
+
+	t.Run("u undoes it all", func(t *testing.T) {
+		e := newTestEditor(text)
+		assert.Nil(t, e.ExecuteCommand("g/foo/d"))
+		keys(e, 'u')
+		assert.Equal(t, text, content(e))
+	})
+
+	t.Run("from the command line", func(t *testing.T) {
+		e := newTestEditor(text)
+		keys(e, ':', 'g', '/', '2', '/', 'd')
+		enter(e)
+		assert.Equal(t, "foo 1\nbar\nfoo 3\nbaz", content(e))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		e := newTestEditor(text)
+		assert.Equal(t, ErrPatternNotFoundId, e.ExecuteCommand("g/nope/d").ID())
+		assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("g/foo/").ID())
+		assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("g/foo/g/1/d").ID())
+		assert.Equal(t, text, content(e))
+	})
+}
+
+// TestDeleteCommand tests that :delete deletes lines when given a range or a
+// count, and the file otherwise.
+func TestDeleteCommand(t *testing.T) {
+	tests := []struct{ cmd, want string }{
+		{"2,3delete", "1\n4"},
+		{"2del", "1\n3\n4"},
+		{"delete 2", "1\n4"},
+		{"del3", "1"},
+	}
+	for _, tt := range tests {
+		e := newTestEditor("1\n2\n3\n4")
+		keys(e, 'j')
+		assert.Nil(t, e.ExecuteCommand(tt.cmd), tt.cmd)
+		assert.Equal(t, tt.want, content(e), tt.cmd)
+	}
+
+	e := newTestEditor("1\n2")
+	drainSignals(e)
+	assert.Equal(t, ErrInvalidCommandId, e.ExecuteCommand("1delete!").ID())
+	assert.Nil(t, e.ExecuteCommand("delete"))
+	_, ok := nextSignal(e).(ConfirmSignal)
+	assert.True(t, ok, "without a range :delete deletes the file")
+	assert.Equal(t, "1\n2", content(e))
+}
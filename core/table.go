@@ -0,0 +1,508 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// Table mode edits markdown pipe tables, or CSV with another delimiter, as
+// tables: Tab and Shift-Tab move between cells, and the columns are aligned
+// as the table changes. The :Table commands add and delete rows and columns.
+
+// table is the table around a line of the buffer, a block of lines that
+// start with | for pipe tables, or of non-blank lines with the delimiter for
+// CSV.
+type table struct {
+	start     int    // Row of the first line
+	lines     int    // Lines of the table in the buffer
+	indent    string // Spaces before the first line of a pipe table
+	delimiter rune
+	rows      []tableRow
+}
+
+// tableRow is a line of a table.
+type tableRow struct {
+	cells     []string // Text of the cells without the spaces around it
+	separator bool     // The line under the header of a pipe table, such as |---|:-:|
+}
+
+// cellSpan is where a cell is on a line of a table: its text without the
+// spaces around it from start up to end, and the delimiter after it at next,
+// or the end of the line.
+type cellSpan struct {
+	start, end, next int
+}
+
+// SetTableMode turns table mode on or off.
+func (e *editor) SetTableMode(enabled bool) {
+	e.state.TableMode = enabled
+}
+
+// SetTableDelimiter sets the delimiter of the cells of tables: | for
+// markdown pipe tables, the default, or such as , or ; for CSV.
+func (e *editor) SetTableDelimiter(delimiter rune) {
+	e.state.TableDelimiter = delimiter
+}
+
+// tableDelimiter returns the delimiter of the cells of tables.
+func (e *editor) tableDelimiter() rune {
+	if e.state.TableDelimiter == 0 {
+		return '|'
+	}
+	return e.state.TableDelimiter
+}
+
+// handleTableKey moves to the next cell for Tab, or the previous one for
+// Shift-Tab, in normal and insert mode when table mode is on and the cursor
+// is in a table. Tab in the last cell adds a row. It reports whether the
+// key was handled.
+func (e *editor) handleTableKey(key KeyEvent) (bool, *EditorError) {
+	if !e.state.TableMode || key.Key != KeyTab || e.HasPendingKeys() || !e.IsNormalMode() && !e.IsInsertMode() {
+		return false, nil
+	}
+	t, ok := parseTable(e.buffer, e.buffer.GetCursor().Position.Row, e.tableDelimiter())
+	if !ok {
+		return false, nil
+	}
+
+	row, cell := t.cursorCell(e.buffer)
+	if key.Modifiers&ModShift != 0 {
+		row, cell = t.prevCell(row, cell)
+	} else {
+		row, cell = t.nextCell(row, cell)
+		if row == len(t.rows) {
+			t.rows = append(t.rows, tableRow{})
+		}
+	}
+
+	err := e.writeTable(t, row, cell, 0)
+	e.SaveHistory()
+	return true, err
+}
+
+// alignTableAfterKey aligns the table the cursor is in after key changed
+// it, when table mode is on. Typing in insert mode only aligns it when the
+// delimiter is typed or insert mode is left, so the cells don't move under
+// the cursor. The alignment is part of the change.
+func (e *editor) alignTableAfterKey(key KeyEvent, wasInsert bool) *EditorError {
+	if !e.state.TableMode || !e.IsNormalMode() && !e.IsInsertMode() {
+		return nil
+	}
+	leftInsert := wasInsert && !e.IsInsertMode()
+	changed := e.historySaved && (!e.IsInsertMode() || key.Rune == e.tableDelimiter())
+	if !leftInsert && !changed {
+		return nil
+	}
+
+	before := e.buffer.GetCurrentContent()
+	if err := e.alignTable(); err != nil || e.buffer.GetCurrentContent() == before {
+		return err
+	}
+	if e.historySaved && e.historyPos >= 0 {
+		e.history[e.historyPos].content = e.buffer.GetCurrentContent()
+	} else {
+		e.SaveHistory()
+	}
+	return nil
+}
+
+// alignTable aligns the columns of the table the cursor is in, keeping the
+// cursor in the same cell.
+func (e *editor) alignTable() *EditorError {
+	t, ok := parseTable(e.buffer, e.buffer.GetCursor().Position.Row, e.tableDelimiter())
+	if !ok {
+		return nil
+	}
+	row, cell, offset := t.cursorOffset(e.buffer)
+	return e.writeTable(t, row, cell, offset)
+}
+
+// executeTableCommand runs the :Table commands on the table the cursor is
+// in.
+func (e *editor) executeTableCommand(command string) *EditorError {
+	t, ok := parseTable(e.buffer, e.buffer.GetCursor().Position.Row, e.tableDelimiter())
+	if !ok {
+		return &EditorError{id: ErrNoTableId, err: ErrNoTable}
+	}
+	row, cell, offset := t.cursorOffset(e.buffer)
+	cell = min(cell, t.columns()-1)
+	if command != "TableAlign" {
+		offset = 0
+	}
+
+	switch command {
+	case "TableAddRow":
+		// A row added to the header goes under the separator
+		if row+1 < len(t.rows) && t.rows[row+1].separator {
+			row++
+		}
+		row++
+		t.rows = slices.Insert(t.rows, row, tableRow{})
+		cell = 0
+
+	case "TableDeleteRow":
+		if len(t.rows) == 1 {
+			return &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+		}
+		t.rows = slices.Delete(t.rows, row, row+1)
+		row = min(row, len(t.rows)-1)
+
+	case "TableAddColumn":
+		t.padRows()
+		for i := range t.rows {
+			t.rows[i].cells = slices.Insert(t.rows[i].cells, cell+1, "")
+		}
+		cell++
+
+	case "TableDeleteColumn":
+		if t.columns() == 1 {
+			return &EditorError{id: ErrInvalidCommandId, err: ErrInvalidCommand}
+		}
+		t.padRows()
+		for i := range t.rows {
+			t.rows[i].cells = slices.Delete(t.rows[i].cells, cell, cell+1)
+		}
+		cell = min(cell, t.columns()-1)
+	}
+
+	err := e.writeTable(t, row, cell, offset)
+	e.SaveHistory()
+	return err
+}
+
+// writeTable replaces the lines of the table in the buffer with t aligned,
+// and puts the cursor offset runes into a cell. A cell past the end of the
+// row puts it at the end of the line.
+func (e *editor) writeTable(t *table, row, cell, offset int) *EditorError {
+	widths := t.widths()
+	lines := make([][]rune, len(t.rows))
+	var starts []int
+	for i := range t.rows {
+		var rowStarts []int
+		lines[i], rowStarts = t.formatRow(i, widths)
+		if i == row {
+			starts = rowStarts
+		}
+	}
+
+	// Make room for the rows added, or remove the lines of those deleted
+	last := t.start + t.lines - 1
+	if added := len(lines) - t.lines; added > 0 {
+		if err := e.buffer.InsertRunesAt(last, e.buffer.LineRuneCount(last), []rune(strings.Repeat("\n", added))); err != nil {
+			return &EditorError{id: ErrInvalidPositionId, err: err}
+		}
+	} else if added < 0 {
+		keep := last + added
+		if err := deleteRange(e.buffer, Position{Row: keep, Col: e.buffer.LineRuneCount(keep)}, Position{Row: last, Col: e.buffer.LineRuneCount(last)}); err != nil {
+			return err
+		}
+	}
+
+	for i, line := range lines {
+		if err := replaceLine(e.buffer, t.start+i, line); err != nil {
+			return err
+		}
+	}
+
+	cursor := e.buffer.GetCursor()
+	cursor.Position.Row = t.start + row
+	if cell < len(starts) {
+		cells := t.paddedCells(row)
+		cursor.Position.Col = starts[cell] + min(offset, len([]rune(cells[cell])))
+	} else {
+		cursor.Position.Col = len(lines[row])
+	}
+	if e.IsNormalMode() {
+		cursor.Position.Col = min(cursor.Position.Col, max(len(lines[row])-1, 0))
+	}
+	if e.state.AvailableWidth > 0 {
+		cursor.Preferred = cursor.Position.Col % e.state.AvailableWidth
+	}
+	e.buffer.SetCursor(cursor)
+	return nil
+}
+
+// replaceLine replaces the text of a line.
+func replaceLine(buffer Buffer, row int, text []rune) *EditorError {
+	if slices.Equal(buffer.GetLineRunes(row), text) {
+		return nil
+	}
+	if err := buffer.DeleteRunesAt(row, 0, buffer.LineRuneCount(row)); err != nil {
+		return err
+	}
+	if err := buffer.InsertRunesAt(row, 0, text); err != nil {
+		return &EditorError{id: ErrInvalidPositionId, err: err}
+	}
+	return nil
+}
+
+// parseTable returns the table around row, and false if row isn't in one.
+func parseTable(buffer Buffer, row int, delimiter rune) (*table, bool) {
+	if !isTableLine(buffer.GetLineRunes(row), delimiter) {
+		return nil, false
+	}
+
+	start, end := row, row
+	for start > 0 && isTableLine(buffer.GetLineRunes(start-1), delimiter) {
+		start--
+	}
+	for end < buffer.LineCount()-1 && isTableLine(buffer.GetLineRunes(end+1), delimiter) {
+		end++
+	}
+
+	t := &table{start: start, lines: end - start + 1, delimiter: delimiter}
+	if delimiter == '|' {
+		line := string(buffer.GetLineRunes(start))
+		t.indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+	for r := start; r <= end; r++ {
+		line := buffer.GetLineRunes(r)
+		var cells []string
+		for _, span := range splitRow(line, delimiter) {
+			cells = append(cells, string(line[span.start:span.end]))
+		}
+		t.rows = append(t.rows, tableRow{cells: cells, separator: delimiter == '|' && isSeparatorRow(cells)})
+	}
+	return t, true
+}
+
+// isTableLine reports whether line is part of a table.
+func isTableLine(line []rune, delimiter rune) bool {
+	text := strings.TrimLeft(string(line), " \t")
+	if delimiter == '|' {
+		return strings.HasPrefix(text, "|")
+	}
+	return text != "" && strings.ContainsRune(text, delimiter)
+}
+
+// isSeparatorRow reports whether cells are those of the line under the
+// header of a pipe table, such as |---|:-:|.
+func isSeparatorRow(cells []string) bool {
+	if len(cells) == 0 {
+		return false
+	}
+	for _, cell := range cells {
+		dashes := strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if dashes == "" || strings.Trim(dashes, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRow returns the cells of a line of a table. A pipe table's line
+// starts and may end with |, and \| is a | within a cell. In CSV, the
+// delimiter is part of a cell within double quotes.
+func splitRow(line []rune, delimiter rune) []cellSpan {
+	i := 0
+	if delimiter == '|' {
+		for i < len(line) && unicode.IsSpace(line[i]) {
+			i++
+		}
+		if i < len(line) && line[i] == '|' {
+			i++
+		}
+	}
+
+	var spans []cellSpan
+	quoted := false
+	segmentStart := i
+	for ; i <= len(line); i++ {
+		if i < len(line) {
+			switch r := line[i]; {
+			case delimiter == '|' && r == '\\':
+				if i+1 < len(line) {
+					i++
+				}
+				continue
+			case delimiter != '|' && r == '"':
+				quoted = !quoted
+				continue
+			case r != delimiter || quoted:
+				continue
+			}
+		}
+
+		start, end := segmentStart, i
+		for start < end && unicode.IsSpace(line[start]) {
+			start++
+		}
+		for end > start && unicode.IsSpace(line[end-1]) {
+			end--
+		}
+		if start == end {
+			// An empty cell starts after the space that pads it
+			start = min(segmentStart+1, i)
+			end = start
+		}
+
+		// Nothing after the last | of a pipe table is a cell
+		if i == len(line) && delimiter == '|' && len(spans) > 0 && start == end {
+			break
+		}
+		spans = append(spans, cellSpan{start: start, end: end, next: i})
+		segmentStart = i + 1
+	}
+	return spans
+}
+
+// cursorCell returns the row and cell of the table the cursor is in. A
+// cursor after the last | of a line is in the cell after the last one.
+func (t *table) cursorCell(buffer Buffer) (row, cell int) {
+	row, cell, _ = t.cursorOffset(buffer)
+	return row, cell
+}
+
+// cursorOffset returns the row and cell of the table the cursor is in, and
+// how far into the text of the cell it is.
+func (t *table) cursorOffset(buffer Buffer) (row, cell, offset int) {
+	pos := buffer.GetCursor().Position
+	row = pos.Row - t.start
+	spans := splitRow(buffer.GetLineRunes(pos.Row), t.delimiter)
+	for i, span := range spans {
+		if pos.Col <= span.next {
+			return row, i, max(min(pos.Col-span.start, span.end-span.start), 0)
+		}
+	}
+	return row, len(spans), 0
+}
+
+// nextCell returns the cell after the one given, skipping separators. A
+// row of len(t.rows) is after the last cell of the table.
+func (t *table) nextCell(row, cell int) (int, int) {
+	if cell+1 < t.columns() {
+		return row, cell + 1
+	}
+	for row++; row < len(t.rows) && t.rows[row].separator; row++ {
+	}
+	return row, 0
+}
+
+// prevCell returns the cell before the one given, skipping separators, or
+// the first cell.
+func (t *table) prevCell(row, cell int) (int, int) {
+	if cell = min(cell, t.columns()); cell > 0 {
+		return row, cell - 1
+	}
+	for prev := row - 1; prev >= 0; prev-- {
+		if !t.rows[prev].separator {
+			return prev, t.columns() - 1
+		}
+	}
+	return row, 0
+}
+
+// columns returns the number of cells of the longest row.
+func (t *table) columns() int {
+	n := 1
+	for _, row := range t.rows {
+		n = max(n, len(row.cells))
+	}
+	return n
+}
+
+// paddedCells returns the cells of a row with empty ones added up to the
+// number of columns.
+func (t *table) paddedCells(row int) []string {
+	cells := t.rows[row].cells
+	for len(cells) < t.columns() {
+		if t.rows[row].separator {
+			cells = append(cells, "---")
+		} else {
+			cells = append(cells, "")
+		}
+	}
+	return cells
+}
+
+// padRows adds empty cells to the rows up to the number of columns.
+func (t *table) padRows() {
+	cells := make([][]string, len(t.rows))
+	for i := range t.rows {
+		cells[i] = t.paddedCells(i)
+	}
+	for i := range t.rows {
+		t.rows[i].cells = cells[i]
+	}
+}
+
+// widths returns the display width of each column. Pipe table columns are
+// at least 3 wide, for the --- of the separator.
+func (t *table) widths() []int {
+	widths := make([]int, t.columns())
+	for i := range widths {
+		if t.delimiter == '|' {
+			widths[i] = 3
+		}
+	}
+	for r, row := range t.rows {
+		if row.separator {
+			continue
+		}
+		for i, cell := range t.paddedCells(r) {
+			widths[i] = max(widths[i], uniseg.StringWidth(cell))
+		}
+	}
+	return widths
+}
+
+// formatRow returns a row of the table aligned to widths, and where the text
+// of each cell starts. Cells of pipe tables are padded to the width of their
+// column; in CSV the padding goes after the delimiter, where readers that
+// trim leading spaces drop it.
+func (t *table) formatRow(row int, widths []int) ([]rune, []int) {
+	cells := t.paddedCells(row)
+	starts := make([]int, len(cells))
+	var line []rune
+
+	if t.delimiter != '|' {
+		for i, cell := range cells {
+			starts[i] = len(line)
+			line = append(line, []rune(cell)...)
+			if i < len(cells)-1 {
+				line = append(line, t.delimiter)
+				line = append(line, []rune(strings.Repeat(" ", widths[i]-uniseg.StringWidth(cell)+1))...)
+			}
+		}
+		return line, starts
+	}
+
+	line = append([]rune(t.indent), '|')
+	for i, cell := range cells {
+		if t.rows[row].separator {
+			cell = separatorCell(cell, widths[i])
+		}
+		line = append(line, ' ')
+		starts[i] = len(line)
+		line = append(line, []rune(cell)...)
+		line = append(line, []rune(strings.Repeat(" ", widths[i]-uniseg.StringWidth(cell)))...)
+		line = append(line, ' ', '|')
+	}
+	return line, starts
+}
+
+// separatorCell returns a cell of the separator of a pipe table as dashes of
+// width, keeping the colons that align the column.
+func separatorCell(cell string, width int) string {
+	left, right := strings.HasPrefix(cell, ":"), len(cell) > 1 && strings.HasSuffix(cell, ":")
+	dashes := width
+	if left {
+		dashes--
+	}
+	if right {
+		dashes--
+	}
+
+	var b strings.Builder
+	if left {
+		b.WriteByte(':')
+	}
+	b.WriteString(strings.Repeat("-", dashes))
+	if right {
+		b.WriteByte(':')
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const markdownTable = `Intro
+| Name | Age |
+|:-|-:|
+| Alice | 30 |
+| Bob | 7 |
+Outro`
+
+// TestTableMode tests aligning tables and moving between their cells.
+func TestTableMode(t *testing.T) {
+	aligned := `Intro
+| Name  | Age |
+| :---- | --: |
+| Alice | 30  |
+| Bob   | 7   |
+Outro`
+
+	t.Run("a change aligns the table", func(t *testing.T) {
+		e := newTestEditor(markdownTable, withTableMode(), withCursor(Position{3, 2}))
+		keys(e, 'x')
+		assert.Equal(t, `Intro
+| Name | Age |
+| :--- | --: |
+| lice | 30  |
+| Bob  | 7   |
+Outro`, content(e))
+		assert.Equal(t, Position{3, 2}, cursorPos(e))
+
+		keys(e, 'u')
+		assert.Equal(t, markdownTable, content(e))
+	})
+
+	t.Run("typing only aligns on the delimiter and when leaving insert mode", func(t *testing.T) {
+		e := newTestEditor(aligned, withTableMode(), withCursor(Position{Row: 4}))
+		keys(e, 'A', ' ', 'x', 'y', 'z')
+		assert.Equal(t, "| Bob   | 7   | xyz", e.GetBuffer().GetLines()[4])
+		keys(e, ' ', '|')
+		assert.Equal(t, "| Bob   | 7   | xyz |", e.GetBuffer().GetLines()[4])
+		assert.Equal(t, "| Alice | 30  |     |", e.GetBuffer().GetLines()[3])
+		assert.Equal(t, Position{4, 21}, cursorPos(e))
+	})
+
+	t.Run("tab moves between cells and adds a row after the last", func(t *testing.T) {
+		e := newTestEditor(aligned, withTableMode(), withCursor(Position{Row: 1}))
+		keys(e, 'i')
+		tab(e)
+		assert.Equal(t, Position{1, 10}, cursorPos(e))
+		tab(e)
+		assert.Equal(t, Position{3, 2}, cursorPos(e), "the separator is skipped")
+		e.HandleKey(KeyEvent{Key: KeyTab, Modifiers: ModShift})
+		assert.Equal(t, Position{1, 10}, cursorPos(e))
+
+		moveTo(e, Position{4, 10})
+		tab(e)
+		keys(e, 'E', 'v', 'e')
+		escape(e)
+		assert.Equal(t, "| Eve   |     |", e.GetBuffer().GetLines()[5])
+		assert.Equal(t, "Outro", e.GetBuffer().GetLines()[6])
+	})
+
+	t.Run("tab outside a table is left alone", func(t *testing.T) {
+		e := newTestEditor(aligned, withTableMode())
+		keys(e, 'i')
+		tab(e)
+		assert.Equal(t, "\tIntro", e.GetBuffer().GetLines()[0])
+	})
+
+	t.Run("without table mode nothing is aligned", func(t *testing.T) {
+		e := newTestEditor(markdownTable, withTableMode(), withCursor(Position{Row: 3}))
+		e.SetTableMode(false)
+		keys(e, 'x')
+		assert.Equal(t, " Alice | 30 |", e.GetBuffer().GetLines()[3])
+		assert.Equal(t, "| Name | Age |", e.GetBuffer().GetLines()[1])
+	})
+
+	t.Run("escaped pipes stay in their cell", func(t *testing.T) {
+		e := newTestEditor("| a\\|b | c |\n| d | e |", withTableMode())
+		assert.Nil(t, e.ExecuteCommand("TableAlign"))
+		assert.Equal(t, "| a\\|b | c   |\n| d    | e   |", content(e))
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		e := newTestEditor("name,age\nalice,30\n\"b, jr\",7", withTableMode())
+		e.SetTableDelimiter(',')
+		assert.Nil(t, e.ExecuteCommand("TableAlign"))
+		assert.Equal(t, "name,    age\nalice,   30\n\"b, jr\", 7", content(e))
+		keys(e, 'i')
+		tab(e)
+		assert.Equal(t, Position{0, 9}, cursorPos(e))
+	})
+}
+
+// TestTableCommands tests adding and deleting rows and columns.
+func TestTableCommands(t *testing.T) {
+	for _, c := range []struct {
+		name, command string
+		row           int
+		want          string
+		cursor        Position
+	}{
+		{"add a row", "TableAddRow", 3, "Intro\n| Name  | Age |\n| :---- | --: |\n| Alice | 30  |\n|       |     |\n| Bob   | 7   |\nOutro", Position{4, 2}},
+		{"add a row under the header", "TableAddRow", 1, "Intro\n| Name  | Age |\n| :---- | --: |\n|       |     |\n| Alice | 30  |\n| Bob   | 7   |\nOutro", Position{3, 2}},
+		{"delete a row", "TableDeleteRow", 3, "Intro\n| Name | Age |\n| :--- | --: |\n| Bob  | 7   |\nOutro", Position{3, 2}},
+		{"delete the last row", "TableDeleteRow", 4, "Intro\n| Name  | Age |\n| :---- | --: |\n| Alice | 30  |\nOutro", Position{3, 2}},
+		{"add a column", "TableAddColumn", 3, "Intro\n| Name  |     | Age |\n| :---- | --- | --: |\n| Alice |     | 30  |\n| Bob   |     | 7   |\nOutro", Position{3, 10}},
+		{"delete a column", "TableDeleteColumn", 3, "Intro\n| Age |\n| --: |\n| 30  |\n| 7   |\nOutro", Position{3, 2}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			e := newTestEditor(markdownTable, withTableMode(), withCursor(Position{Row: c.row}))
+			assert.Nil(t, e.ExecuteCommand(c.command))
+			assert.Equal(t, c.want, content(e))
+			assert.Equal(t, c.cursor, cursorPos(e))
+		})
+	}
+
+	t.Run("no table", func(t *testing.T) {
+		e := newTestEditor(markdownTable, withTableMode())
+		err := e.ExecuteCommand("TableAddRow")
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrNoTableId, err.ID())
+		}
+	})
+}
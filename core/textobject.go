@@ -1,6 +1,8 @@
 package core
 
-import "fmt"
+import (
+	"strings"
+)
 
 // wordTextObjectRange handles text object yanks like 'yiw' (yank inside word) and 'yaw' (yank around word).
 //
@@ -94,18 +96,32 @@ func wordTextObjectRange(buffer Buffer, pos Position, modifier rune, isWordChar
 	return startCol, endCol, true
 }
 
-func yankTextObject(editor Editor, buffer Buffer, modifier rune, textObject rune) *EditorError {
+// wordTextObjectChars returns the characters that make up the word of a text
+// object: keywords for iw and aw, and any non-blank characters for the WORDs
+// of iW and aW.
+func wordTextObjectChars(editor modeContext, textObject rune) (func(rune) bool, bool) {
+	switch textObject {
+	case 'w':
+		return editor.IsWordChar, true
+	case 'W':
+		return isWORDChar, true
+	}
+	return nil, false
+}
+
+func yankTextObject(editor modeContext, buffer Buffer, modifier rune, textObject rune) *EditorError {
 	cursor := buffer.GetCursor()
 	state := editor.GetState()
 
-	if textObject != 'w' {
+	isWordChar, ok := wordTextObjectChars(editor, textObject)
+	if !ok {
 		return &EditorError{
 			id:  ErrInvalidMotionId,
-			err: fmt.Errorf("unsupported text object: %c", textObject),
+			err: newNotice(MsgUnknownTextObject, textObject),
 		}
 	}
 
-	startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, editor.IsWordChar)
+	startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, isWordChar)
 	if !found {
 		return nil
 	}
@@ -128,17 +144,18 @@ func yankTextObject(editor Editor, buffer Buffer, modifier rune, textObject rune
 	return nil
 }
 
-func deleteTextObject(editor Editor, buffer Buffer, modifier rune, textObject rune) *EditorError {
+func deleteTextObject(editor modeContext, buffer Buffer, modifier rune, textObject rune) *EditorError {
 	cursor := buffer.GetCursor()
 
-	if textObject != 'w' {
+	isWordChar, ok := wordTextObjectChars(editor, textObject)
+	if !ok {
 		return &EditorError{
 			id:  ErrInvalidMotionId,
-			err: fmt.Errorf("unsupported text object: %c", textObject),
+			err: newNotice(MsgUnknownTextObject, textObject),
 		}
 	}
 
-	startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, editor.IsWordChar)
+	startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, isWordChar)
 	if !found {
 		return nil
 	}
@@ -156,17 +173,18 @@ func deleteTextObject(editor Editor, buffer Buffer, modifier rune, textObject ru
 	return err
 }
 
-func changeTextObject(editor Editor, buffer Buffer, modifier rune, textObject rune) *EditorError {
+func changeTextObject(editor modeContext, buffer Buffer, modifier rune, textObject rune) *EditorError {
 	cursor := buffer.GetCursor()
 
-	if textObject != 'w' {
+	isWordChar, ok := wordTextObjectChars(editor, textObject)
+	if !ok {
 		return &EditorError{
 			id:  ErrInvalidMotionId,
-			err: fmt.Errorf("unsupported text object: %c", textObject),
+			err: newNotice(MsgUnknownTextObject, textObject),
 		}
 	}
 
-	startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, editor.IsWordChar)
+	startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, isWordChar)
 	if !found {
 		return nil
 	}
@@ -193,7 +211,7 @@ func changeTextObject(editor Editor, buffer Buffer, modifier rune, textObject ru
 //
 // Cursor on a blank line:
 //   - 'i': the contiguous run of blank lines.
-//   - 'a': blank lines plus the adjacent paragraph below (or above if none below).
+//   - 'a': blank lines plus the adjacent paragraph below (or above, with its leading blank lines, if none below).
 func paragraphRows(buffer Buffer, pos Position, modifier rune) (startRow, endRow int, found bool) {
 	lineCount := buffer.LineCount()
 
@@ -221,6 +239,11 @@ func paragraphRows(buffer Buffer, pos Position, modifier rune) (startRow, endRow
 				for startRow > 0 && len(buffer.GetLineRunes(startRow-1)) > 0 {
 					startRow--
 				}
+				// Take the blank lines separating it from the text above as well,
+				// so the buffer doesn't end with a dangling blank line.
+				for startRow > 0 && len(buffer.GetLineRunes(startRow-1)) == 0 {
+					startRow--
+				}
 			}
 		}
 
@@ -277,7 +300,7 @@ func paragraphDeleteRange(buffer Buffer, startRow, endRow int) (start, end Posit
 	return Position{Row: 0, Col: 0}, Position{Row: endRow, Col: buffer.LineRuneCount(endRow)}
 }
 
-func yankParagraphTextObject(editor Editor, buffer Buffer, modifier rune) *EditorError {
+func yankParagraphTextObject(editor modeContext, buffer Buffer, modifier rune) *EditorError {
 	cursor := buffer.GetCursor()
 	state := editor.GetState()
 
@@ -308,7 +331,7 @@ func yankParagraphTextObject(editor Editor, buffer Buffer, modifier rune) *Edito
 	return nil
 }
 
-func deleteParagraphTextObject(editor Editor, buffer Buffer, modifier rune) *EditorError {
+func deleteParagraphTextObject(editor modeContext, buffer Buffer, modifier rune) *EditorError {
 	cursor := buffer.GetCursor()
 
 	startRow, endRow, found := paragraphRows(buffer, cursor.Position, modifier)
@@ -334,7 +357,7 @@ func deleteParagraphTextObject(editor Editor, buffer Buffer, modifier rune) *Edi
 	return nil
 }
 
-func changeParagraphTextObject(editor Editor, buffer Buffer, modifier rune) *EditorError {
+func changeParagraphTextObject(editor modeContext, buffer Buffer, modifier rune) *EditorError {
 	cursor := buffer.GetCursor()
 
 	startRow, endRow, found := paragraphRows(buffer, cursor.Position, modifier)
@@ -418,3 +441,151 @@ func changeParagraphTextObject(editor Editor, buffer Buffer, modifier rune) *Edi
 	editor.SetInsertMode()
 	return nil
 }
+
+// delimiterPairs maps every key that names a bracket text object to its open and close runes.
+var delimiterPairs = map[rune][2]rune{
+	'(': {'(', ')'}, ')': {'(', ')'}, 'b': {'(', ')'},
+	'[': {'[', ']'}, ']': {'[', ']'},
+	'{': {'{', '}'}, '}': {'{', '}'}, 'B': {'{', '}'},
+	'<': {'<', '>'}, '>': {'<', '>'},
+}
+
+// delimitedTextObjectRange returns the range of the bracket or quote text object
+// under pos, such as i( or a".
+//
+// Brackets may span lines and nest; the innermost pair around pos is used, or
+// the pair that starts or ends at pos. As in Vim, 'i' on a block whose brackets
+// are on their own lines selects the lines in between.
+//
+// Quotes are matched within the line: the pair that contains pos, or the first
+// pair after it. 'a' includes the quotes and the whitespace after them (or
+// before them if there is none after).
+func delimitedTextObjectRange(buffer Buffer, pos Position, modifier, object rune) (r textRange, found bool) {
+	if pair, ok := delimiterPairs[object]; ok {
+		return bracketTextObjectRange(buffer, pos, modifier, pair[0], pair[1])
+	}
+	return quoteTextObjectRange(buffer, pos, modifier, object)
+}
+
+func bracketTextObjectRange(buffer Buffer, pos Position, modifier, open, close rune) (textRange, bool) {
+	openPos, ok := findUnmatched(buffer, pos, open, close, -1)
+	if !ok {
+		return textRange{}, false
+	}
+	closePos, ok := findUnmatched(buffer, Position{Row: openPos.Row, Col: openPos.Col + 1}, open, close, 1)
+	if !ok {
+		return textRange{}, false
+	}
+
+	if modifier == 'a' {
+		return textRange{start: openPos, end: Position{Row: closePos.Row, Col: closePos.Col + 1}}, true
+	}
+
+	start := Position{Row: openPos.Row, Col: openPos.Col + 1}
+	end := closePos
+
+	if closePos.Row > openPos.Row {
+		openAtEnd := start.Col == buffer.LineRuneCount(openPos.Row)
+		closeAtStart := strings.TrimSpace(string(buffer.GetLineRunes(closePos.Row)[:closePos.Col])) == ""
+		if openAtEnd && closeAtStart {
+			if closePos.Row-openPos.Row < 2 {
+				return textRange{}, false
+			}
+			return textRange{start: Position{Row: openPos.Row + 1}, end: Position{Row: closePos.Row - 1}, linewise: true}, true
+		}
+		if openAtEnd {
+			start = Position{Row: openPos.Row + 1}
+		}
+		if closeAtStart {
+			end = Position{Row: closePos.Row - 1, Col: buffer.LineRuneCount(closePos.Row - 1)}
+		}
+	}
+
+	if start.Row == end.Row && start.Col >= end.Col {
+		return textRange{}, false
+	}
+	return textRange{start: start, end: end}, true
+}
+
+// findUnmatched scans from pos in dir (-1 backwards, 1 forwards) for an open
+// (backwards) or close (forwards) rune that is not balanced by a pair in
+// between. Scanning backwards, the rune at pos itself counts, so a cursor on
+// either bracket selects that pair.
+func findUnmatched(buffer Buffer, pos Position, open, close rune, dir int) (Position, bool) {
+	want, other := close, open
+	if dir < 0 {
+		want, other = open, close
+	}
+
+	row, col := pos.Row, pos.Col
+	line := buffer.GetLineRunes(row)
+	if dir < 0 && col < len(line) && line[col] == close {
+		// On the closing bracket: its pair is found by scanning back from before it
+		col--
+	}
+
+	depth := 0
+	for {
+		for ; col >= 0 && col < len(line); col += dir {
+			switch line[col] {
+			case want:
+				if depth == 0 {
+					return Position{Row: row, Col: col}, true
+				}
+				depth--
+			case other:
+				depth++
+			}
+		}
+
+		row += dir
+		if row < 0 || row >= buffer.LineCount() {
+			return Position{}, false
+		}
+		line = buffer.GetLineRunes(row)
+		col = 0
+		if dir < 0 {
+			col = len(line) - 1
+		}
+	}
+}
+
+func quoteTextObjectRange(buffer Buffer, pos Position, modifier, quote rune) (textRange, bool) {
+	line := buffer.GetLineRunes(pos.Row)
+
+	var quotes []int
+	for i, r := range line {
+		if r == quote && (i == 0 || line[i-1] != '\\') {
+			quotes = append(quotes, i)
+		}
+	}
+
+	openCol, closeCol := -1, -1
+	for i := 0; i+1 < len(quotes); i += 2 {
+		if pos.Col <= quotes[i+1] {
+			openCol, closeCol = quotes[i], quotes[i+1]
+			break
+		}
+	}
+	if openCol < 0 {
+		return textRange{}, false
+	}
+
+	if modifier == 'i' {
+		if closeCol == openCol+1 {
+			return textRange{}, false
+		}
+		return textRange{start: Position{Row: pos.Row, Col: openCol + 1}, end: Position{Row: pos.Row, Col: closeCol}}, true
+	}
+
+	startCol, endCol := openCol, closeCol+1
+	for endCol < len(line) && isWhiteSpace(line[endCol]) {
+		endCol++
+	}
+	if endCol == closeCol+1 {
+		for startCol > 0 && isWhiteSpace(line[startCol-1]) {
+			startCol--
+		}
+	}
+	return textRange{start: Position{Row: pos.Row, Col: startCol}, end: Position{Row: pos.Row, Col: endCol}}, true
+}
@@ -0,0 +1,73 @@
+package core
+
+// BeginTransaction starts grouping edits into one change, until the matching
+// EndTransaction. Edits through the Buffer and ReplaceRange, InsertLines,
+// DeleteLines and ReplaceAll in between are undone at once, and are checked
+// against the input limits and protected ranges only at the end.
+// Transactions nest: only the outermost one makes the change.
+func (e *editor) BeginTransaction() {
+	e.enter()
+	defer e.leave()
+
+	e.beginTransaction()
+}
+
+// beginTransaction starts a transaction for BeginTransaction and :g.
+func (e *editor) beginTransaction() {
+	e.transactionDepth++
+	if e.transactionDepth == 1 {
+		e.transaction = e.editSnapshot()
+	}
+}
+
+// EndTransaction ends the transaction started by BeginTransaction. Ending the
+// outermost one saves its edits as one change, or undoes them all and
+// returns why if they are rejected.
+func (e *editor) EndTransaction() *EditorError {
+	e.enter()
+	defer e.leave()
+
+	return e.endTransaction()
+}
+
+// endTransaction ends a transaction for EndTransaction and :g.
+func (e *editor) endTransaction() *EditorError {
+	if e.transactionDepth == 0 {
+		return nil
+	}
+	e.transactionDepth--
+	if e.transactionDepth > 0 {
+		return nil
+	}
+
+	snapshot := e.transaction
+	e.transaction = editState{}
+	if e.buffer.GetCurrentContent() == snapshot.content {
+		return nil
+	}
+	if e.hasEditChecks() {
+		if rejected := e.checkEdit(snapshot); rejected != nil {
+			return rejected
+		}
+	}
+
+	e.preChangeCursor = snapshot.cursor
+	e.buffer.SetCursor(e.buffer.GetCursor())
+	e.clampCursorToLine()
+	e.SaveHistory()
+	e.ScrollViewport()
+	return nil
+}
+
+// Transact runs fn in a transaction, so its edits to the buffer and cursor
+// moves are one change.
+func (e *editor) Transact(fn func(Buffer)) *EditorError {
+	e.BeginTransaction()
+	fn(e.buffer)
+	return e.EndTransaction()
+}
+
+// InTransaction reports whether a transaction has begun and not ended.
+func (e *editor) InTransaction() bool {
+	return e.transactionDepth > 0
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTransaction tests grouping edits into one change.
+func TestTransaction(t *testing.T) {
+	t.Run("Transact is undone at once", func(t *testing.T) {
+		e := newTestEditor("a b", withCursor(Position{Row: 0, Col: 2}))
+		err := e.Transact(func(b Buffer) {
+			_ = b.InsertLines(0, []string{"x"})
+			b.ReplaceAll(regexp.MustCompile(`b`), "c")
+			b.SetCursor(Cursor{Position: Position{Row: 1, Col: 0}})
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "x\na c", content(e))
+		assert.Equal(t, Position{Row: 1, Col: 0}, cursorPos(e))
+
+		keys(e, 'u')
+		assert.Equal(t, "a b", content(e))
+		assert.Equal(t, Position{Row: 0, Col: 2}, cursorPos(e))
+		redo(e)
+		assert.Equal(t, "x\na c", content(e))
+	})
+
+	t.Run("edits through the editor join the transaction", func(t *testing.T) {
+		e := newTestEditor("one")
+		e.BeginTransaction()
+		assert.True(t, e.InTransaction())
+		assert.Nil(t, e.ReplaceRange(Position{Row: 0, Col: 0}, Position{Row: 0, Col: 3}, "two"))
+		assert.Nil(t, e.InsertLines(1, []string{"three"}))
+		assert.Nil(t, e.EndTransaction())
+		assert.False(t, e.InTransaction())
+
+		keys(e, 'u')
+		assert.Equal(t, "one", content(e))
+	})
+
+	t.Run("nested transactions make one change", func(t *testing.T) {
+		e := newTestEditor("a")
+		e.BeginTransaction()
+		assert.Nil(t, e.Transact(func(b Buffer) { _ = b.InsertRunesAt(0, 1, []rune("b")) }))
+		assert.True(t, e.InTransaction())
+		assert.Nil(t, e.InsertLines(1, []string{"c"}))
+		assert.Nil(t, e.EndTransaction())
+
+		keys(e, 'u')
+		assert.Equal(t, "a", content(e))
+	})
+
+	t.Run("the limits are checked at the end", func(t *testing.T) {
+		e := newTestEditor("abc")
+		e.SetMaxLength(3)
+		err := e.Transact(func(b Buffer) {
+			_ = b.InsertRunesAt(0, 3, []rune("def"))
+			_ = b.DeleteRunesAt(0, 0, 3)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "def", content(e))
+
+		err = e.Transact(func(b Buffer) { _ = b.InsertRunesAt(0, 0, []rune("x")) })
+		assert.NotNil(t, err)
+		assert.Equal(t, ErrMaxLengthId, err.ID())
+		assert.Equal(t, "def", content(e))
+
+		keys(e, 'u')
+		assert.Equal(t, "abc", content(e))
+	})
+
+	t.Run("ending without a transaction does nothing", func(t *testing.T) {
+		e := newTestEditor("a")
+		assert.Nil(t, e.EndTransaction())
+		assert.False(t, e.InTransaction())
+	})
+}
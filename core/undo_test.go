@@ -87,21 +87,21 @@ func TestUndoMultipleSteps(t *testing.T) {
 	})
 }
 
-// TestRedoBasic tests 'U' — redo the last undone change.
+// TestRedoBasic tests Ctrl-R — redo the last undone change.
 func TestRedoBasic(t *testing.T) {
 	t.Run("redo after undo reapplies dd", func(t *testing.T) {
 		e := newTestEditor("hello")
 		keys(e, 'd', 'd')
 		keys(e, 'u')
 		assert.Equal(t, "hello", content(e))
-		keys(e, 'U') // redo
+		redo(e) // redo
 		assert.Equal(t, "", content(e))
 	})
 
 	t.Run("redo at newest change does nothing", func(t *testing.T) {
 		e := newTestEditor("hello")
 		keys(e, 'd', 'd')
-		keys(e, 'U') // nothing to redo yet
+		redo(e) // nothing to redo yet
 		assert.Equal(t, "", content(e))
 	})
 
@@ -110,7 +110,7 @@ func TestRedoBasic(t *testing.T) {
 		keys(e, 'j', 'd', 'd') // delete "second", cursor goes to row 0
 		keys(e, 'u')            // undo: "second" restored, cursor at row 1
 		assert.Equal(t, Position{1, 0}, cursorPos(e))
-		keys(e, 'U') // redo: "second" deleted again, cursor at row 0
+		redo(e) // redo: "second" deleted again, cursor at row 0
 		assert.Equal(t, "first", content(e))
 		assert.Equal(t, Position{0, 0}, cursorPos(e))
 	})
@@ -123,7 +123,150 @@ func TestUndoTruncatesRedo(t *testing.T) {
 		keys(e, 'd', 'd') // delete "one" → "two\nthree"
 		keys(e, 'u')       // undo → "one\ntwo\nthree"
 		keys(e, 'x')       // new edit → "ne\ntwo\nthree"
-		keys(e, 'U')       // redo should not restore "two\nthree"
+		redo(e)            // redo should not restore "two\nthree"
 		assert.Equal(t, "ne\ntwo\nthree", content(e))
 	})
 }
+
+// TestUndoLine tests 'U' — undo all the latest changes on the last changed line.
+func TestUndoLine(t *testing.T) {
+	t.Run("restores the line after several changes", func(t *testing.T) {
+		e := newTestEditor("hello world\nnext")
+		keys(e, 'x', 'x', 'w', 'x')
+		assert.Equal(t, "llo orld\nnext", content(e))
+		keys(e, 'U')
+		assert.Equal(t, "hello world\nnext", content(e))
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+	})
+
+	t.Run("restores text typed in insert mode", func(t *testing.T) {
+		e := newTestEditor("ab")
+		keys(e, 'a', 'x', 'y')
+		escape(e)
+		keys(e, 'U')
+		assert.Equal(t, "ab", content(e))
+	})
+
+	t.Run("U again redoes the changes", func(t *testing.T) {
+		e := newTestEditor("abc")
+		keys(e, 'x', 'x')
+		keys(e, 'U')
+		assert.Equal(t, "abc", content(e))
+		keys(e, 'U')
+		assert.Equal(t, "c", content(e))
+	})
+
+	t.Run("is undone by u", func(t *testing.T) {
+		e := newTestEditor("abc")
+		keys(e, 'x', 'U')
+		assert.Equal(t, "abc", content(e))
+		keys(e, 'u')
+		assert.Equal(t, "bc", content(e))
+	})
+
+	t.Run("only the last changed line is restored", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'x', 'j', 'x', 'x')
+		keys(e, 'U')
+		assert.Equal(t, "ne\ntwo", content(e))
+	})
+
+	t.Run("does nothing after a change to several lines", func(t *testing.T) {
+		e := newTestEditor("one\ntwo\nthree")
+		keys(e, 'x', 'd', 'd')
+		keys(e, 'U')
+		assert.Equal(t, "two\nthree", content(e))
+	})
+
+	t.Run("redoes instead when configured", func(t *testing.T) {
+		e := newTestEditor("abc")
+		e.SetRedoOnU(true)
+		keys(e, 'x', 'u')
+		assert.Equal(t, "abc", content(e))
+		keys(e, 'U')
+		assert.Equal(t, "bc", content(e))
+	})
+}
+
+// TestUndoRedoCursorPosition tests that undo puts the cursor back where it was
+// before each change and redo puts it where the change left it.
+func TestUndoRedoCursorPosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		move    []rune // Keys that place the cursor before the change
+		change  []rune
+		before  Position // Cursor restored by undo
+		after   Position // Cursor after the change, restored by redo
+	}{
+		{"dd on the last line", "one\ntwo\nthree", []rune("Gll"), []rune("dd"), Position{2, 2}, Position{1, 0}},
+		{"dj", "one\ntwo\nthree", []rune("l"), []rune("dj"), Position{0, 1}, Position{0, 0}},
+		{"dw", "one two three", []rune("w"), []rune("dw"), Position{0, 4}, Position{0, 4}},
+		{"d0", "one two", []rune("w"), []rune("d0"), Position{0, 4}, Position{0, 0}},
+		{"dG", "one\ntwo\nthree", []rune("j"), []rune("dG"), Position{1, 0}, Position{0, 0}},
+		{"x at the end of the line", "one", []rune("$"), []rune("x"), Position{0, 2}, Position{0, 1}},
+		{"r", "one", []rune("l"), []rune("rx"), Position{0, 1}, Position{0, 1}},
+		{"yyp", "one\ntwo", nil, []rune("yyp"), Position{0, 0}, Position{1, 0}},
+		{"yyP", "one\ntwo", []rune("j"), []rune("yyP"), Position{1, 0}, Position{1, 0}},
+		{"diw", "one two three", []rune("wl"), []rune("diw"), Position{0, 5}, Position{0, 4}},
+		{"vd selected forwards", "one two three", []rune("w"), []rune("ved"), Position{0, 4}, Position{0, 4}},
+		{"vd selected backwards", "one two three", []rune("we"), []rune("vbd"), Position{0, 4}, Position{0, 4}},
+		{"Vd selected backwards", "one\ntwo\nthree", []rune("G"), []rune("Vkd"), Position{1, 0}, Position{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newTestEditorWithClipboard(tt.content)
+			keys(e, tt.move...)
+			keys(e, tt.change...)
+			changed := content(e)
+			assert.Equal(t, tt.after, cursorPos(e))
+
+			keys(e, 'u')
+			assert.Equal(t, tt.content, content(e))
+			assert.Equal(t, tt.before, cursorPos(e))
+
+			redo(e)
+			assert.Equal(t, changed, content(e))
+			assert.Equal(t, tt.after, cursorPos(e))
+		})
+	}
+
+	t.Run("cw", func(t *testing.T) {
+		e := newTestEditor("one two three")
+		keys(e, 'w', 'c', 'w')
+		keys(e, []rune("six")...)
+		escape(e)
+		assert.Equal(t, "one six three", content(e))
+
+		// Each typed character is its own change
+		keys(e, 'u', 'u', 'u', 'u')
+		assert.Equal(t, "one two three", content(e))
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+
+		redo(e)
+		assert.Equal(t, "one  three", content(e))
+		assert.Equal(t, Position{0, 4}, cursorPos(e))
+	})
+}
+
+// BenchmarkUndoRedo measures undoing a chain of 100 changes to a buffer of
+// 10,000 lines, then redoing them.
+func BenchmarkUndoRedo(b *testing.B) {
+	e := newTestEditor(manyLines(10_000))
+	for range 100 {
+		keys(e, 'd', 'd', 'j')
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		for range 100 {
+			keys(e, 'u')
+		}
+		for range 100 {
+			redo(e)
+		}
+	}
+	if lines := e.GetBuffer().LineCount(); lines != 9_900 {
+		b.Fatalf("%d lines after undoing and redoing, want 9900", lines)
+	}
+}
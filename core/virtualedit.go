@@ -0,0 +1,208 @@
+package core
+
+// VirtualEdit sets where the cursor may go past the end of a line, like Vim's
+// 'virtualedit' option.
+type VirtualEdit int
+
+const (
+	// VirtualEditOneMore lets the cursor sit just after the last character
+	// in normal mode too, as $ followed by l does. It's the default, and
+	// suits editors without modes built with DisableVimMode.
+	VirtualEditOneMore VirtualEdit = iota
+	// VirtualEditNone keeps the cursor on the last character in normal mode,
+	// as Vim does. Insert mode can always put it after the last character.
+	VirtualEditNone
+	// VirtualEditAll lets h, l and the arrow keys move the cursor anywhere
+	// past the end of the line, and j and k keep its column on shorter lines.
+	// Typing there fills the gap with spaces.
+	VirtualEditAll
+)
+
+// SetVirtualEdit sets where the cursor may go past the end of a line.
+func (e *editor) SetVirtualEdit(mode VirtualEdit) {
+	e.state.VirtualEdit = mode
+	e.clampCursorToLine()
+}
+
+// clampCursorToLine keeps the cursor within the line in normal mode, on its
+// last character unless virtual editing allows more.
+func (e *editor) clampCursorToLine() {
+	if !e.IsNormalMode() || e.state.VirtualEdit == VirtualEditAll {
+		return
+	}
+
+	cursor := e.buffer.GetCursor()
+	line := e.buffer.GetLineRunes(cursor.Position.Row)
+	last := len(line)
+	if e.state.VirtualEdit == VirtualEditNone {
+		last = prevGraphemeCol(line, len(line))
+	}
+	if cursor.Position.Col <= last {
+		return
+	}
+
+	cursor.Position.Col = last
+	if e.state.AvailableWidth > 0 {
+		cursor.Preferred = last % e.state.AvailableWidth
+	}
+	e.buffer.SetCursor(cursor)
+}
+
+// virtualMove moves the cursor for h, j, k, l and the arrow keys when
+// VirtualEditAll lets it go past the end of the line. It reports whether
+// the key was handled. Insert mode only moves with the arrow keys, and
+// Backspace moves left in the gap after the end of the line.
+func (e *editor) virtualMove(key KeyEvent) bool {
+	if e.state.VirtualEdit != VirtualEditAll {
+		return false
+	}
+
+	var count *int
+	switch mode := e.currentMode.(type) {
+	case *normalMode:
+		if mode.isPending() || !isVirtualMotion(key) {
+			return false
+		}
+		count, e.state.PendingCount = e.state.PendingCount, nil
+	case *visualMode:
+		if mode.isWaiting() || !isVirtualMotion(key) {
+			return false
+		}
+		rememberVisualSelection(e, currentVisualSelection(mode.Name(), e.buffer, mode.startPos))
+		count, mode.currentCount = mode.currentCount, nil
+	case *visualLineMode:
+		if mode.isWaiting() || !isVirtualMotion(key) {
+			return false
+		}
+		rememberVisualSelection(e, currentVisualSelection(mode.Name(), e.buffer, mode.startPos))
+		count, mode.currentCount = mode.currentCount, nil
+	case *insertMode:
+		cursor := e.buffer.GetCursor()
+		if key.Key == KeyBackspace && cursor.Position.Col > e.buffer.LineRuneCount(cursor.Position.Row) {
+			// Nothing to delete in the gap
+			key = KeyEvent{Key: KeyLeft}
+		} else if key.Rune != 0 || key.Modifiers != ModNone || e.IsSelected() || !isVirtualMotion(key) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	n := 1
+	if count != nil {
+		n = *count
+	}
+
+	cursor := e.buffer.GetCursor()
+	line := e.buffer.GetLineRunes(cursor.Position.Row)
+	switch {
+	case key.Key == KeyLeft || key.Rune == 'h':
+		for range n {
+			if cursor.Position.Col > len(line) {
+				cursor.Position.Col--
+			} else {
+				cursor.Position.Col = prevGraphemeCol(line, cursor.Position.Col)
+			}
+		}
+	case key.Key == KeyRight || key.Rune == 'l':
+		for range n {
+			if cursor.Position.Col >= len(line) {
+				cursor.Position.Col++
+			} else {
+				cursor.Position.Col = nextGraphemeCol(line, cursor.Position.Col)
+			}
+		}
+	case key.Key == KeyUp || key.Rune == 'k':
+		cursor.Position.Row = max(cursor.Position.Row-n, 0)
+		cursor.Position.Col = graphemeStart(e.buffer.GetLineRunes(cursor.Position.Row), cursor.Position.Col)
+	case key.Key == KeyDown || key.Rune == 'j':
+		cursor.Position.Row = min(cursor.Position.Row+n, e.buffer.LineCount()-1)
+		cursor.Position.Col = graphemeStart(e.buffer.GetLineRunes(cursor.Position.Row), cursor.Position.Col)
+	}
+
+	if e.state.AvailableWidth > 0 {
+		cursor.Preferred = cursor.Position.Col % e.state.AvailableWidth
+	}
+	// Buffers other than the built-in one keep the cursor within the line
+	if b, ok := e.buffer.(*textBuffer); ok {
+		b.setCursorPastEnd(cursor)
+	} else {
+		e.buffer.SetCursor(cursor)
+	}
+	return true
+}
+
+// isVirtualMotion reports whether key is a motion handled by virtualMove.
+func isVirtualMotion(key KeyEvent) bool {
+	switch key.Key {
+	case KeyLeft, KeyRight, KeyUp, KeyDown:
+		return true
+	}
+	switch key.Rune {
+	case 'h', 'j', 'k', 'l':
+		return true
+	}
+	return false
+}
+
+// graphemeStart returns the start of the character at col, or col itself past
+// the end of the line.
+func graphemeStart(line []rune, col int) int {
+	if col >= len(line) {
+		return col
+	}
+	return prevGraphemeCol(line, nextGraphemeCol(line, col))
+}
+
+// leaveVirtualCol moves a cursor past the end of the line back to the end
+// before a key other than a count. Before i and a it returns the cursor, to
+// insert there once the mode has changed.
+func (e *editor) leaveVirtualCol(key KeyEvent) (virtual *Cursor) {
+	if !e.IsNormalMode() && !e.IsVisualMode() && !e.IsVisualLineMode() {
+		return nil
+	}
+	if key.Rune >= '1' && key.Rune <= '9' || key.Rune == '0' && e.HasPendingKeys() {
+		return nil
+	}
+
+	cursor := e.buffer.GetCursor()
+	if cursor.Position.Col <= e.buffer.LineRuneCount(cursor.Position.Row) {
+		return nil
+	}
+	e.buffer.SetCursor(cursor)
+
+	if e.IsNormalMode() && !e.HasPendingKeys() && (key.Rune == 'i' || key.Rune == 'a') {
+		if key.Rune == 'a' {
+			cursor.Position.Col++
+		}
+		return &cursor
+	}
+	return nil
+}
+
+// insertAtVirtualCol puts the cursor back where leaveVirtualCol found it, if
+// the key entered insert mode.
+func (e *editor) insertAtVirtualCol(virtual *Cursor) {
+	if virtual == nil || !e.IsInsertMode() {
+		return
+	}
+	if b, ok := e.buffer.(*textBuffer); ok {
+		b.setCursorPastEnd(*virtual)
+	}
+}
+
+// fillToCursor adds spaces up to a cursor past the end of the line, before
+// typing there.
+func (e *editor) fillToCursor() error {
+	cursor := e.buffer.GetCursor()
+	lineLen := e.buffer.LineRuneCount(cursor.Position.Row)
+	if cursor.Position.Col <= lineLen {
+		return nil
+	}
+
+	spaces := make([]rune, cursor.Position.Col-lineLen)
+	for i := range spaces {
+		spaces[i] = ' '
+	}
+	return e.buffer.InsertRunesAt(cursor.Position.Row, lineLen, spaces)
+}
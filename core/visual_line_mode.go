@@ -6,9 +6,11 @@ import (
 )
 
 type visualLineMode struct {
-	startPos     Position        // Only the Row is relevant for selection extent
-	currentCount *int            // Temporary count parsed within visual line mode
-	charSearch   charSearchState // Character search state (f/F/t/T)
+	startPos       Position        // Only the Row is relevant for selection extent
+	currentCount   *int            // Temporary count parsed within visual line mode
+	charSearch     charSearchState // Character search state (f/F/t/T)
+	waitingReplace bool            // True when waiting for the character after 'r'
+	g              visualGPrefix   // A g that may start gq
 }
 
 func NewVisualLineMode() EditorMode {
@@ -21,20 +23,22 @@ func NewVisualLineMode() EditorMode {
 
 func (m *visualLineMode) Name() Mode { return VisualLineMode }
 
-func (m *visualLineMode) Enter(editor Editor, buffer Buffer) {
-	editor.UpdateStatus("-- VISUAL LINE --")
+func (m *visualLineMode) Enter(editor modeContext, buffer Buffer) {
+	editor.UpdateStatus(editor.Message(MsgVisualLineStatus))
 	editor.UpdateCommand("")
 	// Record selection start position (row matters most)
 	m.startPos = buffer.GetCursor().Position
 	m.currentCount = nil
 	m.charSearch = charSearchState{}
+	m.waitingReplace = false
+	m.g = visualGPrefix{}
 	// Update editor state to reflect visual mode is active (use same flag)
 	state := editor.GetState()
 	state.VisualStart = m.startPos // Use VisualStart to indicate visual active
 	editor.SetState(state)
 }
 
-func (m *visualLineMode) Exit(editor Editor, buffer Buffer) {
+func (m *visualLineMode) Exit(editor modeContext, buffer Buffer) {
 	// Clear visual selection indication in editor state
 	state := editor.GetState()
 	state.VisualStart = Position{Row: -1, Col: -1} // Mark inactive
@@ -51,7 +55,36 @@ func (m *visualLineMode) SetCurrentCount(count *int) {
 	m.currentCount = count
 }
 
-func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *EditorError {
+// isPending reports whether a count or a command waiting for a character has
+// been typed but not used yet.
+func (m *visualLineMode) isPending() bool {
+	return m.currentCount != nil || m.isWaiting()
+}
+
+// isWaiting reports whether a command is waiting for more keys, such as f
+// for its character.
+func (m *visualLineMode) isWaiting() bool {
+	return m.charSearch.waitingForChar || m.waitingReplace
+}
+
+// clearPendingState drops the count and the command waiting for more keys,
+// keeping the selection.
+func (m *visualLineMode) clearPendingState(editor modeContext) {
+	m.currentCount = nil
+	m.charSearch = charSearchState{}
+	m.waitingReplace = false
+	m.g = visualGPrefix{}
+}
+
+func (m *visualLineMode) HandleKey(editor modeContext, buffer Buffer, key KeyEvent) *EditorError {
+	// Remember the selection before the key can end it, for gv
+	rememberVisualSelection(editor, currentVisualSelection(m.Name(), buffer, m.startPos))
+
+	if m.waitingReplace {
+		m.waitingReplace = false
+		return handleVisualReplaceInput(editor, buffer, m.Name(), m.startPos, key)
+	}
+
 	if key.Key == KeyEscape {
 		editor.SetNormalMode()
 		return nil
@@ -69,6 +102,12 @@ func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *
 		}
 	}
 
+	// : runs a command on the selected lines
+	if key.Rune == ':' {
+		startCommand(editor, "'<,'>")
+		return nil
+	}
+
 	count, processedDigit := getMoveCount(m, editor, key)
 
 	// If a digit was just processed, wait for the next key
@@ -76,12 +115,22 @@ func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *
 		return nil
 	}
 
+	// --- gq, reflowing the selected lines, and ge and gE ---
+	if handled, reflowErr := m.g.handle(editor, buffer, m.Name(), m.startPos, key, count); handled {
+		return reflowErr
+	}
+
+	// --- Shift, join, case, replace and swapping the selection ends ---
+	if handled, editErr := applyVisualEdit(editor, buffer, m.Name(), &m.startPos, &m.waitingReplace, key, count); handled {
+		return editErr
+	}
+
 	state := editor.GetState()
 
 	// --- Visual Line Mode Actions ---
 	switch key.Rune {
 	case 'd', 'x': // Delete/Cut selected lines
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
@@ -103,7 +152,7 @@ func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *
 		if err == nil {
 			editor.SaveHistory()
 			editor.SetNormalMode()
-			editor.DispatchSignal(DeleteSignal{content: contentDeleted})
+			editor.DispatchSignal(DeleteSignal{newRegisterText(contentDeleted, Position{Row: startRow}, true)})
 		}
 
 		actionTaken = true
@@ -117,46 +166,25 @@ func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *
 		}
 		actionTaken = true
 
-	case 'p':
-		if !state.WithInsertMode {
+	case 'p': // Replace the selection with the clipboard content
+		if !editor.allow(EditText) {
 			return nil
 		}
 
-		startRow, endRow := m.startPos.Row, cursor.Position.Row
-		if startRow > endRow {
-			startRow, endRow = endRow, startRow // Ensure start <= end
-		}
-
-		initialCursor := buffer.GetCursor()
-		initialCursor.Position.Row = startRow
-		buffer.SetCursor(initialCursor)
-
-		_, err = deleteLineRange(editor, buffer, startRow, endRow)
-
-		if err == nil {
-			editor.SaveHistory()
-			editor.SetNormalMode()
-		}
-
-		actionTaken = true
-
-		content, pasteErr := editor.Paste()
-		count = len(content)
-
+		_, pasteErr := editor.PasteOverSelection()
 		if pasteErr != nil {
 			err = &EditorError{
 				id:  ErrFailedToPasteId,
 				err: pasteErr,
 			}
-		} else {
-			editor.DispatchSignal(PasteSignal{content: content})
 		}
+		editor.SetNormalMode()
 
 		actionTaken = true
 		editor.ResetPendingCount()
 
 	case 'c': // Change selected text (delete + enter insert)
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
@@ -190,10 +218,14 @@ func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *
 		editor.SetSearchMode()
 
 	case 'n':
-		cursor = editor.NextSearchResult()
+		if editor.allow(Search) {
+			cursor = editor.NextSearchResult()
+		}
 
 	case 'N':
-		cursor = editor.PreviousSearchResult()
+		if editor.allow(Search) {
+			cursor = editor.PreviousSearchResult()
+		}
 	}
 
 	if actionTaken {
@@ -208,10 +240,10 @@ func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *
 	moveCount := count // Use 'count' for actual move amount calculation
 	switch key.Key {   // Use Key for arrows/pgup/dn
 	case KeyDown:
-		cursor.MoveDown(buffer, moveCount, availableWidth)
+		editor.MoveLines(&cursor, moveCount, state.DisplayLineMovement)
 		movementAttempted = true
 	case KeyUp:
-		moveErr = cursor.MoveUp(buffer, moveCount, availableWidth)
+		moveErr = editor.MoveLines(&cursor, -moveCount, state.DisplayLineMovement)
 		movementAttempted = true
 	case KeyPageDown:
 		if count == 1 {
@@ -266,4 +298,3 @@ func (m *visualLineMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *
 
 	return err
 }
-
@@ -9,6 +9,8 @@ type visualMode struct {
 	currentCount    *int            // Temporary count parsed within visual mode
 	charSearch      charSearchState // Character search state (f/F/t/T)
 	pendingModifier rune            // 'i' or 'a' when waiting for text object key
+	waitingReplace  bool            // True when waiting for the character after 'r'
+	g               visualGPrefix   // A g that may start gq
 }
 
 func NewVisualMode() EditorMode {
@@ -20,14 +22,16 @@ func NewVisualMode() EditorMode {
 }
 func (m *visualMode) Name() Mode { return VisualMode }
 
-func (m *visualMode) Enter(editor Editor, buffer Buffer) {
-	editor.UpdateStatus("-- VISUAL --")
+func (m *visualMode) Enter(editor modeContext, buffer Buffer) {
+	editor.UpdateStatus(editor.Message(MsgVisualStatus))
 	editor.UpdateCommand("")
 	// Record selection start position
 	m.startPos = buffer.GetCursor().Position
 	m.currentCount = nil
 	m.charSearch = charSearchState{}
 	m.pendingModifier = 0
+	m.waitingReplace = false
+	m.g = visualGPrefix{}
 	// Update editor state to reflect visual mode is active
 	state := editor.GetState()
 	state.VisualStart = m.startPos
@@ -35,7 +39,7 @@ func (m *visualMode) Enter(editor Editor, buffer Buffer) {
 	editor.SetState(state)
 }
 
-func (m *visualMode) Exit(editor Editor, buffer Buffer) {
+func (m *visualMode) Exit(editor modeContext, buffer Buffer) {
 	// Clear visual selection indication in editor state
 	state := editor.GetState()
 	state.VisualStart = Position{Row: -1, Col: -1} // Mark inactive
@@ -60,7 +64,39 @@ func (m *visualMode) SetCurrentCount(count *int) {
 	m.currentCount = count
 }
 
-func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *EditorError {
+// isPending reports whether a count or a command waiting for a character has
+// been typed but not used yet.
+func (m *visualMode) isPending() bool {
+	return m.currentCount != nil || m.isWaiting()
+}
+
+// isWaiting reports whether a command is waiting for more keys, such as f
+// for its character.
+func (m *visualMode) isWaiting() bool {
+	return m.pendingModifier != 0 ||
+		m.charSearch.waitingForChar ||
+		m.waitingReplace
+}
+
+// clearPendingState drops the count and the command waiting for more keys,
+// keeping the selection.
+func (m *visualMode) clearPendingState(editor modeContext) {
+	m.currentCount = nil
+	m.charSearch = charSearchState{}
+	m.pendingModifier = 0
+	m.waitingReplace = false
+	m.g = visualGPrefix{}
+}
+
+func (m *visualMode) HandleKey(editor modeContext, buffer Buffer, key KeyEvent) *EditorError {
+	// Remember the selection before the key can end it, for gv
+	rememberVisualSelection(editor, currentVisualSelection(m.Name(), buffer, m.startPos))
+
+	if m.waitingReplace {
+		m.waitingReplace = false
+		return handleVisualReplaceInput(editor, buffer, m.Name(), m.startPos, key)
+	}
+
 	if key.Key == KeyEscape {
 		editor.SetNormalMode()
 		return nil
@@ -77,6 +113,12 @@ func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		}
 	}
 
+	// : runs a command on the selected lines
+	if key.Rune == ':' && m.pendingModifier == 0 {
+		startCommand(editor, "'<,'>")
+		return nil
+	}
+
 	count, processedDigit := getMoveCount(m, editor, key)
 
 	// If a digit was just processed, wait for the next key
@@ -89,8 +131,9 @@ func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		modifier := m.pendingModifier
 		m.pendingModifier = 0
 		switch key.Rune {
-		case 'w': // viw / vaw — adjust selection to cover the word
-			startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, editor.IsWordChar)
+		case 'w', 'W': // viw / vaW — adjust selection to cover the word or WORD
+			isWordChar, _ := wordTextObjectChars(editor, key.Rune)
+			startCol, endCol, found := wordTextObjectRange(buffer, cursor.Position, modifier, isWordChar)
 			if found {
 				m.startPos = Position{Row: cursor.Position.Row, Col: startCol}
 				state := editor.GetState()
@@ -111,16 +154,63 @@ func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 				cursor.Position.Row = endRow
 				buffer.SetCursor(cursor)
 			}
+		case '(', ')', 'b', '[', ']', '{', '}', 'B', '<', '>', '"', '\'', '`': // vi( / va" — select inside/around brackets and quotes
+			if r, found := delimitedTextObjectRange(buffer, cursor.Position, modifier, key.Rune); found {
+				start, end := r.start, r.end
+				if r.linewise {
+					end.Col = buffer.LineRuneCount(end.Row)
+				}
+				// The selection end is inclusive
+				if end.Col > 0 {
+					end.Col = prevGraphemeCol(buffer.GetLineRunes(end.Row), end.Col)
+				}
+				m.startPos = start
+				state := editor.GetState()
+				state.VisualStart = m.startPos
+				editor.SetState(state)
+				cursor.Position = end
+				buffer.SetCursor(cursor)
+			}
+		case 's': // vis / vas — select inside/around the statement
+			syntax := editor.GetState().StatementSyntax
+			if syntax == nil {
+				break
+			}
+			if r, found := statementTextObjectRange(buffer, syntax, cursor.Position, modifier); found {
+				// The selection end is inclusive, on the line break when the range ends after one
+				end := r.end
+				if end.Col > 0 {
+					end.Col = prevGraphemeCol(buffer.GetLineRunes(end.Row), end.Col)
+				} else if end.Row > r.start.Row {
+					end = Position{Row: end.Row - 1, Col: buffer.LineRuneCount(end.Row - 1)}
+				}
+				m.startPos = r.start
+				state := editor.GetState()
+				state.VisualStart = m.startPos
+				editor.SetState(state)
+				cursor.Position = end
+				buffer.SetCursor(cursor)
+			}
 		}
 		return nil
 	}
 
+	// --- gq, reflowing the selected lines, and ge and gE ---
+	if handled, reflowErr := m.g.handle(editor, buffer, m.Name(), m.startPos, key, count); handled {
+		return reflowErr
+	}
+
+	// --- Shift, join, case, replace and swapping the selection ends ---
+	if handled, editErr := applyVisualEdit(editor, buffer, m.Name(), &m.startPos, &m.waitingReplace, key, count); handled {
+		return editErr
+	}
+
 	state := editor.GetState()
 
 	// --- Visual Mode Actions ---
 	switch key.Rune {
 	case 'd', 'x': // Delete/Cut selected text
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
@@ -130,6 +220,7 @@ func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 		var finalPos Position
 		var contentDeleted string
+		start, _ := NormalizeSelection(m.startPos, cursor.Position)
 		contentDeleted, finalPos, err = deleteVisualSelection(buffer, m.startPos, cursor.Position)
 
 		if err == nil {
@@ -141,16 +232,20 @@ func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 
 		actionTaken = true
 		editor.ResetPendingCount()
-		editor.DispatchSignal(DeleteSignal{content: contentDeleted})
+		editor.DispatchSignal(DeleteSignal{newRegisterText(contentDeleted, start, false)})
 
 	case '/':
 		editor.SetSearchMode()
 
 	case 'n':
-		cursor = editor.NextSearchResult()
+		if editor.allow(Search) {
+			cursor = editor.NextSearchResult()
+		}
 
 	case 'N':
-		cursor = editor.PreviousSearchResult()
+		if editor.allow(Search) {
+			cursor = editor.PreviousSearchResult()
+		}
 
 	case 'y': // Yank (Copy) selected text
 		if copyErr := editor.Copy(yankType); copyErr != nil {
@@ -162,38 +257,25 @@ func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		actionTaken = true
 		editor.ResetPendingCount()
 
-	case 'p':
-		if !state.WithInsertMode {
+	case 'p': // Replace the selection with the clipboard content
+		if !editor.allow(EditText) {
 			return nil
 		}
 
-		var finalPos Position
-		_, finalPos, err = deleteVisualSelection(buffer, m.startPos, cursor.Position)
-
-		if err == nil {
-			cursor.Position = finalPos
-			buffer.SetCursor(cursor)
-			editor.SaveHistory()
-			editor.SetNormalMode()
-		}
-
-		content, pasteErr := editor.Paste()
-		count = len(content)
-
+		_, pasteErr := editor.PasteOverSelection()
 		if pasteErr != nil {
 			err = &EditorError{
 				id:  ErrFailedToPasteId,
 				err: pasteErr,
 			}
-		} else {
-			editor.DispatchSignal(PasteSignal{content: content})
 		}
+		editor.SetNormalMode()
 
 		actionTaken = true
 		editor.ResetPendingCount()
 
 	case 'c': // Change selected text (delete + enter insert)
-		if !state.WithInsertMode {
+		if !editor.allow(EditText) {
 			return nil
 		}
 
@@ -236,7 +318,6 @@ func (m *visualMode) HandleKey(editor Editor, buffer Buffer, key KeyEvent) *Edit
 		count = *state.PendingCount
 		countWasPending = true
 		editor.SetState(state)
-		editor.UpdateCommand("")
 	}
 
 	col := cursor.Position.Col
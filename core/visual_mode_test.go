@@ -338,3 +338,173 @@ func TestVisualModeMovementSequences(t *testing.T) {
 		assert.Equal(t, Position{0, 6}, cursorPos(e))
 	})
 }
+
+// TestVisualModeShift tests '>' and '<' in both visual modes.
+func TestVisualModeShift(t *testing.T) {
+	t.Run("> indents every selected line with a tab", func(t *testing.T) {
+		e := newTestEditor("a\nb\nc")
+		keys(e, 'V', 'j', '>')
+		assert.Equal(t, "\ta\n\tb\nc", content(e))
+		assert.True(t, e.IsNormalMode())
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+	})
+
+	t.Run("count shifts several times and empty lines are skipped", func(t *testing.T) {
+		e := newTestEditor("a\n\nb")
+		keys(e, 'v', 'j', 'j', '2', '>')
+		assert.Equal(t, "\t\ta\n\n\t\tb", content(e))
+	})
+
+	t.Run("< removes a tab or up to four spaces", func(t *testing.T) {
+		e := newTestEditor("\tx\n      y\n  z")
+		keys(e, 'V', 'j', 'j', '<')
+		assert.Equal(t, "x\n  y\nz", content(e))
+	})
+
+	t.Run("undo restores the indent in one step", func(t *testing.T) {
+		e := newTestEditor("a\nb")
+		keys(e, 'V', 'j', '>', 'u')
+		assert.Equal(t, "a\nb", content(e))
+	})
+}
+
+// TestVisualModeJoin tests 'J' in both visual modes.
+func TestVisualModeJoin(t *testing.T) {
+	t.Run("joins the selected lines with single spaces", func(t *testing.T) {
+		e := newTestEditor("one\n    two\nthree\nfour")
+		keys(e, 'V', 'j', 'j', 'J')
+		assert.Equal(t, "one two three\nfour", content(e))
+		assert.Equal(t, Position{0, 7}, cursorPos(e))
+		assert.True(t, e.IsNormalMode())
+	})
+
+	t.Run("a single line is joined with the next", func(t *testing.T) {
+		e := newTestEditor("one\ntwo")
+		keys(e, 'v', 'J')
+		assert.Equal(t, "one two", content(e))
+	})
+
+	t.Run("no space is added before ) or after trailing whitespace", func(t *testing.T) {
+		e := newTestEditor("f(a\n)\nb \nc")
+		keys(e, 'V', 'j', 'J')
+		assert.Equal(t, "f(a)\nb \nc", content(e))
+		keys(e, 'j', 'V', 'j', 'J')
+		assert.Equal(t, "f(a)\nb c", content(e))
+	})
+}
+
+// TestVisualModeCase tests '~', 'u' and 'U' in both visual modes.
+func TestVisualModeCase(t *testing.T) {
+	t.Run("~ toggles the selection", func(t *testing.T) {
+		e := newTestEditor("Hello World")
+		keys(e, 'v', 'e', '~')
+		assert.Equal(t, "hELLO World", content(e))
+		assert.Equal(t, Position{0, 0}, cursorPos(e))
+		assert.True(t, e.IsNormalMode())
+	})
+
+	t.Run("U uppercases across lines", func(t *testing.T) {
+		e := newTestEditor("ab\ncd")
+		keys(e, 'l', 'v', 'j', '$', 'U')
+		assert.Equal(t, "aB\nCD", content(e))
+	})
+
+	t.Run("u lowercases whole lines in visual line mode", func(t *testing.T) {
+		e := newTestEditor("ÄB\nCD\nEF")
+		keys(e, 'V', 'j', 'u')
+		assert.Equal(t, "äb\ncd\nEF", content(e))
+	})
+}
+
+// TestVisualModeReplace tests 'r{char}' in both visual modes.
+func TestVisualModeReplace(t *testing.T) {
+	t.Run("replaces every selected character", func(t *testing.T) {
+		e := newTestEditor("hello world")
+		keys(e, 'v', 'e', 'r', 'x')
+		assert.Equal(t, "xxxxx world", content(e))
+		assert.True(t, e.IsNormalMode())
+	})
+
+	t.Run("keeps line breaks and counts a grapheme cluster once", func(t *testing.T) {
+		e := newTestEditor("ab\néf")
+		keys(e, 'V', 'j', 'r', '-')
+		assert.Equal(t, "--\n--", content(e))
+	})
+
+	t.Run("escape cancels and keeps the selection", func(t *testing.T) {
+		e := newTestEditor("hello")
+		keys(e, 'v', 'l', 'r')
+		escape(e)
+		assert.Equal(t, "hello", content(e))
+		assert.True(t, e.IsVisualMode())
+	})
+}
+
+// TestVisualModeSwapEnds tests 'o' in both visual modes.
+func TestVisualModeSwapEnds(t *testing.T) {
+	t.Run("o moves the cursor to the other end", func(t *testing.T) {
+		e := newTestEditor("hello world")
+		keys(e, 'l', 'v', 'e', 'o')
+		assert.Equal(t, Position{0, 1}, cursorPos(e))
+		assert.Equal(t, Position{0, 4}, e.GetState().VisualStart)
+
+		// The selection now grows from the other end
+		keys(e, 'h', 'd')
+		assert.Equal(t, " world", content(e))
+	})
+
+	t.Run("o in visual line mode", func(t *testing.T) {
+		e := newTestEditor("a\nb\nc\nd")
+		keys(e, 'j', 'V', 'j', 'o', 'k', 'd')
+		assert.Equal(t, "d", content(e))
+	})
+}
+
+// TestVisualModeReselect tests 'gv' in normal mode.
+func TestVisualModeReselect(t *testing.T) {
+	t.Run("gv restores the last characterwise selection", func(t *testing.T) {
+		e := newTestEditor("hello world\nfoo")
+		keys(e, 'w', 'v', 'e')
+		escape(e)
+		keys(e, 'j', 'g', 'v')
+		assert.True(t, e.IsVisualMode())
+		assert.Equal(t, Position{0, 6}, e.GetState().VisualStart)
+		assert.Equal(t, Position{0, 10}, cursorPos(e))
+	})
+
+	t.Run("gv restores visual line mode after an operator", func(t *testing.T) {
+		e := newTestEditor("a\nb\nc")
+		keys(e, 'V', 'j', '>', 'g', 'v')
+		assert.True(t, e.IsVisualLineMode())
+		keys(e, '<')
+		assert.Equal(t, "a\nb\nc", content(e))
+	})
+
+	t.Run("gv without a previous selection does nothing", func(t *testing.T) {
+		e := newTestEditor("a\nb")
+		keys(e, 'g', 'v')
+		assert.True(t, e.IsNormalMode())
+	})
+}
+
+// TestVisualModeDelimitedTextObjects tests bracket and quote text object selections in visual mode.
+func TestVisualModeDelimitedTextObjects(t *testing.T) {
+	t.Run("vi( selects inside the parentheses", func(t *testing.T) {
+		e := newTestEditor("f(a, b)")
+		keys(e, 'f', 'b', 'v', 'i', '(', 'd')
+		assert.Equal(t, "f()", content(e))
+	})
+
+	t.Run("va\" selects the quotes and trailing space", func(t *testing.T) {
+		e := newTestEditor(`x = "hi" + y`)
+		keys(e, 'f', 'i', 'v', 'a', '"', 'd')
+		assert.Equal(t, "x = + y", content(e))
+	})
+
+	t.Run("vi{ on a block selects the lines inside", func(t *testing.T) {
+		e := newTestEditor("if x {\n\ta()\n\tb()\n}")
+		keys(e, 'j', 'v', 'i', '{')
+		assert.Equal(t, Position{1, 0}, e.GetState().VisualStart)
+		assert.Equal(t, Position{2, 3}, cursorPos(e))
+	})
+}
@@ -2,7 +2,7 @@ package core
 
 // applyVisualMotion handles motion keys shared by all visual modes.
 //
-// Covers: j/k, Ctrl-D/U, {/}, 0/$, ^, g, G, Enter, w/e/b, f/F/t/T, ;/,
+// Covers: j/k, Ctrl-D/U, {/}, (/), 0/$, ^, g, G, Enter, w/e/b, f/F/t/T, ;/,
 // Excludes:
 //   - h/l  — count differs between charwise (user count) and line (always 1)
 //   - PageUp/PageDown, arrow keys — line mode only (handled via key.Key in the outer switch)
@@ -15,7 +15,7 @@ package core
 // earlyReturn=true signals the caller must return nil immediately (charSearch initiated).
 func applyVisualMotion(
 	cs *charSearchState,
-	editor Editor,
+	editor modeContext,
 	buffer Buffer,
 	cursor *Cursor,
 	key KeyEvent,
@@ -26,10 +26,10 @@ func applyVisualMotion(
 	viewportHeight := state.ViewportHeight
 	switch {
 	case key.Rune == 'j' || key.Key == KeyDown:
-		moveErr = cursor.MoveDown(buffer, count, availableWidth)
+		moveErr = editor.MoveLines(cursor, count, state.DisplayLineMovement)
 		movementAttempted = true
 	case key.Rune == 'k' || key.Key == KeyUp:
-		moveErr = cursor.MoveUp(buffer, count, availableWidth)
+		moveErr = editor.MoveLines(cursor, -count, state.DisplayLineMovement)
 		movementAttempted = true
 	case key.Key == KeyCtrlD:
 		moveErr = cursor.ScrollDown(buffer, viewportHeight, availableWidth)
@@ -43,6 +43,12 @@ func applyVisualMotion(
 	case key.Rune == '}':
 		moveErr = cursor.MoveBlockForward(buffer, count)
 		movementAttempted = true
+	case key.Rune == '(':
+		moveErr = cursor.MoveSentenceBackward(buffer, count)
+		movementAttempted = true
+	case key.Rune == ')':
+		moveErr = cursor.MoveSentenceForward(buffer, count)
+		movementAttempted = true
 	case key.Rune == '0' || key.Key == KeyHome:
 		cursor.MoveToLineStart()
 		movementAttempted = true
@@ -62,32 +68,23 @@ func applyVisualMotion(
 		if count > 0 {
 			cursor.Position.Row = count - 1
 			buffer.SetCursor(*cursor)
-			editor.UpdateCommand("")
 			editor.ResetPendingCount()
 		}
 		movementAttempted = true
 	case key.Rune == 'f':
-		cs.searchType = 'f'
-		cs.waitingForChar = true
-		editor.UpdateCommand("f")
+		cs.begin('f', count)
 		earlyReturn = true
 	case key.Rune == 'F':
-		cs.searchType = 'F'
-		cs.waitingForChar = true
-		editor.UpdateCommand("F")
+		cs.begin('F', count)
 		earlyReturn = true
 	case key.Rune == 't':
-		cs.searchType = 't'
-		cs.waitingForChar = true
-		editor.UpdateCommand("t")
+		cs.begin('t', count)
 		earlyReturn = true
 	case key.Rune == 'T':
-		cs.searchType = 'T'
-		cs.waitingForChar = true
-		editor.UpdateCommand("T")
+		cs.begin('T', count)
 		earlyReturn = true
 	case key.Rune == ';':
-		repeatCharSearch(cs, editor, buffer, count, false)
+		repeatCharSearch(editor, buffer, count, false)
 		*cursor = buffer.GetCursor()
 		movementAttempted = true
 	case key.Rune == 'w':
@@ -99,8 +96,17 @@ func applyVisualMotion(
 	case key.Rune == 'b':
 		moveErr = cursor.MoveWordBackward(buffer, count, availableWidth, editor.IsWordChar)
 		movementAttempted = true
+	case key.Rune == 'W':
+		moveErr = cursor.MoveWordForward(buffer, count, availableWidth, isWORDChar)
+		movementAttempted = true
+	case key.Rune == 'E':
+		moveErr = cursor.MoveWordToEnd(buffer, count, availableWidth, isWORDChar)
+		movementAttempted = true
+	case key.Rune == 'B':
+		moveErr = cursor.MoveWordBackward(buffer, count, availableWidth, isWORDChar)
+		movementAttempted = true
 	case key.Rune == ',':
-		repeatCharSearch(cs, editor, buffer, count, true)
+		repeatCharSearch(editor, buffer, count, true)
 		*cursor = buffer.GetCursor()
 		movementAttempted = true
 	}
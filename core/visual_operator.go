@@ -0,0 +1,408 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// visualSelection is a selection made in visual or visual line mode.
+type visualSelection struct {
+	mode       Mode     // VisualMode or VisualLineMode, empty if there is none
+	start, end Position // Where the selection started and the cursor; not ordered
+}
+
+// rows returns the first and last selected rows.
+func (s visualSelection) rows() (int, int) {
+	return min(s.start.Row, s.end.Row), max(s.start.Row, s.end.Row)
+}
+
+// textRange returns the selected text as a range, with an exclusive end for
+// characterwise selections.
+func (s visualSelection) textRange(buffer Buffer) textRange {
+	if s.mode == VisualLineMode {
+		startRow, endRow := s.rows()
+		return textRange{start: Position{Row: startRow}, end: Position{Row: endRow}, linewise: true}
+	}
+
+	start, end := NormalizeSelection(s.start, s.end)
+	line := buffer.GetLineRunes(end.Row)
+	end.Col = min(graphemeEndCol(line, end.Col)+1, len(line))
+	return textRange{start: start, end: end}
+}
+
+// currentVisualSelection returns the selection of a visual mode whose anchor is start.
+func currentVisualSelection(mode Mode, buffer Buffer, start Position) visualSelection {
+	return visualSelection{mode: mode, start: start, end: buffer.GetCursor().Position}
+}
+
+// rememberVisualSelection records sel so that gv can restore it.
+func rememberVisualSelection(editor modeContext, sel visualSelection) {
+	state := editor.GetState()
+	state.lastVisual = sel
+	editor.SetState(state)
+}
+
+// reselectVisual restores the last visual selection (gv), clamped to the
+// current content.
+func reselectVisual(editor modeContext, buffer Buffer) {
+	sel := editor.GetState().lastVisual
+	if sel.mode == "" {
+		return
+	}
+
+	clamp := func(pos Position) Position {
+		row := min(max(pos.Row, 0), buffer.LineCount()-1)
+		col := min(max(pos.Col, 0), max(buffer.LineRuneCount(row)-1, 0))
+		return Position{Row: row, Col: col}
+	}
+
+	// Entering the mode anchors the selection at the cursor
+	cursor := buffer.GetCursor()
+	cursor.Position = clamp(sel.start)
+	buffer.SetCursor(cursor)
+	if sel.mode == VisualLineMode {
+		editor.SetVisualLineMode()
+	} else {
+		editor.SetVisualMode()
+	}
+
+	cursor = buffer.GetCursor()
+	cursor.Position = clamp(sel.end)
+	buffer.SetCursor(cursor)
+}
+
+// visualGPrefix is a g typed in a visual mode. Like gg, it has moved the
+// cursor to the first line, but it may start gq, ge or gE instead.
+type visualGPrefix struct {
+	active bool
+	origin Cursor // Where the cursor was before g
+	count  int    // Count typed before g
+}
+
+// handle completes gq, which reflows the lines of the selection, and ge and
+// gE, which move back to the end of a word, from where the cursor was before
+// g, and remembers where a g was typed with count. Returns handled=false for
+// every other key, g moving as gg does.
+func (g *visualGPrefix) handle(editor modeContext, buffer Buffer, mode Mode, anchor Position, key KeyEvent, count int) (handled bool, err *EditorError) {
+	prefixed, origin, gCount := g.active, g.origin, g.count
+	*g = visualGPrefix{}
+	if key.Rune == 'g' && !prefixed {
+		*g = visualGPrefix{active: true, origin: buffer.GetCursor(), count: count}
+		return false, nil
+	}
+	if prefixed && (key.Rune == 'e' || key.Rune == 'E') {
+		_ = origin.MoveWordEndBackward(buffer, gCount, editor.GetState().AvailableWidth, motionWordChars(editor, key.Rune))
+		buffer.SetCursor(origin)
+		return true, nil
+	}
+	if !prefixed || key.Rune != 'q' {
+		return false, nil
+	}
+
+	buffer.SetCursor(origin)
+	editor.ResetPendingCount()
+	if !editor.allow(EditText) {
+		return true, nil
+	}
+
+	sel := currentVisualSelection(mode, buffer, anchor)
+	rememberVisualSelection(editor, sel)
+	startRow, endRow := sel.rows()
+	if err := reflowRows(editor, buffer, startRow, endRow); err != nil {
+		return true, err
+	}
+	editor.SetNormalMode()
+	return true, nil
+}
+
+// swapVisualEnds moves the cursor to the other end of the selection (o) and
+// returns the new anchor.
+func swapVisualEnds(editor modeContext, buffer Buffer, anchor Position) Position {
+	cursor := buffer.GetCursor()
+	newAnchor := cursor.Position
+	cursor.Position = anchor
+	buffer.SetCursor(cursor)
+
+	state := editor.GetState()
+	state.VisualStart = newAnchor
+	editor.SetState(state)
+
+	return newAnchor
+}
+
+// shiftLines indents (>) or outdents (<) the rows count times. Indenting adds a
+// tab, or the tab width in spaces with ExpandTab, and skips empty lines;
+// outdenting removes a leading tab or up to the tab width in leading spaces
+// per step.
+func shiftLines(editor modeContext, buffer Buffer, startRow, endRow, count int, outdent bool) *EditorError {
+	state := editor.GetState()
+	shiftWidth := tabWidth(state)
+	indent := "\t"
+	if state.ExpandTab {
+		indent = strings.Repeat(" ", shiftWidth)
+	}
+	changed := false
+
+	for row := startRow; row <= endRow; row++ {
+		line := buffer.GetLineRunes(row)
+
+		if !outdent {
+			if len(line) == 0 {
+				continue
+			}
+			if err := buffer.InsertRunesAt(row, 0, []rune(strings.Repeat(indent, count))); err != nil {
+				return &EditorError{id: ErrInvalidPositionId, err: err}
+			}
+			changed = true
+			continue
+		}
+
+		remove := 0
+		for range count {
+			if remove < len(line) && line[remove] == '\t' {
+				remove++
+				continue
+			}
+			spaces := 0
+			for remove+spaces < len(line) && spaces < shiftWidth && line[remove+spaces] == ' ' {
+				spaces++
+			}
+			if spaces == 0 {
+				break
+			}
+			remove += spaces
+		}
+
+		if remove > 0 {
+			if err := buffer.DeleteRunesAt(row, 0, remove); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+
+	cursor := buffer.GetCursor()
+	cursor.Position = Position{Row: startRow}
+	buffer.SetCursor(cursor)
+	cursor = buffer.GetCursor()
+	cursor.MoveToFirstNonBlank(buffer, editor.GetState().AvailableWidth)
+	buffer.SetCursor(cursor)
+
+	if changed {
+		editor.SaveHistory()
+	}
+	return nil
+}
+
+// joinLines joins the rows into one line (J). Leading whitespace of each joined
+// line is replaced by a single space, which is left out when the line so far
+// ends in whitespace or the joined line is empty or starts with ')'. A single
+// row is joined with the row below it.
+func joinLines(editor modeContext, buffer Buffer, startRow, endRow int) *EditorError {
+	if endRow == startRow {
+		endRow++
+	}
+	endRow = min(endRow, buffer.LineCount()-1)
+	if endRow <= startRow {
+		return nil
+	}
+
+	joinCol := 0
+	for range endRow - startRow {
+		line := buffer.GetLineRunes(startRow)
+		next := buffer.GetLineRunes(startRow + 1)
+
+		indent := 0
+		for indent < len(next) && isWhiteSpace(next[indent]) {
+			indent++
+		}
+
+		// Remove the line break and the indent of the next line
+		joinCol = len(line)
+		if err := buffer.DeleteRunesAt(startRow, joinCol, indent+1); err != nil {
+			return err
+		}
+
+		rest := next[indent:]
+		endsInSpace := len(line) > 0 && isWhiteSpace(line[len(line)-1])
+		if len(line) > 0 && len(rest) > 0 && !endsInSpace && rest[0] != ')' {
+			if err := buffer.InsertRunesAt(startRow, joinCol, []rune{' '}); err != nil {
+				return &EditorError{id: ErrInvalidPositionId, err: err}
+			}
+		}
+	}
+
+	// The cursor goes to where the last line was joined
+	cursor := buffer.GetCursor()
+	cursor.Position = Position{Row: startRow, Col: min(joinCol, max(buffer.LineRuneCount(startRow)-1, 0))}
+	buffer.SetCursor(cursor)
+	editor.SaveHistory()
+	return nil
+}
+
+// mapRange replaces every rune in r with f(rune), leaving line breaks alone.
+// It is used by ~, u and U, and returns whether anything changed.
+func mapRange(buffer Buffer, r textRange, f func(rune) rune) (bool, *EditorError) {
+	changed := false
+
+	for row := r.start.Row; row <= r.end.Row; row++ {
+		line := buffer.GetLineRunes(row)
+		lo, hi := r.colsInRow(row, len(line))
+		if lo >= hi {
+			continue
+		}
+
+		mapped := make([]rune, hi-lo)
+		for i, c := range line[lo:hi] {
+			mapped[i] = f(c)
+		}
+		if string(mapped) == string(line[lo:hi]) {
+			continue
+		}
+
+		if err := buffer.DeleteRunesAt(row, lo, hi-lo); err != nil {
+			return changed, err
+		}
+		if err := buffer.InsertRunesAt(row, lo, mapped); err != nil {
+			return changed, &EditorError{id: ErrInvalidPositionId, err: err}
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// toggleCase swaps the case of a letter (~).
+func toggleCase(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+// caseSelection applies f (toggleCase, unicode.ToLower or unicode.ToUpper) to
+// the selection and leaves the cursor at its start.
+func caseSelection(editor modeContext, buffer Buffer, sel visualSelection, f func(rune) rune) *EditorError {
+	r := sel.textRange(buffer)
+	changed, err := mapRange(buffer, r, f)
+	if err != nil {
+		return err
+	}
+
+	cursor := buffer.GetCursor()
+	cursor.Position = r.start
+	buffer.SetCursor(cursor)
+
+	if changed {
+		editor.SaveHistory()
+	}
+	return nil
+}
+
+// replaceSelection replaces every character of the selection with ch (r{char}).
+// A grapheme cluster counts as one character.
+func replaceSelection(editor modeContext, buffer Buffer, sel visualSelection, ch rune) *EditorError {
+	r := sel.textRange(buffer)
+	changed := false
+
+	for row := r.start.Row; row <= r.end.Row; row++ {
+		line := buffer.GetLineRunes(row)
+		lo, hi := r.colsInRow(row, len(line))
+		if lo >= hi {
+			continue
+		}
+
+		var replacement []rune
+		for col := lo; col < hi; col = nextGraphemeCol(line, col) {
+			replacement = append(replacement, ch)
+		}
+
+		if err := buffer.DeleteRunesAt(row, lo, hi-lo); err != nil {
+			return err
+		}
+		if err := buffer.InsertRunesAt(row, lo, replacement); err != nil {
+			return &EditorError{id: ErrInvalidPositionId, err: err}
+		}
+		changed = true
+	}
+
+	cursor := buffer.GetCursor()
+	cursor.Position = r.start
+	buffer.SetCursor(cursor)
+
+	if changed {
+		editor.SaveHistory()
+	}
+	return nil
+}
+
+// applyVisualEdit handles the editing keys shared by both visual modes:
+// > and < (shift count times), J (join), ~ u U (case), r (replace every
+// character; waits for the character via waitingForReplace) and o (swap the
+// ends of the selection, which updates *anchor).
+//
+// Returns handled=false if key is not one of them.
+func applyVisualEdit(
+	editor modeContext,
+	buffer Buffer,
+	mode Mode,
+	anchor *Position,
+	waitingForReplace *bool,
+	key KeyEvent,
+	count int,
+) (handled bool, err *EditorError) {
+	if key.Rune == 'o' {
+		*anchor = swapVisualEnds(editor, buffer, *anchor)
+		return true, nil
+	}
+
+	switch key.Rune {
+	case '>', '<', 'J', '~', 'u', 'U', 'r':
+	default:
+		return false, nil
+	}
+
+	if !editor.allow(EditText) {
+		return true, nil
+	}
+
+	sel := currentVisualSelection(mode, buffer, *anchor)
+	startRow, endRow := sel.rows()
+
+	switch key.Rune {
+	case '>', '<':
+		err = shiftLines(editor, buffer, startRow, endRow, count, key.Rune == '<')
+	case 'J':
+		err = joinLines(editor, buffer, startRow, endRow)
+	case '~':
+		err = caseSelection(editor, buffer, sel, toggleCase)
+	case 'u':
+		err = caseSelection(editor, buffer, sel, unicode.ToLower)
+	case 'U':
+		err = caseSelection(editor, buffer, sel, unicode.ToUpper)
+	case 'r':
+		*waitingForReplace = true
+		return true, nil
+	}
+
+	editor.ResetPendingCount()
+	if err == nil {
+		editor.SetNormalMode()
+	}
+	return true, err
+}
+
+// handleVisualReplaceInput completes r{char} in the visual modes. Escape
+// cancels it and keeps the selection.
+func handleVisualReplaceInput(editor modeContext, buffer Buffer, mode Mode, anchor Position, key KeyEvent) *EditorError {
+	if key.Key == KeyEscape || key.Rune == 0 {
+		return nil
+	}
+
+	sel := currentVisualSelection(mode, buffer, anchor)
+	if err := replaceSelection(editor, buffer, sel, key.Rune); err != nil {
+		return err
+	}
+	editor.SetNormalMode()
+	return nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import "strings"
+
+// isWriteCommand reports whether command is :w or :w!, which take a range.
+func isWriteCommand(command string) bool {
+	switch command {
+	case "w", "write", "w!", "write!":
+		return true
+	}
+	return false
+}
+
+// executeWrite runs :w and :w!. args may start with >> to append to the file.
+// A range that doesn't cover the whole buffer writes only its lines, which
+// needs a file name or !, as writing part of the buffer over the file would
+// lose the rest.
+func (e *editor) executeWrite(force bool, lines *lineRange, args []string) *EditorError {
+	target := strings.Join(args, " ")
+	target, appendTo := strings.CutPrefix(target, ">>")
+	target = strings.TrimSpace(target)
+
+	var path *string
+	if target != "" {
+		path = &target
+	}
+
+	if lines != nil && lines.start == 0 && lines.end == e.buffer.LineCount()-1 {
+		lines = nil
+	}
+
+	if lines == nil && !appendTo {
+		if err := e.formatBeforeSave(); err != nil {
+			return err
+		}
+	}
+
+	if path == nil && !force && !appendTo {
+		if lines != nil {
+			return &EditorError{id: ErrPartialWriteId, err: ErrPartialWrite}
+		}
+
+		if !e.buffer.IsModified() {
+			return &EditorError{
+				id:  ErrNoChangesToSaveId,
+				err: ErrNoChangesToSave,
+			}
+		}
+
+		if e.buffer.IsLossy() {
+			return &EditorError{
+				id:  ErrLossyContentId,
+				err: ErrLossyContent,
+			}
+		}
+	}
+
+	e.save(path, lines, appendTo)
+	return nil
+}
+
+// save dispatches the lines to write, or the whole buffer if lines is nil,
+// reporting false if they can't be encoded. Appending or writing part of the
+// buffer doesn't mark it as saved.
+func (e *editor) save(path *string, lines *lineRange, appendTo bool) bool {
+	signal := SaveSignal{path: path, appendTo: appendTo}
+
+	if lines == nil {
+		content, err := e.buffer.EncodedContent()
+		if err != nil {
+			e.DispatchError(ErrFailedToSaveId, err)
+			return false
+		}
+		signal.content = string(content)
+	} else {
+		content, err := e.buffer.Encoding().Encode(e.lineRangeText(*lines))
+		if err != nil {
+			e.DispatchError(ErrFailedToSaveId, err)
+			return false
+		}
+		signal.content = string(content)
+		signal.partial = true
+		signal.start, signal.end = lines.start, lines.end
+	}
+
+	if lines == nil && !appendTo {
+		e.buffer.SaveContent()
+		e.diskContent = nil // Saving overwrites the file's changes
+		// Like Vim, a buffer without a name takes the one it's first saved to
+		if path != nil && e.buffer.FileName() == "" {
+			e.buffer.SetFileName(*path)
+		}
+	}
+	e.DispatchSignal(signal)
+	return true
+}
+
+// lineRangeText returns the lines of r, each ending with the buffer's line
+// ending. The last line of the buffer has none, as when saving all of it.
+func (e *editor) lineRangeText(r lineRange) string {
+	eol := e.buffer.LineEnding().Sequence()
+
+	var text strings.Builder
+	for row := r.start; row <= r.end; row++ {
+		text.WriteString(string(e.buffer.GetLineRunes(row)))
+		if row < e.buffer.LineCount()-1 {
+			text.WriteString(eol)
+		}
+	}
+	return text.String()
+}
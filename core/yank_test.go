@@ -194,3 +194,84 @@ func TestYankAroundParagraph(t *testing.T) {
 		assert.Equal(t, "\nhello\nworld\n", cb.content)
 	})
 }
+
+// TestRegisterSignals tests the text, range and register reported for yanks,
+// deletes and pastes.
+func TestRegisterSignals(t *testing.T) {
+	type reported struct {
+		text       string
+		start, end Position
+		register   rune
+		linewise   bool
+	}
+	// run types the keys and returns the last yank, delete and paste they reported
+	run := func(e modeContext, runes ...rune) (yank, del, paste reported) {
+		drainSignals(e)
+		keys(e, runes...)
+		for sig := nextSignal(e); sig != nil; sig = nextSignal(e) {
+			switch s := sig.(type) {
+			case YankSignal:
+				start, end := s.Range()
+				yank = reported{s.Value(), start, end, s.Register(), s.Linewise()}
+			case DeleteSignal:
+				start, end := s.Range()
+				del = reported{s.Value(), start, end, s.Register(), s.Linewise()}
+			case PasteSignal:
+				start, end := s.Range()
+				paste = reported{s.Value(), start, end, s.Register(), s.Linewise()}
+			}
+		}
+		return yank, del, paste
+	}
+
+	t.Run("yw", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one two")
+		yank, _, _ := run(e, 'w', 'y', 'w')
+		assert.Equal(t, reported{"two", Position{0, 4}, Position{0, 7}, '"', false}, yank)
+	})
+
+	t.Run("2yy", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one\ntwo\nthree")
+		yank, _, _ := run(e, 'l', '2', 'y', 'y')
+		assert.Equal(t, reported{"one\ntwo\n", Position{0, 0}, Position{2, 0}, '"', true}, yank)
+	})
+
+	t.Run("dj", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one two\nthree")
+		_, del, _ := run(e, 'w', 'd', 'j')
+		assert.Equal(t, reported{"one two\nthree\n", Position{0, 0}, Position{2, 0}, '"', true}, del)
+	})
+
+	t.Run("D", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one two")
+		_, del, _ := run(e, 'w', 'D')
+		assert.Equal(t, reported{"two", Position{0, 4}, Position{0, 7}, '"', false}, del)
+	})
+
+	t.Run("visual delete", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one\ntwo")
+		setWidth(e, 80)
+		_, del, _ := run(e, 'v', 'j', 'l', 'd')
+		assert.Equal(t, reported{"one\ntw", Position{0, 0}, Position{1, 2}, '"', false}, del)
+	})
+
+	t.Run("p pastes a line below", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one\ntwo")
+		_, _, paste := run(e, 'y', 'y', 'j', 'p')
+		assert.Equal(t, reported{"one\n", Position{2, 0}, Position{3, 0}, '"', true}, paste)
+	})
+
+	t.Run("P pastes text before the cursor", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("one")
+		cb.content = "x\ny"
+		_, _, paste := run(e, 'l', 'P')
+		assert.Equal(t, reported{"x\ny", Position{0, 1}, Position{1, 1}, '"', false}, paste)
+	})
+
+	t.Run("visual p", func(t *testing.T) {
+		e, cb := newTestEditorWithClipboard("one two")
+		cb.content = "six"
+		_, _, paste := run(e, 'w', 'v', 'e', 'p')
+		assert.Equal(t, reported{"six", Position{0, 4}, Position{0, 7}, '"', false}, paste)
+	})
+}
@@ -0,0 +1,79 @@
+package core
+
+import "slices"
+
+// yankHistorySize is the number of yanked and deleted texts kept for cycling
+// through after a paste.
+const yankHistorySize = 20
+
+// pasteCycle is the last paste, which Ctrl-P and Ctrl-N swap for an older or
+// newer text of the yank history.
+type pasteCycle struct {
+	key        KeyEvent // p or P
+	count      *int     // Count typed before the paste
+	historyPos int      // Undo history position before the paste
+	index      int      // Index in the yank history of the pasted text, or -1
+}
+
+// YankHistory returns the texts yanked and deleted last, newest first.
+func (e *editor) YankHistory() []string {
+	return slices.Clone(e.yankHistory)
+}
+
+// CanCyclePaste reports whether Ctrl-P and Ctrl-N would swap the text just
+// pasted.
+func (e *editor) CanCyclePaste() bool {
+	return e.lastPaste != nil && len(e.yankHistory) > 0
+}
+
+// recordYank puts text at the front of the yank history.
+func (e *editor) recordYank(text string) {
+	if text == "" {
+		return
+	}
+	e.yankHistory = slices.DeleteFunc(e.yankHistory, func(s string) bool { return s == text })
+	e.yankHistory = slices.Insert(e.yankHistory, 0, text)
+	if len(e.yankHistory) > yankHistorySize {
+		e.yankHistory = e.yankHistory[:yankHistorySize]
+	}
+}
+
+// cyclePaste undoes the last paste and pastes the text yanked before it, or
+// after it when older is false, on the clipboard in its place. It wraps around
+// the yank history.
+func (e *editor) cyclePaste(older bool) *EditorError {
+	if !e.allow(ClipboardWrite) {
+		return nil
+	}
+
+	c := e.lastPaste
+	n := len(e.yankHistory)
+	index := c.index + 1
+	if !older {
+		index = c.index - 1
+		if c.index < 0 {
+			index = n - 1
+		}
+	}
+	index = (index%n + n) % n
+
+	for e.historyPos > c.historyPos {
+		if _, err := e.undo(); err != nil {
+			break
+		}
+	}
+
+	if err := e.clipboard.Write(e.yankHistory[index]); err != nil {
+		return &EditorError{id: ErrFailedToPasteId, err: err}
+	}
+
+	if c.count != nil {
+		count := *c.count
+		e.state.PendingCount = &count
+	}
+	err := e.currentMode.HandleKey(e, e.buffer, c.key)
+
+	c.index = index
+	e.lastPaste = c
+	return err
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestYankHistory tests keeping the texts yanked and deleted last.
+func TestYankHistory(t *testing.T) {
+	t.Run("yanks and deletes, newest first", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one two three")
+		keys(e, 'y', 'w', 'w', 'd', 'w')
+		keys(e, 'y', 'y')
+		assert.Equal(t, []string{"one three\n", "two ", "one "}, e.YankHistory())
+	})
+
+	t.Run("a text yanked again moves to the front", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one two")
+		keys(e, 'y', 'w', 'w', 'y', 'w', '0', 'y', 'w')
+		assert.Equal(t, []string{"one ", "two"}, e.YankHistory())
+	})
+
+	t.Run("is bounded", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("")
+		for i := range yankHistorySize + 5 {
+			e.SetContent([]byte(fmt.Sprint(i)))
+			keys(e, 'y', 'y')
+		}
+		history := e.YankHistory()
+		assert.Len(t, history, yankHistorySize)
+		assert.Equal(t, fmt.Sprintf("%d\n", yankHistorySize+4), history[0])
+	})
+}
+
+// TestCyclePaste tests Ctrl-P and Ctrl-N after p or P — swap the pasted text
+// for an older or a newer yank.
+func TestCyclePaste(t *testing.T) {
+	ctrlP := KeyEvent{Key: KeyCtrlP, Modifiers: ModCtrl}
+	ctrlN := KeyEvent{Key: KeyCtrlN, Modifiers: ModCtrl}
+
+	// newYanked returns an editor that yanked the words of "a b c" in turn.
+	newYanked := func() Editor {
+		e, _ := newTestEditorWithClipboard("a b c\nx")
+		keys(e, 'y', 'w', 'w', 'y', 'w', 'w', 'y', 'w', 'j')
+		return e
+	}
+
+	t.Run("Ctrl-P pastes older yanks and Ctrl-N newer ones", func(t *testing.T) {
+		e := newYanked()
+		keys(e, 'p')
+		assert.Equal(t, "a b c\nxc", content(e))
+		assert.True(t, e.Registers().CanCyclePaste())
+
+		e.HandleKey(ctrlP)
+		assert.Equal(t, "a b c\nxb ", content(e))
+		e.HandleKey(ctrlP)
+		assert.Equal(t, "a b c\nxa ", content(e))
+		e.HandleKey(ctrlP) // Wraps around
+		assert.Equal(t, "a b c\nxc", content(e))
+		e.HandleKey(ctrlN)
+		assert.Equal(t, "a b c\nxa ", content(e))
+	})
+
+	t.Run("one undo removes the paste", func(t *testing.T) {
+		e := newYanked()
+		keys(e, 'P')
+		e.HandleKey(ctrlP)
+		assert.Equal(t, "a b c\nb x", content(e))
+		keys(e, 'u')
+		assert.Equal(t, "a b c\nx", content(e))
+	})
+
+	t.Run("repeats the count", func(t *testing.T) {
+		e, _ := newTestEditorWithClipboard("one\ntwo")
+		keys(e, 'y', 'y', 'j', 'y', 'y', '2', 'p')
+		assert.Equal(t, "one\ntwo\ntwo\ntwo", content(e))
+		e.HandleKey(ctrlP)
+		assert.Equal(t, "one\ntwo\none\none", content(e))
+	})
+
+	t.Run("only right after a paste", func(t *testing.T) {
+		e := newYanked()
+		keys(e, 'p', 'h')
+		assert.False(t, e.Registers().CanCyclePaste())
+		e.HandleKey(ctrlP)
+		assert.Equal(t, "a b c\nxc", content(e))
+	})
+}
@@ -0,0 +1,147 @@
+package goeditor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// debugOverlayID is the id of the overlay ShowDebugOverlay shows.
+const debugOverlayID = "goeditor.debug"
+
+// debugOverlay shows what the editor is doing, over the text area.
+type debugOverlay struct {
+	visible bool
+	key     *core.KeyEvent // Shows and hides the overlay, nil for none
+	lastKey string         // Last key the model got, in Vim's notation
+	dropped string         // Why the model kept the last key from the editor, empty if it didn't
+}
+
+// SetLogger sets the logger the editor tells what it does, at
+// core.LevelTrace, to help find out why a key did nothing: each key, with
+// the mode it came in and what it did, keys the model kept from the editor
+// and why, mode changes, saves of the undo history and signals. nil, the
+// default, logs nothing.
+func (m *Model) SetLogger(logger *slog.Logger) {
+	m.logger = logger
+	m.editor.Options().SetLogger(logger)
+}
+
+// trace logs an event at core.LevelTrace, args being its attributes as for
+// slog.Logger.Log.
+func (m *Model) trace(msg string, args ...any) {
+	if m.logger != nil && m.logger.Enabled(context.Background(), core.LevelTrace) {
+		m.logger.Log(context.Background(), core.LevelTrace, msg, args...)
+	}
+}
+
+// dropKey records that the model kept key from the editor, and why.
+func (m *Model) dropKey(key core.KeyEvent, reason string) {
+	m.debug.dropped = reason
+	m.trace("key dropped", "key", key.String(), "reason", reason)
+}
+
+// SetDebugKey sets the key that shows and hides the debug overlay, written
+// in Vim's notation as core.ParseKeys reads it, such as "<A-d>", or "" for
+// none, the default. It works in every mode.
+func (m *Model) SetDebugKey(key string) error {
+	if key == "" {
+		m.debug.key = nil
+		return nil
+	}
+	events, err := core.ParseKeys(key)
+	if err != nil {
+		return err
+	}
+	if len(events) != 1 {
+		return fmt.Errorf("%q is not a single key", key)
+	}
+	m.debug.key = &events[0]
+	return nil
+}
+
+// ShowDebugOverlay shows or hides a box in the top right corner of the text
+// area with what the editor is doing, updated with every key: the mode, the
+// keys of the command being typed, the last key and whether the editor took
+// it, the cursor, the scroll, the lines the layout and highlighting caches
+// cover and the capabilities taken away.
+func (m *Model) ShowDebugOverlay(show bool) {
+	m.debug.visible = show
+	m.dirty |= needsRender
+}
+
+// IsDebugOverlayVisible reports whether the debug overlay is shown.
+func (m *Model) IsDebugOverlayVisible() bool {
+	return m.debug.visible
+}
+
+// isDebugKey reports whether key shows and hides the debug overlay.
+func (m *Model) isDebugKey(key core.KeyEvent) bool {
+	return m.debug.key != nil && sameKey(key, *m.debug.key)
+}
+
+// debugLayer renders the debug overlay, nil if it's hidden.
+func (m *Model) debugLayer() *lipgloss.Layer {
+	if !m.debug.visible {
+		return nil
+	}
+	return m.overlayLayer(overlay{
+		id:       debugOverlayID,
+		position: OverlayPosition{Anchor: AnchorTopRight},
+		content:  m.debugText,
+		zIndex:   3,
+	})
+}
+
+// debugText returns the lines of the debug overlay.
+func (m *Model) debugText() string {
+	state := m.editor.GetState()
+	buffer := m.editor.GetBuffer()
+	cursor := buffer.GetCursor().Position
+
+	lastKey := m.debug.lastKey
+	switch {
+	case lastKey == "":
+		lastKey = "none"
+	case m.debug.dropped != "":
+		lastKey += " (dropped: " + m.debug.dropped + ")"
+	case m.keyConsumed:
+		lastKey += " (consumed)"
+	default:
+		lastKey += " (not consumed)"
+	}
+
+	layout := "none"
+	if n := len(m.visualLayoutCache); n > 0 {
+		first, last := m.visualLayoutCache[0].LogicalRow, m.visualLayoutCache[n-1].LogicalRow
+		layout = fmt.Sprintf("lines %d-%d, %d rows", first+1, last+1, n)
+	}
+
+	rows := [][2]string{
+		{"mode", string(state.Mode)},
+		{"pending", state.PendingKeys},
+		{"last key", lastKey},
+		{"cursor", fmt.Sprintf("%d:%d, row %d of %d", cursor.Row+1, cursor.Col+1, m.cursorAbsoluteVisualRow+1, m.fullVisualLayoutHeight)},
+		{"top", fmt.Sprintf("row %d", m.currentVisualTopLine+1)},
+		{"layout", layout},
+		{"valid", fmt.Sprintf("lines %d-%d", m.cacheValidStartRow+1, m.cacheValidEndRow+1)},
+		{"tokens", fmt.Sprintf("%d lines", len(m.persistentTokenCache))},
+		{"revision", fmt.Sprint(buffer.Revision())},
+	}
+	if blocked := core.AllCapabilities &^ state.Capabilities; blocked != 0 {
+		rows = append(rows, [2]string{"blocked", blocked.String()})
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%-9s%s", row[0], row[1])
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package goeditor
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDebugOverlay tests showing what the editor is doing.
+func TestDebugOverlay(t *testing.T) {
+	altD := tea.KeyPressMsg{Code: 'd', Mod: tea.ModAlt}
+	const content = "one\ntwo"
+
+	t.Run("the debug key shows and hides the overlay", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 16), withListening())
+		assert.NoError(t, m.SetDebugKey("<A-d>"))
+		m, _ = m.update(altD)
+		assert.True(t, m.IsDebugOverlayVisible())
+		assert.True(t, m.keyConsumed)
+
+		m = typeText(m, "j2d")
+		view := m.View()
+		assert.Contains(t, view, "mode     normal")
+		assert.Contains(t, view, "pending  2d")
+		assert.Contains(t, view, "last key d (consumed)")
+		assert.Contains(t, view, "cursor   2:1")
+
+		m, _ = m.update(altD)
+		assert.False(t, m.IsDebugOverlayVisible())
+		assert.NotContains(t, m.View(), "pending")
+	})
+
+	t.Run("the overlay says why a key did nothing", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 16), withListening())
+		m.ShowDebugOverlay(true)
+		assert.NoError(t, m.DisableKeys("x"))
+		m = typeText(m, "x")
+		assert.Contains(t, m.View(), "last key x (dropped: disabled)")
+
+		m.SetCapabilities(core.AllCapabilities &^ core.EditText)
+		m = typeText(m, "i")
+		assert.Contains(t, m.View(), "last key i (not consumed)")
+		assert.Contains(t, m.View(), "blocked  editing")
+	})
+
+	t.Run("keys in unknown notation are an error", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 16), withListening())
+		assert.Error(t, m.SetDebugKey("<F99>"))
+		assert.Error(t, m.SetDebugKey("ab"))
+		assert.NoError(t, m.SetDebugKey(""))
+	})
+}
+
+// TestSetLogger tests logging what the editor does.
+func TestSetLogger(t *testing.T) {
+	var out strings.Builder
+	m := newTestModel("", withListening())
+	m.SetLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: core.LevelTrace})))
+	assert.NoError(t, m.DisableKeys(":"))
+	m = typeText(m, ":i")
+
+	log := out.String()
+	assert.Contains(t, log, `msg="key dropped" key=: reason=disabled`)
+	assert.Contains(t, log, `msg=key key=i mode=normal`)
+	assert.Contains(t, log, `msg=mode from=normal to=insert`)
+}
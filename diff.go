@@ -0,0 +1,625 @@
+package goeditor
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// DiffSide identifies one of the two panes of a DiffModel
+type DiffSide int
+
+const (
+	DiffLeft DiffSide = iota
+	DiffRight
+)
+
+// DiffTheme holds the styles used by DiffModel
+type DiffTheme struct {
+	AddedLineStyle   lipgloss.Style
+	DeletedLineStyle lipgloss.Style
+	ChangedLineStyle lipgloss.Style
+	ChangedTextStyle lipgloss.Style // Intra-line highlight of the differing text
+	FillerStyle      lipgloss.Style // Rows with no counterpart on this side
+	LineNumberStyle  lipgloss.Style
+	CursorLineStyle  lipgloss.Style
+	SeparatorStyle   lipgloss.Style
+	StatusLineStyle  lipgloss.Style
+}
+
+// DefaultDiffTheme creates a diff theme with adaptive colors based on terminal background.
+func DefaultDiffTheme(isDark bool) DiffTheme {
+	lightDark := func(light, dark string) color.Color {
+		if isDark {
+			return lipgloss.Color(dark)
+		}
+		return lipgloss.Color(light)
+	}
+
+	return DiffTheme{
+		AddedLineStyle: lipgloss.NewStyle().
+			Background(lightDark("#d5ecd0", "#2b3b2e")), // Green tint
+
+		DeletedLineStyle: lipgloss.NewStyle().
+			Background(lightDark("#f5d0d8", "#3f2a33")), // Red tint
+
+		ChangedLineStyle: lipgloss.NewStyle().
+			Background(lightDark("#d6e0f5", "#2a3045")), // Blue tint
+
+		ChangedTextStyle: lipgloss.NewStyle().
+			Background(lightDark("#a8c0f0", "#3f4f7a")).
+			Bold(true),
+
+		FillerStyle: lipgloss.NewStyle().
+			Background(lightDark("#e6e9ef", "#181825")), // Mantle
+
+		LineNumberStyle: lipgloss.NewStyle().
+			Foreground(lightDark("#9ca0b0", "#6c7086")), // Overlay0
+
+		CursorLineStyle: lipgloss.NewStyle().
+			Background(lightDark("#ccd0da", "#313244")), // Surface0
+
+		SeparatorStyle: lipgloss.NewStyle().
+			Foreground(lightDark("#bcc0cc", "#45475a")), // Surface1
+
+		StatusLineStyle: lipgloss.NewStyle().
+			Background(lightDark("#ccd0da", "#313244")). // Surface0
+			Foreground(lightDark("#4c4f69", "#cdd6f4")), // Text
+	}
+}
+
+// DiffChangedMsg is sent when a hunk was copied into one of the panes with `do` or `dp`
+type DiffChangedMsg struct {
+	Side    DiffSide
+	Content string
+}
+
+// diffRow is one aligned row of the side-by-side view.
+// A line index of -1 means the side has no line on this row.
+type diffRow struct {
+	left  int
+	right int
+	hunk  int // Index of the hunk the row belongs to, -1 for unchanged rows
+}
+
+// DiffModel shows two buffers side by side with aligned hunks.
+//
+// Key bindings:
+//
+//	j/k, up/down  move between rows
+//	gg/G          first/last row
+//	]c/[c         next/previous hunk
+//	do            obtain the hunk from the other pane into the focused pane
+//	dp            put the hunk from the focused pane into the other pane
+//	tab           switch the focused pane
+//	u/Ctrl-R      undo/redo in the focused pane
+//	U             undo the last changed line in the focused pane, as in the editor
+type DiffModel struct {
+	left  core.Editor
+	right core.Editor
+
+	hunks []core.Hunk
+	rows  []diffRow
+
+	cursorRow int
+	topRow    int
+	focus     DiffSide
+
+	pendingKey rune
+
+	width  int
+	height int
+
+	leftName  string
+	rightName string
+
+	showLineNumbers bool
+	theme           DiffTheme
+	labels          Labels
+}
+
+// NewDiff creates a side-by-side diff model with the given dimensions.
+func NewDiff(width, height int) DiffModel {
+	isDark := lipgloss.HasDarkBackground(os.Stdin, os.Stderr)
+
+	clipboard := &fallbackClipboard{clipboard: SystemClipboard{}}
+	m := DiffModel{
+		left:            core.New(clipboard),
+		right:           core.New(clipboard),
+		width:           width,
+		height:          height,
+		showLineNumbers: true,
+		theme:           DefaultDiffTheme(isDark),
+		labels:          EnglishLabels(),
+	}
+	m.refresh()
+
+	return m
+}
+
+// SetSize sets the width and height of the diff view.
+func (m *DiffModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.scrollToCursor()
+}
+
+// SetContents replaces the content of both panes and recomputes the hunks.
+func (m *DiffModel) SetContents(left, right string) {
+	m.left.SetContent([]byte(left))
+	m.right.SetContent([]byte(right))
+	m.cursorRow = 0
+	m.topRow = 0
+	m.refresh()
+}
+
+// SetNames sets the names shown in the status line for each pane.
+func (m *DiffModel) SetNames(left, right string) {
+	m.leftName = left
+	m.rightName = right
+}
+
+// WithTheme sets the styles used by the diff view.
+func (m *DiffModel) WithTheme(theme DiffTheme) {
+	m.theme = theme
+}
+
+// HideLineNumbers hides or shows the line numbers of both panes.
+func (m *DiffModel) HideLineNumbers(hide bool) {
+	m.showLineNumbers = !hide
+}
+
+// GetContent returns the current content of the given pane.
+func (m *DiffModel) GetContent(side DiffSide) string {
+	return m.editorFor(side).GetBuffer().GetCurrentContent()
+}
+
+// GetEditor returns the core editor backing the given pane.
+func (m *DiffModel) GetEditor(side DiffSide) core.Editor {
+	return m.editorFor(side)
+}
+
+// Hunks returns the hunks that turn the left pane into the right pane.
+func (m *DiffModel) Hunks() []core.Hunk {
+	return m.hunks
+}
+
+// CurrentHunk returns the index of the hunk under the cursor, or -1.
+func (m *DiffModel) CurrentHunk() int {
+	if m.cursorRow < 0 || m.cursorRow >= len(m.rows) {
+		return -1
+	}
+	return m.rows[m.cursorRow].hunk
+}
+
+// Focus returns the focused pane.
+func (m *DiffModel) Focus() DiffSide {
+	return m.focus
+}
+
+// SetFocus changes the focused pane.
+func (m *DiffModel) SetFocus(side DiffSide) {
+	m.focus = side
+}
+
+// NextHunk moves the cursor to the start of the next hunk. Returns false if there is none.
+func (m *DiffModel) NextHunk() bool {
+	current := m.CurrentHunk()
+	for i := m.cursorRow + 1; i < len(m.rows); i++ {
+		if h := m.rows[i].hunk; h >= 0 && h != current {
+			m.cursorRow = i
+			m.scrollToCursor()
+			return true
+		}
+	}
+	return false
+}
+
+// PreviousHunk moves the cursor to the start of the previous hunk. Returns false if there is none.
+func (m *DiffModel) PreviousHunk() bool {
+	current := m.CurrentHunk()
+	for i := m.cursorRow - 1; i >= 0; i-- {
+		h := m.rows[i].hunk
+		if h < 0 || h == current {
+			continue
+		}
+		// Walk back to the first row of that hunk
+		for i > 0 && m.rows[i-1].hunk == h {
+			i--
+		}
+		m.cursorRow = i
+		m.scrollToCursor()
+		return true
+	}
+	return false
+}
+
+// Obtain replaces the hunk under the cursor in the focused pane with the other pane's version (`do`).
+func (m *DiffModel) Obtain() bool {
+	return m.takeHunk(m.focus)
+}
+
+// Put replaces the hunk under the cursor in the other pane with the focused pane's version (`dp`).
+func (m *DiffModel) Put() bool {
+	return m.takeHunk(m.otherSide())
+}
+
+func (m DiffModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m DiffModel) Update(msg tea.Msg) (DiffModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	key := convertBubbleKey(keyMsg)
+
+	if m.pendingKey != 0 {
+		pending := m.pendingKey
+		m.pendingKey = 0
+
+		switch {
+		case pending == ']' && key.Rune == 'c':
+			m.NextHunk()
+		case pending == '[' && key.Rune == 'c':
+			m.PreviousHunk()
+		case pending == 'g' && key.Rune == 'g':
+			m.cursorRow = 0
+			m.scrollToCursor()
+		case pending == 'd' && key.Rune == 'o':
+			if m.Obtain() {
+				return m, m.changedCmd(m.focus)
+			}
+		case pending == 'd' && key.Rune == 'p':
+			if m.Put() {
+				return m, m.changedCmd(m.otherSide())
+			}
+		}
+		return m, nil
+	}
+
+	switch key.Key {
+	case core.KeyDown:
+		m.moveCursor(1)
+		return m, nil
+	case core.KeyUp:
+		m.moveCursor(-1)
+		return m, nil
+	case core.KeyTab:
+		m.focus = m.otherSide()
+		return m, nil
+	case core.KeyCtrlR:
+		if _, err := m.editorFor(m.focus).Redo(); err == nil {
+			m.refresh()
+			return m, m.changedCmd(m.focus)
+		}
+		return m, nil
+	}
+
+	switch key.Rune {
+	case 'j':
+		m.moveCursor(1)
+	case 'k':
+		m.moveCursor(-1)
+	case 'G':
+		m.cursorRow = max(len(m.rows)-1, 0)
+		m.scrollToCursor()
+	case ']', '[', 'g', 'd':
+		m.pendingKey = key.Rune
+	case 'u':
+		if _, err := m.editorFor(m.focus).Undo(); err == nil {
+			m.refresh()
+			return m, m.changedCmd(m.focus)
+		}
+	case 'U':
+		// The pane's editor gives U its meaning, undoing the line or redoing
+		// with RedoOnU
+		editor := m.editorFor(m.focus)
+		revision := editor.GetBuffer().Revision()
+		editor.HandleKey(key)
+		if editor.GetBuffer().Revision() != revision {
+			m.refresh()
+			return m, m.changedCmd(m.focus)
+		}
+	}
+
+	return m, nil
+}
+
+func (m DiffModel) View() string {
+	if m.width <= 0 || m.height <= 0 {
+		return ""
+	}
+
+	const separator = "│"
+	paneWidth := max((m.width-lipgloss.Width(separator))/2, 1)
+	bodyHeight := max(m.height-1, 1)
+
+	leftLines := m.left.GetBuffer().GetLines()
+	rightLines := m.right.GetBuffer().GetLines()
+
+	lineNumWidth := 0
+	if m.showLineNumbers {
+		lineNumWidth = len(fmt.Sprintf("%d", max(len(leftLines), len(rightLines), 1))) + 1
+	}
+
+	var sb strings.Builder
+	for i := range bodyHeight {
+		rowIdx := m.topRow + i
+		if rowIdx >= len(m.rows) {
+			sb.WriteString(m.theme.FillerStyle.Render(strings.Repeat(" ", paneWidth)))
+			sb.WriteString(m.theme.SeparatorStyle.Render(separator))
+			sb.WriteString(m.theme.FillerStyle.Render(strings.Repeat(" ", paneWidth)))
+			sb.WriteString("\n")
+			continue
+		}
+
+		row := m.rows[rowIdx]
+		isCursorRow := rowIdx == m.cursorRow
+
+		var leftRunes, rightRunes []rune
+		if row.left >= 0 {
+			leftRunes = []rune(leftLines[row.left])
+		}
+		if row.right >= 0 {
+			rightRunes = []rune(rightLines[row.right])
+		}
+
+		lStart, lEnd, rStart, rEnd := -1, -1, -1, -1
+		if row.left >= 0 && row.right >= 0 && row.hunk >= 0 {
+			lStart, lEnd, rStart, rEnd = intraLineRange(leftRunes, rightRunes)
+		}
+
+		sb.WriteString(m.renderDiffCell(DiffLeft, row, row.left, leftRunes, lStart, lEnd, paneWidth, lineNumWidth, isCursorRow))
+		sb.WriteString(m.theme.SeparatorStyle.Render(separator))
+		sb.WriteString(m.renderDiffCell(DiffRight, row, row.right, rightRunes, rStart, rEnd, paneWidth, lineNumWidth, isCursorRow))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(m.getStatusLine())
+
+	return sb.String()
+}
+
+func (m *DiffModel) getStatusLine() string {
+	focused := m.labels.DiffLeft
+	if m.focus == DiffRight {
+		focused = m.labels.DiffRight
+	}
+
+	names := ""
+	if m.leftName != "" || m.rightName != "" {
+		names = fmt.Sprintf(" %s ↔ %s ", m.leftName, m.rightName)
+	}
+
+	hunkInfo := fmt.Sprintf(m.labels.DiffHunks, len(m.hunks))
+	if current := m.CurrentHunk(); current >= 0 {
+		hunkInfo = fmt.Sprintf(m.labels.DiffHunk, current+1, len(m.hunks))
+	}
+
+	right := fmt.Sprintf(" %s  [%s] ", hunkInfo, focused)
+	padding := max(m.width-lipgloss.Width(names)-lipgloss.Width(right), 0)
+
+	return m.theme.StatusLineStyle.Render(names + strings.Repeat(" ", padding) + right)
+}
+
+// renderDiffCell renders one pane of one aligned row, clipped to the pane width.
+// Runes in [hlStart, hlEnd) are rendered with the intra-line highlight style.
+func (m *DiffModel) renderDiffCell(side DiffSide, row diffRow, lineIdx int, runes []rune, hlStart, hlEnd, paneWidth, lineNumWidth int, isCursorRow bool) string {
+	var base lipgloss.Style
+	switch {
+	case lineIdx < 0:
+		base = m.theme.FillerStyle
+	case row.hunk < 0:
+		base = lipgloss.NewStyle()
+	case row.left >= 0 && row.right >= 0:
+		base = m.theme.ChangedLineStyle
+	case side == DiffLeft:
+		base = m.theme.DeletedLineStyle
+	default:
+		base = m.theme.AddedLineStyle
+	}
+
+	if isCursorRow && side == m.focus {
+		base = base.Inherit(m.theme.CursorLineStyle)
+	}
+	highlight := m.theme.ChangedTextStyle.Inherit(base)
+
+	var sb strings.Builder
+
+	if lineNumWidth > 0 {
+		number := ""
+		if lineIdx >= 0 {
+			number = fmt.Sprintf("%d", lineIdx+1)
+		}
+		sb.WriteString(m.theme.LineNumberStyle.Render(fmt.Sprintf("%*s ", lineNumWidth-1, number)))
+	}
+
+	textWidth := max(paneWidth-lineNumWidth, 0)
+	col := 0
+
+	var run strings.Builder
+	runHighlighted := false
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if runHighlighted {
+			sb.WriteString(highlight.Render(run.String()))
+		} else {
+			sb.WriteString(base.Render(run.String()))
+		}
+		run.Reset()
+	}
+
+	for i := 0; i < len(runes); {
+		grapheme, width, consumed := nextGrapheme(runes, i, col, defaultTabWidth)
+		if consumed == 0 || col+width > textWidth {
+			break
+		}
+		if grapheme == "\t" {
+			grapheme = strings.Repeat(" ", width)
+		}
+
+		highlighted := i >= hlStart && i < hlEnd
+		if highlighted != runHighlighted {
+			flush()
+			runHighlighted = highlighted
+		}
+		run.WriteString(grapheme)
+
+		col += width
+		i += consumed
+	}
+	flush()
+
+	if col < textWidth {
+		sb.WriteString(base.Render(strings.Repeat(" ", textWidth-col)))
+	}
+
+	return sb.String()
+}
+
+func (m *DiffModel) editorFor(side DiffSide) core.Editor {
+	if side == DiffRight {
+		return m.right
+	}
+	return m.left
+}
+
+func (m *DiffModel) otherSide() DiffSide {
+	if m.focus == DiffLeft {
+		return DiffRight
+	}
+	return DiffLeft
+}
+
+func (m *DiffModel) changedCmd(side DiffSide) tea.Cmd {
+	content := m.GetContent(side)
+	return func() tea.Msg {
+		return DiffChangedMsg{Side: side, Content: content}
+	}
+}
+
+// takeHunk copies the hunk under the cursor into the target pane from the other pane.
+func (m *DiffModel) takeHunk(target DiffSide) bool {
+	idx := m.CurrentHunk()
+	if idx < 0 {
+		return false
+	}
+	h := m.hunks[idx]
+
+	leftLines := m.left.GetBuffer().GetLines()
+	rightLines := m.right.GetBuffer().GetLines()
+
+	var err *core.EditorError
+	if target == DiffLeft {
+		err = replaceEditorLines(m.left, h.OldStart, h.OldEnd(), rightLines[h.NewStart:h.NewEnd()])
+	} else {
+		err = replaceEditorLines(m.right, h.NewStart, h.NewEnd(), leftLines[h.OldStart:h.OldEnd()])
+	}
+
+	m.refresh()
+	return err == nil
+}
+
+// replaceEditorLines replaces rows [start, end) of the editor's buffer with lines
+// as one change, which u undoes at once, returning why if the editor rejects it.
+// Only those rows are touched, so the rest of the buffer and its line ending stay.
+func replaceEditorLines(editor core.Editor, start, end int, lines []string) *core.EditorError {
+	return editor.Transact(func(buffer core.Buffer) {
+		// Insert before deleting, so that replacing every row leaves no empty line behind
+		if len(lines) > 0 {
+			buffer.InsertLines(end, lines)
+		}
+		if end > start {
+			buffer.DeleteLines(start, end-1)
+		}
+		buffer.SetCursor(core.Cursor{Position: core.Position{Row: start, Col: 0}})
+	})
+}
+
+// refresh recomputes the hunks and the aligned rows after either pane changed.
+func (m *DiffModel) refresh() {
+	leftLines := m.left.GetBuffer().GetLines()
+	rightLines := m.right.GetBuffer().GetLines()
+
+	m.hunks = core.DiffLines(leftLines, rightLines)
+	m.rows = buildDiffRows(m.hunks, len(leftLines), len(rightLines))
+
+	m.cursorRow = min(m.cursorRow, max(len(m.rows)-1, 0))
+	m.scrollToCursor()
+}
+
+func (m *DiffModel) moveCursor(delta int) {
+	m.cursorRow = max(min(m.cursorRow+delta, len(m.rows)-1), 0)
+	m.scrollToCursor()
+}
+
+func (m *DiffModel) scrollToCursor() {
+	bodyHeight := max(m.height-1, 1)
+
+	if m.cursorRow < m.topRow {
+		m.topRow = m.cursorRow
+	} else if m.cursorRow >= m.topRow+bodyHeight {
+		m.topRow = m.cursorRow - bodyHeight + 1
+	}
+}
+
+// buildDiffRows aligns the lines of both sides, padding the shorter side of each hunk with filler rows.
+func buildDiffRows(hunks []core.Hunk, leftCount, rightCount int) []diffRow {
+	rows := make([]diffRow, 0, max(leftCount, rightCount))
+	left, right := 0, 0
+
+	for i, h := range hunks {
+		for left < h.OldStart {
+			rows = append(rows, diffRow{left: left, right: right, hunk: -1})
+			left++
+			right++
+		}
+
+		for k := range max(h.OldLines, h.NewLines) {
+			row := diffRow{left: -1, right: -1, hunk: i}
+			if k < h.OldLines {
+				row.left = h.OldStart + k
+			}
+			if k < h.NewLines {
+				row.right = h.NewStart + k
+			}
+			rows = append(rows, row)
+		}
+
+		left, right = h.OldEnd(), h.NewEnd()
+	}
+
+	for left < leftCount && right < rightCount {
+		rows = append(rows, diffRow{left: left, right: right, hunk: -1})
+		left++
+		right++
+	}
+
+	return rows
+}
+
+// intraLineRange finds the differing part of two lines by trimming their common
+// prefix and suffix. Returns half-open rune ranges for both lines.
+func intraLineRange(a, b []rune) (aStart, aEnd, bStart, bEnd int) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return prefix, len(a) - suffix, prefix, len(b) - suffix
+}
@@ -0,0 +1,232 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// typeDiffKeys sends the keys of text to m, one at a time.
+func typeDiffKeys(m DiffModel, text string) DiffModel {
+	for _, r := range text {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	return m
+}
+
+// TestDiffUndo tests undoing and redoing in the focused pane with the keys of
+// the editor.
+func TestDiffUndo(t *testing.T) {
+	newDiff := func() DiffModel {
+		m := NewDiff(80, 10)
+		m.SetContents("one\ntwo", "one\nTWO")
+		return typeDiffKeys(m, "jdo")
+	}
+	ctrlR := tea.KeyPressMsg{Code: 'r', Mod: tea.ModCtrl}
+
+	t.Run("u undoes and Ctrl-R redoes", func(t *testing.T) {
+		m := newDiff()
+		assert.Equal(t, "one\nTWO", m.GetContent(DiffLeft))
+
+		m = typeDiffKeys(m, "u")
+		assert.Equal(t, "one\ntwo", m.GetContent(DiffLeft))
+
+		m, cmd := m.Update(ctrlR)
+		assert.Equal(t, "one\nTWO", m.GetContent(DiffLeft))
+		assert.Equal(t, DiffChangedMsg{Side: DiffLeft, Content: "one\nTWO"}, cmd())
+	})
+
+	t.Run("U undoes the last changed line", func(t *testing.T) {
+		m := typeDiffKeys(newDiff(), "U")
+		assert.Equal(t, "one\ntwo", m.GetContent(DiffLeft))
+		assert.Len(t, m.Hunks(), 1)
+	})
+
+	t.Run("U redoes with RedoOnU", func(t *testing.T) {
+		m := newDiff()
+		m.GetEditor(DiffLeft).Options().SetRedoOnU(true)
+		m = typeDiffKeys(m, "uU")
+		assert.Equal(t, "one\nTWO", m.GetContent(DiffLeft))
+	})
+}
+
+// TestDiffHunkNavigation tests moving between hunks with ]c and [c.
+func TestDiffHunkNavigation(t *testing.T) {
+	m := NewDiff(80, 10)
+	m.SetContents("a\nb\nc\nd\ne", "a\nB\nc\nd\nE")
+	assert.Equal(t, []core.Hunk{
+		{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1},
+		{OldStart: 4, OldLines: 1, NewStart: 4, NewLines: 1},
+	}, m.Hunks())
+	assert.Equal(t, -1, m.CurrentHunk())
+
+	m = typeDiffKeys(m, "]c")
+	assert.Equal(t, 0, m.CurrentHunk())
+
+	m = typeDiffKeys(m, "]c")
+	assert.Equal(t, 1, m.CurrentHunk())
+
+	m = typeDiffKeys(m, "]c")
+	assert.Equal(t, 1, m.CurrentHunk(), "there is no hunk after the last")
+
+	m = typeDiffKeys(m, "[c")
+	assert.Equal(t, 0, m.CurrentHunk())
+}
+
+// TestDiffObtainPut tests copying hunks between the panes with do and dp.
+func TestDiffObtainPut(t *testing.T) {
+	t.Run("do copies the hunk into the focused pane", func(t *testing.T) {
+		m := NewDiff(80, 10)
+		m.SetContents("a\nb\nc\nd\ne", "a\nB\nc\nd\nE")
+		m = typeDiffKeys(m, "]c")
+
+		m, cmd := m.Update(tea.KeyPressMsg{Code: 'd', Text: "d"})
+		assert.Nil(t, cmd)
+		m, cmd = m.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+		assert.Equal(t, DiffChangedMsg{Side: DiffLeft, Content: "a\nB\nc\nd\ne"}, cmd())
+
+		assert.Equal(t, "a\nB\nc\nd\ne", m.GetContent(DiffLeft))
+		assert.Equal(t, "a\nB\nc\nd\nE", m.GetContent(DiffRight))
+		assert.Equal(t, []core.Hunk{{OldStart: 4, OldLines: 1, NewStart: 4, NewLines: 1}}, m.Hunks())
+	})
+
+	t.Run("dp copies the hunk into the other pane", func(t *testing.T) {
+		m := NewDiff(80, 10)
+		m.SetContents("a\nb\nc\nd\ne", "a\nB\nc\nd\nE")
+		m = typeDiffKeys(m, "]c]cdp")
+
+		assert.Equal(t, "a\nb\nc\nd\ne", m.GetContent(DiffLeft))
+		assert.Equal(t, "a\nB\nc\nd\ne", m.GetContent(DiffRight))
+		assert.Equal(t, []core.Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}}, m.Hunks())
+	})
+
+	t.Run("added and deleted lines", func(t *testing.T) {
+		m := NewDiff(80, 10)
+		m.SetContents("a\nc", "a\nb\nc")
+		assert.Equal(t, []core.Hunk{{OldStart: 1, OldLines: 0, NewStart: 1, NewLines: 1}}, m.Hunks())
+
+		m = typeDiffKeys(m, "]cdo")
+		assert.Equal(t, "a\nb\nc", m.GetContent(DiffLeft))
+		assert.Empty(t, m.Hunks())
+
+		m.SetContents("a\nc", "a\nb\nc")
+		m.SetFocus(DiffRight)
+		m = typeDiffKeys(m, "]cdo")
+		assert.Equal(t, "a\nc", m.GetContent(DiffRight))
+		assert.Equal(t, "a\nc", m.GetContent(DiffLeft))
+		assert.Empty(t, m.Hunks())
+	})
+
+	t.Run("every line is replaced", func(t *testing.T) {
+		m := NewDiff(80, 10)
+		m.SetContents("one", "two\nthree")
+		m = typeDiffKeys(m, "do")
+		assert.Equal(t, "two\nthree", m.GetContent(DiffLeft))
+		assert.Empty(t, m.Hunks())
+	})
+
+	t.Run("the line ending and the other lines are kept", func(t *testing.T) {
+		m := NewDiff(80, 10)
+		m.SetContents("a\r\nb\r\nc", "a\nB\nc")
+		m = typeDiffKeys(m, "]cdo")
+		assert.Equal(t, "a\r\nB\r\nc", m.GetContent(DiffLeft))
+		assert.Equal(t, core.LineEndingCRLF, m.GetEditor(DiffLeft).GetBuffer().LineEnding())
+	})
+
+	t.Run("off a hunk nothing is copied", func(t *testing.T) {
+		m := NewDiff(80, 10)
+		m.SetContents("a\nb", "a\nB")
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'd', Text: "d"})
+		m, cmd := m.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+		assert.Nil(t, cmd)
+		assert.Equal(t, "a\nb", m.GetContent(DiffLeft))
+	})
+}
+
+// TestDiffUndoAcrossPanes tests that u undoes in the focused pane only, after
+// a hunk was put into the other one.
+func TestDiffUndoAcrossPanes(t *testing.T) {
+	m := NewDiff(80, 10)
+	m.SetContents("a\nb\nc", "a\nB\nc")
+	m = typeDiffKeys(m, "]cdp")
+	assert.Equal(t, "a\nb\nc", m.GetContent(DiffRight))
+	assert.Empty(t, m.Hunks())
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'u', Text: "u"})
+	assert.Nil(t, cmd, "the left pane has nothing to undo")
+	assert.Equal(t, "a\nb\nc", m.GetContent(DiffRight))
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+	m, cmd = m.Update(tea.KeyPressMsg{Code: 'u', Text: "u"})
+	assert.Equal(t, DiffChangedMsg{Side: DiffRight, Content: "a\nB\nc"}, cmd())
+	assert.Equal(t, "a\nb\nc", m.GetContent(DiffLeft))
+	assert.Equal(t, []core.Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}}, m.Hunks())
+}
+
+// TestBuildDiffRows tests aligning the lines of both panes.
+func TestBuildDiffRows(t *testing.T) {
+	tests := []struct {
+		name              string
+		hunks             []core.Hunk
+		leftLen, rightLen int
+		want              []diffRow
+	}{
+		{
+			name:    "no hunks",
+			leftLen: 2, rightLen: 2,
+			want: []diffRow{{0, 0, -1}, {1, 1, -1}},
+		},
+		{
+			name:    "change",
+			hunks:   []core.Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}},
+			leftLen: 3, rightLen: 3,
+			want: []diffRow{{0, 0, -1}, {1, 1, 0}, {2, 2, -1}},
+		},
+		{
+			name:    "added lines are padded on the left",
+			hunks:   []core.Hunk{{OldStart: 1, OldLines: 0, NewStart: 1, NewLines: 2}},
+			leftLen: 2, rightLen: 4,
+			want: []diffRow{{0, 0, -1}, {-1, 1, 0}, {-1, 2, 0}, {1, 3, -1}},
+		},
+		{
+			name:    "deleted lines are padded on the right",
+			hunks:   []core.Hunk{{OldStart: 0, OldLines: 1, NewStart: 0, NewLines: 0}},
+			leftLen: 2, rightLen: 1,
+			want: []diffRow{{0, -1, 0}, {1, 0, -1}},
+		},
+		{
+			name:    "uneven change",
+			hunks:   []core.Hunk{{OldStart: 0, OldLines: 1, NewStart: 0, NewLines: 2}},
+			leftLen: 1, rightLen: 2,
+			want: []diffRow{{0, 0, 0}, {-1, 1, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildDiffRows(tt.hunks, tt.leftLen, tt.rightLen))
+		})
+	}
+}
+
+// TestIntraLineRange tests finding the differing part of two lines.
+func TestIntraLineRange(t *testing.T) {
+	tests := []struct {
+		a, b                       string
+		aStart, aEnd, bStart, bEnd int
+	}{
+		{"hello world", "hello there", 6, 11, 6, 11},
+		{"abc", "abc", 3, 3, 3, 3},
+		{"abc", "aXc", 1, 2, 1, 2},
+		{"abc", "abbc", 2, 2, 2, 3},
+		{"", "new", 0, 0, 0, 3},
+		{"héllo", "hallo", 1, 2, 1, 2},
+	}
+
+	for _, tt := range tests {
+		aStart, aEnd, bStart, bEnd := intraLineRange([]rune(tt.a), []rune(tt.b))
+		assert.Equal(t, []int{tt.aStart, tt.aEnd, tt.bStart, tt.bEnd}, []int{aStart, aEnd, bStart, bEnd}, "%q and %q", tt.a, tt.b)
+	}
+}
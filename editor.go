@@ -3,18 +3,21 @@ package goeditor
 import (
 	"context"
 	"fmt"
-	"image/color"
+	"log/slog"
 	"os"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"charm.land/bubbles/v2/cursor"
+	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/textinput"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/colorprofile"
 	"github.com/ionut-t/goeditor/core"
 	"github.com/ionut-t/goeditor/highlighter"
 )
@@ -35,6 +38,8 @@ type Theme struct {
 	ErrorStyle             lipgloss.Style
 	HighlightYankStyle     lipgloss.Style
 	PlaceholderStyle       lipgloss.Style
+	PreeditStyle           lipgloss.Style
+	ProtectedStyle         lipgloss.Style
 
 	SearchHighlightStyle   lipgloss.Style
 	SearchInputPromptStyle lipgloss.Style
@@ -46,128 +51,31 @@ type Theme struct {
 	CompletionMenuBorderStyle       lipgloss.Style
 	CompletionMenuLabelStyle        lipgloss.Style
 	CompletionMenuTypeStyle         lipgloss.Style
-}
-
-// DefaultTheme creates a theme with adaptive colors based on terminal background.
-func DefaultTheme(isDark bool) Theme {
-	// Helper closure to select the colour based on the boolean
-	lightDark := func(light, dark string) color.Color {
-		if isDark {
-			return lipgloss.Color(dark)
-		}
-		return lipgloss.Color(light)
-	}
-
-	return Theme{
-		NormalModeStyle: lipgloss.NewStyle().
-			Background(lightDark("#179299", "#94e2d5")). // Teal
-			Foreground(lightDark("#eff1f5", "#1e1e2e")).
-			Bold(true),
-
-		InsertModeStyle: lipgloss.NewStyle().
-			Background(lightDark("#1e66f5", "#89b4fa")). // Blue
-			Foreground(lightDark("#eff1f5", "#1e1e2e")).
-			Bold(true),
-
-		VisualModeStyle: lipgloss.NewStyle().
-			Background(lightDark("#8839ef", "#cba6f7")). // Mauve
-			Foreground(lightDark("#eff1f5", "#1e1e2e")).
-			Bold(true),
-
-		CommandModeStyle: lipgloss.NewStyle().
-			Background(lightDark("#fe640b", "#fab387")). // Peach
-			Foreground(lightDark("#eff1f5", "#1e1e2e")).
-			Bold(true),
-
-		SearchModeStyle: lipgloss.NewStyle().
-			Background(lightDark("#df8e1d", "#f9e2af")). // Yellow
-			Foreground(lightDark("#eff1f5", "#1e1e2e")).
-			Bold(true),
-
-		// Status and command line
-		StatusLineStyle: lipgloss.NewStyle().
-			Background(lightDark("#ccd0da", "#313244")). // Surface0
-			Foreground(lightDark("#4c4f69", "#cdd6f4")), // Text
-
-		CommandLineStyle: lipgloss.NewStyle().
-			Background(lightDark("#eff1f5", "#1e1e2e")). // Base
-			Foreground(lightDark("#4c4f69", "#cdd6f4")), // Text
-
-		// Messages and errors
-		MessageStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#40a02b", "#a6e3a1")), // Green
-
-		ErrorStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#d20f39", "#f38ba8")). // Red
-			Bold(true),
 
-		// Line numbers
-		LineNumberStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#9ca0b0", "#6c7086")). // Overlay0
-			Width(4).
-			Align(lipgloss.Right),
+	QuickfixTitleStyle        lipgloss.Style
+	QuickfixItemStyle         lipgloss.Style
+	QuickfixSelectedItemStyle lipgloss.Style
 
-		CurrentLineNumberStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#4c4f69", "#cdd6f4")). // Text
-			Width(4).
-			Align(lipgloss.Right).
-			Bold(true),
+	PaletteBorderStyle       lipgloss.Style
+	PaletteInputStyle        lipgloss.Style
+	PaletteItemStyle         lipgloss.Style
+	PaletteSelectedItemStyle lipgloss.Style
 
-		// Current line highlight (subtle)
-		CurrentLineStyle: lipgloss.NewStyle().
-			Background(lightDark("#e6e9ef", "#2A2B3C")), // Mantle / Surface0
+	JumpLabelStyle lipgloss.Style
 
-		// Selection highlighting
-		SelectionStyle: lipgloss.NewStyle().
-			Background(lightDark("#bcc0cc", "#45475a")), // Surface1
+	OverlayBorderStyle lipgloss.Style
 
-		// Yank highlight (brief flash effect)
-		HighlightYankStyle: lipgloss.NewStyle().
-			Background(lightDark("#209fb5", "#74c7ec")). // Sapphire
-			Foreground(lightDark("#eff1f5", "#1e1e2e")).
-			Bold(true),
-
-		// Search highlighting
-		SearchHighlightStyle: lipgloss.NewStyle().
-			Background(lightDark("#df8e1d", "#f9e2af")). // Yellow
-			Foreground(lightDark("#eff1f5", "#1e1e2e")).
-			Bold(true),
-
-		SearchInputPromptStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#df8e1d", "#f9e2af")). // Yellow
-			Bold(true),
-
-		SearchInputTextStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#4c4f69", "#cdd6f4")), // Text
-
-		SearchInputCursorStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#df8e1d", "#f9e2af")), // Yellow
-
-		// Placeholder text
-		PlaceholderStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#8c8fa1", "#7f849c")). // Overlay1
-			Italic(true),
-
-		CompletionMenuItemStyle: lipgloss.NewStyle().
-			Padding(0, 1),
-
-		CompletionMenuSelectedItemStyle: lipgloss.NewStyle().
-			Background(lightDark("#bcc0cc", "#45475a")). // Surface1
-			Padding(0, 1).
-			Bold(true),
+	// StylePrecedence orders the styles that can apply to the same character
+	// of the text, highest first. DefaultStylePrecedence is used when nil.
+	StylePrecedence []StyleLayer
+}
 
-		CompletionMenuBorderStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lightDark("#9ca0b0", "#6c7086")). // Overlay0
-			Padding(0),
-
-		CompletionMenuLabelStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#1e66f5", "#89b4fa")). // Blue
-			Bold(true),
-
-		CompletionMenuTypeStyle: lipgloss.NewStyle().
-			Foreground(lightDark("#8839ef", "#cba6f7")), // Mauve
+// DefaultTheme creates a theme with adaptive colors based on terminal background.
+func DefaultTheme(isDark bool) Theme {
+	if isDark {
+		return NewTheme(CatppuccinMocha)
 	}
+	return NewTheme(CatppuccinLatte)
 }
 
 type (
@@ -188,8 +96,21 @@ const (
 	cursorActivityResetDelay = 250 * time.Millisecond
 )
 
+// hostEditor is the core editor as the model drives it: the public
+// core.Editor, and the signals and command line between them, which hosts
+// reach through the model instead.
+type hostEditor interface {
+	core.Editor
+	GetUpdateSignalChan() <-chan core.Signal
+	DispatchSignal(signal core.Signal)
+	DispatchError(id core.ErrorId, err error)
+	UpdateCommand(string)
+}
+
+// Model is the Bubble Tea model of the editor. Like the core editor, it's not
+// safe for concurrent use: call its methods from Update, not from a tea.Cmd.
 type Model struct {
-	editor   core.Editor
+	editor   hostEditor
 	viewport viewport.Model
 
 	width  int
@@ -200,6 +121,7 @@ type Model struct {
 	showStatusLine     bool
 
 	theme          Theme
+	labels         Labels
 	StatusLineFunc func() string
 
 	err     error
@@ -209,6 +131,8 @@ type Model struct {
 
 	disableVimMode bool
 
+	follow bool // Whether AppendContent scrolls to the content it adds
+
 	fullVisualLayoutHeight  int // Total number of visual lines in the entire buffer
 	cursorAbsoluteVisualRow int // Cursor's current row index in the full visual layout
 	currentVisualTopLine    int // Top line of the current visual slice
@@ -216,25 +140,51 @@ type Model struct {
 	visualLayoutCache               []VisualLineInfo                    // Cache of visual line information
 	visualLayoutCacheStartRow       int                                 // First logical line in cache (for lazy mode)
 	visualLayoutCacheStartVisualRow int                                 // First visual row in cache (offset for lazy mode)
+	layoutOptions                   layoutOptions                       // How the cached layout expands tabs and marks the display lines continuing a line
 	visualRowAnchors                map[int]int                         // Sparse anchors: logical line -> visual row (cleared on edits)
 	lastKnownLineCount              int                                 // Track line count to detect content changes
 	cacheValidStartRow              int                                 // Start of cursor range for which cache is valid
 	cacheValidEndRow                int                                 // End of cursor range for which cache is valid
 	persistentTokenCache            map[int][]highlighter.TokenPosition // Persistent token cache across renders
+	contentRevision                 uint64                              // Revision of the content the caches are for
+	dirty                           dirtyFlags                          // What the message being handled changed, for refresh
+	frame                           renderedFrame                       // The last frame rendered, for the cursor blinking to patch
+	frameInterval                   time.Duration                       // Least time between frames keys draw, 0 for none
+	lastFrame                       time.Time                           // When the last frame was drawn, while frames are capped
+	framePending                    bool                                // Whether a frameMsg will draw the keys held back
+	keySeq                          int                                 // Keys the editor has handled, for timeoutMsg to tell whether one came since
 
 	clampedCursorLogicalCol      int // Clamped cursor column
+	cursorVirtualCols            int // Columns between the end of the line and a cursor past it
 	highlightedWords             map[string]lipgloss.Style
+	gutterSigns                  map[int]GutterSign       // Signs drawn next to line numbers, keyed by logical row
 	compiledHighlightedWords     []highlightedWordPattern // Cached compiled patterns
 	compiledHighlightedWordsHash uint64                   // Hash of highlightedWords to detect changes
 	extraHighlightedContextLines uint16
+	maxHighlightLineLength       int // Longer lines are drawn without syntax highlighting
 
 	isFocused        bool
 	placeholder      string
+	preedit          []rune // IME composition text, not part of the buffer
+	preeditCursor    int    // Cursor offset within the composition text, in runes
 	cursorMode       CursorMode
 	cursorVisible    bool
+	terminalCursor   bool          // Leave the cursor to the terminal instead of drawing it
+	dimProtected     bool          // Draw read-only text with Theme.ProtectedStyle
+	concealRules     []ConcealRule // Markup hidden on lines the cursor isn't on
+	concealLevel     ConcealLevel
+	cursorScreenPos  core.Position // Where renderVisibleSlice put the cursor in the text, -1 if hidden
 	highlighter      *highlighter.Highlighter
 	language         string
 	highlighterTheme string
+	colorProfile     colorprofile.Profile // Unknown until detected or set
+	clipboard        core.Clipboard
+
+	asyncHighlighting bool
+	pendingTokens     *tokenRequest // Tokenisation to start on the next Update
+	runningTokens     *tokenRequest // Tokenisation running in the background
+	tasks             taskRunner    // Background work, cancelled when the content changes
+	listening         bool          // Whether a command waits for the editor's signals
 
 	searchInput   textinput.Model
 	searchOptions core.SearchOptions
@@ -249,23 +199,65 @@ type Model struct {
 	completionDebounceTime      time.Duration
 	precomputedCompletionStyles completionStyles
 
+	quickfix quickfixPanel
+
+	palette           commandPalette
+	commandPaletteKey bool                     // Ctrl-P opens the command palette
+	disabledKeys      []core.KeyEvent          // Keys the editor ignores, set by DisableKeys
+	interceptKey      func(core.KeyEvent) bool // Whether the editor may handle a key, set by InterceptKeys
+	keyConsumed       bool                     // Whether the editor consumed the last key, as HandleKey reports
+	suspendKey        *core.KeyEvent           // Suspends the program in normal and visual mode, nil for none
+	debug             debugOverlay             // What the editor is doing, shown over the text
+	logger            *slog.Logger             // Told what the editor does at core.LevelTrace, nil for none
+	lineJump          lineJump
+	accessibility     accessibility // Plain drawing and announcements, set by SetAccessibilityMode
+	jumpLabels        jumpLabels
+	overlays          []overlay     // Registered with RegisterOverlay, in registration order
+	hoverCursor       core.Position // Cursor position when the hover was shown
+
 	cursorBlinkCancel context.CancelFunc
 	clearMsgCancel    context.CancelFunc
 	clearYankCancel   context.CancelFunc
 }
 
+// ErrorMsg is sent when a key or a command fails. Error is in the words set
+// with SetMessages, and Severity tells how much it matters, for the host to
+// show it accordingly, e.g. not to interrupt the user for a
+// core.SeverityInfo such as "already at oldest change".
 type ErrorMsg struct {
-	ID    core.ErrorId
-	Error error
+	ID       core.ErrorId
+	Error    error
+	Severity core.Severity
 }
 
+// SaveMsg is sent when the content is saved. Content is encoded in the
+// buffer's encoding, so it may hold bytes that aren't valid UTF-8.
 type SaveMsg struct {
 	Path    *string
 	Content string
+	// Partial is set when only the lines StartLine to EndLine (0-based) were
+	// written, as by :2,5w file
+	Partial            bool
+	StartLine, EndLine int
+	// Append is set when Content is added to the end of the file instead of
+	// replacing it, as by :w >> file
+	Append bool
 }
 
 type QuitMsg struct{}
 
+// ConfirmQuitMsg is sent when :q is refused because of unsaved changes. The
+// host may ask whether to save and quit, quit anyway or cancel, and answer
+// with ConfirmQuit.
+type ConfirmQuitMsg struct{}
+
+// ModeChangedMsg is sent when the editor changes mode, e.g. to enable an
+// input method in insert mode. See SetModeHook to act at once instead.
+type ModeChangedMsg struct {
+	From core.Mode
+	To   core.Mode
+}
+
 type clearMsg struct{}
 
 type commandMsg struct{}
@@ -276,32 +268,79 @@ type exitSearchMode struct{}
 
 // yankedMsg is an internal message indicating that content has been yanked.
 // It handles the visual feedback for yanked content and dispatches the YankMsg to the consumer.
-type yankedMsg struct {
-	Content string
-}
+type yankedMsg YankMsg
 
+// YankMsg reports yanked text. Start is the position of its first character
+// and End the position just past its last, which for line-wise text is the
+// start of the line after it. Register is the register it went to; the unnamed
+// register '"' is the clipboard.
 type YankMsg struct {
-	Content string
+	Content  string
+	Start    core.Position
+	End      core.Position
+	Register rune
+	Linewise bool
 }
 
 type clearYankMsg struct{}
 
+// PasteMsg reports pasted text, with its range in the buffer after the paste
+// and the register it came from, as in YankMsg.
 type PasteMsg struct {
-	Content string
+	Content  string
+	Start    core.Position
+	End      core.Position
+	Register rune
+	Linewise bool
 }
 
+// RenameMsg asks the host to rename the file From to FileName, once :rename
+// is confirmed. The host answers FileRenamed when it has, for the editor to
+// take the new name.
 type RenameMsg struct {
 	FileName string
+	From     string
 }
 
+// DeleteFileMsg asks the host to delete the file, once :delete is confirmed.
 type DeleteFileMsg struct{}
 
+// ConfirmMsg asks the host to confirm an action on the file before the
+// editor takes it, as :delete and :rename do, e.g. by showing Prompt and
+// waiting for y or n. The host calls Accept to go ahead or Reject to cancel;
+// only the first answer counts. :delete! and :rename! don't ask.
+type ConfirmMsg struct {
+	Action core.ConfirmAction
+	Prompt string
+	Accept func()
+	Reject func()
+}
+
+// BlockedMsg is sent when the user tries something the editor doesn't have
+// the capability for, as set by SetCapabilities, e.g. to say the buffer is
+// read-only.
+type BlockedMsg struct {
+	Capability core.Capability
+}
+
 type RelativeNumbersChangeMsg struct {
 	Enabled bool
 }
 
+// NumbersChangeMsg reports :set number or :set nonumber, turning absolute
+// line numbers on or off.
+type NumbersChangeMsg struct {
+	Enabled bool
+}
+
+// DeleteMsg reports deleted text, with its range in the buffer before the
+// deletion and the register it went to, as in YankMsg.
 type DeleteMsg struct {
-	Content string
+	Content  string
+	Start    core.Position
+	End      core.Position
+	Register rune
+	Linewise bool
 }
 
 type UndoMsg struct {
@@ -312,8 +351,14 @@ type RedoMsg struct {
 	ContentBefore string
 }
 
+// SearchResultsMsg is sent after a search and after each n or N.
+// Positions holds every match in buffer order and Index the one under the
+// cursor, or -1 when there is none. A search started with SearchAsync sends
+// the matches found so far until Done.
 type SearchResultsMsg struct {
 	Positions []core.Position
+	Index     int
+	Done      bool
 }
 
 type CompletionRequestMsg struct {
@@ -367,21 +412,18 @@ func (m *Model) dispatchClearYankMsg() tea.Cmd {
 	}
 }
 
-type clipboardImpl struct{}
-
-func (c *clipboardImpl) Write(text string) error {
-	return clipboard.WriteAll(text)
-}
-
-func (c *clipboardImpl) Read() (string, error) {
-	return clipboard.ReadAll()
-}
-
 func New(width, height int) Model {
-	texteditor := core.New(&clipboardImpl{})
+	clipboard := &fallbackClipboard{clipboard: SystemClipboard{}}
+	texteditor := core.New(clipboard).(hostEditor)
+	texteditor.Display().SetLayoutProvider(wrapLayout{editor: texteditor})
 	vp := viewport.New(viewport.WithWidth(width), viewport.WithHeight(height-2))
 	searchInput := textinput.New()
 	searchInput.Prompt = "/"
+	// Edit the search like the command line, where Ctrl-B goes to the start
+	searchInput.KeyMap.CharacterBackward = key.NewBinding(key.WithKeys("left"))
+	searchInput.KeyMap.LineStart = key.NewBinding(key.WithKeys("home", "ctrl+a", "ctrl+b"))
+	searchInput.KeyMap.WordForward.SetKeys(append(searchInput.KeyMap.WordForward.Keys(), "shift+right")...)
+	searchInput.KeyMap.WordBackward.SetKeys(append(searchInput.KeyMap.WordBackward.Keys(), "shift+left")...)
 
 	isDark := lipgloss.HasDarkBackground(os.Stdin, os.Stderr)
 	defaultTheme := DefaultTheme(isDark)
@@ -402,16 +444,24 @@ func New(width, height int) Model {
 	}
 
 	m := Model{
-		editor:           texteditor,
-		viewport:         vp,
-		showLineNumbers:  true,
-		showStatusLine:   true,
-		theme:            defaultTheme,
-		highlightedWords: make(map[string]lipgloss.Style),
-		cursorMode:       CursorSteady,
-		cursorVisible:    true,
-		searchInput:      searchInput,
-		searchOptions:    searchOptions,
+		editor:                 texteditor,
+		clipboard:              clipboard,
+		viewport:               vp,
+		showLineNumbers:        true,
+		showStatusLine:         true,
+		theme:                  defaultTheme,
+		labels:                 EnglishLabels(),
+		highlightedWords:       make(map[string]lipgloss.Style),
+		cursorMode:             CursorSteady,
+		maxHighlightLineLength: highlighter.DefaultMaxLineLength,
+		cursorVisible:          true,
+		concealLevel:           ConcealHide,
+		searchInput:            searchInput,
+		suspendKey:             defaultSuspendKey,
+		searchOptions:          searchOptions,
+
+		asyncHighlighting: true,
+		accessibility:     accessibility{announcements: make(chan string, announcementBuffer)},
 
 		autoTriggerEnabled:          false,
 		completionDebounceTime:      300 * time.Millisecond,
@@ -426,26 +476,19 @@ func New(width, height int) Model {
 func (m *Model) SetSize(width, height int) {
 	m.width = width
 	m.height = height
+	// The status and command lines and the quickfix panel take rows from the text
+	editorHeight := max(height-2-m.quickfix.height(), 1)
+
 	m.viewport.SetWidth(width)
-	m.viewport.SetHeight(height - 2)
+	m.viewport.SetHeight(editorHeight)
 
-	lineNumWidth := 0
-	if m.showLineNumbers {
-		maxLineNum := m.editor.GetBuffer().LineCount()
-		maxWidth := len(strconv.Itoa(max(1, maxLineNum)))
-		lineNumWidth = max(4, maxWidth) + 1
-		lineNumWidth = min(lineNumWidth, 10)
-	}
+	lineNumWidth := m.calculateLineNumberWidth(m.editor.GetBuffer().LineCount())
 	availableWidth := m.viewport.Width() - lineNumWidth
 	if availableWidth <= 0 {
 		availableWidth = 1
 	}
 
-	state := m.editor.GetState()
-	state.ViewportWidth = m.viewport.Width()
-	state.AvailableWidth = availableWidth
-	state.ViewportHeight = height - 2
-	m.editor.SetState(state)
+	m.editor.Display().SetViewportSize(m.viewport.Width(), editorHeight, availableWidth)
 
 	// Recalculate layout if dimensions changed and we have content
 	if !m.editor.GetBuffer().IsEmpty() {
@@ -478,9 +521,6 @@ func (m *Model) SetSize(width, height int) {
 
 // SetBytes sets the content of the core.
 func (m *Model) SetBytes(content []byte) {
-	if len(content) == 0 {
-		content = []byte("\n")
-	}
 	m.editor.SetContent(content)
 	m.handleContentChange()
 }
@@ -490,6 +530,22 @@ func (m *Model) SetContent(content string) {
 	m.SetBytes([]byte(content))
 }
 
+// SetBytesWithEncoding sets the content of the editor from bytes in the given
+// encoding, such as core.EncodingUTF16LE or core.EncodingWindows1252.
+// Saving re-encodes the content to the same encoding.
+func (m *Model) SetBytesWithEncoding(content []byte, enc core.Encoding) {
+	m.editor.SetContentWithEncoding(content, enc)
+	m.handleContentChange()
+}
+
+// SetInvalidUTF8Fallback sets how SetBytes decodes content that is not valid UTF-8.
+// With core.EncodingLatin1 every byte is kept and written back unchanged on save.
+// With core.EncodingUTF8 (the default) invalid bytes are replaced, the status line
+// shows [invalid utf-8] and :w refuses to save unless forced with :w!.
+func (m *Model) SetInvalidUTF8Fallback(enc core.Encoding) {
+	m.editor.Options().SetInvalidUTF8Fallback(enc)
+}
+
 // WithTheme allows setting a custom theme for the core.
 func (m *Model) WithTheme(theme Theme) {
 	m.theme = theme
@@ -508,6 +564,15 @@ func (m *Model) WithSearchOptions(options core.SearchOptions) {
 	m.searchOptions = options
 }
 
+// SearchAsync searches for pattern in the background, for buffers too large
+// to search at once. The matches are highlighted as they are found, and each
+// batch arrives as a SearchResultsMsg, the last one Done. Starting another
+// search cancels it.
+func (m *Model) SearchAsync(pattern string, options core.SearchOptions) {
+	m.editor.SearchAsync(pattern, options)
+	m.renderVisibleSlice()
+}
+
 // WithSearchInputCursorMode allows setting the cursor mode for the search input.
 // Default is CursorStatic.
 func (m *Model) WithSearchInputCursorMode(mode cursor.Mode) {
@@ -519,6 +584,8 @@ func (m *Model) WithSearchInputCursorMode(mode cursor.Mode) {
 // SetLanguage sets the programming language for syntax highlighting.
 //
 // If the language is empty, syntax highlighting will be disabled.
+// The language also picks the syntax of statements for is, as, ]s and [s
+// (see SetStatementSyntax), and applies the settings ConfigureLanguage gave it.
 //
 // The theme parameter allows specifying a Chroma theme for the syntax highlighter.
 // For a full list of available themes, see: https://github.com/alecthomas/chroma/blob/master/styles
@@ -529,6 +596,9 @@ func (m *Model) SetLanguage(language string, theme string) {
 
 	m.language = language
 	m.highlighterTheme = theme
+	m.editor.Languages().SetLanguage(language)
+	m.dirty |= sizeChanged | needsRender // Its settings may change the tab width
+	m.cancelTokenise()
 	if language == "" {
 		m.highlighter = nil
 		m.persistentTokenCache = make(map[int][]highlighter.TokenPosition)
@@ -536,6 +606,10 @@ func (m *Model) SetLanguage(language string, theme string) {
 	}
 
 	m.highlighter = highlighter.New(language, theme)
+	m.highlighter.SetMaxLineLength(m.maxHighlightLineLength)
+	if m.colorProfile != colorprofile.Unknown {
+		m.highlighter.SetColorProfile(m.colorProfile)
+	}
 	// Clear token cache when language changes
 	m.persistentTokenCache = make(map[int][]highlighter.TokenPosition)
 
@@ -544,11 +618,27 @@ func (m *Model) SetLanguage(language string, theme string) {
 	}
 }
 
+// detectLines is how many lines of the content SetLanguageFromFilename looks
+// at when the file name doesn't tell the language.
+const detectLines = 200
+
+// SetLanguageFromFilename sets the language for the file at path, as
+// highlighter.DetectLanguage finds it from the file name or the first lines
+// of the content, such as a shebang line, keeping the theme. It returns the
+// language, empty if none was found, which turns highlighting off.
+func (m *Model) SetLanguageFromFilename(path string) string {
+	lines := m.editor.GetBuffer().GetLines()
+	content := strings.Join(lines[:min(len(lines), detectLines)], "\n")
+	language := highlighter.DetectLanguage(content, path)
+	m.SetLanguage(language, m.highlighterTheme)
+	return language
+}
+
 // SetExtraWordChars allows specifying additional characters to be considered part of words for cursor movement and selection.
 // By default, the editor considers alphanumeric characters and underscores as part of words.
 // This method allows to include additional characters (e.g., hyphens, dots).
 func (m *Model) SetExtraWordChars(chars ...rune) {
-	m.editor.SetExtraWordChars(chars...)
+	m.editor.Options().SetExtraWordChars(chars...)
 }
 
 // SetExtraHighlightedContextLines sets the number of extra lines to tokenise around the visible viewport.
@@ -569,9 +659,38 @@ func (m *Model) SetExtraHighlightedContextLines(lines uint16) {
 	m.extraHighlightedContextLines = lines
 }
 
+// SetMaxHighlightLineLength sets the length, in characters, of the longest
+// line drawn with syntax highlighting, 0 for no limit. Longer lines, such as
+// minified JSON or JavaScript, are slow to tokenise and drawn plain instead.
+// The default is highlighter.DefaultMaxLineLength.
+func (m *Model) SetMaxHighlightLineLength(length int) {
+	m.maxHighlightLineLength = max(0, length)
+	if m.highlighter != nil {
+		m.cancelTokenise()
+		m.highlighter.SetMaxLineLength(m.maxHighlightLineLength)
+		m.persistentTokenCache = make(map[int][]highlighter.TokenPosition)
+	}
+	m.dirty |= needsRender
+}
+
 // WithSyntaxHighlighter allows setting a custom syntax highlighter.
 func (m *Model) WithSyntaxHighlighter(highlighter *highlighter.Highlighter) {
+	m.cancelTokenise()
 	m.highlighter = highlighter
+	if highlighter != nil && m.colorProfile != colorprofile.Unknown {
+		highlighter.SetColorProfile(m.colorProfile)
+	}
+}
+
+// SetColorProfile sets the colour profile of the terminal, used to convert
+// syntax colours to ones the terminal can show. The profile is detected when
+// the program sends tea.ColorProfileMsg, so hosts only need this to override
+// the detected one.
+func (m *Model) SetColorProfile(profile colorprofile.Profile) {
+	m.colorProfile = profile
+	if m.highlighter != nil {
+		m.highlighter.SetColorProfile(profile)
+	}
 }
 
 // WithAutoTrigger enables or disables auto-trigger completions
@@ -600,6 +719,16 @@ func (m *Model) DispatchError(err error, duration time.Duration) tea.Cmd {
 	return m.dispatchClearMsg(duration)
 }
 
+// SetMessages puts the messages the editor shows the user in the host's
+// words, such as those of another language: the errors of ErrorMsg, the
+// prompts of ConfirmMsg and the notices on the command line, such as the
+// file info of Ctrl-G. Each text is a format for fmt.Sprintf with the
+// arguments of the message; core.DefaultMessages lists them in English,
+// which messages missing from texts stay in.
+func (m *Model) SetMessages(texts map[core.MessageId]string) {
+	m.editor.Options().SetMessages(texts)
+}
+
 // HideLineNumbers controls whether to show line numbers in the viewport.
 func (m *Model) HideLineNumbers(hide bool) {
 	m.showLineNumbers = !hide
@@ -608,12 +737,48 @@ func (m *Model) HideLineNumbers(hide bool) {
 // ShowLineNumbers controls whether to show relative line numbers in the viewport.
 // If Vim mode is disabled, this will not have any effect.
 // If line numbers are hidden, this will not have any effect.
+// With absolute line numbers also shown, the default, the cursor line has its
+// number and the others count from it, as Vim's :set number relativenumber.
 func (m *Model) ShowRelativeLineNumbers(show bool) {
 	if m.disableVimMode {
 		return
 	}
 
-	m.editor.ShowRelativeLineNumbers(show)
+	m.editor.Display().ShowRelativeLineNumbers(show)
+}
+
+// ShowAbsoluteLineNumbers controls whether lines have their own numbers, as
+// Vim's :set number does. They are shown by default; without them, relative
+// line numbers are 0 on the cursor line, and with neither there are none.
+// If line numbers are hidden, this will not have any effect.
+func (m *Model) ShowAbsoluteLineNumbers(show bool) {
+	m.editor.Display().ShowAbsoluteLineNumbers(show)
+}
+
+// SetNumberWidth sets the least columns the line numbers take, with the
+// space after them, as Vim's numberwidth; 0, the default, is 5. The width
+// stays the same however many lines there are, up to that many digits.
+func (m *Model) SetNumberWidth(width int) {
+	m.editor.Display().SetNumberWidth(width)
+	m.dirty |= sizeChanged | needsRender
+}
+
+// SetShowBreak sets what is drawn at the start of the display lines wrapping
+// continues a line on, such as "↪ ", so that they stand apart from the lines
+// of the content, as Vim's showbreak and :set showbreak do. Empty, the
+// default, draws nothing.
+func (m *Model) SetShowBreak(showBreak string) {
+	m.editor.Display().SetShowBreak(showBreak)
+	m.dirty |= sizeChanged | needsRender
+}
+
+// SetBreakIndent sets whether the display lines wrapping continues a line on
+// are indented as the line is, before any showbreak, as Vim's breakindent
+// and :set breakindent do. Lines are not indented when it would leave less
+// than 20 columns for their text.
+func (m *Model) SetBreakIndent(enabled bool) {
+	m.editor.Display().SetBreakIndent(enabled)
+	m.dirty |= sizeChanged | needsRender
 }
 
 // ShowTildeIndicator controls whether to show the tilde indicator in the viewport.
@@ -646,11 +811,49 @@ func (m *Model) HasChanges() bool {
 	return m.editor.GetBuffer().IsModified()
 }
 
+// Revision returns a number that grows with every change to the content, so
+// a host can tell whether it changed since it last looked without comparing
+// it.
+func (m *Model) Revision() uint64 {
+	return m.editor.GetBuffer().Revision()
+}
+
+// SetFileName sets the name of the file being edited, shown in the status
+// line and by :f and Ctrl-G. Saving a buffer without a name to a file with
+// :w {file} names it after the file.
+func (m *Model) SetFileName(name string) {
+	m.editor.GetBuffer().SetFileName(name)
+}
+
+// FileName returns the name of the file being edited, or "" if it has none.
+func (m *Model) FileName() string {
+	return m.editor.GetBuffer().FileName()
+}
+
 // GetEditor returns the underlying editor instance
 func (m *Model) GetEditor() core.Editor {
 	return m.editor
 }
 
+// CommandHandler runs an ex command registered with Model.RegisterCommand,
+// returning the message to send the host, nil for none, and the error to
+// show the user.
+type CommandHandler func(editor core.Editor, args []string) (tea.Msg, *core.EditorError)
+
+// RegisterCommand registers an extra ex command, as core.Editor's does, whose
+// handler may answer with a message for the host, such as a request for work
+// the editor doesn't do itself.
+func (m *Model) RegisterCommand(name string, handler CommandHandler) {
+	ed := m.editor
+	ed.RegisterCommand(name, func(editor core.Editor, args []string) *core.EditorError {
+		msg, err := handler(editor, args)
+		if msg != nil {
+			ed.DispatchSignal(msg)
+		}
+		return err
+	})
+}
+
 // DisableVimMode allows disabling Vim mode in the core.
 // This will disable all Vim-specific features and revert to a simpler text editor mode.
 // If Vim mode is disabled, the editor will not respond to Vim keybindings.
@@ -659,35 +862,237 @@ func (m *Model) DisableVimMode(disable bool) {
 	m.editor.DisableVimMode(disable)
 }
 
+// SetDisplayLineMovement makes j, k and the up and down arrows move by the
+// lines wrapping draws, as gj and gk do, and gj and gk move by lines, for
+// editing prose.
+func (m *Model) SetDisplayLineMovement(enabled bool) {
+	m.editor.Display().SetDisplayLineMovement(enabled)
+}
+
+// SetTextWidth sets the width gq reflows text to, in columns, as
+// :set textwidth does; 0, the default, is 79. Other than 0, it's also where
+// typing breaks lines, as SetAutoWrap sets.
+func (m *Model) SetTextWidth(width int) {
+	m.editor.Options().SetTextWidth(width)
+}
+
+// SetAutoWrap sets which lines insert mode breaks at the last space before
+// the text width once typing goes past it: every line, the default, only
+// comments of the language, or none. :set formatoptions sets it too, as
+// fo=tc, fo=c and fo= do.
+func (m *Model) SetAutoWrap(wrap core.AutoWrap) {
+	m.editor.Options().SetAutoWrap(wrap)
+}
+
+// SetRedoOnU makes U redo, as Ctrl-R does, instead of undoing the last changed line.
+func (m *Model) SetRedoOnU(enabled bool) {
+	m.editor.Options().SetRedoOnU(enabled)
+}
+
+// SetMaxLength limits the content to maxLength characters, counting line
+// breaks. Typing or pasting past it is rejected, leaving the content as it
+// was, and sends an ErrorMsg with core.ErrMaxLengthId. Zero removes the limit.
+func (m *Model) SetMaxLength(maxLength int) {
+	m.editor.Limits().SetMaxLength(maxLength)
+}
+
+// SetValidateFunc sets a function that checks the content after each edit,
+// e.g. to accept only digits. An edit it returns an error for is rejected,
+// leaving the content as it was, and sends an ErrorMsg with
+// core.ErrInvalidInputId and that error. nil removes it.
+func (m *Model) SetValidateFunc(validate func(content string) error) {
+	m.editor.Limits().SetValidateFunc(validate)
+}
+
+// ProtectRange makes the text from start up to but not including end
+// read-only, such as the header of a template. Edits that change it are
+// rejected, leaving the content as it was, and send an ErrorMsg with
+// core.ErrProtectedId. The range moves as text is added or removed around it,
+// and is cleared when new content is set.
+func (m *Model) ProtectRange(start, end core.Position) {
+	m.editor.Limits().ProtectRange(start, end)
+	m.renderVisibleSlice()
+}
+
+// ClearProtectedRanges makes the whole content editable again.
+func (m *Model) ClearProtectedRanges() {
+	m.editor.Limits().ClearProtectedRanges()
+	m.renderVisibleSlice()
+}
+
+// ProtectedRanges returns the read-only ranges where they are now.
+func (m Model) ProtectedRanges() []core.ProtectedRange {
+	return m.editor.Limits().ProtectedRanges()
+}
+
+// SetDimProtectedRanges draws read-only text with Theme.ProtectedStyle, faint
+// by default.
+func (m *Model) SetDimProtectedRanges(dim bool) {
+	m.dimProtected = dim
+	m.renderVisibleSlice()
+}
+
+// SetVirtualEdit sets where the cursor may go past the end of a line:
+// core.VirtualEditNone keeps it on the last character in normal mode as Vim
+// does, and core.VirtualEditAll lets it move anywhere, for editing tables
+// and columns.
+func (m *Model) SetVirtualEdit(mode core.VirtualEdit) {
+	m.editor.Options().SetVirtualEdit(mode)
+	m.renderVisibleSlice()
+}
+
+// SetTableMode turns table mode on or off. In a markdown table, Tab and
+// Shift-Tab move to the next and previous cell, Tab in the last cell adds a
+// row, and the columns are aligned after each change and when typing | or
+// leaving insert mode. :TableAddRow, :TableDeleteRow, :TableAddColumn,
+// :TableDeleteColumn and :TableAlign work with or without it.
+func (m *Model) SetTableMode(enabled bool) {
+	m.editor.Options().SetTableMode(enabled)
+}
+
+// SetTableDelimiter sets the delimiter of the cells of tables, such as , to
+// edit CSV, where a table is a block of lines with the delimiter. The default
+// | edits markdown pipe tables.
+func (m *Model) SetTableDelimiter(delimiter rune) {
+	m.editor.Options().SetTableDelimiter(delimiter)
+}
+
+// SetStatementSyntax sets where the statements of language end, for the is
+// and as text objects and the ]s and [s motions once SetLanguage picks the
+// language. The SQL languages use core.SQLStatementSyntax by default; nil
+// turns statements off for language.
+func (m *Model) SetStatementSyntax(language string, syntax *core.StatementSyntax) {
+	m.editor.Languages().SetStatementSyntax(language, syntax)
+}
+
+// SetCommentLeaders sets what starts the line comments of language, such as
+// # for Python, which gq keeps at the start of the lines it reflows once
+// SetLanguage picks the language. Common languages have theirs by default.
+func (m *Model) SetCommentLeaders(language string, leaders ...string) {
+	m.editor.Languages().SetCommentLeaders(language, leaders...)
+}
+
+// ConfigureLanguage sets the tab width, indenting, comment string and text
+// width of language, applied whenever SetLanguage picks it, and at once if
+// it's the current language. Hosts switching between, say, Go buffers
+// indented with tabs and YAML ones indented with two spaces configure both
+// once:
+//
+//	m.ConfigureLanguage("go", core.LanguageConfig{TabWidth: 8})
+//	m.ConfigureLanguage("yaml", core.LanguageConfig{TabWidth: 2, ExpandTab: true, AutoIndent: true})
+//
+// A language without settings keeps the ones in use.
+func (m *Model) ConfigureLanguage(language string, config core.LanguageConfig) {
+	m.editor.Languages().ConfigureLanguage(language, config)
+	m.dirty |= sizeChanged | needsRender
+}
+
+// SetFormatter sets the formatter :format uses for language once SetLanguage
+// picks the language, such as one running gofmt. JSON uses
+// core.JSONFormatter by default; nil turns formatting off for language.
+func (m *Model) SetFormatter(language string, formatter core.Formatter) {
+	m.editor.Languages().SetFormatter(language, formatter)
+}
+
+// SetFormatOnSave sets whether :w formats the content before writing all of
+// it, when the language has a formatter. Content the formatter rejects is
+// not saved.
+func (m *Model) SetFormatOnSave(enabled bool) {
+	m.editor.Languages().SetFormatOnSave(enabled)
+}
+
+// Format formats the content with the formatter of its language as a single
+// undoable change, as :format does, replacing only the lines that differ.
+func (m *Model) Format() error {
+	return m.programmaticEdit(m.editor.Format())
+}
+
+// SetCapabilities sets what the user may do, core.AllCapabilities by
+// default, e.g. core.AllCapabilities &^ core.EditText for a read-only buffer
+// that can still be searched and copied from. Keys that need a capability
+// the editor doesn't have send a BlockedMsg instead. Edits made through the
+// Model, such as SetContent and ReplaceRange, are never blocked.
+func (m *Model) SetCapabilities(capabilities core.Capability) {
+	m.editor.SetCapabilities(capabilities)
+}
+
+// HasCapability reports whether the user may do all of capabilities.
+func (m *Model) HasCapability(capabilities core.Capability) bool {
+	return m.editor.HasCapability(capabilities)
+}
+
 // DisableCommandMode allows disabling command mode in the core.
 // This will disable the command mode functionality, meaning the editor will not respond to command mode keybindings.
+// It takes core.CommandLine away, see SetCapabilities.
 func (m *Model) DisableCommandMode(disable bool) {
 	m.editor.DisableCommandMode(disable)
 }
 
 // DisableInsertMode allows disabling insert mode in the core.
 // This will disable the insert mode functionality, meaning the editor will not respond to insert mode keybindings
-// and will prevent text modifications.
+// and will prevent text modifications. It takes core.EditText away, see SetCapabilities.
 func (m *Model) DisableInsertMode(disable bool) {
 	m.editor.DisableInsertMode(disable)
 }
 
 // DisableVisualMode allows disabling visual mode in the core.
 // This will disable the visual mode functionality, meaning the editor will not respond to visual mode keybindings.
+// It takes core.VisualSelect away, which disables visual line mode too.
 func (m *Model) DisableVisualMode(disable bool) {
 	m.editor.DisableVisualMode(disable)
 }
 
 // DisableVisualLineMode allows disabling visual line mode in the core.
 // This will disable the visual line mode functionality, meaning the editor will not respond to visual line mode keybindings.
+// It takes core.VisualSelect away, which disables visual mode too.
 func (m *Model) DisableVisualLineMode(disable bool) {
 	m.editor.DisableVisualLineMode(disable)
 }
 
+// DisableSearchMode allows disabling search mode in the core. It takes
+// core.Search away, see SetCapabilities.
 func (m *Model) DisableSearchMode(disable bool) {
 	m.editor.DisableSearchMode(disable)
 }
 
+// SetModeHook sets the hook run as the editor enters or leaves mode, e.g. to
+// enable an input method in insert mode or turn off key bindings of the host
+// in command mode. Unlike ModeChangedMsg, it runs before the next key is
+// handled. The zero core.ModeHook removes it.
+func (m *Model) SetModeHook(mode core.Mode, hook core.ModeHook) {
+	m.editor.SetModeHook(mode, hook)
+}
+
+// SetMark sets mark name, a to z, at pos, as m does at the cursor. Marks are
+// used by ' and ` and in ranges such as :'a,'bd.
+func (m *Model) SetMark(name rune, pos core.Position) {
+	m.editor.SetMark(name, pos)
+}
+
+// Mark returns the position of mark name, including < and > for the start
+// and end of the last visual selection.
+func (m *Model) Mark(name rune) (core.Position, bool) {
+	return m.editor.Mark(name)
+}
+
+// GutterSign is a single-column marker drawn between the line number and the text.
+type GutterSign struct {
+	Text  string
+	Style lipgloss.Style
+}
+
+// SetGutterSigns replaces the signs shown in the gutter, keyed by zero-based row.
+// Signs are drawn in the column after the line number, so they are only visible
+// when line numbers are shown.
+func (m *Model) SetGutterSigns(signs map[int]GutterSign) {
+	m.gutterSigns = signs
+}
+
+// ClearGutterSigns removes all gutter signs.
+func (m *Model) ClearGutterSigns() {
+	m.gutterSigns = nil
+}
+
 // SetHighlightedWords allows setting highlighted words in the core.
 // These words will be styled with the provided lipgloss styles.
 // This is useful for highlighting specific keywords or phrases in the text.
@@ -701,11 +1106,13 @@ func (m *Model) SetHighlightedWords(words map[string]lipgloss.Style) {
 // Focus sets the editor to focused state.
 func (m *Model) Focus() {
 	m.isFocused = true
+	m.dirty |= needsRender
 }
 
 // Blur sets the editor to unfocused state.
 func (m *Model) Blur() {
 	m.isFocused = false
+	m.dirty |= needsRender
 }
 
 // IsFocused returns whether the editor is currently focused.
@@ -828,35 +1235,293 @@ func (m *Model) SetCursorPositionEnd() error {
 	return nil
 }
 
+// SetPreedit shows IME composition text inline at the cursor. The text is not
+// part of the buffer until it is committed with CommitPreedit.
+// cursorOffset is the cursor position within the text, in runes.
+func (m *Model) SetPreedit(text string, cursorOffset int) {
+	m.preedit = []rune(text)
+	m.preeditCursor = max(0, min(cursorOffset, len(m.preedit)))
+	m.renderVisibleSlice()
+}
+
+// ClearPreedit removes the composition text without inserting it.
+func (m *Model) ClearPreedit() {
+	m.preedit = nil
+	m.preeditCursor = 0
+	m.renderVisibleSlice()
+}
+
+// Preedit returns the current composition text.
+func (m *Model) Preedit() string {
+	return string(m.preedit)
+}
+
+// CommitPreedit clears the composition text and inserts text at the cursor as a
+// single undoable change. The editor must be in insert mode.
+func (m *Model) CommitPreedit(text string) error {
+	m.preedit = nil
+	m.preeditCursor = 0
+
+	if err := m.editor.CommitText(text); err != nil {
+		return err.Error()
+	}
+
+	m.handleContentChange()
+	m.renderVisibleSlice()
+
+	return nil
+}
+
+// ReplaceRange replaces the text from start up to but not including end with
+// text as a single undoable change, in any mode. Like the other edits below,
+// it lays out and highlights again only the lines it changes.
+func (m *Model) ReplaceRange(start, end core.Position, text string) error {
+	start, end = core.NormalizeSelection(start, end)
+	return m.editLines(start.Row, end.Row-start.Row+1, func() *core.EditorError {
+		return m.editor.ReplaceRange(start, end, text)
+	})
+}
+
+// InsertAt inserts text at row and col as a single undoable change; newlines
+// in it break the line.
+func (m *Model) InsertAt(row, col int, text string) error {
+	at := core.Position{Row: row, Col: col}
+	return m.ReplaceRange(at, at, text)
+}
+
+// DeleteRange deletes the text from start up to but not including end as a
+// single undoable change.
+func (m *Model) DeleteRange(start, end core.Position) error {
+	return m.ReplaceRange(start, end, "")
+}
+
+// InsertLines inserts lines before line at as a single undoable change. at
+// may be the line count to add them at the end.
+func (m *Model) InsertLines(at int, lines []string) error {
+	return m.editLines(at, 0, func() *core.EditorError {
+		return m.editor.InsertLines(at, lines)
+	})
+}
+
+// DeleteLines deletes the lines from through to as a single undoable change.
+func (m *Model) DeleteLines(from, to int) error {
+	return m.editLines(from, to-from+1, func() *core.EditorError {
+		return m.editor.DeleteLines(from, to)
+	})
+}
+
+// ReplaceAll replaces every match of pattern with replacement as a single
+// undoable change, expanding $1 and ${name} to the text of groups. Patterns
+// match across lines joined by \n. It returns how many matches it replaced.
+func (m *Model) ReplaceAll(pattern *regexp.Regexp, replacement string) (int, error) {
+	count, err := m.editor.ReplaceAll(pattern, replacement)
+	return count, m.programmaticEdit(err)
+}
+
+// BeginTransaction starts grouping edits into one change, until the matching
+// EndTransaction. The edits in between are undone at once, and the content
+// is redrawn and highlighted again only when the transaction ends.
+func (m *Model) BeginTransaction() {
+	m.editor.BeginTransaction()
+}
+
+// EndTransaction ends the transaction started by BeginTransaction. Ending the
+// outermost one saves its edits as one change, or undoes them all and
+// returns why if they are rejected, e.g. by SetMaxLength.
+func (m *Model) EndTransaction() error {
+	err := m.editor.EndTransaction()
+	if !m.editor.InTransaction() {
+		m.handleContentChange()
+		m.renderVisibleSlice()
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return nil
+}
+
+// Transact runs fn in a transaction, so its edits to the buffer and cursor
+// moves are one undoable change:
+//
+//	m.Transact(func(b core.Buffer) {
+//		b.InsertLines(0, []string{"package main", ""})
+//		b.ReplaceAll(regexp.MustCompile(`\bfoo\b`), "bar")
+//	})
+func (m *Model) Transact(fn func(core.Buffer)) error {
+	m.BeginTransaction()
+	fn(m.editor.GetBuffer())
+	return m.EndTransaction()
+}
+
+// programmaticEdit redraws the content after an edit made through the API,
+// returning its error. In a transaction, EndTransaction redraws it.
+func (m *Model) programmaticEdit(err *core.EditorError) error {
+	if err != nil {
+		return err.Error()
+	}
+	if m.editor.InTransaction() {
+		return nil
+	}
+
+	m.handleContentChange()
+	m.renderVisibleSlice()
+
+	return nil
+}
+
+// editLines runs edit, an edit made through the API that replaces the
+// removed lines from line from, and redraws the content laying out and
+// highlighting again only the lines it changed, returning its error. In a
+// transaction, EndTransaction redraws it.
+func (m *Model) editLines(from, removed int, edit func() *core.EditorError) error {
+	lines := m.editor.GetBuffer().LineCount()
+	if err := edit(); err != nil {
+		return err.Error()
+	}
+	if m.editor.InTransaction() {
+		return nil
+	}
+
+	m.handleLinesChange(from, removed, removed+m.editor.GetBuffer().LineCount()-lines)
+	m.renderVisibleSlice()
+
+	return nil
+}
+
 // GetCursorPosition returns the current cursor position in the core.
 func (m Model) GetCursorPosition() core.Position {
 	return m.editor.GetBuffer().GetCursor().Position
 }
 
+// Init returns nil: the first Update starts listening for the editor's
+// signals, so only one command waits for them.
 func (m Model) Init() tea.Cmd {
-	return m.listenForEditorUpdate()
+	return nil
 }
 
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var before editorSnapshot
+	if m.accessibility.enabled {
+		before = m.snapshot()
+	}
+
+	m, cmd := m.update(msg)
+	if m.accessibility.enabled {
+		m.announceChanges(before)
+	}
+	return m, tea.Batch(cmd, m.tokeniseCmd(), m.clipboardCmd())
+}
+
+// update handles msg; Update adds the background work it asked for.
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmds []tea.Cmd
+	var keyStart *keyEffects // What the editor was like before a key it handles
 
 	switch msg := msg.(type) {
+	case signalsMsg:
+		// Each signal's message goes through Update and the host in order,
+		// then the listening goes on
+		for _, signal := range msg {
+			cmds = append(cmds, func() tea.Msg { return signal })
+		}
+		return m, tea.Sequence(append(cmds, m.listenForEditorUpdate())...)
+
+	case tea.KeyReleaseMsg:
+		// Terminals report releases when asked to with the Kitty keyboard
+		// protocol; only presses are keys
+
 	case tea.KeyMsg:
+		m.keyConsumed = false
+		events := convertBubbleKeys(msg)
+		m.debug.lastKey, m.debug.dropped = events[len(events)-1].String(), ""
 		if !m.IsFocused() {
+			m.dropKey(events[len(events)-1], "not focused")
 			break
 		}
 
-		keyEvent := convertBubbleKey(msg)
+		keyEvents := m.filterKeys(events)
+		if len(keyEvents) == 0 {
+			return m, nil
+		}
+		keyEvent := keyEvents[len(keyEvents)-1]
 		skipNormalKeyHandling := false
 
+		if len(keyEvents) == 1 && m.isDebugKey(keyEvent) {
+			m.keyConsumed = true
+			m.ShowDebugOverlay(!m.debug.visible)
+			m.refresh()
+			return m, nil
+		}
+
+		// Keys for the panels and menus over the text are theirs
+		m.keyConsumed = true
+
+		if m.palette.open {
+			cmds = append(cmds, m.handlePaletteKey(keyEvent))
+			m.dirty |= needsRender
+			m.refresh()
+			return m, tea.Batch(cmds...)
+		}
+
+		if m.lineJump.open {
+			cmds = append(cmds, m.handleLineJumpKey(keyEvent))
+			m.dirty |= needsRender
+			m.refresh()
+			return m, tea.Batch(cmds...)
+		}
+
+		// Right after a paste, Ctrl-P swaps the pasted text for an older yank
+		if m.commandPaletteKey && keyEvent.Key == core.KeyCtrlP && !m.editor.Registers().CanCyclePaste() {
+			m.OpenCommandPalette()
+			return m, tea.Batch(cmds...)
+		}
+
+		if m.quickfix.focused {
+			cmds = append(cmds, m.handleQuickfixKey(keyEvent))
+			m.dirty |= needsRender
+			m.refresh()
+			return m, tea.Batch(cmds...)
+		}
+
+		if m.jumpLabels.active {
+			m.handleJumpLabelKey(keyEvent)
+			m.dirty |= needsRender
+			m.refresh()
+			return m, tea.Batch(cmds...)
+		}
+
+		var triggered bool
+		if keyEvents, triggered = m.handleJumpTrigger(keyEvents); triggered {
+			return m, tea.Batch(cmds...)
+		}
+		m.keyConsumed = false
+
+		if len(keyEvents) == 1 && m.isSuspendKey(keyEvent) {
+			m.keyConsumed = true
+			return m, m.suspend()
+		}
+
+		if keyEvent.Key == core.KeyEscape && m.IsHoverVisible() {
+			m.keyConsumed = true
+			m.HideHover()
+			m.dirty |= needsRender
+			m.refresh()
+			return m, tea.Batch(cmds...)
+		}
+
 		// Manual completion trigger: Ctrl+Space in Insert mode
 		if keyEvent.Key == core.KeySpace && keyEvent.Modifiers&core.ModCtrl != 0 {
 			if m.editor.IsInsertMode() {
 				m.editor.TriggerCompletion(core.CompletionTriggerManual, "")
+				m.keyConsumed = true
 				return m, tea.Batch(cmds...)
 			}
 		}
 
+		// The key is consumed if it changes the editor, which is compared below
+		effects := m.keyEffects()
+		keyStart = &effects
+
 		// Completion menu navigation
 		if m.completionMenuVisible {
 			switch keyEvent.Key {
@@ -881,13 +1546,22 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		var err *core.EditorError
 		if !skipNormalKeyHandling {
-			err = m.editor.HandleKey(keyEvent)
+			for _, event := range keyEvents {
+				if err = m.editor.HandleKey(event); err != nil {
+					break
+				}
+			}
 		}
 		if err != nil {
+			m.keyConsumed = true
 			cmds = append(cmds, func() tea.Msg {
-				return ErrorMsg{ID: err.ID(), Error: err.Error()}
+				return ErrorMsg{ID: err.ID(), Error: err.Error(), Severity: err.Severity()}
 			})
 		}
+		if skipNormalKeyHandling {
+			m.keyConsumed = true
+		}
+		cmds = append(cmds, m.timeoutPendingKeys())
 
 		// Auto-trigger handling
 		if m.autoTriggerEnabled && m.editor.IsInsertMode() && !m.completionMenuVisible && !skipNormalKeyHandling {
@@ -915,22 +1589,33 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 		}
 
-		/* TODO: Optimise to only tokenise changed lines if possible. */
-		m.handleContentChange()
+		// The layout is updated once below, and only laid out again if the
+		// content changed, which refresh finds by its revision
+		m.dirty |= cursorMoved | needsRender
 
 		m.cursorVisible = true
 		if m.cursorBlinkCancel != nil {
 			m.cursorBlinkCancel()
 		}
 
-		if m.cursorMode == CursorBlink {
+		if m.cursorBlinks() {
 			cmds = append(cmds, m.restartBlinkCycleCmd())
 		}
 
-		// The core editor's ScrollViewport() operates on logical lines and doesn't account
-		// for line wrapping or emoji visual widths, so we bypass it here.
+	case tea.ResumeMsg:
+		cmds = append(cmds, m.resume())
 
-		m.updateVisualTopLine()
+	case tea.PasteMsg:
+		// Terminal pastes are inserted at once rather than typed key by key
+		if !m.IsFocused() || !m.editor.IsInsertMode() {
+			break
+		}
+		if err := m.editor.InsertText(msg.Content); err != nil {
+			cmds = append(cmds, func() tea.Msg {
+				return ErrorMsg{ID: err.ID(), Error: err.Error(), Severity: err.Severity()}
+			})
+		}
+		m.dirty |= contentChanged | needsRender
 
 	case commandMsg:
 		m.message = ""
@@ -939,6 +1624,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.clearMsgCancel()
 		}
 
+	case TokensReadyMsg:
+		m.handleTokensReady(msg)
+
+	case tea.ClipboardMsg:
+		m.handleClipboardMsg(msg)
+
+	case tea.ColorProfileMsg:
+		m.SetColorProfile(msg.Profile)
+		m.dirty |= needsRender
+
 	case clearMsg:
 		m.message = ""
 		m.err = nil
@@ -956,11 +1651,15 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case clearYankMsg:
 		m.yanked = false
 		m.clearYankCancel = nil
-		m.editor.ResetSelection()
+		// Without Vim mode the selection stays after copying it
+		if !m.disableVimMode {
+			m.editor.ResetSelection()
+		}
 		// Return to normal mode if we were in visual mode
 		if m.editor.IsVisualMode() || m.editor.IsVisualLineMode() {
 			m.editor.SetNormalMode()
 		}
+		m.dirty |= needsRender
 
 	case enterSearchMode:
 		m.searchInput.Focus()
@@ -973,15 +1672,22 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.searchInput.Blur()
 
 	case cursorBlinkMsg:
-		if m.isFocused && m.cursorMode == CursorBlink {
+		visible := m.cursorVisible
+		if m.isFocused && m.cursorBlinks() {
 			m.cursorVisible = !m.cursorVisible
 			cmds = append(cmds, m.CursorBlink())
 		} else {
 			m.cursorVisible = m.isFocused
 		}
+		if m.cursorVisible != visible {
+			m.dirty |= cursorBlinked
+		}
 
 	case resumeBlinkCycleMsg:
-		if m.isFocused && m.cursorMode == CursorBlink {
+		if m.isFocused && m.cursorBlinks() {
+			if !m.cursorVisible {
+				m.dirty |= cursorBlinked
+			}
 			m.cursorVisible = true
 			cmds = append(cmds, m.CursorBlink())
 		}
@@ -997,6 +1703,22 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		// Forward to parent application
 		cmds = append(cmds, func() tea.Msg { return msg })
 
+	case frameMsg:
+		// Keys held back are drawn below
+		m.framePending = false
+
+	case timeoutMsg:
+		m.handleTimeout(msg)
+
+	case SearchResultsMsg:
+		// Matches of a background search are highlighted as they arrive
+		m.dirty |= needsRender
+
+	case QuickfixMsg:
+		if m.quickfix.sync(msg) {
+			m.SetSize(m.width, m.height)
+		}
+
 	case CompletionResponseMsg:
 		// Update completions
 		m.completions = msg.Completions
@@ -1007,9 +1729,17 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		} else {
 			m.completionMenuVisible = false
 		}
+
+	default:
+		// Hosts pass on messages after changing the editor through its
+		// methods, some of which leave drawing the change to the next update
+		m.dirty |= needsRender
 	}
 
-	cmds = append(cmds, m.listenForEditorUpdate())
+	if !m.listening {
+		m.listening = true
+		cmds = append(cmds, m.listenForEditorUpdate())
+	}
 
 	var viewportCmd tea.Cmd
 	m.viewport, viewportCmd = m.viewport.Update(msg)
@@ -1022,10 +1752,17 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		cmds = append(cmds, searchCmd)
 	}
 
-	// Note: calculateVisualMetrics() is called in handleContentChange() for KeyMsg events
-	// Other message types don't modify buffer content, so no recalculation needed.
-	// Rendering always uses the cached visual layout from the last calculation.
-	m.renderVisibleSlice()
+	// Lay out, scroll and render once, as far as the message needs, or for
+	// a key, maybe at the end of the frame
+	if keyStart != nil {
+		cmds = append(cmds, m.refreshKey())
+	} else {
+		m.refresh()
+	}
+
+	if keyStart != nil && *keyStart != m.keyEffects() {
+		m.keyConsumed = true
+	}
 
 	return m, tea.Batch(cmds...)
 }
@@ -1033,12 +1770,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 func (m Model) View() string {
 	state := m.editor.GetState()
 
-	content := m.viewport.View()
-
-	// Overlay completion menu if visible
-	if m.completionMenuVisible && len(m.completions) > 0 {
-		content = m.renderWithCompletionMenu(content)
-	}
+	content := m.renderOverlays(m.viewport.View())
 
 	if m.disableVimMode {
 		return content
@@ -1047,7 +1779,12 @@ func (m Model) View() string {
 	var commandLine string
 
 	if !m.disableVimMode {
-		commandLine = m.theme.CommandLineStyle.Render(state.CommandLine)
+		commandLine = m.renderCommandLine(state)
+
+		if count := searchCount(state); count != "" {
+			gap := max(1, m.width-lipgloss.Width(commandLine)-lipgloss.Width(count))
+			commandLine += m.theme.CommandLineStyle.Render(strings.Repeat(" ", gap) + count)
+		}
 	}
 
 	if m.message != "" {
@@ -1078,16 +1815,50 @@ func (m Model) View() string {
 		commandLine = m.theme.CommandLineStyle.Render(m.searchInput.View())
 	}
 
-	viewContent := lipgloss.JoinVertical(
-		lipgloss.Left,
-		content,
-		statusLine,
-		commandLine,
-	)
+	sections := []string{content, statusLine}
+	if m.quickfix.open {
+		sections = append(sections, m.renderQuickfixPanel())
+	}
+	sections = append(sections, commandLine)
+
+	viewContent := lipgloss.JoinVertical(lipgloss.Left, sections...)
 
 	return viewContent
 }
 
+// renderCommandLine renders the command line, with the cursor drawn on the
+// character it's on, or after the text, while a command is typed.
+func (m *Model) renderCommandLine(state core.State) string {
+	runes := []rune(state.CommandLine)
+	col := state.CommandCursor
+	if col < 0 || col > len(runes) || !m.drawsCursor() {
+		return m.theme.CommandLineStyle.Render(state.CommandLine)
+	}
+
+	under, after := " ", ""
+	if col < len(runes) {
+		under, after = string(runes[col]), string(runes[col+1:])
+	}
+	return m.theme.CommandLineStyle.Render(string(runes[:col])) +
+		m.getCursorStyles().Render(under) +
+		m.theme.CommandLineStyle.Render(after)
+}
+
+// searchCount returns the "[index/total]" shown after a search, or "" when the
+// command line no longer shows the search.
+func searchCount(state core.State) string {
+	if state.SearchQuery.Pattern == "" || state.CommandLine != "/"+state.SearchQuery.Pattern {
+		return ""
+	}
+
+	total := len(state.SearchResults)
+	if total == 0 || state.SearchResultIndex < 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("[%d/%d]", state.SearchResultIndex+1, total)
+}
+
 func (m *Model) getStatusLine() string {
 	if !m.showStatusLine {
 		return ""
@@ -1100,25 +1871,52 @@ func (m *Model) getStatusLine() string {
 	state := m.editor.GetState()
 
 	var statusLine string
+	label := " " + m.labels.mode(state.Mode) + " "
 	switch state.Mode {
 	case core.NormalMode:
-		statusLine = m.theme.NormalModeStyle.Render(" NORMAL ")
+		statusLine = m.theme.NormalModeStyle.Render(label)
 	case core.InsertMode:
-		statusLine = m.theme.InsertModeStyle.Render(" INSERT ")
-	case core.VisualMode:
-		statusLine = m.theme.VisualModeStyle.Render(" VISUAL ")
-	case core.VisualLineMode:
-		statusLine = m.theme.VisualModeStyle.Render(" VISUAL LINE ")
+		statusLine = m.theme.InsertModeStyle.Render(label)
+	case core.VisualMode, core.VisualLineMode:
+		statusLine = m.theme.VisualModeStyle.Render(label)
 	case core.CommandMode:
-		statusLine = m.theme.CommandModeStyle.Render(" COMMAND ")
+		statusLine = m.theme.CommandModeStyle.Render(label)
 	case core.SearchMode:
-		statusLine = m.theme.SearchModeStyle.Render(" SEARCH ")
+		statusLine = m.theme.SearchModeStyle.Render(label)
 	}
 
+	fileInfo := ""
+	if name := m.editor.GetBuffer().FileName(); name != "" {
+		fileInfo = " " + name
+	}
+	if m.editor.GetBuffer().IsModified() {
+		fileInfo += " " + m.labels.Modified
+	}
+	if m.editor.HasConflict() {
+		fileInfo += " " + m.labels.Conflict
+	}
+	statusLine += m.theme.StatusLineStyle.Render(fileInfo)
+
 	cursor := m.editor.GetBuffer().GetCursor()
 
 	cursorInfo := fmt.Sprintf("%d/%d ", cursor.Position.Row+1, cursor.Position.Col+1)
 
+	// Only non-default file formats are shown, as Vim does
+	if lineEnding := m.editor.GetBuffer().LineEnding(); lineEnding != core.LineEndingLF {
+		cursorInfo = fmt.Sprintf("[%s] %s", lineEnding, cursorInfo)
+	}
+
+	if buffer := m.editor.GetBuffer(); buffer.IsLossy() {
+		cursorInfo = m.labels.InvalidUTF8 + " " + cursorInfo
+	} else if encoding := buffer.Encoding(); encoding != core.EncodingUTF8 {
+		cursorInfo = fmt.Sprintf("[%s] %s", encoding, cursorInfo)
+	}
+
+	// The keys of the command being typed, as Vim's showcmd
+	if state.PendingKeys != "" {
+		cursorInfo = state.PendingKeys + "  " + cursorInfo
+	}
+
 	width := m.width - (lipgloss.Width(cursorInfo) + lipgloss.Width(statusLine))
 	gap := strings.Repeat(" ", max(0, width))
 
@@ -1129,6 +1927,25 @@ func (m *Model) getStatusLine() string {
 	return statusLine
 }
 
+// ConfirmQuit answers a ConfirmQuitMsg: it saves first if save is true, then
+// quits, which sends SaveMsg and QuitMsg. If the content can't be saved, an
+// ErrorMsg is sent instead of quitting.
+func (m *Model) ConfirmQuit(save bool) {
+	command := "q!"
+	if save {
+		command = "x"
+	}
+	if err := m.editor.ExecuteCommand(command); err != nil {
+		m.editor.DispatchError(err.ID(), err.Error())
+	}
+}
+
+// FileRenamed answers a RenameMsg once the host renamed the file, for the
+// editor to take the new name. Unsaved changes stay unsaved.
+func (m *Model) FileRenamed(fileName string) {
+	m.editor.FileRenamed(fileName)
+}
+
 // SetMaxHistory sets the maximum number of history entries for undo/redo.
 // This allows controlling how many undo steps are kept in memory.
 // If set to 0, no history will be kept.
@@ -1136,90 +1953,185 @@ func (m *Model) getStatusLine() string {
 // If the number of history entries exceeds this limit, the oldest entries will be removed.
 // This is useful for managing memory usage in the core.
 func (m *Model) SetMaxHistory(max uint32) {
-	m.editor.SetMaxHistory(max)
+	m.editor.Options().SetMaxHistory(max)
+}
+
+// YankHistory returns the texts yanked and deleted last, newest first, e.g. to
+// show them in a picker. After a paste, Ctrl-P and Ctrl-N swap the pasted text
+// for an older or a newer one.
+func (m Model) YankHistory() []string {
+	return m.editor.Registers().YankHistory()
 }
 
+// SetSignalPolicy sets what the editor does with its signals when the model
+// falls behind reading them: drop the new one, the default, drop the oldest,
+// or drop those the new one supersedes, such as older search results.
+func (m *Model) SetSignalPolicy(policy core.SignalPolicy) {
+	m.editor.Options().SetSignalPolicy(policy)
+}
+
+// DroppedSignals returns how many signals were dropped because the model fell
+// behind reading them.
+func (m Model) DroppedSignals() int {
+	return m.editor.DroppedSignals()
+}
+
+// signalsMsg carries the signals the editor sent since the model last read
+// them, as messages, in the order they were sent.
+type signalsMsg []tea.Msg
+
+// listenForEditorUpdate returns a command that waits for the editor's next
+// signal and takes the others already sent with it, so a burst of them, such
+// as a delete's, arrives at once rather than one per Update.
 func (m *Model) listenForEditorUpdate() tea.Cmd {
+	editorChan := m.editor.GetUpdateSignalChan()
 	return func() tea.Msg {
-		editorChan := m.editor.GetUpdateSignalChan()
-		signal := <-editorChan
+		msgs := signalsMsg{signalMsg(<-editorChan)}
+		for len(msgs) < cap(editorChan) {
+			select {
+			case signal := <-editorChan:
+				msgs = append(msgs, signalMsg(signal))
+			default:
+				return msgs
+			}
+		}
+		return msgs
+	}
+}
 
-		switch signal := signal.(type) {
-		case core.CommandSignal:
-			return commandMsg{}
+// signalMsg returns the message the model sends for an editor signal.
+func signalMsg(signal core.Signal) tea.Msg {
+	switch signal := signal.(type) {
+	case core.CommandSignal:
+		return commandMsg{}
+
+	case core.ErrorSignal:
+		id, err := signal.Value()
+		return ErrorMsg{ID: id, Error: err, Severity: signal.Severity()}
+
+	case core.YankSignal:
+		start, end := signal.Range()
+		return yankedMsg{
+			Content:  signal.Value(),
+			Start:    start,
+			End:      end,
+			Register: signal.Register(),
+			Linewise: signal.Linewise(),
+		}
 
-		case core.ErrorSignal:
-			id, err := signal.Value()
-			return ErrorMsg{ID: id, Error: err}
+	case core.PasteSignal:
+		start, end := signal.Range()
+		return PasteMsg{
+			Content:  signal.Value(),
+			Start:    start,
+			End:      end,
+			Register: signal.Register(),
+			Linewise: signal.Linewise(),
+		}
 
-		case core.YankSignal:
-			content := signal.Value()
-			return yankedMsg{
-				Content: content,
-			}
+	case core.SaveSignal:
+		path, content := signal.Value()
+		start, end, partial := signal.Lines()
+		return SaveMsg{
+			Path:      path,
+			Content:   content,
+			Partial:   partial,
+			StartLine: start,
+			EndLine:   end,
+			Append:    signal.Append(),
+		}
 
-		case core.PasteSignal:
-			content := signal.Value()
-			return PasteMsg{Content: content}
+	case core.EnterCommandModeSignal:
+		return clearMsg{}
 
-		case core.SaveSignal:
-			path, content := signal.Value()
-			return SaveMsg{Path: path, Content: content}
+	case core.ModeChangedSignal:
+		from, to := signal.Value()
+		return ModeChangedMsg{From: from, To: to}
 
-		case core.EnterCommandModeSignal:
-			return clearMsg{}
+	case core.QuitSignal:
+		return QuitMsg{}
 
-		case core.QuitSignal:
-			return QuitMsg{}
+	case core.ConfirmQuitSignal:
+		return ConfirmQuitMsg{}
 
-		case core.RenameSignal:
-			return RenameMsg{FileName: signal.Value()}
+	case core.ConflictSignal:
+		base, mine, theirs := signal.Value()
+		return ConflictMsg{Base: base, Mine: mine, Theirs: theirs}
 
-		case core.DeleteFileSignal:
-			return DeleteFileMsg{}
+	case core.RenameSignal:
+		return RenameMsg{FileName: signal.Value(), From: signal.From()}
 
-		case core.RelativeNumbersSignal:
-			return RelativeNumbersChangeMsg{Enabled: signal.Value()}
+	case core.ConfirmSignal:
+		return ConfirmMsg{Action: signal.Value(), Prompt: signal.Prompt(), Accept: signal.Accept, Reject: signal.Reject}
 
-		case core.DeleteSignal:
-			return DeleteMsg{Content: signal.Value()}
+	case core.DeleteFileSignal:
+		return DeleteFileMsg{}
 
-		case core.UndoSignal:
-			return UndoMsg{ContentBefore: signal.Value()}
+	case core.BlockedSignal:
+		return BlockedMsg{Capability: signal.Value()}
 
-		case core.RedoSignal:
-			return RedoMsg{ContentBefore: signal.Value()}
+	case core.RelativeNumbersSignal:
+		return RelativeNumbersChangeMsg{Enabled: signal.Value()}
+	case core.NumbersSignal:
+		return NumbersChangeMsg{Enabled: signal.Value()}
 
-		case core.EnterSearchModeSignal:
-			return enterSearchMode{}
+	case core.DeleteSignal:
+		start, end := signal.Range()
+		return DeleteMsg{
+			Content:  signal.Value(),
+			Start:    start,
+			End:      end,
+			Register: signal.Register(),
+			Linewise: signal.Linewise(),
+		}
 
-		case core.ExitSearchModeSignal:
-			return exitSearchMode{}
+	case core.UndoSignal:
+		return UndoMsg{ContentBefore: signal.Value()}
 
-		case core.SearchResultsSignal:
-			return SearchResultsMsg{Positions: signal.Value()}
+	case core.RedoSignal:
+		return RedoMsg{ContentBefore: signal.Value()}
 
-		case core.CompletionRequestSignal:
-			return CompletionRequestMsg{Context: signal.Context()}
+	case core.EnterSearchModeSignal:
+		return enterSearchMode{}
 
-		case core.CompletionResponseSignal:
-			completions, ctx := signal.Value()
-			return CompletionResponseMsg{Completions: completions, Context: ctx}
-		}
+	case core.ExitSearchModeSignal:
+		return exitSearchMode{}
 
-		return nil
+	case core.SearchResultsSignal:
+		return SearchResultsMsg{Positions: signal.Value(), Index: signal.Index(), Done: signal.Done()}
+
+	case core.QuickfixSignal:
+		return QuickfixMsg{Items: signal.Value(), Index: signal.Index(), Open: signal.Open()}
+
+	case core.CompletionRequestSignal:
+		return CompletionRequestMsg{Context: signal.Context()}
+
+	case core.CompletionResponseSignal:
+		completions, ctx := signal.Value()
+		return CompletionResponseMsg{Completions: completions, Context: ctx}
 	}
+
+	// The messages of commands registered with RegisterCommand are
+	// forwarded as they are.
+	return signal
 }
 
-// Convert Bubbletea key to core.Key
+// convertBubbleKey converts a Bubbletea key to a core key event. Text of more
+// than one rune is cut to its first rune; use convertBubbleKeys to keep it all.
 func convertBubbleKey(msg tea.KeyMsg) core.KeyEvent {
+	return convertBubbleKeys(msg)[0]
+}
+
+// convertBubbleKeys converts a Bubbletea key to core key events. A key usually
+// converts to one event, but text of several runes, as delivered by pastes and
+// input methods, converts to one event per rune so that none of it is lost.
+// Keys the Kitty keyboard protocol tells apart stay apart, so that hosts can
+// bind them: Ctrl-I isn't Tab, and characters typed with Ctrl, Alt or Super,
+// which have no text, are the character with the modifiers.
+func convertBubbleKeys(msg tea.KeyMsg) []core.KeyEvent {
 	k := msg.Key()
 	result := core.KeyEvent{}
 
-	if k.Text != "" {
-		result.Rune = rune(k.Text[0])
-	}
-
 	if k.Mod&tea.ModAlt != 0 {
 		result.Modifiers |= core.ModAlt
 	}
@@ -1228,6 +2140,14 @@ func convertBubbleKey(msg tea.KeyMsg) core.KeyEvent {
 		result.Modifiers |= core.ModCtrl
 	}
 
+	if k.Mod&tea.ModShift != 0 {
+		result.Modifiers |= core.ModShift
+	}
+
+	if k.Mod&tea.ModSuper != 0 {
+		result.Modifiers |= core.ModSuper
+	}
+
 	switch k.Code {
 	case tea.KeyEnter:
 		result.Key = core.KeyEnter
@@ -1260,22 +2180,61 @@ func convertBubbleKey(msg tea.KeyMsg) core.KeyEvent {
 	case tea.KeyPgDown:
 		result.Key = core.KeyPageDown
 	default:
+		// Ctrl+letter has no text; it is a key of its own so that modes do
+		// not mistake it for the letter. On layouts other than Latin ones,
+		// the letter is the key's on a US keyboard.
 		if k.Mod&tea.ModCtrl != 0 {
-			switch k.Code {
-			case 'd':
-				result.Key = core.KeyCtrlD
-			case 'u':
-				result.Key = core.KeyCtrlU
+			key := core.CtrlKey(k.Code)
+			if key == core.KeyUnknown && k.BaseCode != 0 {
+				key = core.CtrlKey(k.BaseCode)
+			}
+			if key != core.KeyUnknown {
+				result.Key = key
+				return []core.KeyEvent{result}
 			}
 		}
+
+		if k.Text == "" {
+			if k.Mod&(tea.ModCtrl|tea.ModAlt|tea.ModSuper) != 0 && unicode.IsPrint(k.Code) {
+				result.Rune = chordRune(k)
+			}
+			return []core.KeyEvent{result}
+		}
+
+		// Text is what was typed, one event per rune. Spaces within it are
+		// typed as the space key, like a space on its own.
+		events := make([]core.KeyEvent, 0, utf8.RuneCountInString(k.Text))
+		for _, r := range k.Text {
+			event := result
+			event.Rune = r
+			if r == ' ' {
+				event.Key = core.KeySpace
+			}
+			events = append(events, event)
+		}
+		return events
 	}
 
-	return result
+	return []core.KeyEvent{result}
+}
+
+// chordRune returns the character of k, typed with Ctrl, Alt or Super, as
+// Shift makes it.
+func chordRune(k tea.Key) rune {
+	switch {
+	case k.Mod&tea.ModShift == 0:
+		return k.Code
+	case k.ShiftedCode != 0:
+		return k.ShiftedCode
+	default:
+		return unicode.ToUpper(k.Code)
+	}
 }
 
-// CursorBlink is the main command for the blinking cursor effect (toggling visibility)
+// CursorBlink is the main command for the blinking cursor effect (toggling visibility).
+// The terminal cursor blinks by itself, so it returns nil for it.
 func (m *Model) CursorBlink() tea.Cmd {
-	if m.cursorMode != CursorBlink || !m.isFocused {
+	if !m.cursorBlinks() || !m.isFocused || m.terminalCursor {
 		m.cursorVisible = m.isFocused
 		return nil
 	}
@@ -1299,7 +2258,7 @@ func (m *Model) CursorBlink() tea.Cmd {
 
 // restartBlinkCycleCmd is used after user activity to delay the resumption of blinking.
 func (m *Model) restartBlinkCycleCmd() tea.Cmd {
-	if m.cursorMode != CursorBlink || !m.isFocused {
+	if !m.cursorBlinks() || !m.isFocused || m.terminalCursor {
 		m.cursorVisible = m.isFocused
 		return nil
 	}
@@ -1320,7 +2279,7 @@ func (m *Model) insertCompletion() tea.Cmd {
 	if err := m.editor.InsertCompletion(completion); err != nil {
 		m.completionMenuVisible = false
 		return func() tea.Msg {
-			return ErrorMsg{ID: core.ErrInvalidPositionId, Error: err}
+			return ErrorMsg{ID: core.ErrInvalidPositionId, Error: err, Severity: core.SeverityError}
 		}
 	}
 
@@ -18,8 +18,8 @@ import (
 const messageDuration = 3 * time.Second
 
 type Model struct {
-	editor editor.Model
-	file   string
+	editor  editor.Model
+	confirm *editor.ConfirmMsg // Waiting for y or n, for :delete or :rename
 }
 
 func (m Model) Init() tea.Cmd {
@@ -37,6 +37,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+		if m.confirm != nil {
+			if msg.String() == "y" {
+				m.confirm.Accept()
+			} else {
+				m.confirm.Reject()
+			}
+			m.confirm = nil
+			return m, m.editor.DispatchMessage("", 0)
+		}
+
+	case editor.ConfirmMsg:
+		m.confirm = &msg
+		return m, m.editor.DispatchMessage(msg.Prompt+" (y/n)", time.Hour)
+
 	case editor.ErrorMsg:
 		return m, m.editor.DispatchError(msg.Error, messageDuration)
 
@@ -52,11 +66,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case editor.SaveMsg:
+		filePath := m.editor.FileName()
 		if msg.Path != nil {
-			m.file = *msg.Path
+			filePath = *msg.Path
 		}
 
-		filePath := m.file
 		if strings.HasPrefix(filePath, "~/") {
 			homeDir, err := os.UserHomeDir()
 			if err != nil {
@@ -66,19 +80,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		if err := os.WriteFile(filePath, []byte(msg.Content), 0o644); err != nil {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if msg.Append {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+
+		if err := writeFile(filePath, flags, msg.Content); err != nil {
 			return m, m.editor.DispatchError(err, messageDuration)
 		}
 
-		return m, m.editor.DispatchMessage(fmt.Sprintf("file saved to %s", m.file), messageDuration)
+		return m, m.editor.DispatchMessage(fmt.Sprintf("file saved to %s", filePath), messageDuration)
 
 	case editor.RenameMsg:
-		if err := os.Rename(m.file, msg.FileName); err != nil {
+		if err := os.Rename(msg.From, msg.FileName); err != nil {
 			return m, m.editor.DispatchError(err, messageDuration)
 		}
+		m.editor.FileRenamed(msg.FileName)
 
 	case editor.DeleteFileMsg:
-		if err := os.Remove(m.file); err != nil {
+		if err := os.Remove(m.editor.FileName()); err != nil {
 			return m, m.editor.DispatchError(err, messageDuration)
 		}
 
@@ -99,6 +119,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m Model) View() tea.View {
 	v := tea.NewView(m.editor.View())
+	v.Cursor = m.editor.Cursor()
 	v.AltScreen = true
 	return v
 }
@@ -119,6 +140,7 @@ func main() {
 	textEditor := editor.New(80, 20)
 	textEditor.Focus()
 	textEditor.SetCursorMode(editor.CursorBlink)
+	textEditor.SetTerminalCursor(true)
 	isDark := lipgloss.HasDarkBackground(os.Stdout, os.Stderr)
 	textEditor.SetLanguage(lang, languageTheme(isDark))
 	textEditor.WithSearchOptions(core.SearchOptions{
@@ -132,10 +154,10 @@ func main() {
 	if content, err := os.ReadFile(file); err == nil {
 		textEditor.SetBytes(content)
 	}
+	textEditor.SetFileName(file)
 
 	m := Model{
 		editor: textEditor,
-		file:   file,
 	}
 
 	p := tea.NewProgram(m)
@@ -154,3 +176,18 @@ func languageTheme(isDark bool) string {
 
 	return "catppuccin-latte"
 }
+
+// writeFile writes content to the file at path, opened with flags.
+func writeFile(path string, flags int, content string) error {
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
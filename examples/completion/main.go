@@ -37,11 +37,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle completion request and provide completions
 		completions := getCompletions(msg.Context)
 
-		// Dispatch completion response signal back to editor
-		m.editor.GetEditor().DispatchSignal(
-			core.NewCompletionResponseSignal(completions, msg.Context),
-		)
-		return m, nil
+		// Send the completions back to the editor
+		return m, func() tea.Msg {
+			return editor.CompletionResponseMsg{Completions: completions, Context: msg.Context}
+		}
 
 	case editor.ErrorMsg:
 		return m, m.editor.DispatchError(msg.Error, messageDuration)
@@ -0,0 +1,58 @@
+package goeditor
+
+import "github.com/ionut-t/goeditor/core"
+
+// ConflictMsg is sent when MarkExternallyModified finds unsaved changes: the
+// file changed on disk under them. Base is the content last saved, Mine the
+// buffer's and Theirs the file's. The host may ask whether to keep the
+// buffer, take the file or compare the two, e.g. in the view Diff returns,
+// and answer with ResolveConflict or MergeConflict.
+type ConflictMsg struct {
+	Base   string
+	Mine   string
+	Theirs string
+}
+
+// Diff returns a diff view of the buffer, on the left, and the file, on the
+// right, of width by height. Once the changes wanted are in the left pane,
+// e.g. taken from the file with do, pass its content to MergeConflict.
+func (msg ConflictMsg) Diff(width, height int) DiffModel {
+	diff := NewDiff(width, height)
+	diff.SetContents(msg.Mine, msg.Theirs)
+	diff.SetNames("buffer", "disk")
+	return diff
+}
+
+// MarkExternallyModified tells the editor the file changed on disk, to
+// content, e.g. from a file watcher. Without unsaved changes the content is
+// reloaded, as a change that u undoes; with them, a ConflictMsg is sent.
+// Content that is the saved content, as after the editor's own save, is
+// ignored. :e! reloads the file's content, dropping unsaved changes.
+func (m *Model) MarkExternallyModified(content []byte) {
+	m.editor.MarkExternallyModified(content)
+	m.handleContentChange()
+	m.renderVisibleSlice()
+}
+
+// HasConflict reports whether a ConflictMsg awaits ResolveConflict or
+// MergeConflict. The status line shows [conflict] meanwhile.
+func (m *Model) HasConflict() bool {
+	return m.editor.HasConflict()
+}
+
+// ResolveConflict answers a ConflictMsg, keeping the buffer with
+// core.KeepMine, which saving then writes over the file, or reloading the
+// file with core.TakeTheirs.
+func (m *Model) ResolveConflict(resolution core.ConflictResolution) {
+	m.editor.ResolveConflict(resolution)
+	m.handleContentChange()
+	m.renderVisibleSlice()
+}
+
+// MergeConflict answers a ConflictMsg with merged, the content to keep, e.g.
+// from the left pane of ConflictMsg.Diff, as a change that u undoes.
+func (m *Model) MergeConflict(merged string) {
+	m.editor.MergeConflict(merged)
+	m.handleContentChange()
+	m.renderVisibleSlice()
+}
@@ -0,0 +1,41 @@
+package goeditor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExternalConflict tests resolving a change on disk that conflicts with
+// unsaved changes through a diff view.
+func TestExternalConflict(t *testing.T) {
+	m := New(60, 10)
+	m.Focus()
+	m.SetContent("one\ntwo\nthree")
+	m = typeText(m, "x")
+	for signals := m.editor.GetUpdateSignalChan(); len(signals) > 0; {
+		<-signals
+	}
+
+	m.MarkExternallyModified([]byte("one\ntwo\nthree\nfour"))
+	assert.True(t, m.HasConflict())
+	assert.Contains(t, m.View(), "[conflict]")
+
+	msgs := m.listenForEditorUpdate()().(signalsMsg)
+	msg := msgs[0].(ConflictMsg)
+	assert.Equal(t, ConflictMsg{Base: "one\ntwo\nthree", Mine: "ne\ntwo\nthree", Theirs: "one\ntwo\nthree\nfour"}, msg)
+
+	diff := msg.Diff(60, 10)
+	assert.Equal(t, "ne\ntwo\nthree", diff.GetContent(DiffLeft))
+	assert.Len(t, diff.Hunks(), 2)
+
+	diff.NextHunk()
+	diff.NextHunk()
+	assert.True(t, diff.Obtain())
+	m.MergeConflict(diff.GetContent(DiffLeft))
+
+	assert.False(t, m.HasConflict())
+	assert.Equal(t, "ne\ntwo\nthree\nfour", m.GetCurrentContent())
+	assert.Equal(t, "one\ntwo\nthree\nfour", m.GetSavedContent())
+	assert.NotContains(t, m.View(), "[conflict]")
+}
@@ -0,0 +1,56 @@
+package goeditor
+
+import "github.com/ionut-t/goeditor/core"
+
+// SetFollow sets whether the editor follows content added with
+// AppendContent, as tail -f does, for log viewers. While the cursor is on
+// the last line, appending moves it to the start of the new last line and
+// scrolls to show it. Moving the cursor up stops following, so the lines
+// being read stay in place, and going back to the last line, e.g. with G,
+// starts it again. Enabling it moves the cursor to the last line.
+func (m *Model) SetFollow(enabled bool) {
+	m.follow = enabled
+	if !enabled {
+		return
+	}
+
+	buffer := m.editor.GetBuffer()
+	cursor := buffer.GetCursor()
+	cursor.Position = core.Position{Row: buffer.LineCount() - 1}
+	buffer.SetCursor(cursor)
+
+	m.calculateVisualMetrics()
+	m.updateVisualTopLine()
+}
+
+// IsFollowing reports whether content added with AppendContent scrolls into
+// view: follow is enabled and the cursor is on the last line.
+func (m *Model) IsFollowing() bool {
+	buffer := m.editor.GetBuffer()
+	return m.follow && buffer.GetCursor().Position.Row == buffer.LineCount()-1
+}
+
+// AppendContent adds text at the end of the content as a single undoable
+// change; newlines in it start new lines. Only the lines it changes are laid
+// out and highlighted again, so it stays fast however long the content
+// grows. See SetFollow to keep them in view.
+func (m *Model) AppendContent(text string) error {
+	if text == "" {
+		return nil
+	}
+
+	following := m.IsFollowing()
+	buffer := m.editor.GetBuffer()
+	row := buffer.LineCount() - 1
+	end := core.Position{Row: row, Col: buffer.LineRuneCount(row)}
+	return m.editLines(row, 1, func() *core.EditorError {
+		if err := m.editor.ReplaceRange(end, end, text); err != nil || !following {
+			return err
+		}
+
+		cursor := buffer.GetCursor()
+		cursor.Position = core.Position{Row: buffer.LineCount() - 1}
+		buffer.SetCursor(cursor)
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+package goeditor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logLines returns lines from to up to but not including to, numbered.
+func logLines(from, to int) string {
+	var b strings.Builder
+	for i := from; i < to; i++ {
+		fmt.Fprintf(&b, "\nline %d", i)
+	}
+	return b.String()
+}
+
+// TestFollow tests appending content while following it and after scrolling up.
+func TestFollow(t *testing.T) {
+	t.Run("appending keeps the last line in view", func(t *testing.T) {
+		m := newTestModel("start", withSize(40, 8), withFollow())
+		assert.True(t, m.IsFollowing())
+
+		assert.NoError(t, m.AppendContent(logLines(1, 30)))
+		assert.Equal(t, 29, m.GetCursorPosition().Row)
+		assert.Equal(t, m.fullVisualLayoutHeight-m.viewport.Height(), m.currentVisualTopLine)
+		assert.Contains(t, sgr.ReplaceAllString(m.View(), ""), "line 29")
+
+		assert.NoError(t, m.AppendContent(" done"))
+		assert.Contains(t, sgr.ReplaceAllString(m.View(), ""), "line 29 done")
+		assert.True(t, m.IsFollowing())
+	})
+
+	t.Run("moving up stops following and G resumes it", func(t *testing.T) {
+		m := newTestModel("start"+logLines(1, 30), withSize(40, 8), withFollow())
+		m = typeText(m, "kkkkkkkkkk")
+		top := m.currentVisualTopLine
+		assert.False(t, m.IsFollowing())
+
+		assert.NoError(t, m.AppendContent(logLines(30, 40)))
+		assert.Equal(t, 19, m.GetCursorPosition().Row)
+		assert.Equal(t, top, m.currentVisualTopLine)
+
+		m = typeText(m, "G")
+		assert.True(t, m.IsFollowing())
+		assert.NoError(t, m.AppendContent(logLines(40, 41)))
+		assert.Equal(t, 40, m.GetCursorPosition().Row)
+		assert.Contains(t, sgr.ReplaceAllString(m.View(), ""), "line 40")
+	})
+
+	t.Run("appending is undone at once", func(t *testing.T) {
+		m := newTestModel("start", withSize(40, 8), withFollow())
+		assert.NoError(t, m.AppendContent(logLines(1, 4)))
+		m = typeText(m, "u")
+		assert.Equal(t, "start", m.GetCurrentContent())
+	})
+
+	t.Run("the layout of a long log is extended, not redone", func(t *testing.T) {
+		long := strings.Repeat("x", 70)
+		m := newTestModel("start"+logLines(1, 300), withSize(40, 8), withFollow())
+		for i := 300; i < 400; i += 10 {
+			assert.NoError(t, m.AppendContent(logLines(i, i+5)+"\n"+long+logLines(i+5, i+10)))
+		}
+		assert.Equal(t, 410, m.lastKnownLineCount)
+
+		assertLayout(t, m)
+		assert.Contains(t, sgr.ReplaceAllString(m.View(), ""), "line 399")
+	})
+}
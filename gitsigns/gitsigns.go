@@ -0,0 +1,191 @@
+// Package gitsigns marks lines that differ from a base version of the buffer
+// (usually the git index or HEAD blob) and lets the user request staging of a
+// hunk with the :Gstage command. The package never runs git itself: the host
+// receives a HunkStageRequested message and performs the operation.
+package gitsigns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	editor "github.com/ionut-t/goeditor"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// StageCommand is the command registered by Attach.
+const StageCommand = "Gstage"
+
+// ErrNoHunk is returned by :Gstage when the cursor is not on a hunk.
+var ErrNoHunk = errors.New("no hunk under cursor")
+
+// HunkStageRequested is sent to the host when the user runs :Gstage on a hunk.
+type HunkStageRequested struct {
+	Hunk      core.Hunk
+	BaseLines []string // Lines the hunk replaces in the base version
+	Lines     []string // Lines of the hunk in the current buffer
+}
+
+// Patch returns the hunk as a unified diff without context lines, suitable for
+// `git apply --cached --unidiff-zero`.
+func (h HunkStageRequested) Patch(path string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", path, path)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+		patchRange(h.Hunk.OldStart, h.Hunk.OldLines),
+		patchRange(h.Hunk.NewStart, h.Hunk.NewLines))
+
+	for _, line := range h.BaseLines {
+		sb.WriteString("-" + line + "\n")
+	}
+	for _, line := range h.Lines {
+		sb.WriteString("+" + line + "\n")
+	}
+
+	return sb.String()
+}
+
+// patchRange formats a unified diff range. Empty ranges point at the line before the hunk.
+func patchRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Signs computes hunks against a base version and turns them into gutter signs.
+type Signs struct {
+	base  []string
+	hunks []core.Hunk
+
+	AddedSign   editor.GutterSign
+	ChangedSign editor.GutterSign
+	DeletedSign editor.GutterSign
+}
+
+// New creates a helper that compares the buffer against base.
+func New(base string) *Signs {
+	s := &Signs{
+		AddedSign:   editor.GutterSign{Text: "│", Style: signStyle("#40a02b")}, // Green
+		ChangedSign: editor.GutterSign{Text: "│", Style: signStyle("#df8e1d")}, // Yellow
+		DeletedSign: editor.GutterSign{Text: "_", Style: signStyle("#d20f39")}, // Red
+	}
+	s.SetBase(base)
+
+	return s
+}
+
+func signStyle(c string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+}
+
+// SetBase replaces the base version, e.g. after the host staged a hunk. CRLF
+// line endings are read as LF, as the buffer reads them.
+func (s *Signs) SetBase(base string) {
+	s.base = splitLines(base)
+	s.hunks = nil
+}
+
+// Hunks returns the hunks computed by the last call to Compute or Update.
+func (s *Signs) Hunks() []core.Hunk {
+	return s.hunks
+}
+
+// Compute diffs content against the base version. CRLF line endings are read
+// as LF, so a file read from disk compares equal to the buffer it was loaded in.
+func (s *Signs) Compute(content string) []core.Hunk {
+	return s.computeLines(splitLines(content))
+}
+
+// computeLines diffs the lines of the buffer against the base version. Update
+// and :Gstage both diff the buffer's lines, so that they agree on the hunks
+// whatever line ending the buffer saves with.
+func (s *Signs) computeLines(lines []string) []core.Hunk {
+	s.hunks = core.DiffLines(s.base, lines)
+	return s.hunks
+}
+
+// splitLines splits text into lines ending in LF or CRLF.
+func splitLines(text string) []string {
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// Update recomputes the hunks for the model's current content and sets its gutter signs.
+// Call it after the content changed, e.g. when handling editor messages.
+func (s *Signs) Update(m *editor.Model) {
+	buffer := m.GetEditor().GetBuffer()
+	hunks := s.computeLines(buffer.GetLines())
+	lineCount := buffer.LineCount()
+
+	signs := make(map[int]editor.GutterSign)
+	for _, h := range hunks {
+		switch h.Kind() {
+		case core.HunkAdd:
+			for row := h.NewStart; row < h.NewEnd(); row++ {
+				signs[row] = s.AddedSign
+			}
+		case core.HunkChange:
+			for row := h.NewStart; row < h.NewEnd(); row++ {
+				signs[row] = s.ChangedSign
+			}
+		case core.HunkDelete:
+			// Mark the line that now sits where the deleted lines were
+			row := min(h.NewStart, max(lineCount-1, 0))
+			if _, exists := signs[row]; !exists {
+				signs[row] = s.DeletedSign
+			}
+		}
+	}
+
+	m.SetGutterSigns(signs)
+}
+
+// HunkAt returns the hunk that covers the given row of the current content.
+// Deleted hunks are matched on the row that follows the deletion.
+func (s *Signs) HunkAt(row int) (core.Hunk, bool) {
+	for _, h := range s.hunks {
+		if h.NewLines == 0 {
+			if row == h.NewStart || row == h.NewStart-1 && h.NewStart > 0 {
+				return h, true
+			}
+			continue
+		}
+		if row >= h.NewStart && row < h.NewEnd() {
+			return h, true
+		}
+	}
+	return core.Hunk{}, false
+}
+
+// Registrar registers ex commands, as editor.Model does.
+type Registrar interface {
+	RegisterCommand(name string, handler editor.CommandHandler)
+}
+
+// Attach registers the :Gstage command with r, usually the model. Running it
+// on a line that belongs to a hunk sends a HunkStageRequested message to the
+// host.
+func (s *Signs) Attach(r Registrar) {
+	r.RegisterCommand(StageCommand, func(ed core.Editor, _ []string) (tea.Msg, *core.EditorError) {
+		buffer := ed.GetBuffer()
+		lines := buffer.GetLines()
+		s.computeLines(lines)
+
+		hunk, ok := s.HunkAt(buffer.GetCursor().Position.Row)
+		if !ok {
+			return nil, core.NewEditorError(core.ErrInvalidCommandId, ErrNoHunk)
+		}
+
+		return HunkStageRequested{
+			Hunk:      hunk,
+			BaseLines: append([]string(nil), s.base[hunk.OldStart:hunk.OldEnd()]...),
+			Lines:     append([]string(nil), lines[hunk.NewStart:hunk.NewEnd()]...),
+		}, nil
+	})
+}
@@ -0,0 +1,98 @@
+package gitsigns
+
+import (
+	"testing"
+
+	editor "github.com/ionut-t/goeditor"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHunkAt tests mapping buffer rows to hunks.
+func TestHunkAt(t *testing.T) {
+	s := New("a\nb\nc\nd")
+	s.Compute("a\nB\nc")
+
+	h, ok := s.HunkAt(1)
+	assert.True(t, ok)
+	assert.Equal(t, core.Hunk{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}, h)
+
+	_, ok = s.HunkAt(0)
+	assert.False(t, ok)
+
+	// The deleted last line is reported on the line before it
+	h, ok = s.HunkAt(2)
+	assert.True(t, ok)
+	assert.Equal(t, core.HunkDelete, h.Kind())
+}
+
+// TestPatch tests the zero-context unified diff sent to the host.
+func TestPatch(t *testing.T) {
+	t.Run("changed line", func(t *testing.T) {
+		req := HunkStageRequested{
+			Hunk:      core.Hunk{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 2},
+			BaseLines: []string{"b"},
+			Lines:     []string{"x", "y"},
+		}
+		assert.Equal(t, "--- a/f.txt\n+++ b/f.txt\n@@ -2 +2,2 @@\n-b\n+x\n+y\n", req.Patch("f.txt"))
+	})
+
+	t.Run("added line", func(t *testing.T) {
+		req := HunkStageRequested{
+			Hunk:  core.Hunk{OldStart: 1, OldLines: 0, NewStart: 1, NewLines: 1},
+			Lines: []string{"new"},
+		}
+		assert.Equal(t, "--- a/f.txt\n+++ b/f.txt\n@@ -1,0 +2 @@\n+new\n", req.Patch("f.txt"))
+	})
+}
+
+// commands registers ex commands for Attach, as the model does.
+type commands map[string]editor.CommandHandler
+
+func (c commands) RegisterCommand(name string, handler editor.CommandHandler) {
+	c[name] = handler
+}
+
+// TestAttach tests asking the host to stage the hunk under the cursor.
+func TestAttach(t *testing.T) {
+	s := New("a\nb\nc")
+	registered := commands{}
+	s.Attach(registered)
+
+	ed := core.New(nil)
+	ed.SetContent([]byte("a\nB\nc"))
+
+	msg, err := registered[StageCommand](ed, nil)
+	assert.Nil(t, msg)
+	assert.ErrorIs(t, err.Error(), ErrNoHunk)
+
+	ed.GetBuffer().SetCursor(core.Cursor{Position: core.Position{Row: 1}})
+	msg, err = registered[StageCommand](ed, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, HunkStageRequested{
+		Hunk:      core.Hunk{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1},
+		BaseLines: []string{"b"},
+		Lines:     []string{"B"},
+	}, msg)
+}
+
+// TestCRLF tests that Update and :Gstage agree on the hunks of a file with
+// CRLF line endings compared against a CRLF base.
+func TestCRLF(t *testing.T) {
+	s := New("a\r\nb\r\nc\r\n")
+	registered := commands{}
+	s.Attach(registered)
+
+	m := editor.New(40, 10)
+	m.SetContent("a\r\nB\r\nc\r\n")
+	want := []core.Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}}
+
+	s.Update(&m)
+	assert.Equal(t, want, s.Hunks())
+
+	m.GetEditor().GetBuffer().SetCursor(core.Cursor{Position: core.Position{Row: 1}})
+	msg, err := registered[StageCommand](m.GetEditor(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, HunkStageRequested{Hunk: want[0], BaseLines: []string{"b"}, Lines: []string{"B"}}, msg)
+	assert.Equal(t, want, s.Hunks())
+}
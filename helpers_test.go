@@ -0,0 +1,110 @@
+package goeditor
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// modelOption sets up a model made by newTestModel, such as its size or a
+// feature to turn on. Options apply in order, after the content is set.
+type modelOption func(m *Model)
+
+// newTestModel returns a focused model of 40 by 10 cells with content.
+func newTestModel(content string, options ...modelOption) Model {
+	m := New(40, 10)
+	m.Focus()
+	m.SetContent(content)
+	for _, option := range options {
+		option(&m)
+	}
+	return m
+}
+
+// withSize sizes the model to width by height cells.
+func withSize(width, height int) modelOption {
+	return func(m *Model) { m.SetSize(width, height) }
+}
+
+// withListening lets the model start listening for the editor's signals,
+// dropping the command that waits for them, so that the commands of later
+// updates can be run without blocking.
+func withListening() modelOption {
+	return func(m *Model) { *m, _ = m.update(commandMsg{}) }
+}
+
+// withTrueColor draws styles in true colour.
+func withTrueColor() modelOption {
+	return func(m *Model) { m.SetColorProfile(colorprofile.TrueColor) }
+}
+
+// withLanguage sets the language of the content, highlighted with theme.
+func withLanguage(language, theme string) modelOption {
+	return func(m *Model) { m.SetLanguage(language, theme) }
+}
+
+// withoutLineNumbers hides the line numbers.
+func withoutLineNumbers() modelOption {
+	return func(m *Model) { m.HideLineNumbers(true) }
+}
+
+// withAccessibility turns on the accessibility mode.
+func withAccessibility() modelOption {
+	return func(m *Model) { m.SetAccessibilityMode(true) }
+}
+
+// withFollow keeps the last line in view as content is appended.
+func withFollow() modelOption {
+	return func(m *Model) { m.SetFollow(true) }
+}
+
+// withJumpLabels turns on jump labels with trigger and labels of chars
+// characters.
+func withJumpLabels(trigger string, chars int) modelOption {
+	return func(m *Model) { m.WithJumpLabels(trigger, chars) }
+}
+
+// withLabels draws and announces in the words of labels.
+func withLabels(labels Labels) modelOption {
+	return func(m *Model) { m.WithLabels(labels) }
+}
+
+// withTerminalCursor leaves drawing the cursor to the terminal.
+func withTerminalCursor() modelOption {
+	return func(m *Model) { m.SetTerminalCursor(true) }
+}
+
+// withTimeoutLen sets how long keys waiting for more wait.
+func withTimeoutLen(timeout time.Duration) modelOption {
+	return func(m *Model) { m.SetTimeoutLen(timeout) }
+}
+
+// withQuickfix sets the quickfix list.
+func withQuickfix(items ...core.QuickfixItem) modelOption {
+	return func(m *Model) { m.SetQuickfixList(items) }
+}
+
+// withRendered draws the visible lines, as the first View does.
+func withRendered() modelOption {
+	return func(m *Model) { m.renderVisibleSlice() }
+}
+
+// withoutAsyncHighlighting tokenises the content as it is drawn.
+func withoutAsyncHighlighting() modelOption {
+	return func(m *Model) { m.SetAsyncHighlighting(false) }
+}
+
+// withConcealRules hides the text the rules match off the cursor line.
+func withConcealRules(rules []ConcealRule) modelOption {
+	return func(m *Model) { m.SetConcealRules(rules) }
+}
+
+// typeText types the characters of text into m, one key press each.
+func typeText(m Model, text string) Model {
+	for _, r := range text {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	return m
+}
@@ -0,0 +1,100 @@
+package highlighter
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// analyseLimit is how much of the content DetectLanguage looks at when the
+// file name doesn't tell the language.
+const analyseLimit = 64 * 1024
+
+// interpreters are the languages of the interpreters of shebang lines that
+// have no lexer of their name.
+var interpreters = map[string]string{
+	"node":   "javascript",
+	"nodejs": "javascript",
+	"deno":   "typescript",
+	"pwsh":   "powershell",
+}
+
+// DetectLanguage returns the language of a file with content, as
+// Model.SetLanguage takes it: the one Chroma matches to the file name, else
+// the interpreter of a shebang line, such as #!/usr/bin/env python3, else
+// the one Chroma's analysers find in the content. It's empty for plain text
+// and content of no known language.
+func DetectLanguage(content, filename string) string {
+	if filename != "" {
+		if lexer := lexers.Match(filepath.Base(filename)); lexer != nil {
+			return languageName(lexer)
+		}
+	}
+
+	if language := shebangLanguage(content); language != "" {
+		return language
+	}
+
+	if len(content) > analyseLimit {
+		content = content[:analyseLimit]
+	}
+	if lexer := lexers.Analyse(content); lexer != nil {
+		return languageName(lexer)
+	}
+	return ""
+}
+
+// shebangLanguage returns the language of the interpreter the shebang line
+// starting content names, empty if it has none or it's not known. Versions,
+// as in python3.12, are dropped when there is no lexer with them.
+func shebangLanguage(content string) string {
+	line, ok := strings.CutPrefix(content, "#!")
+	if !ok {
+		return ""
+	}
+	line, _, _ = strings.Cut(line, "\n")
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, arg := range fields[1:] {
+			if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+				interpreter = filepath.Base(arg)
+				break
+			}
+		}
+	}
+
+	for _, name := range []string{interpreter, strings.TrimRight(interpreter, "0123456789.")} {
+		if name == "" {
+			continue
+		}
+		if language, ok := interpreters[name]; ok {
+			return language
+		}
+		if lexer := lexers.Get(name); lexer != nil {
+			return languageName(lexer)
+		}
+	}
+	return ""
+}
+
+// languageName returns the name SetLanguage takes for lexer: its name in
+// lower case, such as go or c++, or its first alias for names with spaces,
+// such as postgresql. Plain text has none.
+func languageName(lexer chroma.Lexer) string {
+	config := lexer.Config()
+	if config.Name == "plaintext" || config.Name == lexers.Fallback.Config().Name {
+		return ""
+	}
+	if strings.Contains(config.Name, " ") && len(config.Aliases) > 0 {
+		return config.Aliases[0]
+	}
+	return strings.ToLower(config.Name)
+}
@@ -0,0 +1,31 @@
+package highlighter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDetectLanguage tests picking the language of a file from its name and
+// content.
+func TestDetectLanguage(t *testing.T) {
+	for _, c := range []struct {
+		name, content, filename, want string
+	}{
+		{"extension", "", "main.go", "go"},
+		{"path", "", "/srv/app/config.yaml", "yaml"},
+		{"file name", "", "Makefile", "makefile"},
+		{"markdown", "", "README.md", "markdown"},
+		{"name with spaces", "", "api.proto", "protobuf"},
+		{"shebang", "#!/bin/bash\necho hi", "deploy", "bash"},
+		{"shebang through env", "#!/usr/bin/env python3\nprint(1)", "", "python"},
+		{"shebang with a version", "#!/usr/bin/python3.12\n", "", "python"},
+		{"shebang of an interpreter", "#!/usr/bin/env -S node --harmony\n", "", "javascript"},
+		{"plain text", "hello", "notes.txt", ""},
+		{"unknown", "hello", "", ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, DetectLanguage(c.content, c.filename))
+		})
+	}
+}
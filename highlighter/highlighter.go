@@ -1,12 +1,16 @@
 package highlighter
 
 import (
+	"context"
+	"slices"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/colorprofile"
 	"charm.land/lipgloss/v2"
 )
 
@@ -15,11 +19,20 @@ type Highlighter struct {
 	lexer           chroma.Lexer
 	style           *chroma.Style
 	cache           map[int][]chroma.Token // Cache tokens by line number
-	styleCache      map[chroma.TokenType]lipgloss.Style
+	styleCache      map[styleKey]lipgloss.Style
+	profile         colorprofile.Profile
+	maxLineLength   int // Longer lines aren't highlighted, 0 for no limit
 	cacheMutex      sync.RWMutex
 	styleCacheMutex sync.RWMutex
 }
 
+// styleKey identifies a cached token style: the same token type is styled
+// differently for each colour profile.
+type styleKey struct {
+	tokenType chroma.TokenType
+	profile   colorprofile.Profile
+}
+
 // TokenPosition represents a token's position in the original line
 type TokenPosition struct {
 	Token    chroma.Token
@@ -27,6 +40,11 @@ type TokenPosition struct {
 	EndCol   int
 }
 
+// DefaultMaxLineLength is the length, in characters, of the longest line
+// highlighted by default, as Vim's synmaxcol: longer lines, such as minified
+// JSON, are slow to tokenise and drawn plain instead.
+const DefaultMaxLineLength = 3000
+
 // New creates a new syntax highlighter
 func New(language string, theme string) *Highlighter {
 	lexer := lexers.Get(language)
@@ -39,19 +57,68 @@ func New(language string, theme string) *Highlighter {
 	style := styles.Get(theme)
 
 	return &Highlighter{
-		lexer:      lexer,
-		style:      style,
-		cache:      make(map[int][]chroma.Token),
-		styleCache: make(map[chroma.TokenType]lipgloss.Style),
+		lexer:         lexer,
+		style:         style,
+		cache:         make(map[int][]chroma.Token),
+		styleCache:    make(map[styleKey]lipgloss.Style),
+		profile:       colorprofile.TrueColor,
+		maxLineLength: DefaultMaxLineLength,
 	}
 }
 
+// SetMaxLineLength sets the length, in characters, of the longest line
+// highlighted, 0 for no limit. Longer lines get no tokens and are drawn
+// plain, and the lexer sees them as empty lines. The tokens cached for the
+// lines are cleared.
+func (sh *Highlighter) SetMaxLineLength(length int) {
+	sh.cacheMutex.Lock()
+	defer sh.cacheMutex.Unlock()
+	sh.maxLineLength = max(0, length)
+	sh.cache = make(map[int][]chroma.Token)
+}
+
+// MaxLineLength returns the length of the longest line highlighted, 0 for
+// no limit.
+func (sh *Highlighter) MaxLineLength() int {
+	sh.cacheMutex.RLock()
+	defer sh.cacheMutex.RUnlock()
+	return sh.maxLineLength
+}
+
+// isTooLong reports whether line is longer than length characters, without
+// counting past them.
+func isTooLong(line string, length int) bool {
+	switch {
+	case length <= 0 || len(line) <= length:
+		return false
+	case len(line) > utf8.UTFMax*length:
+		return true
+	}
+	return utf8.RuneCountInString(line) > length
+}
+
+// SetColorProfile sets the colour profile of the terminal. Token colours are
+// converted to the nearest colour the profile supports, and dropped for
+// profiles without colours. The default is TrueColor.
+func (sh *Highlighter) SetColorProfile(profile colorprofile.Profile) {
+	sh.styleCacheMutex.Lock()
+	defer sh.styleCacheMutex.Unlock()
+	sh.profile = profile
+}
+
+// ColorProfile returns the colour profile token colours are converted to.
+func (sh *Highlighter) ColorProfile() colorprofile.Profile {
+	sh.styleCacheMutex.RLock()
+	defer sh.styleCacheMutex.RUnlock()
+	return sh.profile
+}
+
 // InvalidateCache clears the token cache (call when content changes)
 func (sh *Highlighter) InvalidateCache() {
 	sh.cacheMutex.Lock()
 	defer sh.cacheMutex.Unlock()
 	sh.cache = make(map[int][]chroma.Token)
-	sh.styleCache = make(map[chroma.TokenType]lipgloss.Style)
+	sh.styleCache = make(map[styleKey]lipgloss.Style)
 }
 
 // InvalidateLine clears the cache for a specific line number.
@@ -61,76 +128,129 @@ func (sh *Highlighter) InvalidateLine(lineNum int) {
 	delete(sh.cache, lineNum)
 }
 
-// Tokenise tokenises only the visible range of lines.
-// Optimised to skip re-tokenisation if all lines are already cached.
-func (sh *Highlighter) Tokenise(lines []string, startLine, endLine int) {
+// SpliceLines updates the cache after the removed lines from line from were
+// replaced by inserted lines: it clears the lines replaced and moves the
+// tokens of the lines below, so they aren't tokenised again.
+func (sh *Highlighter) SpliceLines(from, removed, inserted int) {
 	sh.cacheMutex.Lock()
 	defer sh.cacheMutex.Unlock()
 
-	if startLine < 0 || endLine > len(lines) || startLine >= endLine {
-		return
+	cache := make(map[int][]chroma.Token, len(sh.cache))
+	for lineNum, tokens := range sh.cache {
+		switch {
+		case lineNum < from:
+			cache[lineNum] = tokens
+		case lineNum >= from+removed:
+			cache[lineNum+inserted-removed] = tokens
+		}
 	}
+	sh.cache = cache
+}
 
-	// Check if all lines are already cached
-	allCached := true
-	for i := startLine; i < endLine; i++ {
-		if _, exists := sh.cache[i]; !exists {
-			allCached = false
-			break
-		}
+// Tokenise tokenises only the visible range of lines.
+// Optimised to skip re-tokenisation if all lines are already cached.
+func (sh *Highlighter) Tokenise(lines []string, startLine, endLine int) {
+	if startLine < 0 || endLine > len(lines) || startLine >= endLine {
+		return
 	}
 
 	// If everything is cached, skip tokenisation
-	if allCached {
+	if sh.IsCached(startLine, endLine) {
 		return
 	}
 
-	// Clear cache for the range we're about to tokenise
+	tokens, _ := sh.TokeniseLines(context.Background(), lines[startLine:endLine], startLine)
+	sh.SetTokens(tokens)
+}
+
+// IsCached reports whether the lines from startLine up to endLine all have
+// tokens in the cache.
+func (sh *Highlighter) IsCached(startLine, endLine int) bool {
+	sh.cacheMutex.RLock()
+	defer sh.cacheMutex.RUnlock()
+
 	for i := startLine; i < endLine; i++ {
-		delete(sh.cache, i)
+		if _, exists := sh.cache[i]; !exists {
+			return false
+		}
 	}
-
-	sh.tokeniseRange(lines, startLine, endLine)
+	return true
 }
 
-// tokeniseRange tokenises a specific range of lines and updates the cache
-func (sh *Highlighter) tokeniseRange(lines []string, startLine, endLine int) {
+// TokeniseLines tokenises lines, the first of which is line startLine, and
+// returns the tokens of each line by line number without touching the cache.
+// It is safe to call from another goroutine and stops with ctx.Err() when ctx
+// is cancelled. Store the result with SetTokens.
+func (sh *Highlighter) TokeniseLines(ctx context.Context, lines []string, startLine int) (map[int][]chroma.Token, error) {
+	result := make(map[int][]chroma.Token, len(lines))
+
+	// Lines too long to highlight are lexed as empty, so they get no tokens
+	maxLineLength := sh.MaxLineLength()
+	cloned := false
+	for i, line := range lines {
+		if isTooLong(line, maxLineLength) {
+			if !cloned {
+				lines, cloned = slices.Clone(lines), true
+			}
+			lines[i] = ""
+		}
+	}
+
 	// Join only the lines in this range
-	content := strings.Join(lines[startLine:endLine], "\n")
+	content := strings.Join(lines, "\n")
 	if content != "" && !strings.HasSuffix(content, "\n") {
 		content += "\n"
 	}
 	if content == "" {
-		return
+		return result, nil
 	}
 
 	iterator, err := sh.lexer.Tokenise(nil, content)
 	if err != nil {
-		for i := startLine; i < endLine; i++ {
-			sh.cache[i] = []chroma.Token{}
+		for i := range lines {
+			result[startLine+i] = []chroma.Token{}
 		}
-		return
+		return result, nil
 	}
 
-	tokens := iterator.Tokens()
 	lineNum := startLine
-	sh.cache[lineNum] = []chroma.Token{}
+	result[lineNum] = []chroma.Token{}
+
+	for token := iterator(); token != chroma.EOF; token = iterator() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-	for _, token := range tokens {
 		value := token.Value
 		for strings.Contains(value, "\n") {
 			before, after, _ := strings.Cut(value, "\n")
 			if before != "" {
-				sh.cache[lineNum] = append(sh.cache[lineNum], chroma.Token{Type: token.Type, Value: before})
+				result[lineNum] = append(result[lineNum], chroma.Token{Type: token.Type, Value: before})
 			}
 			lineNum++
-			sh.cache[lineNum] = []chroma.Token{}
+			result[lineNum] = []chroma.Token{}
 			value = after
 		}
 		if value != "" {
-			sh.cache[lineNum] = append(sh.cache[lineNum], chroma.Token{Type: token.Type, Value: value})
+			result[lineNum] = append(result[lineNum], chroma.Token{Type: token.Type, Value: value})
 		}
 	}
+
+	// The newline ending the last line starts a line past the range
+	delete(result, startLine+len(lines))
+
+	return result, nil
+}
+
+// SetTokens stores the tokens of lines by line number, as returned by
+// TokeniseLines.
+func (sh *Highlighter) SetTokens(tokens map[int][]chroma.Token) {
+	sh.cacheMutex.Lock()
+	defer sh.cacheMutex.Unlock()
+
+	for lineNum, lineTokens := range tokens {
+		sh.cache[lineNum] = lineTokens
+	}
 }
 
 // GetTokensForLine returns syntax tokens for a specific line.
@@ -144,12 +264,14 @@ func (sh *Highlighter) GetTokensForLine(lineNum int, lines []string) []chroma.To
 	return nil
 }
 
-// GetStyleForToken converts a Chroma token type to a lipgloss style.
+// GetStyleForToken converts a Chroma token type to a lipgloss style, with the
+// colour converted for the colour profile.
 // Thread-safe with read-write lock for cache access.
 func (sh *Highlighter) GetStyleForToken(tokenType chroma.TokenType) lipgloss.Style {
 	// Try read lock first (fast path for cached styles)
 	sh.styleCacheMutex.RLock()
-	if style, ok := sh.styleCache[tokenType]; ok {
+	key := styleKey{tokenType: tokenType, profile: sh.profile}
+	if style, ok := sh.styleCache[key]; ok {
 		sh.styleCacheMutex.RUnlock()
 		return style
 	}
@@ -160,7 +282,9 @@ func (sh *Highlighter) GetStyleForToken(tokenType chroma.TokenType) lipgloss.Sty
 
 	style := lipgloss.NewStyle()
 	if entry.Colour.IsSet() {
-		style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+		if colour := key.profile.Convert(lipgloss.Color(entry.Colour.String())); colour != nil {
+			style = style.Foreground(colour)
+		}
 	}
 
 	if entry.Bold == chroma.Yes {
@@ -175,7 +299,7 @@ func (sh *Highlighter) GetStyleForToken(tokenType chroma.TokenType) lipgloss.Sty
 
 	// Write lock to update cache
 	sh.styleCacheMutex.Lock()
-	sh.styleCache[tokenType] = style
+	sh.styleCache[key] = style
 	sh.styleCacheMutex.Unlock()
 
 	return style
@@ -212,3 +336,122 @@ func FindTokenAtPosition(positions []TokenPosition, col int) (chroma.Token, bool
 	}
 	return chroma.Token{}, false
 }
+
+// Symbol is a declaration found in the content, such as a function, a type or
+// a Markdown heading.
+type Symbol struct {
+	Name string
+	Line int // Zero-indexed line
+	Col  int // Rune column of the name
+}
+
+// declarationKeywords are the keywords that start a declaration whose name is a
+// symbol, across the common languages.
+var declarationKeywords = map[string]bool{
+	"func": true, "function": true, "def": true, "fn": true, "fun": true,
+	"class": true, "struct": true, "type": true, "interface": true, "enum": true,
+	"trait": true, "impl": true, "module": true, "mod": true, "object": true,
+	"record": true, "namespace": true, "protocol": true, "extension": true,
+}
+
+// declarationModifiers are keywords that may come before a declaration keyword.
+var declarationModifiers = map[string]bool{
+	"pub": true, "export": true, "default": true, "async": true, "static": true,
+	"public": true, "private": true, "protected": true, "internal": true,
+	"abstract": true, "final": true, "sealed": true, "open": true, "unsafe": true,
+	"data": true, "inline": true,
+}
+
+// Symbols returns the declarations in lines: the name declared by each line
+// that starts with a declaration keyword (func, def, fn, class, type, ...) and,
+// for Markdown, each heading. It tokenises the whole content and does not use
+// or change the token cache.
+func (sh *Highlighter) Symbols(lines []string) []Symbol {
+	content := strings.Join(lines, "\n")
+	if content == "" {
+		return nil
+	}
+
+	iterator, err := sh.lexer.Tokenise(nil, content+"\n")
+	if err != nil {
+		return nil
+	}
+
+	// Split the tokens into lines, like tokeniseRange
+	tokenLines := [][]chroma.Token{{}}
+	for _, token := range iterator.Tokens() {
+		value := token.Value
+		for {
+			before, after, found := strings.Cut(value, "\n")
+			if before != "" {
+				last := len(tokenLines) - 1
+				tokenLines[last] = append(tokenLines[last], chroma.Token{Type: token.Type, Value: before})
+			}
+			if !found {
+				break
+			}
+			tokenLines = append(tokenLines, []chroma.Token{})
+			value = after
+		}
+	}
+
+	var symbols []Symbol
+	for line, tokens := range tokenLines {
+		if line >= len(lines) {
+			break
+		}
+		if symbol, ok := lineSymbol(tokens); ok {
+			symbol.Line = line
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// lineSymbol returns the symbol declared by the tokens of one line.
+func lineSymbol(tokens []chroma.Token) (Symbol, bool) {
+	positions := GetTokenPositions(tokens)
+
+	// Skip indentation and modifiers to the first keyword
+	i := 0
+	for i < len(positions) {
+		t := positions[i].Token
+		value := strings.TrimSpace(t.Value)
+		switch {
+		case value == "" || t.Type.InCategory(chroma.Comment):
+			i++
+			continue
+		case t.Type == chroma.GenericHeading || t.Type == chroma.GenericSubheading:
+			name := strings.TrimSpace(strings.TrimLeft(value, "#"))
+			return Symbol{Name: name, Col: positions[i].StartCol}, name != ""
+		case t.Type.InCategory(chroma.Keyword) && declarationModifiers[value]:
+			i++
+			continue
+		case t.Type.InCategory(chroma.Keyword) && declarationKeywords[value]:
+		default:
+			return Symbol{}, false
+		}
+		break
+	}
+	if i == len(positions) {
+		return Symbol{}, false
+	}
+
+	// A function or class name wins, e.g. the method name after a Go
+	// receiver; otherwise the first name after the keyword
+	var first *TokenPosition
+	for j := i + 1; j < len(positions); j++ {
+		p := &positions[j]
+		t := p.Token.Type
+		if t == chroma.NameFunction || t == chroma.NameClass {
+			return Symbol{Name: p.Token.Value, Col: p.StartCol}, true
+		}
+		if first == nil && t.InCategory(chroma.Name) && t != chroma.NameBuiltinPseudo {
+			first = p
+		}
+	}
+	if first == nil {
+		return Symbol{}, false
+	}
+	return Symbol{Name: first.Token.Value, Col: first.StartCol}, true
+}
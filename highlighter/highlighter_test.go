@@ -0,0 +1,118 @@
+package highlighter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetStyleForTokenColorProfile tests converting token colours for the
+// colour profile of the terminal.
+func TestGetStyleForTokenColorProfile(t *testing.T) {
+	// monokai keywords are #66d9ef
+	sh := New("go", "monokai")
+	keyword := lipgloss.Color("#66d9ef")
+
+	t.Run("true colour keeps the colour", func(t *testing.T) {
+		assert.Equal(t, keyword, sh.GetStyleForToken(chroma.Keyword).GetForeground())
+	})
+
+	t.Run("256 colours", func(t *testing.T) {
+		sh.SetColorProfile(colorprofile.ANSI256)
+		fg := sh.GetStyleForToken(chroma.Keyword).GetForeground()
+		assert.Equal(t, colorprofile.ANSI256.Convert(keyword), fg)
+		assert.NotEqual(t, keyword, fg)
+	})
+
+	t.Run("16 colours", func(t *testing.T) {
+		sh.SetColorProfile(colorprofile.ANSI)
+		assert.Equal(t, colorprofile.ANSI.Convert(keyword), sh.GetStyleForToken(chroma.Keyword).GetForeground())
+	})
+
+	t.Run("no colours keeps the attributes", func(t *testing.T) {
+		sh.SetColorProfile(colorprofile.ASCII)
+		style := sh.GetStyleForToken(chroma.Keyword)
+		assert.Equal(t, lipgloss.NoColor{}, style.GetForeground())
+		assert.Equal(t, sh.style.Get(chroma.Keyword).Bold == chroma.Yes, style.GetBold())
+	})
+
+	t.Run("styles are cached per profile", func(t *testing.T) {
+		sh.SetColorProfile(colorprofile.TrueColor)
+		sh.GetStyleForToken(chroma.Keyword)
+		assert.Contains(t, sh.styleCache, styleKey{chroma.Keyword, colorprofile.ANSI256})
+		assert.Contains(t, sh.styleCache, styleKey{chroma.Keyword, colorprofile.TrueColor})
+	})
+}
+
+// TestTokeniseLines tests tokenising lines without the cache.
+func TestTokeniseLines(t *testing.T) {
+	sh := New("go", "monokai")
+
+	t.Run("returns the tokens of each line", func(t *testing.T) {
+		tokens, err := sh.TokeniseLines(context.Background(), []string{"package main", "", "func main() {}"}, 4)
+		assert.NoError(t, err)
+		assert.Len(t, tokens, 3)
+		assert.Equal(t, chroma.KeywordNamespace, tokens[4][0].Type)
+		assert.Empty(t, tokens[5])
+		assert.False(t, sh.IsCached(4, 7))
+
+		sh.SetTokens(tokens)
+		assert.True(t, sh.IsCached(4, 7))
+		assert.Equal(t, tokens[6], sh.GetTokensForLine(6, nil))
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tokens, err := sh.TokeniseLines(ctx, []string{"package main"}, 0)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, tokens)
+	})
+}
+
+// TestSpliceLines tests moving the cached tokens when lines are replaced.
+func TestSpliceLines(t *testing.T) {
+	sh := New("go", "monokai")
+	lines := []string{"package main", "", "var a = 1", "var b = 2", "func main() {}"}
+	sh.Tokenise(lines, 0, len(lines))
+	last := sh.GetTokensForLine(4, nil)
+
+	// Line 2 and 3 replaced by one line
+	sh.SpliceLines(2, 2, 1)
+	assert.True(t, sh.IsCached(0, 2))
+	assert.Nil(t, sh.GetTokensForLine(2, nil))
+	assert.Equal(t, last, sh.GetTokensForLine(3, nil))
+	assert.Nil(t, sh.GetTokensForLine(4, nil))
+
+	// Two lines inserted before line 1
+	sh.SpliceLines(1, 0, 2)
+	assert.True(t, sh.IsCached(3, 4))
+	assert.Nil(t, sh.GetTokensForLine(1, nil))
+	assert.Equal(t, last, sh.GetTokensForLine(5, nil))
+}
+
+// TestMaxLineLength tests that lines too long to highlight get no tokens.
+func TestMaxLineLength(t *testing.T) {
+	sh := New("go", "monokai")
+	assert.Equal(t, DefaultMaxLineLength, sh.MaxLineLength())
+
+	long := "var a = " + strings.Repeat("1", 40)
+	lines := []string{"package main", long, "var b = 2", "var é = \"" + strings.Repeat("é", 9) + "\""}
+	sh.SetMaxLineLength(20)
+	tokens, err := sh.TokeniseLines(context.Background(), lines, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, tokens[1])
+	assert.Equal(t, chroma.KeywordDeclaration, tokens[2][0].Type)
+	assert.NotEmpty(t, tokens[3], "the length is in characters, not bytes")
+	assert.Equal(t, long, lines[1], "the lines passed in are kept")
+
+	sh.SetMaxLineLength(0)
+	tokens, err = sh.TokeniseLines(context.Background(), lines, 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens[1])
+}
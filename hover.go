@@ -0,0 +1,43 @@
+package goeditor
+
+import (
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// hoverOverlayID is the id of the overlay shown by ShowHover.
+const hoverOverlayID = "goeditor.hover"
+
+// ShowHover shows content in a popup framed with style, below the buffer
+// position or above it when there is no room below, e.g. documentation from a
+// language server. The popup follows the position through wrapping and
+// scrolling and is dismissed when the cursor moves or Escape is pressed.
+// Showing another hover replaces it.
+func (m *Model) ShowHover(position core.Position, content string, style lipgloss.Style) {
+	m.hoverCursor = m.editor.GetBuffer().GetCursor().Position
+	m.registerOverlay(overlay{
+		id:       hoverOverlayID,
+		position: OverlayPosition{Anchor: AnchorPosition, Position: position},
+		content:  func() string { return content },
+		zIndex:   1,
+		style:    &style,
+	})
+}
+
+// HideHover dismisses the popup shown by ShowHover.
+func (m *Model) HideHover() {
+	m.RemoveOverlay(hoverOverlayID)
+}
+
+// IsHoverVisible reports whether a popup shown by ShowHover is still up.
+func (m *Model) IsHoverVisible() bool {
+	return m.HasOverlay(hoverOverlayID)
+}
+
+// hideHoverOnCursorMove dismisses the hover once the cursor has left the
+// position it had when the hover was shown.
+func (m *Model) hideHoverOnCursorMove() {
+	if m.IsHoverVisible() && m.editor.GetBuffer().GetCursor().Position != m.hoverCursor {
+		m.HideHover()
+	}
+}
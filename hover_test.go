@@ -0,0 +1,79 @@
+package goeditor
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHover tests showing a popup at a buffer position and dismissing it.
+func TestHover(t *testing.T) {
+	style := lipgloss.NewStyle().Border(lipgloss.NormalBorder())
+
+	const content = "func main() {\n\tfmt.Println()\n}"
+
+	t.Run("is drawn below the position", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12))
+		m.ShowHover(core.Position{Row: 1, Col: 5}, "prints a line", style)
+
+		assert.True(t, m.IsHoverVisible())
+		lines := strings.Split(m.View(), "\n")
+		assert.Contains(t, lines[3], "│prints a line│")
+
+		x, _, _ := m.screenPosition(core.Position{Row: 1, Col: 5})
+		layer := m.overlayLayer(m.overlays[0])
+		assert.Equal(t, x, layer.GetX())
+		assert.Equal(t, 2, layer.GetY())
+	})
+
+	t.Run("follows wrapping", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12))
+		m.SetContent(strings.Repeat("word ", 20))
+		m.ShowHover(core.Position{Row: 0, Col: 60}, "doc", style)
+
+		_, y, ok := m.screenPosition(core.Position{Row: 0, Col: 60})
+		assert.True(t, ok)
+		assert.Positive(t, y)
+		assert.Equal(t, y+1, m.overlayLayer(m.overlays[0]).GetY())
+	})
+
+	t.Run("a cursor move dismisses it", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12))
+		m.ShowHover(core.Position{Row: 0, Col: 0}, "doc", style)
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'l', Text: "l"})
+		assert.False(t, m.IsHoverVisible())
+		assert.NotContains(t, m.View(), "doc")
+	})
+
+	t.Run("a key that does not move keeps it", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12))
+		m.ShowHover(core.Position{Row: 0, Col: 0}, "doc", style)
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'k', Text: "k"})
+		assert.True(t, m.IsHoverVisible())
+	})
+
+	t.Run("Escape dismisses it", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12))
+		m.ShowHover(core.Position{Row: 0, Col: 0}, "doc", style)
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+		assert.False(t, m.IsHoverVisible())
+	})
+
+	t.Run("showing again replaces it", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12))
+		m.ShowHover(core.Position{Row: 0, Col: 0}, "first", style)
+		m.ShowHover(core.Position{Row: 2, Col: 0}, "second", style)
+
+		assert.Len(t, m.overlays, 1)
+		view := m.View()
+		assert.NotContains(t, view, "first")
+		assert.Contains(t, view, "second")
+	})
+}
@@ -0,0 +1,278 @@
+package goeditor
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// jumpLabelChars are the characters jump labels are made of, home row first.
+const jumpLabelChars = "asdfghjklqwertyuiopzxcvbnm"
+
+// jumpTarget is a visible match of the typed characters.
+type jumpTarget struct {
+	pos   core.Position
+	x, y  int // Screen cell of the match within the viewport
+	label string
+}
+
+// jumpLabels is the state of EasyMotion/leap style jumping: the trigger keys
+// are followed by one or two characters, every visible match of them gets a
+// label and typing a label moves the cursor to its match.
+type jumpLabels struct {
+	trigger []rune          // Keys that start a jump in normal mode, e.g. "s" or "gs"
+	chars   int             // Characters typed to pick the matches
+	held    []core.KeyEvent // Trigger keys typed so far, replayed if the trigger is not completed
+
+	active  bool
+	pattern []rune
+	targets []jumpTarget
+	typed   string // Label characters typed so far
+}
+
+// WithJumpLabels makes trigger (e.g. "s" or "gs") start a jump in normal mode:
+// after it, type chars characters (1 or 2) and every visible match of them is
+// labelled; typing a label moves the cursor to its match. Escape cancels. An
+// empty trigger turns jump labels off.
+func (m *Model) WithJumpLabels(trigger string, chars int) {
+	m.jumpLabels = jumpLabels{
+		trigger: []rune(trigger),
+		chars:   min(max(chars, 1), 2),
+	}
+}
+
+// IsJumpLabelActive reports whether a jump is waiting for its characters or label.
+func (m *Model) IsJumpLabelActive() bool {
+	return m.jumpLabels.active
+}
+
+// handleJumpTrigger matches keys against the trigger. It returns the keys the
+// editor should handle, which include held trigger keys when the trigger is not
+// completed, and whether the keys were taken by the trigger.
+func (m *Model) handleJumpTrigger(keyEvents []core.KeyEvent) ([]core.KeyEvent, bool) {
+	j := &m.jumpLabels
+	if len(j.trigger) == 0 {
+		return keyEvents, false
+	}
+
+	held := j.held
+	j.held = nil
+
+	if len(keyEvents) == 1 && m.editor.IsNormalMode() && (len(held) > 0 || !m.editor.HasPendingKeys()) {
+		key := keyEvents[0]
+		if key.Modifiers&(core.ModCtrl|core.ModAlt) == 0 && key.Rune == j.trigger[len(held)] {
+			if len(held)+1 == len(j.trigger) {
+				m.startJumpLabels()
+			} else {
+				j.held = append(held, key)
+			}
+			return nil, true
+		}
+	}
+
+	return append(held, keyEvents...), false
+}
+
+// startJumpLabels waits for the characters to jump to.
+func (m *Model) startJumpLabels() {
+	j := &m.jumpLabels
+	j.active = true
+	j.pattern = nil
+	j.targets = nil
+	j.typed = ""
+	m.editor.UpdateCommand(string(j.trigger))
+}
+
+// stopJumpLabels ends the jump without moving the cursor.
+func (m *Model) stopJumpLabels() {
+	j := &m.jumpLabels
+	j.active = false
+	j.pattern = nil
+	j.targets = nil
+	j.typed = ""
+	m.editor.UpdateCommand("")
+}
+
+// handleJumpLabelKey handles a key while a jump is active: first the
+// characters to match, then the label of the match to jump to.
+func (m *Model) handleJumpLabelKey(key core.KeyEvent) {
+	j := &m.jumpLabels
+
+	if key.Key == core.KeyEscape || key.Rune == 0 || key.Modifiers&(core.ModCtrl|core.ModAlt) != 0 {
+		m.stopJumpLabels()
+		return
+	}
+
+	if len(j.pattern) < j.chars {
+		j.pattern = append(j.pattern, key.Rune)
+		m.editor.UpdateCommand(string(j.trigger) + string(j.pattern))
+		if len(j.pattern) < j.chars {
+			return
+		}
+
+		m.findJumpTargets()
+		switch len(j.targets) {
+		case 0:
+			m.stopJumpLabels()
+		case 1:
+			m.jumpTo(j.targets[0].pos)
+		}
+		return
+	}
+
+	typed := j.typed + string(key.Rune)
+	var matching []jumpTarget
+	for _, target := range j.targets {
+		if strings.HasPrefix(target.label, typed) {
+			matching = append(matching, target)
+		}
+	}
+
+	switch {
+	case len(matching) == 0:
+		m.stopJumpLabels()
+	case len(matching) == 1 && matching[0].label == typed:
+		m.jumpTo(matching[0].pos)
+	default:
+		j.typed = typed
+	}
+}
+
+// jumpTo moves the cursor to pos and ends the jump.
+func (m *Model) jumpTo(pos core.Position) {
+	m.stopJumpLabels()
+
+	buffer := m.editor.GetBuffer()
+	cursor := buffer.GetCursor()
+	cursor.Position = pos
+	cursor.Preferred = pos.Col
+	buffer.SetCursor(cursor)
+
+	m.calculateVisualMetrics()
+	m.updateVisualTopLine()
+}
+
+// findJumpTargets finds the matches of the pattern in the visible rows and
+// labels them, nearest to the cursor first. The pattern ignores case unless it
+// has an upper case letter.
+func (m *Model) findJumpTargets() {
+	j := &m.jumpLabels
+	j.targets = nil
+
+	pattern := j.pattern
+	ignoreCase := !slices.ContainsFunc(pattern, unicode.IsUpper)
+	if ignoreCase {
+		pattern = lowerRunes(pattern)
+	}
+
+	lines := m.editor.GetBuffer().GetLines()
+	cursor := m.editor.GetBuffer().GetCursor().Position
+	lineNumWidth := m.calculateLineNumberWidth(len(lines))
+
+	top := max(m.currentVisualTopLine, 0)
+	for y := range m.viewport.Height() {
+		cacheIdx := top + y - m.visualLayoutCacheStartVisualRow
+		if cacheIdx < 0 || cacheIdx >= len(m.visualLayoutCache) {
+			continue
+		}
+		vli := m.visualLayoutCache[cacheIdx]
+		if vli.LogicalRow < 0 || vli.LogicalRow >= len(lines) {
+			continue
+		}
+
+		line := []rune(lines[vli.LogicalRow])
+		if ignoreCase {
+			line = lowerRunes(line)
+		}
+		segment := []rune(vli.Content)
+		end := vli.LogicalStartCol + len(segment)
+
+		// Matches may run into the next segment but must start in this one
+		for col := vli.LogicalStartCol; col < end && col+len(pattern) <= len(line); col++ {
+			if !slices.Equal(line[col:col+len(pattern)], pattern) {
+				continue
+			}
+			pos := core.Position{Row: vli.LogicalRow, Col: col}
+			if pos == cursor {
+				continue
+			}
+			x := lineNumWidth + vli.prefixWidth() + m.layoutOptions.width(string(segment[:col-vli.LogicalStartCol]))
+			j.targets = append(j.targets, jumpTarget{pos: pos, x: x, y: y})
+		}
+	}
+
+	distance := func(p core.Position) int {
+		rows := abs(p.Row - cursor.Row)
+		return rows*10000 + abs(p.Col-cursor.Col)
+	}
+	slices.SortStableFunc(j.targets, func(a, b jumpTarget) int {
+		return distance(a.pos) - distance(b.pos)
+	})
+
+	labels := jumpLabelsFor(len(j.targets))
+	j.targets = j.targets[:len(labels)]
+	for i := range j.targets {
+		j.targets[i].label = labels[i]
+	}
+}
+
+// jumpLabelsFor returns labels for n targets: single characters while they
+// last, two characters otherwise. Targets beyond the two character labels get
+// none.
+func jumpLabelsFor(n int) []string {
+	chars := []rune(jumpLabelChars)
+	if n <= len(chars) {
+		labels := make([]string, n)
+		for i := range labels {
+			labels[i] = string(chars[i])
+		}
+		return labels
+	}
+
+	labels := make([]string, 0, min(n, len(chars)*len(chars)))
+	for _, first := range chars {
+		for _, second := range chars {
+			if len(labels) == n {
+				return labels
+			}
+			labels = append(labels, string(first)+string(second))
+		}
+	}
+	return labels
+}
+
+// jumpLabelLayers returns a layer for each label that still matches what was
+// typed, placed on its match and leaving out the label characters already typed.
+func (m *Model) jumpLabelLayers() []*lipgloss.Layer {
+	j := &m.jumpLabels
+
+	var layers []*lipgloss.Layer
+	for _, target := range j.targets {
+		if !strings.HasPrefix(target.label, j.typed) {
+			continue
+		}
+		label := m.theme.JumpLabelStyle.Render(strings.TrimPrefix(target.label, j.typed))
+		layers = append(layers, lipgloss.NewLayer(label).X(target.x).Y(target.y).Z(1))
+	}
+
+	return layers
+}
+
+// lowerRunes lower-cases runes one by one, so that indexes stay the same.
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
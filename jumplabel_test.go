@@ -0,0 +1,106 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestJumpLabels tests labelling the visible matches of typed characters and jumping to one.
+func TestJumpLabels(t *testing.T) {
+	const content = "the cat sat\non the mat\nthe end"
+
+	t.Run("labels matches nearest first and jumps to the typed label", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 12), withJumpLabels("s", 2))
+		m = typeText(m, "sth")
+
+		assert.True(t, m.IsJumpLabelActive())
+		// The match under the cursor is left out
+		labels := map[core.Position]string{}
+		for _, target := range m.jumpLabels.targets {
+			labels[target.pos] = target.label
+		}
+		assert.Equal(t, map[core.Position]string{
+			{Row: 1, Col: 3}: "a",
+			{Row: 2, Col: 0}: "s",
+		}, labels)
+
+		m = typeText(m, "s")
+		assert.False(t, m.IsJumpLabelActive())
+		assert.Equal(t, core.Position{Row: 2, Col: 0}, m.GetCursorPosition())
+	})
+
+	t.Run("draws the labels over the matches", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 12), withJumpLabels("s", 1))
+		m = typeText(m, "sm")
+
+		// A single match is jumped to at once
+		assert.False(t, m.IsJumpLabelActive())
+		assert.Equal(t, core.Position{Row: 1, Col: 7}, m.GetCursorPosition())
+
+		m = typeText(m, "sa")
+		assert.True(t, m.IsJumpLabelActive())
+		assert.Len(t, m.jumpLabels.targets, 3)
+
+		nearest := m.jumpLabels.targets[0]
+		assert.Equal(t, core.Position{Row: 1, Col: 8}, nearest.pos)
+		assert.Equal(t, "a", nearest.label)
+		assert.Equal(t, 1, nearest.y)
+		assert.Equal(t, m.calculateLineNumberWidth(3)+8, nearest.x)
+		m.theme.JumpLabelStyle = lipgloss.NewStyle()
+		assert.Contains(t, m.View(), "the cst sdt")
+	})
+
+	t.Run("a two key trigger replays its first key when not completed", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 12), withJumpLabels("gs", 2))
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+		m = typeText(m, "gg")
+
+		assert.False(t, m.IsJumpLabelActive())
+		assert.Equal(t, 0, m.GetCursorPosition().Row)
+
+		m = typeText(m, "gsen")
+		assert.False(t, m.IsJumpLabelActive())
+		assert.Equal(t, core.Position{Row: 2, Col: 4}, m.GetCursorPosition())
+	})
+
+	t.Run("the trigger is not taken in the middle of a command", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 12), withJumpLabels("s", 2))
+		m = typeText(m, "fs")
+
+		assert.False(t, m.IsJumpLabelActive())
+		assert.Equal(t, core.Position{Row: 0, Col: 8}, m.GetCursorPosition())
+	})
+
+	t.Run("Escape cancels without moving", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 12), withJumpLabels("s", 2))
+		m = typeText(m, "sth")
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+		assert.False(t, m.IsJumpLabelActive())
+		assert.Equal(t, core.Position{}, m.GetCursorPosition())
+	})
+
+	t.Run("an upper case character matches case", func(t *testing.T) {
+		m := newTestModel(content, withSize(60, 12), withJumpLabels("s", 1))
+		m.SetContent("a A a A")
+		m = typeText(m, "sA")
+
+		assert.Len(t, m.jumpLabels.targets, 2)
+	})
+}
+
+// TestJumpLabelsFor tests the label sets for different numbers of targets.
+func TestJumpLabelsFor(t *testing.T) {
+	assert.Equal(t, []string{"a", "s", "d"}, jumpLabelsFor(3))
+
+	labels := jumpLabelsFor(30)
+	assert.Len(t, labels, 30)
+	assert.Equal(t, "aa", labels[0])
+	assert.Equal(t, "sa", labels[26])
+
+	assert.Len(t, jumpLabelsFor(1000), 26*26)
+}
@@ -0,0 +1,72 @@
+package goeditor
+
+import "github.com/ionut-t/goeditor/core"
+
+// DisableKeys keeps keys from the editor, for hosts that use them themselves,
+// such as : or Ctrl-C for their own commands. Keys are written in Vim's
+// notation, as core.ParseKeys reads it: "<C-c>", ":", "<A-x>", "<Esc>".
+// The editor ignores them in every mode, leaving them to the rest of the
+// program. Each call replaces the keys disabled before, so calling it with
+// none enables them all again.
+func (m *Model) DisableKeys(keys ...string) error {
+	var disabled []core.KeyEvent
+	for _, key := range keys {
+		events, err := core.ParseKeys(key)
+		if err != nil {
+			return err
+		}
+		disabled = append(disabled, events...)
+	}
+	m.disabledKeys = disabled
+	return nil
+}
+
+// InterceptKeys sets a function that sees each key before the editor does,
+// after the keys disabled by DisableKeys are left out. It returns true to
+// pass the key through to the editor, or false to swallow it, for hosts
+// whose keys depend on their own state. nil removes it.
+func (m *Model) InterceptKeys(intercept func(key core.KeyEvent) bool) {
+	m.interceptKey = intercept
+}
+
+// filterKeys returns the keys of events the editor may handle.
+func (m *Model) filterKeys(events []core.KeyEvent) []core.KeyEvent {
+	if len(m.disabledKeys) == 0 && m.interceptKey == nil {
+		return events
+	}
+
+	allowed := events[:0:0]
+	for _, event := range events {
+		switch {
+		case m.isDisabledKey(event):
+			m.dropKey(event, "disabled")
+		case m.interceptKey != nil && !m.interceptKey(event):
+			m.dropKey(event, "intercepted")
+		default:
+			allowed = append(allowed, event)
+		}
+	}
+	return allowed
+}
+
+// isDisabledKey reports whether event is one of the keys DisableKeys set.
+func (m *Model) isDisabledKey(event core.KeyEvent) bool {
+	for _, key := range m.disabledKeys {
+		if sameKey(event, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameKey reports whether event is key, as parsed by core.ParseKeys. Shift
+// is ignored for characters, which it has already changed, so ":" matches
+// whether the terminal reports Shift with it or not.
+func sameKey(event, key core.KeyEvent) bool {
+	modifiers, want := event.Modifiers, key.Modifiers
+	if event.Key == core.KeyUnknown {
+		modifiers &^= core.ModShift
+		want &^= core.ModShift
+	}
+	return event.Key == key.Key && event.Rune == key.Rune && modifiers == want
+}
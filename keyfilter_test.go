@@ -0,0 +1,84 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestKeyFilters tests keeping keys from the editor.
+func TestKeyFilters(t *testing.T) {
+	const content = "hello world"
+
+	t.Run("disabled keys are ignored", func(t *testing.T) {
+		m := newTestModel(content)
+		assert.NoError(t, m.DisableKeys(":", "<C-r>", "x"))
+		m = typeText(m, ":x")
+		assert.True(t, m.IsNormalMode())
+		assert.Equal(t, "hello world", m.GetCurrentContent())
+
+		m = typeText(m, "dw")
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'u', Text: "u"})
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'r', Mod: tea.ModCtrl})
+		assert.Equal(t, "hello world", m.GetCurrentContent())
+	})
+
+	t.Run("Shift doesn't matter for characters", func(t *testing.T) {
+		m := newTestModel(content)
+		assert.NoError(t, m.DisableKeys(":"))
+		m, _ = m.Update(tea.KeyPressMsg{Code: ':', Text: ":", Mod: tea.ModShift})
+		assert.True(t, m.IsNormalMode())
+	})
+
+	t.Run("disabled keys are ignored in every mode", func(t *testing.T) {
+		m := newTestModel(content)
+		assert.NoError(t, m.DisableKeys("x"))
+		m = typeText(m, "ix!")
+		assert.True(t, m.IsInsertMode())
+		assert.Equal(t, "!hello world", m.GetCurrentContent())
+	})
+
+	t.Run("no keys enables them again", func(t *testing.T) {
+		m := newTestModel(content)
+		assert.NoError(t, m.DisableKeys(":"))
+		assert.NoError(t, m.DisableKeys())
+		m = typeText(m, ":")
+		assert.True(t, m.IsCommandMode())
+	})
+
+	t.Run("keys in unknown notation are an error", func(t *testing.T) {
+		m := newTestModel(content)
+		assert.NoError(t, m.DisableKeys(":"))
+		assert.Error(t, m.DisableKeys("x", "<Escape>"))
+		m = typeText(m, "x")
+		assert.Equal(t, "ello world", m.GetCurrentContent())
+	})
+
+	t.Run("an interceptor passes keys through or swallows them", func(t *testing.T) {
+		m := newTestModel(content)
+		var seen []rune
+		m.InterceptKeys(func(key core.KeyEvent) bool {
+			seen = append(seen, key.Rune)
+			return key.Rune != 'x'
+		})
+		m = typeText(m, "xlx")
+		assert.Equal(t, []rune("xlx"), seen)
+		assert.Equal(t, "hello world", m.GetCurrentContent())
+		assert.Equal(t, 1, m.GetCursorPosition().Col)
+
+		m.InterceptKeys(nil)
+		m = typeText(m, "x")
+		assert.Equal(t, "hllo world", m.GetCurrentContent())
+	})
+
+	t.Run("the interceptor doesn't see disabled keys", func(t *testing.T) {
+		m := newTestModel(content)
+		assert.NoError(t, m.DisableKeys("x"))
+		called := false
+		m.InterceptKeys(func(core.KeyEvent) bool { called = true; return true })
+		m = typeText(m, "x")
+		assert.False(t, called)
+	})
+}
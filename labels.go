@@ -0,0 +1,115 @@
+package goeditor
+
+import "github.com/ionut-t/goeditor/core"
+
+// Labels are the words the editor draws and announces, for hosts to ship
+// translated UIs. Those with verbs are formats for fmt.Sprintf, which may
+// take their arguments in another order with explicit indexes, as in
+// "%[2]d/%[1]d". Start from EnglishLabels, the default, and change what is
+// translated.
+type Labels struct {
+	// Names of the modes in the status line and the announcements
+	NormalMode     string
+	InsertMode     string
+	VisualMode     string
+	VisualLineMode string
+	CommandMode    string
+	SearchMode     string
+
+	// Marks in the status line
+	Modified    string // After the file name while there are unsaved changes
+	Conflict    string // After the file name while the file changed on disk under unsaved changes
+	InvalidUTF8 string // Before the position when invalid UTF-8 was replaced reading the content
+
+	// Panels
+	QuickfixTitle      string // Title of the quickfix list, with the number of items
+	NoMatchingCommands string // Command palette with nothing matching the query
+	LineJumpPrompt     string // Line jump list looking for lines
+	SymbolJumpPrompt   string // Line jump list looking for symbols
+
+	// Accessibility announcements
+	ModeAnnouncement     string // With the name of the mode
+	LineAdded            string
+	LinesAdded           string // With the number of lines
+	LineDeleted          string
+	LinesDeleted         string // With the number of lines
+	PositionAnnouncement string // With the line and the column, from 1
+
+	// Status line of the diff view
+	DiffHunks string // With the number of hunks
+	DiffHunk  string // With the hunk under the cursor, from 1, and the number of hunks
+	DiffLeft  string // Focused pane
+	DiffRight string // Focused pane
+
+	// Messages of core, as for SetMessages; nil leaves them in English
+	Messages map[core.MessageId]string
+}
+
+// EnglishLabels returns the labels in English, which the editor starts
+// with.
+func EnglishLabels() Labels {
+	return Labels{
+		NormalMode:     "NORMAL",
+		InsertMode:     "INSERT",
+		VisualMode:     "VISUAL",
+		VisualLineMode: "VISUAL LINE",
+		CommandMode:    "COMMAND",
+		SearchMode:     "SEARCH",
+
+		Modified:    "[+]",
+		Conflict:    "[conflict]",
+		InvalidUTF8: "[invalid utf-8]",
+
+		QuickfixTitle:      "Quickfix (%d)",
+		NoMatchingCommands: "no matching commands",
+		LineJumpPrompt:     "Line: ",
+		SymbolJumpPrompt:   "Symbol: ",
+
+		ModeAnnouncement:     "%s mode",
+		LineAdded:            "added 1 line",
+		LinesAdded:           "added %d lines",
+		LineDeleted:          "deleted 1 line",
+		LinesDeleted:         "deleted %d lines",
+		PositionAnnouncement: "line %d, column %d",
+
+		DiffHunks: "%d hunks",
+		DiffHunk:  "hunk %d/%d",
+		DiffLeft:  "left",
+		DiffRight: "right",
+	}
+}
+
+// mode returns the name of mode.
+func (l Labels) mode(mode core.Mode) string {
+	switch mode {
+	case core.NormalMode:
+		return l.NormalMode
+	case core.InsertMode:
+		return l.InsertMode
+	case core.VisualMode:
+		return l.VisualMode
+	case core.VisualLineMode:
+		return l.VisualLineMode
+	case core.CommandMode:
+		return l.CommandMode
+	case core.SearchMode:
+		return l.SearchMode
+	}
+	return string(mode)
+}
+
+// WithLabels sets the words the editor draws and announces, and those of
+// the messages of core, as SetMessages does.
+func (m *Model) WithLabels(labels Labels) {
+	m.labels = labels
+	m.editor.Options().SetMessages(labels.Messages)
+	m.dirty |= needsRender
+}
+
+// WithLabels sets the words of the status line of the diff view, and those
+// of the messages of core in both panes.
+func (m *DiffModel) WithLabels(labels Labels) {
+	m.labels = labels
+	m.left.Options().SetMessages(labels.Messages)
+	m.right.Options().SetMessages(labels.Messages)
+}
@@ -0,0 +1,66 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLabels tests drawing and announcing in the words of the host.
+func TestLabels(t *testing.T) {
+	french := EnglishLabels()
+	french.InsertMode = "INSERTION"
+	french.Modified = "[modifié]"
+	french.QuickfixTitle = "Erreurs (%d)"
+	french.ModeAnnouncement = "mode %s"
+	french.LinesDeleted = "%d lignes supprimées"
+	french.PositionAnnouncement = "ligne %d, colonne %d"
+	french.Messages = map[core.MessageId]string{
+		core.MsgInsertStatus:    "-- INSERTION --",
+		core.MsgNoQuickfixItems: "aucune erreur",
+	}
+
+	const content = "one\ntwo\nthree"
+
+	t.Run("English is the default", func(t *testing.T) {
+		m := New(40, 10)
+		m.Focus()
+		m.SetContent("one")
+		m = typeText(m, "ix")
+		assert.Contains(t, m.getStatusLine(), " INSERT ")
+		assert.Contains(t, m.getStatusLine(), "[+]")
+		assert.Equal(t, "-- INSERT --", m.editor.GetState().StatusLine)
+	})
+
+	t.Run("the status line is translated", func(t *testing.T) {
+		m := typeText(newTestModel(content, withLabels(french)), "ix")
+		assert.Contains(t, m.getStatusLine(), " INSERTION ")
+		assert.Contains(t, m.getStatusLine(), "[modifié]")
+		assert.Equal(t, "-- INSERTION --", m.editor.GetState().StatusLine)
+	})
+
+	t.Run("the quickfix list is translated", func(t *testing.T) {
+		m := newTestModel(content, withLabels(french))
+		m, _ = m.Update(QuickfixMsg{Index: -1, Open: true})
+		panel := m.renderQuickfixPanel()
+		assert.Contains(t, panel, "Erreurs (0)")
+		assert.Contains(t, panel, "aucune erreur")
+	})
+
+	t.Run("announcements are translated", func(t *testing.T) {
+		m := newTestModel(content, withLabels(french))
+		m.SetAccessibilityMode(true)
+		m = typeText(m, "i")
+		assert.Equal(t, []string{"mode INSERTION"}, drainAnnouncements(m))
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+		drainAnnouncements(m)
+		m = typeText(m, "2dd")
+		assert.Equal(t, []string{"2 lignes supprimées"}, drainAnnouncements(m))
+
+		m = typeText(m, "l")
+		assert.Equal(t, []string{"ligne 1, colonne 2"}, drainAnnouncements(m))
+	})
+}
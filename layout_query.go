@@ -0,0 +1,93 @@
+package goeditor
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/ionut-t/goeditor/core"
+)
+
+// viewTop returns the visual row drawn at the top of the text area.
+func (m *Model) viewTop() int {
+	return max(0, min(m.currentVisualTopLine, m.fullVisualLayoutHeight-m.viewport.Height()))
+}
+
+// viewLine returns the visual line drawn at row of the text area, false if
+// there is none, as below the end of the content.
+func (m *Model) viewLine(row int) (VisualLineInfo, bool) {
+	if row < 0 || row >= m.viewport.Height() {
+		return VisualLineInfo{}, false
+	}
+	cacheIdx := m.viewTop() + row - m.visualLayoutCacheStartVisualRow
+	if cacheIdx < 0 || cacheIdx >= len(m.visualLayoutCache) {
+		return VisualLineInfo{}, false
+	}
+	return m.visualLayoutCache[cacheIdx], true
+}
+
+// VisualRowForPosition returns the row of the text area the buffer position
+// pos is drawn on, 0 being the top one, taking wrapping and scrolling into
+// account, e.g. to line up a widget with it. It returns an error if pos is
+// outside the content or scrolled out of view.
+func (m *Model) VisualRowForPosition(pos core.Position) (int, error) {
+	buffer := m.editor.GetBuffer()
+	if pos.Row < 0 || pos.Row >= buffer.LineCount() || pos.Col < 0 || pos.Col > buffer.LineRuneCount(pos.Row) {
+		return 0, fmt.Errorf("invalid position: (%d, %d)", pos.Row, pos.Col)
+	}
+
+	_, row, ok := m.screenPosition(pos)
+	if !ok {
+		return 0, fmt.Errorf("position (%d, %d) is not in view", pos.Row, pos.Col)
+	}
+	return row, nil
+}
+
+// PositionForVisualRowCol returns the buffer position drawn at row and col of
+// the text area, as overlays count them: row 0 is the top row and col 0 the
+// first cell of the line numbers, e.g. for a click. Cells in the line numbers
+// or the showbreak of a wrapped line are the start of its text, and cells past the end of a row or within a wide
+// character are the last position before them. It returns an error if no text
+// is drawn on row.
+func (m *Model) PositionForVisualRowCol(row, col int) (core.Position, error) {
+	vli, ok := m.viewLine(row)
+	if !ok {
+		return core.Position{}, fmt.Errorf("no text on row %d", row)
+	}
+
+	col -= m.calculateLineNumberWidth(m.editor.GetBuffer().LineCount()) + vli.prefixWidth()
+	runes := []rune(vli.Content)
+	offset, width := 0, 0
+	for offset < len(runes) {
+		_, graphemeWidth, runesConsumed := nextGrapheme(runes, offset, width, m.layoutOptions.tabWidth)
+		if width+graphemeWidth > col {
+			break
+		}
+		width += graphemeWidth
+		offset += runesConsumed
+	}
+
+	return core.Position{Row: vli.LogicalRow, Col: vli.LogicalStartCol + offset}, nil
+}
+
+// VisibleRange returns the part of the content in view, from the first
+// position drawn up to just past the last, e.g. to request only the
+// diagnostics or inlay hints the user can see.
+func (m *Model) VisibleRange() (start, end core.Position) {
+	first, ok := m.viewLine(0)
+	if !ok {
+		return core.Position{}, core.Position{}
+	}
+
+	last := first
+	for row := 1; row < m.viewport.Height(); row++ {
+		vli, drawn := m.viewLine(row)
+		if !drawn {
+			break
+		}
+		last = vli
+	}
+
+	start = core.Position{Row: first.LogicalRow, Col: first.LogicalStartCol}
+	end = core.Position{Row: last.LogicalRow, Col: last.LogicalStartCol + utf8.RuneCountInString(last.Content)}
+	return start, end
+}
@@ -0,0 +1,73 @@
+package goeditor
+
+import (
+	"testing"
+
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLayoutQueries tests mapping between buffer positions and the rows and
+// columns of the text area.
+func TestLayoutQueries(t *testing.T) {
+	const content = "one\ntwo three four five six\n漢字\nfour\nfive\nsix\nseven\neight"
+
+	m := newTestModel(content, withSize(20, 7), withListening())
+	gutter := m.calculateLineNumberWidth(8)
+
+	t.Run("positions to rows", func(t *testing.T) {
+		m := newTestModel(content, withSize(20, 7), withListening())
+		for pos, want := range map[core.Position]int{
+			{Row: 0, Col: 0}:  0,
+			{Row: 1, Col: 3}:  1,
+			{Row: 1, Col: 16}: 2,
+			{Row: 1, Col: 23}: 2,
+			{Row: 2, Col: 1}:  3,
+		} {
+			row, err := m.VisualRowForPosition(pos)
+			assert.NoError(t, err)
+			assert.Equal(t, want, row, pos)
+		}
+
+		_, err := m.VisualRowForPosition(core.Position{Row: 6, Col: 0})
+		assert.ErrorContains(t, err, "not in view")
+		_, err = m.VisualRowForPosition(core.Position{Row: 0, Col: 4})
+		assert.ErrorContains(t, err, "invalid position")
+	})
+
+	t.Run("rows and columns to positions", func(t *testing.T) {
+		m := newTestModel(content, withSize(20, 7), withListening())
+		for cell, want := range map[[2]int]core.Position{
+			{0, 0}:            {Row: 0, Col: 0},
+			{0, gutter + 1}:   {Row: 0, Col: 1},
+			{0, gutter + 50}:  {Row: 0, Col: 3},
+			{2, gutter}:       {Row: 1, Col: 15},
+			{2, gutter + 2}:   {Row: 1, Col: 17},
+			{3, gutter + 1}:   {Row: 2, Col: 0},
+			{3, gutter + 2}:   {Row: 2, Col: 1},
+			{4, gutter + 100}: {Row: 3, Col: 4},
+		} {
+			pos, err := m.PositionForVisualRowCol(cell[0], cell[1])
+			assert.NoError(t, err)
+			assert.Equal(t, want, pos, cell)
+		}
+
+		_, err := m.PositionForVisualRowCol(5, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("visible range", func(t *testing.T) {
+		m := newTestModel(content, withSize(20, 7), withListening())
+		start, end := m.VisibleRange()
+		assert.Equal(t, core.Position{Row: 0, Col: 0}, start)
+		assert.Equal(t, core.Position{Row: 3, Col: 4}, end)
+
+		m = typeText(m, "G")
+		start, end = m.VisibleRange()
+		assert.Equal(t, core.Position{Row: 3, Col: 0}, start)
+		assert.Equal(t, core.Position{Row: 7, Col: 5}, end)
+		row, err := m.VisualRowForPosition(core.Position{Row: 7, Col: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, row)
+	})
+}
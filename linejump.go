@@ -0,0 +1,193 @@
+package goeditor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// lineJumpItem is an entry of the line jump list.
+type lineJumpItem struct {
+	row  int
+	col  int
+	text string // Matched against the query and shown after the line number
+}
+
+// lineJump is an overlay listing the buffer's lines, or its symbols when a
+// language is set, filtered by what is typed. Up/Down (or Ctrl-N/Ctrl-P)
+// select, Enter moves the cursor to the selected entry and centres it, and
+// Escape closes the list.
+type lineJump struct {
+	open     bool
+	symbols  bool // Items are symbols rather than lines
+	query    []rune
+	items    []lineJumpItem
+	matches  []lineJumpItem
+	selected int
+}
+
+// OpenLineJump shows a filterable list of the buffer's lines over the editor.
+// When a language is set and its syntax declares symbols (functions, types,
+// headings, ...), the symbols are listed instead.
+func (m *Model) OpenLineJump() {
+	m.lineJump = lineJump{open: true}
+
+	lines := m.editor.GetBuffer().GetLines()
+	if m.highlighter != nil {
+		for _, symbol := range m.highlighter.Symbols(lines) {
+			m.lineJump.items = append(m.lineJump.items, lineJumpItem{
+				row:  symbol.Line,
+				col:  symbol.Col,
+				text: symbol.Name,
+			})
+		}
+		m.lineJump.symbols = len(m.lineJump.items) > 0
+	}
+
+	if !m.lineJump.symbols {
+		for row, line := range lines {
+			text := strings.TrimSpace(line)
+			if text == "" {
+				continue
+			}
+			col := len([]rune(line)) - len([]rune(strings.TrimLeft(line, " \t")))
+			m.lineJump.items = append(m.lineJump.items, lineJumpItem{row: row, col: col, text: text})
+		}
+	}
+
+	m.filterLineJump()
+}
+
+// CloseLineJump hides the line jump list.
+func (m *Model) CloseLineJump() {
+	m.lineJump = lineJump{}
+}
+
+// IsLineJumpOpen reports whether the line jump list is shown.
+func (m *Model) IsLineJumpOpen() bool {
+	return m.lineJump.open
+}
+
+// filterLineJump matches the query against the items, best match first.
+func (m *Model) filterLineJump() {
+	j := &m.lineJump
+	query := strings.TrimSpace(string(j.query))
+
+	type scored struct {
+		item  lineJumpItem
+		score int
+	}
+	var results []scored
+	for _, item := range j.items {
+		if score, ok := fuzzyMatch(query, item.text); ok {
+			results = append(results, scored{item, score})
+		}
+	}
+	// Stable, so items that score the same stay in buffer order
+	slices.SortStableFunc(results, func(a, b scored) int { return b.score - a.score })
+
+	j.matches = j.matches[:0]
+	for _, r := range results {
+		j.matches = append(j.matches, r.item)
+	}
+	j.selected = 0
+}
+
+// handleLineJumpKey handles a key while the line jump list is open.
+func (m *Model) handleLineJumpKey(key core.KeyEvent) tea.Cmd {
+	j := &m.lineJump
+
+	switch {
+	case key.Key == core.KeyEscape:
+		m.CloseLineJump()
+	case key.Key == core.KeyUp || key.Key == core.KeyCtrlP:
+		if j.selected > 0 {
+			j.selected--
+		}
+	case key.Key == core.KeyDown || key.Key == core.KeyCtrlN || key.Key == core.KeyTab:
+		if j.selected < len(j.matches)-1 {
+			j.selected++
+		}
+	case key.Key == core.KeyBackspace:
+		if len(j.query) > 0 {
+			j.query = j.query[:len(j.query)-1]
+			m.filterLineJump()
+		}
+	case key.Key == core.KeyEnter:
+		m.jumpToLineJumpItem()
+	case key.Rune != 0 && key.Modifiers&(core.ModCtrl|core.ModAlt) == 0:
+		j.query = append(j.query, key.Rune)
+		m.filterLineJump()
+	}
+
+	return nil
+}
+
+// jumpToLineJumpItem moves the cursor to the selected item, closes the list
+// and centres the view on the cursor.
+func (m *Model) jumpToLineJumpItem() {
+	j := &m.lineJump
+	if len(j.matches) == 0 {
+		return
+	}
+	item := j.matches[j.selected]
+	m.CloseLineJump()
+
+	buffer := m.editor.GetBuffer()
+	if buffer.LineCount() == 0 {
+		return
+	}
+	row := min(max(item.row, 0), buffer.LineCount()-1)
+	col := min(max(item.col, 0), max(buffer.LineRuneCount(row)-1, 0))
+
+	cursor := buffer.GetCursor()
+	cursor.Position = core.Position{Row: row, Col: col}
+	cursor.Preferred = col
+	buffer.SetCursor(cursor)
+
+	m.calculateVisualMetrics()
+	m.centreVisualTopLine()
+}
+
+// lineJumpLayer returns the line jump list as a layer at the top of the
+// editor, centred.
+func (m *Model) lineJumpLayer() *lipgloss.Layer {
+	j := &m.lineJump
+
+	width := min(72, max(m.width-4, 10))
+	innerWidth := width - 2 // Border
+
+	prompt := m.labels.LineJumpPrompt
+	if j.symbols {
+		prompt = m.labels.SymbolJumpPrompt
+	}
+	lines := []string{
+		m.theme.PaletteInputStyle.Width(innerWidth).Render(truncateToWidth(prompt+string(j.query), innerWidth)),
+	}
+
+	numberWidth := len(fmt.Sprint(m.editor.GetBuffer().LineCount()))
+	start := max(0, min(j.selected-maxPaletteItems+1, len(j.matches)-maxPaletteItems))
+	end := min(start+maxPaletteItems, len(j.matches))
+	for i := start; i < end; i++ {
+		item := j.matches[i]
+		line := truncateToWidth(fmt.Sprintf(" %*d  %s", numberWidth, item.row+1, strings.ReplaceAll(item.text, "\t", " ")), innerWidth)
+
+		style := m.theme.PaletteItemStyle
+		if i == j.selected {
+			style = m.theme.PaletteSelectedItemStyle
+		}
+		lines = append(lines, style.Width(innerWidth).Render(line))
+	}
+	if len(j.matches) == 0 {
+		lines = append(lines, m.theme.PaletteItemStyle.Width(innerWidth).Render(" no matching lines"))
+	}
+
+	box := m.theme.PaletteBorderStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	x := max(0, (m.width-lipgloss.Width(box))/2)
+	return lipgloss.NewLayer(box).X(x).Y(1).Z(2)
+}
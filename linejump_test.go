@@ -0,0 +1,101 @@
+package goeditor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLineJump tests filtering the buffer's lines and symbols and jumping to one.
+func TestLineJump(t *testing.T) {
+	t.Run("lists the non-empty lines without a language", func(t *testing.T) {
+		m := newTestModel("alpha\n\n  beta\ngamma", withSize(60, 12))
+		m.OpenLineJump()
+
+		assert.True(t, m.IsLineJumpOpen())
+		assert.False(t, m.lineJump.symbols)
+		assert.Len(t, m.lineJump.matches, 3)
+
+		view := m.View()
+		assert.Contains(t, view, "Line:")
+		assert.Contains(t, view, "3  beta")
+	})
+
+	t.Run("typing filters and Enter jumps to the first non-blank", func(t *testing.T) {
+		m := newTestModel("alpha\n\n  beta\ngamma", withSize(60, 12))
+		m.OpenLineJump()
+		m = typeText(m, "bt")
+
+		assert.Len(t, m.lineJump.matches, 1)
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+		assert.False(t, m.IsLineJumpOpen())
+		assert.Equal(t, core.Position{Row: 2, Col: 2}, m.GetCursorPosition())
+	})
+
+	t.Run("Escape closes without moving", func(t *testing.T) {
+		m := newTestModel("alpha\nbeta", withSize(60, 12))
+		m.OpenLineJump()
+		m = typeText(m, "beta")
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+		assert.False(t, m.IsLineJumpOpen())
+		assert.Equal(t, core.Position{}, m.GetCursorPosition())
+	})
+
+	t.Run("lists symbols when a language is set", func(t *testing.T) {
+		m := newTestModel("package main\n\ntype Server struct{}\n\nfunc (s *Server) Start() {\n\tfmt.Println(\"hi\")\n}\n\nfunc main() {}", withSize(60, 12))
+		m.SetLanguage("go", "")
+		m.OpenLineJump()
+
+		assert.True(t, m.lineJump.symbols)
+		var names []string
+		for _, item := range m.lineJump.matches {
+			names = append(names, item.text)
+		}
+		assert.Equal(t, []string{"Server", "Start", "main"}, names)
+
+		m = typeText(m, "start")
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+		assert.Equal(t, core.Position{Row: 4, Col: 17}, m.GetCursorPosition())
+	})
+
+	t.Run("lists Python definitions", func(t *testing.T) {
+		m := newTestModel("class Greeter:\n    def greet(self):\n        pass\n\nprint(1)", withSize(60, 12))
+		m.SetLanguage("python", "")
+		m.OpenLineJump()
+
+		var names []string
+		for _, item := range m.lineJump.matches {
+			names = append(names, item.text)
+		}
+		assert.Equal(t, []string{"Greeter", "greet"}, names)
+	})
+
+	t.Run("falls back to lines when there are no symbols", func(t *testing.T) {
+		m := newTestModel("x := 1\ny := 2", withSize(60, 12))
+		m.SetLanguage("go", "")
+		m.OpenLineJump()
+
+		assert.False(t, m.lineJump.symbols)
+		assert.Len(t, m.lineJump.matches, 2)
+	})
+
+	t.Run("centres the view on the target line", func(t *testing.T) {
+		var lines []string
+		for i := range 100 {
+			lines = append(lines, fmt.Sprintf("line %d", i+1))
+		}
+		m := newTestModel(strings.Join(lines, "\n"), withSize(60, 12))
+		m.OpenLineJump()
+		m = typeText(m, "line 50")
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+		assert.Equal(t, 49, m.GetCursorPosition().Row)
+		assert.Equal(t, 49-m.viewport.Height()/2, m.currentVisualTopLine)
+	})
+}
@@ -0,0 +1,163 @@
+// Package markdown renders the content of an editor as markdown with glamour,
+// for a preview pane next to it:
+//
+//	preview, err := markdown.New(width)
+//	...
+//	out, err := preview.Render(m.GetCurrentContent())
+//	top := preview.ScrollSync(m.GetCursorPosition().Row)
+//
+// The content is split into sections at its headings, and only the sections
+// that changed since the last Render are rendered again, so the preview can
+// follow every key. A Preview is not safe for concurrent use.
+package markdown
+
+import (
+	"strings"
+
+	"charm.land/glamour/v2"
+	"charm.land/glamour/v2/styles"
+)
+
+// Preview renders markdown, keeping the sections it rendered last.
+type Preview struct {
+	options  []glamour.TermRendererOption
+	renderer *glamour.TermRenderer
+	sections []section
+	cache    map[string][]string // Rendered lines of the sections, by their source
+	renders  int                 // Sections rendered, for tests
+}
+
+// section is the text from a heading up to the next one.
+type section struct {
+	source       string
+	startLine    int      // First line of the section in the content
+	lines        int      // Lines of the section in the content
+	rendered     []string // Lines of the section in the preview
+	previewStart int      // First line of the section in the preview
+}
+
+// New returns a Preview that wraps text at width. glamour's dark style is
+// used unless options set another.
+func New(width int, options ...glamour.TermRendererOption) (*Preview, error) {
+	p := &Preview{options: append([]glamour.TermRendererOption{glamour.WithStandardStyle(styles.DarkStyle)}, options...)}
+	if err := p.SetWidth(width); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetWidth wraps text at width, rendering everything again on the next
+// Render.
+func (p *Preview) SetWidth(width int) error {
+	renderer, err := glamour.NewTermRenderer(append(p.options, glamour.WithWordWrap(width))...)
+	if err != nil {
+		return err
+	}
+	p.renderer = renderer
+	p.cache = nil
+	return nil
+}
+
+// Render returns content rendered as markdown. Sections whose text didn't
+// change since the last Render are reused. Reference links only resolve
+// within the section they are defined in.
+func (p *Preview) Render(content string) (string, error) {
+	sections := splitSections(content)
+	cache := make(map[string][]string, len(sections))
+
+	previewLine := 0
+	for i := range sections {
+		s := &sections[i]
+		rendered, ok := p.cache[s.source]
+		if !ok {
+			out, err := p.renderer.Render(s.source)
+			if err != nil {
+				return "", err
+			}
+			rendered = strings.Split(strings.Trim(out, "\n"), "\n")
+			p.renders++
+		}
+		cache[s.source] = rendered
+
+		s.rendered = rendered
+		s.previewStart = previewLine
+		// Sections are separated by a blank line
+		previewLine += len(rendered) + 1
+	}
+	p.sections, p.cache = sections, cache
+
+	var out strings.Builder
+	out.WriteString("\n")
+	for i, s := range sections {
+		if i > 0 {
+			out.WriteString("\n\n")
+		}
+		out.WriteString(strings.Join(s.rendered, "\n"))
+	}
+	out.WriteString("\n")
+	return out.String(), nil
+}
+
+// ScrollSync returns the line of the last Render that shows line of the
+// content, such as the cursor line, to scroll the preview with the editor.
+// Lines within a section are mapped in proportion to its height.
+func (p *Preview) ScrollSync(line int) int {
+	if len(p.sections) == 0 {
+		return 0
+	}
+
+	s := p.sections[len(p.sections)-1]
+	for _, candidate := range p.sections {
+		if line < candidate.startLine+candidate.lines {
+			s = candidate
+			break
+		}
+	}
+
+	offset := max(min(line-s.startLine, s.lines-1), 0)
+	// The first line of the preview is blank
+	return 1 + s.previewStart + offset*len(s.rendered)/s.lines
+}
+
+// splitSections splits content into sections at the ATX headings outside
+// fenced code blocks.
+func splitSections(content string) []section {
+	lines := strings.Split(content, "\n")
+
+	var sections []section
+	start := 0
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence == "" && i > start && isHeading(trimmed) {
+			sections = append(sections, newSection(lines, start, i))
+			start = i
+		}
+
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			fence = trimmed[:3]
+		case fence != "" && strings.HasPrefix(trimmed, fence):
+			fence = ""
+		}
+	}
+	return append(sections, newSection(lines, start, len(lines)))
+}
+
+// newSection returns the section of lines from start up to end.
+func newSection(lines []string, start, end int) section {
+	return section{
+		source:    strings.Join(lines[start:end], "\n"),
+		startLine: start,
+		lines:     end - start,
+	}
+}
+
+// isHeading reports whether line is an ATX heading, such as ## Usage.
+func isHeading(line string) bool {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return false
+	}
+	return len(line) == level || line[level] == ' ' || line[level] == '\t'
+}
@@ -0,0 +1,97 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"charm.land/glamour/v2"
+	"charm.land/glamour/v2/styles"
+	"github.com/stretchr/testify/assert"
+)
+
+// sgr matches the escape sequences that style text.
+var sgr = regexp.MustCompile("\x1b\\[[0-9;:]*m")
+
+const document = `# Title
+
+Some text.
+
+## Usage
+
+` + "```" + `
+# not a heading
+` + "```" + `
+
+## End
+
+Last line.`
+
+// plainLines returns the lines of a rendering without styles or margins.
+func plainLines(out string) []string {
+	lines := strings.Split(sgr.ReplaceAllString(out, ""), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
+
+// TestRender tests rendering markdown a section at a time.
+func TestRender(t *testing.T) {
+	t.Run("sections are split at headings outside code", func(t *testing.T) {
+		sections := splitSections(document)
+		if assert.Len(t, sections, 3) {
+			assert.Equal(t, 0, sections[0].startLine)
+			assert.Equal(t, 4, sections[1].startLine)
+			assert.Equal(t, 10, sections[2].startLine)
+			assert.Equal(t, "## End\n\nLast line.", sections[2].source)
+		}
+	})
+
+	t.Run("the whole document is rendered", func(t *testing.T) {
+		p, err := New(40, glamour.WithStandardStyle(styles.NoTTYStyle))
+		assert.NoError(t, err)
+		out, err := p.Render(document)
+		assert.NoError(t, err)
+		lines := plainLines(out)
+		assert.Contains(t, lines, "# Title")
+		assert.Contains(t, lines, "Some text.")
+		assert.Contains(t, lines, "# not a heading")
+		assert.Contains(t, lines, "Last line.")
+	})
+
+	t.Run("only changed sections are rendered again", func(t *testing.T) {
+		p, err := New(40)
+		assert.NoError(t, err)
+		_, err = p.Render(document)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, p.renders)
+
+		out, err := p.Render(strings.Replace(document, "Some text.", "Other text.", 1))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, p.renders)
+		assert.Contains(t, plainLines(out), "Other text.")
+
+		assert.NoError(t, p.SetWidth(60))
+		_, err = p.Render(document)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, p.renders)
+	})
+}
+
+// TestScrollSync tests mapping lines of the content to lines of the preview.
+func TestScrollSync(t *testing.T) {
+	p, err := New(40, glamour.WithStandardStyle(styles.NoTTYStyle))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, p.ScrollSync(3))
+
+	out, err := p.Render(document)
+	assert.NoError(t, err)
+	lines := plainLines(out)
+
+	for line, want := range map[int]string{0: "# Title", 4: "## Usage", 10: "## End"} {
+		assert.Equal(t, want, lines[p.ScrollSync(line)], line)
+	}
+	assert.Equal(t, "Last line.", lines[p.ScrollSync(99)])
+	assert.Equal(t, 1, p.ScrollSync(-1))
+}
@@ -0,0 +1,200 @@
+package goeditor
+
+import (
+	"slices"
+
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// OverlayAnchor is the place in the text area an overlay is positioned against.
+type OverlayAnchor int
+
+const (
+	// AnchorCursor places the overlay below the cursor, or above it when
+	// there is no room below.
+	AnchorCursor OverlayAnchor = iota
+	// AnchorPosition places the overlay below OverlayPosition.Position, or
+	// above it when there is no room below. The overlay is hidden while the
+	// position is scrolled out of view.
+	AnchorPosition
+	AnchorTop         // Centred at the top
+	AnchorCenter      // Centred
+	AnchorTopLeft     // Top left corner
+	AnchorTopRight    // Top right corner
+	AnchorBottomLeft  // Bottom left corner
+	AnchorBottomRight // Bottom right corner
+)
+
+// OverlayPosition says where an overlay is shown.
+type OverlayPosition struct {
+	Anchor   OverlayAnchor
+	Position core.Position // Buffer position for AnchorPosition
+	X, Y     int           // Offset in cells from the anchored place
+}
+
+// overlay is a floating box registered with RegisterOverlay or ShowHover.
+type overlay struct {
+	id       string
+	position OverlayPosition
+	content  func() string
+	zIndex   int
+	style    *lipgloss.Style // Frame; Theme.OverlayBorderStyle if nil
+}
+
+// RegisterOverlay shows the text returned by content in a floating box over
+// the text area. content is called on every render and an empty result hides
+// the box until it returns text again. The box is framed with
+// Theme.OverlayBorderStyle and cut to fit in the text area.
+//
+// Overlays with a higher zIndex are drawn over lower ones; the completion menu
+// and jump labels are at 1 and the command palette and line jump list at 2.
+// Registering an id again replaces its overlay.
+func (m *Model) RegisterOverlay(id string, position OverlayPosition, content func() string, zIndex int) {
+	m.registerOverlay(overlay{id: id, position: position, content: content, zIndex: zIndex})
+}
+
+// registerOverlay adds o, replacing the overlay with the same id.
+func (m *Model) registerOverlay(o overlay) {
+	if i := slices.IndexFunc(m.overlays, func(other overlay) bool { return other.id == o.id }); i >= 0 {
+		m.overlays[i] = o
+		return
+	}
+	m.overlays = append(m.overlays, o)
+}
+
+// RemoveOverlay removes the overlay registered with id.
+func (m *Model) RemoveOverlay(id string) {
+	m.overlays = slices.DeleteFunc(m.overlays, func(o overlay) bool { return o.id == id })
+}
+
+// HasOverlay reports whether an overlay is registered with id.
+func (m *Model) HasOverlay(id string) bool {
+	return slices.ContainsFunc(m.overlays, func(o overlay) bool { return o.id == id })
+}
+
+// renderOverlays draws the floating overlays over the content: jump labels,
+// the completion menu, the command palette, the line jump list, the
+// registered overlays and the debug overlay.
+func (m *Model) renderOverlays(content string) string {
+	var layers []*lipgloss.Layer
+
+	if m.jumpLabels.active {
+		layers = append(layers, m.jumpLabelLayers()...)
+	}
+	if m.completionMenuVisible {
+		if layer := m.completionMenuLayer(); layer != nil {
+			layers = append(layers, layer)
+		}
+	}
+	if m.palette.open {
+		layers = append(layers, m.commandPaletteLayer())
+	}
+	if m.lineJump.open {
+		layers = append(layers, m.lineJumpLayer())
+	}
+	for _, o := range m.overlays {
+		if layer := m.overlayLayer(o); layer != nil {
+			layers = append(layers, layer)
+		}
+	}
+	if layer := m.debugLayer(); layer != nil {
+		layers = append(layers, layer)
+	}
+
+	if len(layers) == 0 {
+		return content
+	}
+
+	// The compositor does not keep the order of layers with the same z-index,
+	// so number them in order: by z-index, then in the order added above
+	slices.SortStableFunc(layers, func(a, b *lipgloss.Layer) int { return a.GetZ() - b.GetZ() })
+	for i, layer := range layers {
+		layer.Z(i + 1)
+	}
+
+	layers = slices.Insert(layers, 0, lipgloss.NewLayer(content).X(0).Y(0).Z(0))
+	return lipgloss.NewCompositor(layers...).Render()
+}
+
+// overlayLayer renders a registered overlay, framed and cut to fit in the text
+// area, and places it. It returns nil when there is nothing to show.
+func (m *Model) overlayLayer(o overlay) *lipgloss.Layer {
+	text := o.content()
+	if text == "" {
+		return nil
+	}
+
+	style := m.theme.OverlayBorderStyle
+	if o.style != nil {
+		style = *o.style
+	}
+	width, height := m.viewport.Width(), m.viewport.Height()
+	innerWidth := width - style.GetHorizontalFrameSize()
+	innerHeight := height - style.GetVerticalFrameSize()
+	if innerWidth <= 0 || innerHeight <= 0 {
+		return nil
+	}
+
+	text = lipgloss.NewStyle().MaxWidth(innerWidth).MaxHeight(innerHeight).Render(text)
+	box := style.Render(text)
+	boxWidth, boxHeight := lipgloss.Width(box), lipgloss.Height(box)
+
+	var x, y int
+	switch o.position.Anchor {
+	case AnchorCursor, AnchorPosition:
+		pos := o.position.Position
+		if o.position.Anchor == AnchorCursor {
+			pos = m.editor.GetBuffer().GetCursor().Position
+		}
+
+		col, row, ok := m.screenPosition(pos)
+		if !ok {
+			return nil
+		}
+		x = col
+		y = row + 1
+		if y+boxHeight > height && row-boxHeight >= 0 {
+			y = row - boxHeight
+		}
+	case AnchorTop:
+		x = (width - boxWidth) / 2
+	case AnchorCenter:
+		x = (width - boxWidth) / 2
+		y = (height - boxHeight) / 2
+	case AnchorTopRight:
+		x = width - boxWidth
+	case AnchorBottomLeft:
+		y = height - boxHeight
+	case AnchorBottomRight:
+		x = width - boxWidth
+		y = height - boxHeight
+	}
+
+	x = min(max(x+o.position.X, 0), width-boxWidth)
+	y = min(max(y+o.position.Y, 0), height-boxHeight)
+
+	return lipgloss.NewLayer(box).X(x).Y(y).Z(o.zIndex)
+}
+
+// screenPosition returns the cell of the text area where the buffer position
+// pos is drawn, taking wrapping and scrolling into account. It reports false
+// when pos is scrolled out of view.
+func (m *Model) screenPosition(pos core.Position) (x, y int, ok bool) {
+	lineNumWidth := m.calculateLineNumberWidth(m.editor.GetBuffer().LineCount())
+
+	for row := range m.viewport.Height() {
+		vli, drawn := m.viewLine(row)
+		if !drawn || vli.LogicalRow != pos.Row || pos.Col < vli.LogicalStartCol {
+			continue
+		}
+
+		// Segments of a line come in order, so the last one starting at or
+		// before the column holds it
+		segment := []rune(vli.Content)
+		offset := min(pos.Col-vli.LogicalStartCol, len(segment))
+		x, y, ok = lineNumWidth+vli.prefixWidth()+m.layoutOptions.width(string(segment[:offset])), row, true
+	}
+
+	return x, y, ok
+}
@@ -0,0 +1,105 @@
+package goeditor
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOverlays tests registering, placing, clipping and removing overlays.
+func TestOverlays(t *testing.T) {
+	const content = "one\ntwo\nthree\nfour"
+	// bordered draws overlays with a border, so that their edges show.
+	bordered := func(m *Model) {
+		m.theme.OverlayBorderStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder())
+	}
+
+	// viewLines returns the text area of the view, one entry per row.
+	viewLines := func(m Model) []string {
+		return strings.Split(m.renderOverlays(m.viewport.View()), "\n")
+	}
+
+	t.Run("is drawn below the cursor", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), bordered)
+		m.RegisterOverlay("info", OverlayPosition{Anchor: AnchorCursor}, func() string { return "hi" }, 1)
+
+		lines := viewLines(m)
+		assert.Contains(t, lines[1], "┌──┐")
+		assert.Contains(t, lines[2], "│hi│")
+
+		layer := m.overlayLayer(m.overlays[0])
+		assert.Equal(t, m.calculateLineNumberWidth(4), layer.GetX())
+	})
+
+	t.Run("is placed at a buffer position", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), bordered)
+		m.RegisterOverlay("info", OverlayPosition{Anchor: AnchorPosition, Position: core.Position{Row: 2, Col: 2}}, func() string { return "hi" }, 1)
+
+		x, y, ok := m.screenPosition(core.Position{Row: 2, Col: 2})
+		assert.True(t, ok)
+		assert.Equal(t, 2, y)
+
+		layer := m.overlayLayer(m.overlays[0])
+		assert.Equal(t, x, layer.GetX())
+		assert.Equal(t, 3, layer.GetY())
+	})
+
+	t.Run("goes above the anchor when there is no room below", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), bordered)
+		m.SetContent(strings.Repeat("line\n", 9) + "last")
+		_ = m.SetCursorPositionEnd()
+		m.RegisterOverlay("info", OverlayPosition{Anchor: AnchorCursor}, func() string { return "a\nb" }, 1)
+
+		_, row, _ := m.screenPosition(m.GetCursorPosition())
+		layer := m.overlayLayer(m.overlays[0])
+		assert.Equal(t, row-4, layer.GetY())
+	})
+
+	t.Run("is cut to the width of the text area", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), bordered)
+		m.RegisterOverlay("wide", OverlayPosition{Anchor: AnchorTopRight}, func() string {
+			return strings.Repeat("x", 100)
+		}, 1)
+
+		for _, line := range viewLines(m) {
+			assert.LessOrEqual(t, lipgloss.Width(line), 40)
+		}
+		layer := m.overlayLayer(m.overlays[0])
+		assert.Equal(t, 0, layer.GetX())
+	})
+
+	t.Run("higher z-index is drawn on top", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), bordered)
+		m.RegisterOverlay("low", OverlayPosition{Anchor: AnchorTopLeft}, func() string { return "low" }, 5)
+		m.RegisterOverlay("high", OverlayPosition{Anchor: AnchorTopLeft}, func() string { return "top" }, 6)
+		assert.Contains(t, viewLines(m)[1], "top")
+
+		m.RegisterOverlay("high", OverlayPosition{Anchor: AnchorTopLeft}, func() string { return "top" }, 4)
+		assert.Contains(t, viewLines(m)[1], "low")
+	})
+
+	t.Run("empty content hides and removal forgets", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), bordered)
+		text := ""
+		m.RegisterOverlay("info", OverlayPosition{Anchor: AnchorCenter}, func() string { return text }, 1)
+		assert.NotContains(t, m.View(), "┌")
+
+		text = "shown"
+		assert.Contains(t, m.View(), "shown")
+
+		m.RemoveOverlay("info")
+		assert.False(t, m.HasOverlay("info"))
+		assert.NotContains(t, m.View(), "shown")
+	})
+
+	t.Run("a position out of view hides it", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), bordered)
+		m.SetContent(strings.Repeat("line\n", 50) + "last")
+		m.RegisterOverlay("info", OverlayPosition{Anchor: AnchorPosition, Position: core.Position{Row: 40}}, func() string { return "hi" }, 1)
+
+		assert.Nil(t, m.overlayLayer(m.overlays[0]))
+	})
+}
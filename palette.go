@@ -0,0 +1,217 @@
+package goeditor
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// maxPaletteItems is the most commands the command palette shows at once.
+const maxPaletteItems = 10
+
+// commandPalette is an overlay listing the editor's commands, filtered by
+// what is typed. Up/Down (or Ctrl-N/Ctrl-P) select, Enter runs the selected
+// command and Escape closes the palette. Text after the command name is passed
+// as its arguments.
+type commandPalette struct {
+	open     bool
+	query    []rune
+	matches  []core.CommandInfo
+	selected int
+}
+
+// WithCommandPalette makes Ctrl-P open the command palette in every mode.
+func (m *Model) WithCommandPalette(enabled bool) {
+	m.commandPaletteKey = enabled
+}
+
+// OpenCommandPalette shows the command palette over the editor.
+func (m *Model) OpenCommandPalette() {
+	m.palette = commandPalette{open: true}
+	m.filterPalette()
+}
+
+// CloseCommandPalette hides the command palette.
+func (m *Model) CloseCommandPalette() {
+	m.palette = commandPalette{}
+}
+
+// IsCommandPaletteOpen reports whether the command palette is shown.
+func (m *Model) IsCommandPaletteOpen() bool {
+	return m.palette.open
+}
+
+// filterPalette matches the command name part of the query against the
+// commands, best match first.
+func (m *Model) filterPalette() {
+	name, _, _ := strings.Cut(strings.TrimLeft(string(m.palette.query), " "), " ")
+
+	type scored struct {
+		command core.CommandInfo
+		score   int
+	}
+	var results []scored
+	for _, command := range m.editor.Commands() {
+		text := command.Name + " " + command.Description
+		if score, ok := fuzzyMatch(name, text); ok {
+			results = append(results, scored{command, score})
+		}
+	}
+	// Stable, so commands that score the same keep their order
+	slices.SortStableFunc(results, func(a, b scored) int { return b.score - a.score })
+
+	m.palette.matches = m.palette.matches[:0]
+	for _, r := range results {
+		m.palette.matches = append(m.palette.matches, r.command)
+	}
+	m.palette.selected = 0
+}
+
+// handlePaletteKey handles a key while the command palette is open.
+func (m *Model) handlePaletteKey(key core.KeyEvent) tea.Cmd {
+	p := &m.palette
+
+	switch {
+	case key.Key == core.KeyEscape:
+		m.CloseCommandPalette()
+	case key.Key == core.KeyUp || key.Key == core.KeyCtrlP:
+		if p.selected > 0 {
+			p.selected--
+		}
+	case key.Key == core.KeyDown || key.Key == core.KeyCtrlN || key.Key == core.KeyTab:
+		if p.selected < len(p.matches)-1 {
+			p.selected++
+		}
+	case key.Key == core.KeyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			m.filterPalette()
+		}
+	case key.Key == core.KeyEnter:
+		return m.runPaletteCommand()
+	case key.Rune != 0 && key.Modifiers&(core.ModCtrl|core.ModAlt) == 0:
+		p.query = append(p.query, key.Rune)
+		m.filterPalette()
+	}
+
+	return nil
+}
+
+// runPaletteCommand runs the selected command with the arguments typed after
+// its name. A command that needs arguments that were not typed fills in the
+// query instead, so they can be typed next.
+func (m *Model) runPaletteCommand() tea.Cmd {
+	p := &m.palette
+	if len(p.matches) == 0 {
+		return nil
+	}
+	command := p.matches[p.selected]
+
+	_, args, _ := strings.Cut(strings.TrimLeft(string(p.query), " "), " ")
+	args = strings.TrimSpace(args)
+	if command.Args != "" && args == "" {
+		p.query = []rune(command.Name + " ")
+		m.filterPalette()
+		return nil
+	}
+
+	line := command.Name
+	if args != "" {
+		line += " " + args
+	}
+
+	m.CloseCommandPalette()
+	if err := m.editor.ExecuteCommand(line); err != nil {
+		return func() tea.Msg {
+			return ErrorMsg{ID: err.ID(), Error: err.Error(), Severity: err.Severity()}
+		}
+	}
+	m.handleContentChange()
+	m.updateVisualTopLine()
+	return nil
+}
+
+// commandPaletteLayer returns the command palette as a layer at the top of the
+// editor, centred.
+func (m *Model) commandPaletteLayer() *lipgloss.Layer {
+	p := &m.palette
+
+	width := min(60, max(m.width-4, 10))
+	innerWidth := width - 2 // Border
+
+	lines := []string{
+		m.theme.PaletteInputStyle.Width(innerWidth).Render(truncateToWidth(":"+string(p.query), innerWidth)),
+	}
+
+	start := max(0, min(p.selected-maxPaletteItems+1, len(p.matches)-maxPaletteItems))
+	end := min(start+maxPaletteItems, len(p.matches))
+	for i := start; i < end; i++ {
+		command := p.matches[i]
+		name := command.Name
+		if command.Args != "" {
+			name += " " + command.Args
+		}
+
+		gap := max(2, innerWidth-lipgloss.Width(name)-lipgloss.Width(command.Description)-2)
+		line := truncateToWidth(" "+name+strings.Repeat(" ", gap)+command.Description, innerWidth)
+
+		style := m.theme.PaletteItemStyle
+		if i == p.selected {
+			style = m.theme.PaletteSelectedItemStyle
+		}
+		lines = append(lines, style.Width(innerWidth).Render(line))
+	}
+	if len(p.matches) == 0 {
+		lines = append(lines, m.theme.PaletteItemStyle.Width(innerWidth).Render(" "+m.labels.NoMatchingCommands))
+	}
+
+	box := m.theme.PaletteBorderStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	x := max(0, (m.width-lipgloss.Width(box))/2)
+	return lipgloss.NewLayer(box).X(x).Y(1).Z(2)
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in text in order,
+// ignoring case, and scores the match: matches at the start of text or of a
+// word and runs of consecutive matches score higher.
+func fuzzyMatch(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+
+	score := 0
+	pi := 0
+	prev := -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+
+		switch {
+		case ti == 0:
+			score += 8
+		case !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]):
+			score += 4
+		}
+		if ti == prev+1 {
+			score += 5
+		}
+		score++
+
+		prev = ti
+		pi++
+	}
+
+	if pi < len(p) {
+		return 0, false
+	}
+	// Prefer shorter texts among equal matches
+	return score*100 - len(t), true
+}
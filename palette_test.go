@@ -0,0 +1,112 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFuzzyMatch tests matching a pattern against text as an in-order subsequence.
+func TestFuzzyMatch(t *testing.T) {
+	_, ok := fuzzyMatch("cpn", "copen")
+	assert.True(t, ok)
+
+	_, ok = fuzzyMatch("nc", "copen")
+	assert.False(t, ok)
+
+	_, ok = fuzzyMatch("", "anything")
+	assert.True(t, ok)
+
+	prefix, _ := fuzzyMatch("cl", "clast")
+	scattered, _ := fuzzyMatch("cl", "cclose")
+	assert.Greater(t, prefix, scattered)
+
+	upper, ok := fuzzyMatch("SAVE", "save the file")
+	assert.True(t, ok)
+	lower, _ := fuzzyMatch("save", "save the file")
+	assert.Equal(t, lower, upper)
+}
+
+// TestCommandPalette tests opening, filtering and running commands from the palette.
+func TestCommandPalette(t *testing.T) {
+	const content = "one\ntwo\nthree"
+
+	t.Run("Ctrl-P opens it when enabled", func(t *testing.T) {
+		m := newTestModel(content, withSize(80, 20))
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'p', Mod: tea.ModCtrl})
+		assert.False(t, m.IsCommandPaletteOpen())
+
+		m.WithCommandPalette(true)
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'p', Mod: tea.ModCtrl})
+		assert.True(t, m.IsCommandPaletteOpen())
+		assert.Contains(t, m.View(), "Save and quit")
+	})
+
+	t.Run("typing filters the commands", func(t *testing.T) {
+		m := newTestModel(content, withSize(80, 20))
+		m.OpenCommandPalette()
+		m = typeText(m, "relative")
+		assert.Equal(t, "set rnu", m.palette.matches[0].Name)
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyBackspace})
+		assert.Equal(t, "relativ", string(m.palette.query))
+	})
+
+	t.Run("Enter runs the selected command", func(t *testing.T) {
+		m := newTestModel(content, withSize(80, 20))
+		m.OpenCommandPalette()
+		m = typeText(m, "relative")
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+		assert.False(t, m.IsCommandPaletteOpen())
+		assert.True(t, m.GetEditor().GetState().RelativeNumbers)
+	})
+
+	t.Run("registered commands are listed and run with arguments", func(t *testing.T) {
+		m := newTestModel(content, withSize(80, 20))
+		var got []string
+		m.GetEditor().RegisterCommand("Greet", func(_ core.Editor, args []string) *core.EditorError {
+			got = args
+			return nil
+		})
+
+		m.OpenCommandPalette()
+		m = typeText(m, "Greet world")
+		assert.Equal(t, "Greet", m.palette.matches[0].Name)
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+		assert.Equal(t, []string{"world"}, got)
+	})
+
+	t.Run("a command that takes arguments waits for them", func(t *testing.T) {
+		m := newTestModel(content, withSize(80, 20))
+		m.OpenCommandPalette()
+		m = typeText(m, "rename")
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+		assert.True(t, m.IsCommandPaletteOpen())
+		assert.Equal(t, "rename ", string(m.palette.query))
+	})
+
+	t.Run("arrow keys move the selection and Escape closes it", func(t *testing.T) {
+		m := newTestModel(content, withSize(80, 20))
+		m.OpenCommandPalette()
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+		assert.Equal(t, 1, m.palette.selected)
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyUp})
+		assert.Equal(t, 0, m.palette.selected)
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+		assert.False(t, m.IsCommandPaletteOpen())
+		assert.Equal(t, "one\ntwo\nthree", m.GetCurrentContent())
+	})
+
+	t.Run("keys do not reach the editor while it is open", func(t *testing.T) {
+		m := newTestModel(content, withSize(80, 20))
+		m.OpenCommandPalette()
+		m = typeText(m, "dd")
+		assert.Equal(t, "one\ntwo\nthree", m.GetCurrentContent())
+	})
+}
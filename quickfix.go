@@ -0,0 +1,161 @@
+package goeditor
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// maxQuickfixRows is the most items the quickfix panel shows at once.
+const maxQuickfixRows = 8
+
+// QuickfixMsg is sent when the quickfix list, its selected item or whether it
+// is shown changes.
+type QuickfixMsg struct {
+	Items []core.QuickfixItem
+	Index int // Index in Items of the selected item, -1 if none
+	Open  bool
+}
+
+// quickfixPanel is the list shown below the editor by :copen. While it has
+// focus, j/k and the arrow keys move the selection, Enter jumps to the selected
+// item, Escape returns to the editor and q closes the list.
+type quickfixPanel struct {
+	items    []core.QuickfixItem
+	open     bool
+	focused  bool
+	selected int
+	offset   int // First item shown
+}
+
+// SetQuickfixList replaces the quickfix list, e.g. with the output of a
+// compiler or linter. Use :copen to show it and :cn/:cp to go through it.
+func (m *Model) SetQuickfixList(items []core.QuickfixItem) {
+	m.editor.Quickfix().SetQuickfixList(items)
+}
+
+// height returns the number of rows the panel takes, including its title.
+func (p *quickfixPanel) height() int {
+	if !p.open {
+		return 0
+	}
+	return 1 + max(1, min(len(p.items), maxQuickfixRows))
+}
+
+// sync updates the panel from a QuickfixMsg and reports whether its height changed.
+func (p *quickfixPanel) sync(msg QuickfixMsg) bool {
+	before := p.height()
+
+	if !p.open && msg.Open {
+		p.focused = true
+	}
+	if !msg.Open {
+		p.focused = false
+	}
+
+	p.items = msg.Items
+	p.open = msg.Open
+	if msg.Index >= 0 {
+		p.selected = msg.Index
+	}
+	p.selected = min(max(p.selected, 0), max(len(p.items)-1, 0))
+	p.scrollToSelected()
+
+	return p.height() != before
+}
+
+func (p *quickfixPanel) scrollToSelected() {
+	if p.selected < p.offset {
+		p.offset = p.selected
+	} else if p.selected >= p.offset+maxQuickfixRows {
+		p.offset = p.selected - maxQuickfixRows + 1
+	}
+}
+
+// handleQuickfixKey handles a key while the quickfix panel has focus.
+func (m *Model) handleQuickfixKey(key core.KeyEvent) tea.Cmd {
+	p := &m.quickfix
+
+	switch {
+	case key.Rune == 'j' || key.Key == core.KeyDown:
+		if p.selected < len(p.items)-1 {
+			p.selected++
+			p.scrollToSelected()
+		}
+	case key.Rune == 'k' || key.Key == core.KeyUp:
+		if p.selected > 0 {
+			p.selected--
+			p.scrollToSelected()
+		}
+	case key.Key == core.KeyEnter:
+		p.focused = false
+		if err := m.editor.Quickfix().JumpToQuickfix(p.selected); err != nil {
+			return func() tea.Msg {
+				return ErrorMsg{ID: err.ID(), Error: err.Error(), Severity: err.Severity()}
+			}
+		}
+		m.updateVisualTopLine()
+	case key.Key == core.KeyEscape:
+		p.focused = false
+	case key.Rune == 'q':
+		m.editor.Quickfix().CloseQuickfix()
+	}
+
+	return nil
+}
+
+// renderQuickfixPanel renders the quickfix list, one "line:col text" row per item.
+func (m *Model) renderQuickfixPanel() string {
+	p := &m.quickfix
+
+	title := " " + fmt.Sprintf(m.labels.QuickfixTitle, len(p.items))
+	rows := []string{m.theme.QuickfixTitleStyle.Width(m.width).Render(title)}
+
+	if len(p.items) == 0 {
+		rows = append(rows, m.theme.QuickfixItemStyle.Width(m.width).Render(" "+m.editor.Message(core.MsgNoQuickfixItems)))
+		return lipgloss.JoinVertical(lipgloss.Left, rows...)
+	}
+
+	end := min(p.offset+maxQuickfixRows, len(p.items))
+	for i := p.offset; i < end; i++ {
+		item := p.items[i]
+		line := fmt.Sprintf(" %d:%d %s", item.Position.Row+1, item.Position.Col+1, firstLine(item.Text))
+		line = truncateToWidth(line, m.width)
+
+		style := m.theme.QuickfixItemStyle
+		if i == p.selected {
+			style = m.theme.QuickfixSelectedItemStyle
+		}
+		rows = append(rows, style.Width(m.width).Render(line))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// firstLine returns s up to its first line break.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// truncateToWidth cuts s so that it fits in width cells.
+func truncateToWidth(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := lipgloss.Width(string(r))
+		if used+w > width {
+			break
+		}
+		used += w
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package goeditor
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestQuickfixPanel tests showing the quickfix list below the editor and jumping from it.
+func TestQuickfixPanel(t *testing.T) {
+	items := []core.QuickfixItem{
+		{Position: core.Position{Row: 1, Col: 2}, Text: "unused variable"},
+		{Position: core.Position{Row: 3, Col: 0}, Text: "missing return"},
+	}
+
+	const content = "one\ntwo three\nfour\nfive\nsix"
+
+	t.Run("opening shows the items and shrinks the text area", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), withQuickfix(items...))
+		height := m.editor.GetState().ViewportHeight
+
+		m, _ = m.Update(QuickfixMsg{Items: items, Index: -1, Open: true})
+
+		view := m.View()
+		assert.Contains(t, view, "Quickfix (2)")
+		assert.Contains(t, view, "2:3 unused variable")
+		assert.Contains(t, view, "4:1 missing return")
+		assert.Equal(t, height-3, m.editor.GetState().ViewportHeight)
+	})
+
+	t.Run("Enter jumps to the selected item and returns to the editor", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), withQuickfix(items...))
+		m, _ = m.Update(QuickfixMsg{Items: items, Index: -1, Open: true})
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+		assert.Equal(t, core.Position{}, m.GetCursorPosition())
+
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+		assert.Equal(t, core.Position{Row: 3, Col: 0}, m.GetCursorPosition())
+		assert.False(t, m.quickfix.focused)
+
+		// Keys go to the editor again
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+		assert.Equal(t, core.Position{Row: 4, Col: 0}, m.GetCursorPosition())
+	})
+
+	t.Run("closing gives the rows back", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), withQuickfix(items...))
+		height := m.editor.GetState().ViewportHeight
+		m, _ = m.Update(QuickfixMsg{Items: items, Index: -1, Open: true})
+		m, _ = m.Update(QuickfixMsg{Items: items, Index: -1, Open: false})
+
+		assert.NotContains(t, m.View(), "Quickfix")
+		assert.Equal(t, height, m.editor.GetState().ViewportHeight)
+	})
+
+	t.Run("long messages are cut to the width", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 12), withQuickfix(items...))
+		long := []core.QuickfixItem{{Text: strings.Repeat("x", 100)}}
+		m, _ = m.Update(QuickfixMsg{Items: long, Index: -1, Open: true})
+
+		for line := range strings.SplitSeq(m.renderQuickfixPanel(), "\n") {
+			assert.LessOrEqual(t, lipgloss.Width(line), 40)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package goeditor
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// dirtyFlags records what handling a message changed, so that update lays
+// out, scrolls and renders at most once for it, and only as much as needed.
+// Keys held back to the end of a frame add theirs up.
+type dirtyFlags uint8
+
+const (
+	contentChanged dirtyFlags = 1 << iota // The text changed: clear the caches and lay it out again
+	sizeChanged                           // The text may wrap differently: lay it out again
+	cursorMoved                           // The cursor may have moved: find it and scroll to it
+	needsRender                           // The frame is stale: render the visible lines again
+	cursorBlinked                         // Only the drawn cursor blinked: repaint its line
+)
+
+// refresh brings the layout, the scroll and the frame up to date with what
+// the message being handled changed, as recorded in m.dirty. Content changed
+// without the model knowing, as by a key, is found by its revision.
+func (m *Model) refresh() {
+	if m.editor.GetBuffer().Revision() != m.contentRevision {
+		m.dirty |= contentChanged
+	}
+
+	switch {
+	case m.dirty&contentChanged != 0:
+		m.handleContentChange()
+	case m.dirty&sizeChanged != 0:
+		m.cacheValidStartRow = 0
+		m.cacheValidEndRow = 0
+		m.calculateVisualMetrics()
+		m.updateVisualTopLine()
+	case m.dirty&cursorMoved != 0:
+		m.followCursor()
+	}
+
+	m.hideHoverOnCursorMove()
+	switch {
+	case m.dirty&needsRender != 0:
+		m.renderVisibleSlice()
+	case m.dirty&cursorBlinked != 0:
+		m.repaintCursorRow()
+	}
+	m.dirty = 0
+	if m.frameInterval > 0 {
+		m.lastFrame = time.Now()
+	}
+}
+
+// frameMsg draws the keys held back since the last frame.
+type frameMsg struct{}
+
+// SetMaxFrameRate caps how often keys lay out and draw the text, at fps
+// frames a second, such as 60 for the terminal's refresh rate. Keys coming
+// faster, as when j is held down on a big file, are all handled, and what
+// they changed is drawn once, at the end of the frame. 0, the default, draws
+// after every key.
+func (m *Model) SetMaxFrameRate(fps int) {
+	m.frameInterval = 0
+	if fps > 0 {
+		m.frameInterval = time.Second / time.Duration(fps)
+	}
+}
+
+// refreshKey refreshes for a key, or, if a frame was drawn less than the
+// frame interval ago, holds it back to the end of the frame, returning the
+// command that draws it then.
+func (m *Model) refreshKey() tea.Cmd {
+	wait := m.frameInterval - time.Since(m.lastFrame)
+	if m.frameInterval == 0 || wait <= 0 {
+		m.refresh()
+		return nil
+	}
+
+	if m.framePending {
+		return nil
+	}
+	m.framePending = true
+	return tea.Tick(wait, func(time.Time) tea.Msg { return frameMsg{} })
+}
+
+// followCursor finds the cursor in the layout and scrolls to it. A full
+// layout still holds for the content, so only a lazy one, covering the lines
+// around the cursor, is extended; both are laid out again if the width the
+// text wraps at, the tab width, or how it marks the lines it continues,
+// changed.
+func (m *Model) followCursor() {
+	lines := m.editor.GetBuffer().LineCount()
+	availableWidth := max(1, m.viewport.Width()-m.calculateLineNumberWidth(lines))
+	state := m.editor.GetState()
+	widthChanged := availableWidth != state.AvailableWidth || layoutOptionsOf(state) != m.layoutOptions
+	if widthChanged {
+		m.cacheValidStartRow = 0
+		m.cacheValidEndRow = 0
+	}
+	if widthChanged || lines > largeFileThreshold || len(m.visualLayoutCache) == 0 {
+		m.calculateVisualMetrics()
+	} else {
+		m.locateCursor()
+	}
+	m.updateVisualTopLine()
+}
+
+// renderedFrame is the last frame renderVisibleSlice drew, kept for the
+// cursor blinking to repaint only the line the cursor is on.
+type renderedFrame struct {
+	rows    []string   // The rendered visual lines, nil if they can't be repainted
+	top     int        // Absolute visual row of the first line
+	context rowContext // What rendering the lines took
+	effects keyEffects // What the editor was like when drawn
+}
+
+// repaintCursorRow renders again the line of the frame the cursor is on, for
+// it blinking, or the whole frame if the editor changed since it was drawn.
+func (m *Model) repaintCursorRow() {
+	row := m.frame.context.cursorRow
+	cacheIdx := m.frame.top + row - m.visualLayoutCacheStartVisualRow
+	if m.frame.rows == nil || row < 0 || row >= len(m.frame.rows) ||
+		cacheIdx < 0 || cacheIdx >= len(m.visualLayoutCache) || m.frame.effects != m.keyEffects() {
+		m.renderVisibleSlice()
+		return
+	}
+
+	// Older copies of the model share the rows
+	m.frame.rows = slices.Clone(m.frame.rows)
+	m.frame.rows[row] = m.renderRow(&m.frame.context, m.visualLayoutCache[cacheIdx], row)
+	m.viewport.SetContent(strings.Join(m.frame.rows, "\n"))
+}
@@ -0,0 +1,142 @@
+package goeditor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRefresh tests that update lays out and renders only as much as a
+// message needs.
+func TestRefresh(t *testing.T) {
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	content := strings.Join(lines, "\n")
+
+	t.Run("moving the cursor keeps the layout and scrolls", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 8), withListening())
+		layout := &m.visualLayoutCache[0]
+
+		m = typeText(m, "12j")
+		assert.Same(t, layout, &m.visualLayoutCache[0])
+		assert.Equal(t, 12, m.cursorAbsoluteVisualRow)
+		assert.Equal(t, 7, m.currentVisualTopLine)
+		assert.Contains(t, sgr.ReplaceAllString(m.viewport.View(), ""), "13 line 12")
+		assert.Zero(t, m.dirty)
+	})
+
+	t.Run("changing the content lays it out again", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 8), withListening())
+		layout := &m.visualLayoutCache[0]
+
+		m = typeText(m, "Aend")
+		assert.NotSame(t, layout, &m.visualLayoutCache[0])
+		assert.Equal(t, m.editor.GetBuffer().Revision(), m.contentRevision)
+		assertLayout(t, m)
+		assert.Contains(t, sgr.ReplaceAllString(m.viewport.View(), ""), "line 0end")
+	})
+
+	t.Run("content changed outside update is found by its revision", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 8), withListening())
+		assert.NoError(t, m.editor.GetBuffer().InsertRunesAt(0, 0, []rune("new ")))
+
+		m, _ = m.update(cursorBlinkMsg{})
+		assertLayout(t, m)
+		assert.Contains(t, sgr.ReplaceAllString(m.viewport.View(), ""), "new line 0")
+	})
+
+	t.Run("messages that change nothing don't render", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 8), withListening())
+		m.viewport.SetContent("stale")
+
+		m, _ = m.update(CompletionDebounceMsg{})
+		m, _ = m.update(commandMsg{})
+		assert.Equal(t, "stale", strings.TrimSpace(m.viewport.View()))
+
+		m.Blur()
+		m, _ = m.update(cursorBlinkMsg{})
+		assert.Contains(t, m.viewport.View(), "line 0")
+	})
+
+	t.Run("blinking repaints only the cursor line", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 8), withListening())
+		m.SetCursorMode(CursorBlink)
+		m.frame.rows = slices.Clone(m.frame.rows)
+		m.frame.rows[3] = "kept"
+		before := m.frame.rows[0]
+
+		m, _ = m.update(cursorBlinkMsg{})
+		assert.False(t, m.cursorVisible)
+		view := m.viewport.View()
+		assert.Contains(t, view, "kept")
+		assert.NotEqual(t, before, m.frame.rows[0])
+		assert.Contains(t, sgr.ReplaceAllString(m.frame.rows[0], ""), "line 0")
+
+		// Anything else changing draws the whole frame
+		m = typeText(m, "j")
+		assert.NotContains(t, m.viewport.View(), "kept")
+	})
+
+	t.Run("keys faster than the frame rate are drawn once, at the end of the frame", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 8), withListening())
+		m.SetMaxFrameRate(1)
+		m = typeText(m, "j")
+		assert.Equal(t, 1, m.cursorAbsoluteVisualRow)
+		drawn := m.viewport.View()
+
+		// The first key held back asks for the frame
+		m, cmd := m.update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+		assert.NotNil(t, cmd)
+		for range 9 {
+			m, cmd = m.update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+			assert.Nil(t, cmd)
+		}
+		assert.Equal(t, 11, m.GetCursorPosition().Row)
+		assert.Equal(t, 1, m.cursorAbsoluteVisualRow)
+		assert.Equal(t, drawn, m.viewport.View())
+		assert.True(t, m.framePending)
+
+		m, _ = m.update(frameMsg{})
+		assert.Equal(t, 11, m.cursorAbsoluteVisualRow)
+		assert.Contains(t, sgr.ReplaceAllString(m.viewport.View(), ""), "12 line 11")
+		assert.False(t, m.framePending)
+	})
+
+	t.Run("the terminal cursor blinks without waking the editor", func(t *testing.T) {
+		m := newTestModel(content, withSize(40, 8), withListening())
+		m.SetCursorMode(CursorBlink)
+		m.SetTerminalCursor(true)
+		assert.Nil(t, m.CursorBlink())
+		assert.True(t, m.cursorVisible)
+		assert.True(t, m.Cursor().Blink)
+	})
+}
+
+// BenchmarkHoldDownJ measures moving down a big file a line at a time, as
+// when j is held down, drawing every key or at most 60 frames a second.
+func BenchmarkHoldDownJ(b *testing.B) {
+	lines := make([]string, 100_000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d of a big file, long enough to wrap on a narrow terminal", i)
+	}
+
+	for _, fps := range []int{0, 60} {
+		b.Run(fmt.Sprintf("%d fps", fps), func(b *testing.B) {
+			m := newTestModel(strings.Join(lines, "\n"), withSize(40, 50), withListening())
+			m.SetMaxFrameRate(fps)
+			b.ReportAllocs()
+			for b.Loop() {
+				m, _ = m.update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+				if m.GetCursorPosition().Row == len(lines)-1 {
+					m = typeText(m, "gg")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEditorSignals tests reading the editor's signals.
+func TestEditorSignals(t *testing.T) {
+	t.Run("a burst of signals arrives at once and in order", func(t *testing.T) {
+		m := New(40, 10)
+		ed := m.editor
+		ed.DispatchSignal(core.QuitSignal{})
+		ed.DispatchSignal(core.ConfirmQuitSignal{})
+		ed.DispatchSignal(core.DeleteFileSignal{})
+
+		msg := m.listenForEditorUpdate()()
+		assert.Equal(t, signalsMsg{QuitMsg{}, ConfirmQuitMsg{}, DeleteFileMsg{}}, msg)
+	})
+
+	t.Run("mode changes arrive as ModeChangedMsg", func(t *testing.T) {
+		m := New(40, 10)
+		m.Focus()
+		m = typeText(m, "i")
+
+		msg := m.listenForEditorUpdate()()
+		assert.Contains(t, msg, ModeChangedMsg{From: core.NormalMode, To: core.InsertMode})
+	})
+
+	t.Run("messages of registered commands arrive as they are", func(t *testing.T) {
+		type stageMsg struct{ line int }
+		m := New(40, 10)
+		m.RegisterCommand("stage", func(editor core.Editor, args []string) (tea.Msg, *core.EditorError) {
+			return stageMsg{editor.GetBuffer().GetCursor().Position.Row}, nil
+		})
+		assert.Nil(t, m.GetEditor().ExecuteCommand("stage"))
+
+		msg := m.listenForEditorUpdate()()
+		assert.Contains(t, msg, stageMsg{0})
+	})
+
+	t.Run(":delete asks with ConfirmMsg", func(t *testing.T) {
+		m := New(40, 10)
+		m.SetFileName("notes.md")
+		assert.Nil(t, m.GetEditor().ExecuteCommand("delete"))
+
+		msgs := m.listenForEditorUpdate()().(signalsMsg)
+		confirm := msgs[len(msgs)-1].(ConfirmMsg)
+		assert.Equal(t, core.ConfirmDeleteFile, confirm.Action)
+		assert.Equal(t, "Delete notes.md?", confirm.Prompt)
+
+		confirm.Accept()
+		assert.Equal(t, signalsMsg{DeleteFileMsg{}}, m.listenForEditorUpdate()())
+	})
+
+	t.Run("blocked keys arrive as BlockedMsg", func(t *testing.T) {
+		m := New(40, 10)
+		m.Focus()
+		m.SetCapabilities(core.AllCapabilities &^ core.EditText)
+		assert.False(t, m.HasCapability(core.EditText))
+		m = typeText(m, "i")
+
+		msg := m.listenForEditorUpdate()()
+		assert.Equal(t, signalsMsg{BlockedMsg{Capability: core.EditText}}, msg)
+	})
+
+	t.Run("errors arrive with their severity, in the host's words", func(t *testing.T) {
+		m := New(40, 10)
+		m.Focus()
+		m.SetMessages(map[core.MessageId]string{core.MsgCharNotFound: "no %c"})
+		m = typeText(m, "fz")
+
+		msg := m.listenForEditorUpdate()().(signalsMsg)
+		errMsg := msg[len(msg)-1].(ErrorMsg)
+		assert.Equal(t, "no z", errMsg.Error.Error())
+		assert.Equal(t, core.SeverityWarning, errMsg.Severity)
+	})
+
+	t.Run("one command listens at a time", func(t *testing.T) {
+		m := New(40, 10)
+		m, _ = m.Update(tea.WindowSizeMsg{Width: 40, Height: 10})
+		assert.True(t, m.listening)
+
+		m, _ = m.Update(tea.WindowSizeMsg{Width: 50, Height: 10})
+		assert.True(t, m.listening)
+
+		_, cmd := m.Update(signalsMsg{QuitMsg{}})
+		assert.NotNil(t, cmd)
+	})
+}
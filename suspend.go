@@ -0,0 +1,65 @@
+package goeditor
+
+import (
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// defaultSuspendKey suspends the program, as Ctrl-Z does in a shell and Vim.
+var defaultSuspendKey = &core.KeyEvent{Key: core.KeyCtrlZ, Modifiers: core.ModCtrl}
+
+// SetSuspendKey sets the key that suspends the program in normal and visual
+// mode, written in Vim's notation as core.ParseKeys reads it, or "" for
+// none. It's <C-z> by default, as in Vim; without Vim mode, where Ctrl-Z
+// undoes, no key suspends.
+//
+// Bubble Tea restores the terminal while the program is suspended. On
+// resuming, the editor restarts the cursor blinking, lays the text out
+// again and asks for the window size, as the terminal may have been resized
+// meanwhile: hosts that call SetSize on tea.WindowSizeMsg need nothing else.
+func (m *Model) SetSuspendKey(key string) error {
+	if key == "" {
+		m.suspendKey = nil
+		return nil
+	}
+	events, err := core.ParseKeys(key)
+	if err != nil {
+		return err
+	}
+	if len(events) != 1 {
+		return fmt.Errorf("%q is not a single key", key)
+	}
+	m.suspendKey = &events[0]
+	return nil
+}
+
+// isSuspendKey reports whether key suspends the program now, which it
+// doesn't while a command waits for more keys.
+func (m *Model) isSuspendKey(key core.KeyEvent) bool {
+	if m.suspendKey == nil || m.disableVimMode || m.editor.HasPendingKeys() {
+		return false
+	}
+	switch m.editor.GetState().Mode {
+	case core.NormalMode, core.VisualMode, core.VisualLineMode:
+		return sameKey(key, *m.suspendKey)
+	}
+	return false
+}
+
+// suspend stops the cursor blinking and suspends the program.
+func (m *Model) suspend() tea.Cmd {
+	if m.cursorBlinkCancel != nil {
+		m.cursorBlinkCancel()
+		m.cursorBlinkCancel = nil
+	}
+	return tea.Suspend
+}
+
+// resume restores what suspending stopped or may have made stale.
+func (m *Model) resume() tea.Cmd {
+	m.cursorVisible = m.isFocused
+	m.dirty |= sizeChanged | needsRender
+	return tea.Batch(tea.RequestWindowSize, m.CursorBlink())
+}
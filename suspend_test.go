@@ -0,0 +1,78 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSuspend tests suspending the program with Ctrl-Z and resuming it.
+func TestSuspend(t *testing.T) {
+	ctrlZ := tea.KeyPressMsg{Code: 'z', Mod: tea.ModCtrl}
+	const content = "one\ntwo"
+
+	// The commands are those of update, without the background work Update adds
+	suspends := func(cmd tea.Cmd) bool {
+		if cmd == nil {
+			return false
+		}
+		_, ok := cmd().(tea.SuspendMsg)
+		return ok
+	}
+
+	t.Run("Ctrl-Z suspends in normal and visual mode", func(t *testing.T) {
+		m := newTestModel(content, withListening())
+		_, _, consumed := m.HandleKey(ctrlZ)
+		assert.True(t, consumed)
+		_, cmd := m.update(ctrlZ)
+		assert.True(t, suspends(cmd))
+
+		m = typeText(m, "v")
+		_, cmd = m.update(ctrlZ)
+		assert.True(t, suspends(cmd))
+	})
+
+	t.Run("but not in insert mode, after a pending key or without Vim mode", func(t *testing.T) {
+		m := newTestModel(content, withListening())
+		m = typeText(m, "i")
+		_, cmd := m.update(ctrlZ)
+		assert.False(t, suspends(cmd))
+
+		m = newTestModel(content, withListening())
+		m = typeText(m, "d")
+		_, cmd = m.update(ctrlZ)
+		assert.False(t, suspends(cmd))
+
+		m = newTestModel(content, withListening())
+		m.DisableVimMode(true)
+		m = typeText(m, "x")
+		m, cmd = m.update(ctrlZ)
+		assert.False(t, suspends(cmd))
+		assert.Equal(t, "one\ntwo", m.GetCurrentContent())
+	})
+
+	t.Run("the key can be changed or removed", func(t *testing.T) {
+		m := newTestModel(content, withListening())
+		assert.NoError(t, m.SetSuspendKey("<C-s>"))
+		_, cmd := m.update(ctrlZ)
+		assert.False(t, suspends(cmd))
+		_, cmd = m.update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+		assert.True(t, suspends(cmd))
+
+		assert.NoError(t, m.SetSuspendKey(""))
+		_, cmd = m.update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+		assert.False(t, suspends(cmd))
+
+		assert.Error(t, m.SetSuspendKey("<C-x><C-z>"))
+		assert.Error(t, m.SetSuspendKey("<Nope>"))
+	})
+
+	t.Run("resuming shows the cursor and asks for the window size", func(t *testing.T) {
+		m := newTestModel(content, withListening())
+		m.cursorVisible = false
+		m, cmd := m.Update(tea.ResumeMsg{})
+		assert.True(t, m.cursorVisible)
+		assert.NotNil(t, cmd)
+	})
+}
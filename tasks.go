@@ -0,0 +1,84 @@
+package goeditor
+
+import (
+	"context"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// taskKind is a kind of background work, of which one task runs at a time.
+type taskKind int
+
+const (
+	taskTokenise taskKind = iota // Syntax highlighting of the visible lines
+)
+
+// task is background work started by a taskRunner for a revision of the
+// content.
+type task struct {
+	kind     taskKind
+	revision uint64 // Buffer revision the task works on
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// taskRunner runs the model's background work as tea.Cmds. Starting a task
+// cancels the running one of the same kind, and when the content changes
+// the tasks for older revisions are cancelled, so their results don't race
+// newer ones into the caches. The work checks its context to stop early,
+// and its result is only used if finish reports the task is current.
+type taskRunner struct {
+	running map[taskKind]*task
+}
+
+// start returns a command that runs work for revision as a task of kind,
+// cancelling the running one of that kind.
+func (r *taskRunner) start(kind taskKind, revision uint64, work func(t *task) tea.Msg) tea.Cmd {
+	r.cancel(kind)
+	if r.running == nil {
+		r.running = make(map[taskKind]*task)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &task{kind: kind, revision: revision, ctx: ctx, cancel: cancel}
+	r.running[kind] = t
+	return func() tea.Msg {
+		return work(t)
+	}
+}
+
+// finish marks t as done and reports whether it's still current: not
+// cancelled or replaced by another task since it started.
+func (r *taskRunner) finish(t *task) bool {
+	if r.running[t.kind] != t {
+		return false
+	}
+	delete(r.running, t.kind)
+	t.cancel() // Releases the context
+	return true
+}
+
+// cancel stops the running task of kind, if any.
+func (r *taskRunner) cancel(kind taskKind) {
+	if t, ok := r.running[kind]; ok {
+		t.cancel()
+		delete(r.running, kind)
+	}
+}
+
+// cancelStale stops the running tasks for revisions other than revision,
+// after the content changed.
+func (r *taskRunner) cancelStale(revision uint64) {
+	for kind, t := range r.running {
+		if t.revision != revision {
+			t.cancel()
+			delete(r.running, kind)
+		}
+	}
+}
+
+// isRunning reports whether a task of kind is running.
+func (r *taskRunner) isRunning(kind taskKind) bool {
+	_, ok := r.running[kind]
+	return ok
+}
@@ -0,0 +1,44 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTaskRunner tests cancelling background work.
+func TestTaskRunner(t *testing.T) {
+	run := func(r *taskRunner, revision uint64) *task {
+		var started *task
+		cmd := r.start(taskTokenise, revision, func(t *task) tea.Msg {
+			started = t
+			return nil
+		})
+		cmd()
+		return started
+	}
+
+	t.Run("starting a task cancels the one of its kind", func(t *testing.T) {
+		var r taskRunner
+		first := run(&r, 1)
+		second := run(&r, 1)
+		assert.Error(t, first.ctx.Err())
+		assert.False(t, r.finish(first))
+		assert.True(t, r.finish(second))
+		assert.False(t, r.isRunning(taskTokenise))
+	})
+
+	t.Run("a content change cancels the tasks for older revisions", func(t *testing.T) {
+		var r taskRunner
+		current := run(&r, 2)
+		r.cancelStale(2)
+		assert.NoError(t, current.ctx.Err())
+		assert.True(t, r.isRunning(taskTokenise))
+
+		r.cancelStale(3)
+		assert.Error(t, current.ctx.Err())
+		assert.False(t, r.isRunning(taskTokenise))
+		assert.False(t, r.finish(current))
+	})
+}
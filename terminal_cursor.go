@@ -0,0 +1,74 @@
+package goeditor
+
+import (
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// SetTerminalCursor makes the editor leave the cursor to the terminal instead
+// of drawing it as a styled character. The host shows it by setting the
+// Cursor of its view:
+//
+//	v := tea.NewView(m.editor.View())
+//	v.Cursor = m.editor.Cursor()
+//
+// The terminal then changes the cursor shape with DECSCUSR like Vim: a bar
+// in insert mode, an underline while a command waits for more keys, such as
+// r waiting for its character, and a block otherwise. Unlike the drawn
+// cursor, it covers wide characters fully, and with CursorBlink the terminal
+// blinks it, so the editor doesn't wake to redraw it while idle.
+func (m *Model) SetTerminalCursor(enabled bool) {
+	m.terminalCursor = enabled
+	m.renderVisibleSlice()
+}
+
+// Cursor returns the terminal cursor relative to the top left corner of View,
+// or nil if the editor draws its own cursor, isn't focused or the cursor is
+// off screen.
+func (m Model) Cursor() *tea.Cursor {
+	if !m.terminalCursor || !m.isFocused || m.palette.open || m.editor.IsSearchMode() {
+		return nil
+	}
+
+	pos := m.cursorScreenPos
+	if m.editor.IsCommandMode() {
+		state := m.editor.GetState()
+		typed := []rune(state.CommandLine)
+		if state.CommandCursor >= 0 && state.CommandCursor <= len(typed) {
+			typed = typed[:state.CommandCursor]
+		}
+		pos = core.Position{
+			Row: m.viewport.Height() + m.quickfix.height() + 1,
+			Col: lipgloss.Width(string(typed)),
+		}
+	} else if len(m.preedit) > 0 {
+		pos.Col += getVisualWidth(string(m.preedit[:m.preeditCursor]))
+	}
+	if pos.Row < 0 || pos.Col < 0 {
+		return nil
+	}
+
+	cursor := tea.NewCursor(pos.Col, pos.Row)
+	cursor.Blink = m.cursorBlinks()
+	if color := m.getCursorStyles().GetBackground(); color != (lipgloss.NoColor{}) {
+		cursor.Color = color
+	}
+
+	state := m.editor.GetState()
+	switch {
+	case state.Mode == core.InsertMode || state.Mode == core.CommandMode:
+		cursor.Shape = tea.CursorBar
+	case strings.Trim(state.PendingKeys, "0123456789") != "":
+		cursor.Shape = tea.CursorUnderline
+	}
+
+	return cursor
+}
+
+// drawsCursor reports whether the cursor is drawn as a styled character.
+func (m *Model) drawsCursor() bool {
+	return m.isFocused && m.cursorVisible && !m.terminalCursor
+}
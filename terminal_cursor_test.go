@@ -0,0 +1,92 @@
+package goeditor
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTerminalCursor tests leaving the cursor to the terminal.
+func TestTerminalCursor(t *testing.T) {
+	const content = "abc\n世界x"
+
+	t.Run("the editor draws its own cursor by default", func(t *testing.T) {
+		m := New(40, 10)
+		m.Focus()
+		assert.Nil(t, m.Cursor())
+	})
+
+	t.Run("a block in normal mode after the line numbers", func(t *testing.T) {
+		m := newTestModel(content, withTerminalCursor())
+		cursor := m.Cursor()
+		assert.Equal(t, tea.Position{X: m.calculateLineNumberWidth(2), Y: 0}, cursor.Position)
+		assert.Equal(t, tea.CursorBlock, cursor.Shape)
+		assert.False(t, cursor.Blink)
+	})
+
+	t.Run("wide characters take two columns", func(t *testing.T) {
+		m := newTestModel(content, withTerminalCursor())
+		m = typeText(m, "jll")
+		assert.Equal(t, tea.Position{X: m.calculateLineNumberWidth(2) + 4, Y: 1}, m.Cursor().Position)
+	})
+
+	t.Run("a bar in insert mode", func(t *testing.T) {
+		m := newTestModel(content, withTerminalCursor())
+		m = typeText(m, "A")
+		cursor := m.Cursor()
+		assert.Equal(t, tea.CursorBar, cursor.Shape)
+		assert.Equal(t, m.calculateLineNumberWidth(2)+3, cursor.X)
+	})
+
+	t.Run("an underline while r waits for its character", func(t *testing.T) {
+		m := newTestModel(content, withTerminalCursor())
+		m = typeText(m, "2")
+		assert.Equal(t, tea.CursorBlock, m.Cursor().Shape)
+		m = typeText(m, "r")
+		assert.Equal(t, tea.CursorUnderline, m.Cursor().Shape)
+	})
+
+	t.Run("on the command line in command mode", func(t *testing.T) {
+		m := newTestModel(content, withTerminalCursor())
+		m = typeText(m, ":wq")
+		assert.Equal(t, tea.Position{X: 3, Y: 9}, m.Cursor().Position)
+	})
+
+	t.Run("where the command line is edited", func(t *testing.T) {
+		m := newTestModel(content, withTerminalCursor())
+		m = typeText(m, ":wq")
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyLeft})
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyLeft})
+		assert.Equal(t, tea.Position{X: 1, Y: 9}, m.Cursor().Position)
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnd})
+		assert.Equal(t, tea.Position{X: 3, Y: 9}, m.Cursor().Position)
+	})
+
+	t.Run("hidden when blurred", func(t *testing.T) {
+		m := newTestModel(content, withTerminalCursor())
+		m.Blur()
+		assert.Nil(t, m.Cursor())
+	})
+}
+
+// TestCommandLineCursor tests drawing the cursor where the command line is
+// edited.
+func TestCommandLineCursor(t *testing.T) {
+	m := New(40, 10)
+	m.Focus()
+	m.SetContent("abc")
+	m = typeText(m, ":wq")
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyLeft})
+
+	cursor := m.getCursorStyles()
+	lines := strings.Split(m.View(), "\n")
+	commandLine := lines[len(lines)-1]
+	assert.Contains(t, commandLine, cursor.Render("q"))
+	assert.Equal(t, ":wq", strings.TrimRight(sgr.ReplaceAllString(commandLine, ""), " "))
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnd})
+	lines = strings.Split(m.View(), "\n")
+	assert.Contains(t, lines[len(lines)-1], cursor.Render(" "))
+}
@@ -0,0 +1,84 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ionut-t/goeditor"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write
+// the golden files instead of comparing with them, as in
+// UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// Frame returns the frame m renders as plain text: without the escape
+// sequences that style it, and with the spaces that pad its lines trimmed,
+// so golden files don't depend on the theme. Compare m.View() to check the
+// styles too.
+func Frame(m goeditor.Model) string {
+	lines := strings.Split(stripEscapes(m.View()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripEscapes removes the CSI sequences, such as colours, and the OSC
+// sequences, such as hyperlinks, from s.
+func stripEscapes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '[': // Ends with a byte from @ to ~
+			i += 2
+			for i < len(s) && (s[i] < '@' || s[i] > '~') {
+				i++
+			}
+		case ']': // Ends with BEL or ESC \
+			i += 2
+			for i < len(s) && s[i] != '\a' && !(s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\') {
+				i++
+			}
+			if i < len(s) && s[i] == '\x1b' {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return b.String()
+}
+
+// AssertGolden checks that got is the content of testdata/name.golden. With
+// UpdateGoldenEnv set, it writes got to the file instead, to record a new
+// frame or accept a change to one.
+func AssertGolden(t testing.TB, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("testutil: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("testutil: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("testutil: %v; run with %s=1 to write it", err, UpdateGoldenEnv)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file; run with %s=1 to update it\ngot:\n%s\nwant:\n%s", name, UpdateGoldenEnv, got, want)
+	}
+}
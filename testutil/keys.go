@@ -0,0 +1,8 @@
+package testutil
+
+import "github.com/ionut-t/goeditor/core"
+
+// ParseKeys reads keys written in Vim's notation, as core.ParseKeys does.
+func ParseKeys(notation string) ([]core.KeyEvent, error) {
+	return core.ParseKeys(notation)
+}
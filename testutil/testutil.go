@@ -0,0 +1,173 @@
+// Package testutil helps test code that embeds the editor, and the editor
+// itself: it builds editors from text with a cursor marker, types keys
+// written in Vim's notation, asserts the content, cursor and mode, and
+// compares rendered frames with golden files.
+//
+//	e := testutil.NewEditor("hello wor|ld")
+//	testutil.Type(t, e, "ciwthere<Esc>")
+//	testutil.AssertContent(t, e, "hello there|")
+//	testutil.AssertMode(t, e, core.NormalMode)
+package testutil
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// CursorMarker marks the cursor in the text given to NewEditor, NewModel and
+// AssertContent. Only the first one is a marker.
+const CursorMarker = "|"
+
+// memoryClipboard keeps the clipboard in memory, so tests don't touch the
+// system clipboard.
+type memoryClipboard struct{ content string }
+
+func (c *memoryClipboard) Write(text string) error { c.content = text; return nil }
+func (c *memoryClipboard) Read() (string, error)   { return c.content, nil }
+
+// ParseCursor returns text without its cursor marker and where the marker
+// was, or the start of the text if it has none.
+func ParseCursor(text string) (string, core.Position) {
+	before, after, found := strings.Cut(text, CursorMarker)
+	if !found {
+		return text, core.Position{}
+	}
+
+	row := strings.Count(before, "\n")
+	col := len([]rune(before[strings.LastIndex(before, "\n")+1:]))
+	return before + after, core.Position{Row: row, Col: col}
+}
+
+// NewEditor returns an editor in normal mode with text as its content and
+// the cursor where text has the CursorMarker. Its clipboard is kept in
+// memory.
+func NewEditor(text string) core.Editor {
+	content, cursor := ParseCursor(text)
+	e := core.New(&memoryClipboard{})
+	e.SetContent([]byte(content))
+	e.GetBuffer().SetCursor(core.Cursor{Position: cursor, Preferred: cursor.Col})
+	return e
+}
+
+// NewModel returns a focused model of width and height with text as its
+// content and the cursor where text has the CursorMarker. Its clipboard is
+// kept in memory.
+func NewModel(text string, width, height int) goeditor.Model {
+	content, cursor := ParseCursor(text)
+	m := goeditor.New(width, height)
+	m.WithClipboard(&memoryClipboard{})
+	m.SetContent(content)
+	m.Focus()
+	_ = m.SetCursorPosition(cursor.Row, cursor.Col)
+	return m
+}
+
+// Type types keys, written in Vim's notation as ParseKeys reads it, into e.
+// Errors of the keys themselves, such as a motion that can't move, are
+// ignored as they would be when typed; a mistake in keys fails the test.
+func Type(t testing.TB, e core.Editor, keys string) {
+	t.Helper()
+	events, err := ParseKeys(keys)
+	if err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+	for _, event := range events {
+		_ = e.HandleKey(event)
+	}
+}
+
+// TypeModel types keys into m as key presses through Update, and returns
+// the model they lead to. Commands the presses return are not run.
+func TypeModel(t testing.TB, m goeditor.Model, keys string) goeditor.Model {
+	t.Helper()
+	events, err := ParseKeys(keys)
+	if err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+	for _, event := range events {
+		m, _ = m.Update(KeyPressMsg(event))
+	}
+	return m
+}
+
+// AssertContent checks that the content of e is want. If want has the
+// CursorMarker, the cursor must be there too.
+func AssertContent(t testing.TB, e core.Editor, want string) {
+	t.Helper()
+	content, cursor := ParseCursor(want)
+	if got := e.GetBuffer().GetCurrentContent(); got != content {
+		t.Errorf("content is\n%q\nwant\n%q", got, content)
+	}
+	if strings.Contains(want, CursorMarker) {
+		AssertCursor(t, e, cursor)
+	}
+}
+
+// AssertCursor checks that the cursor of e is at want.
+func AssertCursor(t testing.TB, e core.Editor, want core.Position) {
+	t.Helper()
+	if got := e.GetBuffer().GetCursor().Position; got != want {
+		t.Errorf("cursor is at %d:%d, want %d:%d", got.Row, got.Col, want.Row, want.Col)
+	}
+}
+
+// AssertMode checks that e is in mode want.
+func AssertMode(t testing.TB, e core.Editor, want core.Mode) {
+	t.Helper()
+	if got := e.GetState().Mode; got != want {
+		t.Errorf("mode is %s, want %s", got, want)
+	}
+}
+
+// KeyPressMsg returns the key press that the model converts to key, to send
+// keys through Update.
+func KeyPressMsg(key core.KeyEvent) tea.KeyPressMsg {
+	var mod tea.KeyMod
+	if key.Modifiers&core.ModCtrl != 0 {
+		mod |= tea.ModCtrl
+	}
+	if key.Modifiers&core.ModAlt != 0 {
+		mod |= tea.ModAlt
+	}
+	if key.Modifiers&core.ModShift != 0 {
+		mod |= tea.ModShift
+	}
+	if key.Modifiers&core.ModSuper != 0 {
+		mod |= tea.ModSuper
+	}
+
+	if key.Key >= core.KeyCtrlA && key.Key <= core.KeyCtrlZ {
+		return tea.KeyPressMsg{Code: 'a' + rune(key.Key-core.KeyCtrlA), Mod: mod | tea.ModCtrl}
+	}
+	if code, ok := keyCodes[key.Key]; ok {
+		return tea.KeyPressMsg{Code: code, Mod: mod}
+	}
+	if key.Modifiers&(core.ModCtrl|core.ModAlt|core.ModSuper) != 0 {
+		// Characters typed with these have no text
+		return tea.KeyPressMsg{Code: key.Rune, Mod: mod}
+	}
+	return tea.KeyPressMsg{Code: key.Rune, Text: string(key.Rune), Mod: mod}
+}
+
+// keyCodes are the Bubble Tea codes of the keys that aren't text.
+var keyCodes = map[core.KeyCode]rune{
+	core.KeyEnter:     tea.KeyEnter,
+	core.KeyTab:       tea.KeyTab,
+	core.KeyBackspace: tea.KeyBackspace,
+	core.KeyEscape:    tea.KeyEscape,
+	core.KeySpace:     tea.KeySpace,
+	core.KeyUp:        tea.KeyUp,
+	core.KeyDown:      tea.KeyDown,
+	core.KeyLeft:      tea.KeyLeft,
+	core.KeyRight:     tea.KeyRight,
+	core.KeyHome:      tea.KeyHome,
+	core.KeyEnd:       tea.KeyEnd,
+	core.KeyPageUp:    tea.KeyPgUp,
+	core.KeyPageDown:  tea.KeyPgDown,
+	core.KeyDelete:    tea.KeyDelete,
+	core.KeyInsert:    tea.KeyInsert,
+}
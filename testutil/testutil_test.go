@@ -0,0 +1,74 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/ionut-t/goeditor/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseKeys tests reading keys in Vim's notation.
+func TestParseKeys(t *testing.T) {
+	events, err := ParseKeys("ix <Esc><C-r><S-Tab><lt><A-b>a<b")
+	assert.NoError(t, err)
+	assert.Equal(t, []core.KeyEvent{
+		{Rune: 'i'},
+		{Rune: 'x'},
+		{Key: core.KeySpace, Rune: ' '},
+		{Key: core.KeyEscape},
+		{Key: core.KeyCtrlR, Modifiers: core.ModCtrl},
+		{Key: core.KeyTab, Rune: '\t', Modifiers: core.ModShift},
+		{Rune: '<'},
+		{Rune: 'b', Modifiers: core.ModAlt},
+		{Rune: 'a'},
+		{Rune: '<'},
+		{Rune: 'b'},
+	}, events)
+
+	_, err = ParseKeys("<Escape>")
+	assert.Error(t, err)
+	_, err = ParseKeys("<X-a>")
+	assert.Error(t, err)
+
+	events, err = ParseKeys("<C-1><C-S-a><A-S-a><D-s>")
+	assert.NoError(t, err)
+	assert.Equal(t, []core.KeyEvent{
+		{Rune: '1', Modifiers: core.ModCtrl},
+		{Key: core.KeyCtrlA, Modifiers: core.ModCtrl | core.ModShift},
+		{Rune: 'A', Modifiers: core.ModAlt | core.ModShift},
+		{Rune: 's', Modifiers: core.ModSuper},
+	}, events)
+}
+
+// TestEditorHelpers tests building, typing into and checking an editor.
+func TestEditorHelpers(t *testing.T) {
+	t.Run("the cursor marker places the cursor", func(t *testing.T) {
+		e := NewEditor("one\ntw|o")
+		AssertContent(t, e, "one\ntw|o")
+		AssertCursor(t, e, core.Position{Row: 1, Col: 2})
+	})
+
+	t.Run("keys are typed in Vim's notation", func(t *testing.T) {
+		e := NewEditor("hello wor|ld")
+		Type(t, e, "ciwthere<Esc>")
+		AssertContent(t, e, "hello there|")
+		AssertMode(t, e, core.NormalMode)
+
+		Type(t, e, "0dw")
+		AssertContent(t, e, "|there")
+		Type(t, e, "u")
+		AssertContent(t, e, "hello there")
+		Type(t, e, "<C-r>")
+		AssertContent(t, e, "there")
+	})
+}
+
+// TestModelHelpers tests driving a model and comparing its frames.
+func TestModelHelpers(t *testing.T) {
+	m := NewModel("first\nsec|ond", 30, 6)
+	m = TypeModel(t, m, "A line<Esc>o<Tab>third<Esc>")
+	e := m.GetEditor()
+	AssertContent(t, e, "first\nsecond line\n\tthird|")
+	AssertMode(t, e, core.NormalMode)
+	AssertGolden(t, "frame", Frame(m))
+}
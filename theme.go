@@ -0,0 +1,589 @@
+package goeditor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// StyleLayer is one of the styles that can apply to a character of the text.
+type StyleLayer int
+
+const (
+	StyleSyntax          StyleLayer = iota // Syntax highlighting
+	StyleCurrentLine                       // Theme.CurrentLineStyle on the cursor line
+	StyleHighlightedWord                   // Words set with SetHighlightedWords
+	StyleSelection                         // Theme.SelectionStyle, or Theme.HighlightYankStyle after a yank
+	StyleSearch                            // Theme.SearchHighlightStyle on search matches
+	StyleProtected                         // Theme.ProtectedStyle on read-only text, with SetDimProtectedRanges
+	StyleConceal                           // ConcealRule.Style on text with concealed markup
+	styleLayerCount
+)
+
+// DefaultStylePrecedence is the order of the styles, highest first, used when
+// Theme.StylePrecedence is nil: search matches over the selection, over
+// highlighted words, over text with concealed markup, over read-only text,
+// over the current line background, over syntax colours.
+var DefaultStylePrecedence = []StyleLayer{StyleSearch, StyleSelection, StyleHighlightedWord, StyleConceal, StyleProtected, StyleCurrentLine, StyleSyntax}
+
+// styleLayers holds the styles that apply to a character.
+type styleLayers struct {
+	styles [styleLayerCount]lipgloss.Style
+	set    [styleLayerCount]bool
+}
+
+// add sets the style of a layer.
+func (l *styleLayers) add(layer StyleLayer, style lipgloss.Style) {
+	l.styles[layer] = style
+	l.set[layer] = true
+}
+
+// merge combines the styles in the order of precedence, highest first. Each
+// property is taken from the highest style that sets it, so a selection that
+// only sets a background keeps the syntax foreground. Layers missing from
+// precedence are not drawn.
+func (l *styleLayers) merge(precedence []StyleLayer) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	for _, layer := range precedence {
+		if layer >= 0 && layer < styleLayerCount && l.set[layer] {
+			style = style.Inherit(l.styles[layer])
+		}
+	}
+	return style
+}
+
+// ThemeColors are the colours a Theme is built from with NewTheme. Each is a
+// hex colour such as "#1e1e2e" or an ANSI colour number such as "4".
+type ThemeColors struct {
+	Background  string `json:"background"`   // Text area and command line, and text on coloured backgrounds
+	Panel       string `json:"panel"`        // Command palette and line jump list
+	StatusLine  string `json:"status_line"`  // Status line and quickfix title
+	Selection   string `json:"selection"`    // Visual selection and selected list items
+	CurrentLine string `json:"current_line"` // Line the cursor is on
+	LineNumber  string `json:"line_number"`  // Line numbers and the completion menu border
+	Placeholder string `json:"placeholder"`  // Placeholder text
+	Text        string `json:"text"`         // Text of the status line, command line and lists
+	SubtleText  string `json:"subtle_text"`  // Command palette entries that are not selected
+	NormalMode  string `json:"normal_mode"`  // Normal mode indicator
+	InsertMode  string `json:"insert_mode"`  // Insert mode indicator
+	VisualMode  string `json:"visual_mode"`  // Visual mode indicator
+	CommandMode string `json:"command_mode"` // Command mode indicator
+	SearchMode  string `json:"search_mode"`  // Search mode indicator, search matches and the search prompt
+	Yank        string `json:"yank"`         // Yanked text flash
+	Message     string `json:"message"`      // Messages
+	Error       string `json:"error"`        // Errors and jump labels
+	Accent      string `json:"accent"`       // Completion labels
+	Secondary   string `json:"secondary"`    // Completion types and the command palette border
+	Border      string `json:"border"`       // Border of overlays
+}
+
+// Built-in theme colours.
+var (
+	CatppuccinMocha = ThemeColors{
+		Background:  "#1e1e2e", // Base
+		Panel:       "#181825", // Mantle
+		StatusLine:  "#313244", // Surface0
+		Selection:   "#45475a", // Surface1
+		CurrentLine: "#2A2B3C",
+		LineNumber:  "#6c7086", // Overlay0
+		Placeholder: "#7f849c", // Overlay1
+		Text:        "#cdd6f4", // Text
+		SubtleText:  "#a6adc8", // Subtext0
+		NormalMode:  "#94e2d5", // Teal
+		InsertMode:  "#89b4fa", // Blue
+		VisualMode:  "#cba6f7", // Mauve
+		CommandMode: "#fab387", // Peach
+		SearchMode:  "#f9e2af", // Yellow
+		Yank:        "#74c7ec", // Sapphire
+		Message:     "#a6e3a1", // Green
+		Error:       "#f38ba8", // Red
+		Accent:      "#89b4fa", // Blue
+		Secondary:   "#cba6f7", // Mauve
+		Border:      "#b4befe", // Lavender
+	}
+
+	CatppuccinLatte = ThemeColors{
+		Background:  "#eff1f5", // Base
+		Panel:       "#e6e9ef", // Mantle
+		StatusLine:  "#ccd0da", // Surface0
+		Selection:   "#bcc0cc", // Surface1
+		CurrentLine: "#e6e9ef", // Mantle
+		LineNumber:  "#9ca0b0", // Overlay0
+		Placeholder: "#8c8fa1", // Overlay1
+		Text:        "#4c4f69", // Text
+		SubtleText:  "#6c6f85", // Subtext0
+		NormalMode:  "#179299", // Teal
+		InsertMode:  "#1e66f5", // Blue
+		VisualMode:  "#8839ef", // Mauve
+		CommandMode: "#fe640b", // Peach
+		SearchMode:  "#df8e1d", // Yellow
+		Yank:        "#209fb5", // Sapphire
+		Message:     "#40a02b", // Green
+		Error:       "#d20f39", // Red
+		Accent:      "#1e66f5", // Blue
+		Secondary:   "#8839ef", // Mauve
+		Border:      "#7287fd", // Lavender
+	}
+
+	GruvboxDark = ThemeColors{
+		Background:  "#282828", // bg0
+		Panel:       "#1d2021", // bg0_h
+		StatusLine:  "#3c3836", // bg1
+		Selection:   "#504945", // bg2
+		CurrentLine: "#32302f", // bg0_s
+		LineNumber:  "#7c6f64", // bg4
+		Placeholder: "#928374", // gray
+		Text:        "#ebdbb2", // fg1
+		SubtleText:  "#bdae93", // fg3
+		NormalMode:  "#a89984", // fg4
+		InsertMode:  "#83a598", // blue
+		VisualMode:  "#fe8019", // orange
+		CommandMode: "#b8bb26", // green
+		SearchMode:  "#fabd2f", // yellow
+		Yank:        "#8ec07c", // aqua
+		Message:     "#b8bb26", // green
+		Error:       "#fb4934", // red
+		Accent:      "#83a598", // blue
+		Secondary:   "#d3869b", // purple
+		Border:      "#8ec07c", // aqua
+	}
+
+	GruvboxLight = ThemeColors{
+		Background:  "#fbf1c7", // bg0
+		Panel:       "#f9f5d7", // bg0_h
+		StatusLine:  "#ebdbb2", // bg1
+		Selection:   "#d5c4a1", // bg2
+		CurrentLine: "#f2e5bc", // bg0_s
+		LineNumber:  "#a89984", // bg4
+		Placeholder: "#928374", // gray
+		Text:        "#3c3836", // fg1
+		SubtleText:  "#665c54", // fg3
+		NormalMode:  "#7c6f64", // fg4
+		InsertMode:  "#076678", // blue
+		VisualMode:  "#af3a03", // orange
+		CommandMode: "#79740e", // green
+		SearchMode:  "#b57614", // yellow
+		Yank:        "#427b58", // aqua
+		Message:     "#79740e", // green
+		Error:       "#9d0006", // red
+		Accent:      "#076678", // blue
+		Secondary:   "#8f3f71", // purple
+		Border:      "#427b58", // aqua
+	}
+
+	Nord = ThemeColors{
+		Background:  "#2e3440", // nord0
+		Panel:       "#272c36",
+		StatusLine:  "#3b4252", // nord1
+		Selection:   "#434c5e", // nord2
+		CurrentLine: "#3b4252", // nord1
+		LineNumber:  "#4c566a", // nord3
+		Placeholder: "#616e88",
+		Text:        "#d8dee9", // nord4
+		SubtleText:  "#a5abb6",
+		NormalMode:  "#88c0d0", // nord8
+		InsertMode:  "#81a1c1", // nord9
+		VisualMode:  "#b48ead", // nord15
+		CommandMode: "#d08770", // nord12
+		SearchMode:  "#ebcb8b", // nord13
+		Yank:        "#8fbcbb", // nord7
+		Message:     "#a3be8c", // nord14
+		Error:       "#bf616a", // nord11
+		Accent:      "#81a1c1", // nord9
+		Secondary:   "#b48ead", // nord15
+		Border:      "#5e81ac", // nord10
+	}
+)
+
+// themePresets are the built-in theme colours by name, as accepted by
+// PresetTheme and by the preset key of theme files.
+var themePresets = map[string]ThemeColors{
+	"catppuccin":       CatppuccinMocha,
+	"catppuccin-mocha": CatppuccinMocha,
+	"catppuccin-latte": CatppuccinLatte,
+	"gruvbox":          GruvboxDark,
+	"gruvbox-light":    GruvboxLight,
+	"nord":             Nord,
+}
+
+// PresetTheme returns the built-in theme with the given name: catppuccin
+// (catppuccin-mocha), catppuccin-latte, gruvbox, gruvbox-light or nord.
+func PresetTheme(name string) (Theme, error) {
+	colors, ok := themePresets[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme preset %q", name)
+	}
+	return NewTheme(colors), nil
+}
+
+// NewTheme builds a theme from colours.
+func NewTheme(c ThemeColors) Theme {
+	col := lipgloss.Color
+
+	return Theme{
+		NormalModeStyle: lipgloss.NewStyle().
+			Background(col(c.NormalMode)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		InsertModeStyle: lipgloss.NewStyle().
+			Background(col(c.InsertMode)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		VisualModeStyle: lipgloss.NewStyle().
+			Background(col(c.VisualMode)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		CommandModeStyle: lipgloss.NewStyle().
+			Background(col(c.CommandMode)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		SearchModeStyle: lipgloss.NewStyle().
+			Background(col(c.SearchMode)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		// Status and command line
+		StatusLineStyle: lipgloss.NewStyle().
+			Background(col(c.StatusLine)).
+			Foreground(col(c.Text)),
+
+		CommandLineStyle: lipgloss.NewStyle().
+			Background(col(c.Background)).
+			Foreground(col(c.Text)),
+
+		// Messages and errors
+		MessageStyle: lipgloss.NewStyle().
+			Foreground(col(c.Message)),
+
+		ErrorStyle: lipgloss.NewStyle().
+			Foreground(col(c.Error)).
+			Bold(true),
+
+		// Line numbers
+		LineNumberStyle: lipgloss.NewStyle().
+			Foreground(col(c.LineNumber)).
+			Width(4).
+			Align(lipgloss.Right),
+
+		CurrentLineNumberStyle: lipgloss.NewStyle().
+			Foreground(col(c.Text)).
+			Width(4).
+			Align(lipgloss.Right).
+			Bold(true),
+
+		// Current line highlight (subtle)
+		CurrentLineStyle: lipgloss.NewStyle().
+			Background(col(c.CurrentLine)),
+
+		// Selection highlighting
+		SelectionStyle: lipgloss.NewStyle().
+			Background(col(c.Selection)),
+
+		// Yank highlight (brief flash effect)
+		HighlightYankStyle: lipgloss.NewStyle().
+			Background(col(c.Yank)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		// Search highlighting
+		SearchHighlightStyle: lipgloss.NewStyle().
+			Background(col(c.SearchMode)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		SearchInputPromptStyle: lipgloss.NewStyle().
+			Foreground(col(c.SearchMode)).
+			Bold(true),
+
+		SearchInputTextStyle: lipgloss.NewStyle().
+			Foreground(col(c.Text)),
+
+		SearchInputCursorStyle: lipgloss.NewStyle().
+			Foreground(col(c.SearchMode)),
+
+		// Placeholder text
+		PlaceholderStyle: lipgloss.NewStyle().
+			Foreground(col(c.Placeholder)).
+			Italic(true),
+
+		// IME composition text
+		PreeditStyle: lipgloss.NewStyle().
+			Foreground(col(c.Text)).
+			Underline(true),
+
+		// Read-only text
+		ProtectedStyle: lipgloss.NewStyle().
+			Faint(true),
+
+		CompletionMenuItemStyle: lipgloss.NewStyle().
+			Padding(0, 1),
+
+		CompletionMenuSelectedItemStyle: lipgloss.NewStyle().
+			Background(col(c.Selection)).
+			Padding(0, 1).
+			Bold(true),
+
+		CompletionMenuBorderStyle: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(col(c.LineNumber)).
+			Padding(0),
+
+		CompletionMenuLabelStyle: lipgloss.NewStyle().
+			Foreground(col(c.Accent)).
+			Bold(true),
+
+		CompletionMenuTypeStyle: lipgloss.NewStyle().
+			Foreground(col(c.Secondary)),
+
+		// Quickfix panel
+		QuickfixTitleStyle: lipgloss.NewStyle().
+			Background(col(c.StatusLine)).
+			Foreground(col(c.Text)).
+			Bold(true),
+
+		QuickfixItemStyle: lipgloss.NewStyle().
+			Foreground(col(c.Text)),
+
+		QuickfixSelectedItemStyle: lipgloss.NewStyle().
+			Background(col(c.Selection)).
+			Foreground(col(c.Text)),
+
+		// Command palette
+		PaletteBorderStyle: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(col(c.Secondary)).
+			Background(col(c.Panel)),
+
+		PaletteInputStyle: lipgloss.NewStyle().
+			Background(col(c.Panel)).
+			Foreground(col(c.Text)).
+			Bold(true),
+
+		PaletteItemStyle: lipgloss.NewStyle().
+			Background(col(c.Panel)).
+			Foreground(col(c.SubtleText)),
+
+		PaletteSelectedItemStyle: lipgloss.NewStyle().
+			Background(col(c.Selection)).
+			Foreground(col(c.Text)),
+
+		// Jump labels
+		JumpLabelStyle: lipgloss.NewStyle().
+			Background(col(c.Error)).
+			Foreground(col(c.Background)).
+			Bold(true),
+
+		// Overlays registered with RegisterOverlay
+		OverlayBorderStyle: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(col(c.Border)).
+			Foreground(col(c.Text)).
+			Padding(0, 1),
+	}
+}
+
+// ThemeFromChromaStyle builds a theme whose colours come from the Chroma
+// style with the given name (e.g. "monokai"), so that the editor matches the
+// syntax highlighting: the background and text of the style, with the mode
+// and accent colours taken from its keywords, functions, strings and so on.
+func ThemeFromChromaStyle(name string) (Theme, error) {
+	style, ok := styles.Registry[strings.ToLower(name)]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown chroma style %q", name)
+	}
+	return NewTheme(chromaThemeColors(style)), nil
+}
+
+// chromaThemeColors derives theme colours from a Chroma style. Surfaces are
+// the background made lighter on dark styles and darker on light ones.
+func chromaThemeColors(style *chroma.Style) ThemeColors {
+	base := style.Get(chroma.Background)
+	background, text := base.Background, base.Colour
+	if !background.IsSet() {
+		background = chroma.MustParseColour("#ffffff")
+	}
+	if !text.IsSet() {
+		text = chroma.MustParseColour("#000000")
+		if background.Brightness() < 0.5 {
+			text = chroma.MustParseColour("#ffffff")
+		}
+	}
+
+	// colour returns the colour of the first token type the style colours
+	// differently from plain text.
+	colour := func(fallback string, types ...chroma.TokenType) string {
+		for _, t := range types {
+			if c := style.Get(t).Colour; c.IsSet() && c != text {
+				return c.String()
+			}
+		}
+		return fallback
+	}
+
+	panel := background.Brighten(-0.15)
+	if background.Brightness() < 0.1 {
+		panel = background.Brighten(0.03)
+	}
+
+	currentLine := style.Get(chroma.LineHighlight).Background
+	if !currentLine.IsSet() || currentLine == background {
+		currentLine = background.BrightenOrDarken(0.05)
+	}
+
+	comment := colour(text.BrightenOrDarken(0.4).String(), chroma.Comment)
+	keyword := colour("#5f87d7", chroma.Keyword)
+	function := colour(keyword, chroma.NameFunction, chroma.NameClass)
+	str := colour("#87af5f", chroma.LiteralString)
+	number := colour("#d7875f", chroma.LiteralNumber, chroma.NameConstant)
+	builtin := colour(function, chroma.NameBuiltin, chroma.KeywordType)
+
+	return ThemeColors{
+		Background:  background.String(),
+		Panel:       panel.String(),
+		StatusLine:  background.BrightenOrDarken(0.1).String(),
+		Selection:   background.BrightenOrDarken(0.2).String(),
+		CurrentLine: currentLine.String(),
+		LineNumber:  colour(comment, chroma.LineNumbers),
+		Placeholder: comment,
+		Text:        text.String(),
+		SubtleText:  text.BrightenOrDarken(0.25).String(),
+		NormalMode:  function,
+		InsertMode:  keyword,
+		VisualMode:  builtin,
+		CommandMode: number,
+		SearchMode:  colour(number, chroma.LiteralStringEscape, chroma.NameAttribute, chroma.LiteralNumber),
+		Yank:        colour(function, chroma.NameTag, chroma.NameBuiltin),
+		Message:     str,
+		Error:       colour("#d75f5f", chroma.Error, chroma.GenericDeleted, chroma.NameException),
+		Accent:      keyword,
+		Secondary:   builtin,
+		Border:      keyword,
+	}
+}
+
+// LoadTheme reads a theme file and builds a theme from it. The file is JSON
+// or TOML and maps the keys of ThemeColors (the json tags, e.g. "status_line")
+// to colours. Keys left out keep the colours of the dark Catppuccin theme, or
+// of the built-in theme named by a "preset" key. For example:
+//
+//	preset = "nord"
+//	background = "#242933"
+//	error = "#ff6c6b"
+//
+// Only TOML's top level "key = value" lines are understood, which is all a
+// theme file needs.
+func LoadTheme(r io.Reader) (Theme, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var values map[string]string
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &values); err != nil {
+			return Theme{}, fmt.Errorf("theme: %w", err)
+		}
+	} else if values, err = parseThemeTOML(data); err != nil {
+		return Theme{}, err
+	}
+
+	colors := CatppuccinMocha
+	if preset, ok := values["preset"]; ok {
+		if colors, ok = themePresets[preset]; !ok {
+			return Theme{}, fmt.Errorf("theme: unknown preset %q", preset)
+		}
+		delete(values, "preset")
+	}
+
+	fields := reflect.ValueOf(&colors).Elem()
+	for key, value := range values {
+		i := themeColorField(key)
+		if i < 0 {
+			return Theme{}, fmt.Errorf("theme: unknown key %q", key)
+		}
+		if !isThemeColor(value) {
+			return Theme{}, fmt.Errorf("theme: %s: invalid colour %q", key, value)
+		}
+		fields.Field(i).SetString(value)
+	}
+
+	return NewTheme(colors), nil
+}
+
+// themeColorField returns the index of the ThemeColors field with the json tag
+// key, or -1.
+func themeColorField(key string) int {
+	t := reflect.TypeFor[ThemeColors]()
+	for i := range t.NumField() {
+		if t.Field(i).Tag.Get("json") == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// isThemeColor reports whether s is a hex colour (#rgb or #rrggbb) or an ANSI
+// colour number (0-255).
+func isThemeColor(s string) bool {
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if len(hex) != 3 && len(hex) != 6 {
+			return false
+		}
+		_, err := strconv.ParseUint(hex, 16, 32)
+		return err == nil
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// parseThemeTOML reads the "key = value" lines of a TOML theme file. Values
+// are strings, quoted or not; comments start with #.
+func parseThemeTOML(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("theme: line %d: expected key = value", n)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'"):
+			quote := value[:1]
+			end := strings.Index(value[1:], quote)
+			if end < 0 {
+				return nil, fmt.Errorf("theme: line %d: unterminated string", n)
+			}
+			value = value[1 : end+1]
+		default:
+			// A bare value, e.g. an ANSI colour number, up to a comment
+			value, _, _ = strings.Cut(value, "#")
+			value = strings.TrimSpace(value)
+		}
+
+		values[key] = value
+	}
+
+	return values, scanner.Err()
+}
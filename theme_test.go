@@ -0,0 +1,135 @@
+package goeditor
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPresetTheme tests building the built-in themes by name.
+func TestPresetTheme(t *testing.T) {
+	for _, name := range []string{"catppuccin", "catppuccin-latte", "gruvbox", "gruvbox-light", "nord"} {
+		t.Run(name, func(t *testing.T) {
+			theme, err := PresetTheme(name)
+			assert.NoError(t, err)
+			assert.Equal(t, lipgloss.Color(themePresets[name].Background), theme.CommandLineStyle.GetBackground())
+		})
+	}
+
+	_, err := PresetTheme("solarized")
+	assert.Error(t, err)
+}
+
+// TestThemeFromChromaStyle tests deriving a theme from a Chroma style.
+func TestThemeFromChromaStyle(t *testing.T) {
+	t.Run("takes the background and text of the style", func(t *testing.T) {
+		theme, err := ThemeFromChromaStyle("gruvbox")
+		assert.NoError(t, err)
+		assert.Equal(t, lipgloss.Color("#282828"), theme.CommandLineStyle.GetBackground())
+		assert.Equal(t, lipgloss.Color("#ebdbb2"), theme.CommandLineStyle.GetForeground())
+		// Keyword colour
+		assert.Equal(t, lipgloss.Color("#fe8019"), theme.InsertModeStyle.GetBackground())
+	})
+
+	t.Run("every colour is valid for light and dark styles", func(t *testing.T) {
+		for _, name := range []string{"monokai", "github", "dracula", "solarized-light", "nord"} {
+			style, err := ThemeFromChromaStyle(name)
+			assert.NoError(t, err, name)
+			assert.NotNil(t, style.StatusLineStyle.GetBackground(), name)
+		}
+		for _, name := range []string{"monokai", "github"} {
+			colors := chromaThemeColors(styles.Registry[name])
+			for _, c := range []string{colors.Background, colors.StatusLine, colors.Selection, colors.Text, colors.NormalMode, colors.Error} {
+				assert.True(t, isThemeColor(c), "%s: %q", name, c)
+			}
+			assert.NotEqual(t, colors.Background, colors.StatusLine, name)
+		}
+	})
+
+	t.Run("an unknown style is an error", func(t *testing.T) {
+		_, err := ThemeFromChromaStyle("no-such-style")
+		assert.Error(t, err)
+	})
+}
+
+// TestLoadTheme tests reading JSON and TOML theme files.
+func TestLoadTheme(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		theme, err := LoadTheme(strings.NewReader(`{"background": "#101010", "error": "9"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, lipgloss.Color("#101010"), theme.CommandLineStyle.GetBackground())
+		assert.Equal(t, lipgloss.Color("9"), theme.ErrorStyle.GetForeground())
+		// Keys left out keep the default colours
+		assert.Equal(t, lipgloss.Color(CatppuccinMocha.Text), theme.CommandLineStyle.GetForeground())
+	})
+
+	t.Run("TOML with a preset", func(t *testing.T) {
+		theme, err := LoadTheme(strings.NewReader(`# My theme
+preset = "nord"
+background = "#242933" # darker
+'status_line' = '#333a47'
+error = 1
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, lipgloss.Color("#242933"), theme.CommandLineStyle.GetBackground())
+		assert.Equal(t, lipgloss.Color("#333a47"), theme.StatusLineStyle.GetBackground())
+		assert.Equal(t, lipgloss.Color("1"), theme.ErrorStyle.GetForeground())
+		assert.Equal(t, lipgloss.Color(Nord.Text), theme.CommandLineStyle.GetForeground())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for name, input := range map[string]string{
+			"unknown key":       `colour = "#fff"`,
+			"invalid colour":    `text = "blue"`,
+			"unknown preset":    `preset = "solarized"`,
+			"tables":            "[colors]\ntext = \"#fff\"",
+			"unterminated":      `text = "#fff`,
+			"malformed JSON":    `{"text": }`,
+			"not key and value": "text",
+		} {
+			_, err := LoadTheme(strings.NewReader(input))
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+// TestStylePrecedence tests merging the styles that apply to a character.
+func TestStylePrecedence(t *testing.T) {
+	syntax := lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Italic(true)
+	selection := lipgloss.NewStyle().Background(lipgloss.Color("2"))
+	search := lipgloss.NewStyle().Background(lipgloss.Color("3")).Foreground(lipgloss.Color("4"))
+
+	var layers styleLayers
+	layers.add(StyleSyntax, syntax)
+	layers.add(StyleSelection, selection)
+
+	t.Run("properties come from the highest style that sets them", func(t *testing.T) {
+		style := layers.merge(DefaultStylePrecedence)
+		assert.Equal(t, lipgloss.Color("1"), style.GetForeground())
+		assert.Equal(t, lipgloss.Color("2"), style.GetBackground())
+		assert.True(t, style.GetItalic())
+
+		searched := layers
+		searched.add(StyleSearch, search)
+		style = searched.merge(DefaultStylePrecedence)
+		assert.Equal(t, lipgloss.Color("4"), style.GetForeground())
+		assert.Equal(t, lipgloss.Color("3"), style.GetBackground())
+	})
+
+	t.Run("a custom order", func(t *testing.T) {
+		searched := layers
+		searched.add(StyleSearch, search)
+		style := searched.merge([]StyleLayer{StyleSyntax, StyleSelection, StyleSearch})
+		assert.Equal(t, lipgloss.Color("1"), style.GetForeground())
+		assert.Equal(t, lipgloss.Color("2"), style.GetBackground())
+	})
+
+	t.Run("layers left out are not drawn", func(t *testing.T) {
+		style := layers.merge([]StyleLayer{StyleSelection})
+		assert.Equal(t, lipgloss.NoColor{}, style.GetForeground())
+		assert.False(t, style.GetItalic())
+	})
+}
@@ -0,0 +1,48 @@
+package goeditor
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ionut-t/goeditor/core"
+)
+
+// timeoutMsg tells the editor that the keys pending after the key with
+// sequence number seq waited TimeoutLen for another.
+type timeoutMsg struct {
+	seq int
+}
+
+// SetTimeoutLen sets how long keys waiting for more, such as a count, an
+// operator or f waiting for its character, wait for the next key before
+// they are dropped, as Vim's timeoutlen and :set timeoutlen. 0, the
+// default, waits forever.
+//
+// Vim's ttimeoutlen, how long an Escape waits for the rest of a key code,
+// has no counterpart: Bubble Tea reads the key codes before the editor
+// gets the keys.
+func (m *Model) SetTimeoutLen(timeout time.Duration) {
+	m.editor.Options().SetTimeoutLen(timeout)
+}
+
+// timeoutPendingKeys starts timing the keys pending after the key the
+// editor just handled, returning the command that tells it when they time
+// out, or nil if none are pending or they wait forever.
+func (m *Model) timeoutPendingKeys() tea.Cmd {
+	m.keySeq++
+	timeout := m.editor.GetState().TimeoutLen
+	if timeout <= 0 || !m.editor.HasPendingKeys() {
+		return nil
+	}
+	seq := m.keySeq
+	return tea.Tick(timeout, func(time.Time) tea.Msg { return timeoutMsg{seq: seq} })
+}
+
+// handleTimeout drops the pending keys, unless a key came after them.
+func (m *Model) handleTimeout(msg timeoutMsg) {
+	if msg.seq != m.keySeq || !m.editor.HasPendingKeys() {
+		return
+	}
+	m.editor.HandleKey(core.KeyEvent{Key: core.KeyTimeout})
+	m.dirty |= needsRender
+}
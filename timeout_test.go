@@ -0,0 +1,44 @@
+package goeditor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTimeoutLen tests dropping pending keys that waited too long.
+func TestTimeoutLen(t *testing.T) {
+	const content = "one two"
+
+	t.Run("pending keys are dropped when they time out", func(t *testing.T) {
+		m := newTestModel(content, withTimeoutLen(time.Second), withListening())
+		m = typeText(m, "d")
+		assert.NotNil(t, m.timeoutPendingKeys(), "pending keys are timed")
+
+		m, _ = m.update(timeoutMsg{seq: m.keySeq})
+		assert.False(t, m.GetEditor().HasPendingKeys())
+
+		m = typeText(m, "w")
+		assert.Equal(t, "one two", m.GetCurrentContent())
+	})
+
+	t.Run("a key coming before the timeout restarts it", func(t *testing.T) {
+		m := newTestModel(content, withTimeoutLen(time.Second), withListening())
+		m = typeText(m, "d")
+		seq := m.keySeq
+		m = typeText(m, "2")
+
+		m, _ = m.update(timeoutMsg{seq: seq})
+		assert.Equal(t, "d2", m.GetEditor().GetState().PendingKeys)
+	})
+
+	t.Run("nothing is timed without pending keys or a timeout", func(t *testing.T) {
+		m := newTestModel(content, withTimeoutLen(time.Second), withListening())
+		assert.Nil(t, m.timeoutPendingKeys())
+
+		m = newTestModel(content, withTimeoutLen(0), withListening())
+		m = typeText(m, "d")
+		assert.Nil(t, m.timeoutPendingKeys())
+	})
+}
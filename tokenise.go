@@ -0,0 +1,99 @@
+package goeditor
+
+import (
+	"slices"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/ionut-t/goeditor/highlighter"
+)
+
+// TokensReadyMsg is sent when syntax highlighting computed in the background
+// is ready. The model stores the tokens and redraws the highlighted lines.
+type TokensReadyMsg struct {
+	highlighter *highlighter.Highlighter
+	request     *tokenRequest
+	task        *task
+	tokens      map[int][]chroma.Token
+	err         error
+}
+
+// tokenRequest is a range of lines to tokenise in the background.
+type tokenRequest struct {
+	revision  uint64 // Buffer revision of the lines
+	startLine int
+	endLine   int
+	lines     []string // Copy of the lines from startLine up to endLine
+}
+
+// SetAsyncHighlighting sets whether syntax highlighting is computed in a
+// background goroutine. While it runs, the lines waiting for it are drawn
+// without colours until a TokensReadyMsg brings them, and work for content
+// that has changed again is cancelled. Enabled by default; when disabled,
+// lines are tokenised while rendering.
+func (m *Model) SetAsyncHighlighting(enabled bool) {
+	m.asyncHighlighting = enabled
+}
+
+// tokenise makes sure the lines from startLine up to endLine have tokens,
+// tokenising them now or asking for it in the background.
+func (m *Model) tokenise(lines []string, startLine, endLine int) {
+	if !m.asyncHighlighting || m.highlighter.IsCached(startLine, endLine) {
+		m.highlighter.Tokenise(lines, startLine, endLine)
+		return
+	}
+
+	revision := m.editor.GetBuffer().Revision()
+	for _, request := range []*tokenRequest{m.pendingTokens, m.runningTokens} {
+		if request != nil && request.revision == revision &&
+			request.startLine == startLine && request.endLine == endLine {
+			return
+		}
+	}
+
+	m.pendingTokens = &tokenRequest{
+		revision:  revision,
+		startLine: startLine,
+		endLine:   endLine,
+		lines:     slices.Clone(lines[startLine:endLine]),
+	}
+}
+
+// tokeniseCmd starts the background tokenisation asked for while rendering,
+// cancelling the one still running.
+func (m *Model) tokeniseCmd() tea.Cmd {
+	request := m.pendingTokens
+	if request == nil || m.highlighter == nil {
+		return nil
+	}
+	m.pendingTokens = nil
+	m.runningTokens = request
+
+	h := m.highlighter
+	return m.tasks.start(taskTokenise, request.revision, func(t *task) tea.Msg {
+		tokens, err := h.TokeniseLines(t.ctx, request.lines, request.startLine)
+		return TokensReadyMsg{highlighter: h, request: request, task: t, tokens: tokens, err: err}
+	})
+}
+
+// cancelTokenise stops the background tokenisation, when the highlighter
+// changes.
+func (m *Model) cancelTokenise() {
+	m.tasks.cancel(taskTokenise)
+	m.runningTokens = nil
+}
+
+// handleTokensReady stores the tokens of a finished background tokenisation,
+// unless the content or the highlighter changed since it was asked for.
+func (m *Model) handleTokensReady(msg TokensReadyMsg) {
+	current := m.tasks.finish(msg.task)
+	if msg.request == m.runningTokens {
+		m.runningTokens = nil
+	}
+	if !current || msg.err != nil || msg.highlighter != m.highlighter || msg.request.revision != m.editor.GetBuffer().Revision() {
+		return
+	}
+
+	m.highlighter.SetTokens(msg.tokens)
+	m.renderVisibleSlice()
+}
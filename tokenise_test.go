@@ -0,0 +1,91 @@
+package goeditor
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAsyncHighlighting tests tokenising in the background.
+func TestAsyncHighlighting(t *testing.T) {
+	const content = "package main\n\nfunc main() {}"
+
+	t.Run("draws plain text until the tokens are ready", func(t *testing.T) {
+		m := newTestModel(content, withLanguage("go", "monokai"), withRendered())
+		assert.Empty(t, m.persistentTokenCache)
+
+		cmd := m.tokeniseCmd()
+		assert.NotNil(t, cmd)
+		msg := cmd()
+		assert.IsType(t, TokensReadyMsg{}, msg)
+
+		m, _ = m.Update(msg)
+		assert.True(t, m.highlighter.IsCached(0, 3))
+		assert.NotEmpty(t, m.persistentTokenCache[2])
+	})
+
+	t.Run("Update starts the work asked for", func(t *testing.T) {
+		m := newTestModel(content, withLanguage("go", "monokai"), withRendered())
+		_, cmd := m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("the same range is asked for once", func(t *testing.T) {
+		m := newTestModel(content, withLanguage("go", "monokai"), withRendered())
+		cmd := m.tokeniseCmd()
+		m.renderVisibleSlice()
+		assert.Nil(t, m.pendingTokens)
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("tokens for old content are dropped", func(t *testing.T) {
+		m := newTestModel(content, withLanguage("go", "monokai"), withRendered())
+		cmd := m.tokeniseCmd()
+		m.SetContent("package other")
+		m.renderVisibleSlice()
+
+		msg := cmd()
+		m, cmd = m.Update(msg)
+		assert.False(t, m.highlighter.IsCached(0, 1))
+		// The new content is tokenised instead
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("a content change cancels the running work", func(t *testing.T) {
+		m := newTestModel(content, withLanguage("go", "monokai"), withRendered())
+		cmd := m.tokeniseCmd()
+		m.SetContent("package other")
+
+		assert.Nil(t, m.runningTokens)
+		msg := cmd().(TokensReadyMsg)
+		assert.Error(t, msg.err)
+	})
+
+	t.Run("synchronous highlighting", func(t *testing.T) {
+		m := New(40, 10)
+		m.SetAsyncHighlighting(false)
+		m.SetLanguage("go", "monokai")
+		m.SetContent("package main")
+		m.renderVisibleSlice()
+		assert.Nil(t, m.tokeniseCmd())
+		assert.NotEmpty(t, m.persistentTokenCache[0])
+	})
+}
+
+// TestSetLanguageFromFilename tests picking the language of the file from
+// its name and content.
+func TestSetLanguageFromFilename(t *testing.T) {
+	m := New(40, 10)
+	m.SetLanguage("go", "monokai")
+	assert.Equal(t, "yaml", m.SetLanguageFromFilename("config/app.yml"))
+	assert.Equal(t, "yaml", m.language)
+	assert.Equal(t, "monokai", m.highlighterTheme)
+
+	m.SetContent("#!/usr/bin/env python3\nprint('hi')")
+	assert.Equal(t, "python", m.SetLanguageFromFilename("bin/tool"))
+
+	m.SetContent("just some words")
+	assert.Equal(t, "", m.SetLanguageFromFilename("notes.txt"))
+	assert.Nil(t, m.highlighter)
+}
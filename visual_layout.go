@@ -1,28 +1,43 @@
 package goeditor
 
 import (
-	"image/color"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
 	"github.com/ionut-t/goeditor/core"
 	"github.com/ionut-t/goeditor/highlighter"
 	"github.com/rivo/uniseg"
 )
 
+// defaultTabWidth is the columns between tab stops when the editor's tab
+// width is 0.
+const defaultTabWidth = 4
+
+// tabStopWidth returns the columns a tab at col takes, up to the next tab
+// stop, the default ones if tabWidth is 0.
+func tabStopWidth(col, tabWidth int) int {
+	if tabWidth <= 0 {
+		tabWidth = defaultTabWidth
+	}
+	return tabWidth - col%tabWidth
+}
+
 // getVisualWidth calculates the visual width of a string, properly handling
 // grapheme clusters (e.g., emojis with variation selectors, combining characters) and tabs.
-// Tabs are expanded to the next tab stop (multiples of 4).
+// Tabs are expanded to the next default tab stop (multiples of 4).
 func getVisualWidth(s string) int {
-	return getVisualWidthAt(s, 0)
+	return getVisualWidthAt(s, 0, defaultTabWidth)
 }
 
 // getVisualWidthAt calculates the visual width of a string starting at a given column position.
-// This is necessary for proper tab width calculation, as tabs expand to the next tab stop.
-func getVisualWidthAt(s string, startCol int) int {
-	const tabWidth = 4
+// This is necessary for proper tab width calculation, as tabs expand to the next tab stop,
+// every tabWidth columns.
+func getVisualWidthAt(s string, startCol, tabWidth int) int {
 	width := 0
 	currentCol := startCol
 	gr := uniseg.NewGraphemes(s)
@@ -30,7 +45,7 @@ func getVisualWidthAt(s string, startCol int) int {
 		grapheme := gr.Str()
 		if grapheme == "\t" {
 			// Calculate spaces needed to reach next tab stop
-			spacesToNextTabStop := tabWidth - (currentCol % tabWidth)
+			spacesToNextTabStop := tabStopWidth(currentCol, tabWidth)
 			width += spacesToNextTabStop
 			currentCol += spacesToNextTabStop
 		} else {
@@ -63,26 +78,51 @@ func getRuneVisualWidth(r rune) int {
 	return uniseg.StringWidth(string(r))
 }
 
+// printableASCII holds the characters from space to tilde, to return them
+// as strings without allocating.
+var printableASCII = func() string {
+	var b strings.Builder
+	for r := ' '; r <= '~'; r++ {
+		b.WriteRune(r)
+	}
+	return b.String()
+}()
+
+// graphemeWindow is how many runes nextGrapheme looks at first for a
+// grapheme, more than all but contrived ones take.
+const graphemeWindow = 32
+
 // nextGrapheme returns the next grapheme cluster starting at the given rune index.
 // Returns the grapheme string, its visual width, and the number of runes consumed.
 // This centralises grapheme iteration logic to eliminate redundancy across rendering functions.
-// The currentCol parameter is used for proper tab width calculation.
-func nextGrapheme(runes []rune, startIdx int, currentCol int) (graphemeStr string, visualWidth int, runesConsumed int) {
-	const tabWidth = 4
-
+// The currentCol and tabWidth parameters are used for proper tab width calculation.
+// Only the runes up to graphemeWindow past startIdx are looked at, so that
+// going through a long line takes time in proportion to its length.
+func nextGrapheme(runes []rune, startIdx, currentCol, tabWidth int) (graphemeStr string, visualWidth int, runesConsumed int) {
 	if startIdx >= len(runes) {
 		return "", 0, 0
 	}
 
-	// Use uniseg to properly identify the grapheme cluster boundary
-	remaining := string(runes[startIdx:])
-	gr := uniseg.NewGraphemes(remaining)
+	// Printable ASCII followed by ASCII is a grapheme of its own
+	if r := runes[startIdx]; r >= ' ' && r <= '~' && (startIdx+1 == len(runes) || runes[startIdx+1] < utf8.RuneSelf) {
+		return printableASCII[r-' ' : r-' '+1], 1, 1
+	}
+
+	// Use uniseg to properly identify the grapheme cluster boundary, in a
+	// window of the line grown for graphemes filling it
+	end := min(len(runes), startIdx+graphemeWindow)
+	gr := uniseg.NewGraphemes(string(runes[startIdx:end]))
+	for gr.Next() && end < len(runes) && len([]rune(gr.Str())) == end-startIdx {
+		end = min(len(runes), startIdx+2*(end-startIdx))
+		gr = uniseg.NewGraphemes(string(runes[startIdx:end]))
+	}
+	gr.Reset()
 
 	if !gr.Next() {
 		// Fallback: treat single rune as grapheme if uniseg fails
 		graphemeStr = string(runes[startIdx])
 		if graphemeStr == "\t" {
-			visualWidth = tabWidth - (currentCol % tabWidth)
+			visualWidth = tabStopWidth(currentCol, tabWidth)
 		} else {
 			visualWidth = getRuneVisualWidth(runes[startIdx])
 		}
@@ -92,7 +132,7 @@ func nextGrapheme(runes []rune, startIdx int, currentCol int) (graphemeStr strin
 	graphemeStr = gr.Str()
 	if graphemeStr == "\t" {
 		// Tab width depends on current column position
-		visualWidth = tabWidth - (currentCol % tabWidth)
+		visualWidth = tabStopWidth(currentCol, tabWidth)
 	} else {
 		visualWidth = uniseg.StringWidth(graphemeStr)
 	}
@@ -112,8 +152,31 @@ func (m *Model) calculateCursorScreenCol(vli VisualLineInfo, lineNumWidth int) i
 	}
 
 	substringToCursor := string(segmentRunes[0:visualColInSegmentRuneOffset])
-	visualColInSegmentWidth := getVisualWidth(substringToCursor)
-	return lineNumWidth + visualColInSegmentWidth
+	visualColInSegmentWidth := vli.prefixWidth() + m.layoutOptions.width(substringToCursor)
+	return lineNumWidth + visualColInSegmentWidth + m.virtualCols(lineNumWidth+visualColInSegmentWidth)
+}
+
+// virtualCols returns the columns between the end of the line, drawn up to
+// screenCol, and a cursor past it, as far as the right edge.
+func (m *Model) virtualCols(screenCol int) int {
+	return max(0, min(m.cursorVirtualCols, m.viewport.Width()-1-screenCol))
+}
+
+// renderGutterSign returns the sign for the first segment of a line, or the
+// separator space that normally follows the line number.
+func (m *Model) renderGutterSign(vli VisualLineInfo) string {
+	if !vli.IsFirstSegment || m.accessibility.enabled {
+		return " "
+	}
+
+	sign, ok := m.gutterSigns[vli.LogicalRow]
+	if !ok || sign.Text == "" {
+		return " "
+	}
+
+	gr := uniseg.NewGraphemes(sign.Text)
+	gr.Next()
+	return sign.Style.Render(gr.Str())
 }
 
 type VisualLineInfo struct {
@@ -121,24 +184,91 @@ type VisualLineInfo struct {
 	LogicalRow      int
 	LogicalStartCol int
 	IsFirstSegment  bool
+	Prefix          string // Drawn before Content on the display lines continuing a line: the break indent and showbreak
+}
+
+// prefixWidth returns the columns Prefix takes before the content.
+func (vli VisualLineInfo) prefixWidth() int {
+	if vli.Prefix == "" {
+		return 0
+	}
+	return getVisualWidth(vli.Prefix)
+}
+
+// minBreakIndentWidth is the least width left for the text of the display
+// lines continuing a line for breakindent to indent them, as in Vim.
+const minBreakIndentWidth = 20
+
+// layoutOptions is how wide tabs are and how wrapping marks the display
+// lines continuing a line, as the tabstop, showbreak and breakindent options
+// set.
+type layoutOptions struct {
+	tabWidth    int
+	showBreak   string
+	breakIndent bool
+}
+
+// layoutOptionsOf returns how state lays lines out.
+func layoutOptionsOf(state core.State) layoutOptions {
+	return layoutOptions{tabWidth: state.TabWidth, showBreak: state.ShowBreak, breakIndent: state.BreakIndent}
+}
+
+// width returns the columns s takes from the start of a display line.
+func (o layoutOptions) width(s string) int {
+	return getVisualWidthAt(s, 0, o.tabWidth)
+}
+
+// prefix returns what is drawn before the display lines continuing runes,
+// wrapped at width: the indent of the line with breakindent, unless that
+// leaves less than minBreakIndentWidth columns, followed by showbreak. It's
+// empty if that would leave no room for the text.
+func (o layoutOptions) prefix(runes []rune, width int) string {
+	prefix := o.showBreak
+	if o.breakIndent {
+		end := 0
+		for end < len(runes) && (runes[end] == ' ' || runes[end] == '\t') {
+			end++
+		}
+		indent := o.width(string(runes[:end]))
+		if indent > 0 && width-indent-getVisualWidth(prefix) >= minBreakIndentWidth {
+			prefix = strings.Repeat(" ", indent) + prefix
+		}
+	}
+	if prefix != "" && getVisualWidth(prefix) >= width {
+		return ""
+	}
+	return prefix
+}
+
+// drawsLineNumbers reports whether the gutter has line numbers: they're
+// shown, and absolute, relative or both.
+func (m *Model) drawsLineNumbers() bool {
+	state := m.editor.GetState()
+	return m.showLineNumbers && (state.Numbers || state.RelativeNumbers && !m.disableVimMode)
 }
 
 // calculateLineNumberWidth computes the width needed for line numbers
 func (m *Model) calculateLineNumberWidth(totalLines int) int {
-	if !m.showLineNumbers {
+	if !m.drawsLineNumbers() {
 		return 0
 	}
 
-	state := m.editor.GetState()
+	minWidth := m.editor.GetState().NumberWidth
+	if minWidth == 0 {
+		minWidth = 5
+	}
+
 	maxWidth := len(strconv.Itoa(max(1, totalLines)))
 
-	if state.RelativeNumbers && !m.disableVimMode {
+	// Room for relative numbers whether they're on or not, so that switching
+	// them doesn't move the text
+	if !m.disableVimMode {
 		relWidth := len(strconv.Itoa(max(1, m.viewport.Height())))
 		maxWidth = max(maxWidth, relWidth)
 	}
 
-	lineNumWidth := max(4, maxWidth) + 1
-	return min(lineNumWidth, 10)
+	lineNumWidth := max(minWidth, maxWidth+1)
+	return min(lineNumWidth, max(minWidth, 10))
 }
 
 // isPositionInSearchResult checks if a position is part of a search result
@@ -149,12 +279,12 @@ func (m *Model) isPositionInSearchResult(pos core.Position, col int) bool {
 		return false
 	}
 
-	results := m.editor.SearchResults()
+	results := m.editor.Display().SearchResults()
 	if len(results) == 0 {
 		return false
 	}
 
-	termLen := len(searchTerm)
+	termLen := core.SearchMatchLen(searchTerm)
 
 	// Binary search to find the first result with row >= pos.Row
 	left, right := 0, len(results)
@@ -326,6 +456,92 @@ func (m *Model) calculateFullVisualLayout(allLogicalLines []string, availableWid
 	m.fullVisualLayoutHeight = len(visualLayout)
 }
 
+// relayoutLines updates the layout after the removed lines from line from
+// were replaced by inserted lines, laying out only those and moving the
+// layout of the lines below. In lazy mode the layout drops lines from its
+// top when it grew, to cover as many lines as before, unless the cursor is
+// among them. It reports false when the layout doesn't cover the lines
+// replaced or they must be wrapped to another width, for
+// calculateVisualMetrics to lay them out afresh.
+func (m *Model) relayoutLines(from, removed, inserted int) bool {
+	allLogicalLines := m.editor.GetBuffer().GetLines()
+	totalLines := len(allLogicalLines)
+	delta := inserted - removed
+	previousLines := totalLines - delta
+
+	if len(m.visualLayoutCache) == 0 || from < m.visualLayoutCacheStartRow ||
+		m.visualLayoutCache[len(m.visualLayoutCache)-1].LogicalRow < from+removed-1 {
+		return false
+	}
+
+	lazy := totalLines > largeFileThreshold
+	if lazy != (previousLines > largeFileThreshold) || lazy && m.lastKnownLineCount != previousLines {
+		// Switching between lazy and full layout, or laid out for other content
+		return false
+	}
+
+	availableWidth := max(1, m.viewport.Width()-m.calculateLineNumberWidth(totalLines))
+	if state := m.editor.GetState(); availableWidth != state.AvailableWidth || layoutOptionsOf(state) != m.layoutOptions {
+		return false
+	}
+
+	findRow := func(row int) int {
+		idx := slices.IndexFunc(m.visualLayoutCache, func(vli VisualLineInfo) bool {
+			return vli.LogicalRow >= row
+		})
+		if idx < 0 {
+			return len(m.visualLayoutCache)
+		}
+		return idx
+	}
+	startIdx, endIdx := findRow(from), findRow(from+removed)
+
+	visualLayout := make([]VisualLineInfo, startIdx, len(m.visualLayoutCache)+inserted)
+	copy(visualLayout, m.visualLayoutCache[:startIdx])
+	for bufferRowIdx := from; bufferRowIdx < from+inserted; bufferRowIdx++ {
+		m.appendVisualLayoutForLine(bufferRowIdx, allLogicalLines[bufferRowIdx], availableWidth, &visualLayout)
+	}
+	visualDelta := len(visualLayout) - endIdx
+	for _, vli := range m.visualLayoutCache[endIdx:] {
+		vli.LogicalRow += delta
+		visualLayout = append(visualLayout, vli)
+	}
+
+	if !lazy {
+		m.visualLayoutCache = visualLayout
+		m.fullVisualLayoutHeight = len(visualLayout)
+		return true
+	}
+
+	// Anchors below the lines replaced move with them
+	anchors := make(map[int]int, len(m.visualRowAnchors))
+	for logicalRow, visualRow := range m.visualRowAnchors {
+		switch {
+		case logicalRow < from:
+			anchors[logicalRow] = visualRow
+		case logicalRow >= from+removed:
+			anchors[logicalRow+delta] = visualRow + visualDelta
+		}
+	}
+	m.visualRowAnchors = anchors
+	m.lastKnownLineCount = totalLines
+	m.fullVisualLayoutHeight += visualDelta
+	m.cacheValidEndRow = min(totalLines, m.cacheValidEndRow+delta)
+
+	startLine := m.visualLayoutCacheStartRow + max(0, delta)
+	if delta > 0 && m.editor.GetBuffer().GetCursor().Position.Row >= startLine {
+		drop := slices.IndexFunc(visualLayout, func(vli VisualLineInfo) bool {
+			return vli.LogicalRow >= startLine
+		})
+		visualLayout = visualLayout[drop:]
+		m.visualLayoutCacheStartRow = startLine
+		m.visualLayoutCacheStartVisualRow += drop
+		m.cacheValidStartRow = startLine
+	}
+	m.visualLayoutCache = visualLayout
+	return true
+}
+
 // calculateLazyVisualLayout computes layout only for visible region (large files)
 func (m *Model) calculateLazyVisualLayout(allLogicalLines []string, cursor core.Cursor, availableWidth int, viewportBuffer int) {
 	totalLines := len(allLogicalLines)
@@ -479,10 +695,8 @@ func (m *Model) calculateLazyVisualLayout(allLogicalLines []string, cursor core.
 // appendVisualLayoutForLine wraps a single logical line and appends to visual layout
 func (m *Model) appendVisualLayoutForLine(bufferRowIdx int, logicalLineContent string, availableWidth int, visualLayout *[]VisualLineInfo) {
 	originalLineRunes := []rune(logicalLineContent)
-	originalLineLen := len(originalLineRunes)
-	currentLogicalColToReport := 0
 
-	if originalLineLen == 0 && logicalLineContent == "" {
+	if len(originalLineRunes) == 0 {
 		*visualLayout = append(*visualLayout, VisualLineInfo{
 			Content:         "",
 			LogicalRow:      bufferRowIdx,
@@ -492,29 +706,52 @@ func (m *Model) appendVisualLayoutForLine(bufferRowIdx int, logicalLineContent s
 		return
 	}
 
-	wrappedSegmentStrings := wrapLine(logicalLineContent, availableWidth)
+	prefix := m.layoutOptions.prefix(originalLineRunes, availableWidth)
+	wrappedSegmentStrings := wrapRunesPrefixed(originalLineRunes, availableWidth, getVisualWidth(prefix), m.layoutOptions.tabWidth)
+	starts := segmentStarts(originalLineRunes, wrappedSegmentStrings)
 
 	for segIdx, segmentStr := range wrappedSegmentStrings {
-		segmentRunes := []rune(segmentStr)
-		segmentRunesLen := len(segmentRunes)
-
 		info := VisualLineInfo{
 			Content:         segmentStr,
 			LogicalRow:      bufferRowIdx,
-			LogicalStartCol: currentLogicalColToReport,
+			LogicalStartCol: starts[segIdx],
 			IsFirstSegment:  segIdx == 0,
 		}
+		if segIdx > 0 {
+			info.Prefix = prefix
+		}
 		*visualLayout = append(*visualLayout, info)
+	}
+}
 
-		currentLogicalColToReport += segmentRunesLen
-		if segIdx < len(wrappedSegmentStrings)-1 {
-			for currentLogicalColToReport < originalLineLen && unicode.IsSpace(originalLineRunes[currentLogicalColToReport]) {
-				currentLogicalColToReport++
+// segmentStarts returns the columns of runes the segments wrapRunes split it
+// into start at.
+func segmentStarts(runes []rune, segments []string) []int {
+	starts := make([]int, len(segments))
+	col := 0
+	for i, segment := range segments {
+		starts[i] = col
+		col += utf8.RuneCountInString(segment)
+		if i < len(segments)-1 {
+			// Skip the whitespace wrapRunes dropped at the break
+			for col < len(runes) {
+				grapheme, _, runesConsumed := nextGrapheme(runes, col, 0, defaultTabWidth)
+				if !isBlankGrapheme(grapheme) {
+					break
+				}
+				col += runesConsumed
 			}
 		}
 	}
+	return starts
 }
 
+// largeFileThreshold is the number of lines above which only the region
+// around the cursor is laid out. It's low enough to enable lazy mode for
+// medium files, so cache hysteresis and anchoring work for files of 100-1000
+// lines.
+const largeFileThreshold = 100
+
 // calculateVisualMetrics computes visual layout for visible lines only (lazy evaluation).
 func (m *Model) calculateVisualMetrics() {
 	buffer := m.editor.GetBuffer()
@@ -531,20 +768,14 @@ func (m *Model) calculateVisualMetrics() {
 	}
 
 	if state.AvailableWidth != availableWidth {
-		newState := m.editor.GetState()
-		newState.AvailableWidth = availableWidth
-		m.editor.SetState(newState)
+		m.editor.Display().SetViewportSize(state.ViewportWidth, state.ViewportHeight, availableWidth)
 	}
+	m.layoutOptions = layoutOptionsOf(state)
 
 	// ========================================================================
 	// >>> 1. LAZY VISUAL LAYOUT - Only compute viewport + buffer <<<
 	// ========================================================================
 
-	// For large files, only compute visible region instead of entire buffer
-	// Lowered threshold to 100 lines to enable lazy mode for medium files
-	// This allows cache hysteresis and anchoring to work for files 100-1000 lines
-	const largeFileThreshold = 100
-
 	// Adaptive buffer size: larger buffer for medium files reduces cache thrashing
 	viewportBuffer := 100 // Default for large files (>500 lines)
 	if totalLogicalLines >= largeFileThreshold && totalLogicalLines < 500 {
@@ -559,9 +790,16 @@ func (m *Model) calculateVisualMetrics() {
 		m.calculateFullVisualLayout(allLogicalLines, availableWidth)
 	}
 
-	// ========================================================================
-	// >>> 2. Find Cursor's Absolute Visual Row and Clamped Logical Column <<<
-	// ========================================================================
+	m.locateCursor()
+}
+
+// locateCursor finds the cursor's absolute visual row and clamped logical
+// column in the visual layout.
+func (m *Model) locateCursor() {
+	buffer := m.editor.GetBuffer()
+	cursor := buffer.GetCursor()
+	allLogicalLines := buffer.GetLines()
+
 	absoluteTargetVisualRow := -1
 	m.clampedCursorLogicalCol = cursor.Position.Col
 
@@ -570,8 +808,11 @@ func (m *Model) calculateVisualMetrics() {
 	if clampedCursorRow >= 0 && clampedCursorRow < len(allLogicalLines) {
 		lineContentRunes := []rune(allLogicalLines[clampedCursorRow])
 		m.clampedCursorLogicalCol = max(0, min(cursor.Position.Col, len(lineContentRunes)))
+		// With virtual editing the cursor can be past the end of the line
+		m.cursorVirtualCols = max(0, cursor.Position.Col-len(lineContentRunes))
 	} else {
 		m.clampedCursorLogicalCol = 0
+		m.cursorVirtualCols = 0
 	}
 
 	if m.fullVisualLayoutHeight == 0 {
@@ -626,280 +867,7 @@ func (m *Model) calculateVisualMetrics() {
 		absoluteTargetVisualRow = 0
 	}
 	m.cursorAbsoluteVisualRow = absoluteTargetVisualRow
-}
-
-// renderVisibleSliceDefault renders the calculated slice of the visual layout to the viewport.
-func (m *Model) renderVisibleSliceDefault() {
-	state := m.editor.GetState()
-	allLogicalLines := m.editor.GetBuffer().GetLines()
-
-	selectionStyle := m.theme.SelectionStyle
-	searchHighlightStyle := m.theme.SearchHighlightStyle
-
-	// Check if we're highlighting a yank operation
-	// Either from normal mode (YankSelection) or from visual mode (m.yanked flag)
-	if state.YankSelection != core.SelectionNone || m.yanked {
-		selectionStyle = m.theme.HighlightYankStyle
-	}
-
-	lineNumWidth := m.calculateLineNumberWidth(len(allLogicalLines))
-
-	var contentBuilder strings.Builder
-	renderedDisplayLineCount := 0
-
-	startRenderVisualRow := m.currentVisualTopLine
-	if m.fullVisualLayoutHeight == 0 {
-		startRenderVisualRow = 0
-	} else {
-		if startRenderVisualRow < 0 {
-			startRenderVisualRow = 0
-		}
-		maxTop := max(0, m.fullVisualLayoutHeight-m.viewport.Height())
-		if startRenderVisualRow > maxTop {
-			startRenderVisualRow = maxTop
-		}
-	}
-
-	endRenderVisualRow := min(startRenderVisualRow+m.viewport.Height(), m.fullVisualLayoutHeight)
-
-	targetVisualRowInSlice := -1
-	if m.cursorAbsoluteVisualRow >= startRenderVisualRow && m.cursorAbsoluteVisualRow < endRenderVisualRow {
-		targetVisualRowInSlice = m.cursorAbsoluteVisualRow - startRenderVisualRow
-	}
-
-	targetScreenColForCursor := -1
-	if m.fullVisualLayoutHeight > 0 && m.cursorAbsoluteVisualRow >= 0 && m.cursorAbsoluteVisualRow < m.fullVisualLayoutHeight {
-		// Convert absolute visual row to cache-relative index for cursor lookup
-		cursorCacheIdx := m.cursorAbsoluteVisualRow - m.visualLayoutCacheStartVisualRow
-		if cursorCacheIdx >= 0 && cursorCacheIdx < len(m.visualLayoutCache) {
-			vliAtCursor := m.visualLayoutCache[cursorCacheIdx]
-			targetScreenColForCursor = m.calculateCursorScreenCol(vliAtCursor, lineNumWidth)
-		} else if m.fullVisualLayoutHeight > 0 {
-			targetScreenColForCursor = lineNumWidth
-		}
-	} else if m.fullVisualLayoutHeight == 0 {
-		targetScreenColForCursor = lineNumWidth
-	}
-
-	clampedCursorRowForLineNumbers := m.clampCursorRow(m.editor.GetBuffer().GetCursor().Position.Row, len(allLogicalLines))
-
-	for absVisRowIdxToRender := startRenderVisualRow; absVisRowIdxToRender < endRenderVisualRow; absVisRowIdxToRender++ {
-		// Convert absolute visual row to cache-relative index
-		cacheIdx := absVisRowIdxToRender - m.visualLayoutCacheStartVisualRow
-		if cacheIdx < 0 || cacheIdx >= len(m.visualLayoutCache) {
-			break
-		}
-		vli := m.visualLayoutCache[cacheIdx]
-		currentSliceRow := renderedDisplayLineCount
-
-		if m.showLineNumbers {
-			lineNumStr := ""
-			currentLineNumberStyle := m.theme.LineNumberStyle
-			if vli.IsFirstSegment {
-				if state.RelativeNumbers && !m.disableVimMode && vli.LogicalRow != clampedCursorRowForLineNumbers {
-					relNum := vli.LogicalRow - clampedCursorRowForLineNumbers
-					if relNum < 0 {
-						relNum = -relNum
-					}
-					lineNumStr = strconv.Itoa(relNum)
-				} else {
-					lineNumStr = strconv.Itoa(vli.LogicalRow + 1)
-				}
-				if vli.LogicalRow == clampedCursorRowForLineNumbers {
-					currentLineNumberStyle = m.theme.CurrentLineNumberStyle
-				}
-			}
-			contentBuilder.WriteString(currentLineNumberStyle.Width(lineNumWidth-1).Render(lineNumStr) + " ")
-		}
-
-		segmentRunes := []rune(vli.Content)
-		styledSegment := strings.Builder{}
-		currentVisualCol := 0
-
-		charIdx := 0
-		segmentLen := len(segmentRunes)
-
-		// Check if this is the current line for background highlighting
-		isCurrentLine := vli.LogicalRow == clampedCursorRowForLineNumbers
-		var currentLineBackground color.Color
-		if isCurrentLine {
-			currentLineBackground = m.theme.CurrentLineStyle.GetBackground()
-		}
-
-		for charIdx < segmentLen {
-			currentLogicalCharCol := vli.LogicalStartCol + charIdx
-			currentBufferPos := core.Position{Row: vli.LogicalRow, Col: currentLogicalCharCol}
-
-			isSearchResult := m.isPositionInSearchResult(currentBufferPos, currentLogicalCharCol)
-
-			baseCharStyle := lipgloss.NewStyle()
-
-			// Apply current line background if this is the cursor line
-			if isCurrentLine {
-				baseCharStyle = baseCharStyle.Background(currentLineBackground)
-			}
-
-			charsToAdvance := 1
-
-			bestMatch := m.findHighlightedWordMatch(segmentRunes, charIdx)
-			bestMatchLen := bestMatch.length
-			bestMatchStyle := bestMatch.style
-
-			if bestMatchLen > 0 {
-				for k := range bestMatchLen {
-					idxInSegment := charIdx + k
-					chRuneToStyle := segmentRunes[idxInSegment]
-					logicalColForStyledChar := vli.LogicalStartCol + idxInSegment
-					posForStyledChar := core.Position{Row: vli.LogicalRow, Col: logicalColForStyledChar}
-
-					charSpecificRenderStyle := bestMatchStyle
-
-					// Apply current line background to highlighted words
-					if isCurrentLine {
-						charSpecificRenderStyle = charSpecificRenderStyle.Background(currentLineBackground)
-					}
-
-					selectionStatus := m.editor.GetSelectionStatus(posForStyledChar)
-					if selectionStatus != core.SelectionNone {
-						charSpecificRenderStyle = charSpecificRenderStyle.Background(selectionStyle.GetBackground())
-					}
-
-					currentScreenColForChar := lineNumWidth + currentVisualCol
-					isCursorOnThisChar := (currentSliceRow == targetVisualRowInSlice && currentScreenColForChar == targetScreenColForCursor)
-
-					if isCursorOnThisChar && m.isFocused && m.cursorVisible {
-						styledSegment.WriteString(m.getCursorStyles().Render(string(chRuneToStyle)))
-					} else {
-						styledSegment.WriteString(charSpecificRenderStyle.Render(string(chRuneToStyle)))
-					}
-					currentVisualCol += getRuneVisualWidth(chRuneToStyle)
-				}
-				charsToAdvance = bestMatchLen
-			} else {
-				// Get the next grapheme cluster using centralised helper
-				graphemeStr, graphemeWidth, runesConsumed := nextGrapheme(segmentRunes, charIdx, currentVisualCol)
-				charsToAdvance = runesConsumed
-
-				selectionStatus := m.editor.GetSelectionStatus(currentBufferPos)
-				if selectionStatus != core.SelectionNone {
-					baseCharStyle = selectionStyle
-				}
-
-				if isSearchResult {
-					baseCharStyle = searchHighlightStyle
-				}
-
-				currentScreenColForChar := lineNumWidth + currentVisualCol
-				isCursorOnChar := (currentSliceRow == targetVisualRowInSlice && currentScreenColForChar == targetScreenColForCursor)
-
-				if isCursorOnChar && m.isFocused && m.cursorVisible {
-					styledSegment.WriteString(m.getCursorStyles().Render(graphemeStr))
-				} else {
-					styledSegment.WriteString(baseCharStyle.Render(graphemeStr))
-				}
-				currentVisualCol += graphemeWidth
-			}
-			charIdx += charsToAdvance
-		}
-		contentBuilder.WriteString(styledSegment.String())
-
-		isCursorAfterSegmentEnd := (currentSliceRow == targetVisualRowInSlice && (lineNumWidth+currentVisualCol) == targetScreenColForCursor)
-		isCursorAtLogicalEndOfLineAndThisIsLastSegment := false
-		if currentSliceRow == targetVisualRowInSlice && vli.LogicalRow == clampedCursorRowForLineNumbers {
-			logicalLineLen := 0
-			if vli.LogicalRow >= 0 && vli.LogicalRow < len(allLogicalLines) {
-				logicalLineLen = len([]rune(allLogicalLines[vli.LogicalRow]))
-			}
-
-			if m.clampedCursorLogicalCol == logicalLineLen && (vli.LogicalStartCol+len(segmentRunes) == logicalLineLen) {
-				isCursorAtLogicalEndOfLineAndThisIsLastSegment = true
-			}
-		}
-
-		cursorWidth := 0
-		if m.isFocused && (isCursorAfterSegmentEnd || isCursorAtLogicalEndOfLineAndThisIsLastSegment) {
-			cursorBlockPos := core.Position{Row: clampedCursorRowForLineNumbers, Col: m.clampedCursorLogicalCol}
-			cursorBlockSelectionStatus := m.editor.GetSelectionStatus(cursorBlockPos)
-
-			baseStyleForCursorBlock := lipgloss.NewStyle()
-
-			// Apply current line style if this is the cursor line
-			if vli.LogicalRow == clampedCursorRowForLineNumbers {
-				baseStyleForCursorBlock = m.theme.CurrentLineStyle
-			}
-
-			if cursorBlockSelectionStatus != core.SelectionNone {
-				baseStyleForCursorBlock = selectionStyle
-			}
-
-			if m.cursorVisible {
-				contentBuilder.WriteString(baseStyleForCursorBlock.Render(m.getCursorStyles().Render(" ")))
-				cursorWidth = 1
-			}
-
-		}
-
-		// Fill remaining width with current line style if this is the cursor line
-		if vli.LogicalRow == clampedCursorRowForLineNumbers {
-			segmentWidth := getVisualWidth(vli.Content)
-			usedWidth := lineNumWidth + segmentWidth + cursorWidth
-			remainingWidth := m.viewport.Width() - usedWidth
-			if remainingWidth > 0 {
-				contentBuilder.WriteString(m.theme.CurrentLineStyle.Render(strings.Repeat(" ", remainingWidth)))
-			}
-		}
-
-		contentBuilder.WriteString("\n")
-		renderedDisplayLineCount++
-	}
-
-	for renderedDisplayLineCount < m.viewport.Height() {
-		tildeStyle := m.theme.LineNumberStyle
-		if m.showLineNumbers && m.showTildeIndicator {
-			contentBuilder.WriteString(tildeStyle.Width(lineNumWidth-1).Render("~") + " ")
-		}
-
-		contentBuilder.WriteString("\n")
-		renderedDisplayLineCount++
-	}
-
-	finalContentSlice := strings.TrimSuffix(contentBuilder.String(), "\n")
-
-	if m.placeholder != "" && m.IsEmpty() {
-		placeholderRunes := []rune(m.placeholder)
-		styledPlaceholder := strings.Builder{}
-
-		lineNumWidth := m.calculateLineNumberWidth(1)
-		if m.showLineNumbers {
-			lineNumStr := "1"
-			lineNumStyle := m.theme.LineNumberStyle
-			if m.theme.CurrentLineNumberStyle.String() != "" {
-				lineNumStyle = m.theme.CurrentLineNumberStyle
-			}
-			styledPlaceholder.WriteString(lineNumStyle.Width(lineNumWidth-1).Render(lineNumStr) + " ")
-		}
-
-		for i, r := range placeholderRunes {
-			if i == 0 && m.isFocused && m.cursorVisible {
-				styledPlaceholder.WriteString(m.getCursorStyles().Foreground(m.theme.PlaceholderStyle.GetForeground()).Render(string(r)))
-			} else {
-				styledPlaceholder.WriteString(m.theme.PlaceholderStyle.Render(string(r)))
-			}
-		}
-
-		finalContentSlice = styledPlaceholder.String()
-	}
-
-	m.viewport.SetContent(finalContentSlice)
-}
-
-// renderVisibleSlice renders the visible slice of the visual layout.
-func (m *Model) renderVisibleSlice() {
-	if m.highlighter != nil {
-		m.renderVisibleSliceWithSyntax()
-	} else {
-		m.renderVisibleSliceDefault()
-	}
+	m.dirty |= needsRender
 }
 
 // updateVisualTopLine adjusts the current visual top line based on the cursor's position.
@@ -940,34 +908,72 @@ func (m *Model) updateVisualTopLine() {
 	}
 
 	m.viewport.SetYOffset(0)
+	m.dirty |= needsRender
+}
+
+// centreVisualTopLine scrolls so that the cursor's visual row is in the middle
+// of the viewport, as far as the content allows.
+func (m *Model) centreVisualTopLine() {
+	maxPossibleTopLine := max(m.fullVisualLayoutHeight-m.viewport.Height(), 0)
+	m.currentVisualTopLine = min(max(m.cursorAbsoluteVisualRow-m.viewport.Height()/2, 0), maxPossibleTopLine)
+	m.viewport.SetYOffset(0)
+	m.dirty |= needsRender
+}
+
+// isBlankGrapheme reports whether a grapheme is only whitespace, so a line may
+// break at it and drop it. A space with a combining mark is not.
+func isBlankGrapheme(grapheme string) bool {
+	if grapheme == "" {
+		return false
+	}
+	for _, r := range grapheme {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
 }
 
 // wrapLine wraps a line to fit within the specified width.
 // It operates on grapheme clusters (not runes) to correctly handle multi-rune characters
 // like flag emojis (🇷🇴), skin tone modifiers (👍🏽), and ZWJ sequences (👨‍👩‍👧‍👦).
 func wrapLine(line string, width int) []string {
-	if width <= 0 {
-		if line == "" {
-			return []string{""}
-		}
-		return []string{line}
-	}
-	if line == "" {
+	return wrapRunes([]rune(line), width)
+}
+
+// wrapRunes wraps a line given as runes, as wrapLine does, for callers that
+// have them, converting long lines once rather than twice.
+func wrapRunes(runes []rune, width int) []string {
+	return wrapRunesPrefixed(runes, width, 0, defaultTabWidth)
+}
+
+// wrapRunesPrefixed wraps a line as wrapRunes does, with tab stops every
+// tabWidth columns, leaving prefixWidth columns at the start of the display
+// lines continuing it for the prefix drawn there.
+func wrapRunesPrefixed(runes []rune, lineWidth, prefixWidth, tabWidth int) []string {
+	if len(runes) == 0 {
 		return []string{""}
 	}
+	if lineWidth <= 0 {
+		return []string{string(runes)}
+	}
 
-	runes := []rune(line)
 	var wrappedLines []string
 	currentRuneIdx := 0
 
 	for currentRuneIdx < len(runes) {
+		width := lineWidth
+		if len(wrappedLines) > 0 {
+			width = max(1, lineWidth-prefixWidth)
+		}
+
 		// Early exit optimization: Quick check if remaining runes might fit
 		// Most characters are width 1, so if rune count <= width, text likely fits
 		remainingRuneCount := len(runes) - currentRuneIdx
 		if remainingRuneCount <= width {
 			// Only now do the expensive visual width calculation
 			remainingText := string(runes[currentRuneIdx:])
-			remainingWidth := getVisualWidth(remainingText)
+			remainingWidth := getVisualWidthAt(remainingText, 0, tabWidth)
 			if remainingWidth <= width {
 				wrappedLines = append(wrappedLines, remainingText)
 				break
@@ -981,7 +987,7 @@ func wrapLine(line string, width int) []string {
 		// Find the longest segment that fits within width, breaking at grapheme boundaries
 		tempRuneIdx := currentRuneIdx
 		for tempRuneIdx < len(runes) {
-			graphemeStr, graphemeWidth, runesConsumed := nextGrapheme(runes, tempRuneIdx, currentVisualWidth)
+			graphemeStr, graphemeWidth, runesConsumed := nextGrapheme(runes, tempRuneIdx, currentVisualWidth, tabWidth)
 
 			// If adding this grapheme would exceed width, break here
 			if currentVisualWidth+graphemeWidth > width {
@@ -990,9 +996,7 @@ func wrapLine(line string, width int) []string {
 
 			currentVisualWidth += graphemeWidth
 
-			// Check if this grapheme starts with whitespace
-			graphemeRunes := []rune(graphemeStr)
-			if len(graphemeRunes) > 0 && unicode.IsSpace(graphemeRunes[0]) {
+			if isBlankGrapheme(graphemeStr) {
 				lastSpaceGraphemeStartRuneIdx = tempRuneIdx
 			}
 
@@ -1001,9 +1005,12 @@ func wrapLine(line string, width int) []string {
 
 		// Determine where to break the line
 		var breakEndRuneIdx int
-		if tempRuneIdx == lineStartRuneIdx {
+		if tempRuneIdx == len(runes) {
+			// The rest of the line fits, though it has more runes than width
+			breakEndRuneIdx = tempRuneIdx
+		} else if tempRuneIdx == lineStartRuneIdx {
 			// First grapheme is wider than width - must include it anyway to make progress
-			_, _, runesConsumed := nextGrapheme(runes, lineStartRuneIdx, 0)
+			_, _, runesConsumed := nextGrapheme(runes, lineStartRuneIdx, 0, tabWidth)
 			breakEndRuneIdx = lineStartRuneIdx + runesConsumed
 		} else if lastSpaceGraphemeStartRuneIdx >= lineStartRuneIdx {
 			// Break before the space
@@ -1016,7 +1023,7 @@ func wrapLine(line string, width int) []string {
 		// Ensure progress to prevent infinite loops
 		if breakEndRuneIdx <= lineStartRuneIdx {
 			if lineStartRuneIdx < len(runes) {
-				_, _, runesConsumed := nextGrapheme(runes, lineStartRuneIdx, 0)
+				_, _, runesConsumed := nextGrapheme(runes, lineStartRuneIdx, 0, tabWidth)
 				breakEndRuneIdx = lineStartRuneIdx + runesConsumed
 			} else {
 				break
@@ -1030,9 +1037,8 @@ func wrapLine(line string, width int) []string {
 		// Advance, skipping leading spaces on the next line
 		currentRuneIdx = breakEndRuneIdx
 		for currentRuneIdx < len(runes) {
-			graphemeStr, _, runesConsumed := nextGrapheme(runes, currentRuneIdx, 0)
-			graphemeRunes := []rune(graphemeStr)
-			if len(graphemeRunes) == 0 || !unicode.IsSpace(graphemeRunes[0]) {
+			graphemeStr, _, runesConsumed := nextGrapheme(runes, currentRuneIdx, 0, tabWidth)
+			if !isBlankGrapheme(graphemeStr) {
 				break
 			}
 			currentRuneIdx += runesConsumed
@@ -1041,14 +1047,76 @@ func wrapLine(line string, width int) []string {
 
 	if len(wrappedLines) == 0 {
 		// If wrapping failed but we had non-empty input, return the original line
-		if len(runes) > 0 {
-			return []string{line}
-		}
-		return []string{""}
+		return []string{string(runes)}
 	}
 	return wrappedLines
 }
 
+// wrapLayout tells the editor how the model lays lines out, for gj and gk:
+// as wrapRunes wraps them at the width left for text.
+type wrapLayout struct {
+	editor core.Editor
+}
+
+func (l wrapLayout) DisplayLines(row int) []int {
+	runes := l.editor.GetBuffer().GetLineRunes(row)
+	state := l.editor.GetState()
+	width := max(1, state.AvailableWidth)
+	options := layoutOptionsOf(state)
+	prefix := options.prefix(runes, width)
+	return segmentStarts(runes, wrapRunesPrefixed(runes, width, getVisualWidth(prefix), options.tabWidth))
+}
+
+func (l wrapLayout) DisplayWidth(row, from, to int) int {
+	runes := l.editor.GetBuffer().GetLineRunes(row)
+	tabWidth := l.editor.GetState().TabWidth
+	width := 0
+	for col := from; col < min(to, len(runes)); {
+		_, graphemeWidth, runesConsumed := nextGrapheme(runes, col, width, tabWidth)
+		width += graphemeWidth
+		col += runesConsumed
+	}
+	return width
+}
+
+// renderPreedit renders the IME composition text with the cursor at its offset.
+// A trailing cursor block is added when the cursor is after the last rune.
+func (m *Model) renderPreedit() string {
+	var sb strings.Builder
+	showCursor := m.drawsCursor()
+
+	for i, r := range m.preedit {
+		if i == m.preeditCursor && showCursor {
+			sb.WriteString(m.getCursorStyles().Underline(true).Render(string(r)))
+		} else {
+			sb.WriteString(m.theme.PreeditStyle.Render(string(r)))
+		}
+	}
+
+	if m.preeditCursor == len(m.preedit) {
+		if showCursor {
+			sb.WriteString(m.getCursorStyles().Render(" "))
+		} else {
+			sb.WriteString(" ")
+		}
+	}
+
+	return sb.String()
+}
+
+// preeditWidth returns the number of columns taken by renderPreedit.
+func (m *Model) preeditWidth() int {
+	if len(m.preedit) == 0 {
+		return 0
+	}
+
+	width := getVisualWidth(string(m.preedit))
+	if m.preeditCursor == len(m.preedit) {
+		width++
+	}
+	return width
+}
+
 func (m *Model) getCursorStyles() lipgloss.Style {
 	state := m.editor.GetState()
 	switch state.Mode {
@@ -1063,13 +1131,13 @@ func (m *Model) getCursorStyles() lipgloss.Style {
 	}
 }
 
-// renderVisibleSliceWithSyntax is the modified version of renderVisibleSlice with syntax highlighting support
-func (m *Model) renderVisibleSliceWithSyntax() {
+// renderVisibleSlice renders the calculated slice of the visual layout to the viewport.
+func (m *Model) renderVisibleSlice() {
+	m.dirty &^= needsRender
 	state := m.editor.GetState()
 	allLogicalLines := m.editor.GetBuffer().GetLines()
 
 	selectionStyle := m.theme.SelectionStyle
-	searchHighlightStyle := m.theme.SearchHighlightStyle
 
 	// Check if we're highlighting a yank operation
 	// Either from normal mode (YankSelection) or from visual mode (m.yanked flag)
@@ -1079,9 +1147,6 @@ func (m *Model) renderVisibleSliceWithSyntax() {
 
 	lineNumWidth := m.calculateLineNumberWidth(len(allLogicalLines))
 
-	var contentBuilder strings.Builder
-	renderedDisplayLineCount := 0
-
 	startRenderVisualRow := m.currentVisualTopLine
 	if m.fullVisualLayoutHeight == 0 {
 		startRenderVisualRow = 0
@@ -1116,6 +1181,8 @@ func (m *Model) renderVisibleSliceWithSyntax() {
 		targetScreenColForCursor = lineNumWidth
 	}
 
+	m.cursorScreenPos = core.Position{Row: targetVisualRowInSlice, Col: targetScreenColForCursor}
+
 	clampedCursorRowForLineNumbers := m.clampCursorRow(m.editor.GetBuffer().GetCursor().Position.Row, len(allLogicalLines))
 
 	// Initialise persistent token cache if needed
@@ -1146,7 +1213,7 @@ func (m *Model) renderVisibleSliceWithSyntax() {
 				expandedEndLine := min(len(allLogicalLines), endLogicalLine+extraHighlightedContextLines)
 
 				if expandedStartLine < expandedEndLine {
-					m.highlighter.Tokenise(allLogicalLines, expandedStartLine, expandedEndLine)
+					m.tokenise(allLogicalLines, expandedStartLine, expandedEndLine)
 
 					// Populate persistent cache for the expanded range
 					// This ensures large code blocks have tokens available even when scrolled
@@ -1166,8 +1233,19 @@ func (m *Model) renderVisibleSliceWithSyntax() {
 		}
 	}
 
-	// Use persistent cache for rendering (reference, not copy)
-	lineTokenCache := m.persistentTokenCache
+	// Rows use the persistent cache for rendering (reference, not copy)
+	ctx := rowContext{
+		lines:            allLogicalLines,
+		relativeNumbers:  state.RelativeNumbers && !m.disableVimMode,
+		absoluteNumbers:  state.Numbers,
+		selectionStyle:   selectionStyle,
+		lineNumWidth:     lineNumWidth,
+		cursorRow:        targetVisualRowInSlice,
+		cursorCol:        targetScreenColForCursor,
+		cursorLogicalRow: clampedCursorRowForLineNumbers,
+		tokens:           m.persistentTokenCache,
+	}
+	rows := make([]string, 0, m.viewport.Height())
 
 	for absVisRowIdxToRender := startRenderVisualRow; absVisRowIdxToRender < endRenderVisualRow; absVisRowIdxToRender++ {
 		// Convert absolute visual row to cache-relative index
@@ -1175,134 +1253,27 @@ func (m *Model) renderVisibleSliceWithSyntax() {
 		if cacheIdx < 0 || cacheIdx >= len(m.visualLayoutCache) {
 			break
 		}
-		vli := m.visualLayoutCache[cacheIdx]
-		currentSliceRow := renderedDisplayLineCount
-
-		// Render line number
-		if m.showLineNumbers {
-			lineNumStr := ""
-			currentLineNumberStyle := m.theme.LineNumberStyle
-			if vli.IsFirstSegment {
-				if state.RelativeNumbers && !m.disableVimMode && vli.LogicalRow != clampedCursorRowForLineNumbers {
-					relNum := vli.LogicalRow - clampedCursorRowForLineNumbers
-					if relNum < 0 {
-						relNum = -relNum
-					}
-					lineNumStr = strconv.Itoa(relNum)
-				} else {
-					lineNumStr = strconv.Itoa(vli.LogicalRow + 1)
-				}
-				if vli.LogicalRow == clampedCursorRowForLineNumbers {
-					currentLineNumberStyle = m.theme.CurrentLineNumberStyle
-				}
-			}
-			contentBuilder.WriteString(currentLineNumberStyle.Width(lineNumWidth-1).Render(lineNumStr) + " ")
-		}
-
-		// Get token positions for this line
-		var tokenPositions []highlighter.TokenPosition
-		if m.highlighter != nil {
-			if positions, ok := lineTokenCache[vli.LogicalRow]; ok {
-				tokenPositions = positions
-			}
-		}
-
-		// Render the segment
-		if len(tokenPositions) > 0 {
-			m.renderSegmentWithSyntax(
-				vli,
-				tokenPositions,
-				&contentBuilder,
-				currentSliceRow,
-				targetVisualRowInSlice,
-				targetScreenColForCursor,
-				lineNumWidth,
-				selectionStyle,
-				searchHighlightStyle,
-			)
-		} else {
-			// Fall back to original rendering logic (without syntax highlighting)
-			m.renderSegmentPlain(
-				vli,
-				&contentBuilder,
-				currentSliceRow,
-				targetVisualRowInSlice,
-				targetScreenColForCursor,
-				lineNumWidth,
-				selectionStyle,
-				searchHighlightStyle,
-			)
-		}
-
-		// Handle cursor at end of line
-		segmentVisualWidth := getVisualWidth(vli.Content)
-		isCursorAfterSegmentEnd := (currentSliceRow == targetVisualRowInSlice && (lineNumWidth+segmentVisualWidth) == targetScreenColForCursor)
-		isCursorAtLogicalEndOfLineAndThisIsLastSegment := false
-		if currentSliceRow == targetVisualRowInSlice && vli.LogicalRow == clampedCursorRowForLineNumbers {
-			logicalLineLen := 0
-			if vli.LogicalRow >= 0 && vli.LogicalRow < len(allLogicalLines) {
-				logicalLineLen = len([]rune(allLogicalLines[vli.LogicalRow]))
-			}
-
-			if m.clampedCursorLogicalCol == logicalLineLen && (vli.LogicalStartCol+len([]rune(vli.Content)) == logicalLineLen) {
-				isCursorAtLogicalEndOfLineAndThisIsLastSegment = true
-			}
-		}
-
-		cursorWidth := 0
-		if m.isFocused && (isCursorAfterSegmentEnd || isCursorAtLogicalEndOfLineAndThisIsLastSegment) {
-			cursorBlockPos := core.Position{Row: clampedCursorRowForLineNumbers, Col: m.clampedCursorLogicalCol}
-			cursorBlockSelectionStatus := m.editor.GetSelectionStatus(cursorBlockPos)
-
-			baseStyleForCursorBlock := lipgloss.NewStyle()
-
-			// Apply current line style if this is the cursor line
-			if vli.LogicalRow == clampedCursorRowForLineNumbers {
-				baseStyleForCursorBlock = m.theme.CurrentLineStyle
-			}
-
-			if cursorBlockSelectionStatus != core.SelectionNone {
-				baseStyleForCursorBlock = selectionStyle
-			}
-
-			if m.cursorVisible {
-				contentBuilder.WriteString(baseStyleForCursorBlock.Render(m.getCursorStyles().Render(" ")))
-				cursorWidth = 1
-			}
-		}
-
-		// Fill remaining width with current line style if this is the cursor line
-		if vli.LogicalRow == clampedCursorRowForLineNumbers {
-			segmentWidth := getVisualWidth(vli.Content)
-			usedWidth := lineNumWidth + segmentWidth + cursorWidth
-			remainingWidth := m.viewport.Width() - usedWidth
-			if remainingWidth > 0 {
-				contentBuilder.WriteString(m.theme.CurrentLineStyle.Render(strings.Repeat(" ", remainingWidth)))
-			}
-		}
-
-		contentBuilder.WriteString("\n")
-		renderedDisplayLineCount++
+		rows = append(rows, m.renderRow(&ctx, m.visualLayoutCache[cacheIdx], len(rows)))
 	}
 
 	// Render empty lines with tildes
-	for renderedDisplayLineCount < m.viewport.Height() {
-		tildeStyle := m.theme.LineNumberStyle
-		if m.showLineNumbers && m.showTildeIndicator {
-			contentBuilder.WriteString(tildeStyle.Width(lineNumWidth-1).Render("~") + " ")
+	for len(rows) < m.viewport.Height() {
+		tilde := ""
+		if lineNumWidth > 0 && m.showTildeIndicator && !m.accessibility.enabled {
+			tilde = m.theme.LineNumberStyle.Width(lineNumWidth-1).Render("~") + " "
 		}
-		contentBuilder.WriteString("\n")
-		renderedDisplayLineCount++
+		rows = append(rows, tilde)
 	}
+	m.frame = renderedFrame{rows: rows, top: startRenderVisualRow, context: ctx, effects: m.keyEffects()}
 
-	finalContentSlice := strings.TrimSuffix(contentBuilder.String(), "\n")
+	finalContentSlice := strings.Join(rows, "\n")
 
 	// Handle placeholder
 	if m.placeholder != "" && m.IsEmpty() {
 		placeholderRunes := []rune(m.placeholder)
 		styledPlaceholder := strings.Builder{}
 
-		if m.showLineNumbers {
+		if lineNumWidth > 0 {
 			lineNumStr := "1"
 			lineNumStyle := m.theme.LineNumberStyle
 			if m.theme.CurrentLineNumberStyle.String() != "" {
@@ -1312,7 +1283,7 @@ func (m *Model) renderVisibleSliceWithSyntax() {
 		}
 
 		for i, r := range placeholderRunes {
-			if i == 0 && m.isFocused && m.cursorVisible {
+			if i == 0 && m.drawsCursor() {
 				styledPlaceholder.WriteString(m.getCursorStyles().Foreground(m.theme.PlaceholderStyle.GetForeground()).Render(string(r)))
 			} else {
 				styledPlaceholder.WriteString(m.theme.PlaceholderStyle.Render(string(r)))
@@ -1320,168 +1291,271 @@ func (m *Model) renderVisibleSliceWithSyntax() {
 		}
 
 		finalContentSlice = styledPlaceholder.String()
+		m.frame = renderedFrame{}
 	}
 
 	m.viewport.SetContent(finalContentSlice)
 }
 
-// renderSegment renders a segment with an optional base style provider
+// rowContext is what rendering the visible lines of a frame needs, worked
+// out once for all of them.
+type rowContext struct {
+	lines            []string
+	relativeNumbers  bool // Numbers count from the cursor line
+	absoluteNumbers  bool // The cursor line has its number, or all lines without relativeNumbers
+	selectionStyle   lipgloss.Style
+	lineNumWidth     int
+	cursorRow        int // Row of the cursor in the frame, -1 if off screen
+	cursorCol        int // Screen column of the cursor
+	cursorLogicalRow int
+	tokens           map[int][]highlighter.TokenPosition
+}
+
+// renderRow renders vli, the visual line at row sliceRow of the frame.
+func (m *Model) renderRow(ctx *rowContext, vli VisualLineInfo, sliceRow int) string {
+	var b strings.Builder
+
+	// Render line number
+	if ctx.lineNumWidth > 0 {
+		lineNumStr := ""
+		currentLineNumberStyle := m.theme.LineNumberStyle
+		if vli.IsFirstSegment {
+			// With both, as Vim's number and relativenumber, only the cursor
+			// line has its number
+			if ctx.relativeNumbers && (vli.LogicalRow != ctx.cursorLogicalRow || !ctx.absoluteNumbers) {
+				relNum := vli.LogicalRow - ctx.cursorLogicalRow
+				if relNum < 0 {
+					relNum = -relNum
+				}
+				lineNumStr = strconv.Itoa(relNum)
+			} else {
+				lineNumStr = strconv.Itoa(vli.LogicalRow + 1)
+			}
+			if vli.LogicalRow == ctx.cursorLogicalRow {
+				currentLineNumberStyle = m.theme.CurrentLineNumberStyle
+			}
+		}
+		b.WriteString(currentLineNumberStyle.Width(ctx.lineNumWidth-1).Render(lineNumStr) + m.renderGutterSign(vli))
+	}
+	if vli.Prefix != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(m.theme.LineNumberStyle.GetForeground()).Render(vli.Prefix))
+	}
+	textCol := ctx.lineNumWidth + vli.prefixWidth() // Screen column the text starts at
+
+	// Get token positions for this line
+	var tokenPositions []highlighter.TokenPosition
+	if m.highlighter != nil {
+		if positions, ok := ctx.tokens[vli.LogicalRow]; ok {
+			tokenPositions = positions
+		}
+	}
+
+	m.renderSegment(
+		vli,
+		tokenPositions,
+		&b,
+		sliceRow,
+		ctx.cursorRow,
+		ctx.cursorCol,
+		textCol,
+		ctx.selectionStyle,
+	)
+
+	// Handle cursor at end of line
+	segmentVisualWidth := m.layoutOptions.width(vli.Content)
+	isCursorAfterSegmentEnd := (sliceRow == ctx.cursorRow && (textCol+segmentVisualWidth) == ctx.cursorCol)
+	isCursorAtLogicalEndOfLineAndThisIsLastSegment := false
+	if sliceRow == ctx.cursorRow && vli.LogicalRow == ctx.cursorLogicalRow {
+		logicalLineLen := 0
+		if vli.LogicalRow >= 0 && vli.LogicalRow < len(ctx.lines) {
+			logicalLineLen = len([]rune(ctx.lines[vli.LogicalRow]))
+		}
+
+		if m.clampedCursorLogicalCol == logicalLineLen && (vli.LogicalStartCol+len([]rune(vli.Content)) == logicalLineLen) {
+			isCursorAtLogicalEndOfLineAndThisIsLastSegment = true
+		}
+	}
+
+	cursorWidth := 0
+	if isCursorAtLogicalEndOfLineAndThisIsLastSegment {
+		if pad := m.virtualCols(textCol + segmentVisualWidth); pad > 0 {
+			b.WriteString(m.theme.CurrentLineStyle.Render(strings.Repeat(" ", pad)))
+			cursorWidth = pad
+		}
+	}
+	if len(m.preedit) > 0 && (isCursorAfterSegmentEnd || isCursorAtLogicalEndOfLineAndThisIsLastSegment) {
+		b.WriteString(m.renderPreedit())
+	} else if m.isFocused && (isCursorAfterSegmentEnd || isCursorAtLogicalEndOfLineAndThisIsLastSegment) {
+		cursorBlockPos := core.Position{Row: ctx.cursorLogicalRow, Col: m.clampedCursorLogicalCol}
+		cursorBlockSelectionStatus := m.editor.Display().GetSelectionStatus(cursorBlockPos)
+
+		baseStyleForCursorBlock := lipgloss.NewStyle()
+
+		// Apply current line style if this is the cursor line
+		if vli.LogicalRow == ctx.cursorLogicalRow {
+			baseStyleForCursorBlock = m.theme.CurrentLineStyle
+		}
+
+		if cursorBlockSelectionStatus != core.SelectionNone {
+			baseStyleForCursorBlock = ctx.selectionStyle
+		}
+
+		if m.drawsCursor() {
+			b.WriteString(baseStyleForCursorBlock.Render(m.getCursorStyles().Render(" ")))
+			cursorWidth++
+		}
+	}
+
+	// Fill remaining width with current line style if this is the cursor line
+	if vli.LogicalRow == ctx.cursorLogicalRow {
+		segmentWidth := m.layoutOptions.width(vli.Content)
+		usedWidth := textCol + segmentWidth + cursorWidth
+		if sliceRow == ctx.cursorRow {
+			usedWidth += m.preeditWidth()
+		}
+		remainingWidth := m.viewport.Width() - usedWidth
+		if remainingWidth > 0 {
+			b.WriteString(m.theme.CurrentLineStyle.Render(strings.Repeat(" ", remainingWidth)))
+		}
+	}
+
+	return b.String()
+}
+
+// renderSegment renders the text of a visual line. The styles that apply to
+// each character are merged in the order of Theme.StylePrecedence, and
+// characters with the same styles are rendered together as a run.
 func (m *Model) renderSegment(
 	vli VisualLineInfo,
+	tokenPositions []highlighter.TokenPosition,
 	contentBuilder *strings.Builder,
 	currentSliceRow int,
 	targetVisualRowInSlice int,
 	targetScreenColForCursor int,
 	lineNumWidth int,
 	selectionStyle lipgloss.Style,
-	searchHighlightStyle lipgloss.Style,
-	getBaseStyle func(col int) lipgloss.Style,
 ) {
 	segmentRunes := []rune(vli.Content)
-	styledSegment := strings.Builder{}
 	currentVisualCol := 0
 
-	charIdx := 0
-	segmentLen := len(segmentRunes)
-
 	clampedCursorRow := m.clampCursorRow(m.editor.GetBuffer().GetCursor().Position.Row, m.editor.GetBuffer().LineCount())
 	isCurrentLine := vli.LogicalRow == clampedCursorRow
 
-	// Pre-calculate current line background once per segment for performance
-	var currentLineBackground color.Color
-	if isCurrentLine {
-		currentLineBackground = m.theme.CurrentLineStyle.GetBackground()
+	precedence := m.theme.StylePrecedence
+	if precedence == nil {
+		precedence = DefaultStylePrecedence
 	}
 
-	for charIdx < segmentLen {
-		currentLogicalCharCol := vli.LogicalStartCol + charIdx
-		currentBufferPos := core.Position{Row: vli.LogicalRow, Col: currentLogicalCharCol}
-
-		isSearchResult := m.isPositionInSearchResult(currentBufferPos, currentLogicalCharCol)
-
-		// Get base style from provider function
-		baseCharStyle := getBaseStyle(currentLogicalCharCol)
+	// Markup is concealed on the lines the cursor isn't on
+	var conceal []concealCell
+	if !isCurrentLine {
+		conceal = m.concealLine(m.editor.GetBuffer().GetLineRunes(vli.LogicalRow))
+	}
 
-		// Apply current line background if this is the cursor line
-		if isCurrentLine {
-			baseCharStyle = baseCharStyle.Background(currentLineBackground)
-		}
+	// End of the highlighted word being drawn, and its style
+	wordEnd := 0
+	var wordStyle lipgloss.Style
 
-		if isSearchResult {
-			baseCharStyle = searchHighlightStyle
+	// Text of the current run and the styles it is drawn with
+	var run strings.Builder
+	var runKey styleRunKey
+	var runLayers styleLayers
+	flush := func() {
+		if run.Len() > 0 {
+			contentBuilder.WriteString(runLayers.merge(precedence).Render(run.String()))
+			run.Reset()
 		}
+	}
 
-		// Check for highlighted words (this takes precedence over syntax highlighting)
-		charsToAdvance := 1
-		bestMatch := m.findHighlightedWordMatch(segmentRunes, charIdx)
-		bestMatchLen := bestMatch.length
-		bestMatchStyle := bestMatch.style
-
-		if bestMatchLen > 0 {
-			// Render highlighted word
-			for k := range bestMatchLen {
-				idxInSegment := charIdx + k
-				chRuneToStyle := segmentRunes[idxInSegment]
-				logicalColForStyledChar := vli.LogicalStartCol + idxInSegment
-				posForStyledChar := core.Position{Row: vli.LogicalRow, Col: logicalColForStyledChar}
-
-				charSpecificRenderStyle := bestMatchStyle
-
-				// Apply current line background to highlighted words
-				if isCurrentLine {
-					charSpecificRenderStyle = charSpecificRenderStyle.Background(currentLineBackground)
-				}
+	for charIdx := 0; charIdx < len(segmentRunes); {
+		currentLogicalCharCol := vli.LogicalStartCol + charIdx
+		currentBufferPos := core.Position{Row: vli.LogicalRow, Col: currentLogicalCharCol}
 
-				// Apply selection style if needed
-				selectionStatus := m.editor.GetSelectionStatus(posForStyledChar)
-				if selectionStatus != core.SelectionNone {
-					charSpecificRenderStyle = charSpecificRenderStyle.Background(selectionStyle.GetBackground())
-				}
+		// Get the next grapheme cluster using centralised helper
+		graphemeStr, graphemeWidth, runesConsumed := nextGrapheme(segmentRunes, charIdx, currentVisualCol, m.layoutOptions.tabWidth)
+		// A tab is drawn as the spaces to the next tab stop, so the cursor
+		// covers all of them and lipgloss doesn't expand it to a fixed width
+		if graphemeStr == "\t" {
+			graphemeStr = strings.Repeat(" ", graphemeWidth)
+		}
 
-				currentScreenColForChar := lineNumWidth + currentVisualCol // <-- MUST USE currentVisualCol
-				isCursorOnThisChar := (currentSliceRow == targetVisualRowInSlice && currentScreenColForChar == targetScreenColForCursor)
+		var cell concealCell
+		if currentLogicalCharCol < len(conceal) {
+			cell = conceal[currentLogicalCharCol]
+		}
+		if cell.concealed {
+			graphemeStr = cell.replacement
+			graphemeWidth = getVisualWidth(graphemeStr)
+		}
 
-				if isCursorOnThisChar && m.isFocused && m.cursorVisible {
-					styledSegment.WriteString(m.getCursorStyles().Render(string(chRuneToStyle)))
-				} else {
-					styledSegment.WriteString(charSpecificRenderStyle.Render(string(chRuneToStyle)))
-				}
-				currentVisualCol += getRuneVisualWidth(chRuneToStyle) // <-- MUST INCREMENT BY WIDTH
-			}
-			charsToAdvance = bestMatchLen
-		} else {
-			// Get the next grapheme cluster using centralised helper
-			graphemeStr, graphemeWidth, runesConsumed := nextGrapheme(segmentRunes, charIdx, currentVisualCol)
-			charsToAdvance = runesConsumed
-
-			// Apply selection style on top of syntax highlighting
-			selectionStatus := m.editor.GetSelectionStatus(currentBufferPos)
-			if selectionStatus != core.SelectionNone {
-				if isSearchResult {
-					baseCharStyle = baseCharStyle.Background(searchHighlightStyle.GetBackground())
-				} else {
-					baseCharStyle = baseCharStyle.Background(selectionStyle.GetBackground())
-				}
+		if charIdx >= wordEnd {
+			if match := m.findHighlightedWordMatch(segmentRunes, charIdx); match.length > 0 {
+				wordEnd, wordStyle = charIdx+match.length, match.style
 			}
+		}
 
-			currentScreenColForChar := lineNumWidth + currentVisualCol
-			isCursorOnChar := (currentSliceRow == targetVisualRowInSlice && currentScreenColForChar == targetScreenColForCursor)
-
-			if isCursorOnChar && m.isFocused && m.cursorVisible {
-				styledSegment.WriteString(m.getCursorStyles().Render(graphemeStr))
-			} else {
-				styledSegment.WriteString(baseCharStyle.Render(graphemeStr))
-			}
-			currentVisualCol += graphemeWidth
+		var layers styleLayers
+		var key styleRunKey
+		if token, ok := highlighter.FindTokenAtPosition(tokenPositions, currentLogicalCharCol); ok && m.highlighter != nil {
+			layers.add(StyleSyntax, m.highlighter.GetStyleForToken(token.Type))
+			key.tokenType = token.Type
+		}
+		if isCurrentLine {
+			layers.add(StyleCurrentLine, m.theme.CurrentLineStyle)
+		}
+		if cell.rule != 0 {
+			layers.add(StyleConceal, m.concealRules[cell.rule-1].Style)
+			key.concealRule = cell.rule
 		}
+		if m.dimProtected && m.editor.Limits().IsProtected(currentBufferPos) {
+			layers.add(StyleProtected, m.theme.ProtectedStyle)
+		}
+		if charIdx < wordEnd {
+			layers.add(StyleHighlightedWord, wordStyle)
+			key.wordEnd = wordEnd
+		}
+		if m.editor.Display().GetSelectionStatus(currentBufferPos) != core.SelectionNone {
+			layers.add(StyleSelection, selectionStyle)
+		}
+		if m.isPositionInSearchResult(currentBufferPos, currentLogicalCharCol) {
+			layers.add(StyleSearch, m.theme.SearchHighlightStyle)
+		}
+		key.set = layers.set
 
-		charIdx += charsToAdvance
-	}
+		currentScreenColForChar := lineNumWidth + currentVisualCol
+		isCursorOnChar := (currentSliceRow == targetVisualRowInSlice && currentScreenColForChar == targetScreenColForCursor)
 
-	contentBuilder.WriteString(styledSegment.String())
-}
+		if isCursorOnChar && len(m.preedit) > 0 {
+			flush()
+			contentBuilder.WriteString(m.renderPreedit())
+		} else if isCursorOnChar && m.drawsCursor() {
+			flush()
+			contentBuilder.WriteString(m.getCursorStyles().Render(graphemeStr))
+			graphemeStr = ""
+		}
 
-// renderSegmentWithSyntax renders a segment with syntax highlighting
-func (m *Model) renderSegmentWithSyntax(
-	vli VisualLineInfo,
-	tokenPositions []highlighter.TokenPosition,
-	contentBuilder *strings.Builder,
-	currentSliceRow int,
-	targetVisualRowInSlice int,
-	targetScreenColForCursor int,
-	lineNumWidth int,
-	selectionStyle lipgloss.Style,
-	searchHighlightStyle lipgloss.Style,
-) {
-	getBaseStyle := func(col int) lipgloss.Style {
-		token, hasToken := highlighter.FindTokenAtPosition(tokenPositions, col)
-		if hasToken && m.highlighter != nil {
-			return m.highlighter.GetStyleForToken(token.Type)
+		if graphemeStr != "" {
+			if key != runKey {
+				flush()
+				runKey, runLayers = key, layers
+			}
+			run.WriteString(graphemeStr)
 		}
-		return lipgloss.NewStyle()
+
+		currentVisualCol += graphemeWidth
+		charIdx += runesConsumed
 	}
 
-	m.renderSegment(vli, contentBuilder, currentSliceRow, targetVisualRowInSlice,
-		targetScreenColForCursor, lineNumWidth, selectionStyle, searchHighlightStyle, getBaseStyle)
+	flush()
 }
 
-// renderSegmentPlain renders a segment without syntax highlighting (fallback)
-func (m *Model) renderSegmentPlain(
-	vli VisualLineInfo,
-	contentBuilder *strings.Builder,
-	currentSliceRow int,
-	targetVisualRowInSlice int,
-	targetScreenColForCursor int,
-	lineNumWidth int,
-	selectionStyle lipgloss.Style,
-	searchHighlightStyle lipgloss.Style,
-) {
-	getBaseStyle := func(col int) lipgloss.Style {
-		return lipgloss.NewStyle()
-	}
-
-	m.renderSegment(vli, contentBuilder, currentSliceRow, targetVisualRowInSlice,
-		targetScreenColForCursor, lineNumWidth, selectionStyle, searchHighlightStyle, getBaseStyle)
+// styleRunKey identifies the styles of a character: characters with the same
+// key are drawn with the same style.
+type styleRunKey struct {
+	set         [styleLayerCount]bool
+	tokenType   chroma.TokenType
+	wordEnd     int // Each highlighted word has its own style
+	concealRule int // As has each conceal rule
 }
 
 // handleContentChange is called when the content of the editor changes.
@@ -1490,6 +1564,12 @@ func (m *Model) handleContentChange() {
 		currentLine := m.editor.GetBuffer().GetCursor().Position.Row
 		m.highlighter.InvalidateLine(currentLine)
 	}
+	// Background work for the old content is stale
+	m.pendingTokens = nil
+	m.tasks.cancelStale(m.editor.GetBuffer().Revision())
+	if !m.tasks.isRunning(taskTokenise) {
+		m.runningTokens = nil
+	}
 	// Clear persistent token cache on content changes
 	m.persistentTokenCache = make(map[int][]highlighter.TokenPosition)
 
@@ -1498,10 +1578,53 @@ func (m *Model) handleContentChange() {
 	m.cacheValidStartRow = 0
 	m.cacheValidEndRow = 0
 
+	m.contentRevision = m.editor.GetBuffer().Revision()
+
 	m.calculateVisualMetrics()
 	m.updateVisualTopLine()
 }
 
+// handleLinesChange updates the caches after the removed lines from line
+// from were replaced by inserted lines, laying out and highlighting again
+// only those, for edits that know which lines they change.
+func (m *Model) handleLinesChange(from, removed, inserted int) {
+	if m.highlighter != nil {
+		m.highlighter.SpliceLines(from, removed, inserted)
+	}
+	m.pendingTokens = nil
+	m.tasks.cancelStale(m.editor.GetBuffer().Revision())
+	if !m.tasks.isRunning(taskTokenise) {
+		m.runningTokens = nil
+	}
+	m.persistentTokenCache = spliceLines(m.persistentTokenCache, from, removed, inserted)
+	m.contentRevision = m.editor.GetBuffer().Revision()
+
+	if m.relayoutLines(from, removed, inserted) {
+		m.locateCursor()
+	} else {
+		m.cacheValidStartRow = 0
+		m.cacheValidEndRow = 0
+		m.calculateVisualMetrics()
+	}
+	m.updateVisualTopLine()
+}
+
+// spliceLines returns cache, keyed by line, with the entries of the removed
+// lines from line from dropped and those of the lines below moved by the
+// lines inserted in their place.
+func spliceLines[T any](cache map[int]T, from, removed, inserted int) map[int]T {
+	spliced := make(map[int]T, len(cache))
+	for line, value := range cache {
+		switch {
+		case line < from:
+			spliced[line] = value
+		case line >= from+removed:
+			spliced[line+inserted-removed] = value
+		}
+	}
+	return spliced
+}
+
 type completionStyles struct {
 	leftPadding            int
 	rightPadding           int
@@ -1537,10 +1660,10 @@ func setupCompletionStyles(theme Theme) completionStyles {
 	}
 }
 
-// renderWithCompletionMenu overlays the completion menu on the content
-func (m Model) renderWithCompletionMenu(content string) string {
+// completionMenuLayer returns the completion menu as a layer placed at the cursor.
+func (m *Model) completionMenuLayer() *lipgloss.Layer {
 	if len(m.completions) == 0 {
-		return content
+		return nil
 	}
 
 	maxItems := min(10, len(m.completions))
@@ -1626,8 +1749,5 @@ func (m Model) renderWithCompletionMenu(content string) string {
 		menuCol = lineNumWidth
 	}
 
-	contentLayer := lipgloss.NewLayer(content).X(0).Y(0).Z(0)
-	menuLayer := lipgloss.NewLayer(menuBox).X(menuCol).Y(menuRow).Z(1)
-
-	return lipgloss.NewCompositor(contentLayer, menuLayer).Render()
+	return lipgloss.NewLayer(menuBox).X(menuCol).Y(menuRow).Z(1)
 }
@@ -0,0 +1,533 @@
+package goeditor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/ionut-t/goeditor/core"
+	"github.com/ionut-t/goeditor/highlighter"
+	"github.com/rivo/uniseg"
+	"github.com/stretchr/testify/assert"
+)
+
+// benchmarkSource is a screen of Go code for the render benchmarks.
+var benchmarkSource = strings.Repeat(`// Handler serves the requests of a route.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.check(r.Context(), r.URL.Path); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest) // TODO: log it
+		return
+	}
+	fmt.Fprintf(w, "hello %s, you asked for %q\n", r.RemoteAddr, r.URL)
+}
+`, 8)
+
+// sgr matches the escape sequences that style text.
+var sgr = regexp.MustCompile("\x1b\\[[0-9;:]*m")
+
+// newRenderModel returns a focused editor of 120x50 showing benchmarkSource.
+func newRenderModel(language string) Model {
+	m := New(120, 50)
+	m.Focus()
+	m.SetContent(benchmarkSource)
+	m.SetColorProfile(colorprofile.TrueColor)
+	m.SetAsyncHighlighting(false)
+	m.SetLanguage(language, "monokai")
+	m.SetHighlightedWords(map[string]lipgloss.Style{"TODO": lipgloss.NewStyle().Bold(true)})
+	m.renderVisibleSlice()
+	return m
+}
+
+// TestRenderSegmentRuns tests that rendering styled runs draws the same text
+// as the buffer holds.
+func TestRenderSegmentRuns(t *testing.T) {
+	for _, language := range []string{"", "go"} {
+		t.Run("language "+language, func(t *testing.T) {
+			m := newRenderModel(language)
+			lines := strings.Split(sgr.ReplaceAllString(m.viewport.View(), ""), "\n")
+			source := strings.Split(strings.ReplaceAll(benchmarkSource, "\t", "    "), "\n")
+			for i := 1; i < 10; i++ {
+				assert.Equal(t, strings.TrimRight(source[i], " "), strings.TrimRight(lines[i][m.calculateLineNumberWidth(len(source)):], " "))
+			}
+		})
+	}
+}
+
+// renderedLines returns the lines of the text area without styles, with the
+// characters under the cursor in brackets and trailing spaces trimmed.
+func renderedLines(m Model) []string {
+	cursorStyle := m.getCursorStyles().Render("\x00")
+	open, end, _ := strings.Cut(cursorStyle, "\x00")
+
+	view := m.viewport.View()
+	for {
+		start, after, found := strings.Cut(view, open)
+		if !found {
+			break
+		}
+		cursor, rest, _ := strings.Cut(after, end)
+		view = start + "[" + cursor + "]" + rest
+	}
+
+	lines := strings.Split(sgr.ReplaceAllString(view, ""), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return lines
+}
+
+// TestRenderCursorGolden tests drawing the cursor over characters that don't
+// take one column.
+func TestRenderCursorGolden(t *testing.T) {
+	for _, c := range []struct {
+		name, text, keys string
+		want             []string
+	}{
+		{"on a tab", "a\tb", "l", []string{"   1 a[   ]b"}},
+		{"after a tab", "a\tb", "ll", []string{"   1 a   [b]"}},
+		{"on a tab at a tab stop", "\tb", "", []string{"   1 [    ]b"}},
+		{"after tabs", "ab\tc\td", "$", []string{"   1 ab  c   [d]"}},
+		{"on a wide character", "世界x", "l", []string{"   1 世[界]x"}},
+		{"after wide characters", "世界x", "$", []string{"   1 世界[x]"}},
+		{"on a combining mark", "e\u0301x", "", []string{"   1 [e\u0301]x"}},
+		{"after a combining mark", "e\u0301x", "l", []string{"   1 e\u0301[x]"}},
+		{"after an emoji with a modifier", "👍🏽ok", "l", []string{"   1 👍🏽[o]k"}},
+		{"at the end of the line", "世\t", "A", []string{"   1 世  [ ]"}},
+		{"wrapped wide characters", "一二三四五六七八九十", "$", []string{"   1 一二三四五", "     六七八九[十]"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			m := New(16, 5)
+			m.Focus()
+			m.SetColorProfile(colorprofile.TrueColor)
+			m.SetContent(c.text)
+			m = typeText(m, c.keys)
+			m.renderVisibleSlice()
+
+			lines := renderedLines(m)
+			assert.Equal(t, c.want, lines[:len(c.want)])
+			unbracket := strings.NewReplacer("[", "", "]", "")
+			for _, line := range lines {
+				assert.LessOrEqual(t, getVisualWidth(unbracket.Replace(line)), 16, line)
+			}
+		})
+	}
+}
+
+// TestRenderVirtualCursor tests drawing a cursor past the end of the line.
+func TestRenderVirtualCursor(t *testing.T) {
+	m := New(16, 5)
+	m.Focus()
+	m.SetColorProfile(colorprofile.TrueColor)
+	m.SetContent("ab\nabcdefghij")
+	m.SetVirtualEdit(core.VirtualEditAll)
+
+	t.Run("after the gap", func(t *testing.T) {
+		m = typeText(m, "4l")
+		m.renderVisibleSlice()
+		assert.Equal(t, "   1 ab  [ ]", renderedLines(m)[0])
+		assert.Equal(t, core.Position{Row: 0, Col: 9}, m.cursorScreenPos)
+	})
+
+	t.Run("at most at the right edge", func(t *testing.T) {
+		m = typeText(m, "20l")
+		m.renderVisibleSlice()
+		assert.Equal(t, "   1 ab        [ ]", renderedLines(m)[0])
+		assert.Equal(t, 15, m.cursorScreenPos.Col)
+	})
+}
+
+// TestRenderProtectedRanges tests drawing read-only text faint when asked.
+func TestRenderProtectedRanges(t *testing.T) {
+	m := New(16, 5)
+	m.SetColorProfile(colorprofile.TrueColor)
+	m.SetContent("header\nbody")
+	m.ProtectRange(core.Position{Row: 0, Col: 0}, core.Position{Row: 1, Col: 0})
+	faint := func(text string) *regexp.Regexp {
+		return regexp.MustCompile("\x1b\\[2(;[0-9;]*)?m" + text)
+	}
+
+	assert.NotRegexp(t, faint("header"), m.viewport.View())
+	m.SetDimProtectedRanges(true)
+	assert.Regexp(t, faint("header"), m.viewport.View())
+	assert.NotRegexp(t, faint("body"), m.viewport.View())
+}
+
+// BenchmarkRenderVisibleSlice measures rendering a full screen of text.
+func BenchmarkRenderVisibleSlice(b *testing.B) {
+	for _, language := range []string{"", "go"} {
+		b.Run("language "+language, func(b *testing.B) {
+			m := newRenderModel(language)
+			b.ReportAllocs()
+			for b.Loop() {
+				m.renderVisibleSlice()
+			}
+		})
+	}
+}
+
+// checkWrap fails t unless the segments wrapLine made of line are line, but
+// for the whitespace dropped where it broke, and each fits in width or is a
+// single grapheme too wide to.
+func checkWrap(t *testing.T, line string, width int, segments []string) {
+	t.Helper()
+	if len(segments) == 0 {
+		t.Fatalf("wrapLine(%q, %d) returned no segments", line, width)
+	}
+
+	rest := line
+	for i, segment := range segments {
+		if !strings.HasPrefix(rest, segment) {
+			t.Fatalf("segment %d %q of wrapLine(%q, %d) isn't next in the line, %q", i, segment, line, width, rest)
+		}
+		if width > 0 && getVisualWidth(segment) > width && uniseg.GraphemeClusterCount(segment) > 1 {
+			t.Fatalf("segment %d %q of wrapLine(%q, %d) is wider than the width", i, segment, line, width)
+		}
+		if segment == "" && line != "" {
+			t.Fatalf("segment %d of wrapLine(%q, %d) is empty", i, line, width)
+		}
+
+		rest = rest[len(segment):]
+		if i < len(segments)-1 {
+			// Only whitespace goes between the segments
+			gaps := uniseg.NewGraphemes(rest)
+			skipped := 0
+			for gaps.Next() && isBlankGrapheme(gaps.Str()) {
+				skipped += len(gaps.Str())
+			}
+			rest = rest[skipped:]
+		}
+	}
+	if rest != "" && strings.TrimSpace(rest) != "" {
+		t.Fatalf("wrapLine(%q, %d) left out %q", line, width, rest)
+	}
+}
+
+// TestWrapLine tests wrapping lines of words, wide characters, tabs and
+// clusters of runes.
+func TestWrapLine(t *testing.T) {
+	for _, test := range []struct {
+		line  string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"hello world", 20, []string{"hello world"}},
+		{"hello world", 8, []string{"hello", "world"}},
+		{"hello   world", 6, []string{"hello", "world"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"日本語のテキスト", 6, []string{"日本語", "のテキ", "スト"}},
+		{"👨‍👩‍👧‍👦👨‍👩‍👧‍👦👨‍👩‍👧‍👦", 4, []string{"👨‍👩‍👧‍👦👨‍👩‍👧‍👦", "👨‍👩‍👧‍👦"}},
+		{"a\tb\tc", 6, []string{"a", "b\tc"}},
+		{"日本", 1, []string{"日", "本"}},
+		{"00 \u0301", 3, []string{"00 \u0301"}},
+	} {
+		got := wrapLine(test.line, test.width)
+		assert.Equal(t, test.want, got, "wrapLine(%q, %d)", test.line, test.width)
+		checkWrap(t, test.line, test.width, got)
+	}
+}
+
+// FuzzWrapLine tests that wrapping keeps the text of any line, in segments
+// that fit the width.
+func FuzzWrapLine(f *testing.F) {
+	f.Add("the quick brown fox jumps over the lazy dog", 10)
+	f.Add("  leading and trailing spaces  ", 7)
+	f.Add("tab\tseparated\tcolumns\tof\ttext", 6)
+	f.Add("日本語のテキストと English words", 5)
+	f.Add("flags 🇷🇴🇬🇧 and thumbs 👍🏽👍🏽 and é", 3)
+	f.Add("averyveryverylongwordwithoutanybreaks", 8)
+
+	f.Fuzz(func(t *testing.T, line string, width int) {
+		if !utf8.ValidString(line) || strings.ContainsAny(line, "\n\r") {
+			t.Skip()
+		}
+		width = width % 100
+		checkWrap(t, line, width, wrapLine(line, width))
+	})
+}
+
+// minified returns a line of minified JSON at least size bytes long.
+func minified(size int) string {
+	record := `{"id":1,"name":"value","tags":["a","b"],"ok":true},`
+	return "[" + strings.Repeat(record, size/len(record)+1) + "{}]"
+}
+
+// TestLongLines tests laying out, highlighting and editing a line of
+// hundreds of kilobytes, as minified JSON has.
+func TestLongLines(t *testing.T) {
+	t.Run("graphemes longer than the window are whole", func(t *testing.T) {
+		runes := []rune("e" + strings.Repeat("\u0301", 100) + "x")
+		grapheme, width, consumed := nextGrapheme(runes, 0, 0, defaultTabWidth)
+		assert.Equal(t, 101, consumed)
+		assert.Equal(t, string(runes[:101]), grapheme)
+		assert.Equal(t, 1, width)
+	})
+
+	t.Run("long lines wrap", func(t *testing.T) {
+		line := minified(200_000)
+		checkWrap(t, line, 80, wrapLine(line, 80))
+	})
+
+	t.Run("long lines are drawn plain", func(t *testing.T) {
+		line := minified(200_000)
+		m := New(80, 20)
+		m.SetAsyncHighlighting(false)
+		m.SetLanguage("json", "monokai")
+		m.SetContent(`{"a": 1}` + "\n" + line + "\n" + `{"b": 2}`)
+		m.renderVisibleSlice()
+		assert.NotEmpty(t, m.persistentTokenCache[0])
+		assert.Empty(t, m.persistentTokenCache[1])
+		assert.Contains(t, sgr.ReplaceAllString(m.viewport.View(), ""), line[:60])
+
+		m.SetMaxHighlightLineLength(0)
+		m.renderVisibleSlice()
+		assert.NotEmpty(t, m.persistentTokenCache[1])
+	})
+
+	t.Run("long lines are edited", func(t *testing.T) {
+		line := minified(200_000)
+		m := newTestModel(line, withSize(80, 20), withListening())
+		m = typeText(m, "$ix")
+		assert.Equal(t, line[:len(line)-1]+"x]", m.GetCurrentContent())
+		assertLayout(t, m)
+	})
+}
+
+// TestLineNumbers tests absolute, relative and hybrid line numbers.
+func TestLineNumbers(t *testing.T) {
+	gutters := func(m Model) []string {
+		var numbers []string
+		for _, line := range strings.Split(sgr.ReplaceAllString(m.viewport.View(), ""), "\n")[:4] {
+			numbers = append(numbers, strings.TrimSpace(line[:m.calculateLineNumberWidth(4)]))
+		}
+		return numbers
+	}
+	set := func(m Model, options string) Model {
+		m = typeText(m, ":set "+options)
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+		return m
+	}
+
+	m := typeText(newTestModel("a\nb\nc\nd", withSize(30, 10), withListening()), "j")
+	assert.Equal(t, []string{"1", "2", "3", "4"}, gutters(m))
+	width := m.editor.GetState().AvailableWidth
+
+	m = set(m, "rnu")
+	assert.Equal(t, []string{"1", "2", "1", "2"}, gutters(m))
+	assert.Equal(t, width, m.editor.GetState().AvailableWidth)
+
+	m = set(m, "nonu")
+	assert.Equal(t, []string{"1", "0", "1", "2"}, gutters(m))
+
+	m = set(m, "nornu")
+	assert.Equal(t, 0, m.calculateLineNumberWidth(4))
+	assert.True(t, strings.HasPrefix(sgr.ReplaceAllString(m.viewport.View(), ""), "a"))
+	assertLayout(t, m)
+
+	m = typeText(newTestModel("a\nb\nc\nd", withSize(30, 10), withListening()), "j")
+	m.ShowRelativeLineNumbers(true)
+	m.ShowAbsoluteLineNumbers(false)
+	m.renderVisibleSlice()
+	assert.Equal(t, []string{"1", "0", "1", "2"}, gutters(m))
+}
+
+// TestWrappedLineMarks tests showbreak, breakindent and numberwidth.
+func TestWrappedLineMarks(t *testing.T) {
+	set := func(m Model, options string) Model {
+		m = typeText(m, ":set "+options)
+		m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+		m.renderVisibleSlice()
+		return m
+	}
+
+	t.Run("showbreak starts the lines wrapping continues", func(t *testing.T) {
+		m := newTestModel("aaaa bbbb cccc dddd", withSize(16, 5), withTrueColor(), withListening())
+		m.SetShowBreak("> ")
+		m = typeText(m, "$")
+		m.renderVisibleSlice()
+		assert.Equal(t, []string{"   1 aaaa bbbb", "     > cccc ddd[d]"}, renderedLines(m)[:2])
+		assert.Equal(t, core.Position{Row: 1, Col: 15}, m.cursorScreenPos)
+
+		pos, err := m.PositionForVisualRowCol(1, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, core.Position{Row: 0, Col: 10}, pos)
+	})
+
+	t.Run(":set sbr takes escaped spaces", func(t *testing.T) {
+		m := set(newTestModel("aaaa bbbb cccc dddd", withSize(16, 5), withTrueColor(), withListening()), `sbr=+\ `)
+		assert.Equal(t, "+ ", m.editor.GetState().ShowBreak)
+		assert.Equal(t, "     + cccc dddd", renderedLines(m)[1])
+	})
+
+	t.Run("breakindent lines them up under the line's indent", func(t *testing.T) {
+		line := "  " + strings.TrimSpace(strings.Repeat("word ", 10))
+		m := set(newTestModel(line, withSize(40, 5), withTrueColor(), withListening()), `bri sbr=>\ `)
+		assert.Equal(t, []string{"   1 [ ]" + line[1:31], "       > word word word word"}, renderedLines(m)[:2])
+
+		m = typeText(m, "gj")
+		assert.Equal(t, 1, m.cursorAbsoluteVisualRow)
+
+		m = set(m, "nobri")
+		assert.Equal(t, "     > [w]ord word word word", renderedLines(m)[1])
+	})
+
+	t.Run("numberwidth sets the least width of the line numbers", func(t *testing.T) {
+		m := newTestModel("a\nb", withSize(30, 5), withTrueColor(), withListening())
+		m.SetNumberWidth(8)
+		m.renderVisibleSlice()
+		assert.Equal(t, 8, m.calculateLineNumberWidth(2))
+		assert.Equal(t, "      1 [a]", renderedLines(m)[0])
+
+		m = set(m, "nuw=3")
+		assert.Equal(t, 3, m.calculateLineNumberWidth(2))
+	})
+}
+
+// TestLanguageTabWidth tests that tabs take the tab width of the language's
+// settings.
+func TestLanguageTabWidth(t *testing.T) {
+	m := New(30, 5)
+	m.Focus()
+	m.SetContent("\tx")
+	m.ConfigureLanguage("go", core.LanguageConfig{TabWidth: 8})
+	m.ConfigureLanguage("yaml", core.LanguageConfig{TabWidth: 2})
+	m.SetLanguage("go", "")
+	m, _ = m.update(commandMsg{})
+	m = typeText(m, "$")
+	assert.Equal(t, core.Position{Row: 0, Col: 13}, m.cursorScreenPos)
+
+	m.SetLanguage("yaml", "")
+	m = typeText(m, "0$")
+	assert.Equal(t, core.Position{Row: 0, Col: 7}, m.cursorScreenPos)
+	assert.Equal(t, "   1   [x]", renderedLines(m)[0])
+}
+
+// TestDisplayLineMovement tests that gj and gk move by the lines the model
+// wraps text onto.
+func TestDisplayLineMovement(t *testing.T) {
+
+	t.Run("gj moves to the next display line", func(t *testing.T) {
+		m := newTestModel("the quick brown fox jumps over the lazy dog\nend", withSize(20, 10), withoutLineNumbers(), withListening())
+		m = typeText(m, "wgj")
+		second := m.visualLayoutCache[1]
+		assert.Equal(t, 1, m.cursorAbsoluteVisualRow)
+		assert.Equal(t, core.Position{Row: 0, Col: second.LogicalStartCol + 4}, m.editor.GetBuffer().GetCursor().Position)
+
+		m = typeText(m, "gjgj")
+		assert.Equal(t, core.Position{Row: 1, Col: 3}, m.editor.GetBuffer().GetCursor().Position)
+	})
+
+	t.Run("wide characters take their cells", func(t *testing.T) {
+		m := newTestModel("漢字漢字漢字\nabcdefgh", withSize(20, 10), withoutLineNumbers(), withListening())
+		m = typeText(m, "j4lgk")
+		assert.Equal(t, core.Position{Row: 0, Col: 2}, m.editor.GetBuffer().GetCursor().Position)
+	})
+}
+
+// TestPreferredColumn tests that moving up and down keeps the cursor in its
+// screen column over tabs and wide characters.
+func TestPreferredColumn(t *testing.T) {
+	cursorAfter := func(content, keys string) core.Position {
+		m := typeText(newTestModel(content, withListening()), keys)
+		return m.editor.GetBuffer().GetCursor().Position
+	}
+
+	assert.Equal(t, core.Position{Row: 1, Col: 4}, cursorAfter("\tabc\nabcdefgh", "lj"))
+	assert.Equal(t, core.Position{Row: 0, Col: 1}, cursorAfter("\tabc\nabcdefgh", "j4lk"))
+	assert.Equal(t, core.Position{Row: 1, Col: 4}, cursorAfter("漢字漢字\nabcdefgh", "2lj"))
+	assert.Equal(t, core.Position{Row: 0, Col: 3}, cursorAfter("漢字漢字\nabcdefgh", "j6lk"))
+
+	// Past a line too short for it
+	assert.Equal(t, core.Position{Row: 2, Col: 10}, cursorAfter("漢字漢字漢字\nab\nabcdefghijkl", "5ljj"))
+	assert.Equal(t, core.Position{Row: 2, Col: 5}, cursorAfter("abcdefghijkl\nab\n漢字漢字漢字", "10ljj"))
+}
+
+// BenchmarkWrapLongLine measures wrapping a line of minified JSON.
+func BenchmarkWrapLongLine(b *testing.B) {
+	line := minified(500_000)
+	b.ReportAllocs()
+	for b.Loop() {
+		wrapLine(line, 80)
+	}
+}
+
+// BenchmarkTypeOnLongLine measures typing a character on a line of minified
+// JSON, laying it out and drawing it again.
+func BenchmarkTypeOnLongLine(b *testing.B) {
+	for _, language := range []string{"", "json"} {
+		b.Run("language "+language, func(b *testing.B) {
+			m := newTestModel(minified(500_000), withSize(120, 50), withoutAsyncHighlighting(), withLanguage(language, "monokai"), withListening())
+			m = typeText(m, "$i")
+			b.ReportAllocs()
+			for b.Loop() {
+				m, _ = m.update(tea.KeyPressMsg{Code: 'x', Text: "x"})
+			}
+		})
+	}
+}
+
+// assertLayout checks that the layout m keeps is the layout of its content
+// from scratch, for the lines it covers.
+func assertLayout(t *testing.T, m Model) {
+	t.Helper()
+	fresh := New(m.width, m.height)
+	fresh.SetContent(m.GetCurrentContent())
+	fresh.calculateFullVisualLayout(fresh.editor.GetBuffer().GetLines(), m.editor.GetState().AvailableWidth)
+
+	var want []VisualLineInfo
+	for _, vli := range fresh.visualLayoutCache {
+		if vli.LogicalRow >= m.visualLayoutCacheStartRow && len(want) < len(m.visualLayoutCache) {
+			want = append(want, vli)
+		}
+	}
+	assert.Equal(t, want, m.visualLayoutCache)
+}
+
+// TestIncrementalEdits tests that edits through the API lay out and
+// highlight only the lines they change, and are undone one at a time.
+func TestIncrementalEdits(t *testing.T) {
+	long := strings.Repeat("word ", 30)
+	for _, lines := range []int{20, 300} {
+		t.Run(fmt.Sprintf("%d lines", lines), func(t *testing.T) {
+			content := make([]string, lines)
+			for i := range content {
+				content[i] = fmt.Sprintf("var line%d = %d", i, i)
+			}
+			m := New(60, 12)
+			m.SetContent(strings.Join(content, "\n"))
+			m.SetAsyncHighlighting(false)
+			m.SetLanguage("go", "monokai")
+			m.renderVisibleSlice()
+			tokens := m.persistentTokenCache[9]
+			assert.NotEmpty(t, tokens)
+
+			assert.NoError(t, m.InsertAt(8, 3, "\n"+long+"\n"))
+			assertLayout(t, m)
+			assert.Equal(t, tokens, m.persistentTokenCache[11])
+			assert.Equal(t, highlighter.GetTokenPositions(m.highlighter.GetTokensForLine(11, nil)), tokens)
+
+			assert.NoError(t, m.DeleteRange(core.Position{Row: 8, Col: 0}, core.Position{Row: 10, Col: 2}))
+			assertLayout(t, m)
+			assert.Equal(t, tokens, m.persistentTokenCache[9])
+
+			assert.NoError(t, m.ReplaceRange(core.Position{Row: 3, Col: 4}, core.Position{Row: 3, Col: 9}, long))
+			assert.NoError(t, m.InsertLines(5, []string{"one", "two"}))
+			assert.NoError(t, m.DeleteLines(0, 1))
+			assertLayout(t, m)
+			assert.Equal(t, tokens, m.persistentTokenCache[9])
+			assert.Equal(t, lines, m.editor.GetBuffer().LineCount())
+
+			assert.Error(t, m.InsertAt(lines+5, 0, "x"))
+			for range 5 {
+				_, err := m.editor.Undo()
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, strings.Join(content, "\n"), m.GetCurrentContent())
+		})
+	}
+}